@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"nofx/database/models"
+	"sort"
+	"time"
+)
+
+// FlowLedger 资金划转累计台账（用于把净值换算为扣除入金/出金后的交易净值）
+type FlowLedger struct {
+	times      []time.Time
+	cumulative []float64
+}
+
+// NewFlowLedger 从划转记录构建累计台账（记录顺序不限）
+func NewFlowLedger(flows []*models.BalanceFlow) *FlowLedger {
+	sorted := append([]*models.BalanceFlow(nil), flows...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	ledger := &FlowLedger{
+		times:      make([]time.Time, len(sorted)),
+		cumulative: make([]float64, len(sorted)),
+	}
+	total := 0.0
+	for i, f := range sorted {
+		total += f.Amount
+		ledger.times[i] = f.Timestamp
+		ledger.cumulative[i] = total
+	}
+	return ledger
+}
+
+// NetAt 截至指定时间（含）的累计净划转金额
+func (l *FlowLedger) NetAt(t time.Time) float64 {
+	if l == nil {
+		return 0
+	}
+	n := sort.Search(len(l.times), func(i int) bool { return l.times[i].After(t) })
+	if n == 0 {
+		return 0
+	}
+	return l.cumulative[n-1]
+}
+
+// Total 累计净划转金额
+func (l *FlowLedger) Total() float64 {
+	if l == nil || len(l.cumulative) == 0 {
+		return 0
+	}
+	return l.cumulative[len(l.cumulative)-1]
+}
+
+// EquityCurveNetOfFlows 扣除资金划转后的净值序列：每个点的净值减去截至该时间的累计净划转
+// 入金/出金不会被当作收益或回撤（ledger为nil时与 EquityCurve 相同）
+func EquityCurveNetOfFlows(records []*models.DecisionRecord, ledger *FlowLedger) []float64 {
+	equities := make([]float64, 0, len(records))
+	for _, record := range records {
+		if record.TotalBalance > 0 {
+			equities = append(equities, record.TotalBalance-ledger.NetAt(record.Timestamp))
+		}
+	}
+	return equities
+}
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"nofx/database/models"
+	"time"
+)
+
+// IncomeMonth 一个自然月（UTC）的收益
+// 交易所口径：已实现盈亏 + 资金费 + 手续费 + 清算费 + 其他 = 净收益
+// 系统口径：该月平仓的交易结果盈亏合计（按开平仓价格计算，不含手续费和资金费）
+type IncomeMonth struct {
+	Month       string    `json:"month"` // 2006-01
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"` // 不包含
+	RealizedPnL float64   `json:"realized_pnl"`
+	Funding     float64   `json:"funding"`    // 资金费（收取为正、支付为负）
+	Commission  float64   `json:"commission"` // 手续费（负数）
+	Insurance   float64   `json:"insurance"`  // 强平清算费
+	Other       float64   `json:"other"`      // 其他类型（如返佣、赠金）
+	Net         float64   `json:"net"`        // 交易所口径净收益
+	Entries     int       `json:"entries"`    // 收入流水条数
+	BotPnL      float64   `json:"bot_pnl"`    // 系统记录的已实现盈亏
+	BotTrades   int       `json:"bot_trades"`
+	RealizedGap float64   `json:"realized_gap"` // 交易所已实现盈亏 - 系统记录的盈亏（应接近0，偏差大说明有漏记或外部交易）
+	Difference  float64   `json:"difference"`   // 交易所净收益 - 系统记录的盈亏（主要是手续费和资金费）
+}
+
+// add 累加一条收入流水
+func (m *IncomeMonth) add(income *models.IncomeRecord) {
+	switch income.IncomeType {
+	case models.IncomeRealizedPnL:
+		m.RealizedPnL += income.Amount
+	case models.IncomeFundingFee:
+		m.Funding += income.Amount
+	case models.IncomeCommission:
+		m.Commission += income.Amount
+	case models.IncomeInsurance:
+		m.Insurance += income.Amount
+	default:
+		m.Other += income.Amount
+	}
+	m.Net += income.Amount
+	m.Entries++
+}
+
+// merge 合并另一个月（用于合计）
+func (m *IncomeMonth) merge(o IncomeMonth) {
+	m.RealizedPnL += o.RealizedPnL
+	m.Funding += o.Funding
+	m.Commission += o.Commission
+	m.Insurance += o.Insurance
+	m.Other += o.Other
+	m.Net += o.Net
+	m.Entries += o.Entries
+	m.BotPnL += o.BotPnL
+	m.BotTrades += o.BotTrades
+	m.RealizedGap += o.RealizedGap
+	m.Difference += o.Difference
+}
+
+// IncomeStatement 按月的收益表
+type IncomeStatement struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Supported bool          `json:"supported"` // 交易所驱动是否支持同步收入历史（不支持时交易所口径全部为0）
+	Months    []IncomeMonth `json:"months"`    // 时间正序，最后一个为当月（截至To）
+	Total     IncomeMonth   `json:"total"`
+}
+
+// IncomeStatementStart 最近N个月（含当月）收益表的起始时间（UTC月初）
+func IncomeStatementStart(months int, now time.Time) time.Time {
+	if months < 1 {
+		months = 1
+	}
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// BuildIncomeStatement 把收入流水按UTC自然月汇总为最近N个月（含当月）的收益表（没有流水的月份也会列出）
+func BuildIncomeStatement(incomes []*models.IncomeRecord, months int, now time.Time) *IncomeStatement {
+	from := IncomeStatementStart(months, now)
+	statement := &IncomeStatement{From: from, To: now, Months: []IncomeMonth{}}
+	for start := from; start.Before(now); start = start.AddDate(0, 1, 0) {
+		end := start.AddDate(0, 1, 0)
+		if end.After(now) {
+			end = now
+		}
+		statement.Months = append(statement.Months, IncomeMonth{Month: start.Format("2006-01"), Start: start, End: end})
+	}
+
+	for _, income := range incomes {
+		for i := range statement.Months {
+			m := &statement.Months[i]
+			if !income.Timestamp.Before(m.Start) && income.Timestamp.Before(m.End) {
+				m.add(income)
+				break
+			}
+		}
+	}
+	statement.total()
+	return statement
+}
+
+// Reconcile 按月填入系统记录的已实现盈亏（pnlBetween 返回 [from, to) 内平仓交易的盈亏合计和笔数）并计算差额
+func (s *IncomeStatement) Reconcile(pnlBetween func(from, to time.Time) (float64, int, error)) error {
+	for i := range s.Months {
+		m := &s.Months[i]
+		pnl, trades, err := pnlBetween(m.Start, m.End)
+		if err != nil {
+			return err
+		}
+		m.BotPnL, m.BotTrades = pnl, trades
+		m.RealizedGap = m.RealizedPnL - pnl
+		m.Difference = m.Net - pnl
+	}
+	s.total()
+	return nil
+}
+
+// total 重新计算合计
+func (s *IncomeStatement) total() {
+	s.Total = IncomeMonth{Month: "total", Start: s.From, End: s.To}
+	for _, m := range s.Months {
+		s.Total.merge(m)
+	}
+}
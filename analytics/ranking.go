@@ -0,0 +1,58 @@
+package analytics
+
+import "math"
+
+// PnLToMaxDrawdown 单位最大回撤的盈亏 = 区间盈亏金额 / 最大回撤金额（区间内无回撤时按盈亏方向返回 ±NoVolatilityRatio）
+// 与卡玛比率的区别：按金额计算，不受起始净值大小影响
+func PnLToMaxDrawdown(equities []float64) float64 {
+	if len(equities) < 2 {
+		return 0
+	}
+	_, maxDrawdownUSD := MaxDrawdown(equities)
+	return ratio(equities[len(equities)-1]-equities[0], maxDrawdownUSD)
+}
+
+// ConsistencyScore 收益一致性评分（-100 到 100）：净值曲线对时间线性拟合的 R² × 100，净值整体下行时取负
+// 稳定上涨的曲线接近100，大起大落或靠一两笔暴利拉升的曲线分数较低
+func ConsistencyScore(equities []float64) float64 {
+	n := float64(len(equities))
+	if len(equities) < 3 {
+		return 0
+	}
+
+	meanX := (n - 1) / 2
+	meanY := Mean(equities)
+	var sxy, sxx, syy float64
+	for i, y := range equities {
+		dx := float64(i) - meanX
+		dy := y - meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if syy == 0 {
+		return 0 // 净值无变化
+	}
+
+	r2 := sxy * sxy / (sxx * syy)
+	if sxy < 0 {
+		return -r2 * 100
+	}
+	return r2 * 100
+}
+
+// Downsample 把序列按等间隔降采样到最多n个点（保留首尾两点），用于前端迷你走势图
+func Downsample(values []float64, n int) []float64 {
+	if n <= 0 || len(values) <= n {
+		return append([]float64(nil), values...)
+	}
+	if n == 1 {
+		return []float64{values[len(values)-1]}
+	}
+	result := make([]float64, n)
+	step := float64(len(values)-1) / float64(n-1)
+	for i := range result {
+		result[i] = values[int(math.Round(float64(i)*step))]
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package analytics
+
+import "math"
+
+// TestResult 显著性检验结果
+type TestResult struct {
+	Samples     int     `json:"samples"`     // 样本数（配对样本数或两组样本合计）
+	Statistic   float64 `json:"statistic"`   // 检验统计量（t值或z值）
+	PValue      float64 `json:"p_value"`     // 双侧p值
+	Significant bool    `json:"significant"` // p值是否低于显著性水平
+	Difference  float64 `json:"difference"`  // 差值（候选 - 基准）
+}
+
+// SignificanceLevel 默认显著性水平
+const SignificanceLevel = 0.05
+
+// PairedTTest 配对t检验：检验差值序列的均值是否显著不为0（样本少于2个时p值为1）
+func PairedTTest(diffs []float64) TestResult {
+	n := len(diffs)
+	result := TestResult{Samples: n, PValue: 1, Difference: Mean(diffs)}
+	if n < 2 {
+		return result
+	}
+	// 样本标准差（n-1）
+	sd := StdDev(diffs) * math.Sqrt(float64(n)/float64(n-1))
+	if sd == 0 {
+		// 差值完全一致：均值不为0时视为显著（统计量无穷大，JSON无法表示，保留为0）
+		if result.Difference != 0 {
+			result.PValue = 0
+			result.Significant = true
+		}
+		return result
+	}
+	result.Statistic = result.Difference / (sd / math.Sqrt(float64(n)))
+	result.PValue = 2 * (1 - studentTCDF(math.Abs(result.Statistic), float64(n-1)))
+	result.Significant = result.PValue < SignificanceLevel
+	return result
+}
+
+// TwoProportionZTest 两比例z检验：检验两组胜率是否显著不同（差值为 b - a）
+func TwoProportionZTest(winsA, totalA, winsB, totalB int) TestResult {
+	result := TestResult{Samples: totalA + totalB, PValue: 1}
+	if totalA == 0 || totalB == 0 {
+		return result
+	}
+	pA := float64(winsA) / float64(totalA)
+	pB := float64(winsB) / float64(totalB)
+	result.Difference = pB - pA
+
+	pooled := float64(winsA+winsB) / float64(totalA+totalB)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(totalA) + 1/float64(totalB)))
+	if se == 0 {
+		return result
+	}
+	result.Statistic = result.Difference / se
+	result.PValue = 2 * (1 - normalCDF(math.Abs(result.Statistic)))
+	result.Significant = result.PValue < SignificanceLevel
+	return result
+}
+
+// normalCDF 标准正态分布的累积分布函数
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// studentTCDF 自由度为df的t分布的累积分布函数
+func studentTCDF(t, df float64) float64 {
+	x := df / (df + t*t)
+	tail := 0.5 * regularizedIncompleteBeta(x, df/2, 0.5)
+	if t >= 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// regularizedIncompleteBeta 正则化不完全Beta函数 I_x(a, b)（连分式展开）
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	// 连分式在 x < (a+1)/(a+b+2) 时收敛较快，否则利用对称性 I_x(a,b) = 1 - I_{1-x}(b,a)
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction 不完全Beta函数的连分式（Lentz算法）
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-12
+		tiny          = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		// 偶数项
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// 奇数项
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
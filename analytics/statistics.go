@@ -0,0 +1,224 @@
+// Package analytics 统一的收益与风险统计（夏普、索提诺、卡玛、最大回撤、VaR）
+// 决策引擎、交易表现分析和性能监控都使用这里的实现，保证各API返回的数值一致
+package analytics
+
+import (
+	"math"
+	"nofx/database/models"
+	"sort"
+)
+
+// NoVolatilityRatio 收益率无波动时的比率上限（正收益返回 +999，负收益返回 -999）
+const NoVolatilityRatio = 999.0
+
+// MinVaRSamples 计算VaR所需的最少收益率样本数
+const MinVaRSamples = 10
+
+// EquityCurve 从决策记录提取账户净值序列（记录需按时间从旧到新，忽略净值为0的记录）
+func EquityCurve(records []*models.DecisionRecord) []float64 {
+	equities := make([]float64, 0, len(records))
+	for _, record := range records {
+		if record.TotalBalance > 0 {
+			equities = append(equities, record.TotalBalance)
+		}
+	}
+	return equities
+}
+
+// Returns 净值序列 → 周期收益率序列（前一周期净值不为正时跳过）
+func Returns(equities []float64) []float64 {
+	if len(equities) < 2 {
+		return nil
+	}
+	returns := make([]float64, 0, len(equities)-1)
+	for i := 1; i < len(equities); i++ {
+		if equities[i-1] > 0 {
+			returns = append(returns, (equities[i]-equities[i-1])/equities[i-1])
+		}
+	}
+	return returns
+}
+
+// Mean 平均值
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev 总体标准差
+func StdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	mean := Mean(values)
+	sumSquares := 0.0
+	for _, v := range values {
+		sumSquares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sumSquares / float64(len(values)))
+}
+
+// DownsideDeviation 下行偏差：只统计低于目标收益率的部分（分母为全部样本数）
+func DownsideDeviation(returns []float64, target float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	sumSquares := 0.0
+	for _, r := range returns {
+		if r < target {
+			sumSquares += (r - target) * (r - target)
+		}
+	}
+	return math.Sqrt(sumSquares / float64(len(returns)))
+}
+
+// ratio 收益/波动比率，波动为0时按收益方向返回 ±NoVolatilityRatio
+func ratio(mean, deviation float64) float64 {
+	if deviation == 0 {
+		switch {
+		case mean > 0:
+			return NoVolatilityRatio
+		case mean < 0:
+			return -NoVolatilityRatio
+		}
+		return 0
+	}
+	return mean / deviation
+}
+
+// SharpeRatio 夏普比率（周期级别、非年化，无风险利率为0，正常范围 -2 到 +2）
+func SharpeRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	return ratio(Mean(returns), StdDev(returns))
+}
+
+// SortinoRatio 索提诺比率（周期级别、非年化，只惩罚下行波动）
+func SortinoRatio(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	return ratio(Mean(returns), DownsideDeviation(returns, 0))
+}
+
+// MaxDrawdown 最大回撤，返回百分比和发生最大回撤时的金额
+func MaxDrawdown(equities []float64) (float64, float64) {
+	if len(equities) < 2 {
+		return 0, 0
+	}
+	maxDrawdownPct, maxDrawdownUSD := 0.0, 0.0
+	peak := equities[0]
+	for _, equity := range equities {
+		if equity > peak {
+			peak = equity
+		}
+		if peak <= 0 {
+			continue
+		}
+		drawdownUSD := peak - equity
+		if drawdownPct := drawdownUSD / peak * 100; drawdownPct > maxDrawdownPct {
+			maxDrawdownPct = drawdownPct
+			maxDrawdownUSD = drawdownUSD
+		}
+	}
+	return maxDrawdownPct, maxDrawdownUSD
+}
+
+// CurrentDrawdown 当前净值相对历史峰值的回撤(%)
+func CurrentDrawdown(equities []float64) float64 {
+	if len(equities) == 0 {
+		return 0
+	}
+	peak := equities[0]
+	for _, equity := range equities {
+		peak = math.Max(peak, equity)
+	}
+	if peak <= 0 {
+		return 0
+	}
+	return (peak - equities[len(equities)-1]) / peak * 100
+}
+
+// TotalReturnPct 区间总收益率(%)
+func TotalReturnPct(equities []float64) float64 {
+	if len(equities) < 2 || equities[0] <= 0 {
+		return 0
+	}
+	return (equities[len(equities)-1] - equities[0]) / equities[0] * 100
+}
+
+// CalmarRatio 卡玛比率 = 区间总收益率 / 最大回撤（区间内无回撤时按收益方向返回 ±NoVolatilityRatio）
+func CalmarRatio(equities []float64) float64 {
+	if len(equities) < 2 {
+		return 0
+	}
+	maxDrawdownPct, _ := MaxDrawdown(equities)
+	return ratio(TotalReturnPct(equities), maxDrawdownPct)
+}
+
+// HistoricalVaR 历史模拟法风险价值：给定置信度下单周期的最大亏损比例（正数，0.02表示2%）
+// 样本少于 MinVaRSamples 时返回0
+func HistoricalVaR(returns []float64, confidence float64) float64 {
+	if len(returns) < MinVaRSamples || confidence <= 0 || confidence >= 1 {
+		return 0
+	}
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	index := int(float64(len(sorted)) * (1 - confidence))
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return math.Max(0, -sorted[index])
+}
+
+// Summary 净值序列的完整统计
+type Summary struct {
+	Periods            int     `json:"periods"`              // 收益率样本数
+	MeanReturn         float64 `json:"mean_return"`          // 平均周期收益率
+	Volatility         float64 `json:"volatility"`           // 周期收益率标准差
+	DownsideDeviation  float64 `json:"downside_deviation"`   // 下行偏差
+	SharpeRatio        float64 `json:"sharpe_ratio"`         // 夏普比率
+	SortinoRatio       float64 `json:"sortino_ratio"`        // 索提诺比率
+	CalmarRatio        float64 `json:"calmar_ratio"`         // 卡玛比率
+	PnLToMaxDrawdown   float64 `json:"pnl_to_max_drawdown"`  // 单位最大回撤的盈亏
+	ConsistencyScore   float64 `json:"consistency_score"`    // 收益一致性评分(-100~100)
+	TotalReturnPct     float64 `json:"total_return_pct"`     // 区间总收益率(%)
+	MaxDrawdownPct     float64 `json:"max_drawdown_pct"`     // 最大回撤(%)
+	MaxDrawdownUSD     float64 `json:"max_drawdown_usd"`     // 最大回撤(USD)
+	CurrentDrawdownPct float64 `json:"current_drawdown_pct"` // 当前回撤(%)
+	CurrentEquity      float64 `json:"current_equity"`       // 最新净值
+	VaR95              float64 `json:"var_95"`               // 95%置信度单周期风险价值(USD)
+	VaR99              float64 `json:"var_99"`               // 99%置信度单周期风险价值(USD)
+}
+
+// Summarize 计算净值序列（按时间从旧到新）的全部统计指标
+func Summarize(equities []float64) Summary {
+	returns := Returns(equities)
+	summary := Summary{
+		Periods:            len(returns),
+		MeanReturn:         Mean(returns),
+		Volatility:         StdDev(returns),
+		DownsideDeviation:  DownsideDeviation(returns, 0),
+		SharpeRatio:        SharpeRatio(returns),
+		SortinoRatio:       SortinoRatio(returns),
+		CalmarRatio:        CalmarRatio(equities),
+		PnLToMaxDrawdown:   PnLToMaxDrawdown(equities),
+		ConsistencyScore:   ConsistencyScore(equities),
+		TotalReturnPct:     TotalReturnPct(equities),
+		CurrentDrawdownPct: CurrentDrawdown(equities),
+	}
+	summary.MaxDrawdownPct, summary.MaxDrawdownUSD = MaxDrawdown(equities)
+	if len(equities) > 0 {
+		summary.CurrentEquity = equities[len(equities)-1]
+	}
+	summary.VaR95 = HistoricalVaR(returns, 0.95) * summary.CurrentEquity
+	summary.VaR99 = HistoricalVaR(returns, 0.99) * summary.CurrentEquity
+	return summary
+}
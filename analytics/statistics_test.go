@@ -0,0 +1,157 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+)
+
+// sampleEquities 12个周期的净值（11个收益率样本，含两次回撤）
+var sampleEquities = []float64{1000, 1010, 1005, 1020, 990, 1000, 1030, 1025, 1040, 1035, 1050, 1045}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
+
+// TestSummarize 固定决策引擎、决策日志和性能监控原先各自实现的计算结果
+// 夏普/最大回撤/VaR与被替换的实现一致：总体标准差、非年化、历史模拟法VaR按净值折算为USD
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name     string
+		equities []float64
+		want     Summary
+	}{
+		{
+			name:     "empty",
+			equities: nil,
+			want:     Summary{},
+		},
+		{
+			name:     "single",
+			equities: []float64{1000},
+			want:     Summary{CurrentEquity: 1000},
+		},
+		{
+			name:     "sample",
+			equities: sampleEquities,
+			want: Summary{
+				Periods:            11,
+				MeanReturn:         0.004124277949360642,
+				Volatility:         0.015138160104098873,
+				DownsideDeviation:  0.009336701015419053,
+				SharpeRatio:        0.27244248448950775,
+				SortinoRatio:       0.44172753765485495,
+				CalmarRatio:        1.53,
+				TotalReturnPct:     4.5,
+				MaxDrawdownPct:     2.941176470588235,
+				MaxDrawdownUSD:     30,
+				CurrentDrawdownPct: 0.4761904761904762,
+				CurrentEquity:      1045,
+				VaR95:              30.735294117647058,
+				VaR99:              30.735294117647058,
+			},
+		},
+		{
+			// 无波动且无亏损：夏普、索提诺、卡玛都按收益方向取上限
+			name:     "zero_variance_gain",
+			equities: []float64{1000, 2000, 4000},
+			want: Summary{
+				Periods:        2,
+				MeanReturn:     1,
+				SharpeRatio:    NoVolatilityRatio,
+				SortinoRatio:   NoVolatilityRatio,
+				CalmarRatio:    NoVolatilityRatio,
+				TotalReturnPct: 300,
+				CurrentEquity:  4000,
+			},
+		},
+		{
+			name:     "zero_variance_loss",
+			equities: []float64{1000, 500, 250},
+			want: Summary{
+				Periods:            2,
+				MeanReturn:         -0.5,
+				DownsideDeviation:  0.5,
+				SharpeRatio:        -NoVolatilityRatio,
+				SortinoRatio:       -1,
+				CalmarRatio:        -1,
+				TotalReturnPct:     -75,
+				MaxDrawdownPct:     75,
+				MaxDrawdownUSD:     750,
+				CurrentDrawdownPct: 75,
+				CurrentEquity:      250,
+			},
+		},
+		{
+			name:     "flat",
+			equities: []float64{1000, 1000, 1000},
+			want:     Summary{Periods: 2, CurrentEquity: 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Summarize(tt.equities)
+			checks := []struct {
+				field     string
+				got, want float64
+			}{
+				{"Periods", float64(got.Periods), float64(tt.want.Periods)},
+				{"MeanReturn", got.MeanReturn, tt.want.MeanReturn},
+				{"Volatility", got.Volatility, tt.want.Volatility},
+				{"DownsideDeviation", got.DownsideDeviation, tt.want.DownsideDeviation},
+				{"SharpeRatio", got.SharpeRatio, tt.want.SharpeRatio},
+				{"SortinoRatio", got.SortinoRatio, tt.want.SortinoRatio},
+				{"CalmarRatio", got.CalmarRatio, tt.want.CalmarRatio},
+				{"TotalReturnPct", got.TotalReturnPct, tt.want.TotalReturnPct},
+				{"MaxDrawdownPct", got.MaxDrawdownPct, tt.want.MaxDrawdownPct},
+				{"MaxDrawdownUSD", got.MaxDrawdownUSD, tt.want.MaxDrawdownUSD},
+				{"CurrentDrawdownPct", got.CurrentDrawdownPct, tt.want.CurrentDrawdownPct},
+				{"CurrentEquity", got.CurrentEquity, tt.want.CurrentEquity},
+				{"VaR95", got.VaR95, tt.want.VaR95},
+				{"VaR99", got.VaR99, tt.want.VaR99},
+			}
+			for _, c := range checks {
+				if !almostEqual(c.got, c.want) {
+					t.Errorf("%s = %v, 期望 %v", c.field, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDownsideDeviationWithoutLosses(t *testing.T) {
+	returns := []float64{0.01, 0.02, 0.005}
+	if got := DownsideDeviation(returns, 0); got != 0 {
+		t.Errorf("没有低于目标的收益时下行偏差应为0，实际 %v", got)
+	}
+	if got := SortinoRatio(returns); got != NoVolatilityRatio {
+		t.Errorf("没有下行收益时索提诺比率应为 %v，实际 %v", NoVolatilityRatio, got)
+	}
+	if got := DownsideDeviation(nil, 0); got != 0 {
+		t.Errorf("空序列下行偏差应为0，实际 %v", got)
+	}
+}
+
+func TestHistoricalVaR(t *testing.T) {
+	returns := []float64{0.01, -0.03, 0.02, -0.01, 0.005, -0.02, 0.015, 0, -0.005, 0.01,
+		0.02, -0.04, 0.01, 0.005, -0.015, 0.01, 0.02, -0.01, 0.005, 0.01}
+	tests := []struct {
+		name       string
+		returns    []float64
+		confidence float64
+		want       float64
+	}{
+		{"95%", returns, 0.95, 0.03}, // 20个样本取索引1（第二小的收益率）
+		{"99%", returns, 0.99, 0.04}, // 取索引0（最小收益率）
+		{"too_few_samples", returns[:MinVaRSamples-1], 0.95, 0},
+		{"invalid_confidence", returns, 1, 0},
+		{"all_gains", []float64{0.01, 0.02, 0.01, 0.03, 0.01, 0.02, 0.01, 0.02, 0.01, 0.02}, 0.95, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HistoricalVaR(tt.returns, tt.confidence); !almostEqual(got, tt.want) {
+				t.Errorf("HistoricalVaR = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package analytics
+
+import (
+	"fmt"
+	"nofx/database/models"
+	"sort"
+	"time"
+)
+
+// TimeOfDayWindowHours 时段分析中连续时段的长度（小时）
+const TimeOfDayWindowHours = 4
+
+// 时段结论的筛选条件
+const (
+	timeOfDayMaxWindows       = 2  // 每类结论（弱势/强势）最多列出的时段/星期数
+	timeOfDayWinRateMarginPct = 10 // 胜率至少比整体低/高多少个百分点才视为弱势/强势
+)
+
+// 时段结论类型
+const (
+	TimeWindowHours   = "hours"   // 一天中的连续时段（UTC）
+	TimeWindowWeekday = "weekday" // 星期几（UTC）
+)
+
+// TimeOfDayStats 一组交易的表现
+type TimeOfDayStats struct {
+	Trades   int     `json:"trades"`
+	Wins     int     `json:"wins"`
+	WinRate  float64 `json:"win_rate"`  // 胜率(%)
+	AvgPnL   float64 `json:"avg_pnl"`   // 平均盈亏(USDT)
+	TotalPnL float64 `json:"total_pnl"` // 盈亏合计(USDT)
+}
+
+// add 累加一笔交易
+func (s *TimeOfDayStats) add(pnl float64) {
+	s.Trades++
+	if pnl > 0 {
+		s.Wins++
+	}
+	s.TotalPnL += pnl
+	s.WinRate = float64(s.Wins) / float64(s.Trades) * 100
+	s.AvgPnL = s.TotalPnL / float64(s.Trades)
+}
+
+// merge 合并另一组交易
+func (s *TimeOfDayStats) merge(o TimeOfDayStats) {
+	s.Trades += o.Trades
+	s.Wins += o.Wins
+	s.TotalPnL += o.TotalPnL
+	if s.Trades > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Trades) * 100
+		s.AvgPnL = s.TotalPnL / float64(s.Trades)
+	}
+}
+
+// HourBucket 某个UTC小时开仓的交易表现
+type HourBucket struct {
+	Hour int `json:"hour"` // 0-23
+	TimeOfDayStats
+}
+
+// WeekdayBucket 某个星期几（UTC）开仓的交易表现
+type WeekdayBucket struct {
+	Weekday int    `json:"weekday"` // 0=周日 ... 6=周六（与time.Weekday一致）
+	Name    string `json:"name"`
+	TimeOfDayStats
+}
+
+// HeatmapCell 活跃度热力图中的一格（星期几 × 小时，只包含有交易的格子）
+type HeatmapCell struct {
+	Weekday int     `json:"weekday"`
+	Hour    int     `json:"hour"`
+	Trades  int     `json:"trades"`
+	AvgPnL  float64 `json:"avg_pnl"`
+}
+
+// TimeWindow 表现明显偏离整体的开仓时段
+type TimeWindow struct {
+	Kind      string `json:"kind"`       // hours / weekday
+	Label     string `json:"label"`      // 如 "02:00-06:00 UTC"、"Monday"
+	StartHour int    `json:"start_hour"` // Kind为hours时有效（包含）
+	EndHour   int    `json:"end_hour"`   // Kind为hours时有效（不包含，跨零点时小于StartHour）
+	Weekday   int    `json:"weekday"`    // Kind为weekday时有效
+	TimeOfDayStats
+}
+
+// Contains 时间是否落在该时段内（按UTC）
+func (w TimeWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	if w.Kind == TimeWindowWeekday {
+		return int(t.Weekday()) == w.Weekday
+	}
+	offset := (t.Hour() - w.StartHour + 24) % 24
+	return offset < TimeOfDayWindowHours
+}
+
+// TimeOfDayReport 按开仓时间（UTC小时/星期几）分组的交易表现
+type TimeOfDayReport struct {
+	Overall   TimeOfDayStats  `json:"overall"`
+	MinTrades int             `json:"min_trades"` // 时段结论要求的最少交易数
+	Hours     []HourBucket    `json:"hours"`      // 24个小时（没有交易的小时交易数为0）
+	Weekdays  []WeekdayBucket `json:"weekdays"`   // 7天（周日开始）
+	Heatmap   []HeatmapCell   `json:"heatmap"`
+	Weak      []TimeWindow    `json:"weak"`   // 胜率明显低于整体且平均亏损的时段（亏损最多的在前）
+	Strong    []TimeWindow    `json:"strong"` // 胜率明显高于整体且平均盈利的时段（盈利最多的在前）
+}
+
+// AnalyzeTimeOfDay 按开仓时间统计交易表现，并找出样本数不少于minTrades的弱势/强势时段
+func AnalyzeTimeOfDay(trades []*models.TradeOutcome, minTrades int) *TimeOfDayReport {
+	report := &TimeOfDayReport{
+		MinTrades: minTrades,
+		Hours:     make([]HourBucket, 24),
+		Weekdays:  make([]WeekdayBucket, 7),
+		Heatmap:   []HeatmapCell{},
+		Weak:      []TimeWindow{},
+		Strong:    []TimeWindow{},
+	}
+	for h := range report.Hours {
+		report.Hours[h].Hour = h
+	}
+	for d := range report.Weekdays {
+		report.Weekdays[d].Weekday = d
+		report.Weekdays[d].Name = time.Weekday(d).String()
+	}
+
+	var cells [7][24]TimeOfDayStats
+	for _, trade := range trades {
+		if trade.OpenTime.IsZero() {
+			continue
+		}
+		t := trade.OpenTime.UTC()
+		report.Overall.add(trade.PnL)
+		report.Hours[t.Hour()].add(trade.PnL)
+		report.Weekdays[t.Weekday()].add(trade.PnL)
+		cells[t.Weekday()][t.Hour()].add(trade.PnL)
+	}
+	for d := range cells {
+		for h, cell := range cells[d] {
+			if cell.Trades > 0 {
+				report.Heatmap = append(report.Heatmap, HeatmapCell{Weekday: d, Hour: h, Trades: cell.Trades, AvgPnL: cell.AvgPnL})
+			}
+		}
+	}
+	if report.Overall.Trades == 0 {
+		return report
+	}
+
+	var windows []TimeWindow
+	for start := 0; start < 24; start++ {
+		w := TimeWindow{Kind: TimeWindowHours, StartHour: start, EndHour: (start + TimeOfDayWindowHours) % 24}
+		w.Label = fmt.Sprintf("%02d:00-%02d:00 UTC", w.StartHour, w.EndHour)
+		for i := 0; i < TimeOfDayWindowHours; i++ {
+			w.merge(report.Hours[(start+i)%24].TimeOfDayStats)
+		}
+		windows = append(windows, w)
+	}
+	for _, d := range report.Weekdays {
+		windows = append(windows, TimeWindow{Kind: TimeWindowWeekday, Label: d.Name, Weekday: d.Weekday, TimeOfDayStats: d.TimeOfDayStats})
+	}
+
+	var weak, strong []TimeWindow
+	for _, w := range windows {
+		if w.Trades < minTrades || w.Trades == report.Overall.Trades {
+			continue
+		}
+		if w.AvgPnL < 0 && w.WinRate <= report.Overall.WinRate-timeOfDayWinRateMarginPct {
+			weak = append(weak, w)
+		} else if w.AvgPnL > 0 && w.WinRate >= report.Overall.WinRate+timeOfDayWinRateMarginPct {
+			strong = append(strong, w)
+		}
+	}
+	sort.SliceStable(weak, func(i, j int) bool { return weak[i].TotalPnL < weak[j].TotalPnL })
+	sort.SliceStable(strong, func(i, j int) bool { return strong[i].TotalPnL > strong[j].TotalPnL })
+	report.Weak = pickTimeWindows(weak)
+	report.Strong = pickTimeWindows(strong)
+	return report
+}
+
+// pickTimeWindows 按顺序选出互不重叠的时段（相邻的滑动窗口高度重叠，只保留最显著的一个）
+func pickTimeWindows(candidates []TimeWindow) []TimeWindow {
+	picked := []TimeWindow{}
+	for _, c := range candidates {
+		overlaps := false
+		for _, p := range picked {
+			if p.Kind == TimeWindowHours && c.Kind == TimeWindowHours && hourWindowsOverlap(p.StartHour, c.StartHour) {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		picked = append(picked, c)
+		if len(picked) >= timeOfDayMaxWindows {
+			break
+		}
+	}
+	return picked
+}
+
+// hourWindowsOverlap 两个起点的连续时段是否有重叠（考虑跨零点）
+func hourWindowsOverlap(a, b int) bool {
+	diff := (a - b + 24) % 24
+	return diff < TimeOfDayWindowHours || 24-diff < TimeOfDayWindowHours
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"nofx/database"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestToken 获取请求携带的API Token
+// 支持 Authorization: Bearer <token>、X-API-Token 头以及 ?token= 参数（便于分享只读面板链接）
+// ?token= 只接受观察者Token：查询参数会出现在访问日志和浏览器历史中，管理员Token必须通过请求头传递
+func requestToken(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if token := c.GetHeader("X-API-Token"); token != "" {
+		return token
+	}
+	if token := c.Query("token"); containsToken(database.CurrentAPIAccessConfig().ObserverTokens, token) {
+		return token
+	}
+	return ""
+}
+
+// containsToken 判断Token是否在列表中（空Token不匹配任何项）
+func containsToken(tokens []string, token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, t := range tokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadOnlyRequest 判断请求是否处于只读观察者模式
+func isReadOnlyRequest(c *gin.Context) bool {
+	access := database.CurrentAPIAccessConfig()
+	token := requestToken(c)
+	if containsToken(access.ObserverTokens, token) {
+		return true
+	}
+	return access.ReadOnly && !containsToken(access.AdminTokens, token)
+}
+
+// readOnlySafePaths 使用POST但不修改任何状态的接口（只读模式下也允许访问）
+// 决策干跑验证使用只读的交易上下文（不处理自动平仓、不更新回撤锁和风险台账）
+var readOnlySafePaths = map[string]bool{
+	"/api/decisions/validate": true,
+}
+
+// readOnlyMiddleware 只读观察者模式中间件：GET请求正常返回，修改类请求（POST/PUT/DELETE等）返回403
+func readOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		// 健康检查和不产生修改的POST接口（如决策干跑验证）允许任意方法
+		if c.Request.URL.Path == "/health" || readOnlySafePaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		if isReadOnlyRequest(c) {
+			log.Printf("🔒 只读模式拒绝请求: %s %s (%s)", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			abortError(c, http.StatusForbidden, "只读模式：当前Token无权执行修改操作")
+			return
+		}
+		c.Next()
+	}
+}
+
+// secretConfigKeyMarkers 键名包含这些词的系统配置视为敏感信息（API Token、密码、签名密钥等）
+var secretConfigKeyMarkers = []string{"password", "token", "secret", "private"}
+
+// isSecretConfigKey 系统配置键是否为敏感信息
+func isSecretConfigKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range secretConfigKeyMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactConfigValue 只读请求隐藏敏感配置的值（观察者不能通过读取配置拿到管理员Token而绕过只读模式）
+func redactConfigValue(c *gin.Context, key, value string) string {
+	if value == "" || !isReadOnlyRequest(c) {
+		return value
+	}
+	if isSecretConfigKey(key) {
+		return ""
+	}
+	if key == webhookEndpointsConfigKey {
+		return redactWebhookEndpoints(value)
+	}
+	return value
+}
+
+// webhookEndpointsConfigKey 回调地址配置（JSON数组，每个地址可带独立的签名密钥）
+const webhookEndpointsConfigKey = "webhook_endpoints"
+
+// redactWebhookEndpoints 清空回调地址配置中每个地址的签名密钥（其余字段原样保留，解析失败时整体隐藏）
+func redactWebhookEndpoints(value string) string {
+	var endpoints []map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &endpoints); err != nil {
+		return ""
+	}
+	for _, ep := range endpoints {
+		if _, ok := ep["secret"]; ok {
+			ep["secret"] = ""
+		}
+	}
+	data, err := json.Marshal(endpoints)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
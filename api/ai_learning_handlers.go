@@ -2,11 +2,14 @@ package api
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"nofx/database/models"
+	"nofx/i18n"
 	"github.com/gin-gonic/gin"
 )
 
@@ -14,27 +17,27 @@ import (
 func (s *Server) handleGenerateAILearning(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	logger := trader.GetDecisionLogger()
 	db := logger.GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
 	// 获取历史交易数据进行分析
 	tradeOutcomes, err := db.GetTradeOutcomes(50) // 分析最近50笔交易
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取交易数据失败: %v", err)})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取交易数据失败: %v", err))
 		return
 	}
 
@@ -49,34 +52,21 @@ func (s *Server) handleGenerateAILearning(c *gin.Context) {
 	// 获取最近的决策记录用于分析决策质量
 	decisionRecords, err := db.GetLatestRecords(30) // 最近30条决策记录
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取决策记录失败: %v", err)})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取决策记录失败: %v", err))
 		return
 	}
 
-	// 构建AI分析的系统提示词
-	systemPrompt := `你是专业的交易分析AI，负责分析历史交易数据并生成学习总结。
-
-请基于提供的交易数据，分析以下方面：
-1. **成功模式识别**：什么样的市场条件、技术指标组合、持仓时长等导致了盈利交易
-2. **失败模式识别**：什么情况下容易亏损，常见的错误决策模式
-3. **风险管理评估**：止损执行情况、仓位管理、风险回报比分析
-4. **时间维度分析**：不同时段的交易表现，持仓时长对收益的影响
-5. **币种偏好分析**：哪些币种表现更好，为什么
-6. **改进建议**：基于数据分析提出具体的策略优化建议
-
-输出格式要求：
-- 使用markdown格式
-- 结构清晰，包含数据支撑
-- 重点突出关键发现和可执行的改进建议
-- 控制在800-1200字以内`
+	// 构建AI分析的系统提示词（按trader的提示词语言）
+	lang := trader.PromptLanguage()
+	systemPrompt := i18n.T(lang, "learning.analysis_system")
 
 	// 构建用户提示词（包含交易数据）
-	userPrompt := buildLearningAnalysisPrompt(tradeOutcomes, decisionRecords)
+	userPrompt := buildLearningAnalysisPrompt(tradeOutcomes, decisionRecords, lang)
 
 	// 调用AI进行分析
 	aiResponse, err := trader.CallAI(systemPrompt, userPrompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("AI分析失败: %v", err)})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("AI分析失败: %v", err))
 		return
 	}
 
@@ -97,7 +87,7 @@ func (s *Server) handleGenerateAILearning(c *gin.Context) {
 
 	err = db.SaveAILearningSummary(summary)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存学习总结失败: %v", err)})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("保存学习总结失败: %v", err))
 		return
 	}
 
@@ -116,26 +106,26 @@ func (s *Server) handleGenerateAILearning(c *gin.Context) {
 func (s *Server) handleGetAILearningSummary(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	logger := trader.GetDecisionLogger()
 	db := logger.GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
 	summary, err := db.GetActiveAILearningSummary()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取总结失败: %v", err)})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取总结失败: %v", err))
 		return
 	}
 
@@ -146,12 +136,68 @@ func (s *Server) handleGetAILearningSummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"has_summary":     true,
+		"id":              summary.ID,
 		"summary_content": summary.SummaryContent,
 		"trades_count":    summary.TradesCount,
 		"win_rate":        summary.WinRate,
 		"avg_pnl":         summary.AvgPnL,
 		"date_range":      fmt.Sprintf("%s ~ %s", summary.DateRangeStart, summary.DateRangeEnd),
 		"created_at":      summary.CreatedAt.Format("2006-01-02 15:04:05"),
+		"activated_at":    summary.ActivatedAt,
+	})
+}
+
+// handleAILearningEffectiveness AI学习总结效果报告（各版本生效前后的胜率/盈亏对比）
+func (s *Server) handleAILearningEffectiveness(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	reports, err := trader.GetDecisionLogger().GetLearningEffectiveness()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("生成学习总结效果报告失败: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// handleDeactivateAILearningSummary 停用有害的AI学习总结（之后的决策不再包含该总结）
+func (s *Server) handleDeactivateAILearningSummary(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		respondError(c, http.StatusBadRequest, "无效的学习总结ID")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := trader.GetDecisionLogger().DeactivateLearningSummary(id); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	log.Printf("📚 [%s] 已停用AI学习总结 #%d", traderID, id)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("已停用AI学习总结 #%d", id),
 	})
 }
 
@@ -164,51 +210,51 @@ type TradeStatistics struct {
 }
 
 // buildLearningAnalysisPrompt 构建AI学习分析的用户提示词
-func buildLearningAnalysisPrompt(tradeOutcomes []*models.TradeOutcome, decisionRecords []*models.DecisionRecord) string {
+func buildLearningAnalysisPrompt(tradeOutcomes []*models.TradeOutcome, decisionRecords []*models.DecisionRecord, lang i18n.Lang) string {
 	var prompt strings.Builder
 	
-	prompt.WriteString("## 历史交易数据分析\n\n")
-	prompt.WriteString(fmt.Sprintf("### 交易结果数据 (共%d笔交易)\n", len(tradeOutcomes)))
+	prompt.WriteString(i18n.T(lang, "learning.analysis_title"))
+	prompt.WriteString(i18n.T(lang, "learning.analysis_trades", len(tradeOutcomes)))
 	
 	for i, trade := range tradeOutcomes {
 		if i >= 20 { // 限制显示前20笔交易，避免提示词过长
-			prompt.WriteString(fmt.Sprintf("... 还有%d笔交易数据\n", len(tradeOutcomes)-20))
+			prompt.WriteString(i18n.T(lang, "learning.analysis_more_trades", len(tradeOutcomes)-20))
 			break
 		}
 		
 		// 计算持仓时长
 		duration := time.Duration(trade.DurationMinutes) * time.Minute
 		
-		prompt.WriteString(fmt.Sprintf("**交易%d**: %s %s | 盈亏: %.2f USDT (%.2f%%) | 持仓时长: %s | 开仓: %.4f | 平仓: %.4f\n",
+		prompt.WriteString(i18n.T(lang, "learning.analysis_trade",
 			i+1, trade.Symbol, trade.Side, trade.PnL, trade.PnLPct, 
 			formatDuration(duration), trade.OpenPrice, trade.ClosePrice))
 		
 		if trade.EntryReason != "" {
-			prompt.WriteString(fmt.Sprintf("  开仓理由: %s\n", trade.EntryReason))
+			prompt.WriteString(i18n.T(lang, "learning.analysis_entry_reason", trade.EntryReason))
 		}
 		if trade.ExitReason != "" {
-			prompt.WriteString(fmt.Sprintf("  平仓理由: %s\n", trade.ExitReason))
+			prompt.WriteString(i18n.T(lang, "learning.analysis_exit_reason", trade.ExitReason))
 		}
 		prompt.WriteString("\n")
 	}
 	
-	prompt.WriteString(fmt.Sprintf("\n### 最近决策记录 (共%d条)\n", len(decisionRecords)))
+	prompt.WriteString(i18n.T(lang, "learning.analysis_decisions", len(decisionRecords)))
 	for i, record := range decisionRecords {
 		if i >= 10 { // 限制显示前10条决策记录
-			prompt.WriteString(fmt.Sprintf("... 还有%d条决策记录\n", len(decisionRecords)-10))
+			prompt.WriteString(i18n.T(lang, "learning.analysis_more_decisions", len(decisionRecords)-10))
 			break
 		}
 		
-		prompt.WriteString(fmt.Sprintf("**决策%d**: 周期%d | 时间: %s | 成功: %t\n",
+		prompt.WriteString(i18n.T(lang, "learning.analysis_decision",
 			i+1, record.CycleNumber, record.Timestamp.Format("01-02 15:04"), record.Success))
 		
 		if record.ErrorMessage != "" {
-			prompt.WriteString(fmt.Sprintf("  错误: %s\n", record.ErrorMessage))
+			prompt.WriteString(i18n.T(lang, "learning.analysis_error", record.ErrorMessage))
 		}
 		prompt.WriteString("\n")
 	}
 	
-	prompt.WriteString("\n请基于以上数据进行深入分析，识别成功和失败的模式，并提出具体的改进建议。")
+	prompt.WriteString(i18n.T(lang, "learning.analysis_request"))
 	
 	return prompt.String()
 }
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"nofx/trader"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleSwitchAIModel 运行时切换Trader的AI模型（无需重启，只影响运行中的Trader，不修改数据库中保存的配置）
+func (s *Server) handleSwitchAIModel(c *gin.Context) {
+	traderID := c.Param("id")
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var req trader.AIModelSwitch
+	if err := c.ShouldBindJSON(&req); err != nil || req.AIModel == "" {
+		respondError(c, http.StatusBadRequest, "请求体需要包含 ai_model 字段（deepseek/qwen/custom）")
+		return
+	}
+
+	log.Printf("🔁 收到AI模型切换请求: Trader=%s, Model=%s %s", traderID, req.AIModel, req.ModelName)
+	result, err := t.SwitchAIModel(req)
+	if err != nil {
+		log.Printf("❌ Trader %s 切换AI模型失败: %v", traderID, err)
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"trader":  traderID,
+		"result":  result,
+		"message": "AI模型已切换，重启服务后恢复为数据库中保存的配置",
+	})
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleApprovals 大额交易审批队列（待审批的在前）
+func (s *Server) handleApprovals(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	approvals := trader.GetApprovals()
+	pending := 0
+	for _, a := range approvals {
+		if a.Status == "pending" {
+			pending++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"pending":   pending,
+		"approvals": approvals,
+	})
+}
+
+// handleApproveDecision 审批通过：按当前账户状态重新验证后执行，记录到决策历史
+func (s *Server) handleApproveDecision(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	id := c.Param("id")
+	record, err := trader.ApproveDecision(id)
+	if err != nil {
+		log.Printf("❌ [%s] 审批 %s 执行失败: %v", traderID, id, err)
+		respondErrorDetails(c, http.StatusUnprocessableEntity, err.Error(), gin.H{"record": record})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "审批通过，决策已执行",
+		"record":  record,
+	})
+}
+
+// handleRejectDecision 拒绝待审批的决策
+func (s *Server) handleRejectDecision(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := trader.RejectDecision(c.Param("id")); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已拒绝该决策",
+	})
+}
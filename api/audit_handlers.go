@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleExchangeAudit 交易所请求审计记录（最新的在前，支持分页和 symbol/operation/success/since/until 过滤）
+func (s *Server) handleExchangeAudit(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	q, err := parseListQuery(c, 100)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, total, err := trader.GetExchangeAudit(q.QueryOptions, c.Query("operation"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取交易所审计记录失败: %v", err))
+		return
+	}
+
+	var nextCursor int64
+	if q.Limit > 0 && len(entries) == q.Limit {
+		nextCursor = entries[len(entries)-1].ID
+	}
+	setPageHeaders(c, total, nextCursor)
+
+	result, err := q.selectFields(entries)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
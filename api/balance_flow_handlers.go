@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleBalanceFlows 资金划转台账（入金/出金明细、累计净入金和净投入）
+func (s *Server) handleBalanceFlows(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	ledger, flows, err := trader.GetFlowLedger()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取资金划转台账失败: %v", err))
+		return
+	}
+
+	type flowView struct {
+		TranID    string  `json:"tran_id"`
+		Asset     string  `json:"asset"`
+		Amount    float64 `json:"amount"`
+		Timestamp string  `json:"timestamp"`
+		NetFlows  float64 `json:"net_flows"` // 截至该笔的累计净入金
+	}
+	views := make([]flowView, 0, len(flows))
+	for _, f := range flows {
+		views = append(views, flowView{
+			TranID:    f.TranID,
+			Asset:     f.Asset,
+			Amount:    f.Amount,
+			Timestamp: f.Timestamp.Format("2006-01-02 15:04:05"),
+			NetFlows:  ledger.NetAt(f.Timestamp),
+		})
+	}
+
+	initialBalance, _ := trader.GetStatus()["initial_balance"].(float64)
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":       traderID,
+		"initial_balance": initialBalance,
+		"net_flows":       ledger.Total(),
+		"cost_basis":      initialBalance + ledger.Total(),
+		"flows":           views,
+	})
+}
+
+// handleIncomeStatement 月度收益表：交易所收入历史按月汇总（已实现盈亏、资金费、手续费、清算费），并与系统记录的已实现盈亏对账
+func (s *Server) handleIncomeStatement(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	months := 12
+	if m, err := strconv.Atoi(c.Query("months")); err == nil && m > 0 {
+		months = m
+	}
+	if months > 36 {
+		months = 36
+	}
+
+	statement, err := trader.GetIncomeStatement(months, time.Now())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取收益表失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"statement": statement,
+	})
+}
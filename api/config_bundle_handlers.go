@@ -0,0 +1,280 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/database/repositories"
+	"nofx/decision"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 配置备份格式
+const (
+	configBundleFormat  = "nofx-config-bundle"
+	configBundleVersion = 1
+)
+
+// bundleExcludedConfigTypes 不导出的系统配置类型（API Token、端口、Redis地址、回调地址及其签名密钥等与部署环境绑定的配置）
+var bundleExcludedConfigTypes = map[string]bool{
+	"api":          true,
+	"shared_state": true,
+	"webhook":      true,
+}
+
+// configBundle Trader配置备份（参数、风控、Prompt和系统配置，不含API密钥）
+type configBundle struct {
+	Format                string                 `json:"format"`                  // 固定为 nofx-config-bundle
+	Version               int                    `json:"version"`                 // 备份格式版本
+	DecisionSchemaVersion int                    `json:"decision_schema_version"` // 导出时prompt对应的决策输出格式版本
+	ExportedAt            time.Time              `json:"exported_at"`
+	SourceTraderID        string                 `json:"source_trader_id"`
+	SourceTraderName      string                 `json:"source_trader_name"`
+	Trader                traderTemplateConfig   `json:"trader"`                   // Trader参数（含风控参数，不含密钥）
+	Prompts               []*models.PromptConfig `json:"prompts"`                  // 全部prompt配置（含禁用的section）
+	SystemConfigs         []bundleSystemConfig   `json:"system_configs,omitempty"` // 全局系统配置（风控阈值、分组限额等，整个部署共享）
+}
+
+// bundleSystemConfig 备份中的系统配置项
+type bundleSystemConfig struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+}
+
+// exportable 系统配置是否可以导出
+func (c bundleSystemConfig) exportable() bool {
+	if bundleExcludedConfigTypes[c.Type] {
+		return false
+	}
+	// 敏感信息（键名含token/secret/password等）不导出
+	return !isSecretConfigKey(c.Key)
+}
+
+// validate 检查备份格式和版本
+func (b *configBundle) validate() error {
+	if b.Format != configBundleFormat {
+		return fmt.Errorf("不是有效的配置备份（format应为%s）", configBundleFormat)
+	}
+	if b.Version <= 0 || b.Version > configBundleVersion {
+		return fmt.Errorf("不支持的备份版本: %d（当前支持%d）", b.Version, configBundleVersion)
+	}
+	for i, p := range b.Prompts {
+		if p == nil || strings.TrimSpace(p.SectionName) == "" {
+			return fmt.Errorf("prompts[%d]缺少section_name", i)
+		}
+	}
+	for i, cfg := range b.SystemConfigs {
+		if cfg.Key == "" {
+			return fmt.Errorf("system_configs[%d]缺少key", i)
+		}
+		if !cfg.exportable() {
+			return fmt.Errorf("system_configs[%d] %s 与部署环境绑定或包含敏感信息，不能通过备份恢复", i, cfg.Key)
+		}
+	}
+	return b.Trader.validate()
+}
+
+// openTraderDB 获取Trader的数据库（运行中的Trader复用已有连接，否则临时打开）
+func (s *Server) openTraderDB(traderID string) (*database.DB, func(), error) {
+	if trader, err := s.traderManager.GetTrader(traderID); err == nil {
+		if db := trader.GetDecisionLogger().GetDB(); db != nil {
+			return db, func() {}, nil
+		}
+	}
+	db, err := database.New(traderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, func() { db.Close() }, nil
+}
+
+// handleExportConfigBundle 导出Trader的完整配置备份
+func (s *Server) handleExportConfigBundle(c *gin.Context) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	traderID := c.Query("trader_id")
+	if traderID == "" {
+		respondError(c, http.StatusBadRequest, "trader_id不能为空")
+		return
+	}
+
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("连接数据库失败: %v", err))
+		return
+	}
+	defer sysConn.Close()
+
+	src, err := repositories.NewTraderConfigRepository(sysConn.DB()).GetByTraderID(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "Trader不存在")
+		return
+	}
+
+	db, closeDB, err := s.openTraderDB(traderID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("打开Trader数据库失败: %v", err))
+		return
+	}
+	defer closeDB()
+
+	prompts, err := db.Config().GetAll()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取prompt配置失败: %v", err))
+		return
+	}
+
+	bundle := configBundle{
+		Format:                configBundleFormat,
+		Version:               configBundleVersion,
+		DecisionSchemaVersion: decision.CurrentSchemaVersion,
+		ExportedAt:            time.Now().UTC(),
+		SourceTraderID:        src.TraderID,
+		SourceTraderName:      src.Name,
+		Trader:                templateConfigFromTrader(src),
+		Prompts:               prompts,
+	}
+
+	// 系统配置为整个部署共享，默认一起导出（?system=false 只导出Trader自身配置）
+	if c.DefaultQuery("system", "true") != "false" {
+		configs, err := repositories.NewSystemConfigRepository(sysConn.DB()).GetAll()
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取系统配置失败: %v", err))
+			return
+		}
+		for _, cfg := range configs {
+			item := bundleSystemConfig{Key: cfg.Key, Value: cfg.Value, Description: cfg.Description, Type: cfg.ConfigType}
+			if item.exportable() {
+				bundle.SystemConfigs = append(bundle.SystemConfigs, item)
+			}
+		}
+	}
+
+	log.Printf("✓ 已导出Trader配置备份: %s（%d个prompt，%d个系统配置）", traderID, len(bundle.Prompts), len(bundle.SystemConfigs))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// handleRestoreConfigBundle 把配置备份恢复到同一个或另一个Trader（目标Trader不存在时新建，默认不启用）
+func (s *Server) handleRestoreConfigBundle(c *gin.Context) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	var req struct {
+		TraderID      string       `json:"trader_id"` // 目标Trader（空=备份来源Trader）
+		Name          string       `json:"name"`      // 新建Trader时的名称（空=备份来源名称）
+		Bundle        configBundle `json:"bundle" binding:"required"`
+		Trader        *bool        `json:"trader"`         // 是否恢复Trader参数（默认true）
+		Prompts       *bool        `json:"prompts"`        // 是否恢复prompt配置（默认true）
+		SystemConfigs bool         `json:"system_configs"` // 是否恢复系统配置（影响整个部署，默认false）
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+	if err := req.Bundle.validate(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.TraderID == "" {
+		req.TraderID = req.Bundle.SourceTraderID
+	}
+	if req.TraderID == "" {
+		respondError(c, http.StatusBadRequest, "trader_id不能为空")
+		return
+	}
+	restoreTrader := req.Trader == nil || *req.Trader
+	restorePrompts := req.Prompts == nil || *req.Prompts
+	if req.Bundle.DecisionSchemaVersion != decision.CurrentSchemaVersion {
+		log.Printf("⚠️  配置备份的决策格式版本为v%d（当前v%d），prompt中的输出格式说明可能需要更新",
+			req.Bundle.DecisionSchemaVersion, decision.CurrentSchemaVersion)
+	}
+
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("连接数据库失败: %v", err))
+		return
+	}
+	defer sysConn.Close()
+
+	traderRepo := repositories.NewTraderConfigRepository(sysConn.DB())
+	target, err := traderRepo.GetByTraderID(req.TraderID)
+	created := err != nil
+	if created && !restoreTrader {
+		respondError(c, http.StatusNotFound, "目标Trader不存在（新建Trader需要同时恢复Trader参数）")
+		return
+	}
+
+	restored := gin.H{}
+	if restoreTrader {
+		if created {
+			name := req.Name
+			if name == "" {
+				name = req.Bundle.SourceTraderName
+			}
+			if name == "" {
+				name = req.TraderID
+			}
+			if _, err := traderRepo.Create(req.Bundle.Trader.toTrader(req.TraderID, name)); err != nil {
+				respondError(c, http.StatusInternalServerError, fmt.Sprintf("创建Trader失败: %v", err))
+				return
+			}
+		} else {
+			req.Bundle.Trader.applyTo(target)
+			if err := traderRepo.Update(target); err != nil {
+				respondError(c, http.StatusInternalServerError, fmt.Sprintf("更新Trader失败: %v", err))
+				return
+			}
+		}
+		restored["trader"] = true
+	}
+
+	if restorePrompts {
+		db, closeDB, err := s.openTraderDB(req.TraderID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, fmt.Sprintf("打开Trader数据库失败: %v", err))
+			return
+		}
+		err = db.Config().Restore(req.Bundle.Prompts)
+		closeDB()
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		restored["prompts"] = len(req.Bundle.Prompts)
+	}
+
+	if req.SystemConfigs && len(req.Bundle.SystemConfigs) > 0 {
+		helper := database.NewConfigHelper(sysConn.DB())
+		for _, cfg := range req.Bundle.SystemConfigs {
+			if err := helper.SetString(cfg.Key, cfg.Value, cfg.Description, cfg.Type); err != nil {
+				respondError(c, http.StatusInternalServerError, fmt.Sprintf("恢复系统配置 %s 失败: %v", cfg.Key, err))
+				return
+			}
+		}
+		database.ReloadGlobalConfig()
+		restored["system_configs"] = len(req.Bundle.SystemConfigs)
+	}
+
+	log.Printf("✓ 配置备份已恢复到Trader %s（来源: %s，导出于 %s）: %v",
+		req.TraderID, req.Bundle.SourceTraderID, req.Bundle.ExportedAt.Format(time.RFC3339), restored)
+
+	message := "配置恢复成功，Trader参数需要重启服务或重新加载配置后生效"
+	if created {
+		message = "已新建Trader并恢复配置（未包含API密钥，默认未启用），请填写密钥并启用后重启服务"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"trader_id": req.TraderID,
+		"created":   created,
+		"restored":  restored,
+		"message":   message,
+	})
+}
@@ -8,6 +8,9 @@ import (
 	"nofx/database"
 	"nofx/database/models"
 	"nofx/database/repositories"
+	"nofx/i18n"
+	"nofx/market"
+	"nofx/pool"
 	"sync"
 
 	"github.com/gin-gonic/gin"
@@ -31,7 +34,7 @@ func (s *Server) handleGetConfig(c *gin.Context) {
 	// 从数据库加载配置
 	cfg, err := database.LoadConfigFromDB()
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("加载配置失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("加载配置失败: %v", err))
 		return
 	}
 
@@ -68,14 +71,14 @@ func (s *Server) handleUpdateGlobalConfig(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "请求参数错误"})
+		respondError(c, 400, "请求参数错误")
 		return
 	}
 
 	// 连接系统数据库
 	sysConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("连接数据库失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
 		return
 	}
 	defer sysConn.Close()
@@ -156,14 +159,14 @@ func (s *Server) handleUpdateTraderConfig(c *gin.Context) {
 	var req config.TraderConfig
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "请求参数错误"})
+		respondError(c, 400, "请求参数错误")
 		return
 	}
 
 	// 连接系统数据库
 	sysConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("连接数据库失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
 		return
 	}
 	defer sysConn.Close()
@@ -173,7 +176,7 @@ func (s *Server) handleUpdateTraderConfig(c *gin.Context) {
 	// 查找trader
 	dbTrader, err := traderRepo.GetByTraderID(req.ID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Trader不存在"})
+		respondError(c, 404, "Trader不存在")
 		return
 	}
 
@@ -202,6 +205,34 @@ func (s *Server) handleUpdateTraderConfig(c *gin.Context) {
 	if req.CustomAPIKey != "" && !isMaskedKey(req.CustomAPIKey) {
 		dbTrader.CustomAPIKey = req.CustomAPIKey
 	}
+	if req.CoinPoolAuthHeader != "" && !isMaskedKey(req.CoinPoolAuthHeader) {
+		dbTrader.CoinPoolAuthHeader = req.CoinPoolAuthHeader
+	}
+
+	if _, err := pool.NewCandidateSource(req.CoinSource, 0, nil); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+	if _, err := market.NewProvider(req.MarketDataSource); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+	if err := i18n.Validate(req.PromptLanguage); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+	if req.AIParams != nil {
+		if err := req.AIParams.Validate(); err != nil {
+			respondError(c, 400, err.Error())
+			return
+		}
+	}
+	if req.Critic != nil {
+		if err := req.Critic.Validate(); err != nil {
+			respondError(c, 400, err.Error())
+			return
+		}
+	}
 
 	// 打印接收到的数据用于调试
 	log.Printf("[DEBUG] 接收到的Trader数据: ID=%s, AIAutonomyMode=%v, CompactMode=%v", 
@@ -222,10 +253,24 @@ func (s *Server) handleUpdateTraderConfig(c *gin.Context) {
 	dbTrader.ScanIntervalMinutes = req.ScanIntervalMinutes
 	dbTrader.AIAutonomyMode = req.AIAutonomyMode
 	dbTrader.CompactMode = req.CompactMode
+	dbTrader.CoinSource = req.CoinSource
+	dbTrader.CoinPoolAPIURL = req.CoinPoolAPIURL
+	dbTrader.OITopAPIURL = req.OITopAPIURL
+	dbTrader.CoinPoolRefreshSeconds = req.CoinPoolRefreshSeconds
+	dbTrader.MarketDataSource = req.MarketDataSource
+	dbTrader.PromptLanguage = req.PromptLanguage
+	// 未提供ai_params时保留原值（传 {} 恢复默认值）
+	if req.AIParams != nil {
+		dbTrader.AIParams = req.AIParams.JSON()
+	}
+	// 未提供critic时保留原值（传 {"ai_model": ""} 停用审核模型）
+	if req.Critic != nil {
+		dbTrader.Critic = req.Critic.JSON()
+	}
 
 	// 更新到数据库
 	if err := traderRepo.Update(dbTrader); err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("更新失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("更新失败: %v", err))
 		return
 	}
 
@@ -245,14 +290,39 @@ func (s *Server) handleAddTrader(c *gin.Context) {
 	var req config.TraderConfig
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "请求参数错误"})
+		respondError(c, 400, "请求参数错误")
+		return
+	}
+
+	if _, err := pool.NewCandidateSource(req.CoinSource, 0, nil); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+	if _, err := market.NewProvider(req.MarketDataSource); err != nil {
+		respondError(c, 400, err.Error())
 		return
 	}
+	if err := i18n.Validate(req.PromptLanguage); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+	if req.AIParams != nil {
+		if err := req.AIParams.Validate(); err != nil {
+			respondError(c, 400, err.Error())
+			return
+		}
+	}
+	if req.Critic != nil {
+		if err := req.Critic.Validate(); err != nil {
+			respondError(c, 400, err.Error())
+			return
+		}
+	}
 
 	// 连接系统数据库
 	sysConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("连接数据库失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
 		return
 	}
 	defer sysConn.Close()
@@ -262,7 +332,7 @@ func (s *Server) handleAddTrader(c *gin.Context) {
 	// 检查ID是否已存在
 	_, err = traderRepo.GetByTraderID(req.ID)
 	if err == nil {
-		c.JSON(400, gin.H{"error": "Trader ID已存在"})
+		respondError(c, 409, "Trader ID已存在")
 		return
 	}
 
@@ -299,11 +369,20 @@ func (s *Server) handleAddTrader(c *gin.Context) {
 		AILearnInterval:       10,
 		AIAutonomyMode:        false,
 		CompactMode:           true, // 默认启用紧凑模式
+		CoinSource:            req.CoinSource,
+		CoinPoolAPIURL:        req.CoinPoolAPIURL,
+		OITopAPIURL:           req.OITopAPIURL,
+		CoinPoolAuthHeader:    req.CoinPoolAuthHeader,
+		CoinPoolRefreshSeconds: req.CoinPoolRefreshSeconds,
+		MarketDataSource:      req.MarketDataSource,
+		PromptLanguage:        req.PromptLanguage,
+		AIParams:              req.AIParams.JSON(),
+		Critic:                req.Critic.JSON(),
 	}
 
 	// 保存到数据库
 	if _, err := traderRepo.Create(dbTrader); err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("保存失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("保存失败: %v", err))
 		return
 	}
 
@@ -322,14 +401,14 @@ func (s *Server) handleDeleteTrader(c *gin.Context) {
 
 	traderID := c.Query("trader_id")
 	if traderID == "" {
-		c.JSON(400, gin.H{"error": "trader_id参数缺失"})
+		respondError(c, 400, "trader_id参数缺失")
 		return
 	}
 
 	// 连接系统数据库
 	sysConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("连接数据库失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
 		return
 	}
 	defer sysConn.Close()
@@ -339,13 +418,13 @@ func (s *Server) handleDeleteTrader(c *gin.Context) {
 	// 查找trader
 	dbTrader, err := traderRepo.GetByTraderID(traderID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Trader不存在"})
+		respondError(c, 404, "Trader不存在")
 		return
 	}
 
 	// 删除
 	if err := traderRepo.Delete(dbTrader.ID); err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("删除失败: %v", err)})
+		respondError(c, 500, fmt.Sprintf("删除失败: %v", err))
 		return
 	}
 
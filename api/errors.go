@@ -0,0 +1,169 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求ID的HTTP头（客户端可自带，否则由服务端生成）
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey 请求ID在gin.Context中的键
+const requestIDKey = "request_id"
+
+// 错误码（按HTTP状态码统一映射，前端和自动化脚本按code判断失败类型）
+const (
+	ErrCodeBadRequest      = "bad_request"       // 400 请求参数错误
+	ErrCodeUnauthorized    = "unauthorized"      // 401 未认证
+	ErrCodeForbidden       = "forbidden"         // 403 无权限（如只读模式）
+	ErrCodeNotFound        = "not_found"         // 404 资源不存在
+	ErrCodeConflict        = "conflict"          // 409 状态冲突
+	ErrCodeUnprocessable   = "unprocessable"     // 422 请求合法但被风控/校验拒绝
+	ErrCodeTooManyRequests = "too_many_requests" // 429 请求过于频繁
+	ErrCodeInternal        = "internal_error"    // 500 服务端错误
+	ErrCodeNotImplemented  = "not_implemented"   // 501 功能未实现
+	ErrCodeBadGateway      = "bad_gateway"       // 502 交易所/行情等上游服务错误
+	ErrCodeUnavailable     = "unavailable"       // 503 服务暂不可用
+)
+
+// ErrorResponse 统一的错误响应
+type ErrorResponse struct {
+	Success   bool        `json:"success"` // 固定为false
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id"`
+	Error     string      `json:"error"` // 与message相同，兼容读取error字段的旧客户端
+}
+
+// errorCode HTTP状态码对应的错误码
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusUnprocessableEntity:
+		return ErrCodeUnprocessable
+	case http.StatusTooManyRequests:
+		return ErrCodeTooManyRequests
+	case http.StatusNotImplemented:
+		return ErrCodeNotImplemented
+	case http.StatusBadGateway:
+		return ErrCodeBadGateway
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	}
+	if status >= 500 {
+		return ErrCodeInternal
+	}
+	return ErrCodeBadRequest
+}
+
+// newErrorResponse 构造错误响应，5xx错误同时记录日志（带请求ID便于和客户端报错对应）
+func newErrorResponse(c *gin.Context, status int, message string, details interface{}) ErrorResponse {
+	id := requestID(c)
+	if status >= 500 {
+		log.Printf("❌ [%s] %s %s 返回%d: %s", id, c.Request.Method, c.Request.URL.Path, status, message)
+	}
+	return ErrorResponse{
+		Code:      errorCode(status),
+		Message:   message,
+		Details:   details,
+		RequestID: id,
+		Error:     message,
+	}
+}
+
+// respondError 返回统一格式的错误响应
+func respondError(c *gin.Context, status int, message string) {
+	c.JSON(status, newErrorResponse(c, status, message, nil))
+}
+
+// respondErrorDetails 返回带附加信息的错误响应（如被拒绝的决策记录、部分完成的清理结果）
+func respondErrorDetails(c *gin.Context, status int, message string, details interface{}) {
+	c.JSON(status, newErrorResponse(c, status, message, details))
+}
+
+// abortError 中间件中终止请求并返回统一格式的错误响应
+func abortError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, newErrorResponse(c, status, message, nil))
+}
+
+// requestID 当前请求的ID
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestIDMiddleware 为每个请求分配ID：沿用客户端传入的X-Request-ID（格式合法时），否则生成随机ID，并写回响应头
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Writer.Header().Set(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID 客户端传入的请求ID只接受不超过64位的字母、数字、-和_，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, ch := range id {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9', ch == '-', ch == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成16位十六进制随机请求ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// accessLogMiddleware 请求日志（带请求ID，替代gin默认日志）
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		if c.Request.Method == http.MethodOptions {
+			return
+		}
+		log.Printf("[API] [%s] %s %s %d %v", requestID(c), c.Request.Method, c.Request.URL.Path, c.Writer.Status(), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// recoveryMiddleware handler panic时返回统一格式的500错误，不让单个请求拖垮服务
+func recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		abortError(c, http.StatusInternalServerError, fmt.Sprintf("服务器内部错误: %v", recovered))
+	})
+}
+
+// handleNoRoute 未注册的接口返回404
+func handleNoRoute(c *gin.Context) {
+	respondError(c, http.StatusNotFound, "接口不存在: "+c.Request.Method+" "+c.Request.URL.Path)
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"net/http"
+	"nofx/decision"
+	"nofx/experiment"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WalkForwardRequest 提交walk-forward评估的请求
+type WalkForwardRequest struct {
+	TraderID                string                   `json:"trader_id" binding:"required"`
+	Symbols                 []string                 `json:"symbols"`
+	From                    time.Time                `json:"from" binding:"required"` // RFC3339
+	To                      time.Time                `json:"to" binding:"required"`
+	WindowHours             int                      `json:"window_hours"`
+	StepHours               int                      `json:"step_hours"`
+	DecisionIntervalMinutes int                      `json:"decision_interval_minutes"`
+	InitialEquity           float64                  `json:"initial_equity"`
+	FeeRate                 float64                  `json:"fee_rate"`
+	MaxAICalls              int                      `json:"max_ai_calls"`
+	Candidate               experiment.VariantChange `json:"candidate"` // 提议的prompt/配置改动
+}
+
+// defaultWalkForwardSymbols 未指定回放币种时使用的默认币种
+var defaultWalkForwardSymbols = []string{"BTCUSDT", "ETHUSDT"}
+
+// handleStartWalkForward 提交walk-forward评估任务（异步执行，返回任务ID）
+func (s *Server) handleStartWalkForward(c *gin.Context) {
+	var req WalkForwardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(req.TraderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "Trader不存在: "+req.TraderID)
+		return
+	}
+
+	symbols := req.Symbols
+	if len(symbols) == 0 {
+		symbols = append([]string(nil), defaultWalkForwardSymbols...)
+	}
+	cfg := experiment.WalkForwardConfig{
+		Symbols:                 symbols,
+		From:                    req.From,
+		To:                      req.To,
+		WindowHours:             req.WindowHours,
+		StepHours:               req.StepHours,
+		DecisionIntervalMinutes: req.DecisionIntervalMinutes,
+		InitialEquity:           req.InitialEquity,
+		FeeRate:                 req.FeeRate,
+		MaxAICalls:              req.MaxAICalls,
+	}
+	job, err := trader.StartWalkForward(cfg, req.Candidate)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"job":     job,
+	})
+}
+
+// handleGetWalkForward 查询walk-forward评估任务的进度和结果
+func (s *Server) handleGetWalkForward(c *gin.Context) {
+	job, ok := experiment.GetJob(c.Param("id"))
+	if !ok {
+		respondError(c, http.StatusNotFound, "评估任务不存在: "+c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"job":     job,
+	})
+}
+
+// handleListWalkForward walk-forward评估任务列表（可按trader_id过滤，不含结果明细）
+func (s *Server) handleListWalkForward(c *gin.Context) {
+	jobs := experiment.ListJobs(c.Query("trader_id"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"jobs":    jobs,
+		"count":   len(jobs),
+	})
+}
+
+// RescoreRequest 提交决策质量重评分的请求
+type RescoreRequest struct {
+	TraderID string    `json:"trader_id" binding:"required"`
+	Version  string    `json:"version"` // 评分版本标签（默认为当前规则版本）
+	From     time.Time `json:"from"`    // RFC3339，可选
+	To       time.Time `json:"to"`
+	Limit    int       `json:"limit"`
+}
+
+// handleStartRescore 提交决策质量重评分任务（异步执行，返回任务ID）
+func (s *Server) handleStartRescore(c *gin.Context) {
+	var req RescoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(req.TraderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "Trader不存在: "+req.TraderID)
+		return
+	}
+
+	job, err := trader.StartQualityRescore(experiment.RescoreConfig{
+		Version: req.Version,
+		From:    req.From,
+		To:      req.To,
+		Limit:   req.Limit,
+	})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"job":     job,
+	})
+}
+
+// handleGetRescore 查询重评分任务的进度和结果
+func (s *Server) handleGetRescore(c *gin.Context) {
+	job, ok := experiment.GetRescoreJob(c.Param("id"))
+	if !ok {
+		respondError(c, http.StatusNotFound, "重评分任务不存在: "+c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"job":     job,
+	})
+}
+
+// handleListRescore 重评分任务列表（可按trader_id过滤，不含结果明细）
+func (s *Server) handleListRescore(c *gin.Context) {
+	jobs := experiment.ListRescoreJobs(c.Query("trader_id"))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"jobs":    jobs,
+		"count":   len(jobs),
+	})
+}
+
+// handleQualityVersions 已保存的质量评分版本
+func (s *Server) handleQualityVersions(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	versions, err := trader.QualityVersions()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "查询评分版本失败: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"current_version": decision.QualityRulesVersion,
+		"versions":        versions,
+	})
+}
+
+// handleCompareQualityVersions 对比两个质量评分版本（base默认为recorded，即决策当时记录的评分）
+func (s *Server) handleCompareQualityVersions(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	target := c.Query("target")
+	if target == "" {
+		target = decision.QualityRulesVersion
+	}
+	comparison, err := trader.CompareQualityVersions(c.DefaultQuery("base", experiment.RecordedVersion), target)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"comparison": comparison,
+	})
+}
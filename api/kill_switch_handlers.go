@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"nofx/sharedstate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleKillSwitch 全局停止开关状态
+func (s *Server) handleKillSwitch(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"active":  sharedstate.KillSwitchActive(),
+		"backend": sharedstate.Current().Backend(),
+	})
+}
+
+// handleSetKillSwitch 开启/关闭全局停止开关（使用Redis共享状态时对所有进程生效）
+func (s *Server) handleSetKillSwitch(c *gin.Context) {
+	var req struct {
+		Active *bool `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Active == nil {
+		respondError(c, http.StatusBadRequest, "请求体需要包含 active 字段")
+		return
+	}
+	if err := sharedstate.SetKillSwitch(*req.Active); err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"active":  *req.Active,
+		"backend": sharedstate.Current().Backend(),
+	})
+}
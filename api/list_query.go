@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/database/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 列表接口分页限制
+const (
+	maxPageLimit = 1000 // 单页最大条数
+)
+
+// heavyFields 大文本字段（未在fields中显式请求时不从数据库加载）
+var heavyFields = []string{"system_prompt", "input_prompt", "cot_trace", "entry_reason", "exit_reason"}
+
+// listQuery 列表接口的通用查询参数
+// limit/offset 或 cursor（上一页返回的 X-Next-Cursor）分页；since/until 时间过滤（RFC3339 或 2006-01-02）；
+// success=true/false 过滤；fields=id,timestamp,... 只返回指定字段
+type listQuery struct {
+	repositories.QueryOptions
+	Fields map[string]bool // 为空表示返回全部字段
+}
+
+// parseListQuery 解析列表查询参数（defaultLimit<=0 表示默认不分页）
+func parseListQuery(c *gin.Context, defaultLimit int) (*listQuery, error) {
+	q := &listQuery{}
+	q.Limit = defaultLimit
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("limit 必须为正整数")
+		}
+		q.Limit = limit
+	}
+	if q.Limit > maxPageLimit {
+		q.Limit = maxPageLimit
+	}
+
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("offset 必须为非负整数")
+		}
+		q.Offset = offset
+	}
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || cursor <= 0 {
+			return nil, fmt.Errorf("cursor 无效")
+		}
+		q.BeforeID = cursor
+	}
+
+	var err error
+	if q.Since, err = parseQueryTime(c.Query("since")); err != nil {
+		return nil, fmt.Errorf("since 格式错误: %w", err)
+	}
+	if q.Until, err = parseQueryTime(c.Query("until")); err != nil {
+		return nil, fmt.Errorf("until 格式错误: %w", err)
+	}
+
+	if v := c.Query("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("success 必须为 true 或 false")
+		}
+		q.Success = &success
+	}
+	q.Symbol = strings.ToUpper(c.Query("symbol"))
+
+	if v := c.Query("fields"); v != "" {
+		q.Fields = make(map[string]bool)
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				q.Fields[f] = true
+			}
+		}
+		q.OmitHeavy = true
+		for _, f := range heavyFields {
+			if q.Fields[f] {
+				q.OmitHeavy = false
+			}
+		}
+	}
+	return q, nil
+}
+
+// parseQueryTime 解析时间参数（空字符串返回零值）
+func parseQueryTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, time.Local)
+}
+
+// wants 是否需要返回该字段
+func (q *listQuery) wants(field string) bool {
+	return len(q.Fields) == 0 || q.Fields[field]
+}
+
+// setPageHeaders 设置分页响应头（X-Total-Count 过滤后的总数，X-Next-Cursor 下一页游标）
+func setPageHeaders(c *gin.Context, total int, nextCursor int64) {
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	if nextCursor > 0 {
+		c.Header("X-Next-Cursor", strconv.FormatInt(nextCursor, 10))
+	}
+}
+
+// selectFields 按fields参数裁剪列表中每一项的字段
+func (q *listQuery) selectFields(items interface{}) (interface{}, error) {
+	if len(q.Fields) == 0 {
+		return items, nil
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	selected := make([]map[string]json.RawMessage, len(rows))
+	for i, row := range rows {
+		selected[i] = make(map[string]json.RawMessage, len(q.Fields))
+		for field := range q.Fields {
+			if v, ok := row[field]; ok {
+				selected[i][field] = v
+			}
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleMarketView 引擎对币种的行情视图（market.Data、增强指标、市场情绪和提示词片段）
+func (s *Server) handleMarketView(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		respondError(c, http.StatusBadRequest, "缺少 symbol 参数")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	view, err := trader.GetMarketView(symbol)
+	if err != nil {
+		respondError(c, http.StatusBadGateway, fmt.Sprintf("获取行情视图失败: %v", err))
+		return
+	}
+	// 成交量为0等退化数据可能算出NaN指标，JSON无法编码时返回明确错误
+	if _, err := json.Marshal(view); err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("行情视图包含无法编码的数值: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
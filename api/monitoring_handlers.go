@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleMonitoringMetrics 性能监控指标（风险评分/回撤/VaR/交易频率等）
+func (s *Server) handleMonitoringMetrics(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	metrics, status, err := trader.GetMonitoringMetrics()
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"status":    status,
+		"metrics":   metrics,
+	})
+}
+
+// handleMonitoringAlerts 性能监控预警列表（按时间倒序，limit默认50，unresolved=true只返回未解决的预警）
+func (s *Server) handleMonitoringAlerts(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	alerts, err := trader.GetMonitoringAlerts(0)
+	if err != nil {
+		respondError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	unresolvedOnly := c.Query("unresolved") == "true"
+	filtered := alerts[:0]
+	unresolved := 0
+	for _, alert := range alerts {
+		if !alert.Resolved {
+			unresolved++
+		} else if unresolvedOnly {
+			continue
+		}
+		filtered = append(filtered, alert)
+	}
+	if len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":  traderID,
+		"alerts":     filtered,
+		"unresolved": unresolved,
+	})
+}
+
+// handleResolveMonitoringAlert 将预警标记为已解决
+func (s *Server) handleResolveMonitoringAlert(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req struct {
+		AlertID string `json:"alert_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.AlertID == "" {
+		respondError(c, http.StatusBadRequest, "缺少alert_id参数")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := trader.ResolveMonitoringAlert(req.AlertID); err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	log.Printf("🔔 [%s] 预警已解决: %s", traderID, req.AlertID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("预警 %s 已解决", req.AlertID),
+	})
+}
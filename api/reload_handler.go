@@ -16,10 +16,7 @@ func (s *Server) handleReloadConfig(c *gin.Context) {
 	newConfig, err := database.LoadConfigFromDB()
 	if err != nil {
 		log.Printf("❌ 加载配置失败: %v\n", err)
-		c.JSON(500, gin.H{
-			"success": false,
-			"error":   "加载配置失败: " + err.Error(),
-		})
+		respondError(c, 500, "加载配置失败: "+err.Error())
 		return
 	}
 
@@ -41,10 +38,7 @@ func (s *Server) handleReloadConfig(c *gin.Context) {
 	err = s.traderManager.ReloadConfig(newConfig)
 	if err != nil {
 		log.Printf("❌ 热重载失败: %v\n", err)
-		c.JSON(500, gin.H{
-			"success": false,
-			"error":   "热重载失败: " + err.Error(),
-		})
+		respondError(c, 500, "热重载失败: "+err.Error())
 		return
 	}
 
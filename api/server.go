@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"nofx/database"
 	"nofx/database/models"
+	"nofx/database/repositories"
+	"nofx/i18n"
 	"nofx/manager"
+	"nofx/market"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,11 +28,18 @@ func NewServer(traderManager *manager.TraderManager, port int) *Server {
 	// 设置为Release模式（减少日志输出）
 	gin.SetMode(gin.ReleaseMode)
 
-	router := gin.Default()
+	router := gin.New()
+
+	// 请求ID、请求日志和panic恢复（日志和错误响应都带请求ID）
+	router.Use(requestIDMiddleware(), accessLogMiddleware(), recoveryMiddleware())
+	router.NoRoute(handleNoRoute)
 
 	// 启用CORS
 	router.Use(corsMiddleware())
 
+	// 只读观察者模式（按Token或全局开关禁止修改类请求）
+	router.Use(readOnlyMiddleware())
+
 	s := &Server{
 		router:        router,
 		traderManager: traderManager,
@@ -44,7 +57,8 @@ func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Token, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Next-Cursor, X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusOK)
@@ -68,16 +82,42 @@ func (s *Server) setupRoutes() {
 
 		// Trader列表
 		api.GET("/traders", s.handleTraderList)
+		api.POST("/traders/:id/model", s.handleSwitchAIModel)
+		api.GET("/traders/:id/state", s.handleTraderState)
+		api.POST("/traders/:id/state", s.handleChangeTraderState)
+		api.GET("/traders/:id/preview", s.handleTraderPreview)
+		api.POST("/traders/:id/preview", s.handleRunTraderPreview)
 
 		// 指定trader的数据（使用query参数 ?trader_id=xxx）
 		api.GET("/status", s.handleStatus)
 		api.GET("/account", s.handleAccount)
 		api.GET("/positions", s.handlePositions)
+		api.GET("/positions/exit-history", s.handleExitLevelHistory)
 		api.GET("/decisions", s.handleDecisions)
 		api.GET("/decisions/latest", s.handleLatestDecisions)
+		api.GET("/decisions/:id/explain", s.handleExplainDecision)
+		api.GET("/decisions/diff", s.handleDecisionDiff)
+		api.POST("/decisions/validate", s.handleValidateDecision)
+		api.GET("/trades", s.handleTrades)
+		api.POST("/trades/import", s.handleImportTrades)
 		api.GET("/statistics", s.handleStatistics)
 		api.GET("/equity-history", s.handleEquityHistory)
+		api.GET("/balance-flows", s.handleBalanceFlows)
 		api.GET("/performance", s.handlePerformance)
+		api.GET("/performance/entry-indicators", s.handleEntryIndicatorAttribution)
+		api.GET("/performance/pipeline", s.handlePipelineTimings)
+		api.GET("/analytics/time-of-day", s.handleTimeOfDayAnalytics)
+		api.GET("/analytics/income", s.handleIncomeStatement)
+		api.GET("/candidates/diff", s.handleCandidatePoolDiff)
+		api.GET("/candidates/churn", s.handleCandidateChurn)
+		api.GET("/risk-budget", s.handleRiskBudget)
+		api.GET("/risk", s.handleRiskMetrics)
+		api.GET("/funding-arb", s.handleFundingArb)
+		api.GET("/monitoring/metrics", s.handleMonitoringMetrics)
+		api.GET("/monitoring/alerts", s.handleMonitoringAlerts)
+		api.POST("/monitoring/alerts", s.handleResolveMonitoringAlert)
+		api.GET("/audit", s.handleExchangeAudit)
+		api.GET("/market", s.handleMarketView)
 
 		// Prompt配置相关路由（使用gin格式）
 		api.GET("/prompts", s.handleGetPrompts)
@@ -93,6 +133,12 @@ func (s *Server) setupRoutes() {
 		api.POST("/config/trader/update", s.handleUpdateTraderConfig)
 		api.POST("/config/trader/add", s.handleAddTrader)
 		api.DELETE("/config/trader/delete", s.handleDeleteTrader)
+		api.POST("/config/trader/clone", s.handleCloneTrader)
+		api.GET("/config/templates", s.handleListTraderTemplates)
+		api.POST("/config/templates", s.handleSaveTraderTemplate)
+		api.DELETE("/config/templates/:name", s.handleDeleteTraderTemplate)
+		api.GET("/config/bundle", s.handleExportConfigBundle)
+		api.POST("/config/bundle/restore", s.handleRestoreConfigBundle)
 
 		// 系统运行时配置API（风险阈值、技术指标等可配置参数）
 		api.GET("/system/configs", s.handleGetSystemConfigs)              // 获取所有配置
@@ -100,25 +146,50 @@ func (s *Server) setupRoutes() {
 		api.PUT("/system/configs", s.handleUpdateSystemConfig)            // 更新单个配置
 		api.PUT("/system/configs/batch", s.handleBatchUpdateConfigs)      // 批量更新配置
 		api.POST("/system/configs/:key/reset", s.handleResetConfig)       // 重置配置
+		api.GET("/system/storage", s.handleStorageUsage)                  // 决策历史存储用量
+		api.POST("/system/storage/purge", s.handleStoragePurge)           // 归档清理决策历史
 		
 		// 热重载路由
 		api.POST("/config/reload", s.handleReloadConfig)
 		
 		// 交易控制路由
 		api.POST("/trading/close-position", s.handleManualClosePosition)
+		api.POST("/trading/execute-decision", s.handleExecuteDecision)
 		api.POST("/trading/toggle-trader", s.handleToggleTrader)
+		api.GET("/approvals", s.handleApprovals)
+		api.POST("/approvals/:id/approve", s.handleApproveDecision)
+		api.POST("/approvals/:id/reject", s.handleRejectDecision)
+		api.GET("/kill-switch", s.handleKillSwitch)
+		api.POST("/kill-switch", s.handleSetKillSwitch)
+		api.GET("/sizing/multiplier", s.handleSizeMultiplier)
+		api.POST("/sizing/multiplier", s.handleSetSizeMultiplier)
+		api.GET("/drawdown-lock", s.handleDrawdownLock)
+		api.POST("/drawdown-lock/reset", s.handleResetDrawdownLock)
 		
 		// AI学习总结路由
 		api.POST("/ai-learning/generate", s.handleGenerateAILearning)
 		api.GET("/ai-learning/summary", s.handleGetAILearningSummary)
+		api.GET("/ai-learning/effectiveness", s.handleAILearningEffectiveness)
+		api.POST("/ai-learning/summaries/:id/deactivate", s.handleDeactivateAILearningSummary)
+
+		// prompt/配置改动的离线评估
+		api.POST("/experiments/walkforward", s.handleStartWalkForward)
+		api.GET("/experiments/walkforward", s.handleListWalkForward)
+		api.GET("/experiments/walkforward/:id", s.handleGetWalkForward)
+		api.POST("/experiments/rescore", s.handleStartRescore)
+		api.GET("/experiments/rescore", s.handleListRescore)
+		api.GET("/experiments/rescore/:id", s.handleGetRescore)
+		api.GET("/experiments/quality-versions", s.handleQualityVersions)
+		api.GET("/experiments/quality-versions/compare", s.handleCompareQualityVersions)
 	}
 }
 
 // handleHealth 健康检查
 func (s *Server) handleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"time":   c.Request.Context().Value("time"),
+		"status":    "ok",
+		"time":      c.Request.Context().Value("time"),
+		"read_only": isReadOnlyRequest(c), // 前端据此隐藏交易和配置控件
 	})
 }
 
@@ -136,13 +207,25 @@ func (s *Server) getTraderFromQuery(c *gin.Context) (*manager.TraderManager, str
 	return s.traderManager, traderID, nil
 }
 
-// handleCompetition 竞赛总览（对比所有trader）
+// handleCompetition 竞赛总览（对比所有trader，可按风险调整指标排名）
 func (s *Server) handleCompetition(c *gin.Context) {
-	comparison, err := s.traderManager.GetComparisonData()
+	rankBy, err := manager.ParseRankBy(c.Query("rank_by"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取对比数据失败: %v", err),
-		})
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// 风险调整指标和净值走势的统计窗口（默认最近7天，最多90天）
+	hours := 24 * 7
+	if n, err := strconv.Atoi(c.Query("hours")); err == nil && n > 0 {
+		hours = n
+	}
+	if hours > 24*90 {
+		hours = 24 * 90
+	}
+
+	comparison, err := s.traderManager.GetComparisonData(rankBy, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取对比数据失败: %v", err))
 		return
 	}
 	c.JSON(http.StatusOK, comparison)
@@ -168,13 +251,13 @@ func (s *Server) handleTraderList(c *gin.Context) {
 func (s *Server) handleStatus(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -186,13 +269,13 @@ func (s *Server) handleStatus(c *gin.Context) {
 func (s *Server) handleAccount(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -200,9 +283,7 @@ func (s *Server) handleAccount(c *gin.Context) {
 	account, err := trader.GetAccountInfo()
 	if err != nil {
 		log.Printf("❌ 获取账户信息失败 [%s]: %v", trader.GetName(), err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取账户信息失败: %v", err),
-		})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取账户信息失败: %v", err))
 		return
 	}
 
@@ -219,72 +300,157 @@ func (s *Server) handleAccount(c *gin.Context) {
 func (s *Server) handlePositions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	positions, err := trader.GetPositions()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取持仓列表失败: %v", err),
-		})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取持仓列表失败: %v", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, positions)
 }
 
+// handleExitLevelHistory 持仓止损/止盈调整历史（本次开仓以来）
+func (s *Server) handleExitLevelHistory(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	symbol := c.Query("symbol")
+	side := c.Query("side")
+	if symbol == "" || (side != "long" && side != "short") {
+		respondError(c, http.StatusBadRequest, "缺少symbol参数或side不是long/short")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	changes, err := trader.GetExitLevelHistory(market.Normalize(symbol), side)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取止损止盈调整历史失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":  market.Normalize(symbol),
+		"side":    side,
+		"changes": changes,
+	})
+}
+
 // handleDecisions 决策日志列表
 func (s *Server) handleDecisions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
-	// 获取所有历史决策记录（无限制）
-	records, err := trader.GetDecisionLogger().GetLatestRecords(10000)
+	q, err := parseListQuery(c, 100)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取决策日志失败: %v", err),
-		})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, records)
+	// 按时间倒序分页（每页内从旧到新），未请求decisions字段时不加载决策动作
+	records, total, err := trader.GetDecisionLogger().QueryRecords(q.QueryOptions, q.wants("decisions"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取决策日志失败: %v", err))
+		return
+	}
+
+	var nextCursor int64
+	if q.Limit > 0 && len(records) == q.Limit {
+		nextCursor = records[0].ID
+	}
+	setPageHeaders(c, total, nextCursor)
+
+	result, err := q.selectFields(records)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleTrades 已平仓交易记录（最新的在前，支持分页和过滤）
+func (s *Server) handleTrades(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	q, err := parseListQuery(c, 100)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trades, total, err := trader.GetDecisionLogger().QueryTrades(q.QueryOptions)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取交易记录失败: %v", err))
+		return
+	}
+
+	var nextCursor int64
+	if q.Limit > 0 && len(trades) == q.Limit {
+		nextCursor = trades[len(trades)-1].ID
+	}
+	setPageHeaders(c, total, nextCursor)
+
+	result, err := q.selectFields(trades)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
 }
 
 // handleLatestDecisions 最新决策日志（最近5条，最新的在前）
 func (s *Server) handleLatestDecisions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	records, err := trader.GetDecisionLogger().GetLatestRecords(5)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取决策日志失败: %v", err),
-		})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取决策日志失败: %v", err))
 		return
 	}
 
@@ -297,25 +463,103 @@ func (s *Server) handleLatestDecisions(c *gin.Context) {
 	c.JSON(http.StatusOK, records)
 }
 
+// handleExplainDecision 决策解释（汇总市场快照、Prompt、思维链、校验与质量、执行记录和交易结果）
+func (s *Server) handleExplainDecision(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "无效的决策ID")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	explanation, err := trader.GetDecisionLogger().ExplainDecision(id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取决策解释失败: %v", err))
+		return
+	}
+	if explanation == nil {
+		respondError(c, http.StatusNotFound, fmt.Sprintf("决策记录 %d 不存在", id))
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
+// handleDecisionDiff 对比两个周期的决策和上下文（from/to为决策记录ID，to默认最新周期，from默认to的上一周期）
+func (s *Server) handleDecisionDiff(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var fromID, toID int64
+	if v := c.Query("from"); v != "" {
+		fromID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || fromID <= 0 {
+			respondError(c, http.StatusBadRequest, "无效的from")
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		toID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || toID <= 0 {
+			respondError(c, http.StatusBadRequest, "无效的to")
+			return
+		}
+	}
+	if fromID > 0 && toID > 0 && fromID >= toID {
+		respondError(c, http.StatusBadRequest, "from必须早于to")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	diff, err := trader.GetDecisionLogger().DiffDecisions(fromID, toID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("对比决策失败: %v", err))
+		return
+	}
+	if diff == nil {
+		respondError(c, http.StatusNotFound, "决策记录不存在或没有可对比的上一周期")
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
 // handleStatistics 统计信息
 func (s *Server) handleStatistics(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	stats, err := trader.GetDecisionLogger().GetStatistics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取统计信息失败: %v", err),
-		})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取统计信息失败: %v", err))
 		return
 	}
 
@@ -326,23 +570,32 @@ func (s *Server) handleStatistics(c *gin.Context) {
 func (s *Server) handleEquityHistory(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	q, err := parseListQuery(c, 0)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// 获取尽可能多的历史数据（几天的数据）
-	// 每3分钟一个周期：10000条 = 约20天的数据
-	records, err := trader.GetDecisionLogger().GetLatestRecords(10000)
+	// 每3分钟一个周期：10000条 = 约20天的数据（只需要账户快照，不加载Prompt和决策动作）
+	records, _, err := trader.GetDecisionLogger().QueryRecords(repositories.QueryOptions{
+		Since:     q.Since,
+		Until:     q.Until,
+		Limit:     10000,
+		OmitHeavy: true,
+	}, false)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取历史数据失败: %v", err),
-		})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取历史数据失败: %v", err))
 		return
 	}
 
@@ -351,11 +604,17 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 		Timestamp        string  `json:"timestamp"`
 		TotalEquity      float64 `json:"total_equity"`      // 账户净值（wallet + unrealized）
 		AvailableBalance float64 `json:"available_balance"` // 可用余额
-		TotalPnL         float64 `json:"total_pnl"`         // 总盈亏（相对初始余额）
+		TotalPnL         float64 `json:"total_pnl"`         // 总盈亏（相对净投入：初始余额+截至该点的净入金）
 		TotalPnLPct      float64 `json:"total_pnl_pct"`     // 总盈亏百分比
+		NetFlows         float64 `json:"net_flows"`         // 截至该点的累计净入金（入金 - 出金）
 		PositionCount    int     `json:"position_count"`    // 持仓数量
 		MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
 		CycleNumber      int     `json:"cycle_number"`
+		// 相对上一个数据点的净值变化拆分：已实现部分来自期间平仓的交易，其余为浮动盈亏等变化
+		// （归档的小时级摘要没有逐周期明细，两项均为0）
+		RealizedPnLDelta   float64 `json:"realized_pnl_delta"`
+		UnrealizedPnLDelta float64 `json:"unrealized_pnl_delta"`
+		ClosedTrades       int     `json:"closed_trades"`
 	}
 
 	// 从AutoTrader获取初始余额（用于计算盈亏百分比）
@@ -374,51 +633,130 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 
 	// 如果还是无法获取，返回错误
 	if initialBalance == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "无法获取初始余额",
-		})
+		respondError(c, http.StatusInternalServerError, "无法获取初始余额")
 		return
 	}
 
+	// 资金划转台账：每个点的盈亏扣除截至该点的入金/出金
+	ledger, _, err := trader.GetFlowLedger()
+	if err != nil {
+		log.Printf("⚠️  获取资金划转台账失败: %v", err)
+	}
+	pnlAt := func(ts time.Time, equity float64) (float64, float64, float64) {
+		netFlows := ledger.NetAt(ts)
+		basis := initialBalance + netFlows
+		pnl := equity - basis
+		pct := 0.0
+		if basis > 0 {
+			pct = (pnl / basis) * 100
+		}
+		return pnl, pct, netFlows
+	}
+
 	var history []EquityPoint
-	for _, record := range records {
-		// TotalBalance字段实际存储的是TotalEquity
-		totalEquity := record.AccountState.TotalBalance
-		// TotalUnrealizedProfit字段实际存储的是TotalPnL（相对初始余额）
-		totalPnL := record.AccountState.TotalUnrealizedProfit
 
-		// 计算盈亏百分比
-		totalPnLPct := 0.0
-		if initialBalance > 0 {
-			totalPnLPct = (totalPnL / initialBalance) * 100
+	// 已归档周期保留的小时级净值摘要（在最近记录之前）
+	archived, err := trader.GetDecisionLogger().GetArchivedEquityPoints()
+	if err != nil {
+		log.Printf("⚠️  获取归档净值摘要失败: %v", err)
+	}
+	for _, point := range archived {
+		if (!q.Since.IsZero() && point.Timestamp.Before(q.Since)) || (!q.Until.IsZero() && !point.Timestamp.Before(q.Until)) {
+			continue
 		}
-
+		totalPnL, totalPnLPct, netFlows := pnlAt(point.Timestamp, point.TotalEquity)
 		history = append(history, EquityPoint{
-			Timestamp:        record.Timestamp.Format("2006-01-02 15:04:05"),
-			TotalEquity:      totalEquity,
-			AvailableBalance: record.AccountState.AvailableBalance,
+			Timestamp:        point.Timestamp.Format("2006-01-02 15:04:05"),
+			TotalEquity:      point.TotalEquity,
+			AvailableBalance: point.AvailableBalance,
 			TotalPnL:         totalPnL,
 			TotalPnLPct:      totalPnLPct,
-			PositionCount:    record.AccountState.PositionCount,
-			MarginUsedPct:    record.AccountState.MarginUsedPct,
-			CycleNumber:      record.CycleNumber,
+			NetFlows:         netFlows,
+			PositionCount:    point.PositionCount,
+			MarginUsedPct:    point.MarginUsedPct,
+			CycleNumber:      point.CycleNumber,
 		})
 	}
 
-	c.JSON(http.StatusOK, history)
+	for i, record := range records {
+		// TotalBalance字段实际存储的是TotalEquity
+		totalEquity := record.AccountState.TotalBalance
+		// 按当前净投入重新计算盈亏（记录中保存的TotalPnL可能是划转同步之前按初始余额计算的）
+		totalPnL, totalPnLPct, netFlows := pnlAt(record.Timestamp, totalEquity)
+
+		// 净值变化中扣除本周期已实现盈亏和期间的入金/出金，剩余部分视为浮动盈亏变化
+		unrealizedDelta := 0.0
+		if i > 0 {
+			prev := records[i-1]
+			unrealizedDelta = totalEquity - prev.AccountState.TotalBalance - record.RealizedPnLDelta -
+				(netFlows - ledger.NetAt(prev.Timestamp))
+		}
+
+		history = append(history, EquityPoint{
+			Timestamp:          record.Timestamp.Format("2006-01-02 15:04:05"),
+			TotalEquity:        totalEquity,
+			AvailableBalance:   record.AccountState.AvailableBalance,
+			TotalPnL:           totalPnL,
+			TotalPnLPct:        totalPnLPct,
+			NetFlows:           netFlows,
+			PositionCount:      record.AccountState.PositionCount,
+			MarginUsedPct:      record.AccountState.MarginUsedPct,
+			CycleNumber:        record.CycleNumber,
+			RealizedPnLDelta:   record.RealizedPnLDelta,
+			UnrealizedPnLDelta: unrealizedDelta,
+			ClosedTrades:       record.ClosedTrades,
+		})
+	}
+
+	// 分页从最新的数据往前取（offset跳过最新的N个点），返回结果仍按时间正序
+	total := len(history)
+	end := total - q.Offset
+	if end < 0 {
+		end = 0
+	}
+	start := 0
+	if q.Limit > 0 && end-q.Limit > 0 {
+		start = end - q.Limit
+	}
+	history = history[start:end]
+	setPageHeaders(c, total, 0)
+
+	// currency=reporting：金额按当前指数价格折算为报告币种（历史点也使用当前汇率，百分比不受影响）
+	currency := trader.SettlementAsset()
+	if c.Query("currency") == "reporting" {
+		rate := trader.GetReportingRate()
+		currency = rate.Currency
+		for i := range history {
+			p := &history[i]
+			p.TotalEquity = rate.Convert(p.TotalEquity)
+			p.AvailableBalance = rate.Convert(p.AvailableBalance)
+			p.TotalPnL = rate.Convert(p.TotalPnL)
+			p.NetFlows = rate.Convert(p.NetFlows)
+			p.RealizedPnLDelta = rate.Convert(p.RealizedPnLDelta)
+			p.UnrealizedPnLDelta = rate.Convert(p.UnrealizedPnLDelta)
+		}
+	}
+	c.Header("X-Currency", currency)
+
+	result, err := q.selectFields(history)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, result)
 }
 
 // handlePerformance AI历史表现分析（用于展示AI学习和反思）
 func (s *Server) handlePerformance(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -426,39 +764,321 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	// 假设每3分钟一个周期，100个周期 = 5小时，足够覆盖大部分交易
 	performance, err := trader.GetDecisionLogger().AnalyzePerformance(100)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("分析历史表现失败: %v", err),
-		})
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("分析历史表现失败: %v", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, performance)
 }
 
+// handleEntryIndicatorAttribution 开仓指标归因（按开仓时RSI/量比/MACD方向统计交易表现）
+func (s *Server) handleEntryIndicatorAttribution(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	attribution, err := trader.GetDecisionLogger().AnalyzeEntryIndicators(limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("分析开仓指标归因失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, attribution)
+}
+
+// handleTimeOfDayAnalytics 按开仓时间（UTC小时/星期几）统计的胜率和平均盈亏，含活跃度热力图和弱势/强势时段
+func (s *Server) handleTimeOfDayAnalytics(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	cfg := database.CurrentLearningConfig()
+	limit := cfg.TimeOfDayLookbackTrades
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	minTrades := cfg.TimeOfDayMinTrades
+	if m, err := strconv.Atoi(c.Query("min_trades")); err == nil && m > 0 {
+		minTrades = m
+	}
+
+	report, err := trader.GetDecisionLogger().AnalyzeTimeOfDay(limit, minTrades)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("分析开仓时段表现失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// handleCandidatePoolDiff 候选池相对上一周期的变化
+func (s *Server) handleCandidatePoolDiff(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var recordID int64
+	if v := c.Query("record_id"); v != "" {
+		recordID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || recordID <= 0 {
+			respondError(c, http.StatusBadRequest, "无效的record_id")
+			return
+		}
+	}
+
+	diff, err := trader.GetDecisionLogger().GetCandidatePoolDiff(recordID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// handleCandidateChurn 候选池变动统计
+func (s *Server) handleCandidateChurn(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	cycles := 100
+	if n, err := strconv.Atoi(c.Query("cycles")); err == nil && n > 1 {
+		cycles = n
+	}
+	if cycles > 1000 {
+		cycles = 1000
+	}
+
+	report, err := trader.GetDecisionLogger().AnalyzeCandidateChurn(cycles)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("统计候选池变动失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// handlePipelineTimings 决策流水线各阶段耗时趋势（用于发现性能退化）
+func (s *Server) handlePipelineTimings(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	hours := 24
+	if n, err := strconv.Atoi(c.Query("hours")); err == nil && n > 0 {
+		hours = n
+	}
+	if hours > 24*90 {
+		hours = 24 * 90
+	}
+	bucket := c.DefaultQuery("bucket", "hour")
+	if bucket != "hour" && bucket != "day" {
+		respondError(c, http.StatusBadRequest, "bucket只能为hour或day")
+		return
+	}
+
+	report, err := trader.GetDecisionLogger().AnalyzePipelineTimings(hours, bucket)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("统计流水线耗时失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// handleRiskBudget 日风险预算状态
+func (s *Server) handleRiskBudget(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	status, err := trader.GetRiskBudget()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取风险预算失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// handleRiskMetrics 实时风险指标（含考虑全仓持仓相互影响的账户级强平距离）
+func (s *Server) handleRiskMetrics(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	metrics, err := trader.GetRiskMetrics()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取风险指标失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// handleFundingArb 资金费率套利报告
+func (s *Server) handleFundingArb(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	report, err := trader.GetFundingArbReport()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取资金费率套利报告失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// handleStorageUsage 决策历史存储用量
+func (s *Server) handleStorageUsage(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	usage, err := trader.GetStorageUsage()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("获取存储用量失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// handleStoragePurge 归档并清理 days 天前的决策历史
+// 参数：days（必填）、archive（默认true，false时直接删除）、vacuum（默认false，清理后压缩数据库文件）
+func (s *Server) handleStoragePurge(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days <= 0 {
+		respondError(c, http.StatusBadRequest, "days 参数必须为正整数")
+		return
+	}
+	archive := c.DefaultQuery("archive", "true") != "false"
+	vacuum := c.Query("vacuum") == "true"
+
+	result, err := trader.PurgeDecisionHistory(days, archive, vacuum)
+	if err != nil {
+		respondErrorDetails(c, http.StatusInternalServerError, fmt.Sprintf("清理决策历史失败: %v", err), gin.H{"result": result})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // handleGetPrompts 获取prompt配置
 func (s *Server) handleGetPrompts(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	db := trader.GetDecisionLogger().GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
 	configs, err := db.Config().GetAll()
 	if err != nil {
 		log.Printf("获取prompt配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置失败"})
+		respondError(c, http.StatusInternalServerError, "获取配置失败")
 		return
 	}
 
@@ -472,13 +1092,13 @@ func (s *Server) handleGetPrompts(c *gin.Context) {
 func (s *Server) handleUpdatePrompt(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -487,12 +1107,18 @@ func (s *Server) handleUpdatePrompt(c *gin.Context) {
 		Title        string `json:"title"`
 		Content      string `json:"content"`
 		PromptType   string `json:"prompt_type"`
+		Language     string `json:"language"` // 模板语言（空=zh）
 		Enabled      bool   `json:"enabled"`
 		DisplayOrder int    `json:"display_order"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := i18n.Validate(req.Language); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -503,7 +1129,7 @@ func (s *Server) handleUpdatePrompt(c *gin.Context) {
 
 	// 验证prompt_type
 	if req.PromptType != "system" && req.PromptType != "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt_type must be 'system' or 'user'"})
+		respondError(c, http.StatusBadRequest, "prompt_type must be 'system' or 'user'")
 		return
 	}
 
@@ -514,18 +1140,18 @@ func (s *Server) handleUpdatePrompt(c *gin.Context) {
 
 	// 验证prompt_type
 	if req.PromptType != "system" && req.PromptType != "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt_type must be 'system' or 'user'"})
+		respondError(c, http.StatusBadRequest, "prompt_type must be 'system' or 'user'")
 		return
 	}
 
 	if req.SectionName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "section_name is required"})
+		respondError(c, http.StatusBadRequest, "section_name is required")
 		return
 	}
 
 	db := trader.GetDecisionLogger().GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
@@ -534,13 +1160,14 @@ func (s *Server) handleUpdatePrompt(c *gin.Context) {
 		Title:        req.Title,
 		Content:      req.Content,
 		PromptType:   req.PromptType,
+		Language:     string(i18n.Parse(req.Language)),
 		Enabled:      req.Enabled,
 		DisplayOrder: req.DisplayOrder,
 	}
 
 	if err := db.Config().Update(cfg); err != nil {
 		log.Printf("更新prompt配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败"})
+		respondError(c, http.StatusInternalServerError, "更新配置失败")
 		return
 	}
 
@@ -556,13 +1183,13 @@ func (s *Server) handleUpdatePrompt(c *gin.Context) {
 func (s *Server) handleTogglePrompt(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -572,19 +1199,19 @@ func (s *Server) handleTogglePrompt(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	db := trader.GetDecisionLogger().GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
 	configs, err := db.Config().GetAll()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取配置失败"})
+		respondError(c, http.StatusInternalServerError, "获取配置失败")
 		return
 	}
 
@@ -593,7 +1220,7 @@ func (s *Server) handleTogglePrompt(c *gin.Context) {
 		if cfg.SectionName == req.SectionName {
 			cfg.Enabled = req.Enabled
 			if err := db.Config().Update(cfg); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败"})
+				respondError(c, http.StatusInternalServerError, "更新配置失败")
 				return
 			}
 			found = true
@@ -602,7 +1229,7 @@ func (s *Server) handleTogglePrompt(c *gin.Context) {
 	}
 
 	if !found {
-		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
+		respondError(c, http.StatusNotFound, "配置不存在")
 		return
 	}
 
@@ -622,19 +1249,19 @@ func (s *Server) handleTogglePrompt(c *gin.Context) {
 func (s *Server) handlePreviewPrompt(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	db := trader.GetDecisionLogger().GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
@@ -657,12 +1284,13 @@ func (s *Server) handlePreviewPrompt(c *gin.Context) {
 	actualMaxAlt := baseMaxAlt * 0.85
 
 	// 预览时默认使用限制模式（false），展示完整规则
-	prompt := db.BuildSystemPromptFromDB(accountEquity, btcLeverage, altLeverage, actualMaxBTC, actualMaxAlt, false)
+	prompt := db.BuildSystemPromptFromDB(trader.PromptLanguage(), accountEquity, btcLeverage, altLeverage, actualMaxBTC, actualMaxAlt, false)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
 			"prompt":         prompt,
+			"language":       trader.PromptLanguage(),
 			"account_equity": accountEquity,
 			"btc_leverage":   btcLeverage,
 			"alt_leverage":   altLeverage,
@@ -675,21 +1303,74 @@ func (s *Server) Start() error {
 	addr := fmt.Sprintf(":%d", s.port)
 	log.Printf("🌐 API服务器启动在 http://localhost%s", addr)
 	log.Printf("📊 API文档:")
-	log.Printf("  • GET  /api/competition      - 竞赛总览（对比所有trader）")
+	log.Printf("  • GET  /api/competition      - 竞赛总览（对比所有trader，?rank_by=pnl|sharpe|calmar|pnl_drawdown|consistency&hours=168）")
 	log.Printf("  • GET  /api/traders          - Trader列表")
+	log.Printf("  • POST /api/traders/:id/model - 运行时切换AI模型（body: ai_model, api_key, custom_api_url, model_name；先做连通性测试）")
+	log.Printf("  • GET  /api/traders/:id/state - 当前运行状态（running/paused/risk_stopped/close_only/stopped）及原因")
+	log.Printf("  • POST /api/traders/:id/state - 切换运行状态（body: state, reason, duration_minutes；非法转换返回409）")
+	log.Printf("  • GET  /api/traders/:id/preview - 最近一次预览计划（计划订单：数量/价格/止损止盈/杠杆）")
+	log.Printf("  • POST /api/traders/:id/preview - 立即执行一次预览（获取行情、调用AI、验证，不下单）")
 	log.Printf("  • GET  /api/status?trader_id=xxx     - 指定trader的系统状态")
 	log.Printf("  • GET  /api/account?trader_id=xxx    - 指定trader的账户信息")
 	log.Printf("  • GET  /api/positions?trader_id=xxx  - 指定trader的持仓列表")
-	log.Printf("  • GET  /api/decisions?trader_id=xxx  - 指定trader的决策日志")
+	log.Printf("  • GET  /api/positions/exit-history?trader_id=xxx&symbol=BTCUSDT&side=long - 持仓止损/止盈调整历史")
+	log.Printf("  • GET  /api/decisions?trader_id=xxx  - 指定trader的决策日志（limit/offset/cursor/since/until/success/fields）")
 	log.Printf("  • GET  /api/decisions/latest?trader_id=xxx - 指定trader的最新决策")
+	log.Printf("  • GET  /api/decisions/:id/explain?trader_id=xxx - 决策解释（Prompt/思维链/质量/执行/结果）")
+	log.Printf("  • GET  /api/decisions/diff?trader_id=xxx&from=&to= - 对比两个周期的决策（持仓进出/信心度变化/候选池变化，默认最新周期与上一周期）")
+	log.Printf("  • POST /api/decisions/validate - 决策干跑验证（实时上下文验证+质量评估，不下单）")
 	log.Printf("  • GET  /api/statistics?trader_id=xxx - 指定trader的统计信息")
-	log.Printf("  • GET  /api/equity-history?trader_id=xxx - 指定trader的收益率历史数据")
+	log.Printf("  • GET  /api/trades?trader_id=xxx     - 已平仓交易记录（limit/offset/cursor/since/until/success/symbol/fields）")
+	log.Printf("  • POST /api/trades/import?trader_id=xxx - 从交易所历史成交导入交易记录（body: symbols, limit；默认主流币种）")
+	log.Printf("  • GET  /api/equity-history?trader_id=xxx - 指定trader的收益率历史数据（limit/offset/since/until/fields，currency=reporting按报告币种折算）")
+	log.Printf("  • GET  /api/balance-flows?trader_id=xxx - 入金/出金台账（累计净入金和净投入，盈亏与回撤按净投入计算）")
 	log.Printf("  • GET  /api/performance?trader_id=xxx - 指定trader的AI学习表现分析")
+	log.Printf("  • GET  /api/performance/entry-indicators?trader_id=xxx - 开仓指标归因分析")
+	log.Printf("  • GET  /api/analytics/time-of-day?trader_id=xxx&limit=200 - 按开仓小时/星期几的胜率和平均盈亏（热力图、弱势/强势时段）")
+	log.Printf("  • GET  /api/analytics/income?trader_id=xxx&months=12 - 月度收益表（已实现盈亏/资金费/手续费/清算费，与系统记录的盈亏对账）")
+	log.Printf("  • GET  /api/candidates/diff?trader_id=xxx&record_id=123 - 候选池相对上一周期新增/移出的币种（默认最新周期）")
+	log.Printf("  • GET  /api/performance/pipeline?trader_id=xxx&hours=24&bucket=hour - 决策流水线各阶段耗时趋势（行情/Prompt/AI/解析验证/执行/写库）")
+	log.Printf("  • GET  /api/candidates/churn?trader_id=xxx&cycles=100 - 候选池变动统计（变动比例/反复进出的币种/是否抖动）")
+	log.Printf("  • GET  /api/risk-budget?trader_id=xxx - 日风险预算台账（占用/释放/剩余）")
+	log.Printf("  • GET  /api/risk?trader_id=xxx - 实时风险指标（含账户级强平距离和强平情景）")
+	log.Printf("  • GET  /api/funding-arb?trader_id=xxx - 资金费率套利仓位与资金费收入")
+	log.Printf("  • GET  /api/monitoring/metrics?trader_id=xxx - 性能监控指标（风险评分/回撤/VaR/交易频率）")
+	log.Printf("  • GET  /api/monitoring/alerts?trader_id=xxx - 性能监控预警列表（limit/unresolved）")
+	log.Printf("  • POST /api/monitoring/alerts?trader_id=xxx - 解决预警（body: alert_id）")
+	log.Printf("  • GET  /api/audit?trader_id=xxx - 交易所请求审计（下单/改杠杆/止损止盈/撤单的请求、返回和耗时）")
+	log.Printf("  • GET  /api/market?trader_id=xxx&symbol=BTCUSDT - 引擎计算的行情视图（实时+最近一次AI决策使用的数据及提示词片段）")
+	log.Printf("  • GET  /api/system/storage?trader_id=xxx - 决策历史存储用量（数据库/归档文件/各表行数）")
+	log.Printf("  • POST /api/system/storage/purge?trader_id=xxx&days=30 - 归档并清理旧决策历史")
+	log.Printf("  • GET  /api/ai-learning/effectiveness?trader_id=xxx - AI学习总结各版本生效前后的胜率/盈亏对比")
+	log.Printf("  • POST /api/ai-learning/summaries/:id/deactivate?trader_id=xxx - 停用指定的AI学习总结")
+	log.Printf("  • POST /api/trading/execute-decision - 手动注入决策（经验证后执行，记录为manual）")
+	log.Printf("  • GET  /api/approvals?trader_id=xxx - 大额交易审批队列")
+	log.Printf("  • POST /api/approvals/:id/approve?trader_id=xxx - 审批通过（重新验证后执行）")
+	log.Printf("  • POST /api/approvals/:id/reject?trader_id=xxx - 拒绝待审批决策")
+	log.Printf("  • GET  /api/kill-switch           - 全局停止开关状态")
+	log.Printf("  • POST /api/kill-switch           - 开启/关闭全局停止开关（body: active，Redis共享时对所有进程生效）")
+	log.Printf("  • GET  /api/sizing/multiplier     - 压力测试缩放系数")
+	log.Printf("  • POST /api/sizing/multiplier     - 设置压力测试缩放系数（body: multiplier，(0,1]，下单前缩小所有开仓金额）")
+	log.Printf("  • GET  /api/drawdown-lock         - 回撤锁状态（净值峰值、当前回撤、是否只平仓）")
+	log.Printf("  • POST /api/drawdown-lock/reset   - 人工解除回撤锁（?trader_id=xxx，峰值重置为当前净值）")
+	log.Printf("  • POST /api/config/trader/clone  - 复制Trader或按模板创建（body: source_trader_id|template, id, name；不复制API密钥）")
+	log.Printf("  • GET  /api/config/templates     - Trader配置模板列表")
+	log.Printf("  • POST /api/config/templates     - 保存命名模板（body: name, description, source_trader_id|config）")
+	log.Printf("  • DELETE /api/config/templates/:name - 删除模板")
+	log.Printf("  • GET  /api/config/bundle?trader_id=xxx - 导出配置备份（Trader参数/风控/Prompt/系统配置，不含密钥；system=false不含系统配置）")
+	log.Printf("  • POST /api/config/bundle/restore - 恢复配置备份（body: bundle, trader_id, name, trader, prompts, system_configs；目标不存在时新建）")
 	log.Printf("  • GET  /api/prompts?trader_id=xxx    - 获取Prompt配置")
 	log.Printf("  • POST /api/prompts/update?trader_id=xxx - 更新Prompt配置")
 	log.Printf("  • POST /api/prompts/toggle?trader_id=xxx - 切换Prompt启用状态")
 	log.Printf("  • GET  /api/prompts/preview?trader_id=xxx - 预览完整Prompt")
-	log.Printf("  • GET  /health               - 健康检查")
+	log.Printf("  • POST /api/experiments/walkforward - 提交walk-forward评估（body: trader_id, from, to, symbols, candidate改动；异步执行）")
+	log.Printf("  • GET  /api/experiments/walkforward/:id - 查询评估进度和结果（盈亏/胜率差异的显著性检验）")
+	log.Printf("  • POST /api/experiments/rescore - 按当前质量评分规则重新评分历史决策（body: trader_id, version, from, to, limit；异步执行）")
+	log.Printf("  • GET  /api/experiments/rescore/:id - 查询重评分进度和结果")
+	log.Printf("  • GET  /api/experiments/quality-versions?trader_id=xxx - 已保存的质量评分版本")
+	log.Printf("  • GET  /api/experiments/quality-versions/compare?trader_id=xxx&base=recorded&target=v1 - 对比两个评分版本的分数和等级分布")
+	log.Printf("  • GET  /health               - 健康检查（read_only表示当前Token是否为只读观察者）")
+	log.Printf("  ⚠️  错误响应统一为 {success:false, code, message, details, request_id}，响应头 X-Request-ID 与日志中的请求ID对应")
 	log.Println()
 
 	return s.router.Run(addr)
@@ -699,13 +1380,13 @@ func (s *Server) Start() error {
 func (s *Server) handleAddPrompt(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -714,23 +1395,29 @@ func (s *Server) handleAddPrompt(c *gin.Context) {
 		Title        string `json:"title"`
 		Content      string `json:"content"`
 		PromptType   string `json:"prompt_type"`
+		Language     string `json:"language"` // 模板语言（空=zh）
 		Enabled      bool   `json:"enabled"`
 		DisplayOrder int    `json:"display_order"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := i18n.Validate(req.Language); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if req.SectionName == "" || req.Title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "section_name and title are required"})
+		respondError(c, http.StatusBadRequest, "section_name and title are required")
 		return
 	}
 
 	db := trader.GetDecisionLogger().GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
@@ -738,7 +1425,7 @@ func (s *Server) handleAddPrompt(c *gin.Context) {
 	existing, _ := db.Config().GetAll()
 	for _, cfg := range existing {
 		if cfg.SectionName == req.SectionName {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "该section_name已存在"})
+			respondError(c, http.StatusConflict, "该section_name已存在")
 			return
 		}
 	}
@@ -760,12 +1447,13 @@ func (s *Server) handleAddPrompt(c *gin.Context) {
 		Title:        req.Title,
 		Content:      req.Content,
 		PromptType:   req.PromptType,
+		Language:     string(i18n.Parse(req.Language)),
 		Enabled:      req.Enabled,
 		DisplayOrder: req.DisplayOrder,
 	}
 	if err := db.Config().Insert(cfg); err != nil{
 		log.Printf("添加prompt配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加配置失败"})
+		respondError(c, http.StatusInternalServerError, "添加配置失败")
 		return
 	}
 
@@ -781,25 +1469,25 @@ func (s *Server) handleAddPrompt(c *gin.Context) {
 func (s *Server) handleDeletePrompt(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
 	sectionName := c.Query("section_name")
 	if sectionName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "section_name is required"})
+		respondError(c, http.StatusBadRequest, "section_name is required")
 		return
 	}
 
 	db := trader.GetDecisionLogger().GetDB()
 	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库未初始化"})
+		respondError(c, http.StatusInternalServerError, "数据库未初始化")
 		return
 	}
 
@@ -807,12 +1495,12 @@ func (s *Server) handleDeletePrompt(c *gin.Context) {
 	rows, err := db.Config().Delete(sectionName)
 	if err != nil {
 		log.Printf("删除prompt配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除配置失败"})
+		respondError(c, http.StatusInternalServerError, "删除配置失败")
 		return
 	}
 
 	if rows == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "配置不存在"})
+		respondError(c, http.StatusNotFound, "配置不存在")
 		return
 	}
 
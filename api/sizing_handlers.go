@@ -0,0 +1,54 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"nofx/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleSizeMultiplier 压力测试缩放系数（所有trader共用）
+func (s *Server) handleSizeMultiplier(c *gin.Context) {
+	multiplier := 1.0
+	if rc := database.GetGlobalConfig(); rc != nil {
+		multiplier = rc.GetSizingConfig().PositionMultiplier
+	}
+	c.JSON(http.StatusOK, gin.H{"multiplier": multiplier})
+}
+
+// handleSetSizeMultiplier 设置压力测试缩放系数（下单前按比例缩小所有开仓金额，不修改AI提示词）
+func (s *Server) handleSetSizeMultiplier(c *gin.Context) {
+	var req struct {
+		Multiplier *float64 `json:"multiplier"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Multiplier == nil {
+		respondError(c, http.StatusBadRequest, "请求体需要包含 multiplier 字段")
+		return
+	}
+	if *req.Multiplier <= 0 || *req.Multiplier > 1 {
+		respondError(c, http.StatusBadRequest, "multiplier 必须在 (0, 1] 之间")
+		return
+	}
+
+	systemConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "连接系统数据库失败")
+		return
+	}
+	defer systemConn.Close()
+
+	helper := database.NewConfigHelper(systemConn.DB())
+	if err := helper.SetFloat(database.SizingPositionMultiplierKey, *req.Multiplier,
+		"压力测试缩放系数：下单前按比例缩小所有开仓金额(0-1]，不修改AI提示词，1表示不缩放", "sizing"); err != nil {
+		respondError(c, http.StatusInternalServerError, "更新配置失败: "+err.Error())
+		return
+	}
+	database.ReloadGlobalConfig()
+
+	log.Printf("🧪 压力测试缩放系数已设置为 ×%.2f", *req.Multiplier)
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"multiplier": *req.Multiplier,
+	})
+}
@@ -15,7 +15,7 @@ func (s *Server) handleGetSystemConfigs(c *gin.Context) {
 	// 获取系统数据库连接
 	systemConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "连接系统数据库失败"})
+		respondError(c, http.StatusInternalServerError, "连接系统数据库失败")
 		return
 	}
 	defer systemConn.Close()
@@ -35,7 +35,7 @@ func (s *Server) handleGetSystemConfigs(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询配置失败"})
+		respondError(c, http.StatusInternalServerError, "查询配置失败")
 		return
 	}
 	defer rows.Close()
@@ -48,7 +48,7 @@ func (s *Server) handleGetSystemConfigs(c *gin.Context) {
 		}
 		configs = append(configs, gin.H{
 			"key":         key,
-			"value":       value,
+			"value":       redactConfigValue(c, key, value),
 			"description": description,
 			"type":        cfgType,
 			"updated_at":  updatedAt,
@@ -69,14 +69,14 @@ func (s *Server) handleUpdateSystemConfig(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数"})
+		respondError(c, http.StatusBadRequest, "无效的请求参数")
 		return
 	}
 
 	// 获取系统数据库连接
 	systemConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "连接系统数据库失败"})
+		respondError(c, http.StatusInternalServerError, "连接系统数据库失败")
 		return
 	}
 	defer systemConn.Close()
@@ -88,7 +88,7 @@ func (s *Server) handleUpdateSystemConfig(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("更新配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败"})
+		respondError(c, http.StatusInternalServerError, "更新配置失败")
 		return
 	}
 
@@ -113,14 +113,14 @@ func (s *Server) handleBatchUpdateConfigs(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数"})
+		respondError(c, http.StatusBadRequest, "无效的请求参数")
 		return
 	}
 
 	// 获取系统数据库连接
 	systemConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "连接系统数据库失败"})
+		respondError(c, http.StatusInternalServerError, "连接系统数据库失败")
 		return
 	}
 	defer systemConn.Close()
@@ -128,7 +128,7 @@ func (s *Server) handleBatchUpdateConfigs(c *gin.Context) {
 	// 开始事务
 	tx, err := systemConn.DB().Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "开始事务失败"})
+		respondError(c, http.StatusInternalServerError, "开始事务失败")
 		return
 	}
 	defer tx.Rollback()
@@ -141,14 +141,14 @@ func (s *Server) handleBatchUpdateConfigs(c *gin.Context) {
 
 		if err != nil {
 			log.Printf("更新配置失败 [%s]: %v", cfg.Key, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配置失败"})
+			respondError(c, http.StatusInternalServerError, "更新配置失败")
 			return
 		}
 	}
 
 	// 提交事务
 	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交事务失败"})
+		respondError(c, http.StatusInternalServerError, "提交事务失败")
 		return
 	}
 
@@ -171,7 +171,7 @@ func (s *Server) handleGetConfigByType(c *gin.Context) {
 	// 获取系统数据库连接
 	systemConn, err := database.NewSystemConnection()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "连接系统数据库失败"})
+		respondError(c, http.StatusInternalServerError, "连接系统数据库失败")
 		return
 	}
 	defer systemConn.Close()
@@ -179,9 +179,12 @@ func (s *Server) handleGetConfigByType(c *gin.Context) {
 	helper := database.NewConfigHelper(systemConn.DB())
 	configs, err := helper.GetAllByType(configType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询配置失败"})
+		respondError(c, http.StatusInternalServerError, "查询配置失败")
 		return
 	}
+	for key, value := range configs {
+		configs[key] = redactConfigValue(c, key, value)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -195,7 +198,5 @@ func (s *Server) handleResetConfig(c *gin.Context) {
 	_ = c.Param("key") // 配置键名
 
 	// 这里应该有一个默认值映射，为了简化先返回错误
-	c.JSON(http.StatusNotImplemented, gin.H{
-		"error": "重置功能尚未实现，请手动修改配置值",
-	})
+	respondError(c, http.StatusNotImplemented, "重置功能尚未实现，请手动修改配置值")
 }
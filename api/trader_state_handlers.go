@@ -0,0 +1,90 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"nofx/trader"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleTraderState 当前生效的运行状态（含原因、来源和风控停止到期时间）
+func (s *Server) handleTraderState(c *gin.Context) {
+	t, err := s.traderManager.GetTrader(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, t.State())
+}
+
+// handleChangeTraderState 切换运行状态（running/paused/risk_stopped/close_only，非法转换返回409）
+func (s *Server) handleChangeTraderState(c *gin.Context) {
+	traderID := c.Param("id")
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var req trader.StateChange
+	if err := c.ShouldBindJSON(&req); err != nil || req.State == "" {
+		respondError(c, http.StatusBadRequest, "请求体需要包含 state 字段（running/paused/risk_stopped/close_only）")
+		return
+	}
+
+	log.Printf("🔀 收到状态切换请求: Trader=%s, State=%s %s", traderID, req.State, req.Reason)
+	status, err := t.ChangeState(req)
+	if err != nil {
+		log.Printf("❌ Trader %s 切换状态失败: %v", traderID, err)
+		respondError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"trader":  traderID,
+		"state":   status,
+	})
+}
+
+// handleTraderPreview 最近一次预览计划（预览模式周期或API触发，没有时返回404）
+func (s *Server) handleTraderPreview(c *gin.Context) {
+	t, err := s.traderManager.GetTrader(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	plan := t.LastPreview()
+	if plan == nil {
+		respondError(c, http.StatusNotFound, "暂无预览计划")
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// handleRunTraderPreview 立即执行一次预览：获取行情、调用AI、验证并返回计划订单，不下单
+func (s *Server) handleRunTraderPreview(c *gin.Context) {
+	traderID := c.Param("id")
+	t, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	log.Printf("🔍 收到预览请求: Trader=%s", traderID)
+	plan, err := t.PreviewDecisions()
+	if err != nil {
+		log.Printf("❌ Trader %s 预览失败: %v", traderID, err)
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": plan.Error == "",
+		"trader":  traderID,
+		"plan":    plan,
+	})
+}
@@ -0,0 +1,404 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/config"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/database/repositories"
+	"nofx/i18n"
+	"nofx/market"
+	"nofx/mcp"
+	"nofx/pool"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// traderTemplateConfig 模板/克隆中可复制的Trader参数（不含API密钥、私钥、钱包地址和币种池认证头）
+type traderTemplateConfig struct {
+	AIModel                string  `json:"ai_model"`
+	Exchange               string  `json:"exchange"`
+	HyperliquidTestnet     bool    `json:"hyperliquid_testnet"`
+	CustomAPIURL           string  `json:"custom_api_url,omitempty"`
+	CustomModelName        string  `json:"custom_model_name,omitempty"`
+	InitialBalance         float64 `json:"initial_balance"`
+	ScanIntervalMinutes    int     `json:"scan_interval_minutes"`
+	MaxPositions           int     `json:"max_positions"`
+	BTCETHLeverage         int     `json:"btc_eth_leverage"`
+	AltcoinLeverage        int     `json:"altcoin_leverage"`
+	MaxDailyLoss           float64 `json:"max_daily_loss"`
+	MaxDrawdown            float64 `json:"max_drawdown"`
+	StopTradingMinutes     int     `json:"stop_trading_minutes"`
+	EnableAILearning       bool    `json:"enable_ai_learning"`
+	AILearnInterval        int     `json:"ai_learn_interval"`
+	AIAutonomyMode         bool    `json:"ai_autonomy_mode"`
+	CompactMode            bool    `json:"compact_mode"`
+	CoinSource             string  `json:"coin_source,omitempty"`
+	CoinPoolAPIURL         string  `json:"coin_pool_api_url,omitempty"`
+	OITopAPIURL            string  `json:"oi_top_api_url,omitempty"`
+	CoinPoolRefreshSeconds int     `json:"coin_pool_refresh_seconds,omitempty"`
+	MarketDataSource       string  `json:"market_data_source,omitempty"`
+	PromptLanguage         string  `json:"prompt_language,omitempty"`
+
+	AIParams *mcp.GenerationParams `json:"ai_params,omitempty"`
+	Critic   *config.CriticConfig  `json:"critic,omitempty"`
+}
+
+// templateConfigFromTrader 提取Trader中可复制的参数
+func templateConfigFromTrader(t *models.TraderConfig) traderTemplateConfig {
+	return traderTemplateConfig{
+		AIModel:                t.AIModel,
+		Exchange:               t.Exchange,
+		HyperliquidTestnet:     t.HyperliquidTestnet,
+		CustomAPIURL:           t.CustomAPIURL,
+		CustomModelName:        t.CustomModelName,
+		InitialBalance:         t.InitialBalance,
+		ScanIntervalMinutes:    t.ScanIntervalMinutes,
+		MaxPositions:           t.MaxPositions,
+		BTCETHLeverage:         t.BTCETHLeverage,
+		AltcoinLeverage:        t.AltcoinLeverage,
+		MaxDailyLoss:           t.MaxDailyLoss,
+		MaxDrawdown:            t.MaxDrawdown,
+		StopTradingMinutes:     t.StopTradingMinutes,
+		EnableAILearning:       t.EnableAILearning,
+		AILearnInterval:        t.AILearnInterval,
+		AIAutonomyMode:         t.AIAutonomyMode,
+		CompactMode:            t.CompactMode,
+		CoinSource:             t.CoinSource,
+		CoinPoolAPIURL:         t.CoinPoolAPIURL,
+		OITopAPIURL:            t.OITopAPIURL,
+		CoinPoolRefreshSeconds: t.CoinPoolRefreshSeconds,
+		MarketDataSource:       t.MarketDataSource,
+		PromptLanguage:         t.PromptLanguage,
+		AIParams:               templateAIParams(t.AIParams),
+		Critic:                 templateCritic(t.Critic),
+	}
+}
+
+// templateAIParams 解析Trader保存的AI生成参数（为空或无效时不复制）
+func templateAIParams(raw string) *mcp.GenerationParams {
+	params, err := mcp.ParseGenerationParams(raw)
+	if err != nil || params.IsZero() {
+		return nil
+	}
+	return &params
+}
+
+// templateCritic 解析Trader保存的审核模型配置（为空或无效时不复制）
+func templateCritic(raw string) *config.CriticConfig {
+	critic, err := config.ParseCriticConfig(raw)
+	if err != nil {
+		return nil
+	}
+	return critic
+}
+
+// toTrader 按模板参数生成新的Trader配置
+// 新Trader不含任何密钥，默认不启用，需要通过 /api/config/trader/update 填写密钥后再启用
+func (tc traderTemplateConfig) toTrader(traderID, name string) *models.TraderConfig {
+	t := &models.TraderConfig{
+		UserID:   0, // 系统默认
+		TraderID: traderID,
+		Name:     name,
+		Enabled:  false,
+	}
+	tc.applyTo(t)
+	return t
+}
+
+// applyTo 把模板参数写入Trader配置（保留ID、名称、启用状态、API密钥、钱包和币种池认证头）
+func (tc traderTemplateConfig) applyTo(t *models.TraderConfig) {
+	t.AIModel = tc.AIModel
+	t.Exchange = tc.Exchange
+	t.HyperliquidTestnet = tc.HyperliquidTestnet
+	t.CustomAPIURL = tc.CustomAPIURL
+	t.CustomModelName = tc.CustomModelName
+	t.InitialBalance = tc.InitialBalance
+	t.ScanIntervalMinutes = tc.ScanIntervalMinutes
+	t.MaxPositions = tc.MaxPositions
+	t.BTCETHLeverage = tc.BTCETHLeverage
+	t.AltcoinLeverage = tc.AltcoinLeverage
+	t.MaxDailyLoss = tc.MaxDailyLoss
+	t.MaxDrawdown = tc.MaxDrawdown
+	t.StopTradingMinutes = tc.StopTradingMinutes
+	t.EnableAILearning = tc.EnableAILearning
+	t.AILearnInterval = tc.AILearnInterval
+	t.AIAutonomyMode = tc.AIAutonomyMode
+	t.CompactMode = tc.CompactMode
+	t.CoinSource = tc.CoinSource
+	t.CoinPoolAPIURL = tc.CoinPoolAPIURL
+	t.OITopAPIURL = tc.OITopAPIURL
+	t.CoinPoolRefreshSeconds = tc.CoinPoolRefreshSeconds
+	t.MarketDataSource = tc.MarketDataSource
+	t.PromptLanguage = tc.PromptLanguage
+	t.AIParams = tc.AIParams.JSON()
+	t.Critic = tc.Critic.JSON()
+}
+
+// validate 检查模板参数是否足以创建Trader
+func (tc traderTemplateConfig) validate() error {
+	if tc.AIModel == "" || tc.Exchange == "" {
+		return fmt.Errorf("ai_model和exchange不能为空")
+	}
+	if tc.InitialBalance <= 0 {
+		return fmt.Errorf("initial_balance必须大于0")
+	}
+	if tc.ScanIntervalMinutes <= 0 {
+		return fmt.Errorf("scan_interval_minutes必须大于0")
+	}
+	if _, err := pool.NewCandidateSource(tc.CoinSource, 0, nil); err != nil {
+		return err
+	}
+	if _, err := market.NewProvider(tc.MarketDataSource); err != nil {
+		return err
+	}
+	if err := i18n.Validate(tc.PromptLanguage); err != nil {
+		return err
+	}
+	if tc.AIParams != nil {
+		if err := tc.AIParams.Validate(); err != nil {
+			return err
+		}
+	}
+	if tc.Critic != nil {
+		if err := tc.Critic.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// traderTemplateView 模板的API返回格式
+type traderTemplateView struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Config      traderTemplateConfig `json:"config"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+}
+
+// newTraderTemplateView 解析模板
+func newTraderTemplateView(t *models.TraderTemplate) (traderTemplateView, error) {
+	view := traderTemplateView{
+		Name:        t.Name,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   t.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if err := json.Unmarshal([]byte(t.ConfigJSON), &view.Config); err != nil {
+		return view, fmt.Errorf("解析模板 %s 失败: %w", t.Name, err)
+	}
+	return view, nil
+}
+
+// handleCloneTrader 复制已有Trader（或按命名模板）创建新Trader，不复制API密钥
+func (s *Server) handleCloneTrader(c *gin.Context) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	var req struct {
+		SourceTraderID string `json:"source_trader_id"` // 复制来源Trader（与template二选一）
+		Template       string `json:"template"`         // 模板名称
+		ID             string `json:"id" binding:"required"`
+		Name           string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, "请求参数错误")
+		return
+	}
+	if (req.SourceTraderID == "") == (req.Template == "") {
+		respondError(c, 400, "source_trader_id和template必须且只能指定一个")
+		return
+	}
+
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
+		return
+	}
+	defer sysConn.Close()
+
+	traderRepo := repositories.NewTraderConfigRepository(sysConn.DB())
+
+	// 检查ID是否已存在
+	if _, err := traderRepo.GetByTraderID(req.ID); err == nil {
+		respondError(c, 409, "Trader ID已存在")
+		return
+	}
+
+	var tc traderTemplateConfig
+	source := req.SourceTraderID
+	if req.SourceTraderID != "" {
+		src, err := traderRepo.GetByTraderID(req.SourceTraderID)
+		if err != nil {
+			respondError(c, 404, "来源Trader不存在")
+			return
+		}
+		tc = templateConfigFromTrader(src)
+		if req.Name == "" {
+			req.Name = src.Name + " (copy)"
+		}
+	} else {
+		template, err := repositories.NewTraderTemplateRepository(sysConn.DB()).GetByName(req.Template)
+		if err != nil {
+			respondError(c, 404, "模板不存在")
+			return
+		}
+		view, err := newTraderTemplateView(template)
+		if err != nil {
+			respondError(c, 500, err.Error())
+			return
+		}
+		tc = view.Config
+		source = "模板 " + req.Template
+	}
+	if err := tc.validate(); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+	if req.Name == "" {
+		req.Name = req.ID
+	}
+
+	if _, err := traderRepo.Create(tc.toTrader(req.ID, req.Name)); err != nil {
+		respondError(c, 500, fmt.Sprintf("保存失败: %v", err))
+		return
+	}
+
+	log.Printf("✓ 新Trader已从%s复制: %s（未启用，填写API密钥并启用后重启服务生效）", source, req.ID)
+
+	c.JSON(200, gin.H{
+		"success":   true,
+		"trader_id": req.ID,
+		"message":   "Trader复制成功（未复制API密钥，默认未启用），请填写密钥并启用后重启服务",
+	})
+}
+
+// handleListTraderTemplates 列出所有Trader配置模板
+func (s *Server) handleListTraderTemplates(c *gin.Context) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
+		return
+	}
+	defer sysConn.Close()
+
+	templates, err := repositories.NewTraderTemplateRepository(sysConn.DB()).List()
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("获取模板失败: %v", err))
+		return
+	}
+
+	views := make([]traderTemplateView, 0, len(templates))
+	for _, template := range templates {
+		view, err := newTraderTemplateView(template)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+		views = append(views, view)
+	}
+
+	c.JSON(200, gin.H{"templates": views})
+}
+
+// handleSaveTraderTemplate 保存命名模板（来自已有Trader或直接提供参数，同名覆盖）
+func (s *Server) handleSaveTraderTemplate(c *gin.Context) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	var req struct {
+		Name           string                `json:"name" binding:"required"`
+		Description    string                `json:"description"`
+		SourceTraderID string                `json:"source_trader_id"` // 从已有Trader提取参数
+		Config         *traderTemplateConfig `json:"config"`           // 或直接提供参数
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, 400, "请求参数错误")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || (req.SourceTraderID == "") == (req.Config == nil) {
+		respondError(c, 400, "name必填，source_trader_id和config必须且只能指定一个")
+		return
+	}
+
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
+		return
+	}
+	defer sysConn.Close()
+
+	var tc traderTemplateConfig
+	if req.SourceTraderID != "" {
+		src, err := repositories.NewTraderConfigRepository(sysConn.DB()).GetByTraderID(req.SourceTraderID)
+		if err != nil {
+			respondError(c, 404, "来源Trader不存在")
+			return
+		}
+		tc = templateConfigFromTrader(src)
+	} else {
+		tc = *req.Config
+	}
+	if err := tc.validate(); err != nil {
+		respondError(c, 400, err.Error())
+		return
+	}
+
+	data, err := json.Marshal(tc)
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("序列化模板失败: %v", err))
+		return
+	}
+	template := &models.TraderTemplate{Name: req.Name, Description: req.Description, ConfigJSON: string(data)}
+	if err := repositories.NewTraderTemplateRepository(sysConn.DB()).Save(template); err != nil {
+		respondError(c, 500, fmt.Sprintf("保存模板失败: %v", err))
+		return
+	}
+
+	log.Printf("✓ Trader模板已保存: %s", req.Name)
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "模板保存成功",
+	})
+}
+
+// handleDeleteTraderTemplate 删除Trader配置模板
+func (s *Server) handleDeleteTraderTemplate(c *gin.Context) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+
+	name := c.Param("name")
+
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("连接数据库失败: %v", err))
+		return
+	}
+	defer sysConn.Close()
+
+	found, err := repositories.NewTraderTemplateRepository(sysConn.DB()).Delete(name)
+	if err != nil {
+		respondError(c, 500, fmt.Sprintf("删除模板失败: %v", err))
+		return
+	}
+	if !found {
+		respondError(c, 404, "模板不存在")
+		return
+	}
+
+	log.Printf("✓ Trader模板已删除: %s", name)
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "模板删除成功",
+	})
+}
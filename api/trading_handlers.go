@@ -6,7 +6,11 @@ import (
 	"net/http"
 	"time"
 	
+	"nofx/database/models"
+	"nofx/decision"
 	"nofx/logger"
+	"nofx/money"
+	"nofx/pool"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,10 +26,7 @@ type ManualClosePositionRequest struct {
 func (s *Server) handleManualClosePosition(c *gin.Context) {
 	var req ManualClosePositionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "无效的请求参数: " + err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
 		return
 	}
 
@@ -35,10 +36,7 @@ func (s *Server) handleManualClosePosition(c *gin.Context) {
 	trader, err := s.traderManager.GetTrader(req.TraderID)
 	if err != nil {
 		log.Printf("❌ 获取Trader失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "Trader不存在: " + req.TraderID,
-		})
+		respondError(c, http.StatusNotFound, "Trader不存在: "+req.TraderID)
 		return
 	}
 
@@ -83,40 +81,36 @@ func (s *Server) handleManualClosePosition(c *gin.Context) {
 		}
 	}
 
+	// 平仓会清理开仓指标快照，先读取用于交易记录
+	var entrySnapshot *models.PositionEntrySnapshot
+	if db := trader.GetDecisionLogger().GetDB(); db != nil {
+		entrySnapshot, _ = db.GetPositionEntrySnapshot(req.Symbol, req.Side)
+	}
+
 	// 调用trader的手动平仓方法
 	err = trader.ManualClosePosition(req.Symbol, req.Side)
 	if err != nil {
 		log.Printf("❌ 手动平仓失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "平仓失败: " + err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, "平仓失败: "+err.Error())
 		return
 	}
 
 	// 记录到历史成交表
+	var tradeOutcomeID int64
 	if positionInfo.EntryPrice > 0 && positionInfo.Quantity > 0 {
 		// 计算盈亏
-		pnl := 0.0
-		if req.Side == "long" {
-			pnl = (positionInfo.MarkPrice - positionInfo.EntryPrice) * positionInfo.Quantity
-		} else {
-			pnl = (positionInfo.EntryPrice - positionInfo.MarkPrice) * positionInfo.Quantity
-		}
+		pnl := money.PnL(req.Side, positionInfo.EntryPrice, positionInfo.MarkPrice, positionInfo.Quantity)
 		
 		// 计算盈亏百分比和其他信息
-		positionValue := positionInfo.Quantity * positionInfo.EntryPrice
+		positionValue := money.Mul(positionInfo.Quantity, positionInfo.EntryPrice)
 		
 		// 使用保证金计算盈亏百分比（更准确）
 		marginUsed := positionInfo.MarginUsed
 		if marginUsed == 0 && positionInfo.Leverage > 0 {
-			marginUsed = positionValue / float64(positionInfo.Leverage)
+			marginUsed = money.Div(positionValue, float64(positionInfo.Leverage))
 		}
 		
-		pnlPct := 0.0
-		if marginUsed > 0 {
-			pnlPct = (pnl / marginUsed) * 100
-		}
+		pnlPct := money.Pct(pnl, marginUsed)
 		
 		// 从AutoTrader获取真实的开仓时间
 		closeTime := time.Now()
@@ -176,11 +170,18 @@ func (s *Server) handleManualClosePosition(c *gin.Context) {
 			IsPremature:     isPremature,
 			FailureType:     failureType,
 		}
+		if entrySnapshot != nil {
+			trade.EntryMACD = entrySnapshot.EntryMACD
+			trade.EntryRSI = entrySnapshot.EntryRSI
+			trade.EntryVolRatio = entrySnapshot.EntryVolRatio
+			trade.Regime = entrySnapshot.Regime
+		}
 		
 		// 保存到数据库
 		if err := trader.GetDecisionLogger().SaveTradeOutcome(trade); err != nil {
 			log.Printf("⚠️ 保存交易记录失败: %v", err)
 		} else {
+			tradeOutcomeID = trade.ID
 			log.Printf("📝 已记录到历史成交表: PnL=%+.2f USDT (%.2f%%), 杠杆=%dx", pnl, pnlPct, positionInfo.Leverage)
 		}
 	}
@@ -218,15 +219,17 @@ func (s *Server) handleManualClosePosition(c *gin.Context) {
 			},
 			Decisions: []logger.DecisionAction{
 				{
-					Action:    fmt.Sprintf("close_%s", req.Side),
-					Symbol:    req.Symbol,
-					Quantity:  positionInfo.Quantity,
-					Price:     positionInfo.MarkPrice,
-					Timestamp: time.Now(),
-					Success:   true,
+					Action:         fmt.Sprintf("close_%s", req.Side),
+					Symbol:         req.Symbol,
+					Quantity:       positionInfo.Quantity,
+					Price:          positionInfo.MarkPrice,
+					Timestamp:      time.Now(),
+					Success:        true,
+					TradeOutcomeID: tradeOutcomeID, // 关联本次平仓的交易结果
 				},
 			},
 			Success: true,
+			Manual:  true,
 		}
 		trader.GetDecisionLogger().LogDecision(record)
 		log.Printf("📝 已记录手动平仓到AI学习系统")
@@ -243,16 +246,87 @@ func (s *Server) handleManualClosePosition(c *gin.Context) {
 	})
 }
 
+// ExecuteDecisionRequest 手动注入决策请求
+type ExecuteDecisionRequest struct {
+	TraderID string            `json:"trader_id"`
+	Decision decision.Decision `json:"decision"`
+}
+
+// handleExecuteDecision 处理手动注入决策请求（经过与AI决策相同的验证和执行流程）
+func (s *Server) handleExecuteDecision(c *gin.Context) {
+	var req ExecuteDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
+		return
+	}
+	if req.Decision.Symbol == "" || req.Decision.Action == "" {
+		respondError(c, http.StatusBadRequest, "决策缺少symbol或action")
+		return
+	}
+
+	log.Printf("🖐️ 收到手动决策请求: Trader=%s, Symbol=%s, Action=%s", req.TraderID, req.Decision.Symbol, req.Decision.Action)
+
+	trader, err := s.traderManager.GetTrader(req.TraderID)
+	if err != nil {
+		log.Printf("❌ 获取Trader失败: %v", err)
+		respondError(c, http.StatusNotFound, "Trader不存在: "+req.TraderID)
+		return
+	}
+
+	record, err := trader.ExecuteManualDecision(&req.Decision)
+	if err != nil {
+		log.Printf("❌ 手动决策执行失败: %v", err)
+		respondErrorDetails(c, http.StatusUnprocessableEntity, err.Error(), gin.H{"record": record})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "决策已执行，已记录到决策历史",
+		"trader":  req.TraderID,
+		"record":  record,
+	})
+}
+
+// handleValidateDecision 决策干跑验证：用实时上下文走完整验证和质量评估流程，不下单、不记录
+func (s *Server) handleValidateDecision(c *gin.Context) {
+	var req ExecuteDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
+		return
+	}
+	if req.Decision.Symbol == "" || req.Decision.Action == "" {
+		respondError(c, http.StatusBadRequest, "决策缺少symbol或action")
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(req.TraderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "Trader不存在: "+req.TraderID)
+		return
+	}
+
+	result, err := trader.DryRunDecision(&req.Decision)
+	if err != nil {
+		log.Printf("❌ 决策干跑验证失败: %v", err)
+		respondError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"trader":  req.TraderID,
+		"result":  result,
+	})
+}
+
 // handleToggleTrader 启用/停止Trader
 func (s *Server) handleToggleTrader(c *gin.Context) {
 	traderID := c.Query("trader_id")
 	action := c.Query("action") // "start" or "stop"
 
 	if traderID == "" || action == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "缺少trader_id或action参数",
-		})
+		respondError(c, http.StatusBadRequest, "缺少trader_id或action参数")
 		return
 	}
 
@@ -262,10 +336,7 @@ func (s *Server) handleToggleTrader(c *gin.Context) {
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
 		log.Printf("❌ 获取Trader失败: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"error":   "Trader不存在: " + traderID,
-		})
+		respondError(c, http.StatusNotFound, "Trader不存在: "+traderID)
 		return
 	}
 
@@ -280,10 +351,7 @@ func (s *Server) handleToggleTrader(c *gin.Context) {
 		message = "Trader已暂停"
 		log.Printf("⏸️  Trader已暂停: %s", traderID)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "无效的action参数，必须是start或stop",
-		})
+		respondError(c, http.StatusBadRequest, "无效的action参数，必须是start或stop")
 		return
 	}
 
@@ -294,3 +362,51 @@ func (s *Server) handleToggleTrader(c *gin.Context) {
 		"action":  action,
 	})
 }
+
+// ImportTradesRequest 历史成交导入请求
+type ImportTradesRequest struct {
+	Symbols []string `json:"symbols"` // 为空时使用默认主流币种
+	Limit   int      `json:"limit"`   // 每个币种拉取的成交条数
+}
+
+// handleImportTrades 从交易所历史成交导入交易记录（为表现分析和AI学习提供初始样本）
+func (s *Server) handleImportTrades(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var req ImportTradesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "无效的请求参数: "+err.Error())
+			return
+		}
+	}
+	if req.Limit < 0 || req.Limit > 1000 {
+		respondError(c, http.StatusBadRequest, "limit 必须在 0-1000 之间")
+		return
+	}
+	symbols := req.Symbols
+	if len(symbols) == 0 {
+		symbols = pool.GetDefaultCoins()
+	}
+
+	report, err := trader.ImportExchangeTrades(symbols, req.Limit)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, fmt.Sprintf("导入历史成交失败: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader": traderID,
+		"report": report,
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"nofx/mcp"
 	"os"
 	"time"
 )
@@ -48,6 +49,27 @@ type TraderConfig struct {
 	
 	// 数据优化配置（true=紧凑模式，false=完整模式）
 	CompactMode bool `json:"compact_mode"`
+
+	// 候选币种来源（"pool"=币种池服务，"static"=仅使用default_coins，空=跟随use_default_coins）
+	CoinSource string `json:"coin_source,omitempty"`
+
+	// 独立币种池API（空=使用全局coin_pool_api_url/oi_top_api_url），不同Trader可交易完全不同的币种范围
+	CoinPoolAPIURL         string `json:"coin_pool_api_url,omitempty"`
+	OITopAPIURL            string `json:"oi_top_api_url,omitempty"`
+	CoinPoolAuthHeader     string `json:"coin_pool_auth_header,omitempty"`     // "Bearer xxx" 或 "X-API-Key: xxx"
+	CoinPoolRefreshSeconds int    `json:"coin_pool_refresh_seconds,omitempty"` // 刷新间隔（秒，0=每个周期都请求）
+
+	// 行情数据源（空=binance；可选 bybit、hyperliquid，或组合如 "primary:hyperliquid,binance"、"average:binance,bybit"）
+	MarketDataSource string `json:"market_data_source,omitempty"`
+
+	// 提示词语言（空=zh；可选 en），决定生成的prompt章节和使用的prompt模板语言
+	PromptLanguage string `json:"prompt_language,omitempty"`
+
+	// AI生成参数（temperature/top_p/max_tokens/reasoning_effort，未设置=使用默认值）
+	AIParams *mcp.GenerationParams `json:"ai_params,omitempty"`
+
+	// 决策审核模型（两阶段AI：分析模型输出决策后由审核模型按规则清单逐条审核，nil=不启用）
+	Critic *CriticConfig `json:"critic,omitempty"`
 }
 
 // LeverageConfig 杠杆配置
@@ -204,6 +226,16 @@ func (c *Config) Validate() error {
 		if trader.ScanIntervalMinutes <= 0 {
 			trader.ScanIntervalMinutes = 3 // 默认3分钟
 		}
+		if trader.AIParams != nil {
+			if err := trader.AIParams.Validate(); err != nil {
+				return fmt.Errorf("trader[%d]: ai_params: %w", i, err)
+			}
+		}
+		if trader.Critic != nil {
+			if err := trader.Critic.Validate(); err != nil {
+				return fmt.Errorf("trader[%d]: critic: %w", i, err)
+			}
+		}
 	}
 
 	if c.APIServerPort <= 0 {
@@ -237,6 +269,22 @@ func (tc *TraderConfig) GetScanInterval() time.Duration {
 	return time.Duration(tc.ScanIntervalMinutes) * time.Minute
 }
 
+// GetCritic 获取审核模型配置（未配置时返回零值，即不启用）
+func (tc *TraderConfig) GetCritic() CriticConfig {
+	if tc.Critic == nil {
+		return CriticConfig{}
+	}
+	return *tc.Critic
+}
+
+// GetAIParams 获取AI生成参数（未配置时返回零值，即全部使用默认值）
+func (tc *TraderConfig) GetAIParams() mcp.GenerationParams {
+	if tc.AIParams == nil {
+		return mcp.GenerationParams{}
+	}
+	return *tc.AIParams
+}
+
 // SaveConfig 保存配置到文件
 func SaveConfig(filename string, config *Config) error {
 	data, err := json.MarshalIndent(config, "", "  ")
@@ -289,6 +337,9 @@ func (c *Config) MaskSensitiveData() *Config {
 		if maskedTrader.CustomAPIKey != "" {
 			maskedTrader.CustomAPIKey = maskString(maskedTrader.CustomAPIKey)
 		}
+		if maskedTrader.CoinPoolAuthHeader != "" {
+			maskedTrader.CoinPoolAuthHeader = maskString(maskedTrader.CoinPoolAuthHeader)
+		}
 		
 		masked.Traders[i] = maskedTrader
 	}
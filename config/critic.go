@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CriticConfig 决策审核模型配置
+// 分析模型（trader的ai_model）输出思维链和候选决策后，由更便宜/更快的审核模型按规则清单逐条检查，可否决或下调信心度
+type CriticConfig struct {
+	AIModel   string   `json:"ai_model"`             // deepseek / qwen / custom（custom使用trader的自定义API地址和密钥），空=不启用
+	ModelName string   `json:"model_name,omitempty"` // 模型名称（custom必填；deepseek/qwen可选，覆盖默认模型）
+	Rules     []string `json:"rules,omitempty"`      // 审核规则清单（空=使用内置规则）
+}
+
+// ParseCriticConfig 解析JSON格式的审核模型配置（空字符串返回nil，即不启用）
+func ParseCriticConfig(s string) (*CriticConfig, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var c CriticConfig
+	if err := json.Unmarshal([]byte(s), &c); err != nil {
+		return nil, fmt.Errorf("解析审核模型配置失败: %w", err)
+	}
+	if !c.Enabled() {
+		return nil, nil
+	}
+	return &c, c.Validate()
+}
+
+// Enabled 是否启用审核模型
+func (c *CriticConfig) Enabled() bool {
+	return c != nil && c.AIModel != ""
+}
+
+// Validate 校验审核模型配置
+func (c *CriticConfig) Validate() error {
+	switch c.AIModel {
+	case "", "deepseek", "qwen":
+	case "custom":
+		if strings.TrimSpace(c.ModelName) == "" {
+			return fmt.Errorf("custom审核模型必须设置model_name")
+		}
+	default:
+		return fmt.Errorf("审核模型只支持 deepseek/qwen/custom，当前: %s", c.AIModel)
+	}
+	for i, rule := range c.Rules {
+		if strings.TrimSpace(rule) == "" {
+			return fmt.Errorf("审核规则[%d]不能为空", i)
+		}
+	}
+	return nil
+}
+
+// JSON 序列化为JSON（nil或未启用时返回空字符串）
+func (c *CriticConfig) JSON() string {
+	if !c.Enabled() {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	return string(data)
+}
@@ -17,8 +17,11 @@ func NewConfigHelper(db *sql.DB) *ConfigHelper {
 	return &ConfigHelper{db: db}
 }
 
-// GetString 获取字符串配置
+// GetString 获取字符串配置（未连接数据库时返回默认值）
 func (h *ConfigHelper) GetString(key, defaultValue string) string {
+	if h == nil || h.db == nil {
+		return defaultValue
+	}
 	var value string
 	err := h.db.QueryRow("SELECT value FROM system_configs WHERE key = ?", key).Scan(&value)
 	if err != nil {
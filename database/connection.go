@@ -26,16 +26,12 @@ func NewConnection(traderID string) (*Connection, error) {
 	}
 
 	dbPath := config.GetTraderDBPath(traderID)
-	db, err := sql.Open("sqlite3", dbPath)
+	// WAL模式 + 忙等待超时，API并发读取时不会与交易周期写入互相锁死
+	db, err := openSQLite(dbPath, SQLiteMaxOpenConns, SQLiteMaxIdleConns)
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %w", err)
 	}
 
-	// 设置连接池参数
-	db.SetMaxOpenConns(1) // SQLite 推荐单连接
-	db.SetMaxIdleConns(1)
-	db.SetConnMaxLifetime(0)
-
 	conn := &Connection{
 		db:       db,
 		dbPath:   dbPath,
@@ -116,7 +112,9 @@ func (c *Connection) initSchema() error {
 		win_rate REAL,
 		avg_pnl REAL,
 		created_at TEXT DEFAULT CURRENT_TIMESTAMP,
-		is_active BOOLEAN DEFAULT 1
+		is_active BOOLEAN DEFAULT 1,
+		activated_at DATETIME,
+		deactivated_at DATETIME
 	);
 	CREATE INDEX IF NOT EXISTS idx_ai_learning_trader ON ai_learning_summaries(trader_id);
 	CREATE INDEX IF NOT EXISTS idx_ai_learning_active ON ai_learning_summaries(trader_id, is_active);
@@ -139,6 +137,28 @@ func (c *Connection) initSchema() error {
 		total_unrealized_profit REAL NOT NULL,
 		position_count INTEGER NOT NULL,
 		margin_used_pct REAL NOT NULL,
+		ai_provider TEXT DEFAULT '',
+		prompt_hash TEXT DEFAULT '',
+		cached BOOLEAN DEFAULT 0,
+		regime TEXT DEFAULT '',
+		latency_ms INTEGER DEFAULT 0,
+		learning_summary_id INTEGER DEFAULT 0,
+		manual BOOLEAN DEFAULT 0,
+		schema_version INTEGER DEFAULT 0,
+		realized_pnl_delta REAL DEFAULT 0,
+		closed_trades INTEGER DEFAULT 0,
+		ai_params TEXT DEFAULT '',
+		critic_review TEXT DEFAULT '',
+		critic_reviewed INTEGER DEFAULT 0,
+		critic_vetoed INTEGER DEFAULT 0,
+		market_fetch_ms INTEGER DEFAULT 0,
+		prompt_build_ms INTEGER DEFAULT 0,
+		ai_latency_ms INTEGER DEFAULT 0,
+		parse_validate_ms INTEGER DEFAULT 0,
+		execution_ms INTEGER DEFAULT 0,
+		db_write_ms INTEGER DEFAULT 0,
+		symbols_fetched INTEGER DEFAULT 0,
+		symbols_failed INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -156,6 +176,14 @@ func (c *Connection) initSchema() error {
 		success BOOLEAN NOT NULL,
 		error TEXT,
 		was_stop_loss BOOLEAN DEFAULT 0,
+		executed_qty REAL DEFAULT 0,
+		avg_price REAL DEFAULT 0,
+		price_drift_pct REAL DEFAULT 0,
+		notional_usd REAL DEFAULT 0,
+		margin_usd REAL DEFAULT 0,
+		adjustment TEXT DEFAULT '',
+		error_class TEXT DEFAULT '',
+		book_snapshot TEXT DEFAULT '',
 		FOREIGN KEY (record_id) REFERENCES decision_records(id) ON DELETE CASCADE
 	);
 
@@ -200,10 +228,20 @@ func (c *Connection) initSchema() error {
 		open_time DATETIME NOT NULL,
 		close_time DATETIME NOT NULL,
 		was_stop_loss BOOLEAN DEFAULT 0,
+		take_profit_hit BOOLEAN DEFAULT 0,
 		entry_reason TEXT,
 		exit_reason TEXT,
 		is_premature BOOLEAN DEFAULT 0,
 		failure_type TEXT,
+		entry_macd REAL DEFAULT 0,
+		entry_rsi REAL DEFAULT 0,
+		entry_vol_ratio REAL DEFAULT 0,
+		regime TEXT DEFAULT '',
+		source TEXT DEFAULT 'bot',
+		open_record_id INTEGER DEFAULT 0,
+		open_action_id INTEGER DEFAULT 0,
+		close_record_id INTEGER DEFAULT 0,
+		close_action_id INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -214,6 +252,7 @@ func (c *Connection) initSchema() error {
 		title TEXT NOT NULL,
 		content TEXT NOT NULL,
 		prompt_type TEXT NOT NULL DEFAULT 'system',
+		language TEXT DEFAULT 'zh',
 		enabled BOOLEAN DEFAULT 1,
 		display_order INTEGER DEFAULT 0,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
@@ -225,14 +264,188 @@ func (c *Connection) initSchema() error {
 		symbol TEXT NOT NULL,
 		side TEXT NOT NULL,
 		open_time_ms INTEGER NOT NULL,
+		stop_loss REAL DEFAULT 0,
+		take_profit REAL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (trader_id, symbol, side)
+	);
+
+	-- 持仓开仓指标快照表（平仓时写入TradeOutcome用于归因分析）
+	CREATE TABLE IF NOT EXISTS position_entry_snapshots (
+		trader_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		entry_macd REAL DEFAULT 0,
+		entry_rsi REAL DEFAULT 0,
+		entry_vol_ratio REAL DEFAULT 0,
+		entry_reason TEXT,
+		regime TEXT DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		PRIMARY KEY (trader_id, symbol, side)
 	);
 
+	-- 持仓止损/止盈调整记录表（update_stop_loss / update_take_profit）
+	CREATE TABLE IF NOT EXISTS position_exit_level_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		level_type TEXT NOT NULL,
+		old_price REAL NOT NULL DEFAULT 0,
+		new_price REAL NOT NULL,
+		mark_price REAL NOT NULL DEFAULT 0,
+		reason TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	-- 交易所请求审计表（每次下单/平仓/改杠杆/止损止盈/撤单的请求和返回）
+	CREATE TABLE IF NOT EXISTS exchange_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		exchange TEXT NOT NULL,
+		operation TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		request TEXT,
+		response TEXT,
+		success BOOLEAN NOT NULL,
+		error TEXT,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	-- 风险预算台账表（开仓占用风险，平仓释放）
+	CREATE TABLE IF NOT EXISTS risk_ledger (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		entry_price REAL NOT NULL,
+		stop_loss REAL NOT NULL,
+		quantity REAL NOT NULL,
+		risk_usd REAL NOT NULL,
+		opened_at DATETIME NOT NULL,
+		released_at DATETIME
+	);
+
+	-- 净值摘要表（决策记录归档清理后保留的小时级净值点）
+	CREATE TABLE IF NOT EXISTS equity_points (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		cycle_number INTEGER NOT NULL,
+		total_equity REAL NOT NULL,
+		available_balance REAL NOT NULL,
+		total_pnl REAL NOT NULL,
+		position_count INTEGER NOT NULL,
+		margin_used_pct REAL NOT NULL
+	);
+
+	-- 市场广度表（每个周期的BTC市值占比、EMA50上方币种比例、全市场OI变化）
+	CREATE TABLE IF NOT EXISTS market_breadth (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		cycle_number INTEGER NOT NULL,
+		btc_dominance REAL NOT NULL DEFAULT 0,
+		above_ema50_pct REAL NOT NULL DEFAULT 0,
+		symbol_count INTEGER NOT NULL DEFAULT 0,
+		aggregate_oi_change_pct REAL NOT NULL DEFAULT 0,
+		oi_symbol_count INTEGER NOT NULL DEFAULT 0
+	);
+
+	-- 资金费率套利仓位表（永续腿 + 对冲腿，对AI决策不可见）
+	CREATE TABLE IF NOT EXISTS funding_arb_positions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		perp_side TEXT NOT NULL,
+		hedge_venue TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		perp_entry_price REAL NOT NULL,
+		hedge_entry_price REAL NOT NULL,
+		entry_rate_pct REAL NOT NULL DEFAULT 0,
+		funding_collected REAL NOT NULL DEFAULT 0,
+		last_accrual_at DATETIME NOT NULL,
+		opened_at DATETIME NOT NULL,
+		closed_at DATETIME,
+		perp_exit_price REAL NOT NULL DEFAULT 0,
+		hedge_exit_price REAL NOT NULL DEFAULT 0,
+		realized_pnl REAL NOT NULL DEFAULT 0,
+		close_reason TEXT NOT NULL DEFAULT ''
+	);
+
+	-- 资金划转台账（入金为正、出金为负，用于按净投入计算盈亏和回撤）
+	CREATE TABLE IF NOT EXISTS balance_flows (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		tran_id TEXT NOT NULL,
+		asset TEXT NOT NULL,
+		amount REAL NOT NULL,
+		timestamp DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(trader_id, tran_id)
+	);
+
+	-- 交易所收入历史（已实现盈亏、资金费、手续费、清算费，用于月度收益表与交易所对账）
+	CREATE TABLE IF NOT EXISTS income_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		tran_id TEXT NOT NULL,
+		income_type TEXT NOT NULL,
+		symbol TEXT NOT NULL DEFAULT '',
+		asset TEXT NOT NULL,
+		amount REAL NOT NULL,
+		timestamp DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(trader_id, tran_id, income_type, symbol)
+	);
+
+	-- 每日开仓计数表（按UTC日期和币种，用于限制单日开仓次数）
+	CREATE TABLE IF NOT EXISTS daily_entry_counts (
+		trader_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (trader_id, day, symbol)
+	);
+
+	-- 回撤锁状态表（净值峰值和只平仓锁定，系统重启后恢复）
+	CREATE TABLE IF NOT EXISTS drawdown_locks (
+		trader_id TEXT PRIMARY KEY,
+		peak_equity REAL NOT NULL DEFAULT 0,
+		locked BOOLEAN NOT NULL DEFAULT 0,
+		locked_at DATETIME,
+		reason TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- 决策质量重评分表（按评分规则版本保存，用于对比规则修改前后的评分）
+	CREATE TABLE IF NOT EXISTS decision_quality_scores (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		record_id INTEGER NOT NULL,
+		decision_index INTEGER NOT NULL,
+		version TEXT NOT NULL,
+		symbol TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL DEFAULT '',
+		score REAL NOT NULL DEFAULT 0,
+		grade TEXT NOT NULL DEFAULT '',
+		issues TEXT NOT NULL DEFAULT '',
+		original_score REAL NOT NULL DEFAULT 0,
+		original_grade TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(trader_id, version, record_id, decision_index),
+		FOREIGN KEY (record_id) REFERENCES decision_records(id) ON DELETE CASCADE
+	);
+
 	-- Trader运行状态表（用于系统重启后恢复）
 	CREATE TABLE IF NOT EXISTS trader_states (
 		trader_id TEXT PRIMARY KEY,
 		is_paused BOOLEAN NOT NULL DEFAULT 0,
+		state TEXT DEFAULT '',
+		reason TEXT DEFAULT '',
+		state_until DATETIME,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
@@ -242,16 +455,139 @@ func (c *Connection) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_decision_actions_record_id ON decision_actions(record_id);
 	CREATE INDEX IF NOT EXISTS idx_decision_actions_symbol ON decision_actions(symbol);
 	CREATE INDEX IF NOT EXISTS idx_position_snapshots_record_id ON position_snapshots(record_id);
+	CREATE INDEX IF NOT EXISTS idx_candidate_coins_record_id ON candidate_coins(record_id);
 	CREATE INDEX IF NOT EXISTS idx_trade_outcomes_trader_id ON trade_outcomes(trader_id);
 	CREATE INDEX IF NOT EXISTS idx_trade_outcomes_symbol ON trade_outcomes(symbol);
 	CREATE INDEX IF NOT EXISTS idx_trade_outcomes_close_time ON trade_outcomes(close_time);
 	CREATE INDEX IF NOT EXISTS idx_prompt_configs_section_name ON prompt_configs(section_name);
 	CREATE INDEX IF NOT EXISTS idx_prompt_configs_display_order ON prompt_configs(display_order);
 	CREATE INDEX IF NOT EXISTS idx_position_open_times_trader ON position_open_times(trader_id);
+	CREATE INDEX IF NOT EXISTS idx_exit_level_changes_position ON position_exit_level_changes(trader_id, symbol, side, created_at);
+	CREATE INDEX IF NOT EXISTS idx_exchange_audit_time ON exchange_audit(trader_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_risk_ledger_open ON risk_ledger(trader_id, released_at);
+	CREATE INDEX IF NOT EXISTS idx_equity_points_time ON equity_points(trader_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_market_breadth_time ON market_breadth(trader_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_balance_flows_time ON balance_flows(trader_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_income_history_time ON income_history(trader_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_funding_arb_open ON funding_arb_positions(trader_id, closed_at);
 	`
 
-	_, err := c.db.Exec(schema)
-	return err
+	if _, err := c.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return c.migrateColumns()
+}
+
+// columnMigration 需要补充到旧表中的列定义
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// schemaColumnMigrations 旧版本数据库缺失的列（CREATE TABLE IF NOT EXISTS 不会为已存在的表加列）
+var schemaColumnMigrations = []columnMigration{
+	{"decision_actions", "executed_qty", "REAL DEFAULT 0"},
+	{"decision_actions", "avg_price", "REAL DEFAULT 0"},
+	{"decision_records", "ai_provider", "TEXT DEFAULT ''"},
+	{"decision_records", "prompt_hash", "TEXT DEFAULT ''"},
+	{"decision_records", "cached", "BOOLEAN DEFAULT 0"},
+	{"trade_outcomes", "entry_macd", "REAL DEFAULT 0"},
+	{"trade_outcomes", "entry_rsi", "REAL DEFAULT 0"},
+	{"trade_outcomes", "entry_vol_ratio", "REAL DEFAULT 0"},
+	{"decision_records", "regime", "TEXT DEFAULT ''"},
+	{"trade_outcomes", "regime", "TEXT DEFAULT ''"},
+	{"position_entry_snapshots", "regime", "TEXT DEFAULT ''"},
+	{"decision_records", "latency_ms", "INTEGER DEFAULT 0"},
+	{"decision_actions", "price_drift_pct", "REAL DEFAULT 0"},
+	{"ai_learning_summaries", "activated_at", "DATETIME"},
+	{"ai_learning_summaries", "deactivated_at", "DATETIME"},
+	{"decision_records", "learning_summary_id", "INTEGER DEFAULT 0"},
+	{"decision_records", "manual", "BOOLEAN DEFAULT 0"},
+	{"decision_records", "schema_version", "INTEGER DEFAULT 0"},
+	{"decision_records", "realized_pnl_delta", "REAL DEFAULT 0"},
+	{"decision_records", "closed_trades", "INTEGER DEFAULT 0"},
+	{"decision_records", "ai_params", "TEXT DEFAULT ''"},
+	{"decision_records", "critic_review", "TEXT DEFAULT ''"},
+	{"decision_records", "critic_reviewed", "INTEGER DEFAULT 0"},
+	{"decision_records", "critic_vetoed", "INTEGER DEFAULT 0"},
+	{"decision_records", "market_fetch_ms", "INTEGER DEFAULT 0"},
+	{"decision_records", "prompt_build_ms", "INTEGER DEFAULT 0"},
+	{"decision_records", "ai_latency_ms", "INTEGER DEFAULT 0"},
+	{"decision_records", "parse_validate_ms", "INTEGER DEFAULT 0"},
+	{"decision_records", "execution_ms", "INTEGER DEFAULT 0"},
+	{"decision_records", "db_write_ms", "INTEGER DEFAULT 0"},
+	{"decision_records", "symbols_fetched", "INTEGER DEFAULT 0"},
+	{"decision_records", "symbols_failed", "INTEGER DEFAULT 0"},
+	{"decision_actions", "notional_usd", "REAL DEFAULT 0"},
+	{"decision_actions", "margin_usd", "REAL DEFAULT 0"},
+	{"decision_actions", "adjustment", "TEXT DEFAULT ''"},
+	{"position_open_times", "stop_loss", "REAL DEFAULT 0"},
+	{"position_open_times", "take_profit", "REAL DEFAULT 0"},
+	{"prompt_configs", "language", "TEXT DEFAULT 'zh'"},
+	{"trade_outcomes", "source", "TEXT DEFAULT 'bot'"},
+	{"decision_actions", "error_class", "TEXT DEFAULT ''"},
+	{"decision_actions", "book_snapshot", "TEXT DEFAULT ''"},
+	{"trade_outcomes", "open_record_id", "INTEGER DEFAULT 0"},
+	{"trade_outcomes", "open_action_id", "INTEGER DEFAULT 0"},
+	{"trade_outcomes", "close_record_id", "INTEGER DEFAULT 0"},
+	{"trade_outcomes", "close_action_id", "INTEGER DEFAULT 0"},
+	{"trade_outcomes", "take_profit_hit", "BOOLEAN DEFAULT 0"},
+	{"trader_states", "state", "TEXT DEFAULT ''"},
+	{"trader_states", "reason", "TEXT DEFAULT ''"},
+	{"trader_states", "state_until", "DATETIME"},
+}
+
+// migrateColumns 为已存在的表补充新增列
+func (c *Connection) migrateColumns() error {
+	return applyColumnMigrations(c.db, schemaColumnMigrations)
+}
+
+// applyColumnMigrations 为已存在的表补充缺失的列（交易员数据库和系统数据库共用）
+func applyColumnMigrations(db *sql.DB, migrations []columnMigration) error {
+	for _, m := range migrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return fmt.Errorf("检查列 %s.%s 失败: %w", m.table, m.column, err)
+		}
+		if exists {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("添加列 %s.%s 失败: %w", m.table, m.column, err)
+		}
+		log.Printf("✓ 数据库迁移: 已添加列 %s.%s", m.table, m.column)
+	}
+	return nil
+}
+
+// columnExists 检查表中是否存在指定列
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
 // GetDBPath 获取数据库文件路径
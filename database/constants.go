@@ -41,10 +41,12 @@ const (
 
 // 数据库连接池配置
 const (
-	SQLiteMaxOpenConns    = 1 // SQLite 推荐单连接
-	SQLiteMaxIdleConns    = 1
+	SQLiteMaxOpenConns    = 4 // WAL模式下读写可并发（同一时刻仍只有一个写者）
+	SQLiteMaxIdleConns    = 4
 	SQLiteConnMaxLifetime = 0 // 不限制连接生命周期
 	
 	SystemDBMaxOpenConns = 10 // 系统数据库可以多连接
 	SystemDBMaxIdleConns = 5
+	
+	SQLiteBusyTimeoutMS = 5000 // 遇到锁时的等待时间（毫秒），避免 "database is locked"
 )
@@ -3,6 +3,8 @@ package database
 import (
 	"nofx/database/models"
 	"nofx/database/repositories"
+	"nofx/i18n"
+	"time"
 )
 
 // DB 简化的数据库接口（用于 decision_logger 等组件）
@@ -40,15 +42,15 @@ func (db *DB) Backup(timestamp string) error {
 // BuildSystemPromptFromDB 从数据库构建system prompt
 // maxPositionValueBTC和maxPositionValueAlt是动态风控调整后的实际可用限制
 // aiAutonomyMode: true=自主模式（移除限制性规则），false=限制模式（包含所有规则）
-func (db *DB) BuildSystemPromptFromDB(accountEquity float64, btcEthLeverage, altcoinLeverage int, maxPositionValueBTC, maxPositionValueAlt float64, aiAutonomyMode bool) string {
+func (db *DB) BuildSystemPromptFromDB(lang i18n.Lang, accountEquity float64, btcEthLeverage, altcoinLeverage int, maxPositionValueBTC, maxPositionValueAlt float64, aiAutonomyMode bool) string {
 	repo := repositories.NewConfigRepository(db.conn.DB())
-	return BuildSystemPrompt(repo, accountEquity, btcEthLeverage, altcoinLeverage, maxPositionValueBTC, maxPositionValueAlt, aiAutonomyMode)
+	return BuildSystemPrompt(repo, lang, accountEquity, btcEthLeverage, altcoinLeverage, maxPositionValueBTC, maxPositionValueAlt, aiAutonomyMode)
 }
 
-// GetUserPromptTemplates 获取用户提示词模板
-func (db *DB) GetUserPromptTemplates() ([]*repositories.PromptConfig, error) {
+// GetUserPromptTemplates 获取指定语言的用户提示词模板（该语言没有模板时使用中文模板）
+func (db *DB) GetUserPromptTemplates(lang i18n.Lang) ([]*repositories.PromptConfig, error) {
 	repo := repositories.NewConfigRepository(db.conn.DB())
-	return repo.GetByType("user")
+	return repo.GetByType("user", string(lang))
 }
 
 // Decision 获取决策Repository
@@ -71,6 +73,56 @@ func (db *DB) Learning() *repositories.LearningRepository {
 	return repositories.NewLearningRepository(db.conn.DB(), db.traderID)
 }
 
+// RiskLedger 获取风险预算台账Repository
+func (db *DB) RiskLedger() *repositories.RiskLedgerRepository {
+	return repositories.NewRiskLedgerRepository(db.conn.DB(), db.traderID)
+}
+
+// FundingArb 获取资金费率套利仓位Repository
+func (db *DB) FundingArb() *repositories.FundingArbRepository {
+	return repositories.NewFundingArbRepository(db.conn.DB(), db.traderID)
+}
+
+// Retention 获取数据保留与归档Repository
+func (db *DB) Retention() *repositories.RetentionRepository {
+	return repositories.NewRetentionRepository(db.conn.DB(), db.traderID)
+}
+
+// MarketBreadth 获取市场广度Repository
+func (db *DB) MarketBreadth() *repositories.MarketBreadthRepository {
+	return repositories.NewMarketBreadthRepository(db.conn.DB(), db.traderID)
+}
+
+// ExchangeAudit 获取交易所请求审计Repository
+func (db *DB) ExchangeAudit() *repositories.ExchangeAuditRepository {
+	return repositories.NewExchangeAuditRepository(db.conn.DB(), db.traderID)
+}
+
+// BalanceFlow 获取资金划转台账Repository
+func (db *DB) BalanceFlow() *repositories.BalanceFlowRepository {
+	return repositories.NewBalanceFlowRepository(db.conn.DB(), db.traderID)
+}
+
+// Income 获取交易所收入历史Repository
+func (db *DB) Income() *repositories.IncomeRepository {
+	return repositories.NewIncomeRepository(db.conn.DB(), db.traderID)
+}
+
+// EntryCount 获取每日开仓计数Repository
+func (db *DB) EntryCount() *repositories.EntryCountRepository {
+	return repositories.NewEntryCountRepository(db.conn.DB(), db.traderID)
+}
+
+// QualityScore 获取决策质量重评分Repository
+func (db *DB) QualityScore() *repositories.QualityScoreRepository {
+	return repositories.NewQualityScoreRepository(db.conn.DB(), db.traderID)
+}
+
+// Path 数据库文件路径
+func (db *DB) Path() string {
+	return db.conn.dbPath
+}
+
 // Config 获取配置Repository
 func (db *DB) Config() *repositories.ConfigRepository {
 	return repositories.NewConfigRepository(db.conn.DB())
@@ -86,13 +138,13 @@ func (db *DB) GetAllPositionOpenTimes() (map[string]int64, error) {
 	return db.Position().GetAllOpenTimes()
 }
 
-// GetTraderState 获取Trader状态
-func (db *DB) GetTraderState() (bool, bool) {
+// GetTraderState 获取Trader状态（没有保存的状态时第二个返回值为false）
+func (db *DB) GetTraderState() (*models.TraderState, bool) {
 	state, err := db.Position().GetTraderState()
 	if err != nil || state == nil {
-		return false, false
+		return nil, false
 	}
-	return state.IsPaused, true
+	return state, true
 }
 
 // GetPositionOpenTime 获取持仓开仓时间
@@ -110,9 +162,44 @@ func (db *DB) SavePositionOpenTime(symbol, side string, openTimeMs int64) error
 	return db.Position().SaveOpenTime(symbol, side, openTimeMs)
 }
 
+// SavePositionExitLevels 保存持仓止损止盈价
+func (db *DB) SavePositionExitLevels(symbol, side string, stopLoss, takeProfit float64) error {
+	return db.Position().SaveExitLevels(symbol, side, stopLoss, takeProfit)
+}
+
+// GetAllPositionExitLevels 获取所有持仓止损止盈价（symbol_side -> [止损, 止盈]）
+func (db *DB) GetAllPositionExitLevels() (map[string][2]float64, error) {
+	return db.Position().GetAllExitLevels()
+}
+
+// SaveExitLevelChange 记录持仓止损/止盈调整
+func (db *DB) SaveExitLevelChange(change *models.ExitLevelChange) error {
+	return db.Position().SaveExitLevelChange(change)
+}
+
+// GetExitLevelChanges 获取持仓自指定时间以来的止损/止盈调整记录
+func (db *DB) GetExitLevelChanges(symbol, side string, since time.Time) ([]*models.ExitLevelChange, error) {
+	return db.Position().GetExitLevelChanges(symbol, side, since)
+}
+
+// SavePositionEntrySnapshot 保存持仓开仓指标快照
+func (db *DB) SavePositionEntrySnapshot(snapshot *models.PositionEntrySnapshot) error {
+	return db.Position().SaveEntrySnapshot(snapshot)
+}
+
+// GetPositionEntrySnapshot 获取持仓开仓指标快照
+func (db *DB) GetPositionEntrySnapshot(symbol, side string) (*models.PositionEntrySnapshot, bool) {
+	return db.Position().GetEntrySnapshot(symbol, side)
+}
+
+// DeletePositionEntrySnapshot 删除持仓开仓指标快照
+func (db *DB) DeletePositionEntrySnapshot(symbol, side string) error {
+	return db.Position().DeleteEntrySnapshot(symbol, side)
+}
+
 // SaveTraderState 保存Trader状态
-func (db *DB) SaveTraderState(isPaused bool) error {
-	return db.Position().SaveTraderState(isPaused)
+func (db *DB) SaveTraderState(state *models.TraderState) error {
+	return db.Position().SaveTraderState(state)
 }
 
 // GetActiveAILearningSummary 获取活跃的AI学习总结
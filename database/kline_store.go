@@ -0,0 +1,162 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"nofx/market"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// KlineDBName K线持久化数据库文件名（所有trader共享）
+const KlineDBName = "klines.db"
+
+// KlineStore SQLite实现的K线持久化存储（实现 market.KlineStore）
+type KlineStore struct {
+	db     *sql.DB
+	dbPath string
+}
+
+// NewKlineStore 打开K线数据库（data/klines.db）
+func NewKlineStore() (*KlineStore, error) {
+	dbPath := filepath.Join(DefaultBaseDir, KlineDBName)
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("创建K线数据目录失败: %w", err)
+	}
+
+	db, err := openSQLite(dbPath, SQLiteMaxOpenConns, SQLiteMaxIdleConns)
+	if err != nil {
+		return nil, fmt.Errorf("打开K线数据库失败: %w", err)
+	}
+
+	// 每根K线以 (symbol, interval, open_time) 唯一，重复拉取只更新不新增
+	schema := `
+	CREATE TABLE IF NOT EXISTS klines (
+		symbol TEXT NOT NULL,
+		interval TEXT NOT NULL,
+		open_time INTEGER NOT NULL,
+		open REAL NOT NULL,
+		high REAL NOT NULL,
+		low REAL NOT NULL,
+		close REAL NOT NULL,
+		volume REAL NOT NULL,
+		close_time INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (symbol, interval, open_time)
+	) WITHOUT ROWID;
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化K线表结构失败: %w", err)
+	}
+
+	log.Printf("✓ K线数据库已初始化: %s", dbPath)
+	return &KlineStore{db: db, dbPath: dbPath}, nil
+}
+
+// Close 关闭数据库连接
+func (s *KlineStore) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// SaveKlines 写入K线（已存在的K线覆盖更新）
+func (s *KlineStore) SaveKlines(symbol, interval string, klines []market.Kline) error {
+	if len(klines) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO klines (symbol, interval, open_time, open, high, low, close, volume, close_time, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(symbol, interval, open_time) DO UPDATE SET
+			open = excluded.open, high = excluded.high, low = excluded.low, close = excluded.close,
+			volume = excluded.volume, close_time = excluded.close_time, updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		return fmt.Errorf("准备K线写入失败: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UnixMilli()
+	for _, k := range klines {
+		if _, err := stmt.Exec(symbol, interval, k.OpenTime, k.Open, k.High, k.Low, k.Close,
+			k.Volume, k.CloseTime, now); err != nil {
+			return fmt.Errorf("写入K线失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadKlines 读取最近limit根K线（按时间正序）及其中最近一次写入时间
+func (s *KlineStore) LoadKlines(symbol, interval string, limit int) ([]market.Kline, time.Time, error) {
+	rows, err := s.db.Query(`
+		SELECT open_time, open, high, low, close, volume, close_time, updated_at FROM klines
+		WHERE symbol = ? AND interval = ?
+		ORDER BY open_time DESC LIMIT ?
+	`, symbol, interval, limit)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var klines []market.Kline
+	var latest int64
+	for rows.Next() {
+		var k market.Kline
+		var updatedAt int64
+		if err := rows.Scan(&k.OpenTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume,
+			&k.CloseTime, &updatedAt); err != nil {
+			return nil, time.Time{}, err
+		}
+		if updatedAt > latest {
+			latest = updatedAt
+		}
+		klines = append(klines, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// 倒序查询，翻转为时间正序
+	for i, j := 0, len(klines)-1; i < j; i, j = i+1, j-1 {
+		klines[i], klines[j] = klines[j], klines[i]
+	}
+	if latest == 0 {
+		return klines, time.Time{}, nil
+	}
+	return klines, time.UnixMilli(latest), nil
+}
+
+// LoadKlineRange 读取开盘时间在 [from, to] 内的K线（按时间正序）
+func (s *KlineStore) LoadKlineRange(symbol, interval string, from, to time.Time) ([]market.Kline, error) {
+	rows, err := s.db.Query(`
+		SELECT open_time, open, high, low, close, volume, close_time FROM klines
+		WHERE symbol = ? AND interval = ? AND open_time BETWEEN ? AND ?
+		ORDER BY open_time ASC
+	`, symbol, interval, from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var klines []market.Kline
+	for rows.Next() {
+		var k market.Kline
+		if err := rows.Scan(&k.OpenTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume, &k.CloseTime); err != nil {
+			return nil, err
+		}
+		klines = append(klines, k)
+	}
+	return klines, rows.Err()
+}
@@ -3,8 +3,10 @@ package database
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"nofx/config"
 	"nofx/database/repositories"
+	"nofx/mcp"
 	"os"
 )
 
@@ -133,12 +135,44 @@ func LoadConfigFromDB() (*config.Config, error) {
 			ScanIntervalMinutes:   dbTrader.ScanIntervalMinutes,
 			AIAutonomyMode:        dbTrader.AIAutonomyMode,
 			CompactMode:           dbTrader.CompactMode,
+			CoinSource:            dbTrader.CoinSource,
+			CoinPoolAPIURL:        dbTrader.CoinPoolAPIURL,
+			OITopAPIURL:           dbTrader.OITopAPIURL,
+			CoinPoolAuthHeader:    dbTrader.CoinPoolAuthHeader,
+			CoinPoolRefreshSeconds: dbTrader.CoinPoolRefreshSeconds,
+			MarketDataSource:      dbTrader.MarketDataSource,
+			PromptLanguage:        dbTrader.PromptLanguage,
+			AIParams:              traderAIParams(dbTrader.TraderID, dbTrader.AIParams),
+			Critic:                traderCritic(dbTrader.TraderID, dbTrader.Critic),
 		}
 	}
 
 	return cfg, nil
 }
 
+// traderAIParams 解析数据库中保存的AI生成参数（为空或无效时使用默认值）
+func traderAIParams(traderID, raw string) *mcp.GenerationParams {
+	params, err := mcp.ParseGenerationParams(raw)
+	if err != nil {
+		log.Printf("⚠️  Trader %s 的AI生成参数无效，使用默认值: %v", traderID, err)
+		return nil
+	}
+	if params.IsZero() {
+		return nil
+	}
+	return &params
+}
+
+// traderCritic 解析数据库中保存的审核模型配置（为空或无效时不启用）
+func traderCritic(traderID, raw string) *config.CriticConfig {
+	critic, err := config.ParseCriticConfig(raw)
+	if err != nil {
+		log.Printf("⚠️  Trader %s 的审核模型配置无效，不启用: %v", traderID, err)
+		return nil
+	}
+	return critic
+}
+
 // ensureDataDirectory 确保数据目录存在
 func ensureDataDirectory() error {
 	return os.MkdirAll("data", 0755)
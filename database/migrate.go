@@ -164,6 +164,15 @@ func migrateTraderConfigs(cfg *config.Config, manager *Manager) error {
 			EnableAILearning:    cfg.EnableAILearning,
 			AILearnInterval:     cfg.AILearnInterval,
 			AIAutonomyMode:      cfg.AIAutonomyMode,
+			CoinSource:          traderCfg.CoinSource,
+			CoinPoolAPIURL:      traderCfg.CoinPoolAPIURL,
+			OITopAPIURL:         traderCfg.OITopAPIURL,
+			CoinPoolAuthHeader:  traderCfg.CoinPoolAuthHeader,
+			CoinPoolRefreshSeconds: traderCfg.CoinPoolRefreshSeconds,
+			MarketDataSource:    traderCfg.MarketDataSource,
+			PromptLanguage:      traderCfg.PromptLanguage,
+			AIParams:            traderCfg.AIParams.JSON(),
+			Critic:              traderCfg.Critic.JSON(),
 		}
 
 		_, err = manager.TraderConfigRepo.Create(dbTraderCfg)
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// BalanceFlow 资金划转台账（入金为正、出金为负，金额按USDT计）
+type BalanceFlow struct {
+	ID        int64
+	TraderID  string
+	TranID    string // 交易所划转ID（去重）
+	Asset     string
+	Amount    float64
+	Timestamp time.Time
+	CreatedAt time.Time
+}
@@ -9,6 +9,7 @@ type PromptConfig struct {
 	Title        string    `json:"title"`         // 显示标题
 	Content      string    `json:"content"`       // 内容
 	PromptType   string    `json:"prompt_type"`   // 类型: system / user
+	Language     string    `json:"language"`      // 语言: zh / en
 	Enabled      bool      `json:"enabled"`       // 是否启用
 	DisplayOrder int       `json:"display_order"` // 显示顺序
 	UpdatedAt    time.Time `json:"updated_at"`
@@ -20,6 +20,29 @@ type DecisionRecord struct {
 	TotalUnrealizedProfit float64
 	PositionCount int
 	MarginUsedPct float64
+	AIProvider string // 实际使用的AI提供商
+	PromptHash string // Prompt内容哈希
+	Cached bool       // 是否复用了上一周期决策
+	Regime string     // 决策时的市场状态（trending/ranging/high_vol/crash）
+	LatencyMs int64   // 市场快照到AI决策完成的耗时(毫秒)
+	LearningSummaryID int64 // 决策时生效的AI学习总结ID（0表示没有）
+	Manual bool // 是否为操作员手动注入的决策
+	SchemaVersion int // 解析AI输出使用的决策格式版本（0表示没有解析AI输出）
+	RealizedPnLDelta float64 // 上一条决策记录之后平仓交易的已实现盈亏合计
+	ClosedTrades int // 上一条决策记录之后平仓的交易笔数
+	AIParams string // 本次调用AI实际使用的生成参数（JSON）
+	CriticReview string // 审核模型的审核结果（JSON，未启用两阶段审核时为空）
+	CriticReviewed int // 审核模型审核的决策数
+	CriticVetoed int // 审核模型否决的决策数
+	// 周期各阶段耗时(毫秒)
+	MarketFetchMs int64
+	PromptBuildMs int64
+	AILatencyMs int64
+	ParseValidateMs int64
+	ExecutionMs int64
+	DBWriteMs int64 // 写入决策记录的耗时（写入后回填）
+	SymbolsFetched int // 成功获取行情的币种数
+	SymbolsFailed int // 获取行情失败的币种数
 	CreatedAt time.Time
 }
 
@@ -37,6 +60,56 @@ type DecisionAction struct {
 	Success bool
 	Error string
 	WasStopLoss bool
+	ExecutedQty float64 // 实际成交数量
+	AvgPrice float64    // 实际成交均价
+	PriceDriftPct float64 // 下单前价格相对AI分析价格的漂移(%)
+	NotionalUSD float64 // 下单名义价值(USDT) = 数量 × 价格
+	MarginUSD float64   // 下单保证金(USDT) = 名义价值 / 杠杆
+	Adjustment string   // 执行前系统对决策参数的调整说明（如止损按强平价收紧）
+	ErrorClass string   // 失败原因分类（insufficient_margin/reduce_only/rate_limit/precision/permission/other）
+	BookSnapshot string // 下单时的盘口快照（JSON，未记录时为空）
+	TradeOutcomeID int64 // 本动作平仓产生的交易结果ID（不落库，写入时用于回填交易结果的平仓决策）
+}
+
+// CandidatePool 一个决策周期的候选池成员
+type CandidatePool struct {
+	RecordID    int64
+	CycleNumber int
+	Timestamp   time.Time
+	Symbols     []string // 按AI看到的顺序
+}
+
+// CycleTiming 一个决策周期的各阶段耗时
+type CycleTiming struct {
+	RecordID        int64
+	Timestamp       time.Time
+	Success         bool
+	Cached          bool // 复用上一周期决策（未调用AI）
+	MarketFetchMs   int64
+	PromptBuildMs   int64
+	AILatencyMs     int64
+	ParseValidateMs int64
+	ExecutionMs     int64
+	DBWriteMs       int64
+	SymbolsFetched  int
+	SymbolsFailed   int
+}
+
+// ActionErrorStats 一段时间内决策动作的执行统计
+type ActionErrorStats struct {
+	Total   int               // 执行的动作数（不含hold/wait）
+	Failed  int               // 执行失败的动作数
+	Classes []*ErrorClassStat // 按失败原因分类统计（按次数倒序）
+}
+
+// ErrorClassStat 同一类失败原因的统计
+type ErrorClassStat struct {
+	Class      string
+	Count      int
+	LastSymbol string    // 最近一次失败的币种
+	LastAction string    // 最近一次失败的动作
+	LastError  string    // 最近一次失败的错误信息
+	LastTime   time.Time // 最近一次失败的时间
 }
 
 // PositionSnapshot 持仓快照表（关联决策记录）
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// EquityPoint 净值摘要表（决策记录归档清理后保留的净值点）
+type EquityPoint struct {
+	ID               int64
+	TraderID         string
+	Timestamp        time.Time
+	CycleNumber      int
+	TotalEquity      float64
+	AvailableBalance float64
+	TotalPnL         float64 // 总盈亏（相对初始余额）
+	PositionCount    int
+	MarginUsedPct    float64
+}
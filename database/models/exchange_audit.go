@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ExchangeAuditEvent 交易所请求审计记录（下单、平仓、改杠杆、止损止盈、撤单）
+type ExchangeAuditEvent struct {
+	ID        int64
+	TraderID  string
+	Exchange  string
+	Operation string // open_long / close_short / set_leverage / set_stop_loss / cancel_all_orders 等
+	Symbol    string
+	Request   string // 请求参数(JSON)
+	Response  string // 交易所返回(JSON)，失败时为空
+	Success   bool
+	Error     string
+	LatencyMs int64     // 请求耗时(毫秒)
+	CreatedAt time.Time // 请求发出时间
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FundingArbPosition 资金费率套利仓位表（永续腿 + 对冲腿的Delta中性组合）
+type FundingArbPosition struct {
+	ID               int64
+	TraderID         string
+	Symbol           string
+	PerpSide         string // 永续腿方向：short（正费率收取）/ long（负费率收取）
+	HedgeVenue       string // 对冲腿：binance_spot 或 trader:<id>
+	Quantity         float64
+	PerpEntryPrice   float64
+	HedgeEntryPrice  float64
+	EntryRatePct     float64 // 开仓时的费率差(%/8h)
+	FundingCollected float64 // 累计估算资金费收入(USDT)
+	LastAccrualAt    time.Time
+	OpenedAt         time.Time
+	ClosedAt         *time.Time // 平仓时间（nil表示持仓中）
+	PerpExitPrice    float64
+	HedgeExitPrice   float64
+	RealizedPnL      float64 // 两腿价差盈亏 + 资金费收入(USDT)
+	CloseReason      string
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// 交易所收入类型（与币安收入历史的incomeType一致）
+const (
+	IncomeRealizedPnL = "REALIZED_PNL"    // 已实现盈亏
+	IncomeFundingFee  = "FUNDING_FEE"     // 资金费（收取为正、支付为负）
+	IncomeCommission  = "COMMISSION"      // 手续费（负数）
+	IncomeInsurance   = "INSURANCE_CLEAR" // 强平清算费/保险基金
+)
+
+// IncomeRecord 交易所收入历史（已实现盈亏、资金费、手续费、清算费等，不含资金划转）
+type IncomeRecord struct {
+	ID         int64
+	TraderID   string
+	TranID     string // 交易所流水ID（与类型、币种一起去重）
+	IncomeType string
+	Symbol     string
+	Asset      string
+	Amount     float64
+	Timestamp  time.Time
+	CreatedAt  time.Time
+}
@@ -14,4 +14,6 @@ type AILearningSummary struct {
 	AvgPnL float64
 	CreatedAt time.Time
 	IsActive bool
+	ActivatedAt time.Time // 生效时间（旧数据为空时取CreatedAt）
+	DeactivatedAt time.Time // 停用时间（被新总结替换或手动停用，零值表示未停用）
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MarketBreadth 市场广度表（每个周期记录一次）
+type MarketBreadth struct {
+	ID                   int64
+	TraderID             string
+	Timestamp            time.Time
+	CycleNumber          int
+	BTCDominance         float64 // BTC市值占比(%)，获取失败时为0
+	AboveEMA50Pct        float64 // 价格在EMA50上方的币种比例(%)
+	SymbolCount          int     // 参与统计的币种数
+	AggregateOIChangePct float64 // 按持仓价值加权的OI变化(%)
+	OISymbolCount        int     // 参与OI统计的币种数
+}
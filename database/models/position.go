@@ -8,6 +8,21 @@ type PositionOpenTime struct {
 	Symbol string
 	Side string
 	OpenTimeMs int64
+	StopLoss float64   // 当前止损价（0=未知）
+	TakeProfit float64 // 当前止盈价（0=未知）
+	CreatedAt time.Time
+}
+
+// PositionEntrySnapshot 持仓开仓指标快照表（平仓时用于填充TradeOutcome）
+type PositionEntrySnapshot struct {
+	TraderID string
+	Symbol string
+	Side string
+	EntryMACD float64
+	EntryRSI float64
+	EntryVolRatio float64
+	EntryReason string
+	Regime string
 	CreatedAt time.Time
 }
 
@@ -15,5 +30,32 @@ type PositionOpenTime struct {
 type TraderState struct {
 	TraderID string
 	IsPaused bool
+	State string // running / paused / risk_stopped / close_only（旧数据为空，按IsPaused恢复）
+	Reason string
+	Until *time.Time // 风控停止的到期时间
 	UpdatedAt time.Time
 }
+
+// DrawdownLock 回撤锁状态（净值按扣除入金/出金后的交易净值计）
+type DrawdownLock struct {
+	TraderID   string
+	PeakEquity float64
+	Locked     bool
+	LockedAt   *time.Time
+	Reason     string
+	UpdatedAt  time.Time
+}
+
+// ExitLevelChange 持仓止损/止盈调整记录（update_stop_loss / update_take_profit）
+type ExitLevelChange struct {
+	ID int64
+	TraderID string
+	Symbol string
+	Side string
+	LevelType string // stop_loss 或 take_profit
+	OldPrice float64 // 调整前价格（0=未知）
+	NewPrice float64
+	MarkPrice float64 // 调整时的标记价格
+	Reason string
+	CreatedAt time.Time
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// QualityScore 按某一版本评分规则重新计算的决策质量（每条决策记录中的每个决策一行）
+type QualityScore struct {
+	ID            int64
+	TraderID      string
+	RecordID      int64
+	DecisionIndex int    // 决策在决策JSON中的序号（从0开始）
+	Version       string // 评分规则版本
+	Symbol        string
+	Action        string
+	Score         float64
+	Grade         string
+	Issues        string  // 问题列表（JSON数组）
+	OriginalScore float64 // 决策时记录的评分
+	OriginalGrade string  // 决策时记录的等级（为空表示决策时没有评分，如操作员手动注入的决策）
+	CreatedAt     time.Time
+}
+
+// QualityVersionSummary 某一评分版本的汇总
+type QualityVersionSummary struct {
+	Version  string
+	Records  int // 涉及的决策记录数
+	Count    int // 评分的决策数
+	AvgScore float64
+}
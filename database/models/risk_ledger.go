@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RiskLedgerEntry 风险预算台账表（开仓占用风险，平仓释放）
+type RiskLedgerEntry struct {
+	ID         int64
+	TraderID   string
+	Symbol     string
+	Side       string
+	EntryPrice float64
+	StopLoss   float64
+	Quantity   float64
+	RiskUSD    float64 // 占用的风险（|入场价-止损价| × 数量）
+	OpenedAt   time.Time
+	ReleasedAt *time.Time // 平仓释放时间（nil表示仍占用）
+}
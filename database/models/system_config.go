@@ -61,10 +61,41 @@ type TraderConfig struct {
 	// 数据优化配置
 	CompactMode bool // true=紧凑模式（减少数据量），false=完整模式
 	
+	// 候选币种来源（pool=币种池服务，static=仅使用默认币种列表，空=跟随全局use_default_coins）
+	CoinSource string
+	
+	// 独立币种池API配置（空=使用全局配置）
+	CoinPoolAPIURL         string
+	OITopAPIURL            string
+	CoinPoolAuthHeader     string // 认证头："Bearer xxx" 或 "X-API-Key: xxx"
+	CoinPoolRefreshSeconds int    // 刷新间隔（秒，0=每个周期都请求）
+	
+	// 行情数据源（空=binance，可选 bybit/hyperliquid 或 primary:/average: 组合）
+	MarketDataSource string
+	
+	// 提示词语言（空=zh，可选 en）
+	PromptLanguage string
+	
+	// AI生成参数（JSON：temperature/top_p/max_tokens/reasoning_effort，空=使用默认值）
+	AIParams string
+	
+	// 决策审核模型（JSON：ai_model/model_name/rules，空=不启用）
+	Critic string
+	
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// TraderTemplate 命名的Trader配置模板（不含API密钥，可据此快速创建新Trader）
+type TraderTemplate struct {
+	ID          int64
+	Name        string // 模板名称（唯一），如 conservative-btc-only
+	Description string
+	ConfigJSON  string // 模板中的交易/风控参数（JSON）
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
 // MarketDataConfig 市场数据配置
 type MarketDataConfig struct {
 	ID              int64
@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// 交易结果来源
+const (
+	TradeSourceBot    = "bot"    // 本系统开平仓
+	TradeSourceImport = "import" // 从交易所历史成交导入
+)
+
 // TradeOutcome 交易结果表（用于统计分析）
 type TradeOutcome struct {
 	ID int64
@@ -20,9 +26,21 @@ type TradeOutcome struct {
 	OpenTime time.Time
 	CloseTime time.Time
 	WasStopLoss bool
+	TakeProfitHit bool // 是否止盈单触发（与WasStopLoss互斥，都为false表示主动平仓或无法判断）
 	EntryReason string
 	ExitReason string
 	IsPremature bool
 	FailureType string
+	EntryMACD float64 // 开仓时MACD
+	EntryRSI float64 // 开仓时RSI7
+	EntryVolRatio float64 // 开仓时成交量比率（当前量/均量）
+	Regime string // 开仓时的市场状态
+	Source string // 记录来源：bot（本系统交易）/ import（从交易所历史成交导入）
+	OpenRecordID int64  // 开仓的决策记录ID（0表示未关联，如导入的交易或同一周期内开平仓）
+	OpenActionID int64  // 开仓的决策动作ID
+	CloseRecordID int64 // 平仓的决策记录ID（自动平仓为检测到平仓的周期）
+	CloseActionID int64 // 平仓的决策动作ID
+	OpenCycle int       // 开仓决策的周期编号（查询时关联）
+	CloseCycle int      // 平仓决策的周期编号（查询时关联）
 	CreatedAt time.Time
 }
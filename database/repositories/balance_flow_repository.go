@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"database/sql"
+	"nofx/database/models"
+	"time"
+)
+
+// BalanceFlowRepository 资金划转台账数据访问层
+type BalanceFlowRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewBalanceFlowRepository 创建资金划转台账仓储
+func NewBalanceFlowRepository(db *sql.DB, traderID string) *BalanceFlowRepository {
+	return &BalanceFlowRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// Insert 记录一笔划转（同一划转ID已存在时忽略），返回是否新增
+func (r *BalanceFlowRepository) Insert(flow *models.BalanceFlow) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT OR IGNORE INTO balance_flows (trader_id, tran_id, asset, amount, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+	`, r.traderID, flow.TranID, flow.Asset, flow.Amount, flow.Timestamp)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetAll 获取全部划转记录（按时间正序）
+func (r *BalanceFlowRepository) GetAll() ([]*models.BalanceFlow, error) {
+	rows, err := r.db.Query(`
+		SELECT id, trader_id, tran_id, asset, amount, timestamp, created_at
+		FROM balance_flows
+		WHERE trader_id = ?
+		ORDER BY timestamp ASC, id ASC
+	`, r.traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flows []*models.BalanceFlow
+	for rows.Next() {
+		f := &models.BalanceFlow{}
+		if err := rows.Scan(&f.ID, &f.TraderID, &f.TranID, &f.Asset, &f.Amount, &f.Timestamp, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		flows = append(flows, f)
+	}
+	return flows, nil
+}
+
+// GetLatestTime 获取最近一笔划转的时间（没有记录时返回零值）
+func (r *BalanceFlowRepository) GetLatestTime() (time.Time, error) {
+	var ts sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT timestamp FROM balance_flows WHERE trader_id = ?
+		ORDER BY timestamp DESC LIMIT 1
+	`, r.traderID).Scan(&ts)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.Time, nil
+}
+
+// GetNetTotal 获取累计净划转金额（入金 - 出金）
+func (r *BalanceFlowRepository) GetNetTotal() (float64, error) {
+	var total float64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0) FROM balance_flows WHERE trader_id = ?
+	`, r.traderID).Scan(&total)
+	return total, err
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"nofx/database/models"
+	"strings"
 	"time"
 )
 
@@ -15,6 +16,7 @@ type PromptConfig struct {
 	Title        string
 	Content      string
 	PromptType   string
+	Language     string
 	Enabled      bool
 	DisplayOrder int
 	UpdatedAt    time.Time
@@ -40,7 +42,7 @@ func NewConfigRepository(db *sql.DB) *ConfigRepository {
 // GetAll 获取所有prompt配置
 func (r *ConfigRepository) GetAll() ([]*models.PromptConfig, error) {
 	query := `
-		SELECT id, section_name, title, content, prompt_type, enabled, display_order, updated_at
+		SELECT id, section_name, title, content, prompt_type, COALESCE(language, 'zh'), enabled, display_order, updated_at
 		FROM prompt_configs
 		ORDER BY display_order ASC
 	`
@@ -55,7 +57,7 @@ func (r *ConfigRepository) GetAll() ([]*models.PromptConfig, error) {
 	for rows.Next() {
 		cfg := &models.PromptConfig{}
 		err := rows.Scan(&cfg.ID, &cfg.SectionName, &cfg.Title, &cfg.Content,
-			&cfg.PromptType, &cfg.Enabled, &cfg.DisplayOrder, &cfg.UpdatedAt)
+			&cfg.PromptType, &cfg.Language, &cfg.Enabled, &cfg.DisplayOrder, &cfg.UpdatedAt)
 		if err != nil {
 			continue
 		}
@@ -68,7 +70,7 @@ func (r *ConfigRepository) GetAll() ([]*models.PromptConfig, error) {
 // GetEnabled 获取启用的prompt配置
 func (r *ConfigRepository) GetEnabled() ([]*models.PromptConfig, error) {
 	query := `
-		SELECT id, section_name, title, content, prompt_type, enabled, display_order, updated_at
+		SELECT id, section_name, title, content, prompt_type, COALESCE(language, 'zh'), enabled, display_order, updated_at
 		FROM prompt_configs
 		WHERE enabled = 1
 		ORDER BY display_order ASC
@@ -84,7 +86,7 @@ func (r *ConfigRepository) GetEnabled() ([]*models.PromptConfig, error) {
 	for rows.Next() {
 		cfg := &models.PromptConfig{}
 		err := rows.Scan(&cfg.ID, &cfg.SectionName, &cfg.Title, &cfg.Content,
-			&cfg.PromptType, &cfg.Enabled, &cfg.DisplayOrder, &cfg.UpdatedAt)
+			&cfg.PromptType, &cfg.Language, &cfg.Enabled, &cfg.DisplayOrder, &cfg.UpdatedAt)
 		if err != nil {
 			continue
 		}
@@ -94,16 +96,25 @@ func (r *ConfigRepository) GetEnabled() ([]*models.PromptConfig, error) {
 	return configs, nil
 }
 
-// GetByType 获取指定类型的启用配置
-func (r *ConfigRepository) GetByType(promptType string) ([]*PromptConfig, error) {
+// GetByType 获取指定类型和语言的启用配置（该语言没有任何配置时使用默认语言的配置）
+func (r *ConfigRepository) GetByType(promptType, language string) ([]*PromptConfig, error) {
+	configs, err := r.getByTypeAndLanguage(promptType, language)
+	if err != nil || len(configs) > 0 || language == defaultPromptLanguage {
+		return configs, err
+	}
+	return r.getByTypeAndLanguage(promptType, defaultPromptLanguage)
+}
+
+// getByTypeAndLanguage 获取指定类型和语言的启用配置
+func (r *ConfigRepository) getByTypeAndLanguage(promptType, language string) ([]*PromptConfig, error) {
 	query := `
-		SELECT id, section_name, title, content, prompt_type, enabled, display_order, updated_at
+		SELECT id, section_name, title, content, prompt_type, COALESCE(language, 'zh'), enabled, display_order, updated_at
 		FROM prompt_configs
-		WHERE enabled = 1 AND prompt_type = ?
+		WHERE enabled = 1 AND prompt_type = ? AND COALESCE(language, 'zh') = ?
 		ORDER BY display_order ASC
 	`
 
-	rows, err := r.db.Query(query, promptType)
+	rows, err := r.db.Query(query, promptType, language)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +124,7 @@ func (r *ConfigRepository) GetByType(promptType string) ([]*PromptConfig, error)
 	for rows.Next() {
 		cfg := &PromptConfig{}
 		err := rows.Scan(&cfg.ID, &cfg.SectionName, &cfg.Title, &cfg.Content,
-			&cfg.PromptType, &cfg.Enabled, &cfg.DisplayOrder, &cfg.UpdatedAt)
+			&cfg.PromptType, &cfg.Language, &cfg.Enabled, &cfg.DisplayOrder, &cfg.UpdatedAt)
 		if err != nil {
 			continue
 		}
@@ -127,21 +138,71 @@ func (r *ConfigRepository) GetByType(promptType string) ([]*PromptConfig, error)
 func (r *ConfigRepository) Update(cfg *models.PromptConfig) error {
 	query := `
 		UPDATE prompt_configs 
-		SET title = ?, content = ?, prompt_type = ?, enabled = ?, display_order = ?, updated_at = CURRENT_TIMESTAMP
+		SET title = ?, content = ?, prompt_type = ?, language = ?, enabled = ?, display_order = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE section_name = ?
 	`
 
-	_, err := r.db.Exec(query, cfg.Title, cfg.Content, cfg.PromptType, cfg.Enabled, cfg.DisplayOrder, cfg.SectionName)
+	_, err := r.db.Exec(query, cfg.Title, cfg.Content, cfg.PromptType, promptLanguage(cfg.Language), cfg.Enabled, cfg.DisplayOrder, cfg.SectionName)
 	return err
 }
 
 // Insert 添加新的prompt配置
 func (r *ConfigRepository) Insert(cfg *models.PromptConfig) error {
-	query := `INSERT INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, cfg.SectionName, cfg.Title, cfg.Content, cfg.Enabled, cfg.DisplayOrder, cfg.PromptType)
+	query := `INSERT INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type, language) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, cfg.SectionName, cfg.Title, cfg.Content, cfg.Enabled, cfg.DisplayOrder, cfg.PromptType, promptLanguage(cfg.Language))
 	return err
 }
 
+// Restore 按备份恢复prompt配置（同名section覆盖，备份中没有的section禁用但不删除）
+func (r *ConfigRepository) Restore(configs []*models.PromptConfig) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	sections := make([]interface{}, 0, len(configs))
+	for _, cfg := range configs {
+		_, err := tx.Exec(`
+			INSERT INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type, language, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(section_name) DO UPDATE SET
+				title = excluded.title,
+				content = excluded.content,
+				enabled = excluded.enabled,
+				display_order = excluded.display_order,
+				prompt_type = excluded.prompt_type,
+				language = excluded.language,
+				updated_at = CURRENT_TIMESTAMP
+		`, cfg.SectionName, cfg.Title, cfg.Content, cfg.Enabled, cfg.DisplayOrder, cfg.PromptType, promptLanguage(cfg.Language))
+		if err != nil {
+			return fmt.Errorf("恢复prompt配置 %s 失败: %w", cfg.SectionName, err)
+		}
+		sections = append(sections, cfg.SectionName)
+	}
+
+	query := `UPDATE prompt_configs SET enabled = 0, updated_at = CURRENT_TIMESTAMP WHERE enabled = 1`
+	if len(sections) > 0 {
+		query += ` AND section_name NOT IN (?` + strings.Repeat(`, ?`, len(sections)-1) + `)`
+	}
+	if _, err := tx.Exec(query, sections...); err != nil {
+		return fmt.Errorf("禁用备份外的prompt配置失败: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// defaultPromptLanguage 未标注语言的prompt配置视为中文
+const defaultPromptLanguage = "zh"
+
+// promptLanguage 标准化prompt配置的语言标签（空值使用默认语言）
+func promptLanguage(language string) string {
+	if language == "" {
+		return defaultPromptLanguage
+	}
+	return language
+}
+
 // Delete 删除prompt配置
 func (r *ConfigRepository) Delete(sectionName string) (int64, error) {
 	query := `DELETE FROM prompt_configs WHERE section_name = ?`
@@ -162,7 +223,7 @@ func (r *ConfigRepository) initDefaults() error {
 	}
 
 	if count > 0 {
-		return nil // 已经初始化过了
+		return r.initEnglishDefaults() // 已经初始化过了（旧版本数据库补充英文默认配置）
 	}
 
 	log.Println("🔧 初始化默认Prompt配置...")
@@ -256,5 +317,105 @@ func (r *ConfigRepository) initDefaults() error {
 	}
 
 	log.Println("✓ 默认Prompt配置初始化完成")
+	return r.initEnglishDefaults()
+}
+
+// initEnglishDefaults 初始化英文默认prompt配置（已有任何英文配置时跳过）
+// section_name 全局唯一，英文配置使用 _en 后缀
+func (r *ConfigRepository) initEnglishDefaults() error {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM prompt_configs WHERE language = 'en'").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	defaults := []models.PromptConfig{
+		{
+			SectionName:  "core_mission_en",
+			Title:        "🎯 Core Objective",
+			PromptType:   "system",
+			DisplayOrder: 1,
+			Enabled:      true,
+			Content: `**Maximize the Sharpe Ratio**
+
+Sharpe ratio = average return / return volatility
+
+**This means**:
+- ✅ High-quality trades (high win rate, large reward/risk) → higher Sharpe
+- ✅ Steady returns, controlled drawdowns → higher Sharpe
+- ✅ Patient holding, letting profits run → higher Sharpe
+- ❌ Frequent trading, small wins and losses → more volatility, much lower Sharpe
+- ❌ Overtrading, fee drag → direct losses
+- ❌ Closing too early, jumping in and out → missing big moves
+
+**Key insight**: the system scans every 3 minutes, but that does not mean you must trade every time!
+Most of the time the answer should be wait or hold; only open positions on excellent opportunities.`,
+		},
+		{
+			SectionName:  "hard_constraints_en",
+			Title:        "⚖️ Hard Constraints (Risk Control)",
+			PromptType:   "system",
+			DisplayOrder: 2,
+			Enabled:      true,
+			Content: `1. **Risk/reward**: must be ≥ 1:3 (risk 1% to make 3%+)
+
+2. **Stop loss** (critical! avoid getting shaken out by noise):
+   - Set the stop distance based on ATR14 (average true range)
+   - **Minimum stop distance**: 1.5× ATR14 (leave enough room for volatility)
+   - Long stop = current price - (1.5~2.5)×ATR14
+   - Short stop = current price + (1.5~2.5)×ATR14
+   - Key support/resistance can guide the stop, but never closer than 1.5× ATR
+   - Example: ETH at 3888, ATR14=50 → stop at least 75 away (1.5×50), i.e. long stop ≤ 3813
+
+3. **Take profit**:
+   - Keep risk/reward ≥ 3:1
+   - With a 2% stop distance, take profit at least 6% away
+   - Prior highs/lows and Fibonacci extensions can be used as targets
+
+4. **Max positions**: set by configuration (the user prompt shows position status, check the limit)
+
+5. **Position size per coin**:
+   - Altcoins: {{altMinSize}}-{{altMaxSize}} USDT ({{altcoinLeverage}}x leverage)
+   - BTC/ETH: {{btcMinSize}}-{{btcMaxSize}} USDT ({{btcEthLeverage}}x leverage)
+
+6. **Margin**: total usage ≤ 90%`,
+		},
+		{
+			SectionName:  "long_short_balance_en",
+			Title:        "⚖️ Long/Short Balance",
+			PromptType:   "system",
+			DisplayOrder: 3,
+			Enabled:      true,
+			Content: `**Core principle**: longs and shorts are equally valid ways to make money!
+
+**How to decide**:
+- 📈 Uptrend → long (price>EMA20>EMA50, MACD>0, RSI>50, rising volume)
+- 📉 Downtrend → short (price<EMA20<EMA50, MACD<0, RSI<50, rising volume)
+- ⏸️ Ranging market → wait (indicators conflict, no clear direction)
+
+**Key equalities**:
+- Profit from a 5% rise when long = profit from a 5% drop when short
+- Risk of a long = risk of a short
+- Success depends on reading the trend correctly, not on direction
+
+**No bias**:
+- ❌ Only going long (missing downside moves)
+- ❌ Only going short (missing upside moves)
+- ✅ Analyze the market objectively and follow the trend`,
+		},
+	}
+
+	for _, cfg := range defaults {
+		_, err := r.db.Exec(`
+			INSERT OR IGNORE INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type, language)
+			VALUES (?, ?, ?, ?, ?, ?, 'en')
+		`, cfg.SectionName, cfg.Title, cfg.Content, cfg.Enabled, cfg.DisplayOrder, cfg.PromptType)
+
+		if err != nil {
+			return fmt.Errorf("插入英文默认prompt配置失败 [%s]: %w", cfg.SectionName, err)
+		}
+	}
 	return nil
 }
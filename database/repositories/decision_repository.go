@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"nofx/database/models"
+	"sort"
+	"strings"
+	"time"
 )
 
 // DecisionRepository 决策记录数据访问层
@@ -26,8 +29,11 @@ func (r *DecisionRepository) Insert(record *models.DecisionRecord) (int64, error
 	INSERT INTO decision_records (
 		trader_id, cycle_number, timestamp, system_prompt, input_prompt, cot_trace, decision_json,
 		success, error_message, total_balance, available_balance, total_unrealized_profit,
-		position_count, margin_used_pct
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		position_count, margin_used_pct, ai_provider, prompt_hash, cached, regime, latency_ms,
+		learning_summary_id, manual, schema_version, realized_pnl_delta, closed_trades, ai_params,
+		critic_review, critic_reviewed, critic_vetoed, market_fetch_ms, prompt_build_ms, ai_latency_ms,
+		parse_validate_ms, execution_ms, db_write_ms, symbols_fetched, symbols_failed
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.Exec(query,
@@ -45,6 +51,28 @@ func (r *DecisionRepository) Insert(record *models.DecisionRecord) (int64, error
 		record.TotalUnrealizedProfit,
 		record.PositionCount,
 		record.MarginUsedPct,
+		record.AIProvider,
+		record.PromptHash,
+		record.Cached,
+		record.Regime,
+		record.LatencyMs,
+		record.LearningSummaryID,
+		record.Manual,
+		record.SchemaVersion,
+		record.RealizedPnLDelta,
+		record.ClosedTrades,
+		record.AIParams,
+		record.CriticReview,
+		record.CriticReviewed,
+		record.CriticVetoed,
+		record.MarketFetchMs,
+		record.PromptBuildMs,
+		record.AILatencyMs,
+		record.ParseValidateMs,
+		record.ExecutionMs,
+		record.DBWriteMs,
+		record.SymbolsFetched,
+		record.SymbolsFailed,
 	)
 
 	if err != nil {
@@ -54,10 +82,8 @@ func (r *DecisionRepository) Insert(record *models.DecisionRecord) (int64, error
 	return result.LastInsertId()
 }
 
-// GetLatest 获取最近N条决策记录
-func (r *DecisionRepository) GetLatest(limit int) ([]*models.DecisionRecord, error) {
-	query := `
-	SELECT id, trader_id, cycle_number, timestamp, 
+// decisionRecordColumns 决策记录查询列（与 scanDecisionRecord 的顺序一致）
+const decisionRecordColumns = `id, trader_id, cycle_number, timestamp, 
 		COALESCE(system_prompt, '') as system_prompt, 
 		COALESCE(input_prompt, '') as input_prompt, 
 		COALESCE(cot_trace, '') as cot_trace, 
@@ -65,7 +91,137 @@ func (r *DecisionRepository) GetLatest(limit int) ([]*models.DecisionRecord, err
 		success, 
 		COALESCE(error_message, '') as error_message, 
 		total_balance, available_balance, total_unrealized_profit,
-		position_count, margin_used_pct
+		position_count, margin_used_pct,
+		COALESCE(ai_provider, '') as ai_provider,
+		COALESCE(prompt_hash, '') as prompt_hash,
+		COALESCE(cached, 0) as cached,
+		COALESCE(regime, '') as regime,
+		COALESCE(latency_ms, 0) as latency_ms,
+		COALESCE(learning_summary_id, 0) as learning_summary_id,
+		COALESCE(manual, 0) as manual,
+		COALESCE(schema_version, 0) as schema_version,
+		COALESCE(realized_pnl_delta, 0) as realized_pnl_delta,
+		COALESCE(closed_trades, 0) as closed_trades,
+		COALESCE(ai_params, '') as ai_params,
+		COALESCE(critic_review, '') as critic_review,
+		COALESCE(critic_reviewed, 0) as critic_reviewed,
+		COALESCE(critic_vetoed, 0) as critic_vetoed,
+		COALESCE(market_fetch_ms, 0) as market_fetch_ms,
+		COALESCE(prompt_build_ms, 0) as prompt_build_ms,
+		COALESCE(ai_latency_ms, 0) as ai_latency_ms,
+		COALESCE(parse_validate_ms, 0) as parse_validate_ms,
+		COALESCE(execution_ms, 0) as execution_ms,
+		COALESCE(db_write_ms, 0) as db_write_ms,
+		COALESCE(symbols_fetched, 0) as symbols_fetched,
+		COALESCE(symbols_failed, 0) as symbols_failed`
+
+// decisionRecordLightColumns 不含Prompt和思维链的查询列（顺序与 decisionRecordColumns 一致）
+var decisionRecordLightColumns = strings.NewReplacer(
+	"COALESCE(system_prompt, '')", "''",
+	"COALESCE(input_prompt, '')", "''",
+	"COALESCE(cot_trace, '')", "''",
+).Replace(decisionRecordColumns)
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDecisionRecord 扫描一行决策记录
+func scanDecisionRecord(row rowScanner) (*models.DecisionRecord, error) {
+	record := &models.DecisionRecord{}
+	err := row.Scan(
+		&record.ID,
+		&record.TraderID,
+		&record.CycleNumber,
+		&record.Timestamp,
+		&record.SystemPrompt,
+		&record.InputPrompt,
+		&record.CoTTrace,
+		&record.DecisionJSON,
+		&record.Success,
+		&record.ErrorMessage,
+		&record.TotalBalance,
+		&record.AvailableBalance,
+		&record.TotalUnrealizedProfit,
+		&record.PositionCount,
+		&record.MarginUsedPct,
+		&record.AIProvider,
+		&record.PromptHash,
+		&record.Cached,
+		&record.Regime,
+		&record.LatencyMs,
+		&record.LearningSummaryID,
+		&record.Manual,
+		&record.SchemaVersion,
+		&record.RealizedPnLDelta,
+		&record.ClosedTrades,
+		&record.AIParams,
+		&record.CriticReview,
+		&record.CriticReviewed,
+		&record.CriticVetoed,
+		&record.MarketFetchMs,
+		&record.PromptBuildMs,
+		&record.AILatencyMs,
+		&record.ParseValidateMs,
+		&record.ExecutionMs,
+		&record.DBWriteMs,
+		&record.SymbolsFetched,
+		&record.SymbolsFailed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetMaxCycleNumber 获取已保存的最大周期编号（没有记录时返回0）
+func (r *DecisionRepository) GetMaxCycleNumber() (int, error) {
+	var maxCycle int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(MAX(cycle_number), 0) FROM decision_records WHERE trader_id = ?
+	`, r.traderID).Scan(&maxCycle)
+	return maxCycle, err
+}
+
+// GetPreviousID 获取指定记录之前最近一条决策记录的ID（beforeID为0时返回最新记录的ID，没有记录时返回0）
+func (r *DecisionRepository) GetPreviousID(beforeID int64) (int64, error) {
+	query := `SELECT id FROM decision_records WHERE trader_id = ?`
+	args := []interface{}{r.traderID}
+	if beforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, beforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT 1`
+
+	var id int64
+	err := r.db.QueryRow(query, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// GetFirstTimestamp 获取最早一条决策记录的时间（没有记录时返回零值）
+func (r *DecisionRepository) GetFirstTimestamp() (time.Time, error) {
+	var ts sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT timestamp FROM decision_records WHERE trader_id = ?
+		ORDER BY timestamp ASC LIMIT 1
+	`, r.traderID).Scan(&ts)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.Time, nil
+}
+
+// GetLatest 获取最近N条决策记录
+func (r *DecisionRepository) GetLatest(limit int) ([]*models.DecisionRecord, error) {
+	query := `
+	SELECT ` + decisionRecordColumns + `
 	FROM decision_records
 	WHERE trader_id = ?
 	ORDER BY timestamp DESC
@@ -80,24 +236,7 @@ func (r *DecisionRepository) GetLatest(limit int) ([]*models.DecisionRecord, err
 
 	var records []*models.DecisionRecord
 	for rows.Next() {
-		record := &models.DecisionRecord{}
-		err := rows.Scan(
-			&record.ID,
-			&record.TraderID,
-			&record.CycleNumber,
-			&record.Timestamp,
-			&record.SystemPrompt,
-			&record.InputPrompt,
-			&record.CoTTrace,
-			&record.DecisionJSON,
-			&record.Success,
-			&record.ErrorMessage,
-			&record.TotalBalance,
-			&record.AvailableBalance,
-			&record.TotalUnrealizedProfit,
-			&record.PositionCount,
-			&record.MarginUsedPct,
-		)
+		record, err := scanDecisionRecord(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -112,13 +251,82 @@ func (r *DecisionRepository) GetLatest(limit int) ([]*models.DecisionRecord, err
 	return records, nil
 }
 
+// Query 分页查询决策记录（按时间倒序分页，每页内从旧到新排列），同时返回过滤后的总数
+func (r *DecisionRepository) Query(opts QueryOptions) ([]*models.DecisionRecord, int, error) {
+	where := &whereBuilder{}
+	where.add("trader_id = ?", r.traderID)
+	if !opts.Since.IsZero() {
+		where.add("timestamp >= ?", opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		where.add("timestamp < ?", opts.Until)
+	}
+	if opts.Success != nil {
+		where.add("success = ?", *opts.Success)
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM decision_records WHERE `+where.String(), where.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计决策记录失败: %w", err)
+	}
+
+	if opts.BeforeID > 0 {
+		where.add("id < ?", opts.BeforeID)
+	}
+	columns := decisionRecordColumns
+	if opts.OmitHeavy {
+		columns = decisionRecordLightColumns
+	}
+	page, pageArgs := opts.pageClause()
+	query := `SELECT ` + columns + ` FROM decision_records WHERE ` + where.String() + ` ORDER BY id DESC` + page
+
+	rows, err := r.db.Query(query, append(where.args, pageArgs...)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询决策记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*models.DecisionRecord
+	for rows.Next() {
+		record, err := scanDecisionRecord(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, record)
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, total, nil
+}
+
+// GetByID 根据ID获取决策记录（不存在时返回nil）
+func (r *DecisionRepository) GetByID(id int64) (*models.DecisionRecord, error) {
+	query := `
+	SELECT ` + decisionRecordColumns + `
+	FROM decision_records
+	WHERE trader_id = ? AND id = ?
+	`
+
+	record, err := scanDecisionRecord(r.db.QueryRow(query, r.traderID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询决策记录失败: %w", err)
+	}
+	return record, nil
+}
+
 // InsertAction 插入决策动作
 func (r *DecisionRepository) InsertAction(action *models.DecisionAction) error {
 	query := `
 	INSERT INTO decision_actions (
 		record_id, action, symbol, quantity, leverage, price, order_id,
-		timestamp, success, error, was_stop_loss
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		timestamp, success, error, was_stop_loss, executed_qty, avg_price, price_drift_pct,
+		notional_usd, margin_usd, adjustment, error_class, book_snapshot
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.Exec(query,
@@ -133,6 +341,14 @@ func (r *DecisionRepository) InsertAction(action *models.DecisionAction) error {
 		action.Success,
 		action.Error,
 		action.WasStopLoss,
+		action.ExecutedQty,
+		action.AvgPrice,
+		action.PriceDriftPct,
+		action.NotionalUSD,
+		action.MarginUSD,
+		action.Adjustment,
+		action.ErrorClass,
+		action.BookSnapshot,
 	)
 
 	return err
@@ -142,7 +358,10 @@ func (r *DecisionRepository) InsertAction(action *models.DecisionAction) error {
 func (r *DecisionRepository) GetActions(recordID int64) ([]*models.DecisionAction, error) {
 	query := `
 	SELECT id, record_id, action, symbol, quantity, leverage, price, order_id,
-		timestamp, success, error, was_stop_loss
+		timestamp, success, error, was_stop_loss,
+		COALESCE(executed_qty, 0), COALESCE(avg_price, 0), COALESCE(price_drift_pct, 0),
+		COALESCE(notional_usd, 0), COALESCE(margin_usd, 0), COALESCE(adjustment, ''),
+		COALESCE(error_class, ''), COALESCE(book_snapshot, '')
 	FROM decision_actions
 	WHERE record_id = ?
 	ORDER BY timestamp ASC
@@ -170,6 +389,14 @@ func (r *DecisionRepository) GetActions(recordID int64) ([]*models.DecisionActio
 			&action.Success,
 			&action.Error,
 			&action.WasStopLoss,
+			&action.ExecutedQty,
+			&action.AvgPrice,
+			&action.PriceDriftPct,
+			&action.NotionalUSD,
+			&action.MarginUSD,
+			&action.Adjustment,
+			&action.ErrorClass,
+			&action.BookSnapshot,
 		)
 		if err != nil {
 			continue
@@ -204,6 +431,124 @@ func (r *DecisionRepository) InsertPositionSnapshot(position *models.PositionSna
 	return err
 }
 
+// GetPositionSnapshots 查询指定记录的持仓快照
+func (r *DecisionRepository) GetPositionSnapshots(recordID int64) ([]*models.PositionSnapshot, error) {
+	query := `
+	SELECT id, record_id, symbol, side, position_amt, entry_price, mark_price,
+		unrealized_profit, leverage, liquidation_price
+	FROM position_snapshots
+	WHERE record_id = ?
+	`
+
+	rows, err := r.db.Query(query, recordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []*models.PositionSnapshot
+	for rows.Next() {
+		pos := &models.PositionSnapshot{}
+		if err := rows.Scan(
+			&pos.ID,
+			&pos.RecordID,
+			&pos.Symbol,
+			&pos.Side,
+			&pos.PositionAmt,
+			&pos.EntryPrice,
+			&pos.MarkPrice,
+			&pos.UnrealizedProfit,
+			&pos.Leverage,
+			&pos.LiquidationPrice,
+		); err != nil {
+			return nil, err
+		}
+		positions = append(positions, pos)
+	}
+
+	return positions, nil
+}
+
+// GetCandidateCoins 查询指定记录的候选币种
+func (r *DecisionRepository) GetCandidateCoins(recordID int64) ([]string, error) {
+	rows, err := r.db.Query(`SELECT symbol FROM candidate_coins WHERE record_id = ? ORDER BY id ASC`, recordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols, nil
+}
+
+// GetCandidatePools 查询最近N个有候选币种的周期的候选池（按时间从旧到新，untilID>0时只查该记录及之前的周期）
+func (r *DecisionRepository) GetCandidatePools(untilID int64, limit int) ([]*models.CandidatePool, error) {
+	query := `
+	SELECT id, cycle_number, timestamp FROM decision_records dr
+	WHERE trader_id = ? AND EXISTS (SELECT 1 FROM candidate_coins cc WHERE cc.record_id = dr.id)`
+	args := []interface{}{r.traderID}
+	if untilID > 0 {
+		query += ` AND id <= ?`
+		args = append(args, untilID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var pools []*models.CandidatePool
+	byID := make(map[int64]*models.CandidatePool)
+	for rows.Next() {
+		pool := &models.CandidatePool{}
+		if err := rows.Scan(&pool.RecordID, &pool.CycleNumber, &pool.Timestamp); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pools = append(pools, pool)
+		byID[pool.RecordID] = pool
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(pools) == 0 {
+		return pools, nil
+	}
+
+	// 反转数组，让时间从旧到新排列
+	for i, j := 0, len(pools)-1; i < j; i, j = i+1, j-1 {
+		pools[i], pools[j] = pools[j], pools[i]
+	}
+
+	coinRows, err := r.db.Query(`SELECT record_id, symbol FROM candidate_coins WHERE record_id BETWEEN ? AND ? ORDER BY id ASC`,
+		pools[0].RecordID, pools[len(pools)-1].RecordID)
+	if err != nil {
+		return nil, err
+	}
+	defer coinRows.Close()
+	for coinRows.Next() {
+		var recordID int64
+		var symbol string
+		if err := coinRows.Scan(&recordID, &symbol); err != nil {
+			return nil, err
+		}
+		if pool, ok := byID[recordID]; ok {
+			pool.Symbols = append(pool.Symbols, symbol)
+		}
+	}
+	return pools, coinRows.Err()
+}
+
 // InsertCandidateCoin 插入候选币种
 func (r *DecisionRepository) InsertCandidateCoin(recordID int64, symbol string) error {
 	query := `INSERT INTO candidate_coins (record_id, symbol) VALUES (?, ?)`
@@ -211,6 +556,213 @@ func (r *DecisionRepository) InsertCandidateCoin(recordID int64, symbol string)
 	return err
 }
 
+// InsertWithDetails 在一个事务中批量插入决策记录及其动作、持仓快照、候选币种
+// 每个周期只提交一次事务并复用预编译语句，避免逐行提交拖慢交易周期
+func (r *DecisionRepository) InsertWithDetails(record *models.DecisionRecord, actions []*models.DecisionAction,
+	positions []*models.PositionSnapshot, coins []string) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+	INSERT INTO decision_records (
+		trader_id, cycle_number, timestamp, system_prompt, input_prompt, cot_trace, decision_json,
+		success, error_message, total_balance, available_balance, total_unrealized_profit,
+		position_count, margin_used_pct, ai_provider, prompt_hash, cached, regime, latency_ms,
+		learning_summary_id, manual, schema_version, realized_pnl_delta, closed_trades, ai_params,
+		critic_review, critic_reviewed, critic_vetoed, market_fetch_ms, prompt_build_ms, ai_latency_ms,
+		parse_validate_ms, execution_ms, db_write_ms, symbols_fetched, symbols_failed
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.TraderID, record.CycleNumber, record.Timestamp, record.SystemPrompt, record.InputPrompt,
+		record.CoTTrace, record.DecisionJSON, record.Success, record.ErrorMessage, record.TotalBalance,
+		record.AvailableBalance, record.TotalUnrealizedProfit, record.PositionCount, record.MarginUsedPct,
+		record.AIProvider, record.PromptHash, record.Cached, record.Regime, record.LatencyMs,
+		record.LearningSummaryID, record.Manual, record.SchemaVersion, record.RealizedPnLDelta, record.ClosedTrades,
+		record.AIParams, record.CriticReview, record.CriticReviewed, record.CriticVetoed,
+		record.MarketFetchMs, record.PromptBuildMs, record.AILatencyMs, record.ParseValidateMs,
+		record.ExecutionMs, record.DBWriteMs, record.SymbolsFetched, record.SymbolsFailed,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("插入决策记录失败: %w", err)
+	}
+	recordID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(actions) > 0 {
+		stmt, err := tx.Prepare(`
+		INSERT INTO decision_actions (
+			record_id, action, symbol, quantity, leverage, price, order_id,
+			timestamp, success, error, was_stop_loss, executed_qty, avg_price, price_drift_pct,
+			notional_usd, margin_usd, adjustment, error_class, book_snapshot
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+		for _, a := range actions {
+			res, err := stmt.Exec(recordID, a.Action, a.Symbol, a.Quantity, a.Leverage, a.Price, a.OrderID,
+				a.Timestamp, a.Success, a.Error, a.WasStopLoss, a.ExecutedQty, a.AvgPrice, a.PriceDriftPct,
+				a.NotionalUSD, a.MarginUSD, a.Adjustment, a.ErrorClass, a.BookSnapshot)
+			if err != nil {
+				return 0, fmt.Errorf("插入决策动作失败: %w", err)
+			}
+			if a.ID, err = res.LastInsertId(); err != nil {
+				return 0, err
+			}
+			a.RecordID = recordID
+
+			// 回填平仓产生的交易结果对应的平仓决策
+			if a.TradeOutcomeID > 0 {
+				if _, err := tx.Exec(`
+				UPDATE trade_outcomes SET close_record_id = ?, close_action_id = ?
+				WHERE id = ? AND trader_id = ?`, recordID, a.ID, a.TradeOutcomeID, record.TraderID); err != nil {
+					return 0, fmt.Errorf("关联交易结果失败: %w", err)
+				}
+			}
+		}
+	}
+
+	if len(positions) > 0 {
+		stmt, err := tx.Prepare(`
+		INSERT INTO position_snapshots (
+			record_id, symbol, side, position_amt, entry_price, mark_price,
+			unrealized_profit, leverage, liquidation_price
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+		for _, p := range positions {
+			if _, err := stmt.Exec(recordID, p.Symbol, p.Side, p.PositionAmt, p.EntryPrice, p.MarkPrice,
+				p.UnrealizedProfit, p.Leverage, p.LiquidationPrice); err != nil {
+				return 0, fmt.Errorf("插入持仓快照失败: %w", err)
+			}
+		}
+	}
+
+	if len(coins) > 0 {
+		stmt, err := tx.Prepare(`INSERT INTO candidate_coins (record_id, symbol) VALUES (?, ?)`)
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+		for _, symbol := range coins {
+			if _, err := stmt.Exec(recordID, symbol); err != nil {
+				return 0, fmt.Errorf("插入候选币种失败: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交决策记录失败: %w", err)
+	}
+	return recordID, nil
+}
+
+// FindOpenAction 查找持仓对应的开仓动作：[from, to] 内最近一次成功的开仓动作（没有时返回0）
+func (r *DecisionRepository) FindOpenAction(symbol, side string, from, to time.Time) (recordID, actionID int64, err error) {
+	err = r.db.QueryRow(`
+		SELECT a.record_id, a.id FROM decision_actions a
+		JOIN decision_records dr ON dr.id = a.record_id
+		WHERE dr.trader_id = ? AND a.symbol = ? AND a.action = ? AND a.success = 1
+			AND a.timestamp >= ? AND a.timestamp <= ?
+		ORDER BY a.timestamp DESC, a.id DESC LIMIT 1
+	`, r.traderID, symbol, "open_"+side, from, to).Scan(&recordID, &actionID)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	return recordID, actionID, err
+}
+
+// GetActionErrorStats 统计指定时间之后决策动作的执行失败情况（只统计已分类的执行失败，按分类次数倒序）
+func (r *DecisionRepository) GetActionErrorStats(since time.Time) (*models.ActionErrorStats, error) {
+	stats := &models.ActionErrorStats{}
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN a.success = 0 AND COALESCE(a.error_class, '') != '' THEN 1 ELSE 0 END), 0)
+		FROM decision_actions a
+		JOIN decision_records d ON d.id = a.record_id
+		WHERE d.trader_id = ? AND a.timestamp >= ? AND a.action NOT IN ('hold', 'wait')
+	`, r.traderID, since).Scan(&stats.Total, &stats.Failed)
+	if err != nil {
+		return nil, fmt.Errorf("统计决策动作失败: %w", err)
+	}
+	if stats.Failed == 0 {
+		return stats, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT a.error_class, a.symbol, a.action, COALESCE(a.error, ''), a.timestamp
+		FROM decision_actions a
+		JOIN decision_records d ON d.id = a.record_id
+		WHERE d.trader_id = ? AND a.timestamp >= ? AND a.success = 0 AND COALESCE(a.error_class, '') != ''
+		ORDER BY a.timestamp DESC
+	`, r.traderID, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询失败动作失败: %w", err)
+	}
+	defer rows.Close()
+
+	byClass := make(map[string]*models.ErrorClassStat)
+	for rows.Next() {
+		var class, symbol, action, errMsg string
+		var ts time.Time
+		if err := rows.Scan(&class, &symbol, &action, &errMsg, &ts); err != nil {
+			continue
+		}
+		stat, ok := byClass[class]
+		if !ok {
+			// 按时间倒序，第一条即最近一次
+			stat = &models.ErrorClassStat{Class: class, LastSymbol: symbol, LastAction: action, LastError: errMsg, LastTime: ts}
+			byClass[class] = stat
+			stats.Classes = append(stats.Classes, stat)
+		}
+		stat.Count++
+	}
+	sort.SliceStable(stats.Classes, func(i, j int) bool { return stats.Classes[i].Count > stats.Classes[j].Count })
+	return stats, nil
+}
+
+// UpdateDBWriteMs 回填决策记录的写入耗时
+func (r *DecisionRepository) UpdateDBWriteMs(recordID, ms int64) error {
+	_, err := r.db.Exec(`UPDATE decision_records SET db_write_ms = ? WHERE id = ?`, ms, recordID)
+	return err
+}
+
+// GetCycleTimings 获取指定时间之后交易周期的各阶段耗时（按时间正序）
+// 不含操作员手动注入的决策和没有记录耗时的旧记录
+func (r *DecisionRepository) GetCycleTimings(since time.Time) ([]*models.CycleTiming, error) {
+	rows, err := r.db.Query(`
+		SELECT id, timestamp, success, COALESCE(cached, 0),
+			COALESCE(market_fetch_ms, 0), COALESCE(prompt_build_ms, 0), COALESCE(ai_latency_ms, 0),
+			COALESCE(parse_validate_ms, 0), COALESCE(execution_ms, 0), COALESCE(db_write_ms, 0),
+			COALESCE(symbols_fetched, 0), COALESCE(symbols_failed, 0)
+		FROM decision_records
+		WHERE trader_id = ? AND timestamp >= ? AND COALESCE(manual, 0) = 0
+			AND (COALESCE(market_fetch_ms, 0) > 0 OR COALESCE(db_write_ms, 0) > 0)
+		ORDER BY timestamp ASC, id ASC
+	`, r.traderID, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询周期耗时失败: %w", err)
+	}
+	defer rows.Close()
+
+	var timings []*models.CycleTiming
+	for rows.Next() {
+		t := &models.CycleTiming{}
+		if err := rows.Scan(&t.RecordID, &t.Timestamp, &t.Success, &t.Cached,
+			&t.MarketFetchMs, &t.PromptBuildMs, &t.AILatencyMs, &t.ParseValidateMs,
+			&t.ExecutionMs, &t.DBWriteMs, &t.SymbolsFetched, &t.SymbolsFailed); err != nil {
+			return nil, err
+		}
+		timings = append(timings, t)
+	}
+	return timings, rows.Err()
+}
+
 // GetStatistics 获取统计数据
 func (r *DecisionRepository) GetStatistics() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
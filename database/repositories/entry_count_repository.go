@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"database/sql"
+)
+
+// EntryCountRepository 每日开仓次数计数（按UTC日期和币种）
+type EntryCountRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewEntryCountRepository 创建每日开仓计数仓储
+func NewEntryCountRepository(db *sql.DB, traderID string) *EntryCountRepository {
+	return &EntryCountRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// Increment 记录一次开仓（day格式为 2006-01-02）
+func (r *EntryCountRepository) Increment(day, symbol string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO daily_entry_counts (trader_id, day, symbol, count, updated_at)
+		VALUES (?, ?, ?, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT(trader_id, day, symbol) DO UPDATE SET count = count + 1, updated_at = CURRENT_TIMESTAMP
+	`, r.traderID, day, symbol)
+	return err
+}
+
+// GetByDay 获取指定日期各币种的开仓次数
+func (r *EntryCountRepository) GetByDay(day string) (map[string]int, error) {
+	rows, err := r.db.Query(`
+		SELECT symbol, count FROM daily_entry_counts
+		WHERE trader_id = ? AND day = ?
+	`, r.traderID, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var symbol string
+		var count int
+		if err := rows.Scan(&symbol, &count); err != nil {
+			return nil, err
+		}
+		counts[symbol] = count
+	}
+	return counts, rows.Err()
+}
+
+// DeleteBefore 清理指定日期之前的计数
+func (r *EntryCountRepository) DeleteBefore(day string) error {
+	_, err := r.db.Exec(`DELETE FROM daily_entry_counts WHERE trader_id = ? AND day < ?`, r.traderID, day)
+	return err
+}
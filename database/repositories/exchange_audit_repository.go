@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"nofx/database/models"
+)
+
+// ExchangeAuditRepository 交易所请求审计数据访问层
+type ExchangeAuditRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewExchangeAuditRepository 创建交易所请求审计仓储
+func NewExchangeAuditRepository(db *sql.DB, traderID string) *ExchangeAuditRepository {
+	return &ExchangeAuditRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// Insert 保存一条审计记录
+func (r *ExchangeAuditRepository) Insert(e *models.ExchangeAuditEvent) error {
+	_, err := r.db.Exec(`
+		INSERT INTO exchange_audit (
+			trader_id, exchange, operation, symbol, request, response, success, error, latency_ms, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.traderID, e.Exchange, e.Operation, e.Symbol, e.Request, e.Response, e.Success, e.Error,
+		e.LatencyMs, e.CreatedAt)
+	return err
+}
+
+// Query 分页查询审计记录（按请求时间倒序），operation 为空表示不过滤，同时返回过滤后的总数
+// opts.Success 过滤请求是否成功
+func (r *ExchangeAuditRepository) Query(opts QueryOptions, operation string) ([]*models.ExchangeAuditEvent, int, error) {
+	where := &whereBuilder{}
+	where.add("trader_id = ?", r.traderID)
+	if !opts.Since.IsZero() {
+		where.add("created_at >= ?", opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		where.add("created_at < ?", opts.Until)
+	}
+	if opts.Success != nil {
+		where.add("success = ?", *opts.Success)
+	}
+	if opts.Symbol != "" {
+		where.add("symbol = ?", opts.Symbol)
+	}
+	if operation != "" {
+		where.add("operation = ?", operation)
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM exchange_audit WHERE `+where.String(), where.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计交易所审计记录失败: %w", err)
+	}
+
+	if opts.BeforeID > 0 {
+		where.add("id < ?", opts.BeforeID)
+	}
+	page, pageArgs := opts.pageClause()
+	query := `
+		SELECT id, trader_id, exchange, operation, symbol, COALESCE(request, ''), COALESCE(response, ''),
+			success, COALESCE(error, ''), latency_ms, created_at
+		FROM exchange_audit WHERE ` + where.String() + ` ORDER BY created_at DESC, id DESC` + page
+
+	rows, err := r.db.Query(query, append(where.args, pageArgs...)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询交易所审计记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.ExchangeAuditEvent
+	for rows.Next() {
+		e := &models.ExchangeAuditEvent{}
+		if err := rows.Scan(&e.ID, &e.TraderID, &e.Exchange, &e.Operation, &e.Symbol, &e.Request,
+			&e.Response, &e.Success, &e.Error, &e.LatencyMs, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
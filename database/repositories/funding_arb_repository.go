@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"database/sql"
+	"nofx/database/models"
+	"time"
+)
+
+// FundingArbRepository 资金费率套利仓位数据访问层
+type FundingArbRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewFundingArbRepository 创建资金费率套利仓储
+func NewFundingArbRepository(db *sql.DB, traderID string) *FundingArbRepository {
+	return &FundingArbRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+const fundingArbColumns = `id, trader_id, symbol, perp_side, hedge_venue, quantity, perp_entry_price,
+	hedge_entry_price, entry_rate_pct, funding_collected, last_accrual_at, opened_at, closed_at,
+	perp_exit_price, hedge_exit_price, realized_pnl, close_reason`
+
+// Insert 记录新开的套利仓位
+func (r *FundingArbRepository) Insert(pos *models.FundingArbPosition) (int64, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO funding_arb_positions (
+			trader_id, symbol, perp_side, hedge_venue, quantity, perp_entry_price,
+			hedge_entry_price, entry_rate_pct, last_accrual_at, opened_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.traderID, pos.Symbol, pos.PerpSide, pos.HedgeVenue, pos.Quantity, pos.PerpEntryPrice,
+		pos.HedgeEntryPrice, pos.EntryRatePct, pos.LastAccrualAt, pos.OpenedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateAccrual 累加资金费收入
+func (r *FundingArbRepository) UpdateAccrual(id int64, funding float64, accruedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE funding_arb_positions SET funding_collected = funding_collected + ?, last_accrual_at = ?
+		WHERE id = ? AND trader_id = ?
+	`, funding, accruedAt, id, r.traderID)
+	return err
+}
+
+// Close 记录套利仓位平仓
+func (r *FundingArbRepository) Close(id int64, perpExit, hedgeExit, realizedPnL float64, reason string) error {
+	_, err := r.db.Exec(`
+		UPDATE funding_arb_positions
+		SET closed_at = ?, perp_exit_price = ?, hedge_exit_price = ?, realized_pnl = ?, close_reason = ?
+		WHERE id = ? AND trader_id = ? AND closed_at IS NULL
+	`, time.Now(), perpExit, hedgeExit, realizedPnL, reason, id, r.traderID)
+	return err
+}
+
+// GetOpen 获取持仓中的套利仓位
+func (r *FundingArbRepository) GetOpen() ([]*models.FundingArbPosition, error) {
+	return r.query(`SELECT `+fundingArbColumns+` FROM funding_arb_positions
+		WHERE trader_id = ? AND closed_at IS NULL ORDER BY opened_at ASC`, r.traderID)
+}
+
+// GetRecent 获取最近N个套利仓位（含已平仓，按开仓时间倒序）
+func (r *FundingArbRepository) GetRecent(limit int) ([]*models.FundingArbPosition, error) {
+	return r.query(`SELECT `+fundingArbColumns+` FROM funding_arb_positions
+		WHERE trader_id = ? ORDER BY opened_at DESC LIMIT ?`, r.traderID, limit)
+}
+
+func (r *FundingArbRepository) query(query string, args ...interface{}) ([]*models.FundingArbPosition, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []*models.FundingArbPosition
+	for rows.Next() {
+		pos := &models.FundingArbPosition{}
+		var closedAt sql.NullTime
+		if err := rows.Scan(
+			&pos.ID,
+			&pos.TraderID,
+			&pos.Symbol,
+			&pos.PerpSide,
+			&pos.HedgeVenue,
+			&pos.Quantity,
+			&pos.PerpEntryPrice,
+			&pos.HedgeEntryPrice,
+			&pos.EntryRatePct,
+			&pos.FundingCollected,
+			&pos.LastAccrualAt,
+			&pos.OpenedAt,
+			&closedAt,
+			&pos.PerpExitPrice,
+			&pos.HedgeExitPrice,
+			&pos.RealizedPnL,
+			&pos.CloseReason,
+		); err != nil {
+			return nil, err
+		}
+		if closedAt.Valid {
+			t := closedAt.Time
+			pos.ClosedAt = &t
+		}
+		positions = append(positions, pos)
+	}
+
+	return positions, rows.Err()
+}
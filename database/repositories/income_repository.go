@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"database/sql"
+	"nofx/database/models"
+	"time"
+)
+
+// IncomeRepository 交易所收入历史数据访问层
+type IncomeRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewIncomeRepository 创建收入历史仓储
+func NewIncomeRepository(db *sql.DB, traderID string) *IncomeRepository {
+	return &IncomeRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// Insert 记录一笔收入（同一流水已存在时忽略），返回是否新增
+func (r *IncomeRepository) Insert(income *models.IncomeRecord) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT OR IGNORE INTO income_history (trader_id, tran_id, income_type, symbol, asset, amount, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, r.traderID, income.TranID, income.IncomeType, income.Symbol, income.Asset, income.Amount, income.Timestamp)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetSince 获取指定时间之后的收入记录（按时间正序）
+func (r *IncomeRepository) GetSince(since time.Time) ([]*models.IncomeRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT id, trader_id, tran_id, income_type, symbol, asset, amount, timestamp, created_at
+		FROM income_history
+		WHERE trader_id = ? AND timestamp >= ?
+		ORDER BY timestamp ASC, id ASC
+	`, r.traderID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incomes []*models.IncomeRecord
+	for rows.Next() {
+		i := &models.IncomeRecord{}
+		if err := rows.Scan(&i.ID, &i.TraderID, &i.TranID, &i.IncomeType, &i.Symbol, &i.Asset, &i.Amount, &i.Timestamp, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		incomes = append(incomes, i)
+	}
+	return incomes, nil
+}
+
+// GetLatestTime 获取最近一笔收入的时间（没有记录时返回零值）
+func (r *IncomeRepository) GetLatestTime() (time.Time, error) {
+	var ts sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT timestamp FROM income_history WHERE trader_id = ?
+		ORDER BY timestamp DESC LIMIT 1
+	`, r.traderID).Scan(&ts)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.Time, nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
 	"nofx/database/models"
 	"time"
 )
@@ -20,7 +21,37 @@ func NewLearningRepository(db *sql.DB, traderID string) *LearningRepository {
 	}
 }
 
-// Save 保存AI学习总结（将旧的设置为inactive）
+// learningSummaryColumns AI学习总结查询列（与 scanLearningSummary 的顺序一致）
+const learningSummaryColumns = `id, trader_id, summary_content, trades_count, date_range_start, date_range_end,
+		       win_rate, avg_pnl, created_at, is_active, activated_at, deactivated_at`
+
+// scanLearningSummary 扫描一行AI学习总结
+func scanLearningSummary(row rowScanner) (*models.AILearningSummary, error) {
+	var summary models.AILearningSummary
+	var createdAtStr string
+	var activatedAt, deactivatedAt sql.NullTime
+
+	err := row.Scan(
+		&summary.ID, &summary.TraderID, &summary.SummaryContent, &summary.TradesCount,
+		&summary.DateRangeStart, &summary.DateRangeEnd, &summary.WinRate, &summary.AvgPnL,
+		&createdAtStr, &summary.IsActive, &activatedAt, &deactivatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
+	summary.ActivatedAt = summary.CreatedAt
+	if activatedAt.Valid {
+		summary.ActivatedAt = activatedAt.Time
+	}
+	if deactivatedAt.Valid {
+		summary.DeactivatedAt = deactivatedAt.Time
+	}
+	return &summary, nil
+}
+
+// Save 保存AI学习总结（将旧的设置为inactive，历史版本全部保留）
 func (r *LearningRepository) Save(summary *models.AILearningSummary) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -28,24 +59,31 @@ func (r *LearningRepository) Save(summary *models.AILearningSummary) error {
 	}
 	defer tx.Rollback()
 
+	now := time.Now()
+
 	// 将该trader的所有旧总结设置为inactive
-	_, err = tx.Exec(`UPDATE ai_learning_summaries SET is_active = 0 WHERE trader_id = ?`, r.traderID)
+	_, err = tx.Exec(`UPDATE ai_learning_summaries SET is_active = 0, deactivated_at = ? WHERE trader_id = ? AND is_active = 1`,
+		now, r.traderID)
 	if err != nil {
 		return err
 	}
 
 	// 插入新总结
-	_, err = tx.Exec(`
+	result, err := tx.Exec(`
 		INSERT INTO ai_learning_summaries (
 			trader_id, summary_content, trades_count, date_range_start, date_range_end,
-			win_rate, avg_pnl, is_active
-		) VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+			win_rate, avg_pnl, is_active, activated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?)
 	`, r.traderID, summary.SummaryContent, summary.TradesCount,
-		summary.DateRangeStart, summary.DateRangeEnd, summary.WinRate, summary.AvgPnL)
+		summary.DateRangeStart, summary.DateRangeEnd, summary.WinRate, summary.AvgPnL, now)
 
 	if err != nil {
 		return err
 	}
+	if summary.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	summary.ActivatedAt = now
 
 	return tx.Commit()
 }
@@ -53,42 +91,48 @@ func (r *LearningRepository) Save(summary *models.AILearningSummary) error {
 // GetActive 获取当前激活的AI学习总结
 func (r *LearningRepository) GetActive() (*models.AILearningSummary, error) {
 	query := `
-		SELECT id, trader_id, summary_content, trades_count, date_range_start, date_range_end,
-		       win_rate, avg_pnl, created_at, is_active
+		SELECT ` + learningSummaryColumns + `
 		FROM ai_learning_summaries
 		WHERE trader_id = ? AND is_active = 1
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	var summary models.AILearningSummary
-	var createdAtStr string
-
-	err := r.db.QueryRow(query, r.traderID).Scan(
-		&summary.ID, &summary.TraderID, &summary.SummaryContent, &summary.TradesCount,
-		&summary.DateRangeStart, &summary.DateRangeEnd, &summary.WinRate, &summary.AvgPnL,
-		&createdAtStr, &summary.IsActive,
-	)
-
+	summary, err := scanLearningSummary(r.db.QueryRow(query, r.traderID))
 	if err == sql.ErrNoRows {
 		return nil, nil // 没有总结，返回nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	return summary, nil
+}
 
-	summary.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
-	return &summary, nil
+// GetByID 获取指定ID的AI学习总结（不存在时返回nil）
+func (r *LearningRepository) GetByID(id int64) (*models.AILearningSummary, error) {
+	query := `
+		SELECT ` + learningSummaryColumns + `
+		FROM ai_learning_summaries
+		WHERE trader_id = ? AND id = ?
+	`
+
+	summary, err := scanLearningSummary(r.db.QueryRow(query, r.traderID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
 }
 
 // GetAll 获取所有AI学习总结（用于前端展示历史）
 func (r *LearningRepository) GetAll(limit int) ([]*models.AILearningSummary, error) {
 	query := `
-		SELECT id, trader_id, summary_content, trades_count, date_range_start, date_range_end,
-		       win_rate, avg_pnl, created_at, is_active
+		SELECT ` + learningSummaryColumns + `
 		FROM ai_learning_summaries
 		WHERE trader_id = ?
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT ?
 	`
 
@@ -100,21 +144,42 @@ func (r *LearningRepository) GetAll(limit int) ([]*models.AILearningSummary, err
 
 	var summaries []*models.AILearningSummary
 	for rows.Next() {
-		var summary models.AILearningSummary
-		var createdAtStr string
-
-		err := rows.Scan(
-			&summary.ID, &summary.TraderID, &summary.SummaryContent, &summary.TradesCount,
-			&summary.DateRangeStart, &summary.DateRangeEnd, &summary.WinRate, &summary.AvgPnL,
-			&createdAtStr, &summary.IsActive,
-		)
+		summary, err := scanLearningSummary(rows)
 		if err != nil {
 			return nil, err
 		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
 
-		summary.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
-		summaries = append(summaries, &summary)
+// Deactivate 停用指定的AI学习总结（返回是否有总结被停用）
+func (r *LearningRepository) Deactivate(id int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE ai_learning_summaries SET is_active = 0, deactivated_at = ?
+		WHERE trader_id = ? AND id = ? AND is_active = 1
+	`, time.Now(), r.traderID, id)
+	if err != nil {
+		return false, fmt.Errorf("停用AI学习总结失败: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
 	}
+	return affected > 0, nil
+}
 
-	return summaries, nil
+// GetDecisionStats 统计在指定总结生效期间做出的决策数和成功数
+func (r *LearningRepository) GetDecisionStats(summaryID int64) (int, int, error) {
+	var total, success int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN success THEN 1 ELSE 0 END), 0)
+		FROM decision_records
+		WHERE trader_id = ? AND learning_summary_id = ?
+	`, r.traderID, summaryID).Scan(&total, &success)
+	if err != nil {
+		return 0, 0, fmt.Errorf("统计学习总结关联决策失败: %w", err)
+	}
+	return total, success, nil
 }
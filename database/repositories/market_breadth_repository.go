@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"database/sql"
+	"nofx/database/models"
+	"time"
+)
+
+// MarketBreadthRepository 市场广度数据访问层
+type MarketBreadthRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewMarketBreadthRepository 创建市场广度仓储
+func NewMarketBreadthRepository(db *sql.DB, traderID string) *MarketBreadthRepository {
+	return &MarketBreadthRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// Insert 保存一个周期的市场广度
+func (r *MarketBreadthRepository) Insert(b *models.MarketBreadth) error {
+	_, err := r.db.Exec(`
+		INSERT INTO market_breadth (
+			trader_id, timestamp, cycle_number, btc_dominance, above_ema50_pct,
+			symbol_count, aggregate_oi_change_pct, oi_symbol_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.traderID, b.Timestamp, b.CycleNumber, b.BTCDominance, b.AboveEMA50Pct,
+		b.SymbolCount, b.AggregateOIChangePct, b.OISymbolCount)
+	return err
+}
+
+// GetDominanceAt 获取指定时间点之前最近一次记录的BTC市值占比（没有记录时返回false）
+func (r *MarketBreadthRepository) GetDominanceAt(at time.Time) (float64, bool, error) {
+	var dominance float64
+	err := r.db.QueryRow(`
+		SELECT btc_dominance FROM market_breadth
+		WHERE trader_id = ? AND timestamp <= ? AND btc_dominance > 0
+		ORDER BY timestamp DESC LIMIT 1
+	`, r.traderID, at).Scan(&dominance)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return dominance, true, nil
+}
+
+// GetRange 获取时间范围内的市场广度记录（按时间正序）
+func (r *MarketBreadthRepository) GetRange(from, to time.Time) ([]*models.MarketBreadth, error) {
+	rows, err := r.db.Query(`
+		SELECT id, trader_id, timestamp, cycle_number, btc_dominance, above_ema50_pct,
+			symbol_count, aggregate_oi_change_pct, oi_symbol_count
+		FROM market_breadth
+		WHERE trader_id = ? AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC
+	`, r.traderID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*models.MarketBreadth
+	for rows.Next() {
+		b := &models.MarketBreadth{}
+		if err := rows.Scan(&b.ID, &b.TraderID, &b.Timestamp, &b.CycleNumber, &b.BTCDominance,
+			&b.AboveEMA50Pct, &b.SymbolCount, &b.AggregateOIChangePct, &b.OISymbolCount); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"nofx/database/models"
+	"time"
 )
 
 // PositionRepository 持仓管理数据访问层
@@ -53,6 +54,41 @@ func (r *PositionRepository) DeleteOpenTime(symbol, side string) error {
 	return err
 }
 
+// SaveExitLevels 保存持仓当前的止损止盈价（需先保存开仓时间）
+func (r *PositionRepository) SaveExitLevels(symbol, side string, stopLoss, takeProfit float64) error {
+	query := `
+		UPDATE position_open_times SET stop_loss = ?, take_profit = ?
+		WHERE trader_id = ? AND symbol = ? AND side = ?
+	`
+	_, err := r.db.Exec(query, stopLoss, takeProfit, r.traderID, symbol, side)
+	return err
+}
+
+// GetAllExitLevels 获取所有持仓的止损止盈价（symbol_side -> [止损, 止盈]）
+func (r *PositionRepository) GetAllExitLevels() (map[string][2]float64, error) {
+	query := `
+		SELECT symbol, side, COALESCE(stop_loss, 0), COALESCE(take_profit, 0) FROM position_open_times
+		WHERE trader_id = ?
+	`
+	rows, err := r.db.Query(query, r.traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][2]float64)
+	for rows.Next() {
+		var symbol, side string
+		var stopLoss, takeProfit float64
+		if err := rows.Scan(&symbol, &side, &stopLoss, &takeProfit); err != nil {
+			continue
+		}
+		result[symbol+"_"+side] = [2]float64{stopLoss, takeProfit}
+	}
+
+	return result, nil
+}
+
 // GetAllOpenTimes 获取所有持仓开仓时间（用于系统启动时恢复）
 func (r *PositionRepository) GetAllOpenTimes() (map[string]int64, error) {
 	query := `
@@ -79,31 +115,155 @@ func (r *PositionRepository) GetAllOpenTimes() (map[string]int64, error) {
 	return result, nil
 }
 
-// SaveTraderState 保存Trader运行状态
-func (r *PositionRepository) SaveTraderState(isPaused bool) error {
+// SaveEntrySnapshot 保存持仓开仓指标快照
+func (r *PositionRepository) SaveEntrySnapshot(snapshot *models.PositionEntrySnapshot) error {
 	query := `
-		INSERT OR REPLACE INTO trader_states (trader_id, is_paused, updated_at)
-		VALUES (?, ?, CURRENT_TIMESTAMP)
+		INSERT OR REPLACE INTO position_entry_snapshots (
+			trader_id, symbol, side, entry_macd, entry_rsi, entry_vol_ratio, entry_reason, regime
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.Exec(query, r.traderID, isPaused)
+	_, err := r.db.Exec(query, r.traderID, snapshot.Symbol, snapshot.Side,
+		snapshot.EntryMACD, snapshot.EntryRSI, snapshot.EntryVolRatio, snapshot.EntryReason, snapshot.Regime)
+	return err
+}
+
+// GetEntrySnapshot 获取持仓开仓指标快照
+func (r *PositionRepository) GetEntrySnapshot(symbol, side string) (*models.PositionEntrySnapshot, bool) {
+	query := `
+		SELECT symbol, side, entry_macd, entry_rsi, entry_vol_ratio, COALESCE(entry_reason, ''), COALESCE(regime, '')
+		FROM position_entry_snapshots
+		WHERE trader_id = ? AND symbol = ? AND side = ?
+	`
+	snapshot := &models.PositionEntrySnapshot{TraderID: r.traderID}
+	err := r.db.QueryRow(query, r.traderID, symbol, side).Scan(
+		&snapshot.Symbol,
+		&snapshot.Side,
+		&snapshot.EntryMACD,
+		&snapshot.EntryRSI,
+		&snapshot.EntryVolRatio,
+		&snapshot.EntryReason,
+		&snapshot.Regime,
+	)
+	if err != nil {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+// DeleteEntrySnapshot 删除持仓开仓指标快照
+func (r *PositionRepository) DeleteEntrySnapshot(symbol, side string) error {
+	query := `
+		DELETE FROM position_entry_snapshots
+		WHERE trader_id = ? AND symbol = ? AND side = ?
+	`
+	_, err := r.db.Exec(query, r.traderID, symbol, side)
+	return err
+}
+
+// SaveTraderState 保存Trader运行状态（is_paused 与 state 同步写入，兼容只读取暂停标记的旧版本）
+func (r *PositionRepository) SaveTraderState(state *models.TraderState) error {
+	query := `
+		INSERT OR REPLACE INTO trader_states (trader_id, is_paused, state, reason, state_until, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`
+	_, err := r.db.Exec(query, r.traderID, state.IsPaused, state.State, state.Reason, state.Until)
 	return err
 }
 
 // GetTraderState 获取Trader运行状态
 func (r *PositionRepository) GetTraderState() (*models.TraderState, error) {
 	query := `
-		SELECT trader_id, is_paused, updated_at FROM trader_states
+		SELECT trader_id, is_paused, COALESCE(state, ''), COALESCE(reason, ''), state_until, updated_at FROM trader_states
 		WHERE trader_id = ?
 	`
 	state := &models.TraderState{}
-	var pausedInt int
-	err := r.db.QueryRow(query, r.traderID).Scan(&state.TraderID, &pausedInt, &state.UpdatedAt)
+	var until sql.NullTime
+	err := r.db.QueryRow(query, r.traderID).Scan(&state.TraderID, &state.IsPaused, &state.State, &state.Reason, &until, &state.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // 没有保存的状态
 		}
 		return nil, err
 	}
-	state.IsPaused = (pausedInt == 1)
+	if until.Valid {
+		state.Until = &until.Time
+	}
 	return state, nil
 }
+
+// SaveDrawdownLock 保存回撤锁状态
+func (r *PositionRepository) SaveDrawdownLock(lock *models.DrawdownLock) error {
+	_, err := r.db.Exec(`
+		INSERT OR REPLACE INTO drawdown_locks (trader_id, peak_equity, locked, locked_at, reason, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, r.traderID, lock.PeakEquity, lock.Locked, lock.LockedAt, lock.Reason)
+	return err
+}
+
+// GetDrawdownLock 获取回撤锁状态（没有保存的状态时返回nil）
+func (r *PositionRepository) GetDrawdownLock() (*models.DrawdownLock, error) {
+	lock := &models.DrawdownLock{}
+	var lockedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT trader_id, peak_equity, locked, locked_at, reason, updated_at
+		FROM drawdown_locks WHERE trader_id = ?
+	`, r.traderID).Scan(&lock.TraderID, &lock.PeakEquity, &lock.Locked, &lockedAt, &lock.Reason, &lock.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lockedAt.Valid {
+		lock.LockedAt = &lockedAt.Time
+	}
+	return lock, nil
+}
+
+// SaveExitLevelChange 记录一次止损/止盈调整
+func (r *PositionRepository) SaveExitLevelChange(change *models.ExitLevelChange) error {
+	query := `
+		INSERT INTO position_exit_level_changes (
+			trader_id, symbol, side, level_type, old_price, new_price, mark_price, reason, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.Exec(query, r.traderID, change.Symbol, change.Side, change.LevelType,
+		change.OldPrice, change.NewPrice, change.MarkPrice, change.Reason, change.CreatedAt)
+	return err
+}
+
+// GetExitLevelChanges 获取持仓自指定时间以来的止损/止盈调整记录（按时间正序）
+func (r *PositionRepository) GetExitLevelChanges(symbol, side string, since time.Time) ([]*models.ExitLevelChange, error) {
+	query := `
+		SELECT id, trader_id, symbol, side, level_type, old_price, new_price, mark_price, COALESCE(reason, ''), created_at
+		FROM position_exit_level_changes
+		WHERE trader_id = ? AND symbol = ? AND side = ? AND created_at >= ?
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(query, r.traderID, symbol, side, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*models.ExitLevelChange
+	for rows.Next() {
+		change := &models.ExitLevelChange{}
+		if err := rows.Scan(
+			&change.ID,
+			&change.TraderID,
+			&change.Symbol,
+			&change.Side,
+			&change.LevelType,
+			&change.OldPrice,
+			&change.NewPrice,
+			&change.MarkPrice,
+			&change.Reason,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
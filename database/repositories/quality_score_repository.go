@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"nofx/database/models"
+)
+
+// QualityScoreRepository 决策质量重评分数据访问层
+type QualityScoreRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewQualityScoreRepository 创建决策质量重评分仓储
+func NewQualityScoreRepository(db *sql.DB, traderID string) *QualityScoreRepository {
+	return &QualityScoreRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// SaveBatch 批量保存评分（同一版本重复评分时覆盖原结果）
+func (r *QualityScoreRepository) SaveBatch(scores []*models.QualityScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO decision_quality_scores (
+			trader_id, record_id, decision_index, version, symbol, action,
+			score, grade, issues, original_score, original_grade
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(trader_id, version, record_id, decision_index) DO UPDATE SET
+			symbol = excluded.symbol,
+			action = excluded.action,
+			score = excluded.score,
+			grade = excluded.grade,
+			issues = excluded.issues,
+			original_score = excluded.original_score,
+			original_grade = excluded.original_grade,
+			created_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range scores {
+		if _, err := stmt.Exec(r.traderID, s.RecordID, s.DecisionIndex, s.Version, s.Symbol, s.Action,
+			s.Score, s.Grade, s.Issues, s.OriginalScore, s.OriginalGrade); err != nil {
+			return fmt.Errorf("保存决策评分失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByVersion 获取某一评分版本的全部评分（按决策记录和序号排序）
+func (r *QualityScoreRepository) GetByVersion(version string) ([]*models.QualityScore, error) {
+	rows, err := r.db.Query(`
+		SELECT id, trader_id, record_id, decision_index, version, symbol, action,
+			score, grade, issues, original_score, original_grade, created_at
+		FROM decision_quality_scores
+		WHERE trader_id = ? AND version = ?
+		ORDER BY record_id ASC, decision_index ASC
+	`, r.traderID, version)
+	if err != nil {
+		return nil, fmt.Errorf("查询决策评分失败: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []*models.QualityScore
+	for rows.Next() {
+		s := &models.QualityScore{}
+		if err := rows.Scan(&s.ID, &s.TraderID, &s.RecordID, &s.DecisionIndex, &s.Version, &s.Symbol, &s.Action,
+			&s.Score, &s.Grade, &s.Issues, &s.OriginalScore, &s.OriginalGrade, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		scores = append(scores, s)
+	}
+	return scores, rows.Err()
+}
+
+// ListVersions 已保存的评分版本及其汇总
+func (r *QualityScoreRepository) ListVersions() ([]*models.QualityVersionSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT version, COUNT(DISTINCT record_id), COUNT(*), AVG(score)
+		FROM decision_quality_scores
+		WHERE trader_id = ?
+		GROUP BY version
+		ORDER BY MIN(id) ASC
+	`, r.traderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评分版本失败: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*models.QualityVersionSummary
+	for rows.Next() {
+		v := &models.QualityVersionSummary{}
+		if err := rows.Scan(&v.Version, &v.Records, &v.Count, &v.AvgScore); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
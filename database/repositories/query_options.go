@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"strings"
+	"time"
+)
+
+// QueryOptions 列表查询的分页和过滤参数
+type QueryOptions struct {
+	Since     time.Time // 起始时间（含），零值表示不限
+	Until     time.Time // 结束时间（不含），零值表示不限
+	Success   *bool     // 决策：是否成功；交易：是否盈利。nil表示不限
+	Symbol    string    // 币种（仅交易查询）
+	Limit     int       // 每页条数，<=0 表示不限
+	Offset    int       // 偏移量（与游标二选一）
+	BeforeID  int64     // 游标：只返回ID小于该值的记录（倒序翻页）
+	OmitHeavy bool      // 不加载大文本字段（Prompt、思维链、开平仓理由）
+}
+
+// whereBuilder 拼接WHERE条件
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+func (w *whereBuilder) add(cond string, args ...interface{}) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+func (w *whereBuilder) String() string {
+	return strings.Join(w.conds, " AND ")
+}
+
+// pageClause 生成 LIMIT/OFFSET 子句
+func (o QueryOptions) pageClause() (string, []interface{}) {
+	if o.Limit <= 0 {
+		return "", nil
+	}
+	if o.Offset > 0 {
+		return " LIMIT ? OFFSET ?", []interface{}{o.Limit, o.Offset}
+	}
+	return " LIMIT ?", []interface{}{o.Limit}
+}
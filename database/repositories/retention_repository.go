@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"nofx/database/models"
+	"time"
+)
+
+// RetentionRepository 数据保留与归档数据访问层
+type RetentionRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewRetentionRepository 创建数据保留仓储
+func NewRetentionRepository(db *sql.DB, traderID string) *RetentionRepository {
+	return &RetentionRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// GetRecordsBefore 获取指定时间之前的决策记录（按时间正序）
+func (r *RetentionRepository) GetRecordsBefore(cutoff time.Time, limit int) ([]*models.DecisionRecord, error) {
+	query := `
+	SELECT ` + decisionRecordColumns + `
+	FROM decision_records
+	WHERE trader_id = ? AND timestamp < ?
+	ORDER BY timestamp ASC
+	LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, r.traderID, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询待归档决策记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*models.DecisionRecord
+	for rows.Next() {
+		record, err := scanDecisionRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// DeleteRecords 删除决策记录及其动作、持仓快照、候选币种、重评分结果
+func (r *RetentionRepository) DeleteRecords(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"decision_actions", "position_snapshots", "candidate_coins", "decision_quality_scores"} {
+		stmt, err := tx.Prepare(fmt.Sprintf("DELETE FROM %s WHERE record_id = ?", table))
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if _, err := stmt.Exec(id); err != nil {
+				stmt.Close()
+				return fmt.Errorf("删除 %s 失败: %w", table, err)
+			}
+		}
+		stmt.Close()
+	}
+
+	stmt, err := tx.Prepare("DELETE FROM decision_records WHERE trader_id = ? AND id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, id := range ids {
+		if _, err := stmt.Exec(r.traderID, id); err != nil {
+			return fmt.Errorf("删除决策记录失败: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// InsertEquityPoints 批量插入净值摘要点
+func (r *RetentionRepository) InsertEquityPoints(points []*models.EquityPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO equity_points (
+		trader_id, timestamp, cycle_number, total_equity, available_balance,
+		total_pnl, position_count, margin_used_pct
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range points {
+		if _, err := stmt.Exec(r.traderID, p.Timestamp, p.CycleNumber, p.TotalEquity,
+			p.AvailableBalance, p.TotalPnL, p.PositionCount, p.MarginUsedPct); err != nil {
+			return fmt.Errorf("插入净值摘要失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEquityPoints 获取所有净值摘要点（按时间正序）
+func (r *RetentionRepository) GetEquityPoints() ([]*models.EquityPoint, error) {
+	rows, err := r.db.Query(`
+	SELECT id, trader_id, timestamp, cycle_number, total_equity, available_balance,
+		total_pnl, position_count, margin_used_pct
+	FROM equity_points
+	WHERE trader_id = ?
+	ORDER BY timestamp ASC
+	`, r.traderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询净值摘要失败: %w", err)
+	}
+	defer rows.Close()
+
+	var points []*models.EquityPoint
+	for rows.Next() {
+		p := &models.EquityPoint{}
+		if err := rows.Scan(&p.ID, &p.TraderID, &p.Timestamp, &p.CycleNumber, &p.TotalEquity,
+			&p.AvailableBalance, &p.TotalPnL, &p.PositionCount, &p.MarginUsedPct); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// TableCounts 各表行数（用于存储用量统计）
+func (r *RetentionRepository) TableCounts() (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, table := range []string{
+		"decision_records", "decision_actions", "position_snapshots",
+		"candidate_coins", "trade_outcomes", "equity_points", "market_breadth", "exchange_audit",
+		"decision_quality_scores",
+	} {
+		var count int64
+		if err := r.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count); err != nil {
+			return nil, fmt.Errorf("统计 %s 行数失败: %w", table, err)
+		}
+		counts[table] = count
+	}
+	return counts, nil
+}
+
+// OldestRecordTime 最早一条决策记录的时间
+func (r *RetentionRepository) OldestRecordTime() (*time.Time, error) {
+	var ts sql.NullTime
+	err := r.db.QueryRow(`SELECT timestamp FROM decision_records WHERE trader_id = ? ORDER BY timestamp ASC LIMIT 1`, r.traderID).Scan(&ts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || !ts.Valid {
+		return nil, err
+	}
+	return &ts.Time, nil
+}
+
+// Checkpoint 合并WAL文件到主库并截断（清理后回收WAL占用的空间）
+func (r *RetentionRepository) Checkpoint() error {
+	_, err := r.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// Vacuum 压缩数据库文件（回收已删除数据占用的空间，耗时较长）
+func (r *RetentionRepository) Vacuum() error {
+	_, err := r.db.Exec("VACUUM")
+	return err
+}
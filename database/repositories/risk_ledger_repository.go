@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"database/sql"
+	"nofx/database/models"
+	"time"
+)
+
+// RiskLedgerRepository 风险预算台账数据访问层
+type RiskLedgerRepository struct {
+	db       *sql.DB
+	traderID string
+}
+
+// NewRiskLedgerRepository 创建风险预算台账仓储
+func NewRiskLedgerRepository(db *sql.DB, traderID string) *RiskLedgerRepository {
+	return &RiskLedgerRepository{
+		db:       db,
+		traderID: traderID,
+	}
+}
+
+// Consume 开仓占用风险预算
+func (r *RiskLedgerRepository) Consume(entry *models.RiskLedgerEntry) error {
+	query := `
+		INSERT INTO risk_ledger (
+			trader_id, symbol, side, entry_price, stop_loss, quantity, risk_usd, opened_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.Exec(query, r.traderID, entry.Symbol, entry.Side, entry.EntryPrice,
+		entry.StopLoss, entry.Quantity, entry.RiskUSD, entry.OpenedAt)
+	return err
+}
+
+// Release 平仓释放该持仓占用的风险预算，返回释放的金额
+func (r *RiskLedgerRepository) Release(symbol, side string) (float64, error) {
+	var released float64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(risk_usd), 0) FROM risk_ledger
+		WHERE trader_id = ? AND symbol = ? AND side = ? AND released_at IS NULL
+	`, r.traderID, symbol, side).Scan(&released)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = r.db.Exec(`
+		UPDATE risk_ledger SET released_at = ?
+		WHERE trader_id = ? AND symbol = ? AND side = ? AND released_at IS NULL
+	`, time.Now(), r.traderID, symbol, side)
+	if err != nil {
+		return 0, err
+	}
+	return released, nil
+}
+
+// GetOpen 获取仍在占用风险预算的台账记录
+func (r *RiskLedgerRepository) GetOpen() ([]*models.RiskLedgerEntry, error) {
+	query := `
+		SELECT id, trader_id, symbol, side, entry_price, stop_loss, quantity, risk_usd, opened_at
+		FROM risk_ledger
+		WHERE trader_id = ? AND released_at IS NULL
+		ORDER BY opened_at ASC
+	`
+	rows, err := r.db.Query(query, r.traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.RiskLedgerEntry
+	for rows.Next() {
+		entry := &models.RiskLedgerEntry{}
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.TraderID,
+			&entry.Symbol,
+			&entry.Side,
+			&entry.EntryPrice,
+			&entry.StopLoss,
+			&entry.Quantity,
+			&entry.RiskUSD,
+			&entry.OpenedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
 	"nofx/database/models"
+	"strings"
+	"time"
 )
 
 // TradeRepository 交易结果数据访问层
@@ -26,11 +29,17 @@ func (r *TradeRepository) Insert(trade *models.TradeOutcome) error {
 		trader_id, symbol, side, quantity, leverage, open_price, close_price,
 		position_value, margin_used, pnl, pnl_pct, duration_minutes,
 		open_time, close_time, was_stop_loss, entry_reason, exit_reason,
-		is_premature, failure_type
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		is_premature, failure_type, entry_macd, entry_rsi, entry_vol_ratio, regime, source,
+		open_record_id, open_action_id, take_profit_hit
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query,
+	source := trade.Source
+	if source == "" {
+		source = models.TradeSourceBot
+	}
+
+	result, err := r.db.Exec(query,
 		trade.TraderID,
 		trade.Symbol,
 		trade.Side,
@@ -50,25 +59,87 @@ func (r *TradeRepository) Insert(trade *models.TradeOutcome) error {
 		trade.ExitReason,
 		trade.IsPremature,
 		trade.FailureType,
+		trade.EntryMACD,
+		trade.EntryRSI,
+		trade.EntryVolRatio,
+		trade.Regime,
+		source,
+		trade.OpenRecordID,
+		trade.OpenActionID,
+		trade.TakeProfitHit,
 	)
+	if err != nil {
+		return err
+	}
 
+	trade.ID, err = result.LastInsertId()
 	return err
 }
 
-// GetLatest 获取最近N笔交易结果
-func (r *TradeRepository) GetLatest(limit int) ([]*models.TradeOutcome, error) {
-	query := `
-	SELECT id, trader_id, symbol, side, quantity, leverage, open_price, close_price,
+// tradeOutcomeColumns 交易结果查询列（与 scanTradeOutcome 的顺序一致）
+const tradeOutcomeColumns = `id, trader_id, symbol, side, quantity, leverage, open_price, close_price,
 		position_value, margin_used, pnl, pnl_pct, duration_minutes,
 		open_time, close_time, was_stop_loss, entry_reason, exit_reason,
-		is_premature, failure_type
-	FROM trade_outcomes
-	WHERE trader_id = ?
-	ORDER BY close_time DESC
-	LIMIT ?
-	`
+		is_premature, failure_type,
+		COALESCE(entry_macd, 0), COALESCE(entry_rsi, 0), COALESCE(entry_vol_ratio, 0),
+		COALESCE(regime, ''), COALESCE(source, 'bot'),
+		COALESCE(open_record_id, 0), COALESCE(open_action_id, 0),
+		COALESCE(close_record_id, 0), COALESCE(close_action_id, 0),
+		COALESCE((SELECT cycle_number FROM decision_records WHERE id = trade_outcomes.open_record_id), 0),
+		COALESCE((SELECT cycle_number FROM decision_records WHERE id = trade_outcomes.close_record_id), 0),
+		COALESCE(take_profit_hit, 0)`
+
+// tradeOutcomeLightColumns 不含开平仓理由的查询列（顺序与 tradeOutcomeColumns 一致）
+var tradeOutcomeLightColumns = strings.NewReplacer(
+	"entry_reason, exit_reason", "'' as entry_reason, '' as exit_reason",
+).Replace(tradeOutcomeColumns)
+
+// scanTradeOutcome 扫描一行交易结果
+func scanTradeOutcome(row rowScanner) (*models.TradeOutcome, error) {
+	trade := &models.TradeOutcome{}
+	err := row.Scan(
+		&trade.ID,
+		&trade.TraderID,
+		&trade.Symbol,
+		&trade.Side,
+		&trade.Quantity,
+		&trade.Leverage,
+		&trade.OpenPrice,
+		&trade.ClosePrice,
+		&trade.PositionValue,
+		&trade.MarginUsed,
+		&trade.PnL,
+		&trade.PnLPct,
+		&trade.DurationMinutes,
+		&trade.OpenTime,
+		&trade.CloseTime,
+		&trade.WasStopLoss,
+		&trade.EntryReason,
+		&trade.ExitReason,
+		&trade.IsPremature,
+		&trade.FailureType,
+		&trade.EntryMACD,
+		&trade.EntryRSI,
+		&trade.EntryVolRatio,
+		&trade.Regime,
+		&trade.Source,
+		&trade.OpenRecordID,
+		&trade.OpenActionID,
+		&trade.CloseRecordID,
+		&trade.CloseActionID,
+		&trade.OpenCycle,
+		&trade.CloseCycle,
+		&trade.TakeProfitHit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return trade, nil
+}
 
-	rows, err := r.db.Query(query, r.traderID, limit)
+// queryTradeOutcomes 执行查询并扫描交易结果列表
+func (r *TradeRepository) queryTradeOutcomes(query string, args ...interface{}) ([]*models.TradeOutcome, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -76,29 +147,7 @@ func (r *TradeRepository) GetLatest(limit int) ([]*models.TradeOutcome, error) {
 
 	var trades []*models.TradeOutcome
 	for rows.Next() {
-		trade := &models.TradeOutcome{}
-		err := rows.Scan(
-			&trade.ID,
-			&trade.TraderID,
-			&trade.Symbol,
-			&trade.Side,
-			&trade.Quantity,
-			&trade.Leverage,
-			&trade.OpenPrice,
-			&trade.ClosePrice,
-			&trade.PositionValue,
-			&trade.MarginUsed,
-			&trade.PnL,
-			&trade.PnLPct,
-			&trade.DurationMinutes,
-			&trade.OpenTime,
-			&trade.CloseTime,
-			&trade.WasStopLoss,
-			&trade.EntryReason,
-			&trade.ExitReason,
-			&trade.IsPremature,
-			&trade.FailureType,
-		)
+		trade, err := scanTradeOutcome(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -108,6 +157,135 @@ func (r *TradeRepository) GetLatest(limit int) ([]*models.TradeOutcome, error) {
 	return trades, nil
 }
 
+// GetLatest 获取最近N笔交易结果
+func (r *TradeRepository) GetLatest(limit int) ([]*models.TradeOutcome, error) {
+	query := `
+	SELECT ` + tradeOutcomeColumns + `
+	FROM trade_outcomes
+	WHERE trader_id = ?
+	ORDER BY close_time DESC
+	LIMIT ?
+	`
+
+	return r.queryTradeOutcomes(query, r.traderID, limit)
+}
+
+// Query 分页查询交易结果（按平仓时间倒序，最新的在前），同时返回过滤后的总数
+func (r *TradeRepository) Query(opts QueryOptions) ([]*models.TradeOutcome, int, error) {
+	where := &whereBuilder{}
+	where.add("trader_id = ?", r.traderID)
+	if !opts.Since.IsZero() {
+		where.add("close_time >= ?", opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		where.add("close_time < ?", opts.Until)
+	}
+	if opts.Success != nil {
+		if *opts.Success {
+			where.add("pnl > 0")
+		} else {
+			where.add("pnl <= 0")
+		}
+	}
+	if opts.Symbol != "" {
+		where.add("symbol = ?", opts.Symbol)
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM trade_outcomes WHERE `+where.String(), where.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计交易结果失败: %w", err)
+	}
+
+	if opts.BeforeID > 0 {
+		where.add("id < ?", opts.BeforeID)
+	}
+	columns := tradeOutcomeColumns
+	if opts.OmitHeavy {
+		columns = tradeOutcomeLightColumns
+	}
+	page, pageArgs := opts.pageClause()
+	query := `SELECT ` + columns + ` FROM trade_outcomes WHERE ` + where.String() + ` ORDER BY close_time DESC, id DESC` + page
+
+	trades, err := r.queryTradeOutcomes(query, append(where.args, pageArgs...)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询交易结果失败: %w", err)
+	}
+	return trades, total, nil
+}
+
+// GetBySymbolBetween 查询指定币种在时间范围内开仓或平仓的交易结果
+func (r *TradeRepository) GetBySymbolBetween(symbol string, from, to time.Time) ([]*models.TradeOutcome, error) {
+	query := `
+	SELECT ` + tradeOutcomeColumns + `
+	FROM trade_outcomes
+	WHERE trader_id = ? AND symbol = ?
+		AND ((open_time BETWEEN ? AND ?) OR (close_time BETWEEN ? AND ?))
+	ORDER BY close_time ASC
+	`
+
+	return r.queryTradeOutcomes(query, r.traderID, symbol, from, to, from, to)
+}
+
+// GetByRecord 查询由指定决策记录开仓或平仓的交易结果
+func (r *TradeRepository) GetByRecord(recordID int64) ([]*models.TradeOutcome, error) {
+	query := `
+	SELECT ` + tradeOutcomeColumns + `
+	FROM trade_outcomes
+	WHERE trader_id = ? AND (open_record_id = ? OR close_record_id = ?)
+	ORDER BY close_time ASC
+	`
+
+	return r.queryTradeOutcomes(query, r.traderID, recordID, recordID)
+}
+
+// GetRealizedLossSince 获取指定时间之后平仓的已实现亏损合计（正数）
+func (r *TradeRepository) GetRealizedLossSince(since time.Time) (float64, error) {
+	var loss float64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(-SUM(pnl), 0) FROM trade_outcomes
+		WHERE trader_id = ? AND pnl < 0 AND close_time >= ?
+	`, r.traderID, since).Scan(&loss)
+	return loss, err
+}
+
+// GetRealizedPnLBetween 获取 [from, to) 内平仓交易的已实现盈亏合计和笔数
+func (r *TradeRepository) GetRealizedPnLBetween(from, to time.Time) (float64, int, error) {
+	var pnl float64
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(pnl), 0), COUNT(*) FROM trade_outcomes
+		WHERE trader_id = ? AND close_time >= ? AND close_time < ?
+	`, r.traderID, from, to).Scan(&pnl, &count)
+	return pnl, count, err
+}
+
+// GetFirstBotOpenTime 获取本系统最早一笔交易的开仓时间（不含导入的历史交易，没有时返回零值）
+func (r *TradeRepository) GetFirstBotOpenTime() (time.Time, error) {
+	var openTime sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT open_time FROM trade_outcomes
+		WHERE trader_id = ? AND COALESCE(source, 'bot') != ?
+		ORDER BY open_time ASC LIMIT 1
+	`, r.traderID, models.TradeSourceImport).Scan(&openTime)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return openTime.Time, nil
+}
+
+// ExistsImported 检查导入的历史交易是否已存在（按币种、方向和开平仓时间去重）
+func (r *TradeRepository) ExistsImported(symbol, side string, openTime, closeTime time.Time) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM trade_outcomes
+		WHERE trader_id = ? AND source = ? AND symbol = ? AND side = ? AND open_time = ? AND close_time = ?
+	`, r.traderID, models.TradeSourceImport, symbol, side, openTime, closeTime).Scan(&count)
+	return count > 0, err
+}
+
 // GetStatistics 获取交易统计
 func (r *TradeRepository) GetStatistics() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
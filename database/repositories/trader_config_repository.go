@@ -27,8 +27,10 @@ func (r *TraderConfigRepository) Create(config *models.TraderConfig) (int64, err
 			initial_balance, scan_interval_minutes, max_positions,
 			btc_eth_leverage, altcoin_leverage,
 			max_daily_loss, max_drawdown, stop_trading_minutes,
-			enable_ai_learning, ai_learn_interval, ai_autonomy_mode, compact_mode
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			enable_ai_learning, ai_learn_interval, ai_autonomy_mode, compact_mode,
+			coin_source, coin_pool_api_url, oi_top_api_url, coin_pool_auth_header, coin_pool_refresh_seconds, market_data_source, prompt_language,
+			ai_params, critic
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := r.db.Exec(query,
 		config.UserID, config.TraderID, config.Name, config.Enabled, config.AIModel, config.Exchange,
@@ -40,6 +42,8 @@ func (r *TraderConfigRepository) Create(config *models.TraderConfig) (int64, err
 		config.BTCETHLeverage, config.AltcoinLeverage,
 		config.MaxDailyLoss, config.MaxDrawdown, config.StopTradingMinutes,
 		config.EnableAILearning, config.AILearnInterval, config.AIAutonomyMode, config.CompactMode,
+		config.CoinSource, config.CoinPoolAPIURL, config.OITopAPIURL, config.CoinPoolAuthHeader, config.CoinPoolRefreshSeconds, config.MarketDataSource, config.PromptLanguage,
+		config.AIParams, config.Critic,
 	)
 	if err != nil {
 		return 0, err
@@ -59,6 +63,8 @@ func (r *TraderConfigRepository) GetByID(id int64) (*models.TraderConfig, error)
 			btc_eth_leverage, altcoin_leverage,
 			max_daily_loss, max_drawdown, stop_trading_minutes,
 			enable_ai_learning, ai_learn_interval, ai_autonomy_mode, compact_mode,
+			coin_source, coin_pool_api_url, oi_top_api_url, coin_pool_auth_header, coin_pool_refresh_seconds, market_data_source, prompt_language,
+			COALESCE(ai_params, ''), COALESCE(critic, ''),
 			created_at, updated_at
 		FROM trader_configs WHERE id = ?
 	`
@@ -73,6 +79,8 @@ func (r *TraderConfigRepository) GetByID(id int64) (*models.TraderConfig, error)
 		&config.BTCETHLeverage, &config.AltcoinLeverage,
 		&config.MaxDailyLoss, &config.MaxDrawdown, &config.StopTradingMinutes,
 		&config.EnableAILearning, &config.AILearnInterval, &config.AIAutonomyMode, &config.CompactMode,
+		&config.CoinSource, &config.CoinPoolAPIURL, &config.OITopAPIURL, &config.CoinPoolAuthHeader, &config.CoinPoolRefreshSeconds, &config.MarketDataSource, &config.PromptLanguage,
+		&config.AIParams, &config.Critic,
 		&config.CreatedAt, &config.UpdatedAt,
 	)
 	if err != nil {
@@ -93,6 +101,8 @@ func (r *TraderConfigRepository) GetByTraderID(traderID string) (*models.TraderC
 			btc_eth_leverage, altcoin_leverage,
 			max_daily_loss, max_drawdown, stop_trading_minutes,
 			enable_ai_learning, ai_learn_interval, ai_autonomy_mode, compact_mode,
+			coin_source, coin_pool_api_url, oi_top_api_url, coin_pool_auth_header, coin_pool_refresh_seconds, market_data_source, prompt_language,
+			COALESCE(ai_params, ''), COALESCE(critic, ''),
 			created_at, updated_at
 		FROM trader_configs WHERE trader_id = ?
 	`
@@ -107,6 +117,8 @@ func (r *TraderConfigRepository) GetByTraderID(traderID string) (*models.TraderC
 		&config.BTCETHLeverage, &config.AltcoinLeverage,
 		&config.MaxDailyLoss, &config.MaxDrawdown, &config.StopTradingMinutes,
 		&config.EnableAILearning, &config.AILearnInterval, &config.AIAutonomyMode, &config.CompactMode,
+		&config.CoinSource, &config.CoinPoolAPIURL, &config.OITopAPIURL, &config.CoinPoolAuthHeader, &config.CoinPoolRefreshSeconds, &config.MarketDataSource, &config.PromptLanguage,
+		&config.AIParams, &config.Critic,
 		&config.CreatedAt, &config.UpdatedAt,
 	)
 	if err != nil {
@@ -127,6 +139,8 @@ func (r *TraderConfigRepository) GetByUserID(userID int64) ([]*models.TraderConf
 			btc_eth_leverage, altcoin_leverage,
 			max_daily_loss, max_drawdown, stop_trading_minutes,
 			enable_ai_learning, ai_learn_interval, ai_autonomy_mode, compact_mode,
+			coin_source, coin_pool_api_url, oi_top_api_url, coin_pool_auth_header, coin_pool_refresh_seconds, market_data_source, prompt_language,
+			COALESCE(ai_params, ''), COALESCE(critic, ''),
 			created_at, updated_at
 		FROM trader_configs WHERE user_id = ?
 		ORDER BY created_at DESC
@@ -150,6 +164,8 @@ func (r *TraderConfigRepository) GetByUserID(userID int64) ([]*models.TraderConf
 			&config.BTCETHLeverage, &config.AltcoinLeverage,
 			&config.MaxDailyLoss, &config.MaxDrawdown, &config.StopTradingMinutes,
 			&config.EnableAILearning, &config.AILearnInterval, &config.AIAutonomyMode, &config.CompactMode,
+			&config.CoinSource, &config.CoinPoolAPIURL, &config.OITopAPIURL, &config.CoinPoolAuthHeader, &config.CoinPoolRefreshSeconds, &config.MarketDataSource, &config.PromptLanguage,
+			&config.AIParams, &config.Critic,
 			&config.CreatedAt, &config.UpdatedAt,
 		)
 		if err != nil {
@@ -172,6 +188,8 @@ func (r *TraderConfigRepository) GetAllEnabled() ([]*models.TraderConfig, error)
 			btc_eth_leverage, altcoin_leverage,
 			max_daily_loss, max_drawdown, stop_trading_minutes,
 			enable_ai_learning, ai_learn_interval, ai_autonomy_mode, compact_mode,
+			coin_source, coin_pool_api_url, oi_top_api_url, coin_pool_auth_header, coin_pool_refresh_seconds, market_data_source, prompt_language,
+			COALESCE(ai_params, ''), COALESCE(critic, ''),
 			created_at, updated_at
 		FROM trader_configs WHERE enabled = 1
 		ORDER BY created_at DESC
@@ -195,6 +213,8 @@ func (r *TraderConfigRepository) GetAllEnabled() ([]*models.TraderConfig, error)
 			&config.BTCETHLeverage, &config.AltcoinLeverage,
 			&config.MaxDailyLoss, &config.MaxDrawdown, &config.StopTradingMinutes,
 			&config.EnableAILearning, &config.AILearnInterval, &config.AIAutonomyMode, &config.CompactMode,
+			&config.CoinSource, &config.CoinPoolAPIURL, &config.OITopAPIURL, &config.CoinPoolAuthHeader, &config.CoinPoolRefreshSeconds, &config.MarketDataSource, &config.PromptLanguage,
+			&config.AIParams, &config.Critic,
 			&config.CreatedAt, &config.UpdatedAt,
 		)
 		if err != nil {
@@ -218,6 +238,8 @@ func (r *TraderConfigRepository) Update(config *models.TraderConfig) error {
 			btc_eth_leverage = ?, altcoin_leverage = ?,
 			max_daily_loss = ?, max_drawdown = ?, stop_trading_minutes = ?,
 			enable_ai_learning = ?, ai_learn_interval = ?, ai_autonomy_mode = ?, compact_mode = ?,
+			coin_source = ?, coin_pool_api_url = ?, oi_top_api_url = ?, coin_pool_auth_header = ?, coin_pool_refresh_seconds = ?, market_data_source = ?, prompt_language = ?,
+			ai_params = ?, critic = ?,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
@@ -231,6 +253,8 @@ func (r *TraderConfigRepository) Update(config *models.TraderConfig) error {
 		config.BTCETHLeverage, config.AltcoinLeverage,
 		config.MaxDailyLoss, config.MaxDrawdown, config.StopTradingMinutes,
 		config.EnableAILearning, config.AILearnInterval, config.AIAutonomyMode, &config.CompactMode,
+		config.CoinSource, config.CoinPoolAPIURL, config.OITopAPIURL, config.CoinPoolAuthHeader, config.CoinPoolRefreshSeconds, config.MarketDataSource, config.PromptLanguage,
+		config.AIParams, config.Critic,
 		config.ID,
 	)
 	return err
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"database/sql"
+	"nofx/database/models"
+)
+
+// TraderTemplateRepository Trader配置模板数据访问层
+type TraderTemplateRepository struct {
+	db *sql.DB
+}
+
+// NewTraderTemplateRepository 创建Trader配置模板仓储
+func NewTraderTemplateRepository(db *sql.DB) *TraderTemplateRepository {
+	return &TraderTemplateRepository{db: db}
+}
+
+// Save 保存模板（同名模板覆盖，保留创建时间）
+func (r *TraderTemplateRepository) Save(template *models.TraderTemplate) error {
+	query := `
+		INSERT INTO trader_templates (name, description, config_json, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET
+			description = excluded.description,
+			config_json = excluded.config_json,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.Exec(query, template.Name, template.Description, template.ConfigJSON)
+	return err
+}
+
+// GetByName 根据名称获取模板
+func (r *TraderTemplateRepository) GetByName(name string) (*models.TraderTemplate, error) {
+	query := `
+		SELECT id, name, description, config_json, created_at, updated_at
+		FROM trader_templates WHERE name = ?
+	`
+	template := &models.TraderTemplate{}
+	err := r.db.QueryRow(query, name).Scan(
+		&template.ID, &template.Name, &template.Description, &template.ConfigJSON,
+		&template.CreatedAt, &template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// List 获取所有模板
+func (r *TraderTemplateRepository) List() ([]*models.TraderTemplate, error) {
+	query := `
+		SELECT id, name, description, config_json, created_at, updated_at
+		FROM trader_templates ORDER BY name
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.TraderTemplate
+	for rows.Next() {
+		template := &models.TraderTemplate{}
+		err := rows.Scan(
+			&template.ID, &template.Name, &template.Description, &template.ConfigJSON,
+			&template.CreatedAt, &template.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+// Delete 删除模板，返回是否存在
+func (r *TraderTemplateRepository) Delete(name string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM trader_templates WHERE name = ?`, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -2,7 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"strings"
 	"sync"
+	"time"
 )
 
 // RuntimeConfig 运行时配置管理器（支持热重载）
@@ -78,6 +80,51 @@ func (rc *RuntimeConfig) GetRiskThresholds() RiskThresholds {
 	}
 }
 
+// RiskBudgetConfig 日风险预算配置
+type RiskBudgetConfig struct {
+	DailyBudgetPct float64 // 日风险预算占账户净值的百分比
+	Enforce        bool    // 预算不足时是否拒绝新开仓
+}
+
+// RebalanceConfig 组合再平衡配置
+type RebalanceConfig struct {
+	Enabled       bool              // 是否在Prompt中注入再平衡建议并检查新开仓
+	MaxSymbolPct  float64           // 单币种占总敞口的最大比例(%)
+	MaxSectorPct  float64           // 单板块占总敞口的最大比例(%)
+	MaxNetBiasPct float64           // 最大净方向偏离(%)
+	MinPositions  int               // 持仓数达到该值才检查集中度
+	Enforce       bool              // 新开仓加剧失衡时是否拒绝（false只警告）
+	SectorMap     map[string]string // 基础币种 → 板块（覆盖内置映射）
+}
+
+// GetRebalanceConfig 获取组合再平衡配置
+func (rc *RuntimeConfig) GetRebalanceConfig() RebalanceConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := RebalanceConfig{
+		Enabled:       rc.helper.GetBool("rebalance_enabled", true),
+		MaxSymbolPct:  rc.helper.GetFloat("rebalance_max_symbol_pct", 50.0),
+		MaxSectorPct:  rc.helper.GetFloat("rebalance_max_sector_pct", 70.0),
+		MaxNetBiasPct: rc.helper.GetFloat("rebalance_max_net_bias_pct", 80.0),
+		MinPositions:  rc.helper.GetInt("rebalance_min_positions", 2),
+		Enforce:       rc.helper.GetBool("rebalance_enforce", false),
+	}
+	rc.helper.GetJSON("rebalance_sector_map", &cfg.SectorMap, map[string]string{})
+	return cfg
+}
+
+// GetRiskBudgetConfig 获取日风险预算配置
+func (rc *RuntimeConfig) GetRiskBudgetConfig() RiskBudgetConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return RiskBudgetConfig{
+		DailyBudgetPct: rc.helper.GetFloat("risk_daily_budget_pct", 5.0),
+		Enforce:        rc.helper.GetBool("risk_budget_enforce", true),
+	}
+}
+
 // RiskScores 风险评分权重配置
 type RiskScores struct {
 	MarginHighScore       int
@@ -144,6 +191,7 @@ type PoolConfig struct {
 	RetryDelayMS   int
 	TimeoutSeconds int
 	CacheTTLMin    int
+	AllowedQuoteAssets []string // 候选币种允许的计价币种（USDT/USDC；币本位为USD，仍需交易所支持）
 }
 
 // GetPoolConfig 获取币种池配置
@@ -151,12 +199,855 @@ func (rc *RuntimeConfig) GetPoolConfig() PoolConfig {
 	rc.mu.RLock()
 	defer rc.mu.RUnlock()
 	
-	return PoolConfig{
+	cfg := PoolConfig{
 		MaxRetries:     rc.helper.GetInt("pool_max_retries", 3),
 		RetryDelayMS:   rc.helper.GetInt("pool_retry_delay_ms", 100),
 		TimeoutSeconds: rc.helper.GetInt("pool_timeout_seconds", 10),
 		CacheTTLMin:    rc.helper.GetInt("pool_cache_ttl_minutes", 5),
 	}
+	rc.helper.GetJSON("pool_allowed_quote_assets", &cfg.AllowedQuoteAssets, []string{"USDT", "USDC"})
+	return cfg
+}
+
+// OIHistoryConfig 持仓量历史配置
+type OIHistoryConfig struct {
+	Period      string // openInterestHist 周期
+	SeriesShown int    // Prompt中展示的历史点数
+}
+
+// GetOIHistoryConfig 获取持仓量历史配置
+func (rc *RuntimeConfig) GetOIHistoryConfig() OIHistoryConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return OIHistoryConfig{
+		Period:      rc.helper.GetString("oi_history_period", "1h"),
+		SeriesShown: rc.helper.GetInt("oi_history_series_shown", 12),
+	}
+}
+
+// LiquidationConfig 爆仓监控配置
+type LiquidationConfig struct {
+	CascadeUSD       float64 // 全市场5分钟爆仓金额阈值(USDT)
+	SymbolCascadeUSD float64 // 单币种5分钟爆仓金额阈值(USDT)
+	PauseEntries     bool    // 连环爆仓期间暂停新开仓
+}
+
+// GetLiquidationConfig 获取爆仓监控配置
+func (rc *RuntimeConfig) GetLiquidationConfig() LiquidationConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return LiquidationConfig{
+		CascadeUSD:       rc.helper.GetFloat("liquidation_cascade_usd", 20000000),
+		SymbolCascadeUSD: rc.helper.GetFloat("liquidation_symbol_cascade_usd", 2000000),
+		PauseEntries:     rc.helper.GetBool("liquidation_pause_entries", true),
+	}
+}
+
+// OrderFlowConfig 订单流（盘口微观结构）配置
+type OrderFlowConfig struct {
+	Enabled      bool    // 订阅持仓币种的深度增量和逐笔成交
+	ProximityPct float64 // 距止损/止盈不超过该百分比时在Prompt中提供订单流信号
+}
+
+// GetOrderFlowConfig 获取订单流配置
+func (rc *RuntimeConfig) GetOrderFlowConfig() OrderFlowConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return OrderFlowConfig{
+		Enabled:      rc.helper.GetBool("orderflow_enabled", false),
+		ProximityPct: rc.helper.GetFloat("orderflow_proximity_pct", 1.0),
+	}
+}
+
+// AIConfig AI调用相关配置
+type AIConfig struct {
+	PromptCacheWindowMinutes int // 相同Prompt复用上次决策的时间窗口（0=关闭）
+	CompactMaxCandidates     int // 紧凑模式下最多分析的候选币种数（0=不限制）
+}
+
+// GetAIConfig 获取AI调用配置
+func (rc *RuntimeConfig) GetAIConfig() AIConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return AIConfig{
+		PromptCacheWindowMinutes: rc.helper.GetInt("ai_prompt_cache_window_minutes", 10),
+		CompactMaxCandidates:     rc.helper.GetInt("ai_compact_max_candidates", 5),
+	}
+}
+
+// LearningConfig AI学习与交易归因配置
+type LearningConfig struct {
+	EntrySnapshotEnabled    bool    // 是否记录开仓时的指标快照
+	RSIOverbought           float64 // 归因分析：RSI超买阈值
+	RSIOversold             float64 // 归因分析：RSI超卖阈值
+	HighVolumeRatio         float64 // 归因分析：放量阈值（当前成交量/平均成交量）
+	TimeOfDayPromptEnabled  bool    // 是否在决策prompt中提示历史弱势/强势开仓时段
+	TimeOfDayLookbackTrades int     // 时段分析使用的最近交易数
+	TimeOfDayMinTrades      int     // 时段结论要求的最少交易数（样本不足的时段不下结论）
+}
+
+// defaultLearningConfig 学习配置默认值
+var defaultLearningConfig = LearningConfig{
+	EntrySnapshotEnabled:    true,
+	RSIOverbought:           70.0,
+	RSIOversold:             30.0,
+	HighVolumeRatio:         1.5,
+	TimeOfDayPromptEnabled:  true,
+	TimeOfDayLookbackTrades: 200,
+	TimeOfDayMinTrades:      8,
+}
+
+// GetLearningConfig 获取AI学习与交易归因配置
+func (rc *RuntimeConfig) GetLearningConfig() LearningConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return LearningConfig{
+		EntrySnapshotEnabled:    rc.helper.GetBool("learning_entry_snapshot_enabled", defaultLearningConfig.EntrySnapshotEnabled),
+		RSIOverbought:           rc.helper.GetFloat("learning_rsi_overbought", defaultLearningConfig.RSIOverbought),
+		RSIOversold:             rc.helper.GetFloat("learning_rsi_oversold", defaultLearningConfig.RSIOversold),
+		HighVolumeRatio:         rc.helper.GetFloat("learning_high_volume_ratio", defaultLearningConfig.HighVolumeRatio),
+		TimeOfDayPromptEnabled:  rc.helper.GetBool("learning_time_of_day_prompt_enabled", defaultLearningConfig.TimeOfDayPromptEnabled),
+		TimeOfDayLookbackTrades: rc.helper.GetInt("learning_time_of_day_lookback_trades", defaultLearningConfig.TimeOfDayLookbackTrades),
+		TimeOfDayMinTrades:      rc.helper.GetInt("learning_time_of_day_min_trades", defaultLearningConfig.TimeOfDayMinTrades),
+	}
+}
+
+// CurrentLearningConfig 获取当前生效的学习配置（全局配置未初始化时返回默认值）
+func CurrentLearningConfig() LearningConfig {
+	if rc := GetGlobalConfig(); rc != nil {
+		return rc.GetLearningConfig()
+	}
+	return defaultLearningConfig
+}
+
+// RetentionConfig 决策历史保留配置
+type RetentionConfig struct {
+	Days           int  // 决策记录保留天数（0表示永久保留）
+	ArchiveEnabled bool // 清理前是否归档到按月压缩的文件
+}
+
+// GetRetentionConfig 获取决策历史保留配置
+func (rc *RuntimeConfig) GetRetentionConfig() RetentionConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return RetentionConfig{
+		Days:           rc.helper.GetInt("retention_days", 30),
+		ArchiveEnabled: rc.helper.GetBool("retention_archive_enabled", true),
+	}
+}
+
+// ExecutionConfig 下单执行配置
+type ExecutionConfig struct {
+	MaxPriceDriftPct     float64        // 下单前价格相对AI分析价格的最大漂移(%)，0表示不检查
+	DriftAction          string         // 超过漂移阈值时的处理方式：reject（拒绝）或 resize（按止损距离缩减仓位）
+	CloseConcurrency     int            // 同一周期内平仓决策的最大并发数
+	OrderDelayMs         int            // 顺序下单之间的间隔(毫秒)
+	ExchangeOrderDelayMs map[string]int // 各交易所的下单间隔(毫秒)，未配置时使用OrderDelayMs
+	AllowStopLoosening   bool           // 是否允许update_stop_loss放宽止损（默认只允许收紧）
+	AuditEnabled         bool           // 是否记录交易所请求审计（修改后需重启trader）
+	PreviewMode          bool           // 预览模式：照常获取行情、调用AI和验证，生成计划订单但不下单
+	BookSnapshotEnabled  bool           // 下单前记录盘口前5档和最近成交（保存到决策动作，用于成交质量分析）
+	BookSnapshotTrades   int            // 盘口快照附带的最近成交笔数（0表示不记录成交）
+}
+
+// OrderDelay 指定交易所的下单间隔
+func (c ExecutionConfig) OrderDelay(exchange string) time.Duration {
+	ms := c.OrderDelayMs
+	if v, ok := c.ExchangeOrderDelayMs[exchange]; ok && v >= 0 {
+		ms = v
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// GetExecutionConfig 获取下单执行配置
+func (rc *RuntimeConfig) GetExecutionConfig() ExecutionConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return ExecutionConfig{
+		MaxPriceDriftPct: rc.helper.GetFloat("execution_max_price_drift_pct", 1.0),
+		DriftAction:      rc.helper.GetString("execution_drift_action", "reject"),
+		CloseConcurrency: rc.helper.GetInt("execution_close_concurrency", 3),
+		OrderDelayMs:     rc.helper.GetInt("execution_order_delay_ms", 1000),
+		ExchangeOrderDelayMs: map[string]int{
+			"binance":     rc.helper.GetInt("execution_order_delay_ms_binance", -1),
+			"hyperliquid": rc.helper.GetInt("execution_order_delay_ms_hyperliquid", -1),
+			"aster":       rc.helper.GetInt("execution_order_delay_ms_aster", -1),
+		},
+		AllowStopLoosening: rc.helper.GetBool("execution_allow_stop_loosening", false),
+		AuditEnabled:       rc.helper.GetBool("execution_audit_enabled", true),
+		PreviewMode:        rc.helper.GetBool("execution_preview_mode", false),
+		BookSnapshotEnabled: rc.helper.GetBool("execution_book_snapshot_enabled", true),
+		BookSnapshotTrades:  rc.helper.GetInt("execution_book_snapshot_trades", 20),
+	}
+}
+
+// DataQualityConfig 行情数据质量检查配置
+type DataQualityConfig struct {
+	OutlierSigma      float64 // 价格跳变异常阈值（稳健标准差倍数）
+	MaxMissingCandles int     // 允许的缺失/零成交K线数量
+	StaleIntervals    int     // 最新K线允许落后的周期数（0表示不检查）
+	ExcludeDegraded   bool    // 数据降级的币种是否禁止新开仓
+}
+
+// GetDataQualityConfig 获取行情数据质量检查配置
+func (rc *RuntimeConfig) GetDataQualityConfig() DataQualityConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return DataQualityConfig{
+		OutlierSigma:      rc.helper.GetFloat("data_quality_outlier_sigma", 8.0),
+		MaxMissingCandles: rc.helper.GetInt("data_quality_max_missing_candles", 2),
+		StaleIntervals:    rc.helper.GetInt("data_quality_stale_intervals", 2),
+		ExcludeDegraded:   rc.helper.GetBool("data_quality_exclude_degraded", true),
+	}
+}
+
+// ApprovalConfig 大额交易人工审批配置
+type ApprovalConfig struct {
+	Enabled           bool    // 是否启用审批（超过阈值的开仓决策进入待审批队列）
+	NotionalThreshold float64 // 名义价值超过该值(USDT)需审批，0表示不按名义价值判断
+	RiskThreshold     float64 // 止损风险超过该值(USDT)需审批，0表示不按风险判断
+	TimeoutMinutes    int     // 审批超时时间（分钟），超时后决策作废并记录为跳过
+}
+
+// GetApprovalConfig 获取大额交易人工审批配置
+func (rc *RuntimeConfig) GetApprovalConfig() ApprovalConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return ApprovalConfig{
+		Enabled:           rc.helper.GetBool("approval_enabled", false),
+		NotionalThreshold: rc.helper.GetFloat("approval_notional_threshold_usd", 0),
+		RiskThreshold:     rc.helper.GetFloat("approval_risk_threshold_usd", 0),
+		TimeoutMinutes:    rc.helper.GetInt("approval_timeout_minutes", 10),
+	}
+}
+
+// LiquidationGuardConfig 止损与强平价距离校验配置
+type LiquidationGuardConfig struct {
+	Enabled   bool    // 是否启用（开仓前按杠杆和保证金档位估算强平价，校验止损是否在强平价之前）
+	BufferPct float64 // 止损与强平价之间至少保留的距离（占开仓价的%）
+	Mode      string  // adjust=把止损收紧到强平价缓冲以内，reject=直接拒绝开仓
+}
+
+// GetLiquidationGuardConfig 获取止损与强平价距离校验配置
+func (rc *RuntimeConfig) GetLiquidationGuardConfig() LiquidationGuardConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return LiquidationGuardConfig{
+		Enabled:   rc.helper.GetBool("liq_guard_enabled", true),
+		BufferPct: rc.helper.GetFloat("liq_guard_buffer_pct", 1.0),
+		Mode:      rc.helper.GetString("liq_guard_mode", "adjust"),
+	}
+}
+
+// RiskGroupConfig 单个币种分组的风控额度（组内持仓共用额度，与其他分组隔离）
+type RiskGroupConfig struct {
+	Name           string   `json:"name"`             // 分组名称，如 majors
+	Symbols        []string `json:"symbols"`          // 基础币种（如BTC），为空表示收纳其他分组未列出的币种
+	MaxPositions   int      `json:"max_positions"`    // 组内最大同时持仓数，0表示不限制
+	MaxNotionalUSD float64  `json:"max_notional_usd"` // 组内最大名义敞口(USDT)，0表示不限制
+	MaxLeverage    int      `json:"max_leverage"`     // 组内杠杆上限，0表示不限制
+}
+
+// RiskGroupsConfig 分组风控配置
+type RiskGroupsConfig struct {
+	Enabled bool
+	Groups  []RiskGroupConfig
+}
+
+// defaultRiskGroups 默认分组：主流币、公链币、Meme币，其余币种归入 other
+var defaultRiskGroups = []RiskGroupConfig{
+	{Name: "majors", Symbols: []string{"BTC", "ETH"}, MaxPositions: 2, MaxLeverage: 20},
+	{Name: "l1_alts", Symbols: []string{"SOL", "AVAX", "ADA", "DOT", "NEAR", "APT", "SUI", "TRX", "TON", "SEI", "ATOM", "BNB"}, MaxPositions: 2, MaxLeverage: 10},
+	{Name: "memecoins", Symbols: []string{"DOGE", "SHIB", "PEPE", "WIF", "BONK", "FLOKI", "1000PEPE", "1000SHIB", "1000BONK", "1000FLOKI"}, MaxPositions: 1, MaxLeverage: 5},
+	{Name: "other", MaxPositions: 2, MaxLeverage: 10},
+}
+
+// GetRiskGroupsConfig 获取分组风控配置
+func (rc *RuntimeConfig) GetRiskGroupsConfig() RiskGroupsConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := RiskGroupsConfig{
+		Enabled: rc.helper.GetBool("risk_groups_enabled", false),
+	}
+	rc.helper.GetJSON("risk_groups", &cfg.Groups, defaultRiskGroups)
+	return cfg
+}
+
+// WatchdogConfig 交易循环看门狗配置
+type WatchdogConfig struct {
+	Enabled         bool    // 是否启用（检测交易循环卡死并发出危险预警）
+	StallMultiplier float64 // 超过 N × 扫描间隔 没有完成周期视为卡死
+	AutoRestart     bool    // 卡死时是否自动重建trader实例（旧实例恢复后不再下单）
+}
+
+// GetWatchdogConfig 获取交易循环看门狗配置
+func (rc *RuntimeConfig) GetWatchdogConfig() WatchdogConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return WatchdogConfig{
+		Enabled:         rc.helper.GetBool("watchdog_enabled", true),
+		StallMultiplier: rc.helper.GetFloat("watchdog_stall_multiplier", 3.0),
+		AutoRestart:     rc.helper.GetBool("watchdog_auto_restart", false),
+	}
+}
+
+// PositionDriftConfig 周期间持仓止损监控配置
+type PositionDriftConfig struct {
+	Enabled         bool // 是否在两个交易周期之间检查持仓是否越过止损
+	IntervalSeconds int  // 检查间隔(秒，建议15-30)
+}
+
+// GetPositionDriftConfig 获取周期间持仓止损监控配置
+func (rc *RuntimeConfig) GetPositionDriftConfig() PositionDriftConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return PositionDriftConfig{
+		Enabled:         rc.helper.GetBool("position_drift_enabled", true),
+		IntervalSeconds: rc.helper.GetInt("position_drift_interval_seconds", 20),
+	}
+}
+
+// EntryThrottleConfig 每日开仓次数限制（0表示不限制）
+type EntryThrottleConfig struct {
+	MaxPerSymbolDaily int // 单个币种每日最多开仓次数
+	MaxTotalDaily     int // 每日最多开仓总次数
+}
+
+// GetEntryThrottleConfig 获取每日开仓次数限制
+func (rc *RuntimeConfig) GetEntryThrottleConfig() EntryThrottleConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return EntryThrottleConfig{
+		MaxPerSymbolDaily: rc.helper.GetInt("entry_throttle_max_per_symbol_daily", 0),
+		MaxTotalDaily:     rc.helper.GetInt("entry_throttle_max_total_daily", 0),
+	}
+}
+
+// ConfidenceFloor 决策最低信心度（0表示不限制）
+type ConfidenceFloor struct {
+	TraderID string `json:"trader_id"` // 适用的trader（按trader覆盖时使用）
+	Entry    int    `json:"entry"`     // 开仓决策最低信心度
+	Close    int    `json:"close"`     // 平仓决策最低信心度
+}
+
+// ConfidenceConfig 决策最低信心度配置
+type ConfidenceConfig struct {
+	Default   ConfidenceFloor   // 所有trader的默认值
+	Overrides []ConfidenceFloor // 按trader覆盖（整条替换默认值）
+}
+
+// For 获取指定trader适用的最低信心度
+func (c ConfidenceConfig) For(traderID string) ConfidenceFloor {
+	for _, o := range c.Overrides {
+		if o.TraderID == traderID {
+			return o
+		}
+	}
+	floor := c.Default
+	floor.TraderID = traderID
+	return floor
+}
+
+// GetConfidenceConfig 获取决策最低信心度配置
+func (rc *RuntimeConfig) GetConfidenceConfig() ConfidenceConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := ConfidenceConfig{
+		Default: ConfidenceFloor{
+			Entry: rc.helper.GetInt("confidence_min_entry", 0),
+			Close: rc.helper.GetInt("confidence_min_close", 0),
+		},
+	}
+	rc.helper.GetJSON("confidence_floors", &cfg.Overrides, []ConfidenceFloor{})
+	return cfg
+}
+
+// LiquidityThresholds 候选币种流动性门槛（0表示不检查该项）
+type LiquidityThresholds struct {
+	MinOIValueM     float64 `json:"min_oi_value_m"`     // 最小持仓价值(百万USD)
+	MinQuoteVolumeM float64 `json:"min_quote_volume_m"` // 最小24h成交额(百万USD)
+	MaxAvgSpreadBps float64 `json:"max_avg_spread_bps"` // 最大平均买卖价差(基点)
+}
+
+// LiquidityGroupConfig 按币种分组覆盖的流动性门槛
+type LiquidityGroupConfig struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"` // 基础币种（如BTC），为空表示收纳其他分组未列出的币种
+	LiquidityThresholds
+}
+
+// LiquidityFilterConfig 候选币种流动性过滤配置
+type LiquidityFilterConfig struct {
+	Mode    string                 // oi=只按持仓价值 / volume=只按成交额和价差 / both=全部满足
+	Default LiquidityThresholds    // 未匹配分组的币种使用的门槛
+	Groups  []LiquidityGroupConfig // 按分组覆盖（整条替换默认门槛）
+}
+
+// GetLiquidityFilterConfig 获取候选币种流动性过滤配置
+func (rc *RuntimeConfig) GetLiquidityFilterConfig() LiquidityFilterConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := LiquidityFilterConfig{
+		Mode: strings.ToLower(strings.TrimSpace(rc.helper.GetString("liquidity_filter_mode", "oi"))),
+		Default: LiquidityThresholds{
+			MinOIValueM:     rc.helper.GetFloat("liquidity_min_oi_value_m", 15),
+			MinQuoteVolumeM: rc.helper.GetFloat("liquidity_min_quote_volume_m", 50),
+			MaxAvgSpreadBps: rc.helper.GetFloat("liquidity_max_avg_spread_bps", 10),
+		},
+	}
+	rc.helper.GetJSON("liquidity_groups", &cfg.Groups, []LiquidityGroupConfig{})
+	return cfg
+}
+
+// ReportingConfig 报告币种配置
+type ReportingConfig struct {
+	Currency string // 报告币种（USD/USDT/USDC/BTC等），对比视图和分析按指数价格折算为该币种
+}
+
+// GetReportingConfig 获取报告币种配置
+func (rc *RuntimeConfig) GetReportingConfig() ReportingConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	currency := strings.ToUpper(strings.TrimSpace(rc.helper.GetString("reporting_currency", "USD")))
+	if currency == "" {
+		currency = "USD"
+	}
+	return ReportingConfig{Currency: currency}
+}
+
+// DrawdownLockConfig 净值峰值回撤锁配置
+type DrawdownLockConfig struct {
+	Enabled bool    // 是否启用（净值从峰值回撤超过阈值时切换为只平仓模式，需人工解除）
+	Pct     float64 // 回撤阈值(%，相对净值峰值)
+}
+
+// GetDrawdownLockConfig 获取净值峰值回撤锁配置
+func (rc *RuntimeConfig) GetDrawdownLockConfig() DrawdownLockConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return DrawdownLockConfig{
+		Enabled: rc.helper.GetBool("drawdown_lock_enabled", false),
+		Pct:     rc.helper.GetFloat("drawdown_lock_pct", 10.0),
+	}
+}
+
+// BalanceReconcileConfig 入金/出金对账配置
+type BalanceReconcileConfig struct {
+	Enabled         bool // 是否定期从交易所同步资金划转，按净投入（初始余额+净入金）计算盈亏和回撤
+	IntervalMinutes int  // 同步间隔(分钟)
+}
+
+// GetBalanceReconcileConfig 获取入金/出金对账配置
+func (rc *RuntimeConfig) GetBalanceReconcileConfig() BalanceReconcileConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return BalanceReconcileConfig{
+		Enabled:         rc.helper.GetBool("balance_reconcile_enabled", true),
+		IntervalMinutes: rc.helper.GetInt("balance_reconcile_interval_minutes", 60),
+	}
+}
+
+// IncomeSyncConfig 交易所收入历史同步配置
+type IncomeSyncConfig struct {
+	Enabled         bool // 是否定期同步已实现盈亏、资金费、手续费等收入历史（用于月度收益表）
+	IntervalMinutes int  // 同步间隔(分钟)
+}
+
+// GetIncomeSyncConfig 获取交易所收入历史同步配置
+func (rc *RuntimeConfig) GetIncomeSyncConfig() IncomeSyncConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return IncomeSyncConfig{
+		Enabled:         rc.helper.GetBool("income_sync_enabled", true),
+		IntervalMinutes: rc.helper.GetInt("income_sync_interval_minutes", 60),
+	}
+}
+
+// SharedStateConfig 共享状态配置（多进程/多主机部署时通过Redis共享）
+type SharedStateConfig struct {
+	Backend               string // memory（默认，进程内存）或 redis（修改后需重启）
+	RedisAddr             string // Redis地址 host:port
+	RedisPassword         string // Redis密码
+	RedisDB               int    // Redis库编号
+	KeyPrefix             string // 键前缀（多套部署共用一个Redis时区分）
+	MarketCacheTTLSeconds int    // K线共享缓存时长(秒)，0表示不缓存
+	RateLimitPerMinute    int    // 所有进程合计每分钟请求K线接口的次数上限，0表示不限流
+}
+
+// GetSharedStateConfig 获取共享状态配置
+func (rc *RuntimeConfig) GetSharedStateConfig() SharedStateConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return SharedStateConfig{
+		Backend:               rc.helper.GetString("shared_state_backend", "memory"),
+		RedisAddr:             rc.helper.GetString("shared_state_redis_addr", "127.0.0.1:6379"),
+		RedisPassword:         rc.helper.GetString("shared_state_redis_password", ""),
+		RedisDB:               rc.helper.GetInt("shared_state_redis_db", 0),
+		KeyPrefix:             rc.helper.GetString("shared_state_key_prefix", "nofx:"),
+		MarketCacheTTLSeconds: rc.helper.GetInt("shared_market_cache_ttl_seconds", 0),
+		RateLimitPerMinute:    rc.helper.GetInt("shared_rate_limit_per_minute", 0),
+	}
+}
+
+// SizingConfig 基于ATR的仓位建议配置
+type SizingConfig struct {
+	PromptEnabled   bool    // 是否在prompt中提供波动率和1R仓位表
+	RiskPerTradePct float64 // 单笔风险占账户净值的比例(%)
+	ATRStopMultiple float64 // 建议止损距离 = N × ATR14
+	// PositionMultiplier 压力测试缩放系数：下单前按比例缩小所有开仓金额（不修改AI提示词），1表示不缩放
+	PositionMultiplier float64
+}
+
+// SizingPositionMultiplierKey 压力测试缩放系数的配置键
+const SizingPositionMultiplierKey = "sizing_position_multiplier"
+
+// NormalizePositionMultiplier 缩放系数只允许缩小仓位：超出 (0, 1] 时按1处理
+func NormalizePositionMultiplier(m float64) float64 {
+	if m <= 0 || m > 1 {
+		return 1
+	}
+	return m
+}
+
+// GetSizingConfig 获取ATR仓位建议配置
+func (rc *RuntimeConfig) GetSizingConfig() SizingConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return SizingConfig{
+		PromptEnabled:   rc.helper.GetBool("sizing_prompt_enabled", true),
+		RiskPerTradePct: rc.helper.GetFloat("sizing_risk_per_trade_pct", 1.0),
+		ATRStopMultiple: rc.helper.GetFloat("sizing_atr_stop_multiple", 1.5),
+		PositionMultiplier: NormalizePositionMultiplier(
+			rc.helper.GetFloat(SizingPositionMultiplierKey, 1.0)),
+	}
+}
+
+// FundingScheduleConfig 资金费结算时间感知配置
+type FundingScheduleConfig struct {
+	PromptEnabled     bool    // 是否在prompt中提供候选币种距下次结算的时间和预计资金费
+	EntryDelayMinutes int     // 距结算不足N分钟且费率对开仓方向不利时延迟开仓，0表示不限制
+	AdverseRatePct    float64 // 不利费率阈值(%，单次结算)，超过该值才延迟开仓
+}
+
+// GetFundingScheduleConfig 获取资金费结算时间感知配置
+func (rc *RuntimeConfig) GetFundingScheduleConfig() FundingScheduleConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return FundingScheduleConfig{
+		PromptEnabled:     rc.helper.GetBool("funding_prompt_enabled", true),
+		EntryDelayMinutes: rc.helper.GetInt("funding_entry_delay_minutes", 0),
+		AdverseRatePct:    rc.helper.GetFloat("funding_adverse_rate_pct", 0.05),
+	}
+}
+
+// ExitPolicyConfig 自动退出策略配置（独立于AI决策，每个周期检查一次）
+type ExitPolicyConfig struct {
+	BreakEvenEnabled    bool     // 是否启用保本止损
+	BreakEvenR          float64  // 浮盈达到N倍初始风险(R)后把止损移到开仓价
+	TimeStopEnabled     bool     // 是否启用时间止损
+	TimeStopHours       float64  // 持仓超过N小时且从未达到TimeStopMinR时平仓
+	TimeStopMinR        float64  // 时间止损要求的最低浮盈(R)
+	WeekendCloseEnabled bool     // 是否在周末前平仓
+	EventCloseMinutes   int      // 周末/重大事件前N分钟平仓
+	EventTimes          []string // 重大事件时间（RFC3339，如 2026-10-28T18:00:00Z）
+}
+
+// GetExitPolicyConfig 获取自动退出策略配置
+func (rc *RuntimeConfig) GetExitPolicyConfig() ExitPolicyConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := ExitPolicyConfig{
+		BreakEvenEnabled:    rc.helper.GetBool("exit_policy_break_even_enabled", false),
+		BreakEvenR:          rc.helper.GetFloat("exit_policy_break_even_r", 1.0),
+		TimeStopEnabled:     rc.helper.GetBool("exit_policy_time_stop_enabled", false),
+		TimeStopHours:       rc.helper.GetFloat("exit_policy_time_stop_hours", 24),
+		TimeStopMinR:        rc.helper.GetFloat("exit_policy_time_stop_min_r", 0.5),
+		WeekendCloseEnabled: rc.helper.GetBool("exit_policy_weekend_close_enabled", false),
+		EventCloseMinutes:   rc.helper.GetInt("exit_policy_event_close_minutes", 30),
+	}
+	rc.helper.GetJSON("exit_policy_event_times", &cfg.EventTimes, []string{})
+	return cfg
+}
+
+// MaintenanceWindow 周期性维护窗口（交易所维护、休息时段等，窗口内trader自动暂停，结束后自动恢复）
+type MaintenanceWindow struct {
+	Name     string   `json:"name"`      // 窗口名称，如 "交易所周维护"
+	TraderID string   `json:"trader_id"` // 适用的trader，空表示所有trader
+	Days     []string `json:"days"`      // 星期（mon/tue/wed/thu/fri/sat/sun），空表示每天
+	Start    string   `json:"start"`     // 开始时间 HH:MM
+	End      string   `json:"end"`       // 结束时间 HH:MM（不晚于开始时间表示跨天）
+	Timezone string   `json:"timezone"`  // IANA时区（如 Asia/Shanghai），空表示UTC
+}
+
+// MaintenanceConfig 维护窗口配置
+type MaintenanceConfig struct {
+	Windows        []MaintenanceWindow
+	NoOpenMinutes  int // 窗口开始前N分钟内不再开新仓
+	LookaheadHours int // 状态和prompt中展示未来N小时内的窗口
+}
+
+// GetMaintenanceConfig 获取维护窗口配置
+func (rc *RuntimeConfig) GetMaintenanceConfig() MaintenanceConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := MaintenanceConfig{
+		NoOpenMinutes:  rc.helper.GetInt("maintenance_no_open_minutes", 60),
+		LookaheadHours: rc.helper.GetInt("maintenance_lookahead_hours", 24),
+	}
+	rc.helper.GetJSON("maintenance_windows", &cfg.Windows, []MaintenanceWindow{})
+	return cfg
+}
+
+// KlineStoreConfig K线持久化配置
+type KlineStoreConfig struct {
+	Enabled       bool // 是否把拉取的K线写入本地 data/klines.db（修改后需重启）
+	ServeFromDisk bool // 当前周期的K线已落盘且足够新时直接从本地读取
+	MaxAgeSeconds int  // 本地K线的最大复用时长（秒）
+}
+
+// GetKlineStoreConfig 获取K线持久化配置
+func (rc *RuntimeConfig) GetKlineStoreConfig() KlineStoreConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return KlineStoreConfig{
+		Enabled:       rc.helper.GetBool("kline_store_enabled", false),
+		ServeFromDisk: rc.helper.GetBool("kline_store_serve_from_disk", true),
+		MaxAgeSeconds: rc.helper.GetInt("kline_store_max_age_seconds", 60),
+	}
+}
+
+// IndicatorCacheConfig 增量指标计算配置
+type IndicatorCacheConfig struct {
+	Incremental     bool // 保存指标状态，每个周期只折叠新收盘的K线
+	StateTTLMinutes int  // 指标状态超过N分钟未使用时释放
+}
+
+// GetIndicatorCacheConfig 获取增量指标计算配置
+func (rc *RuntimeConfig) GetIndicatorCacheConfig() IndicatorCacheConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return IndicatorCacheConfig{
+		Incremental:     rc.helper.GetBool("indicator_incremental_enabled", true),
+		StateTTLMinutes: rc.helper.GetInt("indicator_state_ttl_minutes", 360),
+	}
+}
+
+// VenueCapabilityOverride 按交易所覆盖保护单能力（未填写的字段沿用内置矩阵）
+type VenueCapabilityOverride struct {
+	Exchange         string `json:"exchange"`
+	StopMarket       *bool  `json:"stop_market,omitempty"`
+	TakeProfitMarket *bool  `json:"take_profit_market,omitempty"`
+	TriggerLimit     *bool  `json:"trigger_limit,omitempty"`
+	VerifyPlacement  *bool  `json:"verify_placement,omitempty"`
+}
+
+// VenueProtectionConfig 交易所保护单（止损/止盈）下单配置
+type VenueProtectionConfig struct {
+	Overrides               []VenueCapabilityOverride
+	TriggerLimitSlippagePct float64 // 限价条件单的限价相对触发价的让价(%)
+}
+
+// GetVenueProtectionConfig 获取交易所保护单下单配置
+func (rc *RuntimeConfig) GetVenueProtectionConfig() VenueProtectionConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	cfg := VenueProtectionConfig{
+		TriggerLimitSlippagePct: rc.helper.GetFloat("venue_trigger_limit_slippage_pct", 1.0),
+	}
+	rc.helper.GetJSON("venue_capabilities", &cfg.Overrides, []VenueCapabilityOverride{})
+	return cfg
+}
+
+// APIAccessConfig API访问控制配置（只读观察者模式）
+type APIAccessConfig struct {
+	ReadOnly       bool     // 全局只读：所有修改类请求返回403（AdminTokens除外）
+	ObserverTokens []string // 观察者Token：携带这些Token的请求只能读取
+	AdminTokens    []string // 管理员Token：全局只读时仍可执行修改类请求
+}
+
+// GetAPIAccessConfig 获取API访问控制配置
+func (rc *RuntimeConfig) GetAPIAccessConfig() APIAccessConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	cfg := APIAccessConfig{
+		ReadOnly: rc.helper.GetBool("api_read_only", false),
+	}
+	rc.helper.GetJSON("api_observer_tokens", &cfg.ObserverTokens, []string{})
+	rc.helper.GetJSON("api_admin_tokens", &cfg.AdminTokens, []string{})
+	return cfg
+}
+
+// CurrentAPIAccessConfig 获取当前生效的API访问控制配置（全局配置未初始化时不限制）
+func CurrentAPIAccessConfig() APIAccessConfig {
+	if rc := GetGlobalConfig(); rc != nil {
+		return rc.GetAPIAccessConfig()
+	}
+	return APIAccessConfig{}
+}
+
+// ChaosConfig 故障注入配置（仅非实盘模式生效，用于验证异常恢复路径）
+type ChaosConfig struct {
+	Enabled             bool    // 是否启用故障注入
+	ExchangeTimeoutProb float64 // 交易所调用模拟超时的概率(0-1)
+	PartialFillProb     float64 // 开仓模拟部分成交的概率(0-1)
+	AIGarbageProb       float64 // AI返回模拟乱码的概率(0-1)
+	DBWriteFailProb     float64 // 数据库写入模拟失败的概率(0-1)
+}
+
+// GetChaosConfig 获取故障注入配置
+func (rc *RuntimeConfig) GetChaosConfig() ChaosConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return ChaosConfig{
+		Enabled:             rc.helper.GetBool("chaos_enabled", false),
+		ExchangeTimeoutProb: rc.helper.GetFloat("chaos_exchange_timeout_prob", 0.05),
+		PartialFillProb:     rc.helper.GetFloat("chaos_partial_fill_prob", 0.1),
+		AIGarbageProb:       rc.helper.GetFloat("chaos_ai_garbage_prob", 0.05),
+		DBWriteFailProb:     rc.helper.GetFloat("chaos_db_write_fail_prob", 0.05),
+	}
+}
+
+// CurrentChaosConfig 获取当前生效的故障注入配置（全局配置未初始化时不启用）
+func CurrentChaosConfig() ChaosConfig {
+	if rc := GetGlobalConfig(); rc != nil {
+		return rc.GetChaosConfig()
+	}
+	return ChaosConfig{}
+}
+
+// FundingArbConfig 资金费率套利配置
+type FundingArbConfig struct {
+	Enabled          bool
+	Hedge            string  // 对冲腿：binance_spot 或 trader:<id>
+	MinRatePct       float64 // 开仓所需的最小费率差(%/8h)
+	ExitRatePct      float64 // 费率差低于该值时平仓(%/8h)
+	NotionalUSD      float64 // 每条腿的名义价值(USDT)
+	MaxPositions     int
+	MaxTotalNotional float64 // 永续腿总名义价值上限(USDT)
+	Leverage         int
+	MaxBasisPct      float64 // 开仓时两条腿的最大价差(%)
+	MaxLegLossPct    float64 // 永续腿不利波动占保证金的比例上限(%)
+	MaxHoldHours     float64 // 最长持有时间（0表示不限制）
+}
+
+// GetFundingArbConfig 获取资金费率套利配置
+func (rc *RuntimeConfig) GetFundingArbConfig() FundingArbConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	return FundingArbConfig{
+		Enabled:          rc.helper.GetBool("funding_arb_enabled", false),
+		Hedge:            rc.helper.GetString("funding_arb_hedge", "binance_spot"),
+		MinRatePct:       rc.helper.GetFloat("funding_arb_min_rate_pct", 0.05),
+		ExitRatePct:      rc.helper.GetFloat("funding_arb_exit_rate_pct", 0.01),
+		NotionalUSD:      rc.helper.GetFloat("funding_arb_notional_usd", 100),
+		MaxPositions:     rc.helper.GetInt("funding_arb_max_positions", 2),
+		MaxTotalNotional: rc.helper.GetFloat("funding_arb_max_total_notional_usd", 500),
+		Leverage:         rc.helper.GetInt("funding_arb_leverage", 2),
+		MaxBasisPct:      rc.helper.GetFloat("funding_arb_max_basis_pct", 0.3),
+		MaxLegLossPct:    rc.helper.GetFloat("funding_arb_max_leg_loss_pct", 20),
+		MaxHoldHours:     rc.helper.GetFloat("funding_arb_max_hold_hours", 72),
+	}
+}
+
+// CurrentFundingArbConfig 获取当前生效的资金费率套利配置（全局配置未初始化时不启用）
+func CurrentFundingArbConfig() FundingArbConfig {
+	if rc := GetGlobalConfig(); rc != nil {
+		return rc.GetFundingArbConfig()
+	}
+	return FundingArbConfig{}
+}
+
+// NewsConfig 候选币种新闻配置
+type NewsConfig struct {
+	Enabled         bool
+	Source          string   // rss / cryptopanic
+	URLs            []string // RSS地址；source=cryptopanic时为接口地址（可为空）
+	APIKey          string
+	MaxHeadlines    int // 每个币种最多附带的新闻数
+	MaxAgeHours     int
+	CacheTTLMinutes int
+}
+
+// GetNewsConfig 获取候选币种新闻配置
+func (rc *RuntimeConfig) GetNewsConfig() NewsConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	cfg := NewsConfig{
+		Enabled:         rc.helper.GetBool("news_enabled", false),
+		Source:          rc.helper.GetString("news_source", "rss"),
+		APIKey:          rc.helper.GetString("news_api_key", ""),
+		MaxHeadlines:    rc.helper.GetInt("news_max_headlines", 3),
+		MaxAgeHours:     rc.helper.GetInt("news_max_age_hours", 24),
+		CacheTTLMinutes: rc.helper.GetInt("news_cache_ttl_minutes", 15),
+	}
+	rc.helper.GetJSON("news_urls", &cfg.URLs, []string{})
+	return cfg
+}
+
+// WebhookEndpoint 交易事件回调地址
+type WebhookEndpoint struct {
+	URL      string   `json:"url"`
+	Events   []string `json:"events"`    // 订阅的事件（空表示全部事件）
+	TraderID string   `json:"trader_id"` // 只接收该trader的事件（空表示所有trader）
+	Secret   string   `json:"secret"`    // 签名密钥（空表示使用webhook_secret）
+}
+
+// WebhookConfig 交易事件回调配置
+type WebhookConfig struct {
+	Enabled          bool
+	Endpoints        []WebhookEndpoint
+	Secret           string
+	TimeoutSeconds   int
+	MaxRetries       int
+	RetryBaseSeconds int
+}
+
+// GetWebhookConfig 获取交易事件回调配置
+func (rc *RuntimeConfig) GetWebhookConfig() WebhookConfig {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	
+	cfg := WebhookConfig{
+		Enabled:          rc.helper.GetBool("webhook_enabled", false),
+		Secret:           rc.helper.GetString("webhook_secret", ""),
+		TimeoutSeconds:   rc.helper.GetInt("webhook_timeout_seconds", 10),
+		MaxRetries:       rc.helper.GetInt("webhook_max_retries", 3),
+		RetryBaseSeconds: rc.helper.GetInt("webhook_retry_base_seconds", 2),
+	}
+	rc.helper.GetJSON("webhook_endpoints", &cfg.Endpoints, []WebhookEndpoint{})
+	return cfg
 }
 
 // ClearCache 清除配置缓存（用于热重载）
@@ -170,11 +1061,62 @@ func (rc *RuntimeConfig) ClearCache() {
 var globalRuntimeConfig *RuntimeConfig
 var globalConfigMu sync.RWMutex
 
+// 配置重载监听器（system_configs 变更后通知，用于刷新持有配置引用的组件）
+var (
+	reloadListeners   = make(map[int]func(*RuntimeConfig))
+	reloadListenerSeq int
+	reloadListenerMu  sync.Mutex
+)
+
 // InitGlobalConfig 初始化全局配置
 func InitGlobalConfig(db *sql.DB) {
 	globalConfigMu.Lock()
-	defer globalConfigMu.Unlock()
 	globalRuntimeConfig = NewRuntimeConfig(db)
+	globalConfigMu.Unlock()
+	notifyReloadListeners()
+}
+
+// NewDefaultRuntimeConfig 创建不连接数据库的运行时配置（所有配置项均返回默认值）
+func NewDefaultRuntimeConfig() *RuntimeConfig {
+	return NewRuntimeConfig(nil)
+}
+
+// LoadRuntimeConfig 加载运行时配置：优先使用从 system_configs 初始化的全局配置，
+// 未初始化（系统数据库不可用）时返回默认值配置，返回值不会为nil
+func LoadRuntimeConfig() *RuntimeConfig {
+	if rc := GetGlobalConfig(); rc != nil {
+		return rc
+	}
+	return NewDefaultRuntimeConfig()
+}
+
+// OnRuntimeConfigReload 注册配置重载监听器，返回取消注册函数
+func OnRuntimeConfigReload(fn func(*RuntimeConfig)) func() {
+	reloadListenerMu.Lock()
+	defer reloadListenerMu.Unlock()
+	reloadListenerSeq++
+	id := reloadListenerSeq
+	reloadListeners[id] = fn
+	return func() {
+		reloadListenerMu.Lock()
+		defer reloadListenerMu.Unlock()
+		delete(reloadListeners, id)
+	}
+}
+
+// notifyReloadListeners 通知所有监听器使用最新配置
+func notifyReloadListeners() {
+	reloadListenerMu.Lock()
+	listeners := make([]func(*RuntimeConfig), 0, len(reloadListeners))
+	for _, fn := range reloadListeners {
+		listeners = append(listeners, fn)
+	}
+	reloadListenerMu.Unlock()
+
+	rc := LoadRuntimeConfig()
+	for _, fn := range listeners {
+		fn(rc)
+	}
 }
 
 // GetGlobalConfig 获取全局配置
@@ -184,9 +1126,10 @@ func GetGlobalConfig() *RuntimeConfig {
 	return globalRuntimeConfig
 }
 
-// ReloadGlobalConfig 重新加载全局配置
+// ReloadGlobalConfig 重新加载全局配置（/api/system/configs 修改后调用）
 func ReloadGlobalConfig() {
-	if globalRuntimeConfig != nil {
-		globalRuntimeConfig.ClearCache()
+	if rc := GetGlobalConfig(); rc != nil {
+		rc.ClearCache()
 	}
+	notifyReloadListeners()
 }
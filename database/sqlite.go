@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqliteDSN 生成SQLite连接串
+// - WAL模式：API读取和交易周期写入可以并发，读不阻塞写
+// - busy_timeout：写锁被占用时等待而不是立即返回 "database is locked"
+// - synchronous=NORMAL：WAL模式下安全且写入更快
+// - txlock=immediate：事务开始即获取写锁，避免读锁升级写锁时的死锁
+func sqliteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=%d&_synchronous=NORMAL&_txlock=immediate",
+		path, SQLiteBusyTimeoutMS)
+}
+
+// openSQLite 打开SQLite数据库并配置连接池
+func openSQLite(path string, maxOpen, maxIdle int) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(time.Duration(SQLiteConnMaxLifetime))
+
+	// sql.Open 不会真正建立连接，这里确认PRAGMA生效
+	var journalMode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return db, nil
+}
@@ -18,15 +18,11 @@ type SystemConnection struct {
 func NewSystemConnection() (*SystemConnection, error) {
 	dbPath := "data/system.db"
 	
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := openSQLite(dbPath, SystemDBMaxOpenConns, SystemDBMaxIdleConns)
 	if err != nil {
 		return nil, fmt.Errorf("打开系统数据库失败: %w", err)
 	}
 
-	// 设置连接池参数
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-
 	conn := &SystemConnection{
 		db:     db,
 		dbPath: dbPath,
@@ -137,6 +133,21 @@ func (c *SystemConnection) initSchema() error {
 		ai_autonomy_mode BOOLEAN DEFAULT 0,
 		-- 数据优化配置
 		compact_mode BOOLEAN DEFAULT 1,
+		-- 候选币种来源（pool/static，空=跟随全局use_default_coins）
+		coin_source TEXT DEFAULT '',
+		-- 独立币种池API（空=使用全局配置）
+		coin_pool_api_url TEXT DEFAULT '',
+		oi_top_api_url TEXT DEFAULT '',
+		coin_pool_auth_header TEXT DEFAULT '',
+		coin_pool_refresh_seconds INTEGER DEFAULT 0,
+		-- 行情数据源（空=binance）
+		market_data_source TEXT DEFAULT '',
+		-- 提示词语言（空=zh）
+		prompt_language TEXT DEFAULT '',
+		-- AI生成参数（JSON，空=使用默认值）
+		ai_params TEXT DEFAULT '',
+		-- 决策审核模型（JSON，空=不启用）
+		critic TEXT DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
@@ -144,6 +155,16 @@ func (c *SystemConnection) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_trader_configs_trader_id ON trader_configs(trader_id);
 	CREATE INDEX IF NOT EXISTS idx_trader_configs_user_id ON trader_configs(user_id);
 	CREATE INDEX IF NOT EXISTS idx_trader_configs_enabled ON trader_configs(enabled);
+
+	-- Trader配置模板表（不含API密钥）
+	CREATE TABLE IF NOT EXISTS trader_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT DEFAULT '',
+		config_json TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
 	`
 
 	_, err := c.db.Exec(schema)
@@ -151,10 +172,28 @@ func (c *SystemConnection) initSchema() error {
 		return err
 	}
 
+	// 为旧版本数据库补充新增列
+	if err := applyColumnMigrations(c.db, systemColumnMigrations); err != nil {
+		return err
+	}
+
 	// 初始化默认系统配置
 	return c.initDefaultConfigs()
 }
 
+// systemColumnMigrations 系统数据库旧版本缺失的列
+var systemColumnMigrations = []columnMigration{
+	{"trader_configs", "coin_source", "TEXT DEFAULT ''"},
+	{"trader_configs", "coin_pool_api_url", "TEXT DEFAULT ''"},
+	{"trader_configs", "oi_top_api_url", "TEXT DEFAULT ''"},
+	{"trader_configs", "coin_pool_auth_header", "TEXT DEFAULT ''"},
+	{"trader_configs", "coin_pool_refresh_seconds", "INTEGER DEFAULT 0"},
+	{"trader_configs", "market_data_source", "TEXT DEFAULT ''"},
+	{"trader_configs", "prompt_language", "TEXT DEFAULT ''"},
+	{"trader_configs", "ai_params", "TEXT DEFAULT ''"},
+	{"trader_configs", "critic", "TEXT DEFAULT ''"},
+}
+
 // initDefaultConfigs 初始化默认系统配置
 func (c *SystemConnection) initDefaultConfigs() error {
 	// 检查是否已初始化
@@ -178,6 +217,9 @@ func (c *SystemConnection) initDefaultConfigs() error {
 	}{
 		// API配置
 		{"api_server_port", "8080", "API服务器端口", "api"},
+		{"api_read_only", "false", "全局只读模式(所有修改类请求返回403，管理员Token除外)", "api"},
+		{"api_observer_tokens", "[]", "观察者Token列表(JSON数组，携带这些Token的请求只能读取)", "api"},
+		{"api_admin_tokens", "[]", "管理员Token列表(JSON数组，全局只读时仍可修改)", "api"},
 		
 		// 市场数据配置
 		{"coin_pool_api_url", "", "币种池API地址", "market"},
@@ -185,6 +227,13 @@ func (c *SystemConnection) initDefaultConfigs() error {
 		{"use_default_coins", "true", "是否使用默认币种列表", "market"},
 		{"default_coins", `["BTCUSDT","ETHUSDT","SOLUSDT","BNBUSDT","XRPUSDT","DOGEUSDT","ADAUSDT","HYPEUSDT"]`, "默认币种列表", "market"},
 		{"kline_settings", `[{"interval":"3m","limit":20,"show_table":true},{"interval":"4h","limit":60,"show_table":false}]`, "K线配置", "market"},
+		{"oi_history_period", "1h", "持仓量历史周期(5m/15m/30m/1h/2h/4h，自动获取覆盖24小时的数据)", "market"},
+		{"oi_history_series_shown", "12", "Prompt中展示的持仓量历史点数", "market"},
+		{"liquidation_cascade_usd", "20000000", "全市场5分钟爆仓金额超过该值视为连环爆仓(USDT)", "market"},
+		{"liquidation_symbol_cascade_usd", "2000000", "单币种5分钟爆仓金额超过该值视为连环爆仓(USDT)", "market"},
+		{"liquidation_pause_entries", "true", "连环爆仓期间暂停新开仓", "market"},
+		{"orderflow_enabled", "false", "订阅持仓币种的深度增量和逐笔成交，计算盘口失衡和主动买入占比", "market"},
+		{"orderflow_proximity_pct", "1.0", "持仓距止损/止盈不超过该百分比时在Prompt中提供订单流信号(%)", "market"},
 		
 		// 查询限制配置
 		{"query_limit_default", "100", "默认记录查询数量", "database"},
@@ -204,6 +253,15 @@ func (c *SystemConnection) initDefaultConfigs() error {
 		{"risk_winrate_low_threshold", "30.0", "胜率低阈值(%)", "risk"},
 		{"risk_error_rate_high_threshold", "10.0", "错误率高阈值(%)", "risk"},
 		{"risk_min_trades_for_stats", "10", "统计分析最小交易数", "risk"},
+		{"risk_daily_budget_pct", "5.0", "日风险预算(占账户净值%)", "risk"},
+		{"risk_budget_enforce", "true", "风险预算不足时拒绝新开仓", "risk"},
+		{"rebalance_enabled", "true", "在Prompt中提供组合再平衡建议并检查新开仓", "rebalance"},
+		{"rebalance_max_symbol_pct", "50", "单币种占总敞口的最大比例(%)", "rebalance"},
+		{"rebalance_max_sector_pct", "70", "单板块占总敞口的最大比例(%)", "rebalance"},
+		{"rebalance_max_net_bias_pct", "80", "最大净方向偏离(%，|多头-空头|/总敞口)", "rebalance"},
+		{"rebalance_min_positions", "2", "持仓数达到该值才检查集中度", "rebalance"},
+		{"rebalance_enforce", "false", "新开仓加剧组合失衡时拒绝(false=只警告)", "rebalance"},
+		{"rebalance_sector_map", "{}", "币种板块映射(JSON对象，如{\"SOL\":\"公链\"}，覆盖内置映射)", "rebalance"},
 		
 		// 风险评分权重配置
 		{"risk_score_margin_high", "20", "保证金高使用率评分", "risk"},
@@ -231,6 +289,140 @@ func (c *SystemConnection) initDefaultConfigs() error {
 		{"pool_retry_delay_ms", "100", "重试延迟(毫秒)", "pool"},
 		{"pool_timeout_seconds", "10", "请求超时时间(秒)", "pool"},
 		{"pool_cache_ttl_minutes", "5", "缓存有效期(分钟)", "pool"},
+		{"pool_allowed_quote_assets", `["USDT","USDC"]`, "候选币种允许的计价币种(JSON数组，USDT/USDC/USD=币本位，还需交易所支持)", "pool"},
+		
+		// AI调用配置
+		{"ai_prompt_cache_window_minutes", "10", "相同Prompt复用上次决策的时间窗口(分钟，0=关闭)", "ai"},
+		{"ai_compact_max_candidates", "5", "紧凑模式下最多分析的候选币种数(按候选池排序取前N个，0=不限制)", "ai"},
+		
+		// AI学习与交易归因配置
+		{"learning_entry_snapshot_enabled", "true", "是否记录开仓时的指标快照(MACD/RSI/量比)", "learning"},
+		{"learning_rsi_overbought", "70.0", "归因分析RSI超买阈值", "learning"},
+		{"learning_rsi_oversold", "30.0", "归因分析RSI超卖阈值", "learning"},
+		{"learning_high_volume_ratio", "1.5", "归因分析放量阈值(当前量/均量)", "learning"},
+		{"learning_time_of_day_prompt_enabled", "true", "是否在决策prompt中提示历史弱势/强势开仓时段(UTC)", "learning"},
+		{"learning_time_of_day_lookback_trades", "200", "开仓时段分析使用的最近交易数", "learning"},
+		{"learning_time_of_day_min_trades", "8", "开仓时段结论要求的最少交易数", "learning"},
+		
+		// 数据保留配置
+		{"retention_days", "30", "决策记录保留天数(0=永久保留，更早的记录归档后清理)", "retention"},
+		{"retention_archive_enabled", "true", "清理前归档到按月压缩文件(data/traders/<id>/archive)", "retention"},
+		
+		// 下单执行配置
+		{"execution_max_price_drift_pct", "1.0", "下单前价格相对AI分析价格的最大漂移(%，0=不检查)", "execution"},
+		{"execution_drift_action", "reject", "价格漂移超限时的处理方式(reject/resize)", "execution"},
+		{"execution_close_concurrency", "3", "同一周期内平仓决策的最大并发数(1=顺序执行)", "execution"},
+		{"execution_order_delay_ms", "1000", "顺序下单之间的间隔(毫秒)", "execution"},
+		{"execution_order_delay_ms_binance", "", "币安下单间隔(毫秒，留空=使用execution_order_delay_ms)", "execution"},
+		{"execution_order_delay_ms_hyperliquid", "", "Hyperliquid下单间隔(毫秒，留空=使用execution_order_delay_ms)", "execution"},
+		{"execution_order_delay_ms_aster", "", "Aster下单间隔(毫秒，留空=使用execution_order_delay_ms)", "execution"},
+		{"execution_allow_stop_loosening", "false", "是否允许AI通过update_stop_loss放宽止损(默认只允许收紧)", "execution"},
+		{"execution_audit_enabled", "true", "记录每次交易所下单/改杠杆/止损止盈/撤单请求和返回(修改后需重启trader)", "execution"},
+		{"execution_book_snapshot_enabled", "true", "下单前记录盘口前5档和最近成交，保存到决策动作(用于事后分析成交质量和滑点)", "execution"},
+		{"execution_book_snapshot_trades", "20", "盘口快照附带的最近成交笔数(0=不记录成交)", "execution"},
+		{"execution_preview_mode", "false", "预览模式：每个周期照常获取行情、调用AI和验证，只记录计划订单(数量/价格/止损止盈/杠杆)不下单，用于人工监督新的提示词", "execution"},
+		
+		// 行情数据质量配置
+		{"data_quality_outlier_sigma", "8.0", "K线价格跳变超过N倍稳健标准差且随即回归视为异常", "data_quality"},
+		{"data_quality_max_missing_candles", "2", "缺失/零成交K线超过该数量视为数据降级", "data_quality"},
+		{"data_quality_stale_intervals", "2", "最新K线落后超过N个周期视为数据过期(0=不检查)", "data_quality"},
+		{"data_quality_exclude_degraded", "true", "数据降级的币种禁止新开仓(持仓仍可平仓)", "data_quality"},
+		{"approval_enabled", "false", "大额开仓需人工审批后执行", "approval"},
+		{"approval_notional_threshold_usd", "0", "名义价值超过该值(USDT)的开仓需审批(0=不按名义价值)", "approval"},
+		{"approval_risk_threshold_usd", "0", "止损风险超过该值(USDT)的开仓需审批(0=不按风险)", "approval"},
+		{"approval_timeout_minutes", "10", "审批超时时间(分钟)，超时后决策作废并记录为跳过", "approval"},
+		{"liq_guard_enabled", "true", "开仓前校验止损是否在预估强平价之前", "liq_guard"},
+		{"liq_guard_buffer_pct", "1.0", "止损与预估强平价之间至少保留的距离(占开仓价%)", "liq_guard"},
+		{"liq_guard_mode", "adjust", "止损越过强平缓冲时的处理：adjust=收紧止损，reject=拒绝开仓", "liq_guard"},
+		{"risk_groups_enabled", "false", "按币种分组隔离风控额度（各组独立的最大持仓数、最大名义敞口和杠杆上限）", "risk_groups"},
+		{"risk_groups", `[{"name":"majors","symbols":["BTC","ETH"],"max_positions":2,"max_notional_usd":0,"max_leverage":20},{"name":"l1_alts","symbols":["SOL","AVAX","ADA","DOT","NEAR","APT","SUI","TRX","TON","SEI","ATOM","BNB"],"max_positions":2,"max_notional_usd":0,"max_leverage":10},{"name":"memecoins","symbols":["DOGE","SHIB","PEPE","WIF","BONK","FLOKI","1000PEPE","1000SHIB","1000BONK","1000FLOKI"],"max_positions":1,"max_notional_usd":0,"max_leverage":5},{"name":"other","symbols":[],"max_positions":2,"max_notional_usd":0,"max_leverage":10}]`, "币种分组(JSON数组：name、symbols基础币种(空=其余币种)、max_positions、max_notional_usd、max_leverage，0=不限制)", "risk_groups"},
+		{"watchdog_enabled", "true", "检测交易循环卡死（超过N倍扫描间隔没有完成周期）并发出危险预警", "watchdog"},
+		{"watchdog_stall_multiplier", "3.0", "超过N倍扫描间隔没有完成周期视为卡死", "watchdog"},
+		{"watchdog_auto_restart", "false", "卡死时自动重建trader实例(旧实例恢复后不再下单)", "watchdog"},
+		{"position_drift_enabled", "true", "两个交易周期之间检查持仓标记价格是否越过止损：越过且交易所止损单确认缺失时按市价强制平仓并发出预警", "position_drift"},
+		{"position_drift_interval_seconds", "20", "周期间止损监控的检查间隔(秒，建议15-30)", "position_drift"},
+		{"entry_throttle_max_per_symbol_daily", "0", "单个币种每日(UTC)最多开仓次数，防止反复止损后同一币种被反复开仓（如3，0表示不限制）", "entry_throttle"},
+		{"entry_throttle_max_total_daily", "0", "每日(UTC)最多开仓总次数（如10，0表示不限制）", "entry_throttle"},
+		{"confidence_min_entry", "0", "开仓决策最低信心度(0-100)，低于时限制模式和自主模式都拒绝；同时作为质量评估的信心度扣分线(0表示不限制，按75扣分)", "confidence"},
+		{"confidence_min_close", "0", "平仓决策最低信心度(0-100，未给出信心度的平仓不检查，0表示不限制)", "confidence"},
+		{"confidence_floors", "[]", "按trader覆盖最低信心度(JSON数组，如[{\"trader_id\":\"my_trader\",\"entry\":80,\"close\":0}]，整条替换默认值)", "confidence"},
+		{"liquidity_filter_mode", "oi", "候选币种流动性过滤方式：oi=持仓价值 / volume=24h成交额和平均价差 / both=全部满足（持仓币种不过滤）", "liquidity_filter"},
+		{"liquidity_min_oi_value_m", "15", "最小持仓价值(百万USD，0表示不检查)", "liquidity_filter"},
+		{"liquidity_min_quote_volume_m", "50", "最小24h成交额(百万USD，0表示不检查)", "liquidity_filter"},
+		{"liquidity_max_avg_spread_bps", "10", "最大平均买卖价差(基点，约最近30分钟的均值，0表示不检查)", "liquidity_filter"},
+		{"liquidity_groups", "[]", "按币种分组覆盖流动性门槛(JSON数组，如[{\"name\":\"majors\",\"symbols\":[\"BTC\",\"ETH\"],\"min_oi_value_m\":100,\"min_quote_volume_m\":500,\"max_avg_spread_bps\":2}]，symbols为空表示其余币种，整条替换默认门槛)", "liquidity_filter"},
+		{"reporting_currency", "USD", "报告币种（USD/USDT/USDC/BTC等）：对比视图和收益曲线按指数价格把各交易所的结算资产折算为该币种，USD与USDT按1:1处理", "reporting"},
+		{"drawdown_lock_enabled", "false", "净值从峰值回撤超过阈值时自动切换为只平仓模式(禁止开新仓)，需人工解除", "drawdown_lock"},
+		{"drawdown_lock_pct", "10.0", "只平仓锁定的回撤阈值(%，相对净值峰值，净值已扣除入金/出金)", "drawdown_lock"},
+		{"balance_reconcile_enabled", "true", "定期从交易所同步入金/出金，按净投入(初始余额+净入金)计算盈亏、回撤和收益曲线", "balance_reconcile"},
+		{"balance_reconcile_interval_minutes", "60", "入金/出金同步间隔(分钟)", "balance_reconcile"},
+		{"income_sync_enabled", "true", "定期从交易所同步收入历史(已实现盈亏/资金费/手续费/清算费)，生成月度收益表与系统记录的盈亏对账", "balance_reconcile"},
+		{"income_sync_interval_minutes", "60", "收入历史同步间隔(分钟)", "balance_reconcile"},
+		{"shared_state_backend", "memory", "共享状态存储(memory=进程内存/redis=多进程共享行情缓存、限流和暂停/停止开关，修改后需重启)", "shared_state"},
+		{"shared_state_redis_addr", "127.0.0.1:6379", "Redis地址(host:port)", "shared_state"},
+		{"shared_state_redis_password", "", "Redis密码", "shared_state"},
+		{"shared_state_redis_db", "0", "Redis库编号", "shared_state"},
+		{"shared_state_key_prefix", "nofx:", "Redis键前缀(多套部署共用一个Redis时区分)", "shared_state"},
+		{"shared_market_cache_ttl_seconds", "0", "K线共享缓存时长(秒，0=不缓存)", "shared_state"},
+		{"shared_rate_limit_per_minute", "0", "所有进程合计每分钟请求K线接口的次数上限(0=不限流)", "shared_state"},
+		{"funding_prompt_enabled", "true", "在prompt中提供候选币种距下次资金费结算的时间和预计资金费", "funding"},
+		{"funding_entry_delay_minutes", "0", "距资金费结算不足N分钟且费率不利时延迟开仓(0=不限制)", "funding"},
+		{"funding_adverse_rate_pct", "0.05", "单次结算费率对开仓方向不利超过该值(%)才延迟开仓", "funding"},
+		{"sizing_prompt_enabled", "true", "在prompt中提供各币种ATR%、历史波动率和1R仓位", "sizing"},
+		{"sizing_risk_per_trade_pct", "1.0", "1R风险占账户净值的比例(%，同时不超过剩余日风险预算)", "sizing"},
+		{"sizing_atr_stop_multiple", "1.5", "1R仓位的建议止损距离(N倍ATR14)", "sizing"},
+		{"sizing_position_multiplier", "1.0", "压力测试缩放系数：下单前按比例缩小所有开仓金额(0-1]，不修改AI提示词，1表示不缩放", "sizing"},
+		{"exit_policy_break_even_enabled", "false", "浮盈达到N倍初始风险(R)后自动把止损移到开仓价", "exit_policy"},
+		{"exit_policy_break_even_r", "1.0", "触发保本止损的浮盈(R，R=开仓价到初始止损的距离)", "exit_policy"},
+		{"exit_policy_time_stop_enabled", "false", "持仓超时且浮盈从未达到要求时自动平仓", "exit_policy"},
+		{"exit_policy_time_stop_hours", "24", "时间止损的最长持仓时间(小时)", "exit_policy"},
+		{"exit_policy_time_stop_min_r", "0.5", "时间止损要求持仓期间达到的最低浮盈(R)", "exit_policy"},
+		{"exit_policy_weekend_close_enabled", "false", "周末前(周六00:00 UTC)自动平掉全部持仓", "exit_policy"},
+		{"exit_policy_event_close_minutes", "30", "周末/重大事件前N分钟平仓", "exit_policy"},
+		{"exit_policy_event_times", "[]", "重大事件时间列表(JSON数组，RFC3339格式，如[\"2026-10-28T18:00:00Z\"])", "exit_policy"},
+		{"maintenance_windows", "[]", "周期性维护窗口(JSON数组，如[{\"name\":\"交易所维护\",\"trader_id\":\"\",\"days\":[\"wed\"],\"start\":\"02:00\",\"end\":\"04:00\",\"timezone\":\"UTC\"}]，trader_id为空表示所有trader)", "maintenance"},
+		{"maintenance_no_open_minutes", "60", "维护窗口开始前N分钟内不再开新仓", "maintenance"},
+		{"maintenance_lookahead_hours", "24", "状态和prompt中展示未来N小时内的维护窗口", "maintenance"},
+		{"kline_store_enabled", "false", "把拉取的K线去重写入data/klines.db，供离线回测/回放(修改后需重启)", "kline_store"},
+		{"kline_store_serve_from_disk", "true", "当前周期K线已落盘且足够新时直接从本地读取", "kline_store"},
+		{"kline_store_max_age_seconds", "60", "本地K线最大复用时长(秒，不超过一个K线周期)", "kline_store"},
+		{"indicator_incremental_enabled", "true", "按币种和K线周期保存EMA/RSI/ATR等指标状态，每个周期只计算新收盘的K线", "indicators"},
+		{"indicator_state_ttl_minutes", "360", "指标状态超过N分钟未使用时释放(币种移出候选池后)", "indicators"},
+		{"venue_capabilities", "[]", "按交易所覆盖保护单能力(JSON数组，如[{\"exchange\":\"aster\",\"stop_market\":false,\"trigger_limit\":true,\"verify_placement\":true}]，未填写的字段沿用内置矩阵)", "venue"},
+		{"venue_trigger_limit_slippage_pct", "1.0", "市价条件单不可用或未生效时改挂限价条件单，限价相对触发价的让价(%)", "venue"},
+		{"news_enabled", "false", "在提示词中附带候选币种/持仓币种的近期新闻标题", "news"},
+		{"news_source", "rss", "新闻来源：rss(按标题匹配币种代码/名称) 或 cryptopanic(需要API Key)", "news"},
+		{"news_urls", "[\"https://www.coindesk.com/arc/outboundfeeds/rss/\",\"https://cointelegraph.com/rss\"]", "新闻源地址(JSON数组)；source=cryptopanic时为接口地址，可留空", "news"},
+		{"news_api_key", "", "CryptoPanic API Key(auth_token)", "news"},
+		{"news_max_headlines", "3", "每个币种最多附带的新闻数", "news"},
+		{"news_max_age_hours", "24", "只附带最近N小时内发布的新闻", "news"},
+		{"news_cache_ttl_minutes", "15", "新闻缓存时间(分钟，新闻源通常有频率限制)", "news"},
+		{"webhook_enabled", "false", "交易事件发生时回调外部系统(开仓、平仓、止损触发、决策验证失败、trader暂停)", "webhook"},
+		{"webhook_endpoints", "[]", "回调地址(JSON数组，如[{\"url\":\"https://example.com/hook\",\"events\":[\"position_opened\",\"position_closed\"],\"trader_id\":\"\",\"secret\":\"\"}]，events为空表示全部事件，trader_id为空表示所有trader，secret为空时使用webhook_secret)", "webhook"},
+		{"webhook_secret", "", "回调签名密钥(HMAC-SHA256，签名头X-Nofx-Signature，为空时不签名)", "webhook"},
+		{"webhook_timeout_seconds", "10", "单次回调请求超时(秒)", "webhook"},
+		{"webhook_max_retries", "3", "回调失败(网络错误、429或5xx)后的最大重试次数", "webhook"},
+		{"webhook_retry_base_seconds", "2", "首次重试的等待时间(秒)，之后每次翻倍", "webhook"},
+		
+		// 故障注入配置（仅非实盘模式生效）
+		{"chaos_enabled", "false", "启用故障注入(仅测试网/非实盘生效)", "chaos"},
+		{"chaos_exchange_timeout_prob", "0.05", "交易所调用模拟超时概率(0-1)", "chaos"},
+		{"chaos_partial_fill_prob", "0.1", "开仓模拟部分成交概率(0-1)", "chaos"},
+		{"chaos_ai_garbage_prob", "0.05", "AI返回模拟乱码概率(0-1)", "chaos"},
+		{"chaos_db_write_fail_prob", "0.05", "数据库写入模拟失败概率(0-1)", "chaos"},
+		
+		// 资金费率套利配置（Delta中性：永续腿收取资金费，对冲腿抵消价格风险）
+		{"funding_arb_enabled", "false", "启用资金费率套利", "funding_arb"},
+		{"funding_arb_hedge", "binance_spot", "对冲腿：binance_spot(币安现货，仅正费率) 或 trader:<id>(另一个Trader的永续合约)", "funding_arb"},
+		{"funding_arb_min_rate_pct", "0.05", "开仓所需的最小费率差(%/8小时)", "funding_arb"},
+		{"funding_arb_exit_rate_pct", "0.01", "费率差低于该值时平仓(%/8小时)", "funding_arb"},
+		{"funding_arb_notional_usd", "100", "单个套利仓位每条腿的名义价值(USDT)", "funding_arb"},
+		{"funding_arb_max_positions", "2", "最多同时持有的套利仓位数", "funding_arb"},
+		{"funding_arb_max_total_notional_usd", "500", "套利仓位永续腿的总名义价值上限(USDT)", "funding_arb"},
+		{"funding_arb_leverage", "2", "永续腿杠杆倍数", "funding_arb"},
+		{"funding_arb_max_basis_pct", "0.3", "开仓时两条腿的最大价差(%)", "funding_arb"},
+		{"funding_arb_max_leg_loss_pct", "20", "永续腿不利波动超过该比例(占保证金%)时强制平仓", "funding_arb"},
+		{"funding_arb_max_hold_hours", "72", "最长持有时间(小时，0表示不限制)", "funding_arb"},
 		
 		// 交易配置
 		{"trading_max_positions", "3", "最大持仓数", "trading"},
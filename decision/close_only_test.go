@@ -0,0 +1,70 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAIDecisionsCloseOnlyKeepsCloses(t *testing.T) {
+	ctx := &Context{
+		CloseOnlyReason: "回撤锁",
+		Account:         AccountInfo{TotalEquity: 1000, AvailableBalance: 800, PositionCount: 1},
+		Positions: []PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long", EntryPrice: 60000, MarkPrice: 61000, Quantity: 0.01, Leverage: 5, StopLoss: 58000},
+		},
+	}
+	full := &FullDecision{Decisions: []Decision{
+		{Symbol: "BTCUSDT", Action: "close_long", Reasoning: "止盈离场"},
+		{Symbol: "BTCUSDT", Action: "update_stop_loss", StopLoss: 59000},
+		{Symbol: "ETHUSDT", Action: "open_short", Leverage: 3, PositionSizeUSD: 100, StopLoss: 4200, TakeProfit: 3600, Confidence: 80},
+	}}
+
+	if err := validateAIDecisions(full, ctx); err != nil {
+		t.Fatalf("只平仓模式下平仓和止损调整应照常通过验证: %v", err)
+	}
+	if len(full.Decisions) != 2 {
+		t.Fatalf("应保留2个决策，实际 %d: %+v", len(full.Decisions), full.Decisions)
+	}
+	for _, d := range full.Decisions {
+		if d.Action == "open_short" {
+			t.Fatalf("开仓决策应被剔除: %+v", d)
+		}
+	}
+	if len(full.CloseOnlyRejected) != 1 || !strings.Contains(full.CloseOnlyRejected[0], "ETHUSDT open_short") ||
+		!strings.Contains(full.CloseOnlyRejected[0], "回撤锁") {
+		t.Fatalf("剔除原因不正确: %v", full.CloseOnlyRejected)
+	}
+	// 被剔除的开仓决策带着原因一起保存到决策记录
+	recorded := full.RecordedDecisions()
+	if len(recorded) != 3 {
+		t.Fatalf("决策记录应保存3个决策（含被剔除的开仓），实际 %d", len(recorded))
+	}
+	for _, d := range recorded {
+		rejected := d.Action == "open_short"
+		if rejected != (d.ValidationError != "") {
+			t.Errorf("%s %s 的验证结果不正确: %q", d.Symbol, d.Action, d.ValidationError)
+		}
+	}
+	if !strings.Contains(recorded[2].ValidationError, "回撤锁") {
+		t.Errorf("剔除原因应包含只平仓原因: %q", recorded[2].ValidationError)
+	}
+}
+
+func TestValidateAIDecisionsWithoutCloseOnlyKeepsOpens(t *testing.T) {
+	decisions := []Decision{
+		{Symbol: "BTCUSDT", Action: "close_long"},
+		{Symbol: "ETHUSDT", Action: "open_short"},
+	}
+	kept, rejected := dropCloseOnlyOpens(decisions, &Context{})
+	if len(kept) != 2 || rejected != nil {
+		t.Fatalf("非只平仓模式不应剔除决策: kept=%d rejected=%v", len(kept), rejected)
+	}
+}
+
+func TestValidateDecisionCloseOnlyRejectsSingleOpen(t *testing.T) {
+	d := &Decision{Symbol: "ETHUSDT", Action: "open_long"}
+	err := validateDecision(d, &Context{CloseOnlyReason: "日亏损限制"})
+	if err == nil || !strings.Contains(err.Error(), "只平仓模式") {
+		t.Fatalf("单个开仓决策（操作员注入）在只平仓模式下应被拒绝: %v", err)
+	}
+}
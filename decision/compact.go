@@ -0,0 +1,48 @@
+package decision
+
+import (
+	"nofx/i18n"
+	"nofx/market"
+	"unicode/utf8"
+)
+
+// CompactStats 紧凑模式本周期行情数据的token估算（与完整格式对比）
+type CompactStats struct {
+	Symbols           int `json:"symbols"`            // 输出行情摘要的币种数
+	CompactTokens     int `json:"compact_tokens"`     // 行情摘要的估算token数
+	FullTokens        int `json:"full_tokens"`        // 同样的币种使用完整格式的估算token数
+	CandidatesDropped int `json:"candidates_dropped"` // 超出候选数量上限未分析的候选币种数
+}
+
+// SavedPct 行情数据部分节省的token比例(%)
+func (s *CompactStats) SavedPct() float64 {
+	if s == nil || s.FullTokens == 0 {
+		return 0
+	}
+	return float64(s.FullTokens-s.CompactTokens) / float64(s.FullTokens) * 100
+}
+
+// EstimateTokens 粗略估算文本的token数（ASCII约4个字符1个token，中文等非ASCII字符约1个字符1个token）
+func EstimateTokens(s string) int {
+	ascii := 0
+	other := 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// formatMarketData 按trader的数据模式格式化行情数据，紧凑模式同时统计相对完整格式节省的token
+func (ctx *Context) formatMarketData(data *market.Data, lang i18n.Lang) string {
+	formatted := market.FormatForPrompt(data, lang, ctx.CompactMode)
+	if ctx.CompactMode && ctx.CompactStats != nil {
+		ctx.CompactStats.Symbols++
+		ctx.CompactStats.CompactTokens += EstimateTokens(formatted)
+		ctx.CompactStats.FullTokens += EstimateTokens(market.FormatCompactIn(data, lang))
+	}
+	return formatted
+}
@@ -0,0 +1,40 @@
+package decision
+
+import "fmt"
+
+// defaultQualityConfidence 未配置开仓最低信心度时，质量评估对开仓决策的信心度扣分线
+const defaultQualityConfidence = 75
+
+// ConfidenceFloor 决策最低信心度（由trader从运行时配置填充，0表示不限制）
+type ConfidenceFloor struct {
+	Entry int `json:"entry"` // 开仓决策最低信心度
+	Close int `json:"close"` // 平仓决策最低信心度（未给出信心度的平仓不检查）
+}
+
+// checkConfidenceFloor 检查决策信心度是否达到最低要求（限制模式和自主模式使用相同的规则）
+func checkConfidenceFloor(decision *Decision, ctx *Context) error {
+	floor := ctx.ConfidenceFloor
+	if floor == nil {
+		return nil
+	}
+	switch decision.Action {
+	case "open_long", "open_short":
+		if floor.Entry > 0 && decision.Confidence < floor.Entry {
+			return fmt.Errorf("%s %s 信心度%d低于开仓最低信心度%d", decision.Symbol, decision.Action, decision.Confidence, floor.Entry)
+		}
+	case "close_long", "close_short":
+		// 平仓是降低风险的操作，AI未给出信心度时放行
+		if floor.Close > 0 && decision.Confidence > 0 && decision.Confidence < floor.Close {
+			return fmt.Errorf("%s %s 信心度%d低于平仓最低信心度%d", decision.Symbol, decision.Action, decision.Confidence, floor.Close)
+		}
+	}
+	return nil
+}
+
+// qualityConfidenceThreshold 质量评估的开仓信心度扣分线（配置了开仓最低信心度时与之一致）
+func qualityConfidenceThreshold(ctx *Context) int {
+	if ctx != nil && ctx.ConfidenceFloor != nil && ctx.ConfidenceFloor.Entry > 0 {
+		return ctx.ConfidenceFloor.Entry
+	}
+	return defaultQualityConfidence
+}
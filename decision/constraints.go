@@ -0,0 +1,165 @@
+package decision
+
+import (
+	"nofx/i18n"
+	"sort"
+	"strings"
+)
+
+// 计算分类开仓上限时使用的信心度
+const (
+	constraintBaseConfidence = 85  // 仓位上限的信心度系数下限（CalculateSmartPositionSize 最低按85%计算）
+	constraintFullConfidence = 100 // 满信心度时的仓位上限
+	constraintMidConfidence  = 70  // 风险回报比按信心度60-79计算的基准
+	constraintHighConfidence = 80  // 信心度≥80时风险回报比要求降低
+)
+
+// CurrentConstraints 本周期生效的全部开仓限制（从实时风控状态计算，与验证和执行时检查的规则同源）
+type CurrentConstraints struct {
+	Mode                string           `json:"mode"` // restricted / autonomy
+	CloseOnlyReason     string           `json:"close_only_reason,omitempty"`
+	Slots               PositionSlots    `json:"position_slots"`
+	MaxEntriesTotal     int              `json:"max_entries_total"`      // 每日开仓总次数上限（0表示不限制）
+	EntriesRemaining    int              `json:"entries_remaining"`      // 今日剩余开仓次数（不限制时为0）
+	MaxEntriesPerSymbol int              `json:"max_entries_per_symbol"` // 单币种每日开仓次数上限（0表示不限制）
+	CooldownSymbols     []string         `json:"cooldown_symbols"`       // 今日开仓次数已达上限的币种（UTC日切换前不能再开仓）
+	ConfidenceFloor     ConfidenceFloor  `json:"confidence_floor"`
+	RiskBudget          RiskBudgetLimits `json:"risk_budget"`
+	Major               ClassOpenLimits  `json:"major"` // BTC/ETH 单笔开仓上限（限制模式下生效）
+	Alt                 ClassOpenLimits  `json:"alt"`   // 其他币种单笔开仓上限（限制模式下生效）
+}
+
+// RiskBudgetLimits 日风险预算
+type RiskBudgetLimits struct {
+	Daily     float64 `json:"daily"`
+	Used      float64 `json:"used"`
+	Remaining float64 `json:"remaining"`
+	Enforced  bool    `json:"enforced"` // 剩余预算不足时是否拒绝开仓
+}
+
+// ClassOpenLimits 一类币种的单笔开仓上限
+type ClassOpenLimits struct {
+	MaxLeverage           int     `json:"max_leverage"`
+	MaxPositionValue      float64 `json:"max_position_value"`        // 信心度≤85时的名义价值上限(USDT)
+	MaxPositionValueFull  float64 `json:"max_position_value_full"`   // 信心度100时的名义价值上限(USDT)
+	MaxSingleRisk         float64 `json:"max_single_risk"`           // 单笔最大风险(USDT)
+	MinRiskReward         float64 `json:"min_risk_reward"`           // 信心度60-79时的最小风险回报比
+	MinRiskRewardHighConf float64 `json:"min_risk_reward_high_conf"` // 信心度≥80时的最小风险回报比
+}
+
+// CalculateCurrentConstraints 根据实时交易上下文计算本周期的开仓限制
+func CalculateCurrentConstraints(ctx *Context) *CurrentConstraints {
+	c := &CurrentConstraints{
+		Mode:            "restricted",
+		CloseOnlyReason: ctx.CloseOnlyReason,
+		Slots:           CalculatePositionSlots(ctx),
+		CooldownSymbols: []string{},
+		RiskBudget: RiskBudgetLimits{
+			Daily:     ctx.Account.DailyRiskBudget,
+			Used:      ctx.Account.UsedRiskBudget,
+			Remaining: ctx.Account.RemainingRiskBudget,
+			Enforced:  ctx.RiskBudgetEnforced,
+		},
+	}
+	if ctx.AIAutonomyMode {
+		c.Mode = "autonomy"
+	}
+
+	if t := ctx.EntryThrottle; t != nil {
+		c.MaxEntriesTotal = t.MaxTotal
+		c.MaxEntriesPerSymbol = t.MaxPerSymbol
+		if t.MaxTotal > 0 {
+			c.EntriesRemaining = max(t.MaxTotal-t.Total(), 0)
+		}
+		if t.MaxPerSymbol > 0 {
+			for symbol, n := range t.Counts {
+				if n >= t.MaxPerSymbol {
+					c.CooldownSymbols = append(c.CooldownSymbols, symbol)
+				}
+			}
+			sort.Strings(c.CooldownSymbols)
+		}
+	}
+	if ctx.ConfidenceFloor != nil {
+		c.ConfidenceFloor = *ctx.ConfidenceFloor
+	}
+
+	smartRisk := CalculateSmartRiskParams(ctx)
+	c.Major = classOpenLimits(ctx, smartRisk, "BTCUSDT")
+	c.Alt = classOpenLimits(ctx, smartRisk, "OTHER")
+	return c
+}
+
+// classOpenLimits 用验证时的 restrictedOpenLimits 计算一类币种在不同信心度下的上限
+func classOpenLimits(ctx *Context, smartRisk *SmartRiskManager, symbol string) ClassOpenLimits {
+	at := func(confidence int) OpenLimits {
+		return restrictedOpenLimits(&Decision{Symbol: symbol, Confidence: confidence}, ctx, smartRisk)
+	}
+	base := at(constraintBaseConfidence)
+	return ClassOpenLimits{
+		MaxLeverage:           base.MaxLeverage,
+		MaxPositionValue:      base.MaxPositionValue,
+		MaxPositionValueFull:  at(constraintFullConfidence).MaxPositionValue,
+		MaxSingleRisk:         base.MaxSingleRisk,
+		MinRiskReward:         at(constraintMidConfidence).MinRiskReward,
+		MinRiskRewardHighConf: at(constraintHighConfidence).MinRiskReward,
+	}
+}
+
+// formatCurrentConstraints 当前约束提示（持仓名额、每日开仓次数、最低信心度、剩余风险预算、单笔开仓上限）
+func formatCurrentConstraints(ctx *Context) string {
+	c := CalculateCurrentConstraints(ctx)
+	lang := ctx.lang()
+
+	var sb strings.Builder
+	if c.Slots.Max > 0 {
+		sb.WriteString(i18n.T(lang, "slots.summary", c.Slots.Max, c.Slots.Held, c.Slots.Remaining))
+		sb.WriteString(i18n.T(lang, "slots.rule", c.Slots.Remaining))
+	}
+	if c.MaxEntriesTotal > 0 {
+		sb.WriteString(i18n.T(lang, "throttle.total", c.MaxEntriesTotal-c.EntriesRemaining, c.MaxEntriesTotal, c.EntriesRemaining))
+	}
+	if c.MaxEntriesPerSymbol > 0 {
+		sb.WriteString(i18n.T(lang, "throttle.per_symbol", c.MaxEntriesPerSymbol))
+		if len(c.CooldownSymbols) > 0 {
+			sb.WriteString(i18n.T(lang, "throttle.exhausted", strings.Join(c.CooldownSymbols, ", ")))
+		}
+	}
+	if c.ConfidenceFloor.Entry > 0 {
+		sb.WriteString(i18n.T(lang, "confidence.entry", c.ConfidenceFloor.Entry))
+	}
+	if c.ConfidenceFloor.Close > 0 {
+		sb.WriteString(i18n.T(lang, "confidence.close", c.ConfidenceFloor.Close))
+	}
+	if c.RiskBudget.Daily > 0 {
+		key := "constraints.budget_advisory"
+		if c.RiskBudget.Enforced {
+			key = "constraints.budget"
+		}
+		sb.WriteString(i18n.T(lang, key, c.RiskBudget.Remaining, c.RiskBudget.Daily))
+	}
+	if c.Mode == "restricted" && ctx.Account.TotalEquity > 0 {
+		sb.WriteString(i18n.T(lang, "constraints.limits_title"))
+		for _, class := range []struct {
+			label  string
+			limits ClassOpenLimits
+		}{
+			{i18n.T(lang, "constraints.class_major"), c.Major},
+			{i18n.T(lang, "constraints.class_alt"), c.Alt},
+		} {
+			l := class.limits
+			sb.WriteString(i18n.T(lang, "constraints.limits_line", class.label,
+				l.MaxLeverage, l.MaxPositionValue, l.MaxPositionValueFull, l.MaxSingleRisk, l.MinRiskReward, l.MinRiskRewardHighConf))
+		}
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+	return i18n.T(lang, "constraints.title") + sb.String() + "\n"
+}
+
+// systemPromptPositionCaps System Prompt中展示的BTC/ETH和其他币种仓位价值上限（与验证使用同一计算）
+func systemPromptPositionCaps(ctx *Context, smartRisk *SmartRiskManager) (major, alt float64) {
+	return classOpenLimits(ctx, smartRisk, "BTCUSDT").MaxPositionValue, classOpenLimits(ctx, smartRisk, "OTHER").MaxPositionValue
+}
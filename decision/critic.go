@@ -0,0 +1,191 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/i18n"
+	"nofx/mcp"
+	"strings"
+)
+
+// 审核结论
+const (
+	CriticApprove = "approve" // 通过
+	CriticAdjust  = "adjust"  // 通过但下调信心度
+	CriticVeto    = "veto"    // 否决，不执行
+)
+
+// Critic 决策审核器（两阶段AI的第二阶段：更便宜/更快的模型按规则清单逐条检查分析模型的决策）
+type Critic struct {
+	Client *mcp.Client
+	Rules  []string // 审核规则清单（空=使用内置规则）
+}
+
+// CriticVerdict 单个决策的审核结论
+type CriticVerdict struct {
+	Index      int      `json:"index"`                // 决策序号（从1开始，对应分析模型输出的顺序）
+	Decision   Decision `json:"decision"`             // 审核前的决策
+	Verdict    string   `json:"verdict"`              // approve / adjust / veto
+	Confidence int      `json:"confidence,omitempty"` // 审核后的信心度（adjust时有效，只能下调）
+	Reason     string   `json:"reason"`
+}
+
+// CriticReview 一个周期的审核结果（随决策记录保存）
+type CriticReview struct {
+	Provider string          `json:"provider"`        // 审核模型提供商
+	Response string          `json:"response"`        // 审核模型原始输出
+	Verdicts []CriticVerdict `json:"verdicts"`        // 每个待审核决策的结论
+	Reviewed int             `json:"reviewed"`        // 待审核决策数
+	Vetoed   int             `json:"vetoed"`          // 否决数
+	Adjusted int             `json:"adjusted"`        // 下调信心度数
+	Error    string          `json:"error,omitempty"` // 审核失败原因（失败时放行全部决策）
+}
+
+// criticReviewable 是否需要审核（hold/wait不执行任何操作，不审核）
+func criticReviewable(action string) bool {
+	return action != "hold" && action != "wait"
+}
+
+// Review 审核决策，返回去掉被否决决策后的列表
+// 审核模型调用或解析失败时放行全部决策（审核是额外的保护，不应让分析模型的决策整体失效）
+func (c *Critic) Review(ctx *Context, decisions []Decision) ([]Decision, *CriticReview) {
+	var indexes []int
+	for i := range decisions {
+		if criticReviewable(decisions[i].Action) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return decisions, nil
+	}
+
+	review := &CriticReview{Reviewed: len(indexes)}
+	response, err := c.Client.CallWithMessages(c.systemPrompt(ctx.lang()), buildCriticUserPrompt(ctx, decisions, indexes))
+	review.Provider = c.Client.LastUsedProvider()
+	review.Response = response
+	if err != nil {
+		review.Error = fmt.Sprintf("调用审核模型失败: %v", err)
+		log.Printf("⚠️  %s，放行全部决策", review.Error)
+		return decisions, review
+	}
+
+	verdicts, err := parseCriticResponse(response)
+	if err != nil {
+		review.Error = err.Error()
+		log.Printf("⚠️  解析审核结果失败，放行全部决策: %v", err)
+		return decisions, review
+	}
+
+	vetoed := make(map[int]bool)
+	for _, i := range indexes {
+		verdict := verdicts[i+1]
+		verdict.Index = i + 1
+		verdict.Decision = decisions[i]
+		switch verdict.Verdict {
+		case CriticVeto:
+			vetoed[i] = true
+			review.Vetoed++
+			log.Printf("🛑 审核否决决策 %d（%s %s）: %s", i+1, decisions[i].Symbol, decisions[i].Action, verdict.Reason)
+		case CriticAdjust:
+			if verdict.Confidence > 0 && verdict.Confidence < decisions[i].Confidence {
+				log.Printf("🔻 审核下调决策 %d（%s %s）信心度 %d → %d: %s",
+					i+1, decisions[i].Symbol, decisions[i].Action, decisions[i].Confidence, verdict.Confidence, verdict.Reason)
+				decisions[i].Confidence = verdict.Confidence
+				review.Adjusted++
+			} else {
+				verdict.Confidence = 0
+			}
+		default:
+			// 缺失或无法识别的结论按通过处理
+			verdict.Verdict = CriticApprove
+			verdict.Confidence = 0
+		}
+		review.Verdicts = append(review.Verdicts, verdict)
+	}
+
+	kept := make([]Decision, 0, len(decisions)-len(vetoed))
+	for i := range decisions {
+		if !vetoed[i] {
+			kept = append(kept, decisions[i])
+		}
+	}
+	log.Printf("🧑‍⚖️ 决策审核完成（%s）: 审核%d个，否决%d个，下调信心度%d个",
+		review.Provider, review.Reviewed, review.Vetoed, review.Adjusted)
+	return kept, review
+}
+
+// systemPrompt 审核模型的System Prompt（规则清单 + 输出格式）
+func (c *Critic) systemPrompt(lang i18n.Lang) string {
+	rules := c.Rules
+	if len(rules) == 0 {
+		rules = strings.Split(i18n.T(lang, "critic.default_rules"), "\n")
+	}
+	var sb strings.Builder
+	for i, rule := range rules {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, rule))
+	}
+	return i18n.T(lang, "critic.system", sb.String())
+}
+
+// buildCriticUserPrompt 审核模型的User Prompt（账户、持仓、相关币种行情和待审核决策）
+func buildCriticUserPrompt(ctx *Context, decisions []Decision, indexes []int) string {
+	lang := ctx.lang()
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "critic.account", ctx.Account.TotalEquity, ctx.Account.AvailableBalance, ctx.Account.MarginUsedPct))
+
+	if len(ctx.Positions) > 0 {
+		sb.WriteString(i18n.T(lang, "critic.positions"))
+		for _, pos := range ctx.Positions {
+			sb.WriteString(i18n.T(lang, "critic.position", pos.Symbol, pos.Side, pos.EntryPrice, pos.MarkPrice,
+				pos.Leverage, pos.UnrealizedPnLPct, pos.LiquidationPrice))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(i18n.T(lang, "critic.market"))
+	seen := make(map[string]bool)
+	for _, i := range indexes {
+		symbol := decisions[i].Symbol
+		data, ok := ctx.MarketDataMap[symbol]
+		if seen[symbol] || !ok || data == nil {
+			continue
+		}
+		seen[symbol] = true
+		sb.WriteString(i18n.T(lang, "critic.market_line", symbol, data.CurrentPrice, data.PriceChange1h,
+			data.PriceChange4h, data.CurrentRSI7, data.FundingRate*100))
+	}
+
+	sb.WriteString(i18n.T(lang, "critic.decisions"))
+	for _, i := range indexes {
+		d := decisions[i]
+		d.Quality = nil
+		data, _ := json.Marshal(d)
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, data))
+	}
+	return sb.String()
+}
+
+// parseCriticResponse 解析审核模型输出的JSON数组（按决策序号索引）
+func parseCriticResponse(response string) (map[int]CriticVerdict, error) {
+	start := strings.Index(response, "[")
+	if start == -1 {
+		return nil, fmt.Errorf("审核结果中没有JSON数组")
+	}
+	end := findMatchingBracket(response, start)
+	if end == -1 {
+		return nil, fmt.Errorf("审核结果JSON数组不完整")
+	}
+
+	var items []CriticVerdict
+	if err := json.Unmarshal([]byte(response[start:end+1]), &items); err != nil {
+		return nil, fmt.Errorf("审核结果JSON解析失败: %w", err)
+	}
+
+	verdicts := make(map[int]CriticVerdict, len(items))
+	for _, item := range items {
+		item.Verdict = strings.ToLower(strings.TrimSpace(item.Verdict))
+		verdicts[item.Index] = item
+	}
+	return verdicts, nil
+}
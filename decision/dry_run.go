@@ -0,0 +1,81 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"nofx/market"
+)
+
+// DryRunResult 决策干跑验证结果（不下单，只走验证和质量评估流程）
+type DryRunResult struct {
+	Valid              bool                `json:"valid"`
+	Error              string              `json:"error,omitempty"`    // 验证失败原因
+	Warnings           []string            `json:"warnings,omitempty"` // 不影响通过的提示（如行情数据获取失败）
+	Mode               string              `json:"mode"`               // restricted / autonomy
+	Decision           Decision            `json:"decision"`           // 归一化后的决策（交易对写法、仓位字段、质量调整后的信心度）
+	Limits             *OpenLimits         `json:"limits,omitempty"`   // 限制模式下开仓适用的限制
+	PositionSlots      PositionSlots       `json:"position_slots"`
+	Constraints        *CurrentConstraints `json:"constraints"` // 本周期生效的全部开仓限制（与AI在prompt中看到的一致）
+	Quality            *DecisionQuality    `json:"quality,omitempty"`
+	OriginalConfidence int                 `json:"original_confidence"`
+}
+
+// DryRunDecision 用实时交易上下文对单个决策执行完整的验证和质量评估，不执行下单
+// 验证规则与AI决策和手动注入决策完全一致（ValidateDecision + 决策质量评估）
+func DryRunDecision(d *Decision, ctx *Context) *DryRunResult {
+	result := &DryRunResult{
+		Mode:               "restricted",
+		OriginalConfidence: d.Confidence,
+		PositionSlots:      CalculatePositionSlots(ctx),
+		Constraints:        CalculateCurrentConstraints(ctx),
+	}
+	if ctx.AIAutonomyMode {
+		result.Mode = "autonomy"
+	}
+
+	// 质量评估依赖该币种的行情数据
+	if err := ensureMarketData(ctx, d.Symbol); err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+	}
+
+	if err := ValidateDecision(d, ctx); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Valid = true
+	}
+
+	if !ctx.AIAutonomyMode && (d.Action == "open_long" || d.Action == "open_short") {
+		limits := restrictedOpenLimits(d, ctx, CalculateSmartRiskParams(ctx))
+		result.Limits = &limits
+	}
+
+	if result.Valid {
+		analyzer := NewDecisionQualityAnalyzer(ctx, NewSmartMarketAnalyzer(ctx).AnalyzeMarketCondition())
+		quality := applyDecisionQuality(d, 1, analyzer)
+		result.Quality = &quality
+	}
+
+	result.Decision = *d
+	log.Printf("🧪 决策干跑: %s %s 通过=%v %s", d.Symbol, d.Action, result.Valid, result.Error)
+	return result
+}
+
+// ensureMarketData 确保上下文中有该币种的行情数据
+func ensureMarketData(ctx *Context, symbol string) error {
+	if symbol == "" {
+		return nil
+	}
+	symbol = market.Normalize(symbol)
+	if ctx.MarketDataMap == nil {
+		ctx.MarketDataMap = make(map[string]*market.Data)
+	}
+	if _, ok := ctx.MarketDataMap[symbol]; ok {
+		return nil
+	}
+	data, err := market.GetWith(ctx.MarketProvider, symbol)
+	if err != nil {
+		return fmt.Errorf("获取%s行情数据失败，质量评估可能不准确: %w", symbol, err)
+	}
+	ctx.MarketDataMap[symbol] = data
+	return nil
+}
@@ -1,16 +1,19 @@
 package decision
 
 import (
+	"errors"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"nofx/analytics"
 	"nofx/database"
 	"nofx/database/models"
+	"nofx/i18n"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
-	"sort"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -27,7 +30,22 @@ type PositionInfo struct {
 	UnrealizedPnLPct float64 `json:"unrealized_pnl_pct"`
 	LiquidationPrice float64 `json:"liquidation_price"`
 	MarginUsed       float64 `json:"margin_used"`
+	MarginMode       string  `json:"margin_mode,omitempty"`     // cross / isolated（空表示交易所未返回，按全仓估算）
+	IsolatedMargin   float64 `json:"isolated_margin,omitempty"` // 逐仓保证金（含未实现盈亏，交易所返回时填充）
 	UpdateTime       int64   `json:"update_time"` // 持仓更新时间戳（毫秒）
+	StopLoss         float64 `json:"stop_loss,omitempty"`   // 当前止损价（开仓时设置或经update_stop_loss调整，0=未知）
+	TakeProfit       float64 `json:"take_profit,omitempty"` // 当前止盈价（开仓时设置或经update_take_profit调整，0=未知）
+	ExitLevelUpdates int     `json:"exit_level_updates,omitempty"` // 本次持仓期间止损/止盈的调整次数
+	OpenOrders       []OpenOrderInfo `json:"open_orders,omitempty"` // 交易所当前挂单（nil表示未查询，空切片表示没有挂单）
+}
+
+// OpenOrderInfo 持仓在交易所的挂单（止损/止盈条件单等）
+type OpenOrderInfo struct {
+	OrderID      int64   `json:"order_id"`
+	Type         string  `json:"type"`                    // stop_loss / take_profit / limit / other
+	TriggerPrice float64 `json:"trigger_price,omitempty"` // 触发价（条件单）
+	Price        float64 `json:"price,omitempty"`         // 限价
+	Quantity     float64 `json:"quantity"`                // 0表示触发后平掉全部持仓
 }
 
 // AccountInfo 账户信息
@@ -40,16 +58,25 @@ type AccountInfo struct {
 	MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
 	PositionCount    int     `json:"position_count"`    // 持仓数量
 	// 风险管理相关字段
-	RiskCapacityUSD  float64 `json:"risk_capacity_usd"`  // 剩余风险容量（USD）
-	MaxRiskPerTrade  float64 `json:"max_risk_per_trade"` // 单笔最大风险（USD）
-	DailyRiskBudget  float64 `json:"daily_risk_budget"`  // 日风险预算（USD）
-	UsedRiskBudget   float64 `json:"used_risk_budget"`   // 已使用风险预算（USD）
+	RiskCapacityUSD     float64 `json:"risk_capacity_usd"`     // 剩余风险容量（USD）
+	MaxRiskPerTrade     float64 `json:"max_risk_per_trade"`    // 单笔最大风险（USD）
+	DailyRiskBudget     float64 `json:"daily_risk_budget"`     // 日风险预算（USD）
+	UsedRiskBudget      float64 `json:"used_risk_budget"`      // 已使用风险预算（USD）
+	RemainingRiskBudget float64 `json:"remaining_risk_budget"` // 剩余风险预算（USD）
+}
+
+// RiskBudgetEntry 风险预算占用明细（来自风险台账）
+type RiskBudgetEntry struct {
+	Symbol   string    `json:"symbol"`
+	Side     string    `json:"side"`
+	RiskUSD  float64   `json:"risk_usd"`  // 占用的风险（USD）
+	OpenedAt time.Time `json:"opened_at"` // 开仓时间
 }
 
 // CandidateCoin 候选币种（来自币种池）
 type CandidateCoin struct {
 	Symbol  string   `json:"symbol"`
-	Sources []string `json:"sources"` // 来源: "ai500" 和/或 "oi_top"
+	Sources []string `json:"sources"` // 来源: "ai500" 和/或 "oi_top"，静态列表为 "default"
 }
 
 // OITopData 持仓量增长Top数据（用于AI决策参考）
@@ -69,11 +96,17 @@ type RiskMetrics struct {
 	MaxDrawdown       float64 `json:"max_drawdown"`        // 最大回撤（%）
 	MaxDrawdownUSD    float64 `json:"max_drawdown_usd"`    // 最大回撤（USD）
 	SharpeRatio       float64 `json:"sharpe_ratio"`        // 夏普比率
+	SortinoRatio      float64 `json:"sortino_ratio"`       // 索提诺比率（只惩罚下行波动）
+	CalmarRatio       float64 `json:"calmar_ratio"`        // 卡玛比率（区间收益/最大回撤）
+	DownsideDeviation float64 `json:"downside_deviation"`  // 周期收益率下行偏差
 	TotalRiskExposure float64 `json:"total_risk_exposure"` // 总风险敞口（USD）
 	LeverageRisk      float64 `json:"leverage_risk"`       // 杠杆风险评分（0-100）
 	ConcentrationRisk float64 `json:"concentration_risk"`  // 集中度风险评分（0-100）
 	LiquidationRisk   float64 `json:"liquidation_risk"`    // 强平风险评分（0-100）
 	VolatilityRisk    float64 `json:"volatility_risk"`     // 波动率风险评分（0-100）
+	GroupExposure     []GroupExposure `json:"group_exposure,omitempty"` // 分组风控额度占用（未启用分组时为空）
+	AccountLiquidationDistance float64  `json:"account_liquidation_distance_pct"` // 账户级最近强平情景需要的不利价格变动(%)，无持仓或不会强平时为0
+	AccountLiquidation *AccountLiquidation `json:"account_liquidation,omitempty"` // 账户级强平估算（考虑全仓持仓之间的相互影响，无持仓时为空）
 }
 
 // Context 交易上下文（传递给AI的完整信息）
@@ -91,48 +124,143 @@ type Context struct {
 	BTCETHLeverage    int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
 	AltcoinLeverage   int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
 	MaxPositions      int                     `json:"-"` // 最大持仓数限制（从配置读取）
+	RiskBudgetEnforced bool                   `json:"-"` // 剩余日风险预算不足时是否拒绝开仓（由trader从风险台账配置填充）
 	AILearningSummary string                  `json:"-"` // AI学习总结（从数据库加载）
+	AILearningSummaryID int64                 `json:"-"` // 当前生效的AI学习总结ID（用于标记决策记录）
 	DecisionLogger    interface{ GetDB() *database.DB } `json:"-"` // 决策日志记录器（用于获取数据库连接）
 	AIAutonomyMode    bool                    `json:"-"` // AI自主模式（true=完全自主，false=限制模式）
+	PromptCache       *PromptCache            `json:"-"` // 重复Prompt抑制缓存（nil表示不启用）
+	PromptCacheWindow time.Duration           `json:"-"` // 复用上次决策的时间窗口
+	Regime            *MarketRegime           `json:"-"` // 市场状态检测结果（获取市场数据后填充）
+	RiskBudgetEntries []RiskBudgetEntry       `json:"-"` // 风险预算占用明细
+	MarginPositions   []PositionInfo          `json:"-"` // 估算账户强平使用的全部持仓（含不提供给AI的资金费率套利仓位，nil时使用Positions）
+	SymbolAlerts      []string                `json:"-"` // 交易所状态预警（持仓币种下架/交割/暂停交易）
+	MarketSnapshotAt  time.Time               `json:"-"` // 市场数据快照时间（用于计算决策延迟）
+	Timings           StageTimings            `json:"-"` // 本周期决策流水线各阶段耗时（GetFullDecision填充，失败时保留已完成阶段）
+	Breadth           *MarketBreadth          `json:"-"` // 市场广度（获取市场数据后填充）
+	Liquidations      *market.LiquidationSummary `json:"-"` // 全市场爆仓汇总（爆仓数据流未启动时为nil）
+	OrderFlow         map[string]*market.OrderFlowStats `json:"-"` // 接近止损/止盈的持仓的订单流信号
+	Headlines         map[string][]market.Headline `json:"-"` // 候选币种和持仓币种的近期新闻（未启用新闻时为nil）
+	AllowStopLoosening bool                   `json:"-"` // 是否允许update_stop_loss放宽止损（默认只允许收紧）
+	Rebalance         *RebalanceTargets       `json:"-"` // 组合敞口目标（nil表示不启用再平衡建议）
+	Sizing            *SizingTargets          `json:"-"` // ATR仓位建议参数（nil表示不在prompt中提供1R仓位）
+	MaintenanceNotices []string               `json:"-"` // 即将到来的维护窗口（窗口内trader自动暂停）
+	CloseOnlyReason    string                 `json:"-"` // 只平仓模式的原因（为空表示可以开仓）
+	RecentOrderErrors  []OrderErrorStat       `json:"-"` // 近期最常见的交易所下单错误（按次数倒序）
+	EntryThrottle      *EntryThrottle         `json:"-"` // 每日开仓次数限制（nil表示不启用）
+	ConfidenceFloor    *ConfidenceFloor       `json:"-"` // 决策最低信心度（nil表示不限制）
+	LiquidityFilter    *LiquidityFilter       `json:"-"` // 候选币种流动性过滤（nil表示按持仓价值15M过滤）
+	LiquidationGuard   *LiquidationGuard      `json:"-"` // 止损与预估强平价距离校验（nil表示不校验）
+	LiquidityExclusions []LiquidityExclusion  `json:"-"` // 本周期因流动性不足被排除的候选币种（fetchMarketDataForContext填充）
+	Funding           *FundingTargets         `json:"-"` // 资金费结算时间感知参数（nil表示不在prompt中提供结算时间表）
+	TimeOfDay         *analytics.TimeOfDayReport `json:"-"` // 历史开仓时段表现（nil表示不在prompt中提示）
+	CompactMode       bool                    `json:"-"` // 紧凑模式：行情数据只输出指标摘要（不含K线和指标序列）
+	CompactMaxCandidates int                  `json:"-"` // 紧凑模式下最多分析的候选币种数（按候选池排序取前N个，0表示不限制）
+	CompactStats      *CompactStats           `json:"-"` // 紧凑模式本周期的token估算（buildUserPrompt填充，非紧凑模式为nil）
+	MarketProvider    market.Provider         `json:"-"` // 行情数据源（nil表示默认Binance行情）
+	CoinPool          *pool.Client            `json:"-"` // trader独立的币种池客户端（用于OI Top数据，nil表示使用全局配置）
+	CandidateSource   string                  `json:"-"` // 候选币种来源（static时不请求币种池服务，也不获取OI Top数据）
+	Language          i18n.Lang               `json:"-"` // prompt语言（空值表示中文）
+	RiskGroups        RiskGroups              `json:"-"` // 分组风控额度（nil表示不启用）
+	Critic            *Critic                 `json:"-"` // 决策审核模型（nil表示不启用两阶段审核）
+	Clock             Clock                   `json:"-"` // 时间来源（nil表示time.Now，集成测试注入固定时钟使prompt可复现）
+}
+
+// Clock 时间来源（与harness.Clock一致，harness.FakeClock可直接注入）
+type Clock interface {
+	Now() time.Time
+}
+
+// now 当前时间（上下文注入了时钟时使用注入的时钟）
+func (ctx *Context) now() time.Time {
+	if ctx.Clock != nil {
+		return ctx.Clock.Now()
+	}
+	return time.Now()
 }
 
 // Decision AI的交易决策
 type Decision struct {
 	Symbol          string  `json:"symbol"`
-	Action          string  `json:"action"` // "open_long", "open_short", "close_long", "close_short", "hold", "wait"
+	Action          string  `json:"action"` // "open_long", "open_short", "close_long", "close_short", "update_stop_loss", "update_take_profit", "hold", "wait"
 	Leverage        int     `json:"leverage,omitempty"`
-	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
+	PositionSizeUSD float64 `json:"position_size_usd,omitempty"` // 兼容旧字段：按名义价值处理（与 notional_usd 相同）
+	MarginUSD       float64 `json:"margin_usd,omitempty"`        // 保证金(USDT)，名义价值 = 保证金 × 杠杆
+	NotionalUSD     float64 `json:"notional_usd,omitempty"`      // 名义价值(USDT)，优先于 margin_usd 和 position_size_usd
 	StopLoss        float64 `json:"stop_loss,omitempty"`
 	TakeProfit      float64 `json:"take_profit,omitempty"`
 	Confidence      int     `json:"confidence,omitempty"` // 信心度 (0-100)
 	RiskUSD         float64 `json:"risk_usd,omitempty"`   // 最大美元风险
 	Reasoning       string  `json:"reasoning"`
+
+	Quality    *DecisionQuality `json:"quality,omitempty"`    // 决策质量评估结果（系统填充，随决策JSON一起保存）
+	Adjustment string           `json:"adjustment,omitempty"` // 验证时系统对决策参数的调整说明（如止损按强平价收紧）
+	// ValidationError 验证失败原因（系统填充，随决策JSON一起保存；为空表示通过验证）
+	ValidationError string `json:"validation_error,omitempty"`
 }
 
 // FullDecision AI的完整决策（包含思维链）
 type FullDecision struct {
-	SystemPrompt string     `json:"system_prompt"` // System Prompt（规则，从数据库加载）
-	UserPrompt   string     `json:"user_prompt"`   // User Prompt（市场数据）
-	CoTTrace     string     `json:"cot_trace"`     // 思维链分析（AI输出）
-	Decisions    []Decision `json:"decisions"`     // 具体决策列表
-	Timestamp    time.Time  `json:"timestamp"`
+	SystemPrompt  string     `json:"system_prompt"`  // System Prompt（规则，从数据库加载）
+	UserPrompt    string     `json:"user_prompt"`    // User Prompt（市场数据）
+	CoTTrace      string     `json:"cot_trace"`      // 思维链分析（AI输出）
+	Decisions     []Decision `json:"decisions"`      // 具体决策列表
+	Timestamp     time.Time  `json:"timestamp"`
+	PromptHash    string     `json:"prompt_hash"`    // Prompt内容哈希（用于重复Prompt抑制）
+	Cached        bool       `json:"cached"`         // 是否复用了上一周期的决策（未调用AI）
+	SchemaVersion int        `json:"schema_version"` // 解析AI输出使用的决策格式版本
+	Critic        *CriticReview `json:"critic,omitempty"` // 审核模型的审核结果（未启用或没有需要审核的决策时为nil）
+	CloseOnlyRejected []string `json:"close_only_rejected,omitempty"` // 只平仓模式下被剔除的开仓决策及原因（同批平仓和调整照常执行）
+	RejectedDecisions []Decision `json:"rejected_decisions,omitempty"` // 被剔除的决策（ValidationError记录原因，随决策JSON一起保存）
+}
+
+// RecordedDecisions 保存到决策记录的决策：保留的决策加上被剔除的决策（ValidationError记录原因，决策解释按条展示验证结果）
+func (f *FullDecision) RecordedDecisions() []Decision {
+	recorded := make([]Decision, 0, len(f.Decisions)+len(f.RejectedDecisions))
+	recorded = append(recorded, f.Decisions...)
+	return append(recorded, f.RejectedDecisions...)
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
 func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
+	ctx.Timings = StageTimings{}
+	stageStart := time.Now()
+
 	// 1. 为所有币种获取市场数据
 	if err := fetchMarketDataForContext(ctx); err != nil {
 		return nil, fmt.Errorf("获取市场数据失败: %w", err)
 	}
 
+	// 1.5 市场状态检测（趋势/震荡/高波动/急跌）
+	regime := NewSmartMarketAnalyzer(ctx).DetectRegime()
+	ctx.Regime = &regime
+	log.Printf("🧭 市场状态: %s（%s）", regime.Label, regime.Reason)
+
+	// 1.6 市场广度（BTC市值占比趋势、EMA50上方币种比例、全市场OI变化）
+	ctx.Breadth = ComputeMarketBreadth(ctx)
+	log.Printf("🌐 市场广度: BTC占比%.2f%%(%s) | EMA50上方%.0f%% | OI %+.2f%%",
+		ctx.Breadth.BTCDominance, ctx.Breadth.DominanceTrend, ctx.Breadth.AboveEMA50Pct, ctx.Breadth.AggregateOIChangePct)
+
+	// 1.7 全市场爆仓汇总（连环爆仓预警）
+	ctx.Liquidations = market.GetLiquidationSummary()
+	if ctx.Liquidations != nil && ctx.Liquidations.Cascade {
+		log.Printf("💥 连环爆仓: 5分钟 多头%.2fM / 空头%.2fM USDT，%d个币种超阈值",
+			ctx.Liquidations.Long5m/1e6, ctx.Liquidations.Short5m/1e6, len(ctx.Liquidations.CascadeSymbols))
+	}
+
+	// 1.8 接近止损/止盈的持仓的订单流信号（盘口失衡、主动买入占比）
+	ctx.OrderFlow = collectOrderFlow(ctx.Positions)
+
+	// 1.9 候选币种和持仓币种的近期新闻
+	ctx.Headlines = collectHeadlines(ctx)
+	ctx.Timings.MarketFetchMs = time.Since(stageStart).Milliseconds()
+	stageStart = time.Now()
+
 	// 2. 计算智能风控参数和实际仓位限制
 	smartRisk := CalculateSmartRiskParams(ctx)
 	
 	// 计算实际最大仓位（与验证逻辑完全一致）
-	baseMaxBTC := ctx.Account.TotalEquity * 30.0
-	baseMaxAlt := ctx.Account.TotalEquity * 20.0
-	actualMaxBTC := CalculateSmartPositionSize(baseMaxBTC, smartRisk, "BTCUSDT", 85)
-	actualMaxAlt := CalculateSmartPositionSize(baseMaxAlt, smartRisk, "OTHER", 85)
+	actualMaxBTC, actualMaxAlt := systemPromptPositionCaps(ctx, smartRisk)
 	
 	// 3. 构建 System Prompt（从数据库加载）和 User Prompt（动态数据）
 	db := ctx.DecisionLogger.GetDB()
@@ -140,30 +268,60 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 		return nil, fmt.Errorf("数据库连接不可用，无法构建提示词")
 	}
 	
-	systemPrompt := db.BuildSystemPromptFromDB(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, actualMaxBTC, actualMaxAlt, ctx.AIAutonomyMode)
+	systemPrompt := db.BuildSystemPromptFromDB(ctx.lang(), ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, actualMaxBTC, actualMaxAlt, ctx.AIAutonomyMode)
+	if ctx.CloseOnlyReason != "" {
+		// 只平仓模式：规则中明确只允许平仓、调整止损止盈和观望（验证时拒绝开仓）
+		systemPrompt += i18n.T(ctx.lang(), "system.close_only", ctx.CloseOnlyReason)
+	}
 	userPrompt, err := buildUserPrompt(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("构建用户提示词失败: %w", err)
 	}
+	ctx.Timings.PromptBuildMs = time.Since(stageStart).Milliseconds()
 	
 	log.Printf("[Prompt] 实际仓位限制: BTC=%.0f USDT, 其他=%.0f USDT (账户净值%.2f, 盈亏%.1f%%, 保证金%.1f%%)", 
 		actualMaxBTC, actualMaxAlt, ctx.Account.TotalEquity, smartRisk.TotalPnLPct, smartRisk.MarginUsedPct)
 
+	// 3.5 重复Prompt抑制：市场状态与上一周期一致且持仓未变化时，复用上次决策
+	promptHash := ""
+	positionKey := positionFingerprint(ctx.Positions)
+	if ctx.PromptCache != nil {
+		if hash, err := computePromptHash(ctx, systemPrompt); err == nil {
+			promptHash = hash
+			if cached := ctx.PromptCache.Lookup(promptHash, positionKey, ctx.PromptCacheWindow); cached != nil {
+				log.Printf("♻️  Prompt与上一周期一致且持仓未变化，复用上次决策（hash=%s）", promptHash[:12])
+				cached.SystemPrompt = systemPrompt
+				cached.UserPrompt = userPrompt
+				return cached, nil
+			}
+		} else {
+			log.Printf("⚠️  计算Prompt哈希失败: %v", err)
+		}
+	}
+
 	// 4. 调用AI API（使用 system + user prompt）
+	stageStart = time.Now()
 	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	ctx.Timings.AILatencyMs = time.Since(stageStart).Milliseconds()
 	if err != nil {
 		return nil, fmt.Errorf("调用AI API失败: %w", err)
 	}
 
 	// 4. 解析AI响应
+	stageStart = time.Now()
 	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
 	if err != nil {
 		return nil, fmt.Errorf("解析AI响应失败: %w", err)
 	}
 	
 	// 4.5 使用真实ctx验证决策（确保使用正确的AIAutonomyMode）
-	if err := validateDecisions(decision.Decisions, ctx); err != nil {
-		return nil, fmt.Errorf("决策验证失败: %w", err)
+	if err := validateAIDecisions(decision, ctx); err != nil {
+		// 返回带逐条验证结果的决策，调用方保存到决策记录（本批决策不执行）
+		decision.Timestamp = ctx.now()
+		decision.SystemPrompt = systemPrompt
+		decision.UserPrompt = userPrompt
+		decision.PromptHash = promptHash
+		return decision, fmt.Errorf("%w: %w", ErrValidationFailed, err)
 	}
 
 	// 5. 智能市场分析
@@ -175,36 +333,27 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 	
 	// 为每个决策评估质量并记录
 	for i := range decision.Decisions {
-		quality := qualityAnalyzer.EvaluateDecisionQuality(&decision.Decisions[i])
-		
-		// 记录决策质量信息
-		log.Printf("决策 %d 质量评估: 分数=%.1f, 等级=%s", i+1, quality.Score, quality.Grade)
-		if len(quality.Issues) > 0 {
-			log.Printf("决策 %d 风险提示: %v", i+1, quality.Issues)
-		}
-		
-		// 如果决策质量过低，降低信心度
-		if quality.Grade == "poor" {
-			if decision.Decisions[i].Confidence > 30 {
-				decision.Decisions[i].Confidence = 30
-			}
-			log.Printf("决策 %d 质量较差，信心度调整为 %d", i+1, decision.Decisions[i].Confidence)
-		} else if quality.Grade == "fair" {
-			if decision.Decisions[i].Confidence > 60 {
-				decision.Decisions[i].Confidence = 60
-			}
-			log.Printf("决策 %d 质量一般，信心度调整为 %d", i+1, decision.Decisions[i].Confidence)
-		}
+		applyDecisionQuality(&decision.Decisions[i], i+1, qualityAnalyzer)
 	}
 
 	// 记录市场状况
 	log.Printf("市场状况分析: 趋势=%s, 波动率=%s, 情绪=%s, 风险=%s", 
 		marketCondition.Trend, marketCondition.Volatility, 
 		marketCondition.Sentiment, marketCondition.Risk)
+	ctx.Timings.ParseValidateMs = time.Since(stageStart).Milliseconds()
+
+	// 7. 审核模型逐条检查（可否决或下调信心度，审核模型调用耗时计入AI耗时）
+	if ctx.Critic != nil {
+		stageStart = time.Now()
+		decision.Decisions, decision.Critic = ctx.Critic.Review(ctx, decision.Decisions)
+		ctx.Timings.AILatencyMs += time.Since(stageStart).Milliseconds()
+	}
 
-	decision.Timestamp = time.Now()
+	decision.Timestamp = ctx.now()
 	decision.SystemPrompt = systemPrompt // 保存system prompt
 	decision.UserPrompt = userPrompt     // 保存user prompt
+	decision.PromptHash = promptHash
+	ctx.PromptCache.Store(promptHash, positionKey, decision)
 	return decision, nil
 }
 
@@ -212,6 +361,7 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 func fetchMarketDataForContext(ctx *Context) error {
 	ctx.MarketDataMap = make(map[string]*market.Data)
 	ctx.OITopDataMap = make(map[string]*OITopData)
+	ctx.MarketSnapshotAt = ctx.now()
 
 	// 收集所有需要获取数据的币种
 	symbolSet := make(map[string]bool)
@@ -237,33 +387,48 @@ func fetchMarketDataForContext(ctx *Context) error {
 		positionSymbols[pos.Symbol] = true
 	}
 
+	filter := ctx.liquidityFilter()
+	ctx.LiquidityExclusions = nil
 	for symbol := range symbolSet {
-		data, err := market.Get(symbol)
+		data, err := market.GetWith(ctx.MarketProvider, symbol)
 		if err != nil {
 			// 单个币种失败不影响整体，只记录错误
+			ctx.Timings.SymbolsFailed++
 			continue
 		}
+		ctx.Timings.SymbolsFetched++
 
-		// ⚠️ 流动性过滤：持仓价值低于15M USD的币种不做（多空都不做）
-		// 持仓价值 = 持仓量 × 当前价格
+		// ⚠️ 流动性过滤：持仓价值/成交额/价差不达标的候选币种不做（多空都不做）
 		// 但现有持仓必须保留（需要决策是否平仓）
 		isExistingPosition := positionSymbols[symbol]
-		if !isExistingPosition && data.OpenInterest != nil && data.CurrentPrice > 0 {
-			// 计算持仓价值（USD）= 持仓量 × 当前价格
-			oiValue := data.OpenInterest.Latest * data.CurrentPrice
-			oiValueInMillions := oiValue / 1_000_000 // 转换为百万美元单位
-			if oiValueInMillions < 15 {
-				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
-					symbol, oiValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
+		if !isExistingPosition {
+			if group, reason := filter.Check(symbol, data); reason != "" {
+				log.Printf("⚠️  %s 流动性不足，跳过此币种: %s", symbol, reason)
+				ctx.LiquidityExclusions = append(ctx.LiquidityExclusions, LiquidityExclusion{Symbol: symbol, Group: group, Reason: reason})
 				continue
 			}
 		}
 
+		// 数据质量降级的候选币种不参与新开仓（持仓币种保留，提示中会标注降级）
+		if !isExistingPosition && data.IsDegraded() && market.QualitySettings.ExcludeDegraded {
+			log.Printf("⚠️  %s 行情数据质量降级，跳过此币种: %s", symbol, strings.Join(data.Quality.Issues, "; "))
+			continue
+		}
+
 		ctx.MarketDataMap[symbol] = data
 	}
 
-	// 加载OI Top数据（不影响主流程）
-	oiPositions, err := pool.GetOITopPositions()
+	// 加载OI Top数据（不影响主流程，使用trader自己的币种池客户端；静态币种列表不请求币种池服务）
+	if ctx.CandidateSource == pool.SourceStatic {
+		return nil
+	}
+	var oiPositions []pool.OIPosition
+	var err error
+	if ctx.CoinPool != nil {
+		oiPositions, err = ctx.CoinPool.GetOITopPositions()
+	} else {
+		oiPositions, err = pool.GetOITopPositions()
+	}
 	if err == nil {
 		for _, pos := range oiPositions {
 			// 标准化符号匹配
@@ -287,6 +452,10 @@ func calculateMaxCandidates(ctx *Context) int {
 	// 直接返回候选池的全部币种数量
 	// 因为候选池已经在 auto_trader.go 中筛选过了
 	// 固定分析前20个评分最高的币种（来自AI500）
+	// 紧凑模式只分析排在前面的N个
+	if ctx.CompactMode && ctx.CompactMaxCandidates > 0 && ctx.CompactMaxCandidates < len(ctx.CandidateCoins) {
+		return ctx.CompactMaxCandidates
+	}
 	return len(ctx.CandidateCoins)
 }
 
@@ -305,13 +474,67 @@ func buildUserPrompt(ctx *Context) (string, error) {
 	}
 	
 	// 从数据库获取用户提示词模板
-	templates, err := db.GetUserPromptTemplates()
+	lang := ctx.lang()
+	templates, err := db.GetUserPromptTemplates(lang)
 	if err != nil {
 		return "", fmt.Errorf("获取用户提示词模板失败: %w", err)
 	}
 	
 	var sb strings.Builder
 	
+	// 紧凑模式每次构建重新统计token估算
+	ctx.CompactStats = nil
+	if ctx.CompactMode {
+		ctx.CompactStats = &CompactStats{}
+		if max := calculateMaxCandidates(ctx); max < len(ctx.CandidateCoins) {
+			ctx.CompactStats.CandidatesDropped = len(ctx.CandidateCoins) - max
+		}
+	}
+	
+	// 只平仓模式（回撤锁定等，置顶提醒）
+	if ctx.CloseOnlyReason != "" {
+		sb.WriteString(i18n.T(lang, "user.close_only", ctx.CloseOnlyReason))
+	}
+	
+	// 交易所状态预警（持仓币种即将下架/交割时置顶提醒）
+	if len(ctx.SymbolAlerts) > 0 {
+		sb.WriteString(i18n.T(lang, "user.symbol_alerts_title"))
+		for _, alert := range ctx.SymbolAlerts {
+			sb.WriteString("- " + alert + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	
+	// 计划维护窗口（窗口内暂停交易，临近时不应开新仓）
+	if len(ctx.MaintenanceNotices) > 0 {
+		sb.WriteString(i18n.T(lang, "user.maintenance_title"))
+		for _, notice := range ctx.MaintenanceNotices {
+			sb.WriteString("- " + notice + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	
+	// 近期交易所下单错误（避免重复提交无效订单）
+	sb.WriteString(formatOrderErrors(ctx))
+
+	// 当前约束（持仓名额、每日开仓次数、最低信心度、剩余风险预算、单笔开仓上限，与验证规则同源）
+	sb.WriteString(formatCurrentConstraints(ctx))
+
+	// 分组风控额度（主流币/公链币/Meme币各自独立的持仓数、敞口和杠杆上限）
+	sb.WriteString(formatRiskGroups(ctx))
+	
+	// 组合敞口分布与再平衡建议
+	sb.WriteString(formatRebalanceSuggestions(ctx))
+	
+	// 波动率与1R仓位（统一的仓位基准）
+	sb.WriteString(formatVolatilitySizing(ctx))
+	
+	// 资金费结算时间（避免临近结算时开出要支付大额资金费的仓位）
+	sb.WriteString(formatFundingSchedule(ctx, ctx.now()))
+	
+	// 历史开仓时段表现（弱势时段开仓需要更强的信号）
+	sb.WriteString(formatTimeOfDay(ctx, ctx.now()))
+	
 	// 准备模板数据
 	templateData := buildTemplateData(ctx)
 	
@@ -337,6 +560,9 @@ func buildTemplateData(ctx *Context) map[string]interface{} {
 	data["RuntimeMinutes"] = ctx.RuntimeMinutes
 	data["CandidateCount"] = len(ctx.MarketDataMap)
 	data["PositionCount"] = ctx.Account.PositionCount
+	slots := CalculatePositionSlots(ctx)
+	data["MaxPositions"] = slots.Max
+	data["RemainingSlots"] = slots.Remaining
 	
 	// BTC数据
 	if btcData, hasBTC := ctx.MarketDataMap["BTCUSDT"]; hasBTC {
@@ -353,6 +579,9 @@ func buildTemplateData(ctx *Context) map[string]interface{} {
 	data["BalancePercent"] = fmt.Sprintf("%.1f", (ctx.Account.AvailableBalance/ctx.Account.TotalEquity)*100)
 	data["PnLPercent"] = fmt.Sprintf("%+.2f", ctx.Account.TotalPnLPct)
 	data["MarginPercent"] = fmt.Sprintf("%.1f", ctx.Account.MarginUsedPct)
+	data["DailyRiskBudget"] = fmt.Sprintf("%.2f", ctx.Account.DailyRiskBudget)
+	data["UsedRiskBudget"] = fmt.Sprintf("%.2f", ctx.Account.UsedRiskBudget)
+	data["RemainingRiskBudget"] = fmt.Sprintf("%.2f", ctx.Account.RemainingRiskBudget)
 	
 	// 夏普比率
 	if ctx.Performance != nil {
@@ -366,6 +595,8 @@ func buildTemplateData(ctx *Context) map[string]interface{} {
 			}
 		}
 	}
+	data["SortinoRatio"] = fmt.Sprintf("%.2f", ctx.RiskMetrics.SortinoRatio)
+	data["CalmarRatio"] = fmt.Sprintf("%.2f", ctx.RiskMetrics.CalmarRatio)
 	
 	return data
 }
@@ -386,10 +617,27 @@ func renderTemplate(template string, data map[string]interface{}, ctx *Context)
 	return content
 }
 
+// lang 当前prompt语言
+func (ctx *Context) lang() i18n.Lang {
+	return i18n.Parse(string(ctx.Language))
+}
+
+// hasHeading 模板内容是否包含特殊章节标题（任一语言均可识别，该语言没有模板时会回退到中文模板）
+func hasHeading(content, key string) bool {
+	for _, lang := range i18n.Supported {
+		if strings.Contains(content, i18n.T(lang, key)) {
+			return true
+		}
+	}
+	return false
+}
+
 // renderSpecialContent 处理特殊的动态内容
 func renderSpecialContent(content string, ctx *Context) string {
+	lang := ctx.lang()
+
 	// 如果是持仓标题，需要检查是否有持仓
-	if strings.Contains(content, "## 当前持仓") && len(ctx.Positions) > 0 {
+	if hasHeading(content, "heading.positions") && len(ctx.Positions) > 0 {
 		// 添加持仓详情
 		var positionDetails strings.Builder
 		positionDetails.WriteString(content)
@@ -399,25 +647,37 @@ func renderSpecialContent(content string, ctx *Context) string {
 			// 计算持仓时长
 			holdingDuration := ""
 			if pos.UpdateTime > 0 {
-				durationMs := time.Now().UnixMilli() - pos.UpdateTime
+				durationMs := ctx.now().UnixMilli() - pos.UpdateTime
 				durationMin := durationMs / (1000 * 60)
 				if durationMin < 60 {
-					holdingDuration = fmt.Sprintf(" | 持仓时长%d分钟", durationMin)
+					holdingDuration = i18n.T(lang, "position.held_minutes", durationMin)
 				} else {
 					durationHour := durationMin / 60
 					durationMinRemainder := durationMin % 60
-					holdingDuration = fmt.Sprintf(" | 持仓时长%d小时%d分钟", durationHour, durationMinRemainder)
+					holdingDuration = i18n.T(lang, "position.held_hours", durationHour, durationMinRemainder)
 				}
 			}
 
-			positionDetails.WriteString(fmt.Sprintf("%d. %s %s | 入场价%.4f 当前价%.4f | 盈亏%+.2f%% | 杠杆%dx | 保证金%.0f | 强平价%.4f%s\n\n",
+			positionDetails.WriteString(i18n.T(lang, "position.line",
 				i+1, pos.Symbol, strings.ToUpper(pos.Side),
 				pos.EntryPrice, pos.MarkPrice, pos.UnrealizedPnLPct,
 				pos.Leverage, pos.MarginUsed, pos.LiquidationPrice, holdingDuration))
+			if pos.StopLoss > 0 || pos.TakeProfit > 0 {
+				positionDetails.WriteString(i18n.T(lang, "position.exit_levels", pos.StopLoss, pos.TakeProfit))
+				if pos.ExitLevelUpdates > 0 {
+					positionDetails.WriteString(i18n.T(lang, "position.exit_updates", pos.ExitLevelUpdates))
+				}
+				positionDetails.WriteString("\n")
+			}
+			positionDetails.WriteString(formatPositionOrders(pos, lang))
+			if stats, ok := ctx.OrderFlow[pos.Symbol]; ok {
+				positionDetails.WriteString(formatOrderFlowSignal(pos, stats, lang))
+			}
+			positionDetails.WriteString(formatHeadlines(ctx.Headlines[pos.Symbol], lang))
 
 			// 添加市场数据（精简格式）
 			if marketData, ok := ctx.MarketDataMap[pos.Symbol]; ok {
-				positionDetails.WriteString(market.FormatCompact(marketData))
+				positionDetails.WriteString(ctx.formatMarketData(marketData, lang))
 				positionDetails.WriteString("\n")
 			}
 		}
@@ -425,7 +685,7 @@ func renderSpecialContent(content string, ctx *Context) string {
 	}
 	
 	// 如果是候选币种标题，添加候选币种详情
-	if strings.Contains(content, "## 候选币种") {
+	if hasHeading(content, "heading.candidates") {
 		var candidateDetails strings.Builder
 		candidateDetails.WriteString(content)
 		candidateDetails.WriteString("\n\n")
@@ -440,20 +700,44 @@ func renderSpecialContent(content string, ctx *Context) string {
 
 			sourceTags := ""
 			if len(coin.Sources) > 1 {
-				sourceTags = " (AI500+OI_Top双重信号)"
+				sourceTags = i18n.T(lang, "candidate.dual_source")
 			} else if len(coin.Sources) == 1 && coin.Sources[0] == "oi_top" {
-				sourceTags = " (OI_Top持仓增长)"
+				sourceTags = i18n.T(lang, "candidate.oi_top")
 			}
 
-			candidateDetails.WriteString(fmt.Sprintf("### %d. %s%s\n", displayedCount, coin.Symbol, sourceTags))
-			candidateDetails.WriteString(market.FormatCompact(marketData))
+			candidateDetails.WriteString(fmt.Sprintf("### %d. %s%s\n", displayedCount, market.DisplaySymbol(coin.Symbol), sourceTags))
+			candidateDetails.WriteString(ctx.formatMarketData(marketData, lang))
+			candidateDetails.WriteString(formatHeadlines(ctx.Headlines[coin.Symbol], lang))
 			candidateDetails.WriteString("\n")
 		}
 		return candidateDetails.String()
 	}
 	
+	// 如果是风险预算，添加占用明细
+	if hasHeading(content, "heading.risk_budget") {
+		var budgetDetails strings.Builder
+		budgetDetails.WriteString(content)
+		budgetDetails.WriteString(i18n.T(lang, "budget.summary",
+			ctx.Account.DailyRiskBudget, ctx.Account.UsedRiskBudget, ctx.Account.RemainingRiskBudget))
+		for _, entry := range ctx.RiskBudgetEntries {
+			budgetDetails.WriteString(i18n.T(lang, "budget.entry", entry.Symbol, strings.ToUpper(entry.Side), entry.RiskUSD))
+		}
+		budgetDetails.WriteString(i18n.T(lang, "budget.rule"))
+		return budgetDetails.String()
+	}
+	
+	// 如果是市场广度，添加全市场统计
+	if hasHeading(content, "heading.breadth") && ctx.Breadth != nil {
+		return content + "\n\n" + formatMarketBreadth(ctx.Breadth, lang)
+	}
+	
+	// 如果是爆仓监控，添加近期爆仓汇总
+	if hasHeading(content, "heading.liquidations") && ctx.Liquidations != nil {
+		return content + "\n\n" + formatLiquidationSummary(ctx.Liquidations, lang)
+	}
+	
 	// 如果是AI学习总结，添加实际内容
-	if strings.Contains(content, "## 📚 AI历史交易学习总结") && ctx.AILearningSummary != "" {
+	if hasHeading(content, "heading.learning") && ctx.AILearningSummary != "" {
 		return content + "\n\n" + ctx.AILearningSummary
 	}
 	
@@ -467,24 +751,31 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 	// 提取思维链
 	cotTrace := extractCoTTrace(aiResponse)
 
-	// 提取决策JSON
-	decisions, err := extractDecisions(aiResponse)
+	// 提取决策JSON（按输出的格式版本解析）
+	decisions, schemaVersion, err := extractDecisions(aiResponse)
 	if err != nil {
 		return nil, fmt.Errorf("提取决策失败: %w", err)
 	}
+	if schemaVersion != CurrentSchemaVersion {
+		log.Printf("ℹ️  AI输出为旧版决策格式 v%d，已兼容解析", schemaVersion)
+	}
 
 	// 直接返回，不在这里验证（验证在GetFullDecision中用真实ctx进行）
 	return &FullDecision{
-		CoTTrace:  cotTrace,
-		Decisions: decisions,
-		Timestamp: time.Now(),
+		CoTTrace:      cotTrace,
+		Decisions:     decisions,
+		Timestamp:     time.Now(),
+		SchemaVersion: schemaVersion,
 	}, nil
 }
 
 // extractCoTTrace 提取思维链分析
 func extractCoTTrace(response string) string {
-	// 查找JSON数组的开始位置
+	// 查找JSON（带版本号的对象或裸数组）的开始位置
 	jsonStart := strings.Index(response, "[")
+	if envelopeStart := findEnvelopeStart(response); envelopeStart != -1 {
+		jsonStart = envelopeStart
+	}
 
 	if jsonStart > 0 {
 		// 思维链是JSON数组之前的内容
@@ -495,18 +786,30 @@ func extractCoTTrace(response string) string {
 	return strings.TrimSpace(response)
 }
 
-// extractDecisions 提取JSON决策列表
-func extractDecisions(response string) ([]Decision, error) {
+// extractDecisions 提取JSON决策列表，返回解析使用的格式版本
+// 优先解析 {"schema_version": N, "decisions": [...]}，没有版本号的裸数组按字段推断版本
+func extractDecisions(response string) ([]Decision, int, error) {
+	if envelopeStart := findEnvelopeStart(response); envelopeStart != -1 {
+		if envelopeEnd := findMatchingBrace(response, envelopeStart); envelopeEnd != -1 {
+			jsonContent := fixMissingQuotes(strings.TrimSpace(response[envelopeStart : envelopeEnd+1]))
+			var envelope decisionEnvelope
+			if err := json.Unmarshal([]byte(jsonContent), &envelope); err != nil {
+				return nil, 0, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
+			}
+			return parseVersionedDecisions(envelope.Decisions, envelope.SchemaVersion)
+		}
+	}
+
 	// 直接查找JSON数组 - 找第一个完整的JSON数组
 	arrayStart := strings.Index(response, "[")
 	if arrayStart == -1 {
-		return nil, fmt.Errorf("无法找到JSON数组起始")
+		return nil, 0, fmt.Errorf("无法找到JSON数组起始")
 	}
 
 	// 从 [ 开始，匹配括号找到对应的 ]
 	arrayEnd := findMatchingBracket(response, arrayStart)
 	if arrayEnd == -1 {
-		return nil, fmt.Errorf("无法找到JSON数组结束")
+		return nil, 0, fmt.Errorf("无法找到JSON数组结束")
 	}
 
 	jsonContent := strings.TrimSpace(response[arrayStart : arrayEnd+1])
@@ -518,14 +821,17 @@ func extractDecisions(response string) ([]Decision, error) {
 	jsonContent = fixMissingQuotes(jsonContent)
 
 	// 解析JSON
-	var decisions []Decision
-	if err := json.Unmarshal([]byte(jsonContent), &decisions); err != nil {
-		return nil, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
+	decisions, version, err := parseVersionedDecisions([]byte(jsonContent), detectLegacyVersion([]byte(jsonContent)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
 	}
 
-	return decisions, nil
+	return decisions, version, nil
 }
 
+// unquotedSymbolPattern 匹配缺少引号的symbol值，如 "symbol": BTCUSDT
+var unquotedSymbolPattern = regexp.MustCompile(`("symbol"\s*:\s*)([A-Za-z0-9_/\-]+)`)
+
 // fixMissingQuotes 修复JSON中缺失的引号
 func fixMissingQuotes(jsonStr string) string {
 	// 修复action字段
@@ -533,14 +839,19 @@ func fixMissingQuotes(jsonStr string) string {
 	jsonStr = strings.ReplaceAll(jsonStr, `"action": open_short`, `"action": "open_short"`)
 	jsonStr = strings.ReplaceAll(jsonStr, `"action": close_long`, `"action": "close_long"`)
 	jsonStr = strings.ReplaceAll(jsonStr, `"action": close_short`, `"action": "close_short"`)
+	jsonStr = strings.ReplaceAll(jsonStr, `"action": update_stop_loss`, `"action": "update_stop_loss"`)
+	jsonStr = strings.ReplaceAll(jsonStr, `"action": update_take_profit`, `"action": "update_take_profit"`)
 	jsonStr = strings.ReplaceAll(jsonStr, `"action": hold`, `"action": "hold"`)
 	jsonStr = strings.ReplaceAll(jsonStr, `"action": wait`, `"action": "wait"`)
 
-	// 修复symbol字段（常见币种）
-	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "ADAUSDT", "DOTUSDT", "LINKUSDT", "AVAXUSDT", "MATICUSDT", "ATOMUSDT", "NEARUSDT", "FILUSDT", "LTCUSDT", "UNIUSDT", "AAVEUSDT", "SUSHIUSDT", "COMPUSDT", "MKRUSDT", "YFIUSDT", "SNXUSDT", "CRVUSDT", "1INCHUSDT", "ALPHAUSDT", "RENUSDT", "KSMUSDT", "WAVESUSDT", "ICXUSDT", "ONTUSDT", "ZILUSDT", "BATUSDT", "ZRXUSDT", "ENJUSDT", "STORJUSDT", "KNCUSDT", "LRCUSDT", "BANDUSDT", "SANDUSDT", "MANAUSDT", "CHZUSDT", "HOTUSDT", "VETUSDT", "WINUSDT", "DUSKUSDT", "DEFIUSDT", "YFIIUSDT", "AUDIOUSDT", "CTKUSDT", "AKROUSDT", "AXSUSDT", "HARDUSDT", "DNTUSDT", "STRKUSDT", "UNFIUSDT", "ROSEUSDT", "AVAUSDT", "XEMUSDT", "SKLUSDT", "GRTUSDT", "1000SHIBUSDT", "CELOUSDT", "RIFUSDT", "CKBUSDT", "FIROUSDT", "LITUSDT", "SFPUSDT", "DODOUSDT", "CAKEUSDT", "ACMUSDT", "BADGERUSDT", "FISUSDT", "OMUSDT", "PONDUSDT", "DEGOUSDT", "ALICEUSDT", "LINAUSDT", "PERPUSDT", "RAMPUSDT", "SUPERUSDT", "CFXUSDT", "EPSUSDT", "AUTOUSDT", "TKOUSDT", "PUNDIXUSDT", "TLMUSDT", "1000BTTUSDT", "BTCSTUSDT", "TRUUSDT", "DEXEUSDT", "CKBUSDT", "TWTUSDT", "FIROUSDT", "LITUSDT", "SFPUSDT", "DODOUSDT", "CAKEUSDT", "ACMUSDT", "BADGERUSDT", "FISUSDT", "OMUSDT", "PONDUSDT", "DEGOUSDT", "ALICEUSDT", "LINAUSDT", "PERPUSDT", "RAMPUSDT", "SUPERUSDT", "CFXUSDT", "EPSUSDT", "AUTOUSDT", "TKOUSDT", "PUNDIXUSDT", "TLMUSDT", "BTCSTUSDT", "TRUUSDT", "DEXEUSDT", "CKBUSDT", "TWTUSDT", "FTTUSDT", "HNTUSDT", "OCEANUSDT", "BELUSDT", "COTIUSDT", "STMXUSDT", "DENTUSDT", "ONEUSDT", "CHRUSDT", "SANDUSDT", "MANAUSDT", "ANKRUSDT", "BTSUSDT", "LITUSDT", "UNFIUSDT", "REEFUSDT", "RVNUSDT", "SFPUSDT", "XEMUSDT", "COTIUSDT", "CHRUSDT", "STMXUSDT", "DENTUSDT", "ONEUSDT", "BTCSTUSDT", "TRUUSDT", "DEXEUSDT", "CKBUSDT", "TWTUSDT", "FTTUSDT", "HNTUSDT", "OCEANUSDT", "BELUSDT", "COTIUSDT", "STMXUSDT", "DENTUSDT", "ONEUSDT", "CHRUSDT", "SANDUSDT", "MANAUSDT", "ANKRUSDT", "BTSUSDT", "LITUSDT", "UNFIUSDT", "REEFUSDT", "RVNUSDT", "SFPUSDT", "XEMUSDT", "BTCDOMUSDT", "DEFIUSDT", "ADAUSDT", "TRXUSDT", "AVAXUSDT", "UNIUSDT", "SUSHIUSDT", "COMPUSDT", "MKRUSDT", "YFIUSDT", "SNXUSDT", "CRVUSDT", "1INCHUSDT", "ALPHAUSDT", "RENUSDT", "KSMUSDT", "WAVESUSDT", "ICXUSDT", "ONTUSDT", "ZILUSDT", "BATUSDT", "ZRXUSDT", "ENJUSDT", "STORJUSDT", "KNCUSDT", "LRCUSDT", "BANDUSDT", "SANDUSDT", "MANAUSDT", "CHZUSDT", "HOTUSDT", "VETUSDT", "WINUSDT", "DUSKUSDT", "DEFIUSDT", "YFIIUSDT", "AUDIOUSDT", "CTKUSDT", "AKROUSDT", "AXSUSDT", "HARDUSDT", "DNTUSDT", "STRKUSDT", "UNFIUSDT", "ROSEUSDT", "AVAUSDT", "XEMUSDT", "SKLUSDT", "GRTUSDT", "1000SHIBUSDT", "CELOUSDT", "RIFUSDT", "CKBUSDT", "FIROUSDT", "LITUSDT", "SFPUSDT", "DODOUSDT", "CAKEUSDT", "ACMUSDT", "BADGERUSDT", "FISUSDT", "OMUSDT", "PONDUSDT", "DEGOUSDT", "ALICEUSDT", "LINAUSDT", "PERPUSDT", "RAMPUSDT", "SUPERUSDT", "CFXUSDT", "EPSUSDT", "AUTOUSDT", "TKOUSDT", "PUNDIXUSDT", "TLMUSDT", "1000BTTUSDT", "BTCSTUSDT", "TRUUSDT", "DEXEUSDT", "CKBUSDT", "TWTUSDT", "FTTUSDT", "HNTUSDT", "OCEANUSDT", "BELUSDT", "COTIUSDT", "STMXUSDT", "DENTUSDT", "ONEUSDT", "CHRUSDT", "SANDUSDT", "MANAUSDT", "ANKRUSDT", "BTSUSDT", "LITUSDT", "UNFIUSDT", "REEFUSDT", "RVNUSDT", "SFPUSDT", "XEMUSDT", "COTIUSDT", "CHRUSDT", "STMXUSDT", "DENTUSDT", "ONEUSDT", "BTCSTUSDT", "TRUUSDT", "DEXEUSDT", "CKBUSDT", "TWTUSDT", "FTTUSDT", "HNTUSDT", "OCEANUSDT", "BELUSDT", "COTIUSDT", "STMXUSDT", "DENTUSDT", "ONEUSDT", "CHRUSDT", "SANDUSDT", "MANAUSDT", "ANKRUSDT", "BTSUSDT", "LITUSDT", "UNFIUSDT", "REEFUSDT", "RVNUSDT", "SFPUSDT", "XEMUSDT", "BTCDOMUSDT", "DEFIUSDT", "TAOUSDT", "ZECUSDT", "XMRUSDT", "DASHUSDT", "ETCUSDT", "BCHUSDT", "BSVUSDT", "XRPUSDT", "EOSUSDT", "XLMUSDT", "TRXUSDT", "IOTAUSDT", "NEOUSDT", "QTUMUSDT", "ALGOUSDT", "ZECUSDT", "XMRUSDT", "DASHUSDT", "ETCUSDT", "BCHUSDT", "BSVUSDT", "XRPUSDT", "EOSUSDT", "XLMUSDT", "TRXUSDT", "IOTAUSDT", "NEOUSDT", "QTUMUSDT", "ALGOUSDT"}
-	for _, symbol := range symbols {
-		jsonStr = strings.ReplaceAll(jsonStr, `"symbol": `+symbol, `"symbol": "`+symbol+`"`)
-	}
+	// 修复symbol字段（任意交易对，交易所是否存在由验证阶段的交易对索引检查）
+	jsonStr = unquotedSymbolPattern.ReplaceAllStringFunc(jsonStr, func(m string) string {
+		parts := unquotedSymbolPattern.FindStringSubmatch(m)
+		if parts[2] == "null" {
+			return m
+		}
+		return parts[1] + `"` + parts[2] + `"`
+	})
 
 	return jsonStr
 }
@@ -567,25 +878,146 @@ func findMatchingBracket(s string, start int) int {
 	return -1
 }
 
-// validateDecisions 验证所有决策的有效性
+// ErrValidationFailed AI决策未通过验证（本周期决策全部不执行）
+var ErrValidationFailed = errors.New("决策验证失败")
+
+// validateDecisions 验证所有决策的有效性（失败原因同时记录在对应决策的ValidationError上）
 func validateDecisions(decisions []Decision, ctx *Context) error {
-	for i, decision := range decisions {
-		if err := validateDecision(&decision, ctx); err != nil {
+	for i := range decisions {
+		if err := validateDecision(&decisions[i], ctx); err != nil {
+			decisions[i].ValidationError = err.Error()
 			return fmt.Errorf("决策 %d 验证失败: %w", i+1, err)
 		}
 	}
+	for _, check := range []func([]Decision, *Context) error{checkPositionSlots, checkEntryThrottle, checkRiskGroupSlots} {
+		if err := check(decisions, ctx); err != nil {
+			markBatchRejection(decisions, err)
+			return err
+		}
+	}
 	return nil
 }
 
+// markBatchRejection 把整批检查（持仓名额、开仓频率、分组限额）的失败原因记到相关的开仓决策上
+// 优先标记错误信息中提到的币种，都未提到时标记本批全部开仓决策
+func markBatchRejection(decisions []Decision, err error) {
+	msg := err.Error()
+	var opens []int
+	matched := false
+	for i, d := range decisions {
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		opens = append(opens, i)
+		if d.Symbol != "" && strings.Contains(msg, d.Symbol) {
+			decisions[i].ValidationError = msg
+			matched = true
+		}
+	}
+	if matched {
+		return
+	}
+	for _, i := range opens {
+		decisions[i].ValidationError = msg
+	}
+}
+
+// validateAIDecisions 验证AI返回的整批决策：只平仓模式下先剔除开仓决策并记录原因，
+// 其余决策（平仓、止损止盈调整）照常验证，避免一条开仓让同批的平仓全部作废
+func validateAIDecisions(full *FullDecision, ctx *Context) error {
+	full.Decisions, full.RejectedDecisions = dropCloseOnlyOpens(full.Decisions, ctx)
+	full.CloseOnlyRejected = nil
+	for _, d := range full.RejectedDecisions {
+		reason := fmt.Sprintf("%s %s 被拒绝：%s", d.Symbol, d.Action, d.ValidationError)
+		full.CloseOnlyRejected = append(full.CloseOnlyRejected, reason)
+		log.Printf("🔒 %s", reason)
+	}
+	return validateDecisions(full.Decisions, ctx)
+}
+
+// dropCloseOnlyOpens 只平仓模式下剔除开仓决策，返回保留的决策和被剔除的决策（ValidationError记录原因）
+func dropCloseOnlyOpens(decisions []Decision, ctx *Context) ([]Decision, []Decision) {
+	if ctx.CloseOnlyReason == "" {
+		return decisions, nil
+	}
+	kept := make([]Decision, 0, len(decisions))
+	var rejected []Decision
+	for _, d := range decisions {
+		if d.Action == "open_long" || d.Action == "open_short" {
+			d.Symbol = market.Normalize(d.Symbol)
+			d.ValidationError = fmt.Sprintf("只平仓模式（%s）禁止开仓", ctx.CloseOnlyReason)
+			rejected = append(rejected, d)
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept, rejected
+}
+
+// ValidateDecision 验证单个决策的有效性（供手动注入决策等非AI来源使用，规则与AI决策一致）
+func ValidateDecision(decision *Decision, ctx *Context) error {
+	if err := validateDecision(decision, ctx); err != nil {
+		return err
+	}
+	if err := checkPositionSlots([]Decision{*decision}, ctx); err != nil {
+		return err
+	}
+	if err := checkEntryThrottle([]Decision{*decision}, ctx); err != nil {
+		return err
+	}
+	return checkRiskGroupSlots([]Decision{*decision}, ctx)
+}
+
 // validateDecision 验证单个决策的有效性
 func validateDecision(decision *Decision, ctx *Context) error {
+	// 统一仓位字段（margin_usd / notional_usd / position_size_usd → 名义价值和保证金）
+	decision.NormalizeSizing()
+
+	// 统一交易对写法（"btc"、"BTC/USDT" → 交易所实际交易对），开仓时校验交易所是否存在该交易对
+	if decision.Symbol != "" {
+		decision.Symbol = market.Normalize(decision.Symbol)
+	}
+	if decision.Action == "open_long" || decision.Action == "open_short" {
+		// 只平仓模式（回撤锁、日亏损限制、风控停止等）：两种模式都拒绝开仓
+		if ctx.CloseOnlyReason != "" {
+			return fmt.Errorf("%s %s 被拒绝：只平仓模式（%s）禁止开仓", decision.Symbol, decision.Action, ctx.CloseOnlyReason)
+		}
+		if err := market.ValidateSymbol(decision.Symbol); err != nil {
+			return err
+		}
+	}
+
+	// 止损/止盈调整只针对现有持仓，两种模式使用相同的规则
+	if IsExitLevelUpdate(decision.Action) {
+		return validateExitLevelUpdate(decision, ctx)
+	}
+
+	// 组合再平衡：新开仓加剧失衡时警告（开启 rebalance_enforce 时拒绝）
+	if err := checkRebalanceImpact(decision, ctx); err != nil {
+		return err
+	}
+
+	// 分组风控额度：两种模式都不能超出所属分组的杠杆和名义敞口上限
+	if err := checkRiskGroupLimits(decision, ctx); err != nil {
+		return err
+	}
+
+	// 最低信心度：两种模式使用相同的下限
+	if err := checkConfidenceFloor(decision, ctx); err != nil {
+		return err
+	}
+
 	// 调试：打印传入的模式
 	log.Printf("[DEBUG] validateDecision: AIAutonomyMode=%v", ctx.AIAutonomyMode)
 	
 	// 🤖 AI自主模式：只做基本验证，不限制AI决策
 	if ctx.AIAutonomyMode {
 		log.Printf("🚀 [AI自主模式] 使用宽松验证，AI完全自主决策")
-		return validateDecisionAutonomy(decision, ctx)
+		if err := validateDecisionAutonomy(decision, ctx); err != nil {
+			return err
+		}
+		// 止损与强平价距离：两种模式使用相同的规则（预览、干跑和实盘都在验证阶段执行）
+		return checkLiquidationGuard(decision, ctx)
 	}
 	
 	// 🔧 限制模式：计算智能风险管理参数
@@ -607,26 +1039,23 @@ func validateDecision(decision *Decision, ctx *Context) error {
 
 	// 对于开仓操作，验证参数
 	if decision.Action == "open_long" || decision.Action == "open_short" {
+		limits := restrictedOpenLimits(decision, ctx, smartRisk)
+
 		// 验证杠杆
-		if decision.Leverage < 1 || decision.Leverage > 20 {
-			return fmt.Errorf("杠杆必须在1-20之间，当前: %d", decision.Leverage)
+		if decision.Leverage < 1 || decision.Leverage > limits.MaxLeverage {
+			return fmt.Errorf("杠杆必须在1-%d之间，当前: %d", limits.MaxLeverage, decision.Leverage)
 		}
 
 		// 验证仓位大小
-		if decision.PositionSizeUSD <= 0 {
-			return fmt.Errorf("仓位大小必须大于0: %.2f", decision.PositionSizeUSD)
+		if decision.NotionalUSD <= 0 {
+			return fmt.Errorf("仓位大小必须大于0: %.2f", decision.NotionalUSD)
 		}
 
 		// 🔧 优化：动态仓位大小验证（大幅提高基础限制）
-		baseMaxPositionValue := 20.0 * ctx.Account.TotalEquity // 提高基础仓位限制到20倍
-		if decision.Symbol == "BTCUSDT" || decision.Symbol == "ETHUSDT" {
-			baseMaxPositionValue = 30.0 * ctx.Account.TotalEquity // BTC/ETH提高到30倍
-		}
+		baseMaxPositionValue := limits.BaseMaxPositionValue
+		adjustedMaxPositionValue := limits.MaxPositionValue
 		
-		// 使用智能仓位计算
-		adjustedMaxPositionValue := CalculateSmartPositionSize(baseMaxPositionValue, smartRisk, decision.Symbol, decision.Confidence)
-		
-		positionValue := decision.PositionSizeUSD * float64(decision.Leverage)
+		positionValue := decision.NotionalUSD
 		
 		// 添加调试日志
 		log.Printf("🛡️ [限制模式-仓位验证] 币种:%s 基础限制:%.2f 调整后:%.2f AI仓位价值:%.2f 信心度:%d 账户净值:%.2f 亏损率:%.1f%% 近期表现:%.1f",
@@ -638,10 +1067,7 @@ func validateDecision(decision *Decision, ctx *Context) error {
 		}
 
 		// 🔧 新增：单笔交易最大风险限制
-		maxSingleRisk := 0.05 * ctx.Account.TotalEquity // 5%
-		if decision.Symbol == "BTCUSDT" || decision.Symbol == "ETHUSDT" {
-			maxSingleRisk = 0.08 * ctx.Account.TotalEquity // 8%
-		}
+		maxSingleRisk := limits.MaxSingleRisk
 		
 		// 验证止损
 		if decision.StopLoss <= 0 {
@@ -690,39 +1116,65 @@ func validateDecision(decision *Decision, ctx *Context) error {
 			}
 		}
 
-		// 🔧 优化：根据币种和信心度调整最小风险回报比
-		minRiskReward := 3.0 // 默认3:1
-		if decision.Symbol == "BTCUSDT" || decision.Symbol == "ETHUSDT" {
-			minRiskReward = 1.8 // BTC/ETH降低到1.8:1
-		}
-		
-		// 根据信心度调整
-		if decision.Confidence >= 80 {
-			minRiskReward *= 0.8 // 高信心度时降低要求
-		} else if decision.Confidence < 60 {
-			minRiskReward *= 1.2 // 低信心度时提高要求
-		}
-		
-		// 根据最近表现调整
-		if smartRisk.RecentPerformance > 70 {
-			minRiskReward *= 0.9 // 表现好时稍微降低要求
-		} else if smartRisk.RecentPerformance < 30 {
-			minRiskReward *= 1.3 // 表现差时提高要求
-		}
+		// 🔧 优化：根据币种、信心度和最近表现调整最小风险回报比
+		minRiskReward := limits.MinRiskReward
 
 		if riskRewardRatio < minRiskReward {
 			return fmt.Errorf("风险回报比过低: %.2f (最小要求: %.2f)", riskRewardRatio, minRiskReward)
 		}
 
 		// 🔧 新增：单笔最大风险限制验证
-		estimatedRisk := decision.PositionSizeUSD * (riskPercent / 100) / float64(decision.Leverage)
+		estimatedRisk := decision.NotionalUSD * (riskPercent / 100)
 		if estimatedRisk > maxSingleRisk {
 			return fmt.Errorf("单笔风险过高(%.2f USDT)，最大允许%.2f USDT（%.1f%%账户净值）", 
 				estimatedRisk, maxSingleRisk, (maxSingleRisk/ctx.Account.TotalEquity)*100)
 		}
 	}
 
-	return nil
+	return checkLiquidationGuard(decision, ctx)
+}
+
+// OpenLimits 限制模式下单个开仓决策适用的限制
+type OpenLimits struct {
+	BaseMaxPositionValue float64 `json:"base_max_position_value"` // 基础仓位价值上限（净值倍数）
+	MaxPositionValue     float64 `json:"max_position_value"`      // 按智能风控调整后的仓位价值上限
+	MaxSingleRisk        float64 `json:"max_single_risk"`         // 单笔最大风险(USDT)
+	MinRiskReward        float64 `json:"min_risk_reward"`         // 最小风险回报比
+	MaxLeverage          int     `json:"max_leverage"`            // 最大杠杆
+}
+
+// restrictedOpenLimits 计算限制模式下开仓决策的仓位、风险和风险回报比限制
+func restrictedOpenLimits(decision *Decision, ctx *Context, smartRisk *SmartRiskManager) OpenLimits {
+	isMajor := decision.Symbol == "BTCUSDT" || decision.Symbol == "ETHUSDT"
+	limits := OpenLimits{
+		BaseMaxPositionValue: 20.0 * ctx.Account.TotalEquity, // 提高基础仓位限制到20倍
+		MaxSingleRisk:        0.05 * ctx.Account.TotalEquity, // 5%
+		MinRiskReward:        3.0,                            // 默认3:1
+		MaxLeverage:          20,
+	}
+	if isMajor {
+		limits.BaseMaxPositionValue = 30.0 * ctx.Account.TotalEquity // BTC/ETH提高到30倍
+		limits.MaxSingleRisk = 0.08 * ctx.Account.TotalEquity        // 8%
+		limits.MinRiskReward = 1.8                                   // BTC/ETH降低到1.8:1
+	}
+
+	// 使用智能仓位计算
+	limits.MaxPositionValue = CalculateSmartPositionSize(limits.BaseMaxPositionValue, smartRisk, decision.Symbol, decision.Confidence)
+
+	// 根据信心度调整
+	if decision.Confidence >= 80 {
+		limits.MinRiskReward *= 0.8 // 高信心度时降低要求
+	} else if decision.Confidence < 60 {
+		limits.MinRiskReward *= 1.2 // 低信心度时提高要求
+	}
+
+	// 根据最近表现调整
+	if smartRisk.RecentPerformance > 70 {
+		limits.MinRiskReward *= 0.9 // 表现好时稍微降低要求
+	} else if smartRisk.RecentPerformance < 30 {
+		limits.MinRiskReward *= 1.3 // 表现差时提高要求
+	}
+	return limits
 }
 
 // validateDecisionAutonomy AI自主模式下的验证（只做基本安全检查）
@@ -743,8 +1195,8 @@ func validateDecisionAutonomy(decision *Decision, ctx *Context) error {
 		if decision.Leverage < 1 {
 			return fmt.Errorf("杠杆必须大于0，当前: %d", decision.Leverage)
 		}
-		if decision.PositionSizeUSD < 0 {
-			return fmt.Errorf("仓位大小不能为负数: %.2f", decision.PositionSizeUSD)
+		if decision.NotionalUSD < 0 || decision.MarginUSD < 0 {
+			return fmt.Errorf("仓位大小不能为负数: 名义价值%.2f 保证金%.2f", decision.NotionalUSD, decision.MarginUSD)
 		}
 		if decision.StopLoss < 0 {
 			return fmt.Errorf("止损价格不能为负数: %.2f", decision.StopLoss)
@@ -764,8 +1216,8 @@ func validateDecisionAutonomy(decision *Decision, ctx *Context) error {
 			}
 		}
 		
-		log.Printf("🚀 [AI自主模式] ✅ 决策验证通过: %s %s 仓位:%.2f USDT 杠杆:%dx 信心度:%d%% (无限制)",
-			decision.Action, decision.Symbol, decision.PositionSizeUSD, decision.Leverage, decision.Confidence)
+		log.Printf("🚀 [AI自主模式] ✅ 决策验证通过: %s %s 名义价值:%.2f USDT 保证金:%.2f USDT 杠杆:%dx 信心度:%d%% (无限制)",
+			decision.Action, decision.Symbol, decision.NotionalUSD, decision.MarginUSD, decision.Leverage, decision.Confidence)
 	}
 
 	return nil
@@ -782,9 +1234,15 @@ func CalculateRiskMetrics(ctx *Context) RiskMetrics {
 			// 获取最近的决策记录用于计算风险指标
 			records, err := db.Decision().GetLatest(100) // 最近100个周期
 			if err == nil && len(records) > 0 {
-				metrics.SharpeRatio = calculateSharpeRatioFromRecords(records)
-				metrics.MaxDrawdown, metrics.MaxDrawdownUSD = calculateMaxDrawdown(records)
-				metrics.VaR95, metrics.VaR99 = calculateVaR(records)
+				// 回撤和收益率扣除入金/出金，避免资金划转被当作盈亏
+				flows, _ := db.BalanceFlow().GetAll()
+				summary := analytics.Summarize(analytics.EquityCurveNetOfFlows(records, analytics.NewFlowLedger(flows)))
+				metrics.SharpeRatio = summary.SharpeRatio
+				metrics.SortinoRatio = summary.SortinoRatio
+				metrics.CalmarRatio = summary.CalmarRatio
+				metrics.DownsideDeviation = summary.DownsideDeviation
+				metrics.MaxDrawdown, metrics.MaxDrawdownUSD = summary.MaxDrawdownPct, summary.MaxDrawdownUSD
+				metrics.VaR95, metrics.VaR99 = summary.VaR95, summary.VaR99
 			}
 		}
 	}
@@ -793,149 +1251,19 @@ func CalculateRiskMetrics(ctx *Context) RiskMetrics {
 	metrics.TotalRiskExposure = calculateTotalRiskExposure(ctx.Positions)
 	metrics.LeverageRisk = calculateLeverageRisk(ctx.Positions, ctx.Account.TotalEquity)
 	metrics.ConcentrationRisk = calculateConcentrationRisk(ctx.Positions)
-	metrics.LiquidationRisk = calculateLiquidationRisk(ctx.Positions, ctx.Account.TotalEquity)
-	metrics.VolatilityRisk = calculateVolatilityRisk(ctx.Positions, ctx.MarketDataMap)
-	
-	return metrics
-}
-
-// calculateSharpeRatioFromRecords 从决策记录计算夏普比率
-func calculateSharpeRatioFromRecords(records []*models.DecisionRecord) float64 {
-	if len(records) < 2 {
-		return 0.0
-	}
-
-	var equities []float64
-	for _, record := range records {
-		if record.TotalBalance > 0 {
-			equities = append(equities, record.TotalBalance)
-		}
-	}
-
-	if len(equities) < 2 {
-		return 0.0
-	}
-
-	// 计算周期收益率
-	var returns []float64
-	for i := 1; i < len(equities); i++ {
-		if equities[i-1] > 0 {
-			periodReturn := (equities[i] - equities[i-1]) / equities[i-1]
-			returns = append(returns, periodReturn)
-		}
-	}
-
-	if len(returns) == 0 {
-		return 0.0
-	}
-
-	// 计算平均收益率
-	sumReturns := 0.0
-	for _, r := range returns {
-		sumReturns += r
-	}
-	meanReturn := sumReturns / float64(len(returns))
-
-	// 计算标准差
-	sumSquaredDiff := 0.0
-	for _, r := range returns {
-		diff := r - meanReturn
-		sumSquaredDiff += diff * diff
-	}
-	variance := sumSquaredDiff / float64(len(returns))
-	stdDev := math.Sqrt(variance)
-
-	if stdDev == 0 {
-		if meanReturn > 0 {
-			return 999.0
-		} else if meanReturn < 0 {
-			return -999.0
-		}
-		return 0.0
+	marginPositions := ctx.MarginPositions
+	if marginPositions == nil {
+		marginPositions = ctx.Positions
 	}
-
-	return meanReturn / stdDev
-}
-
-// calculateMaxDrawdown 计算最大回撤
-func calculateMaxDrawdown(records []*models.DecisionRecord) (float64, float64) {
-	if len(records) < 2 {
-		return 0.0, 0.0
+	metrics.AccountLiquidation = EstimateAccountLiquidation(ctx.Account.TotalEquity, marginPositions)
+	if liq := metrics.AccountLiquidation; liq != nil && liq.Scenario != "" {
+		metrics.AccountLiquidationDistance = liq.DistancePct
 	}
-
-	var equities []float64
-	for _, record := range records {
-		if record.TotalBalance > 0 {
-			equities = append(equities, record.TotalBalance)
-		}
-	}
-
-	if len(equities) < 2 {
-		return 0.0, 0.0
-	}
-
-	maxDrawdownPct := 0.0
-	maxDrawdownUSD := 0.0
-	peak := equities[0]
-
-	for _, equity := range equities {
-		if equity > peak {
-			peak = equity
-		}
-		
-		drawdownUSD := peak - equity
-		drawdownPct := (drawdownUSD / peak) * 100
-		
-		if drawdownPct > maxDrawdownPct {
-			maxDrawdownPct = drawdownPct
-			maxDrawdownUSD = drawdownUSD
-		}
-	}
-
-	return maxDrawdownPct, maxDrawdownUSD
-}
-
-// calculateVaR 计算风险价值（VaR）
-func calculateVaR(records []*models.DecisionRecord) (float64, float64) {
-	if len(records) < 10 {
-		return 0.0, 0.0
-	}
-
-	var returns []float64
-	for i := 1; i < len(records); i++ {
-		if records[i-1].TotalBalance > 0 {
-			periodReturn := (records[i].TotalBalance - records[i-1].TotalBalance) / records[i-1].TotalBalance
-			returns = append(returns, periodReturn)
-		}
-	}
-
-	if len(returns) < 10 {
-		return 0.0, 0.0
-	}
-
-	// 排序收益率
-	sort.Float64s(returns)
-	
-	// 计算95%和99%置信度的VaR
-	index95 := int(float64(len(returns)) * 0.05) // 5%分位数
-	index99 := int(float64(len(returns)) * 0.01) // 1%分位数
-	
-	var95 := 0.0
-	var99 := 0.0
-	
-	if index95 < len(returns) {
-		var95 = -returns[index95] // VaR为负收益率的绝对值
-	}
-	if index99 < len(returns) {
-		var99 = -returns[index99]
-	}
-	
-	// 转换为USD金额（假设当前账户净值）
-	currentEquity := records[len(records)-1].TotalBalance
-	var95USD := var95 * currentEquity
-	var99USD := var99 * currentEquity
+	metrics.LiquidationRisk = calculateLiquidationRisk(ctx.Positions, ctx.Account.TotalEquity, metrics.AccountLiquidation)
+	metrics.VolatilityRisk = calculateVolatilityRisk(ctx.Positions, ctx.MarketDataMap)
+	metrics.GroupExposure = CalculateGroupExposure(ctx.Positions, ctx.RiskGroups)
 	
-	return var95USD, var99USD
+	return metrics
 }
 
 // calculateTotalRiskExposure 计算总风险敞口
@@ -1017,8 +1345,8 @@ func calculateConcentrationRisk(positions []PositionInfo) float64 {
 	return riskScore
 }
 
-// calculateLiquidationRisk 计算强平风险评分（0-100）
-func calculateLiquidationRisk(positions []PositionInfo, totalEquity float64) float64 {
+// calculateLiquidationRisk 计算强平风险评分（0-100，按单个持仓强平价和账户级强平情景中最近的距离）
+func calculateLiquidationRisk(positions []PositionInfo, totalEquity float64, account *AccountLiquidation) float64 {
 	if len(positions) == 0 || totalEquity <= 0 {
 		return 0.0
 	}
@@ -1043,6 +1371,10 @@ func calculateLiquidationRisk(positions []PositionInfo, totalEquity float64) flo
 		}
 	}
 	
+	if account != nil && account.Scenario != "" && account.DistancePct < minDistanceToLiquidation {
+		minDistanceToLiquidation = account.DistancePct
+	}
+	
 	if math.IsInf(minDistanceToLiquidation, 1) {
 		return 0.0
 	}
@@ -1439,6 +1771,12 @@ type MarketCondition struct {
 	Risk       string `json:"risk"`       // low, medium, high, very_high
 }
 
+// 持仓量背离判定阈值（4小时）
+const (
+	oiDivergencePricePct = 1.0 // 价格变化超过该值(%)
+	oiDivergenceOIPct    = 2.0 // OI反向下降超过该值(%)
+)
+
 // DecisionQualityAnalyzer 决策质量分析器
 type DecisionQualityAnalyzer struct {
 	ctx             *Context
@@ -1500,10 +1838,37 @@ func (dqa *DecisionQualityAnalyzer) EvaluateDecisionQuality(decision *Decision)
 	}
 	
 	return DecisionQuality{
-		Score:  score,
-		Grade:  grade,
-		Issues: issues,
+		Score:   score,
+		Grade:   grade,
+		Issues:  issues,
+		Version: QualityRulesVersion,
+	}
+}
+
+// applyDecisionQuality 评估决策质量并写入决策，质量较差/一般时下调信心度
+func applyDecisionQuality(decision *Decision, index int, analyzer *DecisionQualityAnalyzer) DecisionQuality {
+	quality := analyzer.EvaluateDecisionQuality(decision)
+	decision.Quality = &quality
+
+	// 记录决策质量信息
+	log.Printf("决策 %d 质量评估: 分数=%.1f, 等级=%s", index, quality.Score, quality.Grade)
+	if len(quality.Issues) > 0 {
+		log.Printf("决策 %d 风险提示: %v", index, quality.Issues)
 	}
+
+	// 如果决策质量过低，降低信心度
+	if quality.Grade == "poor" {
+		if decision.Confidence > 30 {
+			decision.Confidence = 30
+		}
+		log.Printf("决策 %d 质量较差，信心度调整为 %d", index, decision.Confidence)
+	} else if quality.Grade == "fair" {
+		if decision.Confidence > 60 {
+			decision.Confidence = 60
+		}
+		log.Printf("决策 %d 质量一般，信心度调整为 %d", index, decision.Confidence)
+	}
+	return quality
 }
 
 // evaluateTechnicalSignals 评估技术信号质量
@@ -1536,6 +1901,19 @@ func (dqa *DecisionQualityAnalyzer) evaluateTechnicalSignals(decision *Decision)
 		issues = append(issues, "MACD正值时做空需谨慎")
 	}
 	
+	// 持仓量背离：价格涨而OI降（空头回补）、价格跌而OI降（多头平仓）说明趋势缺乏新资金支撑
+	if data.OpenInterest != nil && len(data.OpenInterest.Series) > 0 {
+		oiChange := data.OpenInterest.Change4h
+		if decision.Action == "open_long" && data.PriceChange4h >= oiDivergencePricePct && oiChange <= -oiDivergenceOIPct {
+			score *= 0.75
+			issues = append(issues, fmt.Sprintf("价格4h上涨%.2f%%但OI下降%.2f%%（空头回补驱动，上涨缺乏新多头支撑）", data.PriceChange4h, -oiChange))
+		}
+		if decision.Action == "open_short" && data.PriceChange4h <= -oiDivergencePricePct && oiChange <= -oiDivergenceOIPct {
+			score *= 0.75
+			issues = append(issues, fmt.Sprintf("价格4h下跌%.2f%%且OI下降%.2f%%（多头平仓驱动，下跌动能可能衰竭）", -data.PriceChange4h, -oiChange))
+		}
+	}
+	
 	// 布林通道信号检查
 	if data.EnhancedIndicators != nil && data.EnhancedIndicators.BollingerBands != nil {
 		bb := data.EnhancedIndicators.BollingerBands
@@ -1678,20 +2056,20 @@ func (dqa *DecisionQualityAnalyzer) evaluateRiskManagement(decision *Decision) (
 			// 高波动时降低仓位上限
 			if bb.Width > 10.0 {
 				maxPositionSize := baseMaxSize * 0.7 // 降低30%
-				if decision.PositionSizeUSD > maxPositionSize {
+				if decision.NotionalUSD > maxPositionSize {
 					score *= 0.7
 					issues = append(issues, fmt.Sprintf("高波动环境(BB宽度%.2f%%)，建议降低仓位", bb.Width))
 				}
 			} else if bb.Width < 2.0 {
 				// 低波动（Squeeze）时可以适当加大仓位
 				maxPositionSize := baseMaxSize * 1.2 // 提高20%
-				if decision.PositionSizeUSD > maxPositionSize {
+				if decision.NotionalUSD > maxPositionSize {
 					score *= 0.8
 					issues = append(issues, "即使低波动，仓位仍需控制")
 				}
 			} else {
 				// 正常波动
-				if decision.PositionSizeUSD > baseMaxSize {
+				if decision.NotionalUSD > baseMaxSize {
 					score *= 0.6
 					issues = append(issues, "仓位过大，超出风险承受能力")
 				}
@@ -1699,7 +2077,7 @@ func (dqa *DecisionQualityAnalyzer) evaluateRiskManagement(decision *Decision) (
 		} else {
 			// 没有布林带数据时的默认检查
 			maxPositionSize := dqa.ctx.Account.TotalEquity * 3.0
-			if decision.PositionSizeUSD > maxPositionSize {
+			if decision.NotionalUSD > maxPositionSize {
 				score *= 0.6
 				issues = append(issues, "仓位过大，超出风险承受能力")
 			}
@@ -1723,7 +2101,12 @@ func (dqa *DecisionQualityAnalyzer) evaluateMarketEnvironment(decision *Decision
 			issues = append(issues, "高风险环境下开新仓需要更强的信号确认")
 		}
 	}
-	
+
+	// 行情获取失败的持仓币种（平仓/调整止损）没有市场数据
+	if data == nil {
+		return score, issues
+	}
+
 	// 高波动环境下的决策评估（优先使用布林带宽度）
 	if data.EnhancedIndicators != nil && data.EnhancedIndicators.BollingerBands != nil {
 		bb := data.EnhancedIndicators.BollingerBands
@@ -1806,8 +2189,8 @@ func (dqa *DecisionQualityAnalyzer) evaluateTiming(decision *Decision) (float64,
 		issues = append(issues, "持仓过多，增加管理难度")
 	}
 	
-	// 检查信心度
-	if decision.Confidence < 75 && (decision.Action == "open_long" || decision.Action == "open_short") {
+	// 检查信心度（扣分线与开仓最低信心度一致）
+	if decision.Confidence < qualityConfidenceThreshold(dqa.ctx) && (decision.Action == "open_long" || decision.Action == "open_short") {
 		score *= 0.7
 		issues = append(issues, "信心度不足，建议等待更好机会")
 	}
@@ -1815,11 +2198,15 @@ func (dqa *DecisionQualityAnalyzer) evaluateTiming(decision *Decision) (float64,
 	return score, issues
 }
 
+// QualityRulesVersion 决策质量评分规则版本（修改DecisionQualityAnalyzer的评分规则时更新，历史决策重评分按版本保存以便对比）
+const QualityRulesVersion = "v1"
+
 // DecisionQuality 决策质量
 type DecisionQuality struct {
-	Score  float64  `json:"score"`  // 0-100分
-	Grade  string   `json:"grade"`  // excellent, good, fair, poor
-	Issues []string `json:"issues"` // 问题列表
+	Score   float64  `json:"score"`             // 0-100分
+	Grade   string   `json:"grade"`             // excellent, good, fair, poor
+	Issues  []string `json:"issues"`            // 问题列表
+	Version string   `json:"version,omitempty"` // 评分规则版本（旧记录为空）
 }
 
 // ... existing code ...
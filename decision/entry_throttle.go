@@ -0,0 +1,45 @@
+package decision
+
+import "fmt"
+
+// EntryThrottle 每日开仓次数限制（由trader从运行时配置和数据库计数填充，nil表示不启用）
+type EntryThrottle struct {
+	MaxPerSymbol int            // 单个币种每日最多开仓次数（0表示不限制）
+	MaxTotal     int            // 每日最多开仓总次数（0表示不限制）
+	Counts       map[string]int // 今日(UTC)各币种已开仓次数
+}
+
+// Total 今日已开仓总次数
+func (t *EntryThrottle) Total() int {
+	total := 0
+	for _, n := range t.Counts {
+		total += n
+	}
+	return total
+}
+
+// checkEntryThrottle 检查本批开仓决策是否超出每日开仓次数限制（同一币种反复止损后反复开仓时拦截）
+func checkEntryThrottle(decisions []Decision, ctx *Context) error {
+	t := ctx.EntryThrottle
+	if t == nil || (t.MaxPerSymbol <= 0 && t.MaxTotal <= 0) {
+		return nil
+	}
+
+	total := t.Total()
+	perSymbol := make(map[string]int)
+	for _, d := range decisions {
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		perSymbol[d.Symbol]++
+		total++
+		if t.MaxPerSymbol > 0 && t.Counts[d.Symbol]+perSymbol[d.Symbol] > t.MaxPerSymbol {
+			return fmt.Errorf("%s 今日已开仓%d次，本批开仓后将达%d次，超出单币种每日开仓上限%d次",
+				d.Symbol, t.Counts[d.Symbol], t.Counts[d.Symbol]+perSymbol[d.Symbol], t.MaxPerSymbol)
+		}
+		if t.MaxTotal > 0 && total > t.MaxTotal {
+			return fmt.Errorf("%s %s 超出每日开仓总次数上限%d次（今日已开仓%d次）", d.Symbol, d.Action, t.MaxTotal, t.Total())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package decision
+
+import (
+	"fmt"
+)
+
+// IsExitLevelUpdate 是否为调整现有持仓止损/止盈的决策
+func IsExitLevelUpdate(action string) bool {
+	return action == "update_stop_loss" || action == "update_take_profit"
+}
+
+// StopLossTightens 新止损是否收紧（多仓上移、空仓下移），当前止损未知(0)时视为收紧
+func StopLossTightens(side string, current, proposed float64) bool {
+	if current <= 0 {
+		return true
+	}
+	if side == "long" {
+		return proposed >= current
+	}
+	return proposed <= current
+}
+
+// findExitUpdatePosition 查找止损/止盈调整对应的持仓（同一币种同时持有多空仓时无法确定方向）
+func findExitUpdatePosition(positions []PositionInfo, symbol string) (*PositionInfo, error) {
+	var found *PositionInfo
+	for i := range positions {
+		if positions[i].Symbol != symbol {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s 同时持有多仓和空仓，无法确定要调整的持仓", symbol)
+		}
+		found = &positions[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s 没有持仓，无法调整止损/止盈", symbol)
+	}
+	return found, nil
+}
+
+// validateExitLevelUpdate 验证止损/止盈调整决策
+// 1. 必须针对现有持仓，新价格必须位于当前价正确一侧（不能立即触发）
+// 2. 止损只能收紧（多仓上移、空仓下移），除非 execution_allow_stop_loosening 允许放宽
+// 3. 止盈可以双向调整
+func validateExitLevelUpdate(d *Decision, ctx *Context) error {
+	pos, err := findExitUpdatePosition(ctx.Positions, d.Symbol)
+	if err != nil {
+		return err
+	}
+	isLong := pos.Side == "long"
+
+	switch d.Action {
+	case "update_stop_loss":
+		if d.StopLoss <= 0 {
+			return fmt.Errorf("update_stop_loss 必须提供 stop_loss")
+		}
+		if isLong && d.StopLoss >= pos.MarkPrice {
+			return fmt.Errorf("多仓止损价(%.4f)必须低于当前价(%.4f)", d.StopLoss, pos.MarkPrice)
+		}
+		if !isLong && d.StopLoss <= pos.MarkPrice {
+			return fmt.Errorf("空仓止损价(%.4f)必须高于当前价(%.4f)", d.StopLoss, pos.MarkPrice)
+		}
+		if !ctx.AllowStopLoosening && !StopLossTightens(pos.Side, pos.StopLoss, d.StopLoss) {
+			return fmt.Errorf("止损只能收紧：%s %s 当前止损%.4f，新止损%.4f会放大风险", d.Symbol, pos.Side, pos.StopLoss, d.StopLoss)
+		}
+		if pos.TakeProfit > 0 && ((isLong && d.StopLoss >= pos.TakeProfit) || (!isLong && d.StopLoss <= pos.TakeProfit)) {
+			return fmt.Errorf("新止损价(%.4f)越过了当前止盈价(%.4f)", d.StopLoss, pos.TakeProfit)
+		}
+	case "update_take_profit":
+		if d.TakeProfit <= 0 {
+			return fmt.Errorf("update_take_profit 必须提供 take_profit")
+		}
+		if isLong && d.TakeProfit <= pos.MarkPrice {
+			return fmt.Errorf("多仓止盈价(%.4f)必须高于当前价(%.4f)", d.TakeProfit, pos.MarkPrice)
+		}
+		if !isLong && d.TakeProfit >= pos.MarkPrice {
+			return fmt.Errorf("空仓止盈价(%.4f)必须低于当前价(%.4f)", d.TakeProfit, pos.MarkPrice)
+		}
+	}
+	return nil
+}
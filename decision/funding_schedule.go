@@ -0,0 +1,98 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// FundingTargets 资金费结算时间感知参数（由trader从运行时配置填充，nil表示不在prompt中提供）
+type FundingTargets struct {
+	PromptEnabled     bool    // 是否在prompt中提供结算时间表
+	EntryDelayMinutes int     // 距结算不足N分钟且费率不利时延迟开仓，0表示不限制
+	AdverseRatePct    float64 // 不利费率阈值(%，单次结算)
+}
+
+// fundingReferenceNotional 未提供1R仓位时用于估算资金费的参考名义价值(USDT)
+const fundingReferenceNotional = 1000.0
+
+// fundingCountdownStep prompt中距结算时间的取整粒度（同一时间段内prompt不变，重复Prompt抑制才能命中）
+const fundingCountdownStep = 30 * time.Minute
+
+// fundingCountdownMinutes 距下次结算的分钟数，按fundingCountdownStep向上取整
+func fundingCountdownMinutes(next, now time.Time) float64 {
+	until := next.Sub(now)
+	if until <= 0 {
+		return 0
+	}
+	steps := (until + fundingCountdownStep - 1) / fundingCountdownStep
+	return (steps * fundingCountdownStep).Minutes()
+}
+
+// settlementRatePct 单次结算的费率(%)，正数表示多单支付
+func settlementRatePct(data *market.Data) float64 {
+	return data.NextFundingPayment(1) * 100
+}
+
+// AdverseFundingDelay 距结算不足EntryDelayMinutes且单次结算费率对开仓方向不利超过阈值时返回延迟原因，否则返回空字符串
+func AdverseFundingDelay(data *market.Data, side string, targets *FundingTargets, now time.Time) string {
+	if data == nil || targets == nil || targets.EntryDelayMinutes <= 0 || data.NextFundingTime.IsZero() {
+		return ""
+	}
+	untilFunding := data.NextFundingTime.Sub(now)
+	if untilFunding <= 0 || untilFunding > time.Duration(targets.EntryDelayMinutes)*time.Minute {
+		return ""
+	}
+	rate := settlementRatePct(data)
+	adverse, label := rate, "多单" // 多单在正费率时支付
+	if side == "short" {
+		adverse, label = -rate, "空单"
+	}
+	if adverse <= targets.AdverseRatePct {
+		return ""
+	}
+	return fmt.Sprintf("距资金费结算%.0f分钟，单次费率%+.4f%%对%s不利（阈值%.4f%%）",
+		untilFunding.Minutes(), rate, label, targets.AdverseRatePct)
+}
+
+// formatFundingSchedule 生成候选币种的资金费结算时间表（距下次结算时间按30分钟取整，和按参考仓位估算的资金费）
+func formatFundingSchedule(ctx *Context, now time.Time) string {
+	if ctx.Funding == nil || !ctx.Funding.PromptEnabled {
+		return ""
+	}
+
+	lang := ctx.lang()
+	var rows []string
+	for _, coin := range ctx.CandidateCoins {
+		data := ctx.MarketDataMap[coin.Symbol]
+		if data == nil || data.NextFundingTime.IsZero() {
+			continue
+		}
+		notional := fundingReferenceNotional
+		if size := CalculateOneRSize(coin.Symbol, data, ctx.Account, ctx.Sizing); size != nil && size.NotionalUSD > 0 {
+			notional = size.NotionalUSD
+		}
+		payment := data.NextFundingPayment(notional)
+		rows = append(rows, i18n.T(lang, "funding.row",
+			market.DisplaySymbol(coin.Symbol), fundingCountdownMinutes(data.NextFundingTime, now), settlementRatePct(data),
+			notional, -payment, payment))
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "funding.title"))
+	sb.WriteString(i18n.T(lang, "funding.note"))
+	sb.WriteString(i18n.T(lang, "funding.table_header"))
+	sb.WriteString("|---|---|---|---|---|\n")
+	sb.WriteString(strings.Join(rows, "\n"))
+	sb.WriteString("\n\n")
+	if ctx.Funding.EntryDelayMinutes > 0 {
+		sb.WriteString(i18n.T(lang, "funding.entry_delay",
+			ctx.Funding.EntryDelayMinutes, ctx.Funding.AdverseRatePct))
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+package decision
+
+import (
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFundingCountdownMinutes(t *testing.T) {
+	next := time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC)
+	cases := []struct {
+		until time.Duration
+		want  float64
+	}{
+		{95 * time.Minute, 120},
+		{90 * time.Minute, 90},
+		{89*time.Minute + 30*time.Second, 90},
+		{time.Minute, 30},
+		{0, 0},
+		{-time.Minute, 0},
+	}
+	for _, c := range cases {
+		if got := fundingCountdownMinutes(next, next.Add(-c.until)); got != c.want {
+			t.Errorf("距结算%v: 期望%.0f分钟，实际%.0f", c.until, c.want, got)
+		}
+	}
+}
+
+func TestFormatFundingScheduleStableWithinWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 14, 5, 0, 0, time.UTC)
+	ctx := &Context{
+		Account:        AccountInfo{TotalEquity: 1000, AvailableBalance: 1000},
+		CandidateCoins: []CandidateCoin{{Symbol: "BTCUSDT"}},
+		MarketDataMap: map[string]*market.Data{"BTCUSDT": {
+			Symbol: "BTCUSDT", CurrentPrice: 60000, FundingRate: 0.0001,
+			FundingInterval: 8 * time.Hour, NextFundingTime: time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC),
+		}},
+		Funding: &FundingTargets{PromptEnabled: true},
+	}
+
+	first := formatFundingSchedule(ctx, now)
+	if !strings.Contains(first, "120分钟内") {
+		t.Fatalf("距结算115分钟应显示为120分钟内:\n%s", first)
+	}
+	// 相邻两个周期（3分钟后）处于同一个取整区间，prompt不变
+	if next := formatFundingSchedule(ctx, now.Add(3*time.Minute)); next != first {
+		t.Errorf("同一取整区间内结算时间表不应变化:\n%s\n---\n%s", first, next)
+	}
+}
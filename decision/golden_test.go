@@ -0,0 +1,170 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/analytics"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/harness"
+	"nofx/i18n"
+	"nofx/market"
+	"testing"
+	"time"
+)
+
+// goldenNow golden测试的固定时间（周一 08:30 UTC）
+var goldenNow = time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+
+// goldenLogger 为buildUserPrompt提供数据库连接
+type goldenLogger struct{ db *database.DB }
+
+func (l goldenLogger) GetDB() *database.DB { return l.db }
+
+// newGoldenContext 用本地K线库中的合成K线构建固定的交易上下文
+func newGoldenContext(t *testing.T, lang i18n.Lang) *Context {
+	t.Helper()
+	harness.ChdirTemp(t)
+
+	db, err := database.New("golden")
+	if err != nil {
+		t.Fatalf("创建数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// 默认配置没有用户提示词模板，按语言写入概览、持仓和候选币种三个章节
+	templates := []string{
+		"时间: {{.Time}} | 周期: #{{.CycleNumber}} | 运行: {{.RuntimeMinutes}}分钟\n净值 {{.NetValue}} USDT，可用 {{.Balance}} ({{.BalancePercent}}%)，盈亏 {{.PnLPercent}}%\nBTC {{.BTCPrice}} (1h {{.BTC1hChange}}%, 4h {{.BTC4hChange}}%)",
+		i18n.T(lang, "heading.positions"),
+		i18n.T(lang, "heading.candidates"),
+	}
+	for i, content := range templates {
+		if err := db.Config().Insert(&models.PromptConfig{
+			SectionName: fmt.Sprintf("golden_user_%s_%d", lang, i), Content: content,
+			PromptType: "user", Language: string(lang), Enabled: true, DisplayOrder: i + 1,
+		}); err != nil {
+			t.Fatalf("写入用户提示词模板失败: %v", err)
+		}
+	}
+
+	store, err := database.NewKlineStore()
+	if err != nil {
+		t.Fatalf("创建K线库失败: %v", err)
+	}
+	market.SetKlineStore(store)
+	t.Cleanup(func() { market.SetKlineStore(nil) })
+
+	marketData := make(map[string]*market.Data)
+	for symbol, base := range map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000} {
+		if err := store.SaveKlines(symbol, "3m", harness.SyntheticKlines(goldenNow, 3*time.Minute, 60, base)); err != nil {
+			t.Fatalf("写入K线失败: %v", err)
+		}
+		if err := store.SaveKlines(symbol, "4h", harness.SyntheticKlines(goldenNow, 4*time.Hour, 60, base)); err != nil {
+			t.Fatalf("写入K线失败: %v", err)
+		}
+		data, err := market.SnapshotAt(symbol, goldenNow)
+		if err != nil {
+			t.Fatalf("重建%s行情失败: %v", symbol, err)
+		}
+		data.FundingRate = 0.0001
+		data.FundingInterval = 8 * time.Hour
+		data.NextFundingTime = goldenNow.Add(95 * time.Minute)
+		marketData[symbol] = data
+	}
+
+	btc := marketData["BTCUSDT"]
+	return &Context{
+		CurrentTime:    goldenNow.Format("2006-01-02 15:04:05"),
+		RuntimeMinutes: 9,
+		CallCount:      3,
+		Account: AccountInfo{
+			TotalEquity: 1000, AvailableBalance: 800, TotalPnL: 20, TotalPnLPct: 2,
+			MarginUsed: 200, MarginUsedPct: 20, PositionCount: 1,
+			DailyRiskBudget: 30, UsedRiskBudget: 10, RemainingRiskBudget: 20,
+		},
+		Positions: []PositionInfo{{
+			Symbol: "BTCUSDT", Side: "long", EntryPrice: 59000, MarkPrice: btc.CurrentPrice,
+			Quantity: 0.02, Leverage: 5, UnrealizedPnLPct: 3.2, LiquidationPrice: 48000, MarginUsed: 200,
+			UpdateTime: goldenNow.Add(-95 * time.Minute).UnixMilli(), StopLoss: 58000, TakeProfit: 64000,
+		}},
+		CandidateCoins: []CandidateCoin{
+			{Symbol: "BTCUSDT", Sources: []string{"default"}},
+			{Symbol: "ETHUSDT", Sources: []string{"default"}},
+		},
+		MarketDataMap:   marketData,
+		BTCETHLeverage:  5,
+		AltcoinLeverage: 5,
+		MaxPositions:    3,
+		DecisionLogger:  goldenLogger{db: db},
+		Funding:         &FundingTargets{PromptEnabled: true, EntryDelayMinutes: 30, AdverseRatePct: 0.05},
+		TimeOfDay: &analytics.TimeOfDayReport{
+			Overall: analytics.TimeOfDayStats{Trades: 40, WinRate: 55},
+			Weak: []analytics.TimeWindow{{
+				Kind: analytics.TimeWindowHours, Label: "08:00-12:00 UTC", StartHour: 8, EndHour: 12,
+				TimeOfDayStats: analytics.TimeOfDayStats{Trades: 10, WinRate: 30, AvgPnL: -4.5},
+			}},
+		},
+		Language: lang,
+		Clock:    harness.NewFakeClock(goldenNow),
+	}
+}
+
+func TestBuildUserPromptGolden(t *testing.T) {
+	for _, lang := range []i18n.Lang{i18n.ZH, i18n.EN} {
+		t.Run(string(lang), func(t *testing.T) {
+			prompt, err := buildUserPrompt(newGoldenContext(t, lang))
+			if err != nil {
+				t.Fatalf("构建User Prompt失败: %v", err)
+			}
+			harness.AssertGolden(t, "user_prompt_"+string(lang), prompt)
+		})
+	}
+}
+
+func TestParseFullDecisionResponseGolden(t *testing.T) {
+	responses := map[string]string{
+		// 当前格式：带版本号的决策对象
+		"v2_envelope": `BTC多头趋势延续，ETH跌破EMA20。
+{"schema_version": 2, "decisions": [
+  {"symbol": "BTCUSDT", "action": "update_stop_loss", "stop_loss": 59500, "reasoning": "上移止损锁定利润"},
+  {"symbol": "ETHUSDT", "action": "open_short", "leverage": 3, "margin_usd": 50, "stop_loss": 3150, "take_profit": 2850, "confidence": 78, "risk_usd": 12, "reasoning": "跌破EMA20"}
+]}`,
+		// 旧格式：裸数组，仓位只有position_size_usd
+		"v1_array": "思维链分析...\n```json\n" + `[
+  {"symbol": "BTCUSDT", "action": "close_long", "reasoning": "止盈"},
+  {"symbol": "ETHUSDT", "action": "open_long", "leverage": 5, "position_size_usd": 500, "stop_loss": 2900, "take_profit": 3300, "confidence": 80, "risk_usd": 15, "reasoning": "突破"}
+]` + "\n```",
+		// 裸数组但包含v2字段：按v2解析
+		"v2_array": `[{"symbol": "BTCUSDT", "action": "update_take_profit", "take_profit": 66000, "reasoning": "上调止盈"}]`,
+		// action和symbol缺少引号时自动修复
+		"missing_quotes": `观望。
+[{"symbol": BTCUSDT, "action": hold, "reasoning": "趋势未变"}]`,
+	}
+
+	for name, response := range responses {
+		t.Run(name, func(t *testing.T) {
+			full, err := parseFullDecisionResponse(response, 1000, 5, 5)
+			if err != nil {
+				t.Fatalf("解析决策失败: %v", err)
+			}
+			decisions, version, err := extractDecisions(response)
+			if err != nil {
+				t.Fatalf("提取决策失败: %v", err)
+			}
+			if version != full.SchemaVersion || len(decisions) != len(full.Decisions) {
+				t.Fatalf("extractDecisions与parseFullDecisionResponse结果不一致: v%d/%d vs v%d/%d",
+					version, len(decisions), full.SchemaVersion, len(full.Decisions))
+			}
+
+			out, err := json.MarshalIndent(struct {
+				CoTTrace      string     `json:"cot_trace"`
+				SchemaVersion int        `json:"schema_version"`
+				Decisions     []Decision `json:"decisions"`
+			}{full.CoTTrace, full.SchemaVersion, full.Decisions}, "", "  ")
+			if err != nil {
+				t.Fatalf("序列化决策失败: %v", err)
+			}
+			harness.AssertGolden(t, "parse_"+name, string(out))
+		})
+	}
+}
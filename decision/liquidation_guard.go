@@ -0,0 +1,95 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// LiquidationGuard 止损与预估强平价距离校验（由trader从运行时配置填充，nil表示不校验）
+type LiquidationGuard struct {
+	BufferPct float64 // 止损与强平价之间至少保留的距离（占开仓价的%）
+	Reject    bool    // true=止损越过缓冲时拒绝开仓，false=把止损收紧到缓冲以内
+}
+
+// ProjectedLiquidationPrice 估算逐仓开仓后的强平价
+// 多仓: LP = EP×(1-1/L)/(1-MMR) - cum/(Q×(1-MMR))
+// 空仓: LP = EP×(1+1/L)/(1+MMR) + cum/(Q×(1+MMR))
+func ProjectedLiquidationPrice(symbol, side string, entryPrice, quantity float64, leverage int) float64 {
+	if entryPrice <= 0 || quantity <= 0 || leverage <= 0 {
+		return 0
+	}
+	mmr, cum := MaintenanceMargin(symbol, entryPrice*quantity)
+	l := float64(leverage)
+	if side == "long" {
+		return math.Max(0, entryPrice*(1-1/l)/(1-mmr)-cum/(quantity*(1-mmr)))
+	}
+	return entryPrice*(1+1/l)/(1+mmr) + cum/(quantity*(1+mmr))
+}
+
+// checkLiquidationGuard 校验开仓止损是否在预估强平价之前并保留足够缓冲（限制模式和自主模式使用相同的规则）
+// 开仓价按当前行情估算；止损越过缓冲时按配置收紧止损（说明记录在决策的Adjustment中）或拒绝开仓
+func checkLiquidationGuard(decision *Decision, ctx *Context) error {
+	guard := ctx.LiquidationGuard
+	if guard == nil || decision.StopLoss <= 0 || decision.NotionalUSD <= 0 {
+		return nil
+	}
+	var side string
+	switch decision.Action {
+	case "open_long":
+		side = "long"
+	case "open_short":
+		side = "short"
+	default:
+		return nil
+	}
+
+	// 手动注入的决策上下文中可能没有该币种行情，按需获取；获取失败时无法估算，交由下单流程处理
+	if err := ensureMarketData(ctx, decision.Symbol); err != nil {
+		log.Printf("⚠️  %s 强平价校验跳过: %v", decision.Symbol, err)
+		return nil
+	}
+	data := ctx.MarketDataMap[decision.Symbol]
+	if data == nil || data.CurrentPrice <= 0 {
+		return nil
+	}
+	entryPrice := data.CurrentPrice
+	liq := ProjectedLiquidationPrice(decision.Symbol, side, entryPrice, decision.NotionalUSD/entryPrice, decision.Leverage)
+	if liq <= 0 {
+		return nil
+	}
+
+	buffer := entryPrice * guard.BufferPct / 100
+	var safeStop float64
+	var breached bool
+	if side == "long" {
+		safeStop = liq + buffer
+		breached = decision.StopLoss < safeStop
+	} else {
+		safeStop = liq - buffer
+		breached = decision.StopLoss > safeStop
+	}
+	if !breached {
+		return nil
+	}
+
+	// 缓冲后的止损已越过开仓价：杠杆过高，无法通过收紧止损修正
+	if (side == "long" && safeStop >= entryPrice) || (side == "short" && safeStop <= entryPrice) {
+		return fmt.Errorf("%s %d倍杠杆预估强平价 %.4f 距开仓价 %.4f 不足 %.2f%% 缓冲，请降低杠杆",
+			decision.Symbol, decision.Leverage, liq, entryPrice, guard.BufferPct)
+	}
+	if guard.Reject {
+		return fmt.Errorf("%s 止损 %.4f 越过预估强平价 %.4f 的 %.2f%% 缓冲（%d倍杠杆），拒绝开仓",
+			decision.Symbol, decision.StopLoss, liq, guard.BufferPct, decision.Leverage)
+	}
+
+	note := fmt.Sprintf("止损 %.4f → %.4f（%d倍杠杆预估强平价 %.4f，缓冲 %.2f%%）",
+		decision.StopLoss, safeStop, decision.Leverage, liq, guard.BufferPct)
+	log.Printf("  🛡️  %s %s", decision.Symbol, note)
+	decision.StopLoss = safeStop
+	if decision.Adjustment != "" {
+		decision.Adjustment += "；"
+	}
+	decision.Adjustment += note
+	return nil
+}
@@ -0,0 +1,64 @@
+package decision
+
+import (
+	"math"
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+func TestCheckLiquidationGuard(t *testing.T) {
+	newCtx := func(reject bool) *Context {
+		return &Context{
+			MarketDataMap:    map[string]*market.Data{"BTCUSDT": {Symbol: "BTCUSDT", CurrentPrice: 60000}},
+			LiquidationGuard: &LiquidationGuard{BufferPct: 1, Reject: reject},
+		}
+	}
+	liq := ProjectedLiquidationPrice("BTCUSDT", "long", 60000, 1000.0/60000, 20)
+	safeStop := liq + 600
+
+	t.Run("safe_stop_unchanged", func(t *testing.T) {
+		d := &Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 20, NotionalUSD: 1000, StopLoss: 59000}
+		if err := checkLiquidationGuard(d, newCtx(false)); err != nil || d.StopLoss != 59000 || d.Adjustment != "" {
+			t.Fatalf("止损在缓冲之外不应调整: %v %+v", err, d)
+		}
+	})
+
+	t.Run("adjust_tightens_stop", func(t *testing.T) {
+		d := &Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 20, NotionalUSD: 1000, StopLoss: 55000}
+		if err := checkLiquidationGuard(d, newCtx(false)); err != nil {
+			t.Fatalf("adjust模式应收紧止损而不是拒绝: %v", err)
+		}
+		if math.Abs(d.StopLoss-safeStop) > 1e-6 || !strings.Contains(d.Adjustment, "止损 55000.0000") {
+			t.Fatalf("止损应收紧到 %.4f 并记录调整说明: %+v", safeStop, d)
+		}
+	})
+
+	t.Run("reject_mode", func(t *testing.T) {
+		d := &Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 20, NotionalUSD: 1000, StopLoss: 55000}
+		if err := checkLiquidationGuard(d, newCtx(true)); err == nil || d.StopLoss != 55000 {
+			t.Fatalf("reject模式应拒绝开仓且不修改止损: %v %+v", err, d)
+		}
+	})
+
+	t.Run("leverage_too_high", func(t *testing.T) {
+		d := &Decision{Symbol: "BTCUSDT", Action: "open_short", Leverage: 100, NotionalUSD: 1000, StopLoss: 61000}
+		if err := checkLiquidationGuard(d, newCtx(false)); err == nil || !strings.Contains(err.Error(), "请降低杠杆") {
+			t.Fatalf("缓冲越过开仓价时应要求降低杠杆: %v", err)
+		}
+	})
+
+	t.Run("not_open", func(t *testing.T) {
+		d := &Decision{Symbol: "BTCUSDT", Action: "update_stop_loss", StopLoss: 55000}
+		if err := checkLiquidationGuard(d, newCtx(true)); err != nil || d.StopLoss != 55000 {
+			t.Fatalf("非开仓决策不校验: %v", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		d := &Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 20, NotionalUSD: 1000, StopLoss: 55000}
+		if err := checkLiquidationGuard(d, &Context{}); err != nil || d.StopLoss != 55000 {
+			t.Fatalf("未启用时不校验: %v", err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package decision
+
+import (
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+)
+
+// formatLiquidationSummary 爆仓监控Prompt内容（近期连环爆仓、爆仓最多的币种）
+func formatLiquidationSummary(s *market.LiquidationSummary, lang i18n.Lang) string {
+	var sb strings.Builder
+
+	if !s.Connected {
+		sb.WriteString(i18n.T(lang, "liq.disconnected"))
+	}
+	if s.CoverageMinutes < 60 {
+		sb.WriteString(i18n.T(lang, "liq.partial", s.CoverageMinutes))
+	}
+
+	sb.WriteString(i18n.T(lang, "liq.totals",
+		s.Long5m/1e6, s.Short5m/1e6, s.Long1h/1e6, s.Short1h/1e6))
+
+	if s.Cascade {
+		sb.WriteString(i18n.T(lang, "liq.cascade"))
+		if len(s.CascadeSymbols) > 0 {
+			names := make([]string, 0, len(s.CascadeSymbols))
+			for _, stats := range s.CascadeSymbols {
+				side := i18n.T(lang, "liq.shorts_squeezed")
+				if stats.Long5m >= stats.Short5m {
+					side = i18n.T(lang, "liq.longs_flushed")
+				}
+				names = append(names, i18n.T(lang, "liq.cascade_symbol", stats.Symbol, stats.Total5m()/1e6, side))
+			}
+			sb.WriteString(strings.Join(names, ", "))
+		} else {
+			sb.WriteString(i18n.T(lang, "liq.cascade_market"))
+		}
+		sb.WriteString("\n")
+		if market.LiquidationCascade.PauseEntries {
+			sb.WriteString(i18n.T(lang, "liq.pause_entries"))
+		}
+	} else {
+		sb.WriteString(i18n.T(lang, "liq.no_cascade"))
+	}
+
+	if len(s.TopSymbols) > 0 {
+		sb.WriteString(i18n.T(lang, "liq.top"))
+		items := make([]string, 0, len(s.TopSymbols))
+		for _, stats := range s.TopSymbols {
+			items = append(items, i18n.T(lang, "liq.top_item", stats.Symbol, stats.Long1h/1e6, stats.Short1h/1e6))
+		}
+		sb.WriteString(strings.Join(items, " | "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/market"
+	"strings"
+)
+
+// 流动性过滤方式
+const (
+	LiquidityModeOI     = "oi"     // 只按持仓价值
+	LiquidityModeVolume = "volume" // 只按24h成交额和平均价差
+	LiquidityModeBoth   = "both"   // 持仓价值、成交额、价差全部满足
+)
+
+// LiquidityThresholds 流动性门槛（0表示不检查该项）
+type LiquidityThresholds struct {
+	MinOIValueM     float64 // 最小持仓价值(百万USD)
+	MinQuoteVolumeM float64 // 最小24h成交额(百万USD)
+	MaxAvgSpreadBps float64 // 最大平均买卖价差(基点)
+}
+
+// LiquidityGroup 按币种分组覆盖的流动性门槛
+type LiquidityGroup struct {
+	Name       string
+	Symbols    []string // 基础币种，为空表示收纳其他分组未列出的币种
+	Thresholds LiquidityThresholds
+}
+
+// LiquidityFilter 候选币种流动性过滤（由trader从运行时配置填充，nil时按持仓价值15M过滤）
+type LiquidityFilter struct {
+	Mode    string
+	Default LiquidityThresholds
+	Groups  []LiquidityGroup
+}
+
+// LiquidityExclusion 本周期因流动性不足被排除的候选币种
+type LiquidityExclusion struct {
+	Symbol string `json:"symbol"`
+	Group  string `json:"group,omitempty"` // 适用的门槛分组（空表示默认门槛）
+	Reason string `json:"reason"`
+}
+
+// defaultLiquidityFilter 未配置时的过滤规则（持仓价值低于15M USD的币种不做）
+var defaultLiquidityFilter = &LiquidityFilter{Mode: LiquidityModeOI, Default: LiquidityThresholds{MinOIValueM: 15}}
+
+// thresholdsFor 币种适用的门槛（匹配规则与分组风控一致）
+func (f *LiquidityFilter) thresholdsFor(symbol string) (string, LiquidityThresholds) {
+	groups := make(RiskGroups, len(f.Groups))
+	for i, g := range f.Groups {
+		groups[i] = RiskGroup{Name: g.Name, Symbols: g.Symbols}
+	}
+	if g := groups.GroupOf(symbol); g != nil {
+		for _, lg := range f.Groups {
+			if lg.Name == g.Name {
+				return lg.Name, lg.Thresholds
+			}
+		}
+	}
+	return "", f.Default
+}
+
+// Check 检查候选币种的流动性，返回排除原因（空字符串表示通过）
+// 成交额和价差目前只有Binance合约数据，取不到时不按这两项排除
+func (f *LiquidityFilter) Check(symbol string, data *market.Data) (string, string) {
+	group, t := f.thresholdsFor(symbol)
+	var reasons []string
+
+	if f.Mode != LiquidityModeVolume && t.MinOIValueM > 0 && data.OpenInterest != nil && data.CurrentPrice > 0 {
+		// 持仓价值 = 持仓量 × 当前价格
+		oiValueM := data.OpenInterest.Latest * data.CurrentPrice / 1_000_000
+		if oiValueM < t.MinOIValueM {
+			reasons = append(reasons, fmt.Sprintf("持仓价值%.2fM < %.0fM USD [持仓量:%.0f × 价格:%.4f]",
+				oiValueM, t.MinOIValueM, data.OpenInterest.Latest, data.CurrentPrice))
+		}
+	}
+
+	if f.Mode == LiquidityModeVolume || f.Mode == LiquidityModeBoth {
+		if liq, ok := market.GetLiquidity(symbol); ok {
+			if t.MinQuoteVolumeM > 0 && liq.QuoteVolume24h/1_000_000 < t.MinQuoteVolumeM {
+				reasons = append(reasons, fmt.Sprintf("24h成交额%.2fM < %.0fM USD", liq.QuoteVolume24h/1_000_000, t.MinQuoteVolumeM))
+			}
+			if t.MaxAvgSpreadBps > 0 && liq.AvgSpreadBps > t.MaxAvgSpreadBps {
+				reasons = append(reasons, fmt.Sprintf("平均价差%.2fbp > %.2fbp（%d个样本）", liq.AvgSpreadBps, t.MaxAvgSpreadBps, liq.SpreadSamples))
+			}
+		}
+	}
+	return group, strings.Join(reasons, "; ")
+}
+
+// liquidityFilter 本周期使用的流动性过滤规则
+func (ctx *Context) liquidityFilter() *LiquidityFilter {
+	if ctx.LiquidityFilter == nil {
+		return defaultLiquidityFilter
+	}
+	return ctx.LiquidityFilter
+}
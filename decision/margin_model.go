@@ -0,0 +1,242 @@
+package decision
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// 保证金模式
+const (
+	MarginModeCross    = "cross"    // 全仓：所有全仓持仓共用账户净值，任一持仓亏损都会消耗其他持仓的保证金
+	MarginModeIsolated = "isolated" // 逐仓：亏损以该持仓的保证金为限，单独强平
+)
+
+// 账户强平情景
+const (
+	LiquidationScenarioMarketDown = "market_down" // 所有全仓持仓的价格同比例下跌
+	LiquidationScenarioMarketUp   = "market_up"   // 所有全仓持仓的价格同比例上涨
+	LiquidationScenarioAdverseAll = "adverse_all" // 所有全仓持仓同时向不利方向移动（多空对冲失效）
+	LiquidationScenarioSingle     = "single"      // 只有一个全仓持仓向不利方向移动，其他持仓不变
+	LiquidationScenarioIsolated   = "isolated"    // 逐仓持仓到达自身强平价
+)
+
+// marginTier 保证金档位：名义价值不超过Cap(USDT)时适用的维持保证金率
+type marginTier struct {
+	Cap float64
+	MMR float64
+}
+
+// 维持保证金档位（参考币安U本位合约的分档，用于估算强平价；各交易所实际档位略有差异）
+var (
+	majorMarginTiers = []marginTier{
+		{300000, 0.004}, {800000, 0.005}, {3000000, 0.0065}, {12000000, 0.01},
+		{50000000, 0.02}, {80000000, 0.025}, {100000000, 0.05}, {200000000, 0.1},
+		{math.Inf(1), 0.125},
+	}
+	altMarginTiers = []marginTier{
+		{5000, 0.01}, {25000, 0.025}, {100000, 0.05}, {250000, 0.1},
+		{1000000, 0.125}, {math.Inf(1), 0.5},
+	}
+)
+
+// marginTiersFor 币种适用的保证金档位（BTC/ETH使用主流币档位，其余使用山寨币档位）
+func marginTiersFor(symbol string) []marginTier {
+	base := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(symbol), "USDT"), "USDC")
+	if base == "BTC" || base == "ETH" {
+		return majorMarginTiers
+	}
+	return altMarginTiers
+}
+
+// MaintenanceMargin 按档位计算名义价值对应的维持保证金率和速算额（维持保证金 = 名义价值 × mmr - cum）
+// 速算额 cum_i = cum_{i-1} + 下一档起点 × (mmr_i - mmr_{i-1})，保证跨档时维持保证金连续
+func MaintenanceMargin(symbol string, notional float64) (mmr, cum float64) {
+	tiers := marginTiersFor(symbol)
+	floor := 0.0
+	for i, t := range tiers {
+		if i > 0 {
+			cum += floor * (t.MMR - tiers[i-1].MMR)
+		}
+		if notional <= t.Cap {
+			return t.MMR, cum
+		}
+		floor = t.Cap
+	}
+	last := tiers[len(tiers)-1]
+	return last.MMR, cum
+}
+
+// AccountLiquidation 账户级强平估算
+// 全仓持仓共用账户净值，单个持仓接口返回的强平价假设其他持仓不变；这里按情景估算价格变动到多少时全仓净值跌破维持保证金
+type AccountLiquidation struct {
+	CrossEquity       float64               `json:"cross_equity"`       // 全仓净值（账户净值扣除逐仓持仓占用的保证金）
+	MaintenanceMargin float64               `json:"maintenance_margin"` // 全仓持仓当前的维持保证金合计
+	MarginRatio       float64               `json:"margin_ratio"`       // 维持保证金/全仓净值(%)，达到100%时账户强平
+	DistancePct       float64               `json:"distance_pct"`       // 最近的强平情景需要的不利价格变动(%)（Scenario为空表示所有情景都不会强平）
+	Scenario          string                `json:"scenario"`           // 最近的强平情景
+	Symbols           []string              `json:"symbols"`            // 最近的强平情景中移动的持仓（symbol_side）
+	Scenarios         []LiquidationScenario `json:"scenarios"`          // 全部会触发强平的情景（按距离从近到远）
+}
+
+// LiquidationScenario 一个会触发强平的价格变动情景
+type LiquidationScenario struct {
+	Name        string   `json:"name"`
+	Symbols     []string `json:"symbols"`      // 情景中移动的持仓（symbol_side）
+	DistancePct float64  `json:"distance_pct"` // 触发强平需要的不利价格变动(%)
+}
+
+// marginLeg 参与强平估算的一个持仓
+type marginLeg struct {
+	key      string
+	sign     float64 // 多仓+1，空仓-1
+	notional float64
+	mmr      float64
+	cum      float64
+}
+
+// liquidationCase 全仓强平情景：各持仓的移动方向（-1下跌、+1上涨、0不变）
+type liquidationCase struct {
+	name string
+	dirs []float64
+	keys []string
+}
+
+// EstimateAccountLiquidation 估算账户级强平情景（没有持仓时返回nil）
+// 全仓情景按线性模型求解：价格变动比例为x时
+// 全仓净值 E + Σ sign_i×N_i×d_i×x 跌破维持保证金 Σ(mmr_i×N_i×(1+d_i×x) - cum_i)（d_i为该持仓在情景中的移动方向，档位按当前名义价值计算）
+func EstimateAccountLiquidation(totalEquity float64, positions []PositionInfo) *AccountLiquidation {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	result := &AccountLiquidation{CrossEquity: totalEquity, Symbols: []string{}, Scenarios: []LiquidationScenario{}}
+	var legs []marginLeg
+	for _, pos := range positions {
+		notional := math.Abs(pos.Quantity) * pos.MarkPrice
+		if notional <= 0 {
+			continue
+		}
+		key := pos.Symbol + "_" + pos.Side
+		if pos.MarginMode == MarginModeIsolated {
+			result.CrossEquity -= isolatedMargin(pos)
+			if d := isolatedLiquidationDistance(pos); d >= 0 {
+				result.Scenarios = append(result.Scenarios, LiquidationScenario{
+					Name: LiquidationScenarioIsolated, Symbols: []string{key}, DistancePct: d,
+				})
+			}
+			continue
+		}
+		mmr, cum := MaintenanceMargin(pos.Symbol, notional)
+		sign := 1.0
+		if pos.Side == "short" {
+			sign = -1
+		}
+		legs = append(legs, marginLeg{key: key, sign: sign, notional: notional, mmr: mmr, cum: cum})
+		result.MaintenanceMargin += notional*mmr - cum
+	}
+	if result.CrossEquity > 0 {
+		result.MarginRatio = result.MaintenanceMargin / result.CrossEquity * 100
+	}
+
+	if len(legs) > 0 {
+		all := func(d func(l marginLeg) float64) []float64 {
+			dirs := make([]float64, len(legs))
+			for i, l := range legs {
+				dirs[i] = d(l)
+			}
+			return dirs
+		}
+		keys := make([]string, len(legs))
+		for i, l := range legs {
+			keys[i] = l.key
+		}
+		cases := []liquidationCase{
+			{LiquidationScenarioMarketDown, all(func(marginLeg) float64 { return -1 }), keys},
+			{LiquidationScenarioMarketUp, all(func(marginLeg) float64 { return 1 }), keys},
+		}
+		// 持仓方向一致时“全部不利”与整体下跌/上涨相同
+		if hasBothSides(legs) {
+			cases = append(cases, liquidationCase{LiquidationScenarioAdverseAll, all(func(l marginLeg) float64 { return -l.sign }), keys})
+		}
+		if len(legs) > 1 {
+			for i, l := range legs {
+				dirs := make([]float64, len(legs))
+				dirs[i] = -l.sign
+				cases = append(cases, liquidationCase{LiquidationScenarioSingle, dirs, []string{l.key}})
+			}
+		}
+		for _, c := range cases {
+			if x, ok := crossLiquidationMove(result.CrossEquity, result.MaintenanceMargin, legs, c.dirs); ok {
+				result.Scenarios = append(result.Scenarios, LiquidationScenario{Name: c.name, Symbols: c.keys, DistancePct: x * 100})
+			}
+		}
+	}
+
+	sort.SliceStable(result.Scenarios, func(i, j int) bool {
+		return result.Scenarios[i].DistancePct < result.Scenarios[j].DistancePct
+	})
+	if len(result.Scenarios) > 0 {
+		nearest := result.Scenarios[0]
+		result.DistancePct = nearest.DistancePct
+		result.Scenario = nearest.Name
+		result.Symbols = nearest.Symbols
+	}
+	return result
+}
+
+// crossLiquidationMove 求解全仓净值跌破维持保证金所需的价格变动比例（dirs为各持仓的移动方向，返回false表示该情景不会强平）
+func crossLiquidationMove(equity, maintenance float64, legs []marginLeg, dirs []float64) (float64, bool) {
+	if equity <= maintenance {
+		return 0, true // 已经达到强平线
+	}
+	// 每移动1单位比例，净值减少 Σ -sign×N×d，维持保证金增加 Σ mmr×N×d
+	slope := 0.0
+	falling := false
+	for i, l := range legs {
+		slope += l.notional * dirs[i] * (l.mmr - l.sign)
+		if dirs[i] < 0 {
+			falling = true
+		}
+	}
+	if slope <= 0 {
+		return 0, false // 该方向上净值相对维持保证金只增不减
+	}
+	x := (equity - maintenance) / slope
+	if falling && x >= 1 {
+		return 0, false // 需要价格跌到0以下，不会发生
+	}
+	return x, true
+}
+
+// hasBothSides 全仓持仓是否同时有多仓和空仓
+func hasBothSides(legs []marginLeg) bool {
+	long, short := false, false
+	for _, l := range legs {
+		if l.sign > 0 {
+			long = true
+		} else {
+			short = true
+		}
+	}
+	return long && short
+}
+
+// isolatedMargin 逐仓持仓占用的保证金（含未实现盈亏；交易所未返回时按名义价值/杠杆估算）
+func isolatedMargin(pos PositionInfo) float64 {
+	if pos.IsolatedMargin > 0 {
+		return pos.IsolatedMargin
+	}
+	return math.Max(pos.MarginUsed+pos.UnrealizedPnL, 0)
+}
+
+// isolatedLiquidationDistance 逐仓持仓到强平价的不利价格变动(%)（没有强平价时返回-1）
+func isolatedLiquidationDistance(pos PositionInfo) float64 {
+	if pos.LiquidationPrice <= 0 || pos.MarkPrice <= 0 {
+		return -1
+	}
+	if pos.Side == "long" {
+		return math.Max((pos.MarkPrice-pos.LiquidationPrice)/pos.MarkPrice*100, 0)
+	}
+	return math.Max((pos.LiquidationPrice-pos.MarkPrice)/pos.MarkPrice*100, 0)
+}
@@ -0,0 +1,139 @@
+package decision
+
+import (
+	"log"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// breadthDominanceFlatBand BTC市值占比24h变化小于该值(百分点)视为持平
+const breadthDominanceFlatBand = 0.3
+
+// BTC市值占比趋势
+const (
+	DominanceRising  = "rising"
+	DominanceFalling = "falling"
+	DominanceFlat    = "flat"
+)
+
+// MarketBreadth 市场广度（全市场视角，补充单看BTCUSDT走势的不足）
+type MarketBreadth struct {
+	BTCDominance         float64 `json:"btc_dominance"`           // BTC市值占比(%)，获取失败时为0
+	DominanceChange1h    float64 `json:"dominance_change_1h"`     // 1小时变化(百分点)
+	DominanceChange24h   float64 `json:"dominance_change_24h"`    // 24小时变化(百分点)
+	DominanceTrend       string  `json:"dominance_trend"`         // rising, falling, flat（历史不足时为空）
+	AboveEMA50Count      int     `json:"above_ema50_count"`       // 价格在EMA50上方的币种数
+	SymbolCount          int     `json:"symbol_count"`            // 参与统计的币种数
+	AboveEMA50Pct        float64 `json:"above_ema50_pct"`         // 价格在EMA50上方的币种比例(%)
+	AggregateOIChangePct float64 `json:"aggregate_oi_change_pct"` // 按持仓价值加权的1小时OI变化(%)
+	OISymbolCount        int     `json:"oi_symbol_count"`         // 参与OI统计的币种数（有持仓量历史的币种）
+}
+
+// ComputeMarketBreadth 计算市场广度（BTC市值占比趋势、EMA50上方币种比例、全市场OI变化）
+func ComputeMarketBreadth(ctx *Context) *MarketBreadth {
+	breadth := &MarketBreadth{}
+	now := ctx.now()
+
+	// 1. 价格在4小时EMA50上方的币种比例
+	for _, data := range ctx.MarketDataMap {
+		if data.LongerTermContext == nil || data.LongerTermContext.EMA50 <= 0 {
+			continue
+		}
+		breadth.SymbolCount++
+		if data.CurrentPrice > data.LongerTermContext.EMA50 {
+			breadth.AboveEMA50Count++
+		}
+	}
+	if breadth.SymbolCount > 0 {
+		breadth.AboveEMA50Pct = float64(breadth.AboveEMA50Count) / float64(breadth.SymbolCount) * 100
+	}
+
+	// 2. 全市场1小时OI变化（按持仓价值加权）
+	var weightedChange, totalValue float64
+	for _, data := range ctx.MarketDataMap {
+		if data.OpenInterest == nil || data.OpenInterest.Latest <= 0 || len(data.OpenInterest.Series) == 0 {
+			continue
+		}
+		value := data.OpenInterest.Latest * data.CurrentPrice
+		weightedChange += data.OpenInterest.Change1h * value
+		totalValue += value
+		breadth.OISymbolCount++
+	}
+	if totalValue > 0 {
+		breadth.AggregateOIChangePct = weightedChange / totalValue
+	}
+
+	// 3. BTC市值占比及其趋势（历史值来自之前周期保存的市场广度）
+	dominance, err := market.GetBTCDominance()
+	if err != nil {
+		log.Printf("⚠️  获取BTC市值占比失败: %v", err)
+		return breadth
+	}
+	breadth.BTCDominance = dominance
+
+	if ctx.DecisionLogger == nil {
+		return breadth
+	}
+	db := ctx.DecisionLogger.GetDB()
+	if db == nil {
+		return breadth
+	}
+	if prev, ok, err := db.MarketBreadth().GetDominanceAt(now.Add(-time.Hour)); err == nil && ok {
+		breadth.DominanceChange1h = dominance - prev
+	}
+	if prev, ok, err := db.MarketBreadth().GetDominanceAt(now.Add(-24 * time.Hour)); err == nil && ok {
+		breadth.DominanceChange24h = dominance - prev
+		switch {
+		case breadth.DominanceChange24h >= breadthDominanceFlatBand:
+			breadth.DominanceTrend = DominanceRising
+		case breadth.DominanceChange24h <= -breadthDominanceFlatBand:
+			breadth.DominanceTrend = DominanceFalling
+		default:
+			breadth.DominanceTrend = DominanceFlat
+		}
+	}
+	return breadth
+}
+
+// formatMarketBreadth 市场广度Prompt内容
+func formatMarketBreadth(b *MarketBreadth, lang i18n.Lang) string {
+	var sb strings.Builder
+
+	if b.BTCDominance > 0 {
+		sb.WriteString(i18n.T(lang, "breadth.dominance", b.BTCDominance))
+		switch b.DominanceTrend {
+		case DominanceRising:
+			sb.WriteString(i18n.T(lang, "breadth.rising", b.DominanceChange1h, b.DominanceChange24h))
+		case DominanceFalling:
+			sb.WriteString(i18n.T(lang, "breadth.falling", b.DominanceChange1h, b.DominanceChange24h))
+		case DominanceFlat:
+			sb.WriteString(i18n.T(lang, "breadth.flat", b.DominanceChange1h, b.DominanceChange24h))
+		default:
+			sb.WriteString(i18n.T(lang, "breadth.no_history"))
+		}
+	} else {
+		sb.WriteString(i18n.T(lang, "breadth.unavailable"))
+	}
+
+	if b.SymbolCount > 0 {
+		sb.WriteString(i18n.T(lang, "breadth.above_ema", b.AboveEMA50Count, b.SymbolCount, b.AboveEMA50Pct))
+		switch {
+		case b.AboveEMA50Pct >= 70:
+			sb.WriteString(i18n.T(lang, "breadth.broad_up"))
+		case b.AboveEMA50Pct <= 30:
+			sb.WriteString(i18n.T(lang, "breadth.broad_down"))
+		default:
+			sb.WriteString(i18n.T(lang, "breadth.mixed"))
+		}
+	}
+
+	if b.OISymbolCount > 0 {
+		sb.WriteString(i18n.T(lang, "breadth.oi",
+			b.AggregateOIChangePct, b.OISymbolCount))
+	} else {
+		sb.WriteString(i18n.T(lang, "breadth.oi_unavailable"))
+	}
+	return sb.String()
+}
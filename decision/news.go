@@ -0,0 +1,48 @@
+package decision
+
+import (
+	"log"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+)
+
+// collectHeadlines 获取持仓币种和候选币种的近期新闻（未启用新闻时返回nil）
+func collectHeadlines(ctx *Context) map[string][]market.Headline {
+	if !market.News.Enabled {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var symbols []string
+	add := func(symbol string) {
+		if _, ok := ctx.MarketDataMap[symbol]; ok && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	for _, pos := range ctx.Positions {
+		add(pos.Symbol)
+	}
+	for _, coin := range ctx.CandidateCoins {
+		add(coin.Symbol)
+	}
+
+	headlines := market.GetHeadlines(symbols)
+	if len(headlines) > 0 {
+		log.Printf("📰 近期新闻: %d/%d 个币种有新闻", len(headlines), len(symbols))
+	}
+	return headlines
+}
+
+// formatHeadlines 币种近期新闻的Prompt内容（使用绝对时间，保证相同数据生成相同的prompt）
+func formatHeadlines(headlines []market.Headline, lang i18n.Lang) string {
+	if len(headlines) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "news.title"))
+	for _, h := range headlines {
+		sb.WriteString(i18n.T(lang, "news.item", h.PublishedAt.UTC().Format("01-02 15:04"), h.Title, h.Source))
+	}
+	return sb.String()
+}
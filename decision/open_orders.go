@@ -0,0 +1,41 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/i18n"
+	"strings"
+)
+
+// formatPositionOrders 持仓在交易所的挂单（类型、触发价、数量），没有止损单时提示AI设置
+func formatPositionOrders(pos PositionInfo, lang i18n.Lang) string {
+	if pos.OpenOrders == nil {
+		return ""
+	}
+	if len(pos.OpenOrders) == 0 {
+		return i18n.T(lang, "position.no_orders")
+	}
+
+	parts := make([]string, 0, len(pos.OpenOrders))
+	hasStop := false
+	for _, o := range pos.OpenOrders {
+		if o.Type == "stop_loss" {
+			hasStop = true
+		}
+		qty := i18n.T(lang, "position.order_full")
+		if o.Quantity > 0 {
+			qty = fmt.Sprintf("%.4f", o.Quantity)
+		}
+		price := o.TriggerPrice
+		if price == 0 {
+			price = o.Price
+		}
+		parts = append(parts, i18n.T(lang, "position.order_item", i18n.T(lang, "position.order_type."+o.Type), price, qty, o.OrderID))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "position.orders", strings.Join(parts, " | ")))
+	if !hasStop {
+		sb.WriteString(i18n.T(lang, "position.no_stop_order"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/i18n"
+	"strings"
+)
+
+// maxOrderErrorLen 提示词中单条错误信息的最大长度（交易所返回的错误可能很长）
+const maxOrderErrorLen = 120
+
+// OrderErrorStat 近期同一类交易所下单错误的统计（由trader从决策动作记录中汇总）
+type OrderErrorStat struct {
+	Class      string // insufficient_margin/reduce_only/rate_limit/precision/permission
+	Count      int
+	LastSymbol string
+	LastAction string
+	LastError  string
+}
+
+// formatOrderErrors 近期最常见的下单错误及规避建议（避免AI重复提交会被交易所拒绝的订单）
+func formatOrderErrors(ctx *Context) string {
+	if len(ctx.RecentOrderErrors) == 0 {
+		return ""
+	}
+	lang := ctx.lang()
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "order_errors.title"))
+	for _, e := range ctx.RecentOrderErrors {
+		msg := []rune(e.LastError)
+		if len(msg) > maxOrderErrorLen {
+			msg = append(msg[:maxOrderErrorLen], []rune("...")...)
+		}
+		sb.WriteString(i18n.T(lang, "order_errors.item", e.Class, e.Count, e.LastSymbol, e.LastAction, string(msg)))
+		if hint := "order_errors.hint." + e.Class; i18n.Has(lang, hint) {
+			sb.WriteString(fmt.Sprintf("  → %s\n", i18n.T(lang, hint)))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package decision
+
+import (
+	"math"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+)
+
+// exitProximity 持仓当前价距止损/止盈的距离（%），返回最近的一侧（stop_loss/take_profit）
+func exitProximity(pos PositionInfo) (string, float64, bool) {
+	if pos.MarkPrice <= 0 {
+		return "", 0, false
+	}
+	label, nearest := "", math.Inf(1)
+	if pos.StopLoss > 0 {
+		if dist := math.Abs(pos.MarkPrice-pos.StopLoss) / pos.MarkPrice * 100; dist < nearest {
+			label, nearest = "stop_loss", dist
+		}
+	}
+	if pos.TakeProfit > 0 {
+		if dist := math.Abs(pos.TakeProfit-pos.MarkPrice) / pos.MarkPrice * 100; dist < nearest {
+			label, nearest = "take_profit", dist
+		}
+	}
+	return label, nearest, label != ""
+}
+
+// collectOrderFlow 为接近止损/止盈的持仓获取订单流信号
+func collectOrderFlow(positions []PositionInfo) map[string]*market.OrderFlowStats {
+	if !market.OrderFlow.Enabled {
+		return nil
+	}
+	result := make(map[string]*market.OrderFlowStats)
+	for _, pos := range positions {
+		if _, dist, ok := exitProximity(pos); !ok || dist > market.OrderFlow.ProximityPct {
+			continue
+		}
+		if stats := market.GetOrderFlowStats(pos.Symbol); stats != nil && stats.Connected {
+			result[pos.Symbol] = stats
+		}
+	}
+	return result
+}
+
+// formatOrderFlowSignal 接近止损/止盈的持仓的订单流Prompt内容
+func formatOrderFlowSignal(pos PositionInfo, stats *market.OrderFlowStats, lang i18n.Lang) string {
+	side, dist, _ := exitProximity(pos)
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "orderflow.line",
+		i18n.T(lang, "orderflow."+side), dist, stats.BookImbalance, stats.OFI1m, stats.OFI5m,
+		stats.AggBuyRatio1m, stats.AggBuyRatio5m, stats.TradeNotional1m/1e6, stats.TradeNotional5m/1e6))
+	if stats.CoverageSeconds < 300 {
+		sb.WriteString(i18n.T(lang, "orderflow.coverage", stats.CoverageSeconds))
+	}
+	sb.WriteString("\n")
+
+	// 订单流方向是否支持持仓（正值=买方压力）
+	pressure := (stats.OFI1m + stats.BookImbalance) / 2
+	if pos.Side == "short" {
+		pressure = -pressure
+	}
+	switch {
+	case pressure > 0.2:
+		sb.WriteString(i18n.T(lang, "orderflow.supportive"))
+	case pressure < -0.2:
+		sb.WriteString(i18n.T(lang, "orderflow.against"))
+	default:
+		sb.WriteString(i18n.T(lang, "orderflow.neutral"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,72 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PositionSlots 持仓名额（最大持仓数 - 当前持仓数）
+type PositionSlots struct {
+	Max       int // 最大持仓数（0表示不限制）
+	Held      int // 当前持仓数
+	Remaining int // 剩余可开仓名额（不含本周期平仓释放的名额）
+}
+
+// CalculatePositionSlots 计算当前持仓名额
+func CalculatePositionSlots(ctx *Context) PositionSlots {
+	slots := PositionSlots{Max: ctx.MaxPositions, Held: len(ctx.Positions)}
+	if slots.Max > 0 {
+		slots.Remaining = slots.Max - slots.Held
+		if slots.Remaining < 0 {
+			slots.Remaining = 0
+		}
+	}
+	return slots
+}
+
+// checkPositionSlots 检查本批决策的新开仓数是否超过持仓名额
+// 平仓先于开仓执行（见 trader.sortDecisionsByPriority），因此本批中针对现有持仓的平仓决策释放的名额可用于开仓
+// 只统计不重复、且扣除本批平仓后仍未持有的 symbol_side：同币种同方向已有持仓的开仓不占用新名额（执行时会拒绝叠加）
+func checkPositionSlots(decisions []Decision, ctx *Context) error {
+	slots := CalculatePositionSlots(ctx)
+	if slots.Max <= 0 {
+		return nil
+	}
+
+	held := make(map[string]bool, len(ctx.Positions))
+	for _, pos := range ctx.Positions {
+		held[pos.Symbol+"_"+pos.Side] = true
+	}
+
+	freed := make(map[string]bool)
+	for _, d := range decisions {
+		if d.Action != "close_long" && d.Action != "close_short" {
+			continue
+		}
+		key := d.Symbol + "_" + strings.TrimPrefix(d.Action, "close_")
+		if held[key] {
+			freed[key] = true
+		}
+	}
+
+	newKeys := make(map[string]bool)
+	var opens []string
+	for _, d := range decisions {
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		key := d.Symbol + "_" + strings.TrimPrefix(d.Action, "open_")
+		if (held[key] && !freed[key]) || newKeys[key] {
+			continue
+		}
+		newKeys[key] = true
+		opens = append(opens, d.Symbol+" "+d.Action)
+	}
+
+	available := slots.Max - slots.Held + len(freed)
+	if len(opens) > available {
+		return fmt.Errorf("开仓决策 %d 个（%s）超过持仓名额：最大持仓%d，当前持仓%d，本周期平仓释放%d，最多可开%d个",
+			len(opens), strings.Join(opens, ", "), slots.Max, slots.Held, len(freed), max(available, 0))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package decision
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckPositionSlots(t *testing.T) {
+	ctx := &Context{
+		MaxPositions: 3,
+		Positions: []PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long"},
+			{Symbol: "ETHUSDT", Side: "short"},
+		},
+	}
+	tests := []struct {
+		name      string
+		decisions []Decision
+		wantErr   bool
+	}{
+		{"one_new", []Decision{{Symbol: "SOLUSDT", Action: "open_long"}}, false},
+		{"two_new", []Decision{{Symbol: "SOLUSDT", Action: "open_long"}, {Symbol: "BNBUSDT", Action: "open_short"}}, true},
+		// 同一币种方向重复开仓只占一个名额
+		{"duplicate_open", []Decision{{Symbol: "SOLUSDT", Action: "open_long"}, {Symbol: "SOLUSDT", Action: "open_long"}}, false},
+		// 已持有的币种方向不占用新名额
+		{"already_held", []Decision{{Symbol: "BTCUSDT", Action: "open_long"}, {Symbol: "SOLUSDT", Action: "open_long"}}, false},
+		// 平仓释放名额
+		{"close_frees_slot", []Decision{
+			{Symbol: "ETHUSDT", Action: "close_short"},
+			{Symbol: "SOLUSDT", Action: "open_long"}, {Symbol: "BNBUSDT", Action: "open_short"},
+		}, false},
+		// 平仓后反向开仓是新的持仓
+		{"close_and_reverse", []Decision{
+			{Symbol: "ETHUSDT", Action: "close_short"},
+			{Symbol: "ETHUSDT", Action: "open_long"}, {Symbol: "SOLUSDT", Action: "open_long"},
+		}, false},
+		// 平仓后同方向重新开仓占用释放的名额
+		{"close_and_reopen", []Decision{
+			{Symbol: "ETHUSDT", Action: "close_short"},
+			{Symbol: "ETHUSDT", Action: "open_short"}, {Symbol: "SOLUSDT", Action: "open_long"}, {Symbol: "BNBUSDT", Action: "open_long"},
+		}, true},
+		// 平掉未持有的仓位不释放名额
+		{"close_not_held", []Decision{
+			{Symbol: "XRPUSDT", Action: "close_long"},
+			{Symbol: "SOLUSDT", Action: "open_long"}, {Symbol: "BNBUSDT", Action: "open_short"},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPositionSlots(tt.decisions, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPositionSlots() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarkBatchRejection(t *testing.T) {
+	decisions := []Decision{
+		{Symbol: "BTCUSDT", Action: "close_long"},
+		{Symbol: "SOLUSDT", Action: "open_long"},
+		{Symbol: "BNBUSDT", Action: "open_short"},
+	}
+	markBatchRejection(decisions, errors.New("SOLUSDT 今日已开仓3次"))
+	if decisions[0].ValidationError != "" || decisions[2].ValidationError != "" {
+		t.Errorf("错误信息未提到的决策不应标记: %+v", decisions)
+	}
+	if decisions[1].ValidationError == "" {
+		t.Errorf("错误信息提到的开仓决策应标记")
+	}
+
+	// 错误信息未提到任何币种时标记全部开仓决策，平仓决策不受影响
+	decisions[1].ValidationError = ""
+	markBatchRejection(decisions, errors.New("超出每日开仓总次数上限"))
+	if decisions[0].ValidationError != "" || decisions[1].ValidationError == "" || decisions[2].ValidationError == "" {
+		t.Errorf("应只标记全部开仓决策: %+v", decisions)
+	}
+}
@@ -0,0 +1,108 @@
+package decision
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PromptCache 重复Prompt抑制缓存
+// 市场状态几乎没有变化时（休市、周末等），相同的Prompt会重复消耗token，
+// 此时在时间窗口内直接复用上一周期的决策（标记为cached），不再调用AI
+type PromptCache struct {
+	mu          sync.Mutex
+	hash        string
+	positionKey string
+	decision    *FullDecision
+	storedAt    time.Time
+}
+
+// NewPromptCache 创建Prompt缓存
+func NewPromptCache() *PromptCache {
+	return &PromptCache{}
+}
+
+// Lookup 查找可复用的决策（Prompt哈希一致、持仓未变化且在时间窗口内）
+func (c *PromptCache) Lookup(hash, positionKey string, window time.Duration) *FullDecision {
+	if c == nil || window <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.decision == nil || c.hash != hash || c.positionKey != positionKey {
+		return nil
+	}
+	if time.Since(c.storedAt) > window {
+		return nil
+	}
+
+	// 返回副本，避免调用方修改缓存内容
+	cached := *c.decision
+	cached.Decisions = append([]Decision(nil), c.decision.Decisions...)
+	cached.Cached = true
+	cached.Timestamp = time.Now()
+	return &cached
+}
+
+// Store 保存本周期的决策
+// 只缓存不包含开平仓动作的决策（hold/wait），避免重复执行未成交的交易指令
+func (c *PromptCache) Store(hash, positionKey string, decision *FullDecision) {
+	if c == nil || decision == nil || hash == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, d := range decision.Decisions {
+		if d.Action != "hold" && d.Action != "wait" {
+			c.decision = nil
+			return
+		}
+	}
+
+	c.hash = hash
+	c.positionKey = positionKey
+	c.decision = decision
+	c.storedAt = time.Now()
+}
+
+// promptHashClock 计算哈希时使用的固定时钟
+// 持仓时长、距资金费结算时间、当前时段等相对时间文本每个周期都会变化，统一按同一时刻渲染
+type promptHashClock struct{}
+
+func (promptHashClock) Now() time.Time { return time.Unix(0, 0).UTC() }
+
+// computePromptHash 计算Prompt内容哈希
+// 时间、周期编号、运行时长每个周期都会变化，计算哈希前先清空这些字段，并以固定时钟渲染
+func computePromptHash(ctx *Context, systemPrompt string) (string, error) {
+	normalized := *ctx
+	normalized.CurrentTime = ""
+	normalized.CallCount = 0
+	normalized.RuntimeMinutes = 0
+	normalized.Clock = promptHashClock{}
+
+	userPrompt, err := buildUserPrompt(&normalized)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(systemPrompt + "\n---\n" + userPrompt))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// positionFingerprint 持仓指纹（币种+方向+数量），用于判断持仓是否发生变化
+func positionFingerprint(positions []PositionInfo) string {
+	keys := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		keys = append(keys, fmt.Sprintf("%s_%s_%.8f", pos.Symbol, pos.Side, pos.Quantity))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "|")
+}
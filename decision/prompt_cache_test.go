@@ -0,0 +1,51 @@
+package decision
+
+import (
+	"nofx/harness"
+	"nofx/i18n"
+	"testing"
+	"time"
+)
+
+// TestPromptCacheHitsAcrossCycles 市场状态相同的两个周期（时间、周期编号不同）应命中缓存
+func TestPromptCacheHitsAcrossCycles(t *testing.T) {
+	ctx := newGoldenContext(t, i18n.ZH)
+	const systemPrompt = "system"
+
+	first, err := computePromptHash(ctx, systemPrompt)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	cache := NewPromptCache()
+	positionKey := positionFingerprint(ctx.Positions)
+	cache.Store(first, positionKey, &FullDecision{Decisions: []Decision{{Symbol: "BTCUSDT", Action: "hold"}}})
+
+	// 下一周期：3分钟后，持仓时长、资金费倒计时、运行时长都变化
+	next := goldenNow.Add(3 * time.Minute)
+	ctx.Clock = harness.NewFakeClock(next)
+	ctx.CurrentTime = next.Format("2006-01-02 15:04:05")
+	ctx.CallCount++
+	ctx.RuntimeMinutes += 3
+
+	second, err := computePromptHash(ctx, systemPrompt)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	if second != first {
+		t.Fatalf("市场状态未变化时哈希应一致: %s vs %s", first, second)
+	}
+	cached := cache.Lookup(second, positionKey, 10*time.Minute)
+	if cached == nil || !cached.Cached {
+		t.Fatal("第二个周期应复用缓存的决策")
+	}
+
+	// 行情变化后不应命中
+	ctx.Positions[0].MarkPrice += 100
+	changed, err := computePromptHash(ctx, systemPrompt)
+	if err != nil {
+		t.Fatalf("计算哈希失败: %v", err)
+	}
+	if changed == first {
+		t.Error("行情变化后哈希应不同")
+	}
+}
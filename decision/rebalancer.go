@@ -0,0 +1,225 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/i18n"
+	"nofx/market"
+	"sort"
+	"strings"
+)
+
+// RebalanceTargets 组合敞口目标（由trader从运行时配置填充，nil表示不启用）
+type RebalanceTargets struct {
+	MaxSymbolPct  float64           // 单币种占总敞口的最大比例(%)
+	MaxSectorPct  float64           // 单板块占总敞口的最大比例(%)
+	MaxNetBiasPct float64           // 最大净方向偏离(%) = |多头-空头| / 总敞口
+	MinPositions  int               // 持仓数达到该值才检查集中度（单一持仓必然100%集中）
+	Enforce       bool              // 新开仓加剧失衡时是否拒绝（false只记录警告）
+	SectorMap     map[string]string // 基础币种 → 板块（覆盖内置映射）
+}
+
+// defaultSectors 内置板块映射（未收录的币种单独作为一个板块）
+var defaultSectors = map[string]string{
+	"BTC": "主流币", "ETH": "主流币",
+	"SOL": "公链", "AVAX": "公链", "ADA": "公链", "DOT": "公链", "NEAR": "公链", "APT": "公链",
+	"SUI": "公链", "TRX": "公链", "TON": "公链", "SEI": "公链", "ATOM": "公链",
+	"ARB": "L2", "OP": "L2", "POL": "L2", "MATIC": "L2", "STRK": "L2",
+	"DOGE": "Meme", "SHIB": "Meme", "PEPE": "Meme", "WIF": "Meme", "BONK": "Meme", "FLOKI": "Meme",
+	"1000PEPE": "Meme", "1000SHIB": "Meme", "1000BONK": "Meme", "1000FLOKI": "Meme",
+	"UNI": "DeFi", "AAVE": "DeFi", "CRV": "DeFi", "MKR": "DeFi", "LDO": "DeFi", "LINK": "DeFi", "ENA": "DeFi",
+	"BNB": "平台币", "OKB": "平台币",
+	"FET": "AI", "RENDER": "AI", "TAO": "AI", "WLD": "AI",
+	"XRP": "支付", "LTC": "支付", "BCH": "支付", "XLM": "支付",
+}
+
+// SectorOf 币种所属板块
+func (t *RebalanceTargets) SectorOf(symbol string) string {
+	base := market.ParseSymbolQuote(symbol).Base
+	if base == "" {
+		base = symbol
+	}
+	if t != nil {
+		if sector, ok := t.SectorMap[base]; ok && sector != "" {
+			return sector
+		}
+	}
+	if sector, ok := defaultSectors[base]; ok {
+		return sector
+	}
+	return base
+}
+
+// ExposureReport 组合敞口分布
+type ExposureReport struct {
+	Gross       float64            // 总敞口(USDT，多空绝对值之和)
+	Long        float64            // 多头敞口
+	Short       float64            // 空头敞口
+	Positions   int                // 持仓数
+	BySymbol    map[string]float64 // 币种 → 敞口
+	BySector    map[string]float64 // 板块 → 敞口
+	Suggestions []string           // 超出目标时的再平衡建议
+}
+
+// pct 敞口占总敞口的百分比
+func (r *ExposureReport) pct(v float64) float64 {
+	if r.Gross <= 0 {
+		return 0
+	}
+	return v / r.Gross * 100
+}
+
+// NetBiasPct 净方向偏离(%)，正数偏多、负数偏空
+func (r *ExposureReport) NetBiasPct() float64 {
+	return r.pct(r.Long - r.Short)
+}
+
+// add 计入一笔敞口
+func (r *ExposureReport) add(targets *RebalanceTargets, symbol, side string, notional float64) {
+	r.Gross += notional
+	if side == "long" {
+		r.Long += notional
+	} else {
+		r.Short += notional
+	}
+	r.BySymbol[symbol] += notional
+	r.BySector[targets.SectorOf(symbol)] += notional
+}
+
+// exposureOf 计算持仓的敞口分布（不生成建议）
+func exposureOf(positions []PositionInfo, targets *RebalanceTargets) *ExposureReport {
+	report := &ExposureReport{
+		BySymbol: make(map[string]float64),
+		BySector: make(map[string]float64),
+	}
+	for _, pos := range positions {
+		report.add(targets, pos.Symbol, pos.Side, math.Abs(pos.Quantity)*pos.MarkPrice)
+		report.Positions++
+	}
+	return report
+}
+
+// AnalyzeExposure 计算当前持仓的敞口分布并生成再平衡建议
+func AnalyzeExposure(positions []PositionInfo, targets *RebalanceTargets, lang i18n.Lang) *ExposureReport {
+	report := exposureOf(positions, targets)
+	if targets == nil || report.Gross <= 0 {
+		return report
+	}
+
+	if report.Positions >= targets.MinPositions {
+		for _, symbol := range sortedKeys(report.BySymbol) {
+			if pct := report.pct(report.BySymbol[symbol]); targets.MaxSymbolPct > 0 && pct > targets.MaxSymbolPct {
+				reduce := report.BySymbol[symbol] - report.Gross*targets.MaxSymbolPct/100
+				report.Suggestions = append(report.Suggestions, i18n.T(lang,
+					"rebalance.symbol_over", symbol, pct, targets.MaxSymbolPct, reduce))
+			}
+		}
+		for _, sector := range sortedKeys(report.BySector) {
+			if pct := report.pct(report.BySector[sector]); targets.MaxSectorPct > 0 && pct > targets.MaxSectorPct {
+				report.Suggestions = append(report.Suggestions, i18n.T(lang,
+					"rebalance.sector_over", sector, pct, targets.MaxSectorPct))
+			}
+		}
+		if bias := report.NetBiasPct(); targets.MaxNetBiasPct > 0 && math.Abs(bias) > targets.MaxNetBiasPct {
+			key := "rebalance.net_long"
+			if bias < 0 {
+				key = "rebalance.net_short"
+			}
+			report.Suggestions = append(report.Suggestions, i18n.T(lang, key, math.Abs(bias), targets.MaxNetBiasPct))
+		}
+	}
+	return report
+}
+
+// sortedKeys 按敞口从大到小排列的键
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// formatRebalanceSuggestions 组合敞口分布和再平衡建议（无持仓或未启用时为空）
+func formatRebalanceSuggestions(ctx *Context) string {
+	if ctx.Rebalance == nil || len(ctx.Positions) == 0 {
+		return ""
+	}
+	lang := ctx.lang()
+	report := AnalyzeExposure(ctx.Positions, ctx.Rebalance, lang)
+	if report.Gross <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "rebalance.title"))
+	sb.WriteString(i18n.T(lang, "rebalance.exposure",
+		report.Gross, report.Long, report.Short, report.NetBiasPct()))
+	var sectors []string
+	for _, sector := range sortedKeys(report.BySector) {
+		sectors = append(sectors, fmt.Sprintf("%s %.0f%%", sector, report.pct(report.BySector[sector])))
+	}
+	sb.WriteString(i18n.T(lang, "rebalance.sectors") + strings.Join(sectors, " | ") + "\n")
+	if len(report.Suggestions) == 0 {
+		sb.WriteString(i18n.T(lang, "rebalance.balanced"))
+		return sb.String()
+	}
+	sb.WriteString(i18n.T(lang, "rebalance.suggestions"))
+	for _, s := range report.Suggestions {
+		sb.WriteString("- " + s + "\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// checkRebalanceImpact 检查新开仓是否使组合失衡超出目标且比当前更严重
+// 默认只记录警告，Enforce 时拒绝该决策
+func checkRebalanceImpact(d *Decision, ctx *Context) error {
+	targets := ctx.Rebalance
+	if targets == nil || (d.Action != "open_long" && d.Action != "open_short") || d.NotionalUSD <= 0 {
+		return nil
+	}
+
+	before := exposureOf(ctx.Positions, targets)
+	after := exposureOf(ctx.Positions, targets)
+	after.add(targets, d.Symbol, strings.TrimPrefix(d.Action, "open_"), d.NotionalUSD)
+	after.Positions++
+	if after.Positions < targets.MinPositions {
+		return nil
+	}
+
+	var problems []string
+	if targets.MaxSymbolPct > 0 {
+		if pct := after.pct(after.BySymbol[d.Symbol]); pct > targets.MaxSymbolPct && pct > before.pct(before.BySymbol[d.Symbol]) {
+			problems = append(problems, fmt.Sprintf("%s 占总敞口将达%.1f%%（上限%.0f%%）", d.Symbol, pct, targets.MaxSymbolPct))
+		}
+	}
+	if targets.MaxSectorPct > 0 {
+		sector := targets.SectorOf(d.Symbol)
+		if pct := after.pct(after.BySector[sector]); pct > targets.MaxSectorPct && pct > before.pct(before.BySector[sector]) {
+			problems = append(problems, fmt.Sprintf("板块[%s]占总敞口将达%.1f%%（上限%.0f%%）", sector, pct, targets.MaxSectorPct))
+		}
+	}
+	if targets.MaxNetBiasPct > 0 {
+		if bias := math.Abs(after.NetBiasPct()); bias > targets.MaxNetBiasPct && bias > math.Abs(before.NetBiasPct()) {
+			problems = append(problems, fmt.Sprintf("净方向偏离将达%.1f%%（上限%.0f%%）", bias, targets.MaxNetBiasPct))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s %s 加剧组合失衡: %s", d.Symbol, d.Action, strings.Join(problems, "；"))
+	if targets.Enforce {
+		return fmt.Errorf("%s", msg)
+	}
+	log.Printf("⚠️  [再平衡] %s", msg)
+	return nil
+}
@@ -0,0 +1,276 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+)
+
+// 市场状态（Regime）标签
+const (
+	RegimeTrending = "trending" // 趋势市
+	RegimeRanging  = "ranging"  // 震荡市
+	RegimeHighVol  = "high_vol" // 高波动
+	RegimeCrash    = "crash"    // 急跌/崩盘
+	RegimeUnknown  = "unknown"  // 数据不足
+)
+
+// 市场状态判定阈值
+const (
+	regimeADXPeriod          = 14
+	regimeTrendingADX        = 25.0 // 多周期平均ADX高于该值视为趋势市
+	regimeHighVolRatio       = 1.8  // 近期已实现波动率 / 全序列已实现波动率
+	regimeCrashChange1h      = -3.0 // BTC 1小时跌幅(%)
+	regimeCrashChange4h      = -6.0 // BTC 4小时跌幅(%)
+	regimeCrashCorrelation   = 0.7  // 候选币与BTC收益率平均相关性（普跌时相关性升高）
+	regimeRecentVolBars      = 20   // 计算近期波动率的K线数
+	regimeCorrelationMinBars = 10   // 计算相关性的最少K线数
+)
+
+// MarketRegime 市场状态检测结果
+type MarketRegime struct {
+	Label          string             `json:"label"`           // trending, ranging, high_vol, crash, unknown
+	ADX            map[string]float64 `json:"adx"`             // 各时间周期的BTC ADX
+	AvgADX         float64            `json:"avg_adx"`         // 多周期平均ADX
+	RealizedVol    float64            `json:"realized_vol"`    // 近期已实现波动率(%，单根K线收益率标准差)
+	VolRatio       float64            `json:"vol_ratio"`       // 近期波动率 / 全序列波动率
+	BTCCorrelation float64            `json:"btc_correlation"` // 候选币与BTC收益率的平均相关性
+	Reason         string             `json:"reason"`          // 判定依据
+}
+
+// DetectRegime 检测当前市场状态（基于BTC多周期ADX、已实现波动率和全市场与BTC的相关性）
+func (sma *SmartMarketAnalyzer) DetectRegime() MarketRegime {
+	regime := MarketRegime{Label: RegimeUnknown, ADX: make(map[string]float64)}
+
+	btcData, hasBTC := sma.ctx.MarketDataMap["BTCUSDT"]
+	if !hasBTC || btcData == nil {
+		regime.Reason = "缺少BTC市场数据"
+		return regime
+	}
+
+	// 1. 多周期ADX
+	for interval, klines := range regimeKlines(btcData) {
+		if adx := calculateADX(klines, regimeADXPeriod); adx > 0 {
+			regime.ADX[interval] = adx
+		}
+	}
+	if len(regime.ADX) > 0 {
+		total := 0.0
+		for _, adx := range regime.ADX {
+			total += adx
+		}
+		regime.AvgADX = total / float64(len(regime.ADX))
+	}
+
+	// 2. 已实现波动率（使用最短周期K线）
+	shortKlines := shortestKlines(btcData)
+	fullVol := realizedVolatility(shortKlines)
+	recent := shortKlines
+	if len(recent) > regimeRecentVolBars {
+		recent = recent[len(recent)-regimeRecentVolBars:]
+	}
+	regime.RealizedVol = realizedVolatility(recent)
+	if fullVol > 0 {
+		regime.VolRatio = regime.RealizedVol / fullVol
+	}
+
+	// 3. 候选币与BTC的相关性
+	regime.BTCCorrelation = sma.averageBTCCorrelation(shortKlines)
+
+	// 4. 判定（优先级：crash > high_vol > trending > ranging）
+	switch {
+	case (btcData.PriceChange1h <= regimeCrashChange1h || btcData.PriceChange4h <= regimeCrashChange4h) &&
+		regime.BTCCorrelation >= regimeCrashCorrelation:
+		regime.Label = RegimeCrash
+		regime.Reason = fmt.Sprintf("BTC 1h %.2f%% / 4h %.2f%%，全市场相关性 %.2f", btcData.PriceChange1h, btcData.PriceChange4h, regime.BTCCorrelation)
+	case regime.VolRatio >= regimeHighVolRatio:
+		regime.Label = RegimeHighVol
+		regime.Reason = fmt.Sprintf("近期波动率为常态的 %.1f 倍", regime.VolRatio)
+	case len(regime.ADX) == 0:
+		regime.Reason = "K线数据不足，无法计算ADX"
+	case regime.AvgADX >= regimeTrendingADX:
+		regime.Label = RegimeTrending
+		regime.Reason = fmt.Sprintf("多周期平均ADX %.1f ≥ %.0f", regime.AvgADX, regimeTrendingADX)
+	default:
+		regime.Label = RegimeRanging
+		regime.Reason = fmt.Sprintf("多周期平均ADX %.1f < %.0f", regime.AvgADX, regimeTrendingADX)
+	}
+
+	return regime
+}
+
+// averageBTCCorrelation 计算候选币与BTC收益率的平均相关性
+func (sma *SmartMarketAnalyzer) averageBTCCorrelation(btcKlines []market.KlinePoint) float64 {
+	total := 0.0
+	count := 0
+	for symbol, data := range sma.ctx.MarketDataMap {
+		if symbol == "BTCUSDT" || data == nil {
+			continue
+		}
+		corr, ok := returnsCorrelation(btcKlines, shortestKlines(data))
+		if !ok {
+			continue
+		}
+		total += corr
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// regimeKlines 收集各时间周期的K线（优先使用多时间框架数据）
+func regimeKlines(data *market.Data) map[string][]market.KlinePoint {
+	result := make(map[string][]market.KlinePoint)
+	for _, tf := range data.AllTimeframes {
+		if tf != nil && len(tf.Klines) > 0 {
+			result[tf.Interval] = tf.Klines
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+	if data.IntradaySeries != nil && len(data.IntradaySeries.Klines) > 0 {
+		result["3m"] = data.IntradaySeries.Klines
+	}
+	if data.LongerTermContext != nil && len(data.LongerTermContext.Klines) > 0 {
+		result["4h"] = data.LongerTermContext.Klines
+	}
+	return result
+}
+
+// shortestKlines 返回最短周期的K线（日内数据）
+func shortestKlines(data *market.Data) []market.KlinePoint {
+	if data.IntradaySeries != nil && len(data.IntradaySeries.Klines) > 0 {
+		return data.IntradaySeries.Klines
+	}
+	if len(data.AllTimeframes) > 0 && data.AllTimeframes[0] != nil {
+		return data.AllTimeframes[0].Klines
+	}
+	return nil
+}
+
+// calculateADX 计算平均趋向指数（Wilder平滑）
+func calculateADX(klines []market.KlinePoint, period int) float64 {
+	if len(klines) < period*2+1 {
+		return 0
+	}
+
+	var trSum, plusDMSum, minusDMSum float64
+	var dxValues []float64
+	for i := 1; i < len(klines); i++ {
+		high, low, prevClose := klines[i].High, klines[i].Low, klines[i-1].Close
+		upMove := high - klines[i-1].High
+		downMove := klines[i-1].Low - low
+
+		plusDM, minusDM := 0.0, 0.0
+		if upMove > downMove && upMove > 0 {
+			plusDM = upMove
+		}
+		if downMove > upMove && downMove > 0 {
+			minusDM = downMove
+		}
+		tr := math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+
+		if i <= period {
+			trSum += tr
+			plusDMSum += plusDM
+			minusDMSum += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			trSum = trSum - trSum/float64(period) + tr
+			plusDMSum = plusDMSum - plusDMSum/float64(period) + plusDM
+			minusDMSum = minusDMSum - minusDMSum/float64(period) + minusDM
+		}
+
+		if trSum == 0 {
+			continue
+		}
+		plusDI := 100 * plusDMSum / trSum
+		minusDI := 100 * minusDMSum / trSum
+		if plusDI+minusDI == 0 {
+			dxValues = append(dxValues, 0)
+			continue
+		}
+		dxValues = append(dxValues, 100*math.Abs(plusDI-minusDI)/(plusDI+minusDI))
+	}
+
+	if len(dxValues) < period {
+		return 0
+	}
+	adx := 0.0
+	for _, dx := range dxValues[:period] {
+		adx += dx
+	}
+	adx /= float64(period)
+	for _, dx := range dxValues[period:] {
+		adx = (adx*float64(period-1) + dx) / float64(period)
+	}
+	return adx
+}
+
+// klineReturns 计算K线收盘价对数收益率(%)
+func klineReturns(klines []market.KlinePoint) []float64 {
+	var returns []float64
+	for i := 1; i < len(klines); i++ {
+		if klines[i-1].Close <= 0 || klines[i].Close <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(klines[i].Close/klines[i-1].Close)*100)
+	}
+	return returns
+}
+
+// realizedVolatility 已实现波动率（收益率标准差，%）
+func realizedVolatility(klines []market.KlinePoint) float64 {
+	returns := klineReturns(klines)
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// returnsCorrelation 计算两组K线收益率的皮尔逊相关系数（按末尾对齐）
+func returnsCorrelation(a, b []market.KlinePoint) (float64, bool) {
+	ra, rb := klineReturns(a), klineReturns(b)
+	n := len(ra)
+	if len(rb) < n {
+		n = len(rb)
+	}
+	if n < regimeCorrelationMinBars {
+		return 0, false
+	}
+	ra, rb = ra[len(ra)-n:], rb[len(rb)-n:]
+
+	var meanA, meanB float64
+	for i := 0; i < n; i++ {
+		meanA += ra[i]
+		meanB += rb[i]
+	}
+	meanA /= float64(n)
+	meanB /= float64(n)
+
+	var cov, varA, varB float64
+	for i := 0; i < n; i++ {
+		da, db := ra[i]-meanA, rb[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varA*varB), true
+}
@@ -0,0 +1,54 @@
+package decision
+
+import "fmt"
+
+// AICaller 调用AI模型（system + user prompt → 原始响应）
+type AICaller func(systemPrompt, userPrompt string) (string, error)
+
+// ReplayDecision 离线回放的一次决策结果
+type ReplayDecision struct {
+	Decisions []Decision // 通过验证的决策
+	Rejected  []string   // 未通过验证的决策及原因
+}
+
+// GetReplayDecision 用回放的行情快照获取AI决策（不拉取实时行情、不复用缓存）
+// ctx.MarketDataMap 由调用方按回放时点预先填充；systemPrompt为空时从数据库按当前配置构建
+// 与实时周期不同，单个决策验证失败只剔除该决策，不作废整批决策
+func GetReplayDecision(ctx *Context, call AICaller, systemPrompt string) (*ReplayDecision, error) {
+	regime := NewSmartMarketAnalyzer(ctx).DetectRegime()
+	ctx.Regime = &regime
+
+	if systemPrompt == "" {
+		db := ctx.DecisionLogger.GetDB()
+		if db == nil {
+			return nil, fmt.Errorf("数据库连接不可用，无法构建提示词")
+		}
+		smartRisk := CalculateSmartRiskParams(ctx)
+		actualMaxBTC, actualMaxAlt := systemPromptPositionCaps(ctx, smartRisk)
+		systemPrompt = db.BuildSystemPromptFromDB(ctx.lang(), ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, actualMaxBTC, actualMaxAlt, ctx.AIAutonomyMode)
+	}
+	userPrompt, err := buildUserPrompt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("构建用户提示词失败: %w", err)
+	}
+
+	aiResponse, err := call(systemPrompt, userPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("调用AI API失败: %w", err)
+	}
+	full, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		return nil, fmt.Errorf("解析AI响应失败: %w", err)
+	}
+
+	result := &ReplayDecision{}
+	for i := range full.Decisions {
+		d := full.Decisions[i]
+		if err := ValidateDecision(&d, ctx); err != nil {
+			result.Rejected = append(result.Rejected, fmt.Sprintf("%s %s: %v", d.Symbol, d.Action, err))
+			continue
+		}
+		result.Decisions = append(result.Decisions, d)
+	}
+	return result, nil
+}
@@ -0,0 +1,190 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+)
+
+// RiskGroup 币种分组的风控额度（由trader从运行时配置填充）
+type RiskGroup struct {
+	Name           string
+	Symbols        []string // 基础币种，为空表示收纳其他分组未列出的币种
+	MaxPositions   int      // 组内最大同时持仓数，0表示不限制
+	MaxNotionalUSD float64  // 组内最大名义敞口(USDT)，0表示不限制
+	MaxLeverage    int      // 组内杠杆上限，0表示不限制
+}
+
+// RiskGroups 分组风控配置（nil表示不启用）
+type RiskGroups []RiskGroup
+
+// GroupOf 币种所属分组（未列出且没有收纳分组时返回nil，不受分组额度限制）
+func (groups RiskGroups) GroupOf(symbol string) *RiskGroup {
+	base := market.ParseSymbolQuote(symbol).Base
+	if base == "" {
+		base = symbol
+	}
+	var fallback *RiskGroup
+	for i := range groups {
+		if len(groups[i].Symbols) == 0 {
+			if fallback == nil {
+				fallback = &groups[i]
+			}
+			continue
+		}
+		for _, s := range groups[i].Symbols {
+			if strings.EqualFold(s, base) {
+				return &groups[i]
+			}
+		}
+	}
+	return fallback
+}
+
+// GroupExposure 分组的持仓占用
+type GroupExposure struct {
+	Group          string  `json:"group"`
+	Positions      int     `json:"positions"`
+	NotionalUSD    float64 `json:"notional_usd"`
+	MaxPositions   int     `json:"max_positions"`    // 0表示不限制
+	MaxNotionalUSD float64 `json:"max_notional_usd"` // 0表示不限制
+	MaxLeverage    int     `json:"max_leverage"`     // 0表示不限制
+}
+
+// CalculateGroupExposure 按分组统计持仓数和名义敞口（按配置顺序，包含空分组）
+func CalculateGroupExposure(positions []PositionInfo, groups RiskGroups) []GroupExposure {
+	if len(groups) == 0 {
+		return nil
+	}
+	exposures := make([]GroupExposure, len(groups))
+	index := make(map[string]int, len(groups))
+	for i, g := range groups {
+		exposures[i] = GroupExposure{
+			Group:          g.Name,
+			MaxPositions:   g.MaxPositions,
+			MaxNotionalUSD: g.MaxNotionalUSD,
+			MaxLeverage:    g.MaxLeverage,
+		}
+		index[g.Name] = i
+	}
+	for _, pos := range positions {
+		group := groups.GroupOf(pos.Symbol)
+		if group == nil {
+			continue
+		}
+		e := &exposures[index[group.Name]]
+		e.Positions++
+		e.NotionalUSD += math.Abs(pos.Quantity) * pos.MarkPrice
+	}
+	return exposures
+}
+
+// checkRiskGroupLimits 检查单个开仓决策是否超出所属分组的杠杆上限和名义敞口上限
+func checkRiskGroupLimits(d *Decision, ctx *Context) error {
+	if len(ctx.RiskGroups) == 0 || (d.Action != "open_long" && d.Action != "open_short") {
+		return nil
+	}
+	group := ctx.RiskGroups.GroupOf(d.Symbol)
+	if group == nil {
+		return nil
+	}
+	if group.MaxLeverage > 0 && d.Leverage > group.MaxLeverage {
+		return fmt.Errorf("%s 属于分组[%s]，杠杆上限%d倍，当前: %d", d.Symbol, group.Name, group.MaxLeverage, d.Leverage)
+	}
+	if group.MaxNotionalUSD > 0 {
+		held := 0.0
+		for _, pos := range ctx.Positions {
+			if ctx.RiskGroups.GroupOf(pos.Symbol) == group {
+				held += math.Abs(pos.Quantity) * pos.MarkPrice
+			}
+		}
+		if held+d.NotionalUSD > group.MaxNotionalUSD {
+			return fmt.Errorf("%s 属于分组[%s]，组内名义敞口将达%.0f USDT（已占用%.0f，上限%.0f）",
+				d.Symbol, group.Name, held+d.NotionalUSD, held, group.MaxNotionalUSD)
+		}
+	}
+	return nil
+}
+
+// checkRiskGroupSlots 检查本批开仓决策是否超出各分组的持仓数上限（同组平仓释放的名额可用于开仓）
+func checkRiskGroupSlots(decisions []Decision, ctx *Context) error {
+	if len(ctx.RiskGroups) == 0 {
+		return nil
+	}
+
+	held := make(map[string]bool, len(ctx.Positions))
+	for _, pos := range ctx.Positions {
+		held[pos.Symbol+"_"+pos.Side] = true
+	}
+
+	count := make(map[string]int)
+	for _, e := range CalculateGroupExposure(ctx.Positions, ctx.RiskGroups) {
+		count[e.Group] = e.Positions
+	}
+	freed := make(map[string]bool)
+	for _, d := range decisions {
+		if d.Action != "close_long" && d.Action != "close_short" {
+			continue
+		}
+		key := d.Symbol + "_" + strings.TrimPrefix(d.Action, "close_")
+		if group := ctx.RiskGroups.GroupOf(d.Symbol); group != nil && held[key] && !freed[key] {
+			freed[key] = true
+			count[group.Name]--
+		}
+	}
+	for _, d := range decisions {
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		group := ctx.RiskGroups.GroupOf(d.Symbol)
+		if group == nil {
+			continue
+		}
+		count[group.Name]++
+		if group.MaxPositions > 0 && count[group.Name] > group.MaxPositions {
+			return fmt.Errorf("%s %s 超出分组[%s]的持仓上限%d（平仓释放后组内将有%d个持仓）",
+				d.Symbol, d.Action, group.Name, group.MaxPositions, count[group.Name])
+		}
+	}
+	return nil
+}
+
+// formatRiskGroups 分组风控额度提示（各组剩余持仓名额、名义敞口和杠杆上限）
+func formatRiskGroups(ctx *Context) string {
+	exposures := CalculateGroupExposure(ctx.Positions, ctx.RiskGroups)
+	if len(exposures) == 0 {
+		return ""
+	}
+	lang := ctx.lang()
+	unlimited := i18n.T(lang, "groups.unlimited")
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "groups.title"))
+	sb.WriteString(i18n.T(lang, "groups.table_header"))
+	sb.WriteString("|---|---|---|---|---|\n")
+	for i, e := range exposures {
+		members := strings.Join(ctx.RiskGroups[i].Symbols, "/")
+		if members == "" {
+			members = i18n.T(lang, "groups.others")
+		}
+		var positions, notional string
+		leverage := unlimited
+		if e.MaxPositions > 0 {
+			positions = fmt.Sprintf("%d/%d", e.Positions, e.MaxPositions)
+		} else {
+			positions = fmt.Sprintf("%d/%s", e.Positions, unlimited)
+		}
+		if e.MaxNotionalUSD > 0 {
+			notional = fmt.Sprintf("%.0f/%.0f", e.NotionalUSD, e.MaxNotionalUSD)
+		} else {
+			notional = fmt.Sprintf("%.0f/%s", e.NotionalUSD, unlimited)
+		}
+		if e.MaxLeverage > 0 {
+			leverage = fmt.Sprintf("%dx", e.MaxLeverage)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", e.Group, members, positions, notional, leverage))
+	}
+	sb.WriteString(i18n.T(lang, "groups.rule"))
+	return sb.String()
+}
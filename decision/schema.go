@@ -0,0 +1,154 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 决策输出格式版本：
+//   - v1: 裸JSON数组，仓位只有 position_size_usd，只支持开平仓和hold/wait
+//   - v2: {"schema_version": 2, "decisions": [...]}，新增 notional_usd / margin_usd 和 update_stop_loss / update_take_profit
+//
+// 新增字段时递增版本号并为旧版本保留解析器，旧prompt或旧模型输出的格式仍能解析为当前Decision结构
+const CurrentSchemaVersion = 2
+
+// decisionParsers 各版本决策数组的解析器（输出统一为当前Decision结构）
+var decisionParsers = map[int]func(raw []byte) ([]Decision, error){
+	1: parseDecisionsV1,
+	2: parseDecisionsV2,
+}
+
+// decisionEnvelope 带版本号的决策输出
+type decisionEnvelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Decisions     json.RawMessage `json:"decisions"`
+}
+
+// legacyDecisionV1 v1格式的决策
+type legacyDecisionV1 struct {
+	Symbol          string  `json:"symbol"`
+	Action          string  `json:"action"`
+	Leverage        int     `json:"leverage"`
+	PositionSizeUSD float64 `json:"position_size_usd"`
+	StopLoss        float64 `json:"stop_loss"`
+	TakeProfit      float64 `json:"take_profit"`
+	Confidence      int     `json:"confidence"`
+	RiskUSD         float64 `json:"risk_usd"`
+	Reasoning       string  `json:"reasoning"`
+}
+
+// parseDecisionsV1 解析v1决策：position_size_usd 一直按名义价值下单，转换为 notional_usd
+func parseDecisionsV1(raw []byte) ([]Decision, error) {
+	var legacy []legacyDecisionV1
+	if err := json.Unmarshal(raw, &legacy); err != nil {
+		return nil, err
+	}
+	decisions := make([]Decision, 0, len(legacy))
+	for _, l := range legacy {
+		decisions = append(decisions, Decision{
+			Symbol:          l.Symbol,
+			Action:          l.Action,
+			Leverage:        l.Leverage,
+			PositionSizeUSD: l.PositionSizeUSD,
+			NotionalUSD:     l.PositionSizeUSD,
+			StopLoss:        l.StopLoss,
+			TakeProfit:      l.TakeProfit,
+			Confidence:      l.Confidence,
+			RiskUSD:         l.RiskUSD,
+			Reasoning:       l.Reasoning,
+		})
+	}
+	return decisions, nil
+}
+
+// parseDecisionsV2 解析v2（当前）决策
+func parseDecisionsV2(raw []byte) ([]Decision, error) {
+	var decisions []Decision
+	if err := json.Unmarshal(raw, &decisions); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// detectLegacyVersion 没有schema_version的裸数组：出现v2字段或动作时按v2解析，否则按v1
+func detectLegacyVersion(raw []byte) int {
+	var items []map[string]interface{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return CurrentSchemaVersion
+	}
+	for _, item := range items {
+		if _, ok := item["notional_usd"]; ok {
+			return 2
+		}
+		if _, ok := item["margin_usd"]; ok {
+			return 2
+		}
+		if action, _ := item["action"].(string); strings.HasPrefix(action, "update_") {
+			return 2
+		}
+	}
+	return 1
+}
+
+// findEnvelopeStart 查找带版本号的决策对象起始位置（-1表示输出为裸数组）
+func findEnvelopeStart(response string) int {
+	keyIdx := strings.Index(response, `"schema_version"`)
+	if keyIdx == -1 {
+		return -1
+	}
+	start := strings.LastIndex(response[:keyIdx], "{")
+	if start == -1 {
+		return -1
+	}
+	// 版本号对象必须在决策数组之前开始（避免把数组内某条决策误判为外层对象）
+	if arrayStart := strings.Index(response, "["); arrayStart != -1 && arrayStart < start {
+		return -1
+	}
+	return start
+}
+
+// findMatchingBrace 查找匹配的右花括号（跳过字符串中的括号）
+func findMatchingBrace(s string, start int) int {
+	if start >= len(s) || s[start] != '{' {
+		return -1
+	}
+	depth := 0
+	inString := false
+	for i := start; i < len(s); i++ {
+		switch {
+		case inString:
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				inString = false
+			}
+		case s[i] == '"':
+			inString = true
+		case s[i] == '{':
+			depth++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseVersionedDecisions 按版本号选择解析器
+func parseVersionedDecisions(raw []byte, version int) ([]Decision, int, error) {
+	parser, ok := decisionParsers[version]
+	if !ok {
+		log.Printf("⚠️  未知的决策格式版本 %d，按当前版本 %d 解析", version, CurrentSchemaVersion)
+		version = CurrentSchemaVersion
+		parser = decisionParsers[version]
+	}
+	decisions, err := parser(raw)
+	if err != nil {
+		return nil, version, fmt.Errorf("按v%d格式解析决策失败: %w", version, err)
+	}
+	return decisions, version, nil
+}
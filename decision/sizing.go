@@ -0,0 +1,42 @@
+package decision
+
+import "nofx/money"
+
+// 仓位字段语义：
+//   - notional_usd: 名义价值(USDT)，下单数量 = 名义价值 / 价格
+//   - margin_usd: 保证金(USDT)，名义价值 = 保证金 × 杠杆
+//   - position_size_usd: 兼容旧字段，执行层一直按名义价值下单，因此视为 notional_usd
+// 同时给出多个字段时优先级为 notional_usd > margin_usd > position_size_usd
+
+// ResolveNotionalUSD 决策的名义价值(USDT)
+func (d *Decision) ResolveNotionalUSD() float64 {
+	switch {
+	case d.NotionalUSD > 0:
+		return d.NotionalUSD
+	case d.MarginUSD > 0 && d.Leverage > 0:
+		return money.Mul(d.MarginUSD, float64(d.Leverage))
+	default:
+		return d.PositionSizeUSD
+	}
+}
+
+// ResolveMarginUSD 决策占用的保证金(USDT)
+func (d *Decision) ResolveMarginUSD() float64 {
+	if d.Leverage <= 0 {
+		return d.MarginUSD
+	}
+	return money.Div(d.ResolveNotionalUSD(), float64(d.Leverage))
+}
+
+// NormalizeSizing 统一仓位字段：按优先级解析后同时填充 notional_usd、margin_usd 和 position_size_usd
+// 之后验证、执行和记录都只使用 NotionalUSD / MarginUSD
+func (d *Decision) NormalizeSizing() {
+	if d.Action != "open_long" && d.Action != "open_short" {
+		return
+	}
+	notional := d.ResolveNotionalUSD()
+	margin := d.ResolveMarginUSD()
+	d.NotionalUSD = notional
+	d.MarginUSD = margin
+	d.PositionSizeUSD = notional
+}
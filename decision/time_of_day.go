@@ -0,0 +1,44 @@
+package decision
+
+import (
+	"nofx/analytics"
+	"nofx/i18n"
+	"strings"
+	"time"
+)
+
+// formatTimeOfDay 历史开仓时段表现提示（如 "02:00-06:00 UTC 开仓历史上亏损"），当前处于弱势时段时额外提醒
+// 只输出命中的时段名称，不输出具体时刻，同一时段内各周期的prompt保持一致
+func formatTimeOfDay(ctx *Context, now time.Time) string {
+	report := ctx.TimeOfDay
+	if report == nil || (len(report.Weak) == 0 && len(report.Strong) == 0) {
+		return ""
+	}
+
+	lang := ctx.lang()
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "time_of_day.title", report.Overall.Trades, report.Overall.WinRate))
+	var current []string
+	for _, w := range report.Weak {
+		sb.WriteString(i18n.T(lang, "time_of_day.weak", timeWindowLabel(lang, w), w.Trades, w.WinRate, w.AvgPnL))
+		if w.Contains(now) {
+			current = append(current, timeWindowLabel(lang, w))
+		}
+	}
+	for _, w := range report.Strong {
+		sb.WriteString(i18n.T(lang, "time_of_day.strong", timeWindowLabel(lang, w), w.Trades, w.WinRate, w.AvgPnL))
+	}
+	if len(current) > 0 {
+		sb.WriteString(i18n.T(lang, "time_of_day.now_weak", strings.Join(current, ", ")))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// timeWindowLabel 时段显示名称（星期几按prompt语言显示）
+func timeWindowLabel(lang i18n.Lang, w analytics.TimeWindow) string {
+	if w.Kind == analytics.TimeWindowWeekday {
+		return i18n.T(lang, "time_of_day.weekday."+strings.ToLower(w.Label[:3]))
+	}
+	return w.Label
+}
@@ -0,0 +1,37 @@
+package decision
+
+import (
+	"nofx/analytics"
+	"nofx/i18n"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTimeOfDayPrintsOnlyWindowLabel(t *testing.T) {
+	ctx := &Context{
+		Language: i18n.ZH,
+		TimeOfDay: &analytics.TimeOfDayReport{
+			Overall: analytics.TimeOfDayStats{Trades: 40, WinRate: 55},
+			Weak: []analytics.TimeWindow{{
+				Kind: analytics.TimeWindowHours, Label: "08:00-12:00 UTC", StartHour: 8, EndHour: 12,
+				TimeOfDayStats: analytics.TimeOfDayStats{Trades: 10, WinRate: 30, AvgPnL: -4.5},
+			}},
+		},
+	}
+	now := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	first := formatTimeOfDay(ctx, now)
+	if !strings.Contains(first, "当前处于弱势时段（08:00-12:00 UTC）") {
+		t.Fatalf("应提示当前弱势时段: %s", first)
+	}
+	if strings.Contains(first, "08:30") {
+		t.Errorf("不应输出当前时刻: %s", first)
+	}
+	// 同一弱势时段内的后续周期输出不变
+	if later := formatTimeOfDay(ctx, now.Add(3*time.Hour)); later != first {
+		t.Errorf("同一时段内输出不应变化:\n%s\nvs\n%s", first, later)
+	}
+	if outside := formatTimeOfDay(ctx, now.Add(4*time.Hour)); strings.Contains(outside, "当前处于弱势时段") {
+		t.Errorf("时段外不应提示: %s", outside)
+	}
+}
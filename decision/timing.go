@@ -0,0 +1,11 @@
+package decision
+
+// StageTimings 一个决策周期各阶段的耗时（毫秒）和行情获取情况
+type StageTimings struct {
+	MarketFetchMs   int64 `json:"market_fetch_ms"`   // 获取行情、市场状态、广度、爆仓和订单流
+	PromptBuildMs   int64 `json:"prompt_build_ms"`   // 风控参数计算和System/User Prompt构建
+	AILatencyMs     int64 `json:"ai_latency_ms"`     // 调用分析模型（含审核模型）
+	ParseValidateMs int64 `json:"parse_validate_ms"` // 解析AI输出、验证决策和质量评估
+	SymbolsFetched  int   `json:"symbols_fetched"`   // 成功获取行情的币种数
+	SymbolsFailed   int   `json:"symbols_failed"`    // 获取行情失败的币种数
+}
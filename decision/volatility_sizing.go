@@ -0,0 +1,122 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+)
+
+// SizingTargets 基于ATR的仓位建议参数（由trader从运行时配置填充，nil表示不在prompt中提供）
+type SizingTargets struct {
+	RiskPerTradePct float64 // 单笔风险占账户净值的比例(%)
+	ATRStopMultiple float64 // 建议止损距离 = N × ATR14
+}
+
+// OneRSize 单个币种的1R仓位建议：亏到建议止损时正好损失一个单位风险(R)
+type OneRSize struct {
+	Symbol          string
+	Price           float64
+	Volatility      *market.Volatility
+	StopDistancePct float64 // 建议止损距离(%)
+	LongStop        float64 // 做多建议止损价
+	ShortStop       float64 // 做空建议止损价
+	RiskUSD         float64 // 1R风险金额(USDT)
+	NotionalUSD     float64 // 1R仓位的名义价值(USDT) = 风险金额 ÷ 止损距离
+}
+
+// riskPerTrade 单笔风险金额：账户净值 × 单笔风险比例，启用日风险预算时不超过剩余预算
+func (t *SizingTargets) riskPerTrade(account AccountInfo) float64 {
+	risk := account.TotalEquity * t.RiskPerTradePct / 100
+	if account.DailyRiskBudget > 0 {
+		risk = math.Min(risk, math.Max(account.RemainingRiskBudget, 0))
+	}
+	return risk
+}
+
+// CalculateOneRSize 根据ATR计算币种的1R仓位（数据不足时返回nil）
+func CalculateOneRSize(symbol string, data *market.Data, account AccountInfo, targets *SizingTargets) *OneRSize {
+	if targets == nil || targets.ATRStopMultiple <= 0 {
+		return nil
+	}
+	vol := market.CalculateVolatility(data)
+	if vol == nil || vol.ATR14 <= 0 {
+		return nil
+	}
+
+	stopDistance := vol.ATR14 * targets.ATRStopMultiple
+	size := &OneRSize{
+		Symbol:          symbol,
+		Price:           data.CurrentPrice,
+		Volatility:      vol,
+		StopDistancePct: stopDistance / data.CurrentPrice * 100,
+		LongStop:        data.CurrentPrice - stopDistance,
+		ShortStop:       data.CurrentPrice + stopDistance,
+		RiskUSD:         targets.riskPerTrade(account),
+	}
+	if size.StopDistancePct > 0 {
+		size.NotionalUSD = size.RiskUSD / (size.StopDistancePct / 100)
+	}
+	return size
+}
+
+// formatVolatilitySizing 生成波动率与1R仓位表（持仓和候选币种统一按长周期ATR计算）
+func formatVolatilitySizing(ctx *Context) string {
+	if ctx.Sizing == nil || ctx.Account.TotalEquity <= 0 {
+		return ""
+	}
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, pos := range ctx.Positions {
+		if !seen[pos.Symbol] {
+			seen[pos.Symbol] = true
+			symbols = append(symbols, pos.Symbol)
+		}
+	}
+	for _, coin := range ctx.CandidateCoins {
+		if !seen[coin.Symbol] {
+			seen[coin.Symbol] = true
+			symbols = append(symbols, coin.Symbol)
+		}
+	}
+
+	var rows []string
+	interval := ""
+	riskUSD := 0.0
+	for _, symbol := range symbols {
+		size := CalculateOneRSize(symbol, ctx.MarketDataMap[symbol], ctx.Account, ctx.Sizing)
+		if size == nil {
+			continue
+		}
+		interval = size.Volatility.Interval
+		riskUSD = size.RiskUSD
+		rows = append(rows, fmt.Sprintf("| %s | %.2f%% | %.2f%% | %.0f%% | %.2f%% | %.4f / %.4f | %.0f |",
+			market.DisplaySymbol(symbol), size.Volatility.ATRPct, size.Volatility.RealizedVolPct, size.Volatility.AnnualizedVolPct,
+			size.StopDistancePct, size.LongStop, size.ShortStop, size.NotionalUSD))
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	lang := ctx.lang()
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "sizing.title"))
+	sb.WriteString(i18n.T(lang, "sizing.basis",
+		interval, ctx.Sizing.ATRStopMultiple, riskUSD, ctx.Sizing.RiskPerTradePct))
+	if ctx.Account.DailyRiskBudget > 0 {
+		sb.WriteString(i18n.T(lang, "sizing.budget_cap"))
+	}
+	sb.WriteString(i18n.T(lang, "sizing.basis_end"))
+	sb.WriteString(i18n.T(lang, "sizing.table_header"))
+	sb.WriteString("|---|---|---|---|---|---|---|\n")
+	sb.WriteString(strings.Join(rows, "\n"))
+	sb.WriteString("\n\n")
+	if riskUSD <= 0 {
+		sb.WriteString(i18n.T(lang, "sizing.budget_exhausted"))
+	} else {
+		sb.WriteString(i18n.T(lang, "sizing.guidance"))
+	}
+	return sb.String()
+}
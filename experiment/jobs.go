@@ -0,0 +1,169 @@
+package experiment
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JobStatus 评估任务状态
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"   // 排队中（同一时间只运行一个评估任务）
+	JobRunning   JobStatus = "running"   // 运行中
+	JobCompleted JobStatus = "completed" // 已完成
+	JobFailed    JobStatus = "failed"    // 失败
+)
+
+// maxRetainedJobs 内存中保留的评估任务数量（超出后丢弃最早完成的任务）
+const maxRetainedJobs = 20
+
+// Job 异步评估任务（字段只在持有jobs锁时修改，对外返回副本）
+type Job struct {
+	ID         string             `json:"id"`
+	TraderID   string             `json:"trader_id"`
+	Status     JobStatus          `json:"status"`
+	Config     WalkForwardConfig  `json:"config"`
+	Baseline   Variant            `json:"baseline"`
+	Candidate  Variant            `json:"candidate"`
+	Done       int                `json:"done"`  // 已完成的AI调用次数
+	Total      int                `json:"total"` // 计划的AI调用次数
+	CreatedAt  time.Time          `json:"created_at"`
+	StartedAt  *time.Time         `json:"started_at,omitempty"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	Result     *WalkForwardResult `json:"result,omitempty"`
+}
+
+var (
+	jobsMu   sync.Mutex
+	jobs     = make(map[string]*Job)
+	jobSeq   int
+	runSlots = make(chan struct{}, 1) // 评估任务会大量调用AI，同一时间只运行一个
+)
+
+// SubmitWalkForward 校验参数并提交异步评估任务，立即返回任务快照
+func SubmitWalkForward(traderID string, cfg WalkForwardConfig, baseline, candidate Variant, env Env) (Job, error) {
+	if err := cfg.Normalize(); err != nil {
+		return Job{}, err
+	}
+	if env.Call == nil || env.DecisionLogger == nil {
+		return Job{}, fmt.Errorf("评估环境不完整（缺少AI调用或数据库）")
+	}
+
+	jobsMu.Lock()
+	jobSeq++
+	now := time.Now()
+	job := &Job{
+		ID:        fmt.Sprintf("wf-%d-%d", now.Unix(), jobSeq),
+		TraderID:  traderID,
+		Status:    JobPending,
+		Config:    cfg,
+		Baseline:  baseline,
+		Candidate: candidate,
+		Total:     cfg.PlannedAICalls(),
+		CreatedAt: now,
+	}
+	jobs[job.ID] = job
+	pruneJobsLocked()
+	snapshot := *job
+	jobsMu.Unlock()
+
+	go runJob(job, env)
+	log.Printf("🧪 [%s] 已提交walk-forward评估 %s：%d个窗口，预计%d次AI调用",
+		traderID, job.ID, len(cfg.Windows()), job.Total)
+	return snapshot, nil
+}
+
+// runJob 排队等待运行名额后执行评估
+func runJob(job *Job, env Env) {
+	runSlots <- struct{}{}
+	defer func() { <-runSlots }()
+
+	updateJob(job, func(j *Job) {
+		now := time.Now()
+		j.Status = JobRunning
+		j.StartedAt = &now
+	})
+
+	result, err := RunWalkForward(job.Config, job.Baseline, job.Candidate, env, func(done, total int) {
+		updateJob(job, func(j *Job) {
+			j.Done, j.Total = done, total
+		})
+	})
+
+	updateJob(job, func(j *Job) {
+		now := time.Now()
+		j.FinishedAt = &now
+		if err != nil {
+			j.Status = JobFailed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = JobCompleted
+		j.Result = result
+	})
+	if err != nil {
+		log.Printf("❌ walk-forward评估 %s 失败: %v", job.ID, err)
+	} else {
+		log.Printf("✅ walk-forward评估 %s 完成: %s", job.ID, result.Conclusion)
+	}
+}
+
+// updateJob 在锁内修改任务状态
+func updateJob(job *Job, update func(*Job)) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	update(job)
+}
+
+// pruneJobsLocked 超出保留数量时丢弃最早完成的任务（排队和运行中的任务不会被丢弃）
+func pruneJobsLocked() {
+	if len(jobs) <= maxRetainedJobs {
+		return
+	}
+	var finished []*Job
+	for _, j := range jobs {
+		if j.FinishedAt != nil {
+			finished = append(finished, j)
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool { return finished[a].CreatedAt.Before(finished[b].CreatedAt) })
+	for _, j := range finished {
+		if len(jobs) <= maxRetainedJobs {
+			break
+		}
+		delete(jobs, j.ID)
+	}
+}
+
+// GetJob 查询评估任务
+func GetJob(id string) (Job, bool) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	job, ok := jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// ListJobs 评估任务列表（按提交时间倒序，不含结果明细；traderID为空时返回全部）
+func ListJobs(traderID string) []Job {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	list := make([]Job, 0, len(jobs))
+	for _, job := range jobs {
+		if traderID != "" && job.TraderID != traderID {
+			continue
+		}
+		summary := *job
+		summary.Result = nil
+		list = append(list, summary)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].CreatedAt.After(list[b].CreatedAt) })
+	return list
+}
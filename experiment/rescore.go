@@ -0,0 +1,565 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/database/repositories"
+	"nofx/decision"
+	"nofx/market"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedVersion 对比时表示决策当时记录的评分（保存在决策JSON中，不是重评分版本）
+const RecordedVersion = "recorded"
+
+// 重评分参数默认值和上限
+const (
+	defaultRescoreLimit  = 2000  // 默认重评分最近的决策记录数
+	maxRescoreLimit      = 20000 // 单次重评分的决策记录数上限
+	maxRescoreSkipNotes  = 20    // 结果中保留的跳过原因条数
+	maxComparisonChanges = 10    // 对比结果中列出的评分变化最大的决策数
+)
+
+// RescoreConfig 决策质量重评分参数
+type RescoreConfig struct {
+	Version string    `json:"version"` // 评分版本标签（默认为当前规则版本，同一版本重复评分时覆盖）
+	From    time.Time `json:"from"`    // 决策记录时间范围（零值表示不限）
+	To      time.Time `json:"to"`
+	Limit   int       `json:"limit"` // 最多重评分的决策记录数（范围内最新的N条）
+}
+
+// Normalize 填充默认值并校验参数
+func (c *RescoreConfig) Normalize() error {
+	c.Version = strings.TrimSpace(c.Version)
+	if c.Version == "" {
+		c.Version = decision.QualityRulesVersion
+	}
+	if c.Version == RecordedVersion {
+		return fmt.Errorf("版本名%s已保留给决策时记录的评分", RecordedVersion)
+	}
+	if len(c.Version) > 64 {
+		return fmt.Errorf("版本名过长（最多64个字符）")
+	}
+	if !c.From.IsZero() && !c.To.IsZero() && !c.To.After(c.From) {
+		return fmt.Errorf("时间范围无效: from必须早于to")
+	}
+	if c.Limit <= 0 {
+		c.Limit = defaultRescoreLimit
+	}
+	if c.Limit > maxRescoreLimit {
+		return fmt.Errorf("limit超过上限%d", maxRescoreLimit)
+	}
+	return nil
+}
+
+// RescoreEnv 重评分环境
+type RescoreEnv struct {
+	DecisionLogger  interface{ GetDB() *database.DB } // 决策记录和评分结果所在的数据库
+	ConfidenceFloor *decision.ConfidenceFloor         // 当前的信心度门槛（质量评分的时机维度使用）
+}
+
+// RescoreResult 重评分结果
+type RescoreResult struct {
+	Records     int                `json:"records"`                // 处理的决策记录数
+	Scored      int                `json:"scored"`                 // 重新评分的决策数
+	Skipped     int                `json:"skipped"`                // 缺少本地K线等原因跳过的决策数
+	SkipReasons []string           `json:"skip_reasons,omitempty"` // 跳过原因（最多保留前若干条）
+	Comparison  *QualityComparison `json:"comparison,omitempty"`   // 与决策时记录评分的对比
+}
+
+// RunRescore 用本地存储的K线重建每条决策记录当时的行情，按当前DecisionQualityAnalyzer规则重新评分并按版本保存
+// 账户和持仓取自决策记录的快照；持仓量、资金费率等没有历史存档的数据在回放时为空，相关规则不会触发
+// 信心度取自保存的决策，可能已被当时的质量评估下调
+func RunRescore(cfg RescoreConfig, env RescoreEnv, progress Progress) (*RescoreResult, error) {
+	if err := cfg.Normalize(); err != nil {
+		return nil, err
+	}
+	if env.DecisionLogger == nil || env.DecisionLogger.GetDB() == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	db := env.DecisionLogger.GetDB()
+
+	records, _, err := db.Decision().Query(repositories.QueryOptions{
+		Since:     cfg.From,
+		Until:     cfg.To,
+		Limit:     cfg.Limit,
+		OmitHeavy: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RescoreResult{}
+	snapshots := newSnapshotCache()
+	var all []*models.QualityScore
+	for i, record := range records {
+		if progress != nil {
+			progress(i, len(records))
+		}
+		scores, skipped, err := rescoreRecord(db, record, cfg.Version, env.ConfidenceFloor, snapshots)
+		if err != nil {
+			return nil, err
+		}
+		for _, reason := range skipped {
+			result.Skipped++
+			if len(result.SkipReasons) < maxRescoreSkipNotes {
+				result.SkipReasons = append(result.SkipReasons, reason)
+			}
+		}
+		if len(scores) == 0 {
+			continue
+		}
+		if err := db.QualityScore().SaveBatch(scores); err != nil {
+			return nil, err
+		}
+		result.Records++
+		result.Scored += len(scores)
+		all = append(all, scores...)
+	}
+	if progress != nil {
+		progress(len(records), len(records))
+	}
+
+	result.Comparison = compareScores(RecordedVersion, cfg.Version, recordedScores(all), all)
+	return result, nil
+}
+
+// rescoreRecord 重新评分一条决策记录中的全部决策，返回评分结果和跳过原因
+func rescoreRecord(db *database.DB, record *models.DecisionRecord, version string, floor *decision.ConfidenceFloor, snapshots *snapshotCache) ([]*models.QualityScore, []string, error) {
+	if record.DecisionJSON == "" {
+		return nil, nil, nil
+	}
+	var decisions []decision.Decision
+	if err := json.Unmarshal([]byte(record.DecisionJSON), &decisions); err != nil {
+		return nil, []string{fmt.Sprintf("记录#%d: 决策JSON解析失败: %v", record.ID, err)}, nil
+	}
+	if len(decisions) == 0 {
+		return nil, nil, nil
+	}
+
+	// 市场状况按BTC行情判断，缺少BTC行情时整条记录跳过（否则市场环境维度的评分不可比）
+	btc, err := snapshots.get("BTCUSDT", record.Timestamp)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("记录#%d: %v", record.ID, err)}, nil
+	}
+
+	snapshotPositions, err := db.Decision().GetPositionSnapshots(record.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取记录#%d持仓快照失败: %w", record.ID, err)
+	}
+	positions := make([]decision.PositionInfo, 0, len(snapshotPositions))
+	for _, p := range snapshotPositions {
+		positions = append(positions, decision.PositionInfo{
+			Symbol:           p.Symbol,
+			Side:             p.Side,
+			EntryPrice:       p.EntryPrice,
+			MarkPrice:        p.MarkPrice,
+			Quantity:         math.Abs(p.PositionAmt),
+			Leverage:         int(p.Leverage),
+			UnrealizedPnL:    p.UnrealizedProfit,
+			LiquidationPrice: p.LiquidationPrice,
+		})
+	}
+
+	ctx := &decision.Context{
+		CurrentTime: record.Timestamp.Format("2006-01-02 15:04:05"),
+		Account: decision.AccountInfo{
+			TotalEquity:      record.TotalBalance,
+			AvailableBalance: record.AvailableBalance,
+			MarginUsedPct:    record.MarginUsedPct,
+			PositionCount:    record.PositionCount,
+		},
+		Positions:        positions,
+		MarketDataMap:    map[string]*market.Data{"BTCUSDT": btc},
+		MarketSnapshotAt: record.Timestamp,
+		ConfidenceFloor:  floor,
+	}
+
+	var scores []*models.QualityScore
+	var skipped []string
+	for i := range decisions {
+		d := decisions[i]
+		// 未通过验证的决策没有执行也没有质量评估，不参与重新评分
+		if d.ValidationError != "" {
+			continue
+		}
+		if d.Symbol != "" {
+			data, err := snapshots.get(d.Symbol, record.Timestamp)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("记录#%d %s %s: %v", record.ID, d.Symbol, d.Action, err))
+				continue
+			}
+			ctx.MarketDataMap[d.Symbol] = data
+		}
+
+		condition := decision.NewSmartMarketAnalyzer(ctx).AnalyzeMarketCondition()
+		quality := decision.NewDecisionQualityAnalyzer(ctx, condition).EvaluateDecisionQuality(&d)
+		issues, _ := json.Marshal(quality.Issues)
+		score := &models.QualityScore{
+			RecordID:      record.ID,
+			DecisionIndex: i,
+			Version:       version,
+			Symbol:        d.Symbol,
+			Action:        d.Action,
+			Score:         quality.Score,
+			Grade:         quality.Grade,
+			Issues:        string(issues),
+		}
+		if d.Quality != nil {
+			score.OriginalScore = d.Quality.Score
+			score.OriginalGrade = d.Quality.Grade
+		}
+		scores = append(scores, score)
+	}
+	return scores, skipped, nil
+}
+
+// snapshotCache 按币种和时间缓存回放行情（同一记录中BTC和决策币种的行情只重建一次）
+type snapshotCache struct {
+	data map[string]*market.Data
+	errs map[string]error
+}
+
+func newSnapshotCache() *snapshotCache {
+	return &snapshotCache{data: make(map[string]*market.Data), errs: make(map[string]error)}
+}
+
+func (c *snapshotCache) get(symbol string, at time.Time) (*market.Data, error) {
+	key := fmt.Sprintf("%s@%d", market.Normalize(symbol), at.Unix())
+	if data, ok := c.data[key]; ok {
+		return data, nil
+	}
+	if err, ok := c.errs[key]; ok {
+		return nil, err
+	}
+	data, err := market.SnapshotAt(symbol, at)
+	if err != nil {
+		c.errs[key] = err
+		return nil, err
+	}
+	c.data[key] = data
+	return data, nil
+}
+
+// QualityVersion 已保存的评分版本
+type QualityVersion struct {
+	Version  string  `json:"version"`
+	Records  int     `json:"records"`   // 涉及的决策记录数
+	Count    int     `json:"count"`     // 评分的决策数
+	AvgScore float64 `json:"avg_score"` // 平均分
+}
+
+// ListQualityVersions 已保存的评分版本列表（按首次评分时间排序）
+func ListQualityVersions(db *database.DB) ([]QualityVersion, error) {
+	summaries, err := db.QualityScore().ListVersions()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]QualityVersion, 0, len(summaries))
+	for _, s := range summaries {
+		versions = append(versions, QualityVersion{
+			Version:  s.Version,
+			Records:  s.Records,
+			Count:    s.Count,
+			AvgScore: s.AvgScore,
+		})
+	}
+	return versions, nil
+}
+
+// QualityChange 单个决策在两个版本间的评分变化
+type QualityChange struct {
+	RecordID      int64   `json:"record_id"`
+	DecisionIndex int     `json:"decision_index"`
+	Symbol        string  `json:"symbol"`
+	Action        string  `json:"action"`
+	BaseScore     float64 `json:"base_score"`
+	TargetScore   float64 `json:"target_score"`
+	BaseGrade     string  `json:"base_grade"`
+	TargetGrade   string  `json:"target_grade"`
+	Delta         float64 `json:"delta"` // 目标版本 - 基准版本
+}
+
+// QualityComparison 两个评分版本的对比（只统计两边都有评分的决策）
+type QualityComparison struct {
+	Base             string          `json:"base"`
+	Target           string          `json:"target"`
+	Matched          int             `json:"matched"` // 两边都有评分的决策数
+	BaseAvgScore     float64         `json:"base_avg_score"`
+	TargetAvgScore   float64         `json:"target_avg_score"`
+	AvgDelta         float64         `json:"avg_delta"`
+	Improved         int             `json:"improved"`          // 目标版本评分更高
+	Worsened         int             `json:"worsened"`          // 目标版本评分更低
+	Unchanged        int             `json:"unchanged"`         // 评分差异小于0.01
+	GradeChanged     int             `json:"grade_changed"`     // 等级发生变化的决策数
+	BaseGrades       map[string]int  `json:"base_grades"`       // 基准版本的等级分布
+	TargetGrades     map[string]int  `json:"target_grades"`     // 目标版本的等级分布
+	GradeTransitions map[string]int  `json:"grade_transitions"` // 等级变化统计（如 "good→fair"）
+	LargestChanges   []QualityChange `json:"largest_changes"`   // 评分变化最大的决策
+}
+
+// CompareQualityVersions 对比两个评分版本（base为recorded时与决策当时记录的评分对比）
+func CompareQualityVersions(db *database.DB, base, target string) (*QualityComparison, error) {
+	if target == "" || target == RecordedVersion {
+		return nil, fmt.Errorf("目标版本必须是已保存的重评分版本")
+	}
+	targetScores, err := db.QualityScore().GetByVersion(target)
+	if err != nil {
+		return nil, err
+	}
+	if len(targetScores) == 0 {
+		return nil, fmt.Errorf("评分版本%s没有数据", target)
+	}
+
+	var baseScores []*models.QualityScore
+	if base == "" || base == RecordedVersion {
+		base = RecordedVersion
+		baseScores = recordedScores(targetScores)
+	} else {
+		baseScores, err = db.QualityScore().GetByVersion(base)
+		if err != nil {
+			return nil, err
+		}
+		if len(baseScores) == 0 {
+			return nil, fmt.Errorf("评分版本%s没有数据", base)
+		}
+	}
+	return compareScores(base, target, baseScores, targetScores), nil
+}
+
+// recordedScores 取出决策时记录的评分（决策时没有评分的决策不参与对比）
+func recordedScores(scores []*models.QualityScore) []*models.QualityScore {
+	recorded := make([]*models.QualityScore, 0, len(scores))
+	for _, s := range scores {
+		if s.OriginalGrade == "" {
+			continue
+		}
+		r := *s
+		r.Version = RecordedVersion
+		r.Score = s.OriginalScore
+		r.Grade = s.OriginalGrade
+		recorded = append(recorded, &r)
+	}
+	return recorded
+}
+
+// compareScores 按决策记录和序号配对两组评分并统计差异
+func compareScores(base, target string, baseScores, targetScores []*models.QualityScore) *QualityComparison {
+	type key struct {
+		recordID int64
+		index    int
+	}
+	baseByKey := make(map[key]*models.QualityScore, len(baseScores))
+	for _, s := range baseScores {
+		baseByKey[key{s.RecordID, s.DecisionIndex}] = s
+	}
+
+	c := &QualityComparison{
+		Base:             base,
+		Target:           target,
+		BaseGrades:       make(map[string]int),
+		TargetGrades:     make(map[string]int),
+		GradeTransitions: make(map[string]int),
+	}
+	var baseSum, targetSum float64
+	changes := []QualityChange{}
+	for _, t := range targetScores {
+		b, ok := baseByKey[key{t.RecordID, t.DecisionIndex}]
+		if !ok {
+			continue
+		}
+		c.Matched++
+		baseSum += b.Score
+		targetSum += t.Score
+		c.BaseGrades[b.Grade]++
+		c.TargetGrades[t.Grade]++
+
+		delta := t.Score - b.Score
+		switch {
+		case delta >= 0.01:
+			c.Improved++
+		case delta <= -0.01:
+			c.Worsened++
+		default:
+			c.Unchanged++
+		}
+		if b.Grade != t.Grade {
+			c.GradeChanged++
+			c.GradeTransitions[b.Grade+"→"+t.Grade]++
+		}
+		if math.Abs(delta) >= 0.01 {
+			changes = append(changes, QualityChange{
+				RecordID:      t.RecordID,
+				DecisionIndex: t.DecisionIndex,
+				Symbol:        t.Symbol,
+				Action:        t.Action,
+				BaseScore:     b.Score,
+				TargetScore:   t.Score,
+				BaseGrade:     b.Grade,
+				TargetGrade:   t.Grade,
+				Delta:         delta,
+			})
+		}
+	}
+	if c.Matched > 0 {
+		c.BaseAvgScore = baseSum / float64(c.Matched)
+		c.TargetAvgScore = targetSum / float64(c.Matched)
+		c.AvgDelta = c.TargetAvgScore - c.BaseAvgScore
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return math.Abs(changes[i].Delta) > math.Abs(changes[j].Delta) })
+	if len(changes) > maxComparisonChanges {
+		changes = changes[:maxComparisonChanges]
+	}
+	c.LargestChanges = changes
+	return c
+}
+
+// RescoreJob 异步重评分任务（字段只在持有rescoreJobsMu时修改，对外返回副本）
+type RescoreJob struct {
+	ID         string         `json:"id"`
+	TraderID   string         `json:"trader_id"`
+	Status     JobStatus      `json:"status"`
+	Config     RescoreConfig  `json:"config"`
+	Done       int            `json:"done"`  // 已处理的决策记录数
+	Total      int            `json:"total"` // 待处理的决策记录数
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Result     *RescoreResult `json:"result,omitempty"`
+}
+
+var (
+	rescoreJobsMu  sync.Mutex
+	rescoreJobs    = make(map[string]*RescoreJob)
+	rescoreJobSeq  int
+	rescoreRunSlot = make(chan struct{}, 1) // 重评分会大量读取K线并写库，同一时间只运行一个
+)
+
+// SubmitRescore 校验参数并提交异步重评分任务，立即返回任务快照
+func SubmitRescore(traderID string, cfg RescoreConfig, env RescoreEnv) (RescoreJob, error) {
+	if err := cfg.Normalize(); err != nil {
+		return RescoreJob{}, err
+	}
+	if env.DecisionLogger == nil || env.DecisionLogger.GetDB() == nil {
+		return RescoreJob{}, fmt.Errorf("数据库连接不可用")
+	}
+
+	rescoreJobsMu.Lock()
+	rescoreJobSeq++
+	now := time.Now()
+	job := &RescoreJob{
+		ID:        fmt.Sprintf("rs-%d-%d", now.Unix(), rescoreJobSeq),
+		TraderID:  traderID,
+		Status:    JobPending,
+		Config:    cfg,
+		CreatedAt: now,
+	}
+	rescoreJobs[job.ID] = job
+	pruneRescoreJobsLocked()
+	snapshot := *job
+	rescoreJobsMu.Unlock()
+
+	go runRescoreJob(job, env)
+	log.Printf("🧮 [%s] 已提交决策质量重评分 %s：版本=%s，最多%d条记录", traderID, job.ID, cfg.Version, cfg.Limit)
+	return snapshot, nil
+}
+
+// runRescoreJob 排队等待运行名额后执行重评分
+func runRescoreJob(job *RescoreJob, env RescoreEnv) {
+	rescoreRunSlot <- struct{}{}
+	defer func() { <-rescoreRunSlot }()
+
+	updateRescoreJob(job, func(j *RescoreJob) {
+		now := time.Now()
+		j.Status = JobRunning
+		j.StartedAt = &now
+	})
+
+	result, err := RunRescore(job.Config, env, func(done, total int) {
+		updateRescoreJob(job, func(j *RescoreJob) {
+			j.Done, j.Total = done, total
+		})
+	})
+
+	updateRescoreJob(job, func(j *RescoreJob) {
+		now := time.Now()
+		j.FinishedAt = &now
+		if err != nil {
+			j.Status = JobFailed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = JobCompleted
+		j.Result = result
+	})
+	if err != nil {
+		log.Printf("❌ 决策质量重评分 %s 失败: %v", job.ID, err)
+	} else {
+		log.Printf("✅ 决策质量重评分 %s 完成: %d条记录 %d个决策，跳过%d个，平均分变化%+.1f",
+			job.ID, result.Records, result.Scored, result.Skipped, result.Comparison.AvgDelta)
+	}
+}
+
+// updateRescoreJob 在锁内修改任务状态
+func updateRescoreJob(job *RescoreJob, update func(*RescoreJob)) {
+	rescoreJobsMu.Lock()
+	defer rescoreJobsMu.Unlock()
+	update(job)
+}
+
+// pruneRescoreJobsLocked 超出保留数量时丢弃最早完成的任务（排队和运行中的任务不会被丢弃）
+func pruneRescoreJobsLocked() {
+	if len(rescoreJobs) <= maxRetainedJobs {
+		return
+	}
+	var finished []*RescoreJob
+	for _, j := range rescoreJobs {
+		if j.FinishedAt != nil {
+			finished = append(finished, j)
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool { return finished[a].CreatedAt.Before(finished[b].CreatedAt) })
+	for _, j := range finished {
+		if len(rescoreJobs) <= maxRetainedJobs {
+			break
+		}
+		delete(rescoreJobs, j.ID)
+	}
+}
+
+// GetRescoreJob 查询重评分任务
+func GetRescoreJob(id string) (RescoreJob, bool) {
+	rescoreJobsMu.Lock()
+	defer rescoreJobsMu.Unlock()
+	job, ok := rescoreJobs[id]
+	if !ok {
+		return RescoreJob{}, false
+	}
+	return *job, true
+}
+
+// ListRescoreJobs 重评分任务列表（按提交时间倒序，不含结果明细；traderID为空时返回全部）
+func ListRescoreJobs(traderID string) []RescoreJob {
+	rescoreJobsMu.Lock()
+	defer rescoreJobsMu.Unlock()
+	list := make([]RescoreJob, 0, len(rescoreJobs))
+	for _, job := range rescoreJobs {
+		if traderID != "" && job.TraderID != traderID {
+			continue
+		}
+		summary := *job
+		summary.Result = nil
+		list = append(list, summary)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].CreatedAt.After(list[b].CreatedAt) })
+	return list
+}
@@ -0,0 +1,711 @@
+// Package experiment 离线评估prompt/配置改动：用本地存储的历史K线做滚动前推（walk-forward）回放，
+// 对比当前配置与提议改动在相同行情下的盈亏和胜率，并给出差异的统计显著性；
+// 决策质量评分规则修改后，可用同样的本地K线重新评分历史决策，按版本对比规则修改前后的评分
+package experiment
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/analytics"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// Variant 参与比较的一组prompt/配置
+type Variant struct {
+	Name            string `json:"name"`
+	SystemPrompt    string `json:"system_prompt,omitempty"` // 非空时直接使用该system prompt，否则按配置从数据库构建
+	Language        string `json:"language,omitempty"`      // prompt语言（zh/en）
+	AIAutonomyMode  bool   `json:"ai_autonomy_mode"`
+	BTCETHLeverage  int    `json:"btc_eth_leverage"`
+	AltcoinLeverage int    `json:"altcoin_leverage"`
+	MaxPositions    int    `json:"max_positions"`
+}
+
+// WalkForwardConfig 滚动前推评估参数
+type WalkForwardConfig struct {
+	Symbols                 []string  `json:"symbols"`                   // 参与回放的币种（需已有本地K线）
+	From                    time.Time `json:"from"`                      // 评估区间起点
+	To                      time.Time `json:"to"`                        // 评估区间终点
+	WindowHours             int       `json:"window_hours"`              // 每个评估窗口的长度（窗口开始时账户重置）
+	StepHours               int       `json:"step_hours"`                // 窗口滚动步长（小于窗口长度时窗口重叠，样本不再独立）
+	DecisionIntervalMinutes int       `json:"decision_interval_minutes"` // 窗口内的决策间隔
+	InitialEquity           float64   `json:"initial_equity"`            // 每个窗口的初始净值(USDT)
+	FeeRate                 float64   `json:"fee_rate"`                  // 单边手续费率（按名义价值）
+	MaxAICalls              int       `json:"max_ai_calls"`              // AI调用次数上限（两组配置合计）
+}
+
+// 默认评估参数
+const (
+	defaultWindowHours      = 24
+	defaultDecisionInterval = 30
+	defaultInitialEquity    = 1000.0
+	defaultFeeRate          = 0.0004
+	defaultMaxAICalls       = 400
+)
+
+// Normalize 填充默认值并校验参数
+func (c *WalkForwardConfig) Normalize() error {
+	if len(c.Symbols) == 0 {
+		return fmt.Errorf("至少需要一个回放币种")
+	}
+	for i, symbol := range c.Symbols {
+		c.Symbols[i] = market.Normalize(symbol)
+	}
+	if c.From.IsZero() || c.To.IsZero() || !c.To.After(c.From) {
+		return fmt.Errorf("评估区间无效: from必须早于to")
+	}
+	if c.WindowHours <= 0 {
+		c.WindowHours = defaultWindowHours
+	}
+	if c.StepHours <= 0 {
+		c.StepHours = c.WindowHours
+	}
+	if c.DecisionIntervalMinutes <= 0 {
+		c.DecisionIntervalMinutes = defaultDecisionInterval
+	}
+	if c.InitialEquity <= 0 {
+		c.InitialEquity = defaultInitialEquity
+	}
+	if c.FeeRate < 0 || c.FeeRate >= 0.01 {
+		return fmt.Errorf("手续费率无效: %.4f（应在0~0.01之间）", c.FeeRate)
+	}
+	if c.FeeRate == 0 {
+		c.FeeRate = defaultFeeRate
+	}
+	if c.MaxAICalls <= 0 {
+		c.MaxAICalls = defaultMaxAICalls
+	}
+
+	windows := c.Windows()
+	if len(windows) < 2 {
+		return fmt.Errorf("评估区间只能划分出%d个%d小时窗口，至少需要2个才能做显著性检验", len(windows), c.WindowHours)
+	}
+	if calls := c.PlannedAICalls(); calls > c.MaxAICalls {
+		return fmt.Errorf("预计需要%d次AI调用，超过上限%d（可增大决策间隔或缩短评估区间）", calls, c.MaxAICalls)
+	}
+	return nil
+}
+
+// Window 一个评估窗口
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Windows 按窗口长度和步长划分评估区间（不足一个完整窗口的尾部丢弃）
+func (c *WalkForwardConfig) Windows() []Window {
+	length := time.Duration(c.WindowHours) * time.Hour
+	step := time.Duration(c.StepHours) * time.Hour
+	var windows []Window
+	for start := c.From; !start.Add(length).After(c.To); start = start.Add(step) {
+		windows = append(windows, Window{Start: start, End: start.Add(length)})
+	}
+	return windows
+}
+
+// stepsPerWindow 每个窗口内的决策次数
+func (c *WalkForwardConfig) stepsPerWindow() int {
+	return c.WindowHours * 60 / c.DecisionIntervalMinutes
+}
+
+// PlannedAICalls 预计的AI调用次数（两组配置合计）
+func (c *WalkForwardConfig) PlannedAICalls() int {
+	return len(c.Windows()) * c.stepsPerWindow() * 2
+}
+
+// Trade 回放中的一笔已平仓交易
+type Trade struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	EntryTime  time.Time `json:"entry_time"`
+	ExitTime   time.Time `json:"exit_time"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	Quantity   float64   `json:"quantity"`
+	Leverage   int       `json:"leverage"`
+	PnL        float64   `json:"pnl"`         // 扣除开平仓手续费后的盈亏(USDT)
+	ExitReason string    `json:"exit_reason"` // stop_loss / take_profit / ai_close / window_end
+}
+
+// VariantStats 一组配置在一个窗口（或全部窗口）内的表现
+type VariantStats struct {
+	PnL            float64 `json:"pnl"`
+	Trades         int     `json:"trades"`
+	Wins           int     `json:"wins"`
+	WinRate        float64 `json:"win_rate"`
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"`
+	Rejected       int     `json:"rejected"` // 未通过验证的决策数
+	Errors         int     `json:"errors"`   // AI调用或解析失败的周期数
+}
+
+// WindowResult 单个窗口的对比结果
+type WindowResult struct {
+	Window
+	Baseline  VariantStats `json:"baseline"`
+	Candidate VariantStats `json:"candidate"`
+}
+
+// WalkForwardResult 滚动前推评估结果
+type WalkForwardResult struct {
+	Windows         []WindowResult       `json:"windows"`
+	Baseline        VariantStats         `json:"baseline"`  // 全部窗口合计
+	Candidate       VariantStats         `json:"candidate"` // 全部窗口合计
+	BaselineTrades  []Trade              `json:"baseline_trades"`
+	CandidateTrades []Trade              `json:"candidate_trades"`
+	PnLTest         analytics.TestResult `json:"pnl_test"`      // 各窗口盈亏差（候选 - 基准）的配对t检验
+	WinRateTest     analytics.TestResult `json:"win_rate_test"` // 两组胜率的两比例z检验
+	AICalls         int                  `json:"ai_calls"`
+	Conclusion      string               `json:"conclusion"`
+}
+
+// Env 回放运行环境（由trader提供）
+type Env struct {
+	Call           decision.AICaller                 // AI调用
+	DecisionLogger interface{ GetDB() *database.DB } // 提供prompt模板所在的数据库
+}
+
+// Progress 进度回调（已完成/计划的AI调用次数）
+type Progress func(done, total int)
+
+// RunWalkForward 在相同的历史行情上依次回放基准和候选配置，按窗口对比盈亏并做显著性检验
+func RunWalkForward(cfg WalkForwardConfig, baseline, candidate Variant, env Env, progress Progress) (*WalkForwardResult, error) {
+	if err := cfg.Normalize(); err != nil {
+		return nil, err
+	}
+	total := cfg.PlannedAICalls()
+	result := &WalkForwardResult{}
+	var pnlDiffs []float64
+
+	windows := cfg.Windows()
+	for i, window := range windows {
+		feed, err := loadWindowFeed(cfg, window)
+		if err != nil {
+			return nil, fmt.Errorf("加载窗口%d行情失败: %w", i+1, err)
+		}
+		base := newSimulator(cfg, window, baseline, env)
+		cand := newSimulator(cfg, window, candidate, env)
+		for _, at := range feed.times {
+			snapshot := feed.snapshots[at]
+			for _, sim := range []*simulator{base, cand} {
+				sim.advance(feed, at)
+				sim.decide(snapshot, at)
+				result.AICalls++
+				if progress != nil {
+					progress(result.AICalls, total)
+				}
+			}
+		}
+		base.closeAll(feed, window.End)
+		cand.closeAll(feed, window.End)
+
+		wr := WindowResult{Window: window, Baseline: base.stats(), Candidate: cand.stats()}
+		result.Windows = append(result.Windows, wr)
+		result.BaselineTrades = append(result.BaselineTrades, base.trades...)
+		result.CandidateTrades = append(result.CandidateTrades, cand.trades...)
+		pnlDiffs = append(pnlDiffs, wr.Candidate.PnL-wr.Baseline.PnL)
+		log.Printf("🧪 walk-forward 窗口%d/%d %s: 基准 %+.2f（%d笔） vs 候选 %+.2f（%d笔）",
+			i+1, len(windows), window.Start.Format("01-02 15:04"),
+			wr.Baseline.PnL, wr.Baseline.Trades, wr.Candidate.PnL, wr.Candidate.Trades)
+	}
+
+	result.Baseline = aggregateStats(result.Windows, func(w WindowResult) VariantStats { return w.Baseline })
+	result.Candidate = aggregateStats(result.Windows, func(w WindowResult) VariantStats { return w.Candidate })
+	result.PnLTest = analytics.PairedTTest(pnlDiffs)
+	result.WinRateTest = analytics.TwoProportionZTest(result.Baseline.Wins, result.Baseline.Trades,
+		result.Candidate.Wins, result.Candidate.Trades)
+	result.Conclusion = conclude(result)
+	return result, nil
+}
+
+// aggregateStats 汇总各窗口的表现（最大回撤取各窗口最大值）
+func aggregateStats(windows []WindowResult, pick func(WindowResult) VariantStats) VariantStats {
+	var total VariantStats
+	for _, w := range windows {
+		s := pick(w)
+		total.PnL += s.PnL
+		total.Trades += s.Trades
+		total.Wins += s.Wins
+		total.Rejected += s.Rejected
+		total.Errors += s.Errors
+		total.MaxDrawdownPct = math.Max(total.MaxDrawdownPct, s.MaxDrawdownPct)
+	}
+	if total.Trades > 0 {
+		total.WinRate = float64(total.Wins) / float64(total.Trades)
+	}
+	return total
+}
+
+// conclude 用一句话概括检验结论
+func conclude(r *WalkForwardResult) string {
+	var parts []string
+	if r.PnLTest.Significant {
+		direction := "优于"
+		if r.PnLTest.Difference < 0 {
+			direction = "劣于"
+		}
+		parts = append(parts, fmt.Sprintf("候选配置盈亏显著%s基准（每窗口平均%+.2f USDT，p=%.4f）", direction, r.PnLTest.Difference, r.PnLTest.PValue))
+	} else {
+		parts = append(parts, fmt.Sprintf("盈亏差异不显著（每窗口平均%+.2f USDT，p=%.4f）", r.PnLTest.Difference, r.PnLTest.PValue))
+	}
+	if r.WinRateTest.Significant {
+		parts = append(parts, fmt.Sprintf("胜率差异显著（%+.1f%%，p=%.4f）", r.WinRateTest.Difference*100, r.WinRateTest.PValue))
+	} else {
+		parts = append(parts, fmt.Sprintf("胜率差异不显著（%+.1f%%，p=%.4f）", r.WinRateTest.Difference*100, r.WinRateTest.PValue))
+	}
+	return strings.Join(parts, "；")
+}
+
+// windowFeed 一个窗口的回放行情：各决策时点的快照和逐根K线（用于检查止损/止盈）
+type windowFeed struct {
+	times     []time.Time
+	snapshots map[time.Time]map[string]*market.Data
+	bars      map[string][]market.Kline
+}
+
+// loadWindowFeed 读取窗口内的行情（两组配置共用同一份数据，保证对比公平）
+func loadWindowFeed(cfg WalkForwardConfig, window Window) (*windowFeed, error) {
+	feed := &windowFeed{
+		snapshots: make(map[time.Time]map[string]*market.Data),
+		bars:      make(map[string][]market.Kline),
+	}
+	for _, symbol := range cfg.Symbols {
+		bars, err := market.ReplayBars(symbol, window.Start, window.End)
+		if err != nil {
+			return nil, err
+		}
+		if len(bars) == 0 {
+			return nil, fmt.Errorf("%s 在 %s ~ %s 没有本地K线", symbol,
+				window.Start.Format("2006-01-02 15:04"), window.End.Format("2006-01-02 15:04"))
+		}
+		feed.bars[symbol] = bars
+	}
+
+	interval := time.Duration(cfg.DecisionIntervalMinutes) * time.Minute
+	for at := window.Start; at.Before(window.End); at = at.Add(interval) {
+		dataMap := make(map[string]*market.Data)
+		for _, symbol := range cfg.Symbols {
+			data, err := market.SnapshotAt(symbol, at)
+			if err != nil {
+				log.Printf("⚠️ walk-forward %s %s 行情快照失败: %v", symbol, at.Format("01-02 15:04"), err)
+				continue
+			}
+			dataMap[symbol] = data
+		}
+		feed.times = append(feed.times, at)
+		feed.snapshots[at] = dataMap
+	}
+	return feed, nil
+}
+
+// simPosition 回放中的持仓
+type simPosition struct {
+	Symbol     string
+	Side       string
+	EntryTime  time.Time
+	EntryPrice float64
+	Quantity   float64
+	Leverage   int
+	StopLoss   float64
+	TakeProfit float64
+}
+
+// simulator 单组配置在一个窗口内的模拟账户
+type simulator struct {
+	cfg       WalkForwardConfig
+	window    Window
+	variant   Variant
+	env       Env
+	cash      float64 // 已实现净值（初始净值 + 已平仓盈亏 - 手续费）
+	positions map[string]*simPosition
+	lastPrice map[string]float64
+	checked   time.Time // 止损/止盈已检查到的时间
+	trades    []Trade
+	equities  []float64
+	rejected  int
+	errors    int
+	calls     int
+}
+
+func newSimulator(cfg WalkForwardConfig, window Window, variant Variant, env Env) *simulator {
+	return &simulator{
+		cfg:       cfg,
+		window:    window,
+		variant:   variant,
+		env:       env,
+		cash:      cfg.InitialEquity,
+		positions: make(map[string]*simPosition),
+		lastPrice: make(map[string]float64),
+		equities:  []float64{cfg.InitialEquity},
+	}
+}
+
+// positionKey 持仓键（同一币种可同时持有多空）
+func positionKey(symbol, side string) string {
+	return symbol + "_" + side
+}
+
+// advance 逐根检查[checked, at)内收盘的K线是否触发止损/止盈（同一根K线同时触及时按止损处理）
+func (s *simulator) advance(feed *windowFeed, at time.Time) {
+	for _, bar := range feedBars(feed, s.checked, at) {
+		s.lastPrice[bar.symbol] = bar.Close
+		for _, side := range []string{"long", "short"} {
+			pos := s.positions[positionKey(bar.symbol, side)]
+			if pos == nil {
+				continue
+			}
+			exitTime := time.UnixMilli(bar.CloseTime)
+			if price, hit := stopHit(pos, bar.Kline); hit {
+				s.close(pos, price, exitTime, "stop_loss")
+			} else if price, hit := takeProfitHit(pos, bar.Kline); hit {
+				s.close(pos, price, exitTime, "take_profit")
+			}
+		}
+	}
+	s.checked = at
+	s.equities = append(s.equities, s.equity())
+}
+
+// symbolBar 带币种的K线
+type symbolBar struct {
+	symbol string
+	market.Kline
+}
+
+// feedBars 按时间顺序返回[from, to)内收盘的各币种K线
+func feedBars(feed *windowFeed, from, to time.Time) []symbolBar {
+	var bars []symbolBar
+	for symbol, klines := range feed.bars {
+		for _, k := range klines {
+			closeAt := time.UnixMilli(k.CloseTime)
+			if !closeAt.Before(from) && closeAt.Before(to) {
+				bars = append(bars, symbolBar{symbol: symbol, Kline: k})
+			}
+		}
+	}
+	// 插入排序：每个窗口的K线数量有限，且各币种内部已按时间排序
+	for i := 1; i < len(bars); i++ {
+		for j := i; j > 0 && bars[j].CloseTime < bars[j-1].CloseTime; j-- {
+			bars[j], bars[j-1] = bars[j-1], bars[j]
+		}
+	}
+	return bars
+}
+
+// stopHit 止损是否在该K线内触发（跳空越过止损时按开盘价成交）
+func stopHit(pos *simPosition, k market.Kline) (float64, bool) {
+	if pos.StopLoss <= 0 {
+		return 0, false
+	}
+	if pos.Side == "long" && k.Low <= pos.StopLoss {
+		return math.Min(k.Open, pos.StopLoss), true
+	}
+	if pos.Side == "short" && k.High >= pos.StopLoss {
+		return math.Max(k.Open, pos.StopLoss), true
+	}
+	return 0, false
+}
+
+// takeProfitHit 止盈是否在该K线内触发（按止盈价成交）
+func takeProfitHit(pos *simPosition, k market.Kline) (float64, bool) {
+	if pos.TakeProfit <= 0 {
+		return 0, false
+	}
+	if pos.Side == "long" && k.High >= pos.TakeProfit {
+		return pos.TakeProfit, true
+	}
+	if pos.Side == "short" && k.Low <= pos.TakeProfit {
+		return pos.TakeProfit, true
+	}
+	return 0, false
+}
+
+// equity 按最新价格计算的净值
+func (s *simulator) equity() float64 {
+	equity := s.cash
+	for _, pos := range s.positions {
+		if price := s.lastPrice[pos.Symbol]; price > 0 {
+			equity += pnlOf(pos, price)
+		}
+	}
+	return equity
+}
+
+// pnlOf 持仓在指定价格下的盈亏（不含手续费）
+func pnlOf(pos *simPosition, price float64) float64 {
+	if pos.Side == "long" {
+		return (price - pos.EntryPrice) * pos.Quantity
+	}
+	return (pos.EntryPrice - price) * pos.Quantity
+}
+
+// close 平仓并记录交易（开仓手续费在开仓时已从净值扣除，这里计入该笔交易的盈亏）
+func (s *simulator) close(pos *simPosition, price float64, at time.Time, reason string) {
+	entryFee := pos.EntryPrice * pos.Quantity * s.cfg.FeeRate
+	exitFee := price * pos.Quantity * s.cfg.FeeRate
+	gross := pnlOf(pos, price)
+	s.cash += gross - exitFee
+	s.trades = append(s.trades, Trade{
+		Symbol:     pos.Symbol,
+		Side:       pos.Side,
+		EntryTime:  pos.EntryTime,
+		ExitTime:   at,
+		EntryPrice: pos.EntryPrice,
+		ExitPrice:  price,
+		Quantity:   pos.Quantity,
+		Leverage:   pos.Leverage,
+		PnL:        gross - entryFee - exitFee,
+		ExitReason: reason,
+	})
+	delete(s.positions, positionKey(pos.Symbol, pos.Side))
+}
+
+// closeAll 窗口结束时按最后价格平掉全部持仓
+func (s *simulator) closeAll(feed *windowFeed, end time.Time) {
+	s.advance(feed, end)
+	for _, pos := range s.positions {
+		if price := s.lastPrice[pos.Symbol]; price > 0 {
+			s.close(pos, price, end, "window_end")
+		}
+	}
+	s.equities = append(s.equities, s.equity())
+}
+
+// context 构建回放时点的决策上下文
+func (s *simulator) context(dataMap map[string]*market.Data, at time.Time) *decision.Context {
+	equity := s.equity()
+	var positions []decision.PositionInfo
+	marginUsed := 0.0
+	for _, pos := range s.positions {
+		price := s.lastPrice[pos.Symbol]
+		margin := pos.EntryPrice * pos.Quantity / float64(pos.Leverage)
+		marginUsed += margin
+		pnl := pnlOf(pos, price)
+		pnlPct := 0.0
+		if margin > 0 {
+			pnlPct = pnl / margin * 100
+		}
+		positions = append(positions, decision.PositionInfo{
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			EntryPrice:       pos.EntryPrice,
+			MarkPrice:        price,
+			Quantity:         pos.Quantity,
+			Leverage:         pos.Leverage,
+			UnrealizedPnL:    pnl,
+			UnrealizedPnLPct: pnlPct,
+			MarginUsed:       margin,
+			StopLoss:         pos.StopLoss,
+			TakeProfit:       pos.TakeProfit,
+		})
+	}
+
+	candidates := make([]decision.CandidateCoin, 0, len(s.cfg.Symbols))
+	for _, symbol := range s.cfg.Symbols {
+		candidates = append(candidates, decision.CandidateCoin{Symbol: symbol, Sources: []string{"default"}})
+	}
+
+	// 每组配置使用独立的行情映射，避免验证时补充的数据互相影响
+	marketData := make(map[string]*market.Data, len(dataMap))
+	for symbol, data := range dataMap {
+		marketData[symbol] = data
+	}
+
+	ctx := &decision.Context{
+		CurrentTime:     at.Format("2006-01-02 15:04:05"),
+		RuntimeMinutes:  int(at.Sub(s.window.Start).Minutes()),
+		CallCount:       s.calls + 1,
+		BTCETHLeverage:  s.variant.BTCETHLeverage,
+		AltcoinLeverage: s.variant.AltcoinLeverage,
+		MaxPositions:    s.variant.MaxPositions,
+		DecisionLogger:  s.env.DecisionLogger,
+		AIAutonomyMode:  s.variant.AIAutonomyMode,
+		Account: decision.AccountInfo{
+			TotalEquity:      equity,
+			AvailableBalance: equity - marginUsed,
+			TotalPnL:         equity - s.cfg.InitialEquity,
+			TotalPnLPct:      (equity - s.cfg.InitialEquity) / s.cfg.InitialEquity * 100,
+			MarginUsed:       marginUsed,
+			PositionCount:    len(positions),
+		},
+		Positions:        positions,
+		CandidateCoins:   candidates,
+		MarketDataMap:    marketData,
+		OITopDataMap:     make(map[string]*decision.OITopData),
+		MarketSnapshotAt: at,
+		Language:         i18n.Parse(s.variant.Language),
+	}
+	if equity > 0 {
+		ctx.Account.MarginUsedPct = marginUsed / equity * 100
+	}
+	ctx.RiskMetrics = decision.CalculateRiskMetrics(ctx)
+	return ctx
+}
+
+// decide 调用AI获取回放时点的决策并在模拟账户中执行
+func (s *simulator) decide(dataMap map[string]*market.Data, at time.Time) {
+	ctx := s.context(dataMap, at)
+	s.calls++
+	replay, err := decision.GetReplayDecision(ctx, s.env.Call, s.variant.SystemPrompt)
+	if err != nil {
+		s.errors++
+		log.Printf("⚠️ walk-forward [%s] %s 决策失败: %v", s.variant.Name, at.Format("01-02 15:04"), err)
+		return
+	}
+	s.rejected += len(replay.Rejected)
+
+	for _, d := range replay.Decisions {
+		data := dataMap[d.Symbol]
+		if data == nil || data.CurrentPrice <= 0 {
+			s.rejected++
+			continue
+		}
+		price := data.CurrentPrice
+		s.lastPrice[d.Symbol] = price
+		switch d.Action {
+		case "open_long", "open_short":
+			s.open(&d, price, at)
+		case "close_long", "close_short":
+			side := strings.TrimPrefix(d.Action, "close_")
+			if pos := s.positions[positionKey(d.Symbol, side)]; pos != nil {
+				s.close(pos, price, at, "ai_close")
+			}
+		case "update_stop_loss":
+			for _, pos := range s.symbolPositions(d.Symbol) {
+				pos.StopLoss = d.StopLoss
+			}
+		case "update_take_profit":
+			for _, pos := range s.symbolPositions(d.Symbol) {
+				pos.TakeProfit = d.TakeProfit
+			}
+		}
+	}
+}
+
+// symbolPositions 币种的全部持仓
+func (s *simulator) symbolPositions(symbol string) []*simPosition {
+	var positions []*simPosition
+	for _, pos := range s.positions {
+		if pos.Symbol == symbol {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// open 按决策的名义价值开仓（已有同方向持仓时忽略，保证金不足时按可用保证金缩小仓位）
+func (s *simulator) open(d *decision.Decision, price float64, at time.Time) {
+	side := strings.TrimPrefix(d.Action, "open_")
+	key := positionKey(d.Symbol, side)
+	if s.positions[key] != nil {
+		s.rejected++
+		return
+	}
+	leverage := d.Leverage
+	if leverage <= 0 {
+		leverage = 1
+	}
+	notional := d.NotionalUSD
+	if available := s.availableMargin() * float64(leverage); notional > available {
+		notional = available
+	}
+	if notional <= 0 {
+		s.rejected++
+		return
+	}
+	quantity := notional / price
+	s.cash -= notional * s.cfg.FeeRate
+	s.positions[key] = &simPosition{
+		Symbol:     d.Symbol,
+		Side:       side,
+		EntryTime:  at,
+		EntryPrice: price,
+		Quantity:   quantity,
+		Leverage:   leverage,
+		StopLoss:   d.StopLoss,
+		TakeProfit: d.TakeProfit,
+	}
+}
+
+// availableMargin 可用保证金（净值 - 已占用保证金）
+func (s *simulator) availableMargin() float64 {
+	used := 0.0
+	for _, pos := range s.positions {
+		used += pos.EntryPrice * pos.Quantity / float64(pos.Leverage)
+	}
+	return math.Max(0, s.equity()-used)
+}
+
+// stats 汇总窗口内的表现
+func (s *simulator) stats() VariantStats {
+	stats := VariantStats{
+		PnL:      s.equity() - s.cfg.InitialEquity,
+		Trades:   len(s.trades),
+		Rejected: s.rejected,
+		Errors:   s.errors,
+	}
+	for _, trade := range s.trades {
+		if trade.PnL > 0 {
+			stats.Wins++
+		}
+	}
+	if stats.Trades > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Trades)
+	}
+	stats.MaxDrawdownPct, _ = analytics.MaxDrawdown(s.equities)
+	return stats
+}
+
+// VariantChange 提议的prompt/配置改动（nil字段沿用基准配置）
+type VariantChange struct {
+	SystemPrompt    string  `json:"system_prompt,omitempty"`
+	Language        *string `json:"language,omitempty"`
+	AIAutonomyMode  *bool   `json:"ai_autonomy_mode,omitempty"`
+	BTCETHLeverage  *int    `json:"btc_eth_leverage,omitempty"`
+	AltcoinLeverage *int    `json:"altcoin_leverage,omitempty"`
+	MaxPositions    *int    `json:"max_positions,omitempty"`
+}
+
+// Apply 在基准配置上应用改动，得到候选配置
+func (v Variant) Apply(change VariantChange) (Variant, error) {
+	candidate := v
+	candidate.Name = "candidate"
+	if change.SystemPrompt != "" {
+		candidate.SystemPrompt = change.SystemPrompt
+	}
+	if change.Language != nil {
+		if err := i18n.Validate(*change.Language); err != nil {
+			return Variant{}, err
+		}
+		candidate.Language = string(i18n.Parse(*change.Language))
+	}
+	if change.AIAutonomyMode != nil {
+		candidate.AIAutonomyMode = *change.AIAutonomyMode
+	}
+	if change.BTCETHLeverage != nil {
+		candidate.BTCETHLeverage = *change.BTCETHLeverage
+	}
+	if change.AltcoinLeverage != nil {
+		candidate.AltcoinLeverage = *change.AltcoinLeverage
+	}
+	if change.MaxPositions != nil {
+		candidate.MaxPositions = *change.MaxPositions
+	}
+	if candidate.BTCETHLeverage <= 0 || candidate.AltcoinLeverage <= 0 {
+		return Variant{}, fmt.Errorf("杠杆倍数必须大于0")
+	}
+	if candidate.MaxPositions < 0 {
+		return Variant{}, fmt.Errorf("最大持仓数不能为负数")
+	}
+	unchanged := v
+	unchanged.Name = candidate.Name
+	if candidate == unchanged {
+		return Variant{}, fmt.Errorf("候选配置与当前配置完全相同，没有可评估的改动")
+	}
+	return candidate, nil
+}
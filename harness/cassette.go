@@ -0,0 +1,205 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode cassette工作模式
+type Mode int
+
+const (
+	ModeReplay Mode = iota // 回放：只返回已录制的响应，未命中时报错
+	ModeRecord             // 录制：请求真实接口并保存响应
+)
+
+// volatileParams 每次请求都会变化的参数（签名、时间戳），匹配时忽略
+var volatileParams = map[string]bool{
+	"timestamp":  true,
+	"signature":  true,
+	"recvWindow": true,
+}
+
+// Interaction 一次录制的HTTP交互
+type Interaction struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`          // 去掉签名/时间戳后的URL（匹配键）
+	RequestBody string            `json:"request_body"` // 请求体（AI请求的prompt，便于排查）
+	Status      int               `json:"status"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        string            `json:"body"`
+}
+
+// Cassette 一组录制的HTTP交互（实现 http.RoundTripper）
+type Cassette struct {
+	mu           sync.Mutex
+	path         string
+	mode         Mode
+	interactions []*Interaction
+	used         map[int]bool
+	upstream     http.RoundTripper
+}
+
+// LoadCassette 加载cassette文件（录制模式下文件不存在时创建空cassette）
+func LoadCassette(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{
+		path:     path,
+		mode:     mode,
+		used:     make(map[int]bool),
+		upstream: http.DefaultTransport,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && mode == ModeRecord {
+			return c, nil
+		}
+		return nil, fmt.Errorf("读取cassette失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		return nil, fmt.Errorf("解析cassette失败: %w", err)
+	}
+	return c, nil
+}
+
+// RoundTrip 回放或录制一次请求
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := matchKey(req.URL)
+
+	var reqBody []byte
+	if req.Body != nil {
+		reqBody, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 按录制顺序返回第一个未使用的匹配交互（同一接口多次调用时依次返回）
+	for i, it := range c.interactions {
+		if c.used[i] || it.Method != req.Method || it.URL != key {
+			continue
+		}
+		c.used[i] = true
+		return it.response(req), nil
+	}
+
+	if c.mode != ModeRecord {
+		return nil, fmt.Errorf("cassette %s 中没有匹配的请求: %s %s", c.path, req.Method, key)
+	}
+
+	resp, err := c.upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Interaction{
+		Method:      req.Method,
+		URL:         key,
+		RequestBody: string(reqBody),
+		Status:      resp.StatusCode,
+		Header:      map[string]string{"Content-Type": resp.Header.Get("Content-Type")},
+		Body:        string(body),
+	}
+	c.interactions = append(c.interactions, it)
+	c.used[len(c.interactions)-1] = true
+	return it.response(req), nil
+}
+
+// Save 保存录制结果
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化cassette失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("创建cassette目录失败: %w", err)
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// Unused 返回未被回放的交互（用于检查流程是否少发了请求）
+func (c *Cassette) Unused() []*Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var unused []*Interaction
+	for i, it := range c.interactions {
+		if !c.used[i] {
+			unused = append(unused, it)
+		}
+	}
+	return unused
+}
+
+// Install 把cassette安装为默认HTTP传输层，返回恢复函数
+// market包（http.Get）和mcp客户端（未指定Transport的http.Client）都会走 http.DefaultTransport
+func Install(rt http.RoundTripper) func() {
+	original := http.DefaultTransport
+	http.DefaultTransport = rt
+	return func() {
+		http.DefaultTransport = original
+	}
+}
+
+// response 构造回放响应
+func (it *Interaction) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	for k, v := range it.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode:    it.Status,
+		Status:        fmt.Sprintf("%d %s", it.Status, http.StatusText(it.Status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(it.Body)),
+		ContentLength: int64(len(it.Body)),
+		Request:       req,
+	}
+}
+
+// matchKey 生成请求匹配键（去掉签名、时间戳等易变参数，参数按名称排序）
+func matchKey(u *url.URL) string {
+	query := u.Query()
+	var names []string
+	for name := range query {
+		if !volatileParams[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		for _, v := range query[name] {
+			parts = append(parts, name+"="+v)
+		}
+	}
+
+	key := u.Scheme + "://" + u.Host + u.Path
+	if len(parts) > 0 {
+		key += "?" + strings.Join(parts, "&")
+	}
+	return key
+}
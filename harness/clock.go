@@ -0,0 +1,43 @@
+package harness
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时间来源（生产代码使用 time.Now，测试中使用 FakeClock 固定时间）
+type Clock interface {
+	Now() time.Time
+}
+
+// FakeClock 可控时钟
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock 创建固定在指定时间的时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now 当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 时间前进
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set 设置当前时间
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
@@ -0,0 +1,11 @@
+// Package harness 集成测试工具：录制/回放交易所和AI接口的HTTP响应、可控时钟、golden文件比对
+//
+// 用法：
+//
+//	cassette, _ := harness.LoadCassette("testdata/cycle_open_long.json", harness.ModeReplay)
+//	restore := harness.Install(cassette)
+//	defer restore()
+//
+// 录制模式（ModeRecord）会把真实请求的响应写入cassette文件，回放模式下同样的请求按录制顺序返回，
+// 不访问网络，使 runCycle 这类完整流程可以在CI中确定性地运行。
+package harness
@@ -0,0 +1,61 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv 设置该环境变量为1时重写golden文件而不是比对
+const UpdateGoldenEnv = "NOFX_UPDATE_GOLDEN"
+
+// goldenDir golden文件目录（测试启动时的工作目录即包目录，测试中切换工作目录后仍指向包内testdata）
+var goldenDir = func() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "testdata"
+	}
+	return filepath.Join(wd, "testdata")
+}()
+
+// timestampPattern prompt中的时间戳（每次运行都不同，比对前替换掉）
+var timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`)
+
+// NormalizeGolden 去掉易变内容（时间戳、行尾空白），便于golden比对
+func NormalizeGolden(s string) string {
+	s = timestampPattern.ReplaceAllString(s, "<TIME>")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AssertGolden 比对输出与golden文件（testdata/<name>.golden）
+// 用于prompt构建和决策解析的回归检查，格式变化时用 NOFX_UPDATE_GOLDEN=1 重新生成
+func AssertGolden(t testing.TB, name, got string) {
+	t.Helper()
+
+	path := filepath.Join(goldenDir, name+".golden")
+	got = NormalizeGolden(got)
+
+	if os.Getenv(UpdateGoldenEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("创建golden目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("写入golden文件失败: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取golden文件失败（首次运行请设置 %s=1）: %v", UpdateGoldenEnv, err)
+	}
+	if string(want) != got {
+		t.Errorf("%s 与golden文件不一致:\n--- want\n%s\n--- got\n%s", name, want, got)
+	}
+}
@@ -0,0 +1,33 @@
+package harness
+
+import (
+	"math"
+	"nofx/market"
+	"time"
+)
+
+// SyntheticKlines 生成截止到end（不含）的n根确定性K线（缓慢上涨叠加周期波动），用于填充本地K线库
+func SyntheticKlines(end time.Time, interval time.Duration, n int, base float64) []market.Kline {
+	klines := make([]market.Kline, 0, n)
+	start := end.Add(-interval * time.Duration(n))
+	prevClose := base
+	for i := 0; i < n; i++ {
+		openTime := start.Add(interval * time.Duration(i))
+		drift := base * 0.0005 * float64(i)
+		wave := base * 0.01 * math.Sin(float64(i)/5)
+		closePrice := base + drift + wave
+		high := math.Max(prevClose, closePrice) * 1.001
+		low := math.Min(prevClose, closePrice) * 0.999
+		klines = append(klines, market.Kline{
+			OpenTime:  openTime.UnixMilli(),
+			Open:      prevClose,
+			High:      high,
+			Low:       low,
+			Close:     closePrice,
+			Volume:    1000 + float64(i%7)*100,
+			CloseTime: openTime.Add(interval).UnixMilli() - 1,
+		})
+		prevClose = closePrice
+	}
+	return klines
+}
@@ -0,0 +1,22 @@
+package harness
+
+import (
+	"os"
+	"testing"
+)
+
+// ChdirTemp 切换到临时目录运行测试（数据库和K线库使用相对路径data/，避免写入仓库目录），测试结束后恢复
+func ChdirTemp(t testing.TB) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取工作目录失败: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("切换工作目录失败: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang 提示词语言
+type Lang string
+
+const (
+	ZH Lang = "zh" // 中文（默认）
+	EN Lang = "en" // 英文
+)
+
+// Default 未配置语言时使用的默认语言
+const Default = ZH
+
+// Supported 支持的全部语言
+var Supported = []Lang{ZH, EN}
+
+// Parse 解析语言设置（空值或未知值使用默认语言）
+func Parse(s string) Lang {
+	switch Lang(strings.ToLower(strings.TrimSpace(s))) {
+	case EN:
+		return EN
+	default:
+		return Default
+	}
+}
+
+// Validate 校验语言设置（空值表示默认语言）
+func Validate(s string) error {
+	switch Lang(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ZH, EN:
+		return nil
+	}
+	return fmt.Errorf("不支持的提示词语言: %s（可选 zh、en）", s)
+}
+
+// T 按语言取文案，有参数时按 fmt.Sprintf 格式化
+// 缺少该语言的文案时使用默认语言，文案不存在时返回key本身
+func T(lang Lang, key string, args ...interface{}) string {
+	text, ok := messages[key][lang]
+	if !ok {
+		text, ok = messages[key][Default]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Has 是否存在该语言的文案（不回退默认语言）
+func Has(lang Lang, key string) bool {
+	_, ok := messages[key][lang]
+	return ok
+}
@@ -0,0 +1,876 @@
+package i18n
+
+// messages 提示词文案目录：key → 语言 → 文案（带格式参数的文案与 fmt.Sprintf 一致）
+var messages = map[string]map[Lang]string{
+	// ===== System Prompt =====
+	"system.load_failed": {
+		ZH: "错误：无法加载system prompt配置",
+		EN: "Error: failed to load system prompt configuration",
+	},
+	"system.intro": {
+		ZH: "你是专业的加密货币交易AI，在币安合约市场进行自主交易。\n\n",
+		EN: "You are a professional crypto trading AI trading autonomously on Binance perpetual futures.\n\n",
+	},
+	"system.intro_autonomous": {
+		ZH: "你是专业的加密货币交易AI，在币安合约市场进行**完全自主交易**。\n\n" +
+			"🚀 **AI自主模式已启用**：你拥有完全的决策自由，可以根据市场情况自主决定所有参数。\n\n",
+		EN: "You are a professional crypto trading AI trading **fully autonomously** on Binance perpetual futures.\n\n" +
+			"🚀 **AI autonomy mode is enabled**: you have full decision freedom and may choose every parameter based on market conditions.\n\n",
+	},
+	"system.output_format": {
+		ZH: "---\n\n" +
+			"# 📤 输出格式\n\n" +
+			"**第一步: 思维链（纯文本）**\n" +
+			"简洁分析你的思考过程\n\n" +
+			"**第二步: JSON决策（带格式版本号）**\n\n" +
+			"```json\n{\"schema_version\": 2, \"decisions\": [\n" +
+			"  {\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"notional_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"下跌趋势+MACD死叉\"},\n" +
+			"  {\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"止盈离场\"},\n" +
+			"  {\"symbol\": \"SOLUSDT\", \"action\": \"update_stop_loss\", \"stop_loss\": 182.5, \"reasoning\": \"浮盈扩大，止损上移至保本\"}\n" +
+			"]}\n```\n\n" +
+			"**字段说明**:\n" +
+			"- `schema_version`: 固定为 2\n" +
+			"- `action`: open_long | open_short | close_long | close_short | update_stop_loss | update_take_profit | hold | wait\n" +
+			"- `update_stop_loss` / `update_take_profit`: 调整现有持仓的止损/止盈（不平仓），分别必填 stop_loss / take_profit；止损只能收紧（多仓上移、空仓下移）\n" +
+			"- `confidence`: 0-100（开仓建议≥75）\n" +
+			"- 开仓时必填: leverage, notional_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n" +
+			"- `notional_usd`: 仓位名义价值(USDT)，下单数量 = notional_usd / 价格；也可改用 `margin_usd`（保证金），名义价值 = margin_usd × leverage\n\n",
+		EN: "---\n\n" +
+			"# 📤 Output Format\n\n" +
+			"**Step 1: Chain of thought (plain text)**\n" +
+			"Briefly lay out your reasoning\n\n" +
+			"**Step 2: JSON decisions (with schema version)**\n\n" +
+			"```json\n{\"schema_version\": 2, \"decisions\": [\n" +
+			"  {\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"notional_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"Downtrend + MACD bearish cross\"},\n" +
+			"  {\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"Take profit\"},\n" +
+			"  {\"symbol\": \"SOLUSDT\", \"action\": \"update_stop_loss\", \"stop_loss\": 182.5, \"reasoning\": \"Profit growing, move stop to break-even\"}\n" +
+			"]}\n```\n\n" +
+			"**Fields**:\n" +
+			"- `schema_version`: always 2\n" +
+			"- `action`: open_long | open_short | close_long | close_short | update_stop_loss | update_take_profit | hold | wait\n" +
+			"- `update_stop_loss` / `update_take_profit`: adjust the stop loss / take profit of an existing position (without closing it); stop_loss / take_profit is required respectively; stops may only be tightened (raised for longs, lowered for shorts)\n" +
+			"- `confidence`: 0-100 (≥75 recommended for entries)\n" +
+			"- Required when opening: leverage, notional_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n" +
+			"- `notional_usd`: position notional value (USDT), order quantity = notional_usd / price; `margin_usd` may be used instead, notional = margin_usd × leverage\n\n",
+	},
+	"system.position_limits": {
+		ZH: "**⚠️ 当前可用仓位限制（已动态调整）**:\n" +
+			"- BTC/ETH: 名义价值(notional_usd) ≤ %.0f USDT\n" +
+			"- 其他币种: 名义价值(notional_usd) ≤ %.0f USDT\n" +
+			"- 示例BTC（杠杆%dx）：对应保证金(margin_usd)不应超过 %.0f USDT\n" +
+			"- 示例其他币（杠杆%dx）：对应保证金(margin_usd)不应超过 %.0f USDT\n" +
+			"- ⚠️ 这是当前实际可用限制，已根据账户表现、保证金使用率等动态调整，请严格遵守！\n\n",
+		EN: "**⚠️ Current position limits (dynamically adjusted)**:\n" +
+			"- BTC/ETH: notional (notional_usd) ≤ %.0f USDT\n" +
+			"- Other coins: notional (notional_usd) ≤ %.0f USDT\n" +
+			"- BTC example (%dx leverage): margin (margin_usd) should not exceed %.0f USDT\n" +
+			"- Altcoin example (%dx leverage): margin (margin_usd) should not exceed %.0f USDT\n" +
+			"- ⚠️ These are the limits actually available now, adjusted for account performance and margin usage. Follow them strictly!\n\n",
+	},
+	"system.close_only": {
+		ZH: "---\n\n" +
+			"# ⛔ 只平仓模式（本周期生效）\n\n" +
+			"原因：%s\n\n" +
+			"- 只允许 close_long | close_short | update_stop_loss | update_take_profit | hold | wait\n" +
+			"- 禁止 open_long / open_short：开仓决策会被剔除并记录原因，不要输出开仓\n" +
+			"- 你的任务是管理现有持仓：该止损的平仓，该保护利润的收紧止损，其余持仓继续持有\n\n",
+		EN: "---\n\n" +
+			"# ⛔ Close-Only Mode (in effect this cycle)\n\n" +
+			"Reason: %s\n\n" +
+			"- Only close_long | close_short | update_stop_loss | update_take_profit | hold | wait are allowed\n" +
+			"- open_long / open_short are forbidden: entries are dropped and logged as rejected, so do not output any\n" +
+			"- Your job is to manage existing positions: close what should be cut, tighten stops to protect profits, hold the rest\n\n",
+	},
+	"system.reminders": {
+		ZH: "---\n\n" +
+			"**记住**: \n" +
+			"- 🎯 目标是夏普比率，不是交易频率\n" +
+			"- ⚖️ 做多 = 做空，完全平等的工具\n" +
+			"- ✅ 宁可错过，不做低质量交易\n" +
+			"- 🛡️ 风险回报比1:3是底线\n" +
+			"- 📊 多空平衡是成功的关键\n",
+		EN: "---\n\n" +
+			"**Remember**: \n" +
+			"- 🎯 The goal is Sharpe ratio, not trade frequency\n" +
+			"- ⚖️ Long = short, equally valid tools\n" +
+			"- ✅ Better to miss a trade than take a low-quality one\n" +
+			"- 🛡️ 1:3 risk/reward is the floor\n" +
+			"- 📊 Long/short balance is key to success\n",
+	},
+
+	// ===== User Prompt 固定章节 =====
+	"user.symbol_alerts_title": {
+		ZH: "## 🚨 交易所状态预警\n\n",
+		EN: "## 🚨 Exchange Status Alerts\n\n",
+	},
+	"user.close_only": {
+		ZH: "## ⛔ 只平仓模式\n\n%s。当前禁止开新仓（open_long/open_short会被拒绝），只能平仓、调整止损止盈或观望。\n\n",
+		EN: "## ⛔ Close-Only Mode\n\n%s. New entries are blocked (open_long/open_short will be rejected); you may only close positions, adjust stops/targets or wait.\n\n",
+	},
+	"user.maintenance_title": {
+		ZH: "## 🛠 计划维护窗口\n\n",
+		EN: "## 🛠 Scheduled Maintenance\n\n",
+	},
+	"user.symbol_alert": {
+		ZH: "%s %s 持仓所在交易对%s，请尽快评估是否平仓",
+		EN: "%s %s position: the symbol %s, evaluate closing it soon",
+	},
+	"user.symbol_not_trading": {
+		ZH: "状态为%s（非正常交易）",
+		EN: "has status %s (not trading normally)",
+	},
+	"user.symbol_delisting": {
+		ZH: "将于 %s 下架/交割",
+		EN: "will be delisted/delivered at %s",
+	},
+	"user.maintenance_window": {
+		ZH: "%s: %s ~ %s（距开始%.1f小时）",
+		EN: "%s: %s ~ %s (starts in %.1f hours)",
+	},
+	"user.maintenance_rule": {
+		ZH: "维护窗口内交易暂停、持仓无人管理；窗口开始前%d分钟内的开仓会被拒绝，请提前安排持仓的止损止盈",
+		EN: "Trading is paused and positions are unmanaged during maintenance; entries within %d minutes before a window starts are rejected, so set stop loss / take profit for open positions in advance",
+	},
+
+	// ===== 模板特殊章节标题（模板内容包含这些标题时追加动态数据）=====
+	"heading.positions": {
+		ZH: "## 当前持仓",
+		EN: "## Current Positions",
+	},
+	"heading.candidates": {
+		ZH: "## 候选币种",
+		EN: "## Candidate Coins",
+	},
+	"heading.risk_budget": {
+		ZH: "## 🎯 风险预算",
+		EN: "## 🎯 Risk Budget",
+	},
+	"heading.breadth": {
+		ZH: "## 🌐 市场广度",
+		EN: "## 🌐 Market Breadth",
+	},
+	"heading.liquidations": {
+		ZH: "## 💥 爆仓监控",
+		EN: "## 💥 Liquidation Monitor",
+	},
+	"heading.learning": {
+		ZH: "## 📚 AI历史交易学习总结",
+		EN: "## 📚 AI Trade Learning Summary",
+	},
+
+	// ===== 持仓 / 候选币种 / 风险预算 =====
+	"position.held_minutes": {
+		ZH: " | 持仓时长%d分钟",
+		EN: " | held %dm",
+	},
+	"position.held_hours": {
+		ZH: " | 持仓时长%d小时%d分钟",
+		EN: " | held %dh%dm",
+	},
+	"position.line": {
+		ZH: "%d. %s %s | 入场价%.4f 当前价%.4f | 盈亏%+.2f%% | 杠杆%dx | 保证金%.0f | 强平价%.4f%s\n\n",
+		EN: "%d. %s %s | entry %.4f mark %.4f | PnL %+.2f%% | leverage %dx | margin %.0f | liq. price %.4f%s\n\n",
+	},
+	"position.exit_levels": {
+		ZH: "止损%.4f 止盈%.4f",
+		EN: "SL %.4f TP %.4f",
+	},
+	"position.orders": {
+		ZH: "   交易所挂单: %s（用update_stop_loss/update_take_profit调整，会撤销并替换这些挂单）\n",
+		EN: "   Exchange orders: %s (adjust with update_stop_loss/update_take_profit, which cancel and replace these orders)\n",
+	},
+	"position.order_item": {
+		ZH: "%s @%.4f 数量%s #%d",
+		EN: "%s @%.4f qty %s #%d",
+	},
+	"position.order_full": {
+		ZH: "全部",
+		EN: "entire position",
+	},
+	"position.order_type.stop_loss": {
+		ZH: "止损单",
+		EN: "Stop-loss",
+	},
+	"position.order_type.take_profit": {
+		ZH: "止盈单",
+		EN: "Take-profit",
+	},
+	"position.order_type.limit": {
+		ZH: "限价单",
+		EN: "Limit",
+	},
+	"position.order_type.other": {
+		ZH: "其他挂单",
+		EN: "Other",
+	},
+	"position.no_orders": {
+		ZH: "   ⚠️ 交易所没有该持仓的任何挂单（无止损保护），应使用update_stop_loss设置止损\n",
+		EN: "   ⚠️ No exchange orders protect this position (no stop-loss); set one with update_stop_loss\n",
+	},
+	"position.no_stop_order": {
+		ZH: "   ⚠️ 交易所没有该持仓的止损单，应使用update_stop_loss设置止损\n",
+		EN: "   ⚠️ No stop-loss order on the exchange for this position; set one with update_stop_loss\n",
+	},
+	"position.exit_updates": {
+		ZH: "（已调整%d次）",
+		EN: " (adjusted %d times)",
+	},
+	"candidate.dual_source": {
+		ZH: " (AI500+OI_Top双重信号)",
+		EN: " (AI500 + OI_Top dual signal)",
+	},
+	"candidate.oi_top": {
+		ZH: " (OI_Top持仓增长)",
+		EN: " (OI_Top open interest growth)",
+	},
+	"budget.summary": {
+		ZH: "\n\n日预算 %.2f USDT | 已用 %.2f USDT | 剩余 %.2f USDT\n",
+		EN: "\n\nDaily budget %.2f USDT | used %.2f USDT | remaining %.2f USDT\n",
+	},
+	"budget.entry": {
+		ZH: "- %s %s 占用 %.2f USDT\n",
+		EN: "- %s %s uses %.2f USDT\n",
+	},
+	"budget.rule": {
+		ZH: "新开仓的风险（|入场价-止损价| × 数量）不得超过剩余预算，否则会被拒绝执行\n",
+		EN: "The risk of a new entry (|entry - stop| × quantity) must not exceed the remaining budget, otherwise it is rejected\n",
+	},
+
+	// ===== 当前约束 =====
+	"constraints.title": {
+		ZH: "## 🧭 当前约束（与系统校验使用同一套限制，超出的决策会被拒绝）\n\n",
+		EN: "## 🧭 Current Constraints (the same limits validation enforces; decisions beyond them are rejected)\n\n",
+	},
+	"constraints.budget": {
+		ZH: "剩余日风险预算 %.2f / %.2f USDT，新开仓风险（|入场价-止损价| × 数量）超过剩余预算会被拒绝。\n",
+		EN: "Remaining daily risk budget %.2f / %.2f USDT; an entry whose risk (|entry - stop| × quantity) exceeds it is rejected.\n",
+	},
+	"constraints.budget_advisory": {
+		ZH: "剩余日风险预算 %.2f / %.2f USDT（仅作参考，未启用强制拦截）。\n",
+		EN: "Remaining daily risk budget %.2f / %.2f USDT (advisory only, not enforced).\n",
+	},
+	"constraints.limits_title": {
+		ZH: "单笔开仓上限（限制模式）：\n",
+		EN: "Per-entry limits (restricted mode):\n",
+	},
+	"constraints.class_major": {
+		ZH: "BTC/ETH",
+		EN: "BTC/ETH",
+	},
+	"constraints.class_alt": {
+		ZH: "其他币种",
+		EN: "Other coins",
+	},
+	"constraints.limits_line": {
+		ZH: "- %s: 杠杆 ≤ %dx，名义价值 ≤ %.0f USDT（信心度100时 ≤ %.0f），单笔风险 ≤ %.2f USDT，风险回报比 ≥ %.2f（信心度≥80时 ≥ %.2f，<60时更高）\n",
+		EN: "- %s: leverage ≤ %dx, notional ≤ %.0f USDT (≤ %.0f at confidence 100), risk per trade ≤ %.2f USDT, risk/reward ≥ %.2f (≥ %.2f at confidence ≥80, higher below 60)\n",
+	},
+
+	// ===== 持仓名额 =====
+
+	"throttle.total": {
+		ZH: "今日已开仓 %d/%d 次，剩余 %d 次。\n",
+		EN: "Entries today: %d/%d, %d remaining.\n",
+	},
+	"throttle.per_symbol": {
+		ZH: "单个币种每日最多开仓 %d 次，超出的开仓决策会导致整批决策被拒绝。\n",
+		EN: "Each coin may be entered at most %d times per day; exceeding it rejects the whole decision batch.\n",
+	},
+	"throttle.exhausted": {
+		ZH: "今日已达上限、不能再开仓的币种: %s\n",
+		EN: "Coins at today's limit (no more entries): %s\n",
+	},
+
+	"confidence.entry": {
+		ZH: "开仓决策的 confidence 必须 ≥ %d，低于该值会导致整批决策被拒绝。\n",
+		EN: "Entry decisions must have confidence ≥ %d; lower values reject the whole decision batch.\n",
+	},
+	"confidence.close": {
+		ZH: "平仓决策如给出 confidence，必须 ≥ %d。\n",
+		EN: "Close decisions that include a confidence must have confidence ≥ %d.\n",
+	},
+
+	"slots.summary": {
+		ZH: "最大持仓数 %d，当前持仓 %d，剩余可开仓名额 %d。\n",
+		EN: "Max positions %d, currently held %d, remaining slots %d.\n",
+	},
+	"slots.rule": {
+		ZH: "平仓决策先于开仓执行，本周期每平掉一个现有持仓可额外释放1个名额；" +
+			"本周期开仓决策数不得超过 %d + 本周期平仓数，超出时整批决策会被拒绝。\n",
+		EN: "Close decisions execute before entries, and each position closed this cycle frees one extra slot; " +
+			"entries this cycle must not exceed %d + closes this cycle, otherwise the whole batch is rejected.\n",
+	},
+
+	// ===== 分组风控额度 =====
+	"order_errors.title": {
+		ZH: "## ⚠️ 近24小时交易所拒单\n\n以下订单被交易所拒绝，请不要重复提交同类无效订单：\n",
+		EN: "## ⚠️ Exchange Rejections (last 24h)\n\nThe exchange rejected these orders; do not resubmit the same kind of invalid order:\n",
+	},
+	"order_errors.item": {
+		ZH: "- [%s] %d次，最近: %s %s — %s\n",
+		EN: "- [%s] %d times, latest: %s %s — %s\n",
+	},
+	"order_errors.hint.insufficient_margin": {
+		ZH: "保证金不足：降低仓位金额或杠杆，先平仓释放保证金再开新仓",
+		EN: "Insufficient margin: reduce position size or leverage, or close a position first to free margin",
+	},
+	"order_errors.hint.reduce_only": {
+		ZH: "只减仓被拒：只对当前实际持有的方向平仓，平仓数量不超过持仓数量",
+		EN: "Reduce-only rejected: only close the side you actually hold, never more than the position size",
+	},
+	"order_errors.hint.rate_limit": {
+		ZH: "请求频率超限：减少同一周期内的下单和调整次数",
+		EN: "Rate limited: submit fewer orders and adjustments per cycle",
+	},
+	"order_errors.hint.precision": {
+		ZH: "精度/最小下单量不满足：仓位金额不要低于交易所最小名义价值，止损止盈价格使用合理精度",
+		EN: "Precision/minimum size violated: keep position size above the exchange minimum notional and use sensible price precision for stops/targets",
+	},
+	"order_errors.hint.permission": {
+		ZH: "API权限问题：需要操作员处理，重复下单不会成功",
+		EN: "API permission problem: needs operator action, resubmitting will not succeed",
+	},
+	"groups.title": {
+		ZH: "## 🧱 分组风控额度\n\n",
+		EN: "## 🧱 Risk Group Limits\n\n",
+	},
+	"groups.table_header": {
+		ZH: "| 分组 | 币种 | 持仓数(当前/上限) | 名义敞口USDT(当前/上限) | 杠杆上限 |\n",
+		EN: "| Group | Coins | Positions (held/max) | Notional USDT (held/max) | Max leverage |\n",
+	},
+	"groups.unlimited": {
+		ZH: "不限",
+		EN: "no limit",
+	},
+	"groups.others": {
+		ZH: "其余币种",
+		EN: "all other coins",
+	},
+	"groups.rule": {
+		ZH: "\n各分组额度相互独立：开仓超出所属分组的持仓数、名义敞口或杠杆上限会被拒绝，同组平仓释放的名额可在本周期使用。\n\n",
+		EN: "\nEach group has its own isolated limits: entries exceeding the group's position count, notional or leverage cap are rejected; " +
+			"slots freed by closing a position in the same group can be used this cycle.\n\n",
+	},
+
+	// ===== 组合敞口与再平衡 =====
+	"rebalance.title": {
+		ZH: "## ⚖️ 组合敞口与再平衡\n\n",
+		EN: "## ⚖️ Portfolio Exposure & Rebalancing\n\n",
+	},
+	"rebalance.exposure": {
+		ZH: "总敞口 %.0f USDT | 多头 %.0f | 空头 %.0f | 净偏离 %+.1f%%\n",
+		EN: "Gross exposure %.0f USDT | long %.0f | short %.0f | net bias %+.1f%%\n",
+	},
+	"rebalance.sectors": {
+		ZH: "板块分布: ",
+		EN: "Sectors: ",
+	},
+	"rebalance.balanced": {
+		ZH: "当前敞口分布在目标范围内。\n\n",
+		EN: "Exposure is within target ranges.\n\n",
+	},
+	"rebalance.suggestions": {
+		ZH: "再平衡建议:\n",
+		EN: "Rebalancing suggestions:\n",
+	},
+	"rebalance.symbol_over": {
+		ZH: "%s 占总敞口%.1f%%（上限%.0f%%），建议减仓约%.0f USDT或开立其他币种分散",
+		EN: "%s is %.1f%% of gross exposure (limit %.0f%%); reduce by about %.0f USDT or diversify into other coins",
+	},
+	"rebalance.sector_over": {
+		ZH: "板块[%s]占总敞口%.1f%%（上限%.0f%%），避免继续加仓该板块",
+		EN: "Sector [%s] is %.1f%% of gross exposure (limit %.0f%%); avoid adding to this sector",
+	},
+	"rebalance.net_long": {
+		ZH: "净偏多%.1f%%（上限%.0f%%），优先减少多头或寻找做空机会",
+		EN: "Net long bias %.1f%% (limit %.0f%%); reduce longs first or look for short setups",
+	},
+	"rebalance.net_short": {
+		ZH: "净偏空%.1f%%（上限%.0f%%），优先减少空头或寻找做多机会",
+		EN: "Net short bias %.1f%% (limit %.0f%%); reduce shorts first or look for long setups",
+	},
+
+	// ===== 波动率与1R仓位 =====
+	"sizing.title": {
+		ZH: "## 📏 波动率与1R仓位\n\n",
+		EN: "## 📏 Volatility & 1R Position Size\n\n",
+	},
+	"sizing.basis": {
+		ZH: "基于%s K线：ATR%%=ATR14/价格，单根波动=对数收益率标准差，止损距离=%.1f×ATR14；1R风险=%.2f USDT（净值的%.2f%%",
+		EN: "Based on %s klines: ATR%%=ATR14/price, per-bar vol = stdev of log returns, stop distance = %.1f×ATR14; 1R risk = %.2f USDT (%.2f%% of equity",
+	},
+	"sizing.budget_cap": {
+		ZH: "，且不超过剩余日风险预算",
+		EN: ", capped by the remaining daily risk budget",
+	},
+	"sizing.basis_end": {
+		ZH: "）\n\n",
+		EN: ")\n\n",
+	},
+	"sizing.table_header": {
+		ZH: "| 币种 | ATR% | 单根波动 | 年化波动 | 止损距离 | 多/空建议止损 | 1R名义价值(USDT) |\n",
+		EN: "| Symbol | ATR% | Per-bar vol | Annualized vol | Stop distance | Long/short stop | 1R notional (USDT) |\n",
+	},
+	"sizing.budget_exhausted": {
+		ZH: "⚠️ 日风险预算已用完，本周期不应新开仓。\n\n",
+		EN: "⚠️ The daily risk budget is used up; do not open new positions this cycle.\n\n",
+	},
+	"sizing.guidance": {
+		ZH: "开仓时以1R名义价值为基准（notional_usd），按信心度在0.5R~1.5R之间调整；止损比建议更宽时应按比例缩小仓位。\n\n",
+		EN: "Use the 1R notional as the baseline notional_usd and scale between 0.5R and 1.5R by confidence; shrink the size proportionally when the stop is wider than suggested.\n\n",
+	},
+
+	// ===== 资金费结算 =====
+	"funding.title": {
+		ZH: "## ⏱ 资金费结算\n\n",
+		EN: "## ⏱ Funding Settlement\n\n",
+	},
+	"funding.note": {
+		ZH: "单次费率为下次结算实际收取的费率（正数多单支付、空单收取）；预计资金费按1R名义价值估算（无1R仓位时按1000 USDT），正数为收入\n\n",
+		EN: "The settlement rate is what the next settlement actually charges (positive: longs pay, shorts receive); expected funding uses the 1R notional (1000 USDT without a 1R size), positive means income\n\n",
+	},
+	"funding.table_header": {
+		ZH: "| 币种 | 距下次结算 | 单次费率 | 参考名义价值(USDT) | 多/空预计资金费(USDT) |\n",
+		EN: "| Symbol | Next settlement in | Settlement rate | Reference notional (USDT) | Long/short expected funding (USDT) |\n",
+	},
+	"funding.row": {
+		ZH: "| %s | %.0f分钟内 | %+.4f%% | %.0f | %+.2f / %+.2f |",
+		EN: "| %s | within %.0f min | %+.4f%% | %.0f | %+.2f / %+.2f |",
+	},
+	"funding.entry_delay": {
+		ZH: "距结算不足%d分钟且单次费率对开仓方向不利超过%.4f%%的开仓会被延迟到结算之后。\n\n",
+		EN: "Entries within %d minutes of settlement whose settlement rate is adverse to the entry side by more than %.4f%% are delayed until after settlement.\n\n",
+	},
+
+	"time_of_day.title": {
+		ZH: "## ⏰ 开仓时段表现（最近%d笔，按开仓时间UTC统计，整体胜率%.1f%%）\n\n",
+		EN: "## ⏰ Entry Timing (last %d trades by entry time in UTC, overall win rate %.1f%%)\n\n",
+	},
+	"time_of_day.weak": {
+		ZH: "- %s 开仓历史上亏损：%d笔，胜率%.1f%%，平均%+.2f USDT → 该时段开仓需要更强的信号\n",
+		EN: "- Your %s entries historically lose: %d trades, win rate %.1f%%, avg %+.2f USDT → require stronger signals in this window\n",
+	},
+	"time_of_day.strong": {
+		ZH: "- %s 开仓历史表现较好：%d笔，胜率%.1f%%，平均%+.2f USDT\n",
+		EN: "- Your %s entries historically do well: %d trades, win rate %.1f%%, avg %+.2f USDT\n",
+	},
+	"time_of_day.now_weak": {
+		ZH: "⚠️ 当前处于弱势时段（%s）\n",
+		EN: "⚠️ Currently inside a weak window (%s)\n",
+	},
+	"time_of_day.weekday.sun": {
+		ZH: "周日",
+		EN: "Sunday",
+	},
+	"time_of_day.weekday.mon": {
+		ZH: "周一",
+		EN: "Monday",
+	},
+	"time_of_day.weekday.tue": {
+		ZH: "周二",
+		EN: "Tuesday",
+	},
+	"time_of_day.weekday.wed": {
+		ZH: "周三",
+		EN: "Wednesday",
+	},
+	"time_of_day.weekday.thu": {
+		ZH: "周四",
+		EN: "Thursday",
+	},
+	"time_of_day.weekday.fri": {
+		ZH: "周五",
+		EN: "Friday",
+	},
+	"time_of_day.weekday.sat": {
+		ZH: "周六",
+		EN: "Saturday",
+	},
+
+	// ===== 爆仓监控 =====
+	"liq.disconnected": {
+		ZH: "⚠️ 爆仓数据流当前断开，以下统计可能不完整\n",
+		EN: "⚠️ The liquidation stream is disconnected; the stats below may be incomplete\n",
+	},
+	"liq.partial": {
+		ZH: "（数据流启动仅%d分钟，1小时窗口不完整）\n",
+		EN: "(stream started only %d minutes ago; the 1h window is incomplete)\n",
+	},
+	"liq.totals": {
+		ZH: "全市场爆仓 5分钟: 多头 %.2fM / 空头 %.2fM USDT | 1小时: 多头 %.2fM / 空头 %.2fM USDT\n",
+		EN: "Market-wide liquidations 5m: longs %.2fM / shorts %.2fM USDT | 1h: longs %.2fM / shorts %.2fM USDT\n",
+	},
+	"liq.cascade": {
+		ZH: "🚨 正在发生连环爆仓：",
+		EN: "🚨 Liquidation cascade in progress: ",
+	},
+	"liq.shorts_squeezed": {
+		ZH: "空头被挤压",
+		EN: "shorts squeezed",
+	},
+	"liq.longs_flushed": {
+		ZH: "多头被清洗",
+		EN: "longs flushed",
+	},
+	"liq.cascade_symbol": {
+		ZH: "%s(5分钟%.2fM，%s)",
+		EN: "%s(5m %.2fM, %s)",
+	},
+	"liq.cascade_market": {
+		ZH: "全市场爆仓金额超阈值",
+		EN: "market-wide liquidations above threshold",
+	},
+	"liq.pause_entries": {
+		ZH: "连环爆仓期间系统会拒绝新开仓，只考虑管理现有持仓\n",
+		EN: "New entries are rejected during the cascade; only manage existing positions\n",
+	},
+	"liq.no_cascade": {
+		ZH: "当前无连环爆仓\n",
+		EN: "No liquidation cascade right now\n",
+	},
+	"liq.top": {
+		ZH: "1小时爆仓最多: ",
+		EN: "Most liquidated (1h): ",
+	},
+	"liq.top_item": {
+		ZH: "%s 多%.2fM/空%.2fM",
+		EN: "%s L%.2fM/S%.2fM",
+	},
+
+	// ===== 市场广度 =====
+	"breadth.dominance": {
+		ZH: "BTC市值占比: %.2f%%",
+		EN: "BTC dominance: %.2f%%",
+	},
+	"breadth.rising": {
+		ZH: "（1h %+.2f / 24h %+.2f，上升：资金回流BTC，山寨币相对承压）\n",
+		EN: " (1h %+.2f / 24h %+.2f, rising: money rotating into BTC, altcoins under relative pressure)\n",
+	},
+	"breadth.falling": {
+		ZH: "（1h %+.2f / 24h %+.2f，下降：资金流向山寨币，风险偏好上升）\n",
+		EN: " (1h %+.2f / 24h %+.2f, falling: money flowing into altcoins, risk appetite rising)\n",
+	},
+	"breadth.flat": {
+		ZH: "（1h %+.2f / 24h %+.2f，基本持平）\n",
+		EN: " (1h %+.2f / 24h %+.2f, flat)\n",
+	},
+	"breadth.no_history": {
+		ZH: "（历史数据不足，暂无趋势）\n",
+		EN: " (not enough history for a trend yet)\n",
+	},
+	"breadth.unavailable": {
+		ZH: "BTC市值占比: 暂不可用\n",
+		EN: "BTC dominance: unavailable\n",
+	},
+	"breadth.above_ema": {
+		ZH: "4h EMA50上方币种: %d/%d (%.0f%%)",
+		EN: "Coins above 4h EMA50: %d/%d (%.0f%%)",
+	},
+	"breadth.broad_up": {
+		ZH: " → 普涨，多头占优\n",
+		EN: " → broad rally, bulls in control\n",
+	},
+	"breadth.broad_down": {
+		ZH: " → 普跌，空头占优\n",
+		EN: " → broad decline, bears in control\n",
+	},
+	"breadth.mixed": {
+		ZH: " → 分化\n",
+		EN: " → mixed\n",
+	},
+	"breadth.oi": {
+		ZH: "全市场OI 1h变化: %+.2f%%（%d个币种按持仓价值加权）\n",
+		EN: "Market-wide OI 1h change: %+.2f%% (%d coins weighted by OI value)\n",
+	},
+	"breadth.oi_unavailable": {
+		ZH: "全市场OI 1h变化: 暂无持仓量历史\n",
+		EN: "Market-wide OI 1h change: no open interest history yet\n",
+	},
+
+	// ===== 订单流 =====
+	"orderflow.stop_loss": {
+		ZH: "止损",
+		EN: "Stop loss",
+	},
+	"orderflow.take_profit": {
+		ZH: "止盈",
+		EN: "Take profit",
+	},
+	"orderflow.line": {
+		ZH: "⚡ 距%s仅%.2f%% | 订单流: 盘口失衡%+.2f | OFI 1分钟%+.2f / 5分钟%+.2f | 主动买入占比 1分钟%.0f%% / 5分钟%.0f%%（成交额 %.2fM / %.2fM）",
+		EN: "⚡ %s only %.2f%% away | order flow: book imbalance %+.2f | OFI 1m %+.2f / 5m %+.2f | aggressive buy ratio 1m %.0f%% / 5m %.0f%% (volume %.2fM / %.2fM)",
+	},
+	"orderflow.coverage": {
+		ZH: "（仅覆盖%d秒）",
+		EN: " (covers only %d seconds)",
+	},
+	"orderflow.supportive": {
+		ZH: "订单流支持持仓方向，可考虑继续持有等待止盈\n",
+		EN: "Order flow supports the position; consider holding for the take profit\n",
+	},
+	"orderflow.against": {
+		ZH: "订单流与持仓方向相反，可考虑在触发止损前提前平仓\n",
+		EN: "Order flow opposes the position; consider closing before the stop loss triggers\n",
+	},
+	"orderflow.neutral": {
+		ZH: "订单流无明显方向\n",
+		EN: "Order flow shows no clear direction\n",
+	},
+
+	// ===== 币种新闻 =====
+	"news.title": {
+		ZH: "近期新闻（UTC时间，仅供参考，标题不代表价格方向）：\n",
+		EN: "Recent headlines (UTC, for context only; headlines do not imply price direction):\n",
+	},
+	"news.item": {
+		ZH: "- [%s] %s（%s）\n",
+		EN: "- [%s] %s (%s)\n",
+	},
+
+	// ===== 行情数据 =====
+	"market.degraded": {
+		ZH: "⚠️ DATA DEGRADED（数据质量降级，指标可能失真，不要据此开新仓）: %s\n",
+		EN: "⚠️ DATA DEGRADED (indicators may be distorted, do not open new positions on this data): %s\n",
+	},
+	"pattern.confidence": {
+		ZH: "%s 置信度%.0f%%",
+		EN: "%s confidence %.0f%%",
+	},
+	"pattern.hit_rate": {
+		ZH: " 历史胜率%.0f%%(n=%d)",
+		EN: " hit rate %.0f%%(n=%d)",
+	},
+	"pattern.hammer":               {EN: "🔨 Hammer (bullish)"},
+	"pattern.inverted_hammer":      {EN: "🔨 Inverted hammer (potential reversal)"},
+	"pattern.bullish_engulfing":    {EN: "📈 Bullish engulfing (strongly bullish)"},
+	"pattern.bearish_engulfing":    {EN: "📉 Bearish engulfing (strongly bearish)"},
+	"pattern.doji":                 {EN: "✨ Doji (indecision)"},
+	"pattern.shooting_star":        {EN: "💫 Shooting star (bearish)"},
+	"pattern.three_white_soldiers": {EN: "🚀 Three white soldiers (strong rally)"},
+	"pattern.three_black_crows":    {EN: "💀 Three black crows (strong decline)"},
+	"pattern.morning_star":         {EN: "🌅 Morning star (bottom reversal)"},
+	"pattern.evening_star":         {EN: "🌆 Evening star (top reversal)"},
+	"pattern.tweezer_bottom":       {EN: "🥢 Tweezer bottom (support confirmed)"},
+	"pattern.tweezer_top":          {EN: "🥢 Tweezer top (resistance confirmed)"},
+	"pattern.inside_bar":           {EN: "📦 Inside bar (volatility contraction)"},
+	"pattern.bullish_outside_bar":  {EN: "📈 Bullish outside bar"},
+	"pattern.bearish_outside_bar":  {EN: "📉 Bearish outside bar"},
+	"pattern.bull_flag":            {EN: "🚩 Bull flag (trend continuation)"},
+	"pattern.bear_flag":            {EN: "🚩 Bear flag (trend continuation)"},
+	"pattern.range_breakout_up":    {EN: "⬆️ Range breakout up"},
+	"pattern.range_breakout_down":  {EN: "⬇️ Range breakout down"},
+
+	// ===== AI学习总结 =====
+	"learning.summary_system": {
+		ZH: `你是一个专业的加密货币交易分析师。请分析这些历史交易记录，用简洁的Markdown格式输出总结。
+
+要求：
+1. 找出3个最关键的失败模式（什么总是导致亏损）
+2. 找出2个成功模式（什么策略有效）
+3. 提出3条具体的改进建议
+
+**重要**：只总结交易策略和模式，**不要提及具体币种名称**（如BTC、ETH等），避免形成偏见影响未来判断。
+
+格式：
+## ❌ 避免这些错误
+1. [具体错误模式，1句话，不提币种]
+2. ...
+
+## ✅ 复制这些成功策略
+1. [具体成功模式，1句话，不提币种]
+2. ...
+
+## 💡 改进建议
+1. [具体建议，1句话]
+2. ...
+
+保持简洁，每个要点不超过15个字。`,
+		EN: `You are a professional crypto trading analyst. Analyze these historical trades and write a concise Markdown summary.
+
+Requirements:
+1. Identify the 3 most important failure patterns (what keeps causing losses)
+2. Identify 2 success patterns (which strategies work)
+3. Give 3 concrete improvement suggestions
+
+**Important**: summarize strategies and patterns only, **do not mention specific coins** (such as BTC, ETH), to avoid biasing future decisions.
+
+Format:
+## ❌ Avoid These Mistakes
+1. [specific failure pattern, one sentence, no coin names]
+2. ...
+
+## ✅ Repeat These Successes
+1. [specific success pattern, one sentence, no coin names]
+2. ...
+
+## 💡 Improvements
+1. [specific suggestion, one sentence]
+2. ...
+
+Keep it short, at most 12 words per point.`,
+	},
+	"learning.trades_title": {
+		ZH: "# 最近%d笔交易记录\n\n",
+		EN: "# Last %d trades\n\n",
+	},
+	"learning.trade_pnl": {
+		ZH: "   盈亏: %.2f USDT (%.1f%%) | 持仓: %d分钟\n",
+		EN: "   PnL: %.2f USDT (%.1f%%) | held: %d min\n",
+	},
+	"learning.trade_failure": {
+		ZH: "   失败: %s\n",
+		EN: "   failure: %s\n",
+	},
+	"learning.trade_premature": {
+		ZH: "   ⚠️ 过早平仓\n",
+		EN: "   ⚠️ closed prematurely\n",
+	},
+	"learning.trade_indicators": {
+		ZH: "   开仓指标: RSI7=%.1f | MACD=%.4f | 量比=%.2f\n",
+		EN: "   entry indicators: RSI7=%.1f | MACD=%.4f | volume ratio=%.2f\n",
+	},
+	"learning.attribution_title": {
+		ZH: "# 开仓指标归因（%d笔有开仓快照）\n\n",
+		EN: "# Entry indicator attribution (%d trades with entry snapshots)\n\n",
+	},
+	"learning.attribution_bucket": {
+		ZH: "- %s: %d笔 | 胜率 %.0f%% | 平均盈亏 %+.2f USDT",
+		EN: "- %s: %d trades | win rate %.0f%% | avg PnL %+.2f USDT",
+	},
+	"learning.attribution_loss_multiple": {
+		ZH: " | 平均亏损为整体的 %.1f 倍",
+		EN: " | avg loss %.1fx the overall average",
+	},
+	"learning.analysis_system": {
+		ZH: `你是专业的交易分析AI，负责分析历史交易数据并生成学习总结。
+
+请基于提供的交易数据，分析以下方面：
+1. **成功模式识别**：什么样的市场条件、技术指标组合、持仓时长等导致了盈利交易
+2. **失败模式识别**：什么情况下容易亏损，常见的错误决策模式
+3. **风险管理评估**：止损执行情况、仓位管理、风险回报比分析
+4. **时间维度分析**：不同时段的交易表现，持仓时长对收益的影响
+5. **币种偏好分析**：哪些币种表现更好，为什么
+6. **改进建议**：基于数据分析提出具体的策略优化建议
+
+输出格式要求：
+- 使用markdown格式
+- 结构清晰，包含数据支撑
+- 重点突出关键发现和可执行的改进建议
+- 控制在800-1200字以内`,
+		EN: `You are a professional trading analysis AI that analyzes historical trades and writes a learning summary.
+
+Based on the trade data provided, analyze:
+1. **Success patterns**: which market conditions, indicator combinations and holding times led to winning trades
+2. **Failure patterns**: when losses tend to happen and the common decision mistakes
+3. **Risk management**: stop-loss execution, position sizing and risk/reward
+4. **Time analysis**: performance by time of day and how holding time affects returns
+5. **Symbol preference**: which coins performed better and why
+6. **Improvements**: concrete strategy improvements backed by the data
+
+Output requirements:
+- Markdown format
+- Clear structure backed by data
+- Emphasize key findings and actionable improvements
+- Keep it within 500-800 words`,
+	},
+	"learning.analysis_title": {
+		ZH: "## 历史交易数据分析\n\n",
+		EN: "## Historical Trade Analysis\n\n",
+	},
+	"learning.analysis_trades": {
+		ZH: "### 交易结果数据 (共%d笔交易)\n",
+		EN: "### Trade results (%d trades)\n",
+	},
+	"learning.analysis_more_trades": {
+		ZH: "... 还有%d笔交易数据\n",
+		EN: "... %d more trades\n",
+	},
+	"learning.analysis_trade": {
+		ZH: "**交易%d**: %s %s | 盈亏: %.2f USDT (%.2f%%) | 持仓时长: %s | 开仓: %.4f | 平仓: %.4f\n",
+		EN: "**Trade %d**: %s %s | PnL: %.2f USDT (%.2f%%) | held: %s | entry: %.4f | exit: %.4f\n",
+	},
+	"learning.analysis_entry_reason": {
+		ZH: "  开仓理由: %s\n",
+		EN: "  entry reason: %s\n",
+	},
+	"learning.analysis_exit_reason": {
+		ZH: "  平仓理由: %s\n",
+		EN: "  exit reason: %s\n",
+	},
+	"learning.analysis_decisions": {
+		ZH: "\n### 最近决策记录 (共%d条)\n",
+		EN: "\n### Recent decisions (%d records)\n",
+	},
+	"learning.analysis_more_decisions": {
+		ZH: "... 还有%d条决策记录\n",
+		EN: "... %d more decisions\n",
+	},
+	"learning.analysis_decision": {
+		ZH: "**决策%d**: 周期%d | 时间: %s | 成功: %t\n",
+		EN: "**Decision %d**: cycle %d | time: %s | success: %t\n",
+	},
+	"learning.analysis_error": {
+		ZH: "  错误: %s\n",
+		EN: "  error: %s\n",
+	},
+	"learning.analysis_request": {
+		ZH: "\n请基于以上数据进行深入分析，识别成功和失败的模式，并提出具体的改进建议。",
+		EN: "\nAnalyze the data above in depth, identify success and failure patterns, and propose concrete improvements.",
+	},
+
+	// ===== 决策审核模型 =====
+	"critic.system": {
+		ZH: "你是交易决策审核员。分析模型已经给出了候选决策，你的任务是逐条对照下面的规则检查，不要重新分析行情。\n\n" +
+			"# 审核规则\n%s\n" +
+			"# 输出格式\n" +
+			"只输出JSON数组，每个待审核决策一项：\n" +
+			"```json\n[{\"index\": 1, \"verdict\": \"approve\", \"reason\": \"符合规则\"}, {\"index\": 2, \"verdict\": \"adjust\", \"confidence\": 60, \"reason\": \"逆4小时趋势\"}, {\"index\": 3, \"verdict\": \"veto\", \"reason\": \"止损在强平价之外\"}]\n```\n" +
+			"- `verdict`: approve（通过）| adjust（通过但下调信心度，必填confidence）| veto（否决，不执行）\n" +
+			"- 只有明确违反规则时才否决，reason用一句话说明违反了哪条规则\n",
+		EN: "You are a trading decision reviewer. The analysis model has already produced candidate decisions; check each one against the rules below. Do not re-analyze the market.\n\n" +
+			"# Review rules\n%s\n" +
+			"# Output format\n" +
+			"Output only a JSON array with one entry per decision under review:\n" +
+			"```json\n[{\"index\": 1, \"verdict\": \"approve\", \"reason\": \"Follows the rules\"}, {\"index\": 2, \"verdict\": \"adjust\", \"confidence\": 60, \"reason\": \"Against the 4h trend\"}, {\"index\": 3, \"verdict\": \"veto\", \"reason\": \"Stop loss beyond liquidation price\"}]\n```\n" +
+			"- `verdict`: approve | adjust (approve with lower confidence, confidence required) | veto (do not execute)\n" +
+			"- Veto only on a clear rule violation; state the violated rule in one sentence in reason\n",
+	},
+	"critic.default_rules": {
+		ZH: "开仓必须设置止损和止盈，且风险回报比不低于1:2\n" +
+			"多仓止损必须低于当前价、止盈高于当前价，空仓相反\n" +
+			"止损不能在强平价之外\n" +
+			"不能对已有持仓的币种同方向重复开仓\n" +
+			"逆4小时趋势开仓时信心度不应超过60\n" +
+			"平仓和调整止损必须针对现有持仓，理由要与持仓盈亏和行情一致",
+		EN: "Entries must set a stop loss and take profit with a risk/reward of at least 1:2\n" +
+			"For longs the stop must be below and the target above the current price; the reverse for shorts\n" +
+			"The stop loss must not lie beyond the liquidation price\n" +
+			"Do not open a second position in the same direction on a symbol already held\n" +
+			"Entries against the 4h trend should not exceed 60 confidence\n" +
+			"Closes and stop updates must target an existing position, with reasoning consistent with its PnL and the market",
+	},
+	"critic.account": {
+		ZH: "# 账户\n净值 %.2f USDT | 可用 %.2f USDT | 保证金使用率 %.1f%%\n\n",
+		EN: "# Account\nEquity %.2f USDT | available %.2f USDT | margin used %.1f%%\n\n",
+	},
+	"critic.positions": {
+		ZH: "# 当前持仓\n",
+		EN: "# Current positions\n",
+	},
+	"critic.position": {
+		ZH: "- %s %s | 入场 %.4f | 标记 %.4f | %dx | 盈亏 %+.2f%% | 强平 %.4f\n",
+		EN: "- %s %s | entry %.4f | mark %.4f | %dx | PnL %+.2f%% | liquidation %.4f\n",
+	},
+	"critic.market": {
+		ZH: "# 行情\n",
+		EN: "# Market\n",
+	},
+	"critic.market_line": {
+		ZH: "- %s 价格 %.4f | 1小时 %+.2f%% | 4小时 %+.2f%% | RSI7 %.1f | 资金费率 %.4f%%\n",
+		EN: "- %s price %.4f | 1h %+.2f%% | 4h %+.2f%% | RSI7 %.1f | funding %.4f%%\n",
+	},
+	"critic.decisions": {
+		ZH: "\n# 待审核决策\n",
+		EN: "\n# Decisions under review\n",
+	},
+}
@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"fmt"
+	"nofx/database/models"
+	"sort"
+	"time"
+)
+
+// candidateFlappingChurnPct 平均每周期变动比例超过该值视为候选池在抖动（上游币池接口不稳定）
+const candidateFlappingChurnPct = 30.0
+
+// CandidatePoolDiff 候选池相对上一周期的变化
+type CandidatePoolDiff struct {
+	RecordID        int64     `json:"record_id"`
+	CycleNumber     int       `json:"cycle_number"`
+	Timestamp       time.Time `json:"timestamp"`
+	PrevRecordID    int64     `json:"prev_record_id"`    // 上一周期的决策记录ID（0表示没有上一周期）
+	PrevCycleNumber int       `json:"prev_cycle_number"` // 上一周期编号
+	Symbols         []string  `json:"symbols"`           // 本周期候选池
+	Added           []string  `json:"added"`             // 新加入的币种
+	Removed         []string  `json:"removed"`           // 移出的币种
+	ChurnPct        float64   `json:"churn_pct"`         // 变动比例：(新增+移出)/两个周期的并集(%)
+}
+
+// CandidateSymbolFlap 反复进出候选池的币种
+type CandidateSymbolFlap struct {
+	Symbol    string `json:"symbol"`
+	Entries   int    `json:"entries"`   // 加入候选池次数（不含统计窗口第一个周期已在池中）
+	Exits     int    `json:"exits"`     // 移出候选池次数
+	Reentries int    `json:"reentries"` // 移出后又重新加入的次数
+	Cycles    int    `json:"cycles"`    // 在池中的周期数
+}
+
+// CandidateChurnReport 候选池变动统计
+type CandidateChurnReport struct {
+	Cycles          int                   `json:"cycles"`           // 统计的周期数
+	AvgPoolSize     float64               `json:"avg_pool_size"`    // 平均候选池大小
+	UniqueSymbols   int                   `json:"unique_symbols"`   // 出现过的币种数
+	StableSymbols   []string              `json:"stable_symbols"`   // 所有周期都在池中的币种
+	ChangedCycles   int                   `json:"changed_cycles"`   // 候选池有变化的周期数
+	AvgChurnPct     float64               `json:"avg_churn_pct"`    // 平均每周期变动比例(%)
+	MaxChurnPct     float64               `json:"max_churn_pct"`    // 单周期最大变动比例(%)
+	AvgAdded        float64               `json:"avg_added"`        // 平均每周期新加入币种数
+	AvgRemoved      float64               `json:"avg_removed"`      // 平均每周期移出币种数
+	AvgTenure       float64               `json:"avg_tenure"`       // 币种每次连续留在池中的平均周期数
+	Flapping        bool                  `json:"flapping"`         // 平均变动比例超过阈值，候选池在抖动
+	FlappingSymbols []CandidateSymbolFlap `json:"flapping_symbols"` // 移出后又重新加入的币种（按重新加入次数倒序）
+	Diffs           []CandidatePoolDiff   `json:"diffs"`            // 每周期相对上一周期的变化（最新在前）
+}
+
+// diffCandidatePool 计算候选池相对上一周期的变化（prev为nil表示没有上一周期）
+func diffCandidatePool(prev, cur *models.CandidatePool) CandidatePoolDiff {
+	diff := CandidatePoolDiff{
+		RecordID:    cur.RecordID,
+		CycleNumber: cur.CycleNumber,
+		Timestamp:   cur.Timestamp,
+		Symbols:     cur.Symbols,
+		Added:       []string{},
+		Removed:     []string{},
+	}
+	if prev == nil {
+		return diff
+	}
+	diff.PrevRecordID = prev.RecordID
+	diff.PrevCycleNumber = prev.CycleNumber
+
+	prevSet := symbolSet(prev.Symbols)
+	curSet := symbolSet(cur.Symbols)
+	for _, symbol := range cur.Symbols {
+		if !prevSet[symbol] {
+			diff.Added = append(diff.Added, symbol)
+		}
+	}
+	for _, symbol := range prev.Symbols {
+		if !curSet[symbol] {
+			diff.Removed = append(diff.Removed, symbol)
+		}
+	}
+
+	union := len(prevSet) + len(diff.Added)
+	if union > 0 {
+		diff.ChurnPct = float64(len(diff.Added)+len(diff.Removed)) / float64(union) * 100
+	}
+	return diff
+}
+
+// symbolSet 币种列表转集合
+func symbolSet(symbols []string) map[string]bool {
+	set := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		set[symbol] = true
+	}
+	return set
+}
+
+// AnalyzeCandidateChurn 统计候选池的变动（pools按时间从旧到新）
+func AnalyzeCandidateChurn(pools []*models.CandidatePool) *CandidateChurnReport {
+	report := &CandidateChurnReport{
+		Cycles:          len(pools),
+		StableSymbols:   []string{},
+		FlappingSymbols: []CandidateSymbolFlap{},
+		Diffs:           []CandidatePoolDiff{},
+	}
+	if len(pools) == 0 {
+		return report
+	}
+
+	flaps := make(map[string]*CandidateSymbolFlap)
+	runs := make(map[string]int) // 币种当前连续在池中的周期数
+	var tenureTotal, tenureCount int
+	totalSize := 0
+
+	for i, pool := range pools {
+		totalSize += len(pool.Symbols)
+		for _, symbol := range pool.Symbols {
+			flap, ok := flaps[symbol]
+			if !ok {
+				flap = &CandidateSymbolFlap{Symbol: symbol}
+				flaps[symbol] = flap
+			}
+			flap.Cycles++
+			runs[symbol]++
+		}
+		if i == 0 {
+			report.Diffs = append(report.Diffs, diffCandidatePool(nil, pool))
+			continue
+		}
+
+		diff := diffCandidatePool(pools[i-1], pool)
+		report.Diffs = append(report.Diffs, diff)
+		for _, symbol := range diff.Added {
+			flaps[symbol].Entries++
+			if flaps[symbol].Exits > 0 {
+				flaps[symbol].Reentries++
+			}
+		}
+		for _, symbol := range diff.Removed {
+			flaps[symbol].Exits++
+			tenureTotal += runs[symbol]
+			tenureCount++
+			delete(runs, symbol)
+		}
+
+		if len(diff.Added)+len(diff.Removed) > 0 {
+			report.ChangedCycles++
+		}
+		report.AvgChurnPct += diff.ChurnPct
+		report.AvgAdded += float64(len(diff.Added))
+		report.AvgRemoved += float64(len(diff.Removed))
+		if diff.ChurnPct > report.MaxChurnPct {
+			report.MaxChurnPct = diff.ChurnPct
+		}
+	}
+
+	// 统计窗口结束时仍在池中的币种，按已持续的周期数计入
+	for _, run := range runs {
+		tenureTotal += run
+		tenureCount++
+	}
+	if tenureCount > 0 {
+		report.AvgTenure = float64(tenureTotal) / float64(tenureCount)
+	}
+
+	report.AvgPoolSize = float64(totalSize) / float64(len(pools))
+	report.UniqueSymbols = len(flaps)
+	if transitions := len(pools) - 1; transitions > 0 {
+		report.AvgChurnPct /= float64(transitions)
+		report.AvgAdded /= float64(transitions)
+		report.AvgRemoved /= float64(transitions)
+	}
+	report.Flapping = report.AvgChurnPct > candidateFlappingChurnPct
+
+	for _, flap := range flaps {
+		if flap.Cycles == len(pools) {
+			report.StableSymbols = append(report.StableSymbols, flap.Symbol)
+		}
+		if flap.Reentries > 0 {
+			report.FlappingSymbols = append(report.FlappingSymbols, *flap)
+		}
+	}
+	sort.Strings(report.StableSymbols)
+	sort.Slice(report.FlappingSymbols, func(i, j int) bool {
+		a, b := report.FlappingSymbols[i], report.FlappingSymbols[j]
+		if a.Reentries != b.Reentries {
+			return a.Reentries > b.Reentries
+		}
+		if a.Entries+a.Exits != b.Entries+b.Exits {
+			return a.Entries+a.Exits > b.Entries+b.Exits
+		}
+		return a.Symbol < b.Symbol
+	})
+
+	// 最新周期在前
+	for i, j := 0, len(report.Diffs)-1; i < j; i, j = i+1, j-1 {
+		report.Diffs[i], report.Diffs[j] = report.Diffs[j], report.Diffs[i]
+	}
+	return report
+}
+
+// AnalyzeCandidateChurn 统计最近N个周期的候选池变动
+func (l *DecisionLogger) AnalyzeCandidateChurn(cycles int) (*CandidateChurnReport, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	pools, err := l.db.Decision().GetCandidatePools(0, cycles)
+	if err != nil {
+		return nil, fmt.Errorf("获取候选池记录失败: %w", err)
+	}
+	return AnalyzeCandidateChurn(pools), nil
+}
+
+// GetCandidatePoolDiff 获取指定周期（recordID为0时为最新周期）候选池相对上一周期的变化
+func (l *DecisionLogger) GetCandidatePoolDiff(recordID int64) (*CandidatePoolDiff, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	pools, err := l.db.Decision().GetCandidatePools(recordID, 2)
+	if err != nil {
+		return nil, fmt.Errorf("获取候选池记录失败: %w", err)
+	}
+	if len(pools) == 0 || (recordID > 0 && pools[len(pools)-1].RecordID != recordID) {
+		return nil, fmt.Errorf("没有找到该周期的候选池记录")
+	}
+
+	cur := pools[len(pools)-1]
+	var prev *models.CandidatePool
+	if len(pools) == 2 {
+		prev = pools[0]
+	}
+	diff := diffCandidatePool(prev, cur)
+	return &diff, nil
+}
@@ -0,0 +1,449 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"nofx/database/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 同一币种在两个周期之间的决策变化类型
+const (
+	DecisionChangeUnchanged = "unchanged" // 动作相同
+	DecisionChangeReversed  = "reversed"  // 方向反转（做多↔做空）
+	DecisionChangeReverted  = "reverted"  // 上一周期开仓，本周期又平掉同方向持仓
+	DecisionChangeChanged   = "changed"   // 其它动作变化
+	DecisionChangeAdded     = "added"     // 只在后一个周期出现
+	DecisionChangeDropped   = "dropped"   // 只在前一个周期出现
+)
+
+// DecisionDiff 两个周期的决策和上下文对比（用于排查AI在周期之间反复横跳）
+type DecisionDiff struct {
+	From        DecisionDiffCycle    `json:"from"`
+	To          DecisionDiffCycle    `json:"to"`
+	Consecutive bool                 `json:"consecutive"` // 两个周期是否相邻（中间没有其它决策记录）
+	Account     AccountDiff          `json:"account"`     // 账户状态变化
+	Positions   PositionDiff         `json:"positions"`   // 持仓变化
+	Candidates  CandidatePoolDiff    `json:"candidates"`  // 候选池变化
+	Symbols     []SymbolDecisionDiff `json:"symbols"`     // 按币种对比的决策（有变化的在前）
+	Summary     DecisionDiffSummary  `json:"summary"`
+}
+
+// DecisionDiffCycle 参与对比的周期
+type DecisionDiffCycle struct {
+	ID           int64     `json:"id"`
+	CycleNumber  int       `json:"cycle_number"`
+	Timestamp    time.Time `json:"timestamp"`
+	AIProvider   string    `json:"ai_provider"`
+	Cached       bool      `json:"cached"` // 复用上一周期决策（未调用AI）
+	Regime       string    `json:"regime"`
+	PromptHash   string    `json:"prompt_hash"`
+	Success      bool      `json:"success"`
+	ErrorMessage string    `json:"error_message"`
+}
+
+// AccountDiff 账户状态变化（后一个周期减前一个周期）
+type AccountDiff struct {
+	From               AccountSnapshot `json:"from"`
+	To                 AccountSnapshot `json:"to"`
+	BalanceChange      float64         `json:"balance_change"`
+	MarginUsedPctDelta float64         `json:"margin_used_pct_delta"`
+}
+
+// PositionDiff 两个周期决策时的持仓变化
+type PositionDiff struct {
+	Entered []PositionSnapshot `json:"entered"` // 新出现的持仓
+	Exited  []PositionSnapshot `json:"exited"`  // 消失的持仓
+	Resized []PositionResize   `json:"resized"` // 数量变化的持仓
+}
+
+// PositionResize 数量变化的持仓
+type PositionResize struct {
+	Symbol  string  `json:"symbol"`
+	Side    string  `json:"side"`
+	FromAmt float64 `json:"from_amt"`
+	ToAmt   float64 `json:"to_amt"`
+}
+
+// SymbolDecisionDiff 同一币种在两个周期的决策对比
+type SymbolDecisionDiff struct {
+	Symbol          string   `json:"symbol"`
+	Change          string   `json:"change"` // 变化类型（见 DecisionChange* 常量）
+	FromActions     []string `json:"from_actions"`
+	ToActions       []string `json:"to_actions"`
+	FromConfidence  int      `json:"from_confidence"` // 该币种决策的最高信心度（0表示未给出）
+	ToConfidence    int      `json:"to_confidence"`
+	ConfidenceDelta int      `json:"confidence_delta"` // 两个周期都有信心度时的变化
+	FromReasoning   string   `json:"from_reasoning"`
+	ToReasoning     string   `json:"to_reasoning"`
+	FromPosition    bool     `json:"from_position"` // 前一个周期决策时是否持有该币种
+	ToPosition      bool     `json:"to_position"`
+	ToCandidate     bool     `json:"to_candidate"` // 后一个周期是否在候选池中
+}
+
+// DecisionDiffSummary 对比汇总
+type DecisionDiffSummary struct {
+	SymbolsCompared    int     `json:"symbols_compared"`
+	Changed            int     `json:"changed"`              // 动作有变化的币种数（含新增/消失）
+	Reversed           int     `json:"reversed"`             // 方向反转的币种数
+	Reverted           int     `json:"reverted"`             // 开仓后立即平仓的币种数
+	AvgConfidenceShift float64 `json:"avg_confidence_shift"` // 两个周期都给出信心度的币种，信心度变化绝对值的平均
+	PromptChanged      bool    `json:"prompt_changed"`       // Prompt哈希是否变化
+	RegimeChanged      bool    `json:"regime_changed"`       // 市场状态是否变化
+}
+
+// diffDecisionItem 对比时使用的决策字段
+type diffDecisionItem struct {
+	Symbol     string `json:"symbol"`
+	Action     string `json:"action"`
+	Confidence int    `json:"confidence"`
+	Reasoning  string `json:"reasoning"`
+}
+
+// diffCycleData 一个周期参与对比的全部数据
+type diffCycleData struct {
+	record     *models.DecisionRecord
+	decisions  map[string][]diffDecisionItem
+	positions  []PositionSnapshot
+	candidates []string
+}
+
+// DiffDecisions 对比两条决策记录（toID为0时取最新记录，fromID为0时取toID的上一条记录）
+func (l *DecisionLogger) DiffDecisions(fromID, toID int64) (*DecisionDiff, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	var err error
+	if toID == 0 {
+		if toID, err = l.db.Decision().GetPreviousID(0); err != nil {
+			return nil, fmt.Errorf("查询最新决策记录失败: %w", err)
+		}
+		if toID == 0 {
+			return nil, nil
+		}
+	}
+	prevID, err := l.db.Decision().GetPreviousID(toID)
+	if err != nil {
+		return nil, fmt.Errorf("查询上一条决策记录失败: %w", err)
+	}
+	if fromID == 0 {
+		if prevID == 0 {
+			return nil, nil
+		}
+		fromID = prevID
+	}
+
+	from, err := l.loadDiffCycle(fromID)
+	if err != nil || from == nil {
+		return nil, err
+	}
+	to, err := l.loadDiffCycle(toID)
+	if err != nil || to == nil {
+		return nil, err
+	}
+
+	diff := diffCycles(from, to)
+	diff.Consecutive = fromID == prevID
+	return diff, nil
+}
+
+// loadDiffCycle 加载决策记录、解析后的决策、持仓快照和候选池（记录不存在时返回nil）
+func (l *DecisionLogger) loadDiffCycle(id int64) (*diffCycleData, error) {
+	rec, err := l.db.Decision().GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, nil
+	}
+
+	data := &diffCycleData{record: rec, decisions: make(map[string][]diffDecisionItem)}
+	if rec.DecisionJSON != "" {
+		var items []diffDecisionItem
+		if err := json.Unmarshal([]byte(rec.DecisionJSON), &items); err != nil {
+			log.Printf("⚠️ 解析record %d 的决策JSON失败: %v", id, err)
+		}
+		for _, item := range items {
+			if item.Symbol == "" {
+				continue
+			}
+			data.decisions[item.Symbol] = append(data.decisions[item.Symbol], item)
+		}
+	}
+
+	positions, err := l.db.Decision().GetPositionSnapshots(id)
+	if err != nil {
+		log.Printf("⚠️ 加载record %d 的持仓快照失败: %v", id, err)
+	}
+	for _, pos := range positions {
+		data.positions = append(data.positions, PositionSnapshot{
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			PositionAmt:      pos.PositionAmt,
+			EntryPrice:       pos.EntryPrice,
+			MarkPrice:        pos.MarkPrice,
+			UnrealizedProfit: pos.UnrealizedProfit,
+			Leverage:         pos.Leverage,
+			LiquidationPrice: pos.LiquidationPrice,
+		})
+	}
+	if data.candidates, err = l.db.Decision().GetCandidateCoins(id); err != nil {
+		log.Printf("⚠️ 加载record %d 的候选币种失败: %v", id, err)
+	}
+	if data.candidates == nil {
+		data.candidates = []string{}
+	}
+	return data, nil
+}
+
+// diffCycles 计算两个周期的差异
+func diffCycles(from, to *diffCycleData) *DecisionDiff {
+	diff := &DecisionDiff{
+		From:      diffCycleInfo(from.record),
+		To:        diffCycleInfo(to.record),
+		Account:   diffAccount(from.record, to.record),
+		Positions: diffPositions(from.positions, to.positions),
+		Candidates: diffCandidatePool(
+			&models.CandidatePool{RecordID: from.record.ID, CycleNumber: from.record.CycleNumber, Timestamp: from.record.Timestamp, Symbols: from.candidates},
+			&models.CandidatePool{RecordID: to.record.ID, CycleNumber: to.record.CycleNumber, Timestamp: to.record.Timestamp, Symbols: to.candidates},
+		),
+		Symbols: []SymbolDecisionDiff{},
+	}
+	diff.Summary.PromptChanged = from.record.PromptHash != to.record.PromptHash
+	diff.Summary.RegimeChanged = from.record.Regime != to.record.Regime
+
+	symbols := make(map[string]bool)
+	for symbol := range from.decisions {
+		symbols[symbol] = true
+	}
+	for symbol := range to.decisions {
+		symbols[symbol] = true
+	}
+
+	fromHeld := heldSymbols(from.positions)
+	toHeld := heldSymbols(to.positions)
+	toCandidates := symbolSet(to.candidates)
+
+	var shiftTotal float64
+	var shiftCount int
+	for symbol := range symbols {
+		d := SymbolDecisionDiff{
+			Symbol:       symbol,
+			FromActions:  decisionActions(from.decisions[symbol]),
+			ToActions:    decisionActions(to.decisions[symbol]),
+			FromPosition: fromHeld[symbol],
+			ToPosition:   toHeld[symbol],
+			ToCandidate:  toCandidates[symbol],
+		}
+		d.FromConfidence, d.FromReasoning = primaryDecision(from.decisions[symbol])
+		d.ToConfidence, d.ToReasoning = primaryDecision(to.decisions[symbol])
+		if d.FromConfidence > 0 && d.ToConfidence > 0 {
+			d.ConfidenceDelta = d.ToConfidence - d.FromConfidence
+			shiftTotal += math.Abs(float64(d.ConfidenceDelta))
+			shiftCount++
+		}
+		d.Change = classifyDecisionChange(d.FromActions, d.ToActions)
+
+		switch d.Change {
+		case DecisionChangeReversed:
+			diff.Summary.Reversed++
+		case DecisionChangeReverted:
+			diff.Summary.Reverted++
+		}
+		if d.Change != DecisionChangeUnchanged {
+			diff.Summary.Changed++
+		}
+		diff.Symbols = append(diff.Symbols, d)
+	}
+	diff.Summary.SymbolsCompared = len(diff.Symbols)
+	if shiftCount > 0 {
+		diff.Summary.AvgConfidenceShift = shiftTotal / float64(shiftCount)
+	}
+
+	// 有变化的在前（反转、撤回最优先），同类按信心度变化幅度和币种排序
+	sort.Slice(diff.Symbols, func(i, j int) bool {
+		a, b := diff.Symbols[i], diff.Symbols[j]
+		if ra, rb := decisionChangeRank(a.Change), decisionChangeRank(b.Change); ra != rb {
+			return ra < rb
+		}
+		if da, db := absInt(a.ConfidenceDelta), absInt(b.ConfidenceDelta); da != db {
+			return da > db
+		}
+		return a.Symbol < b.Symbol
+	})
+	return diff
+}
+
+// diffCycleInfo 决策记录的周期信息
+func diffCycleInfo(rec *models.DecisionRecord) DecisionDiffCycle {
+	return DecisionDiffCycle{
+		ID:           rec.ID,
+		CycleNumber:  rec.CycleNumber,
+		Timestamp:    rec.Timestamp,
+		AIProvider:   rec.AIProvider,
+		Cached:       rec.Cached,
+		Regime:       rec.Regime,
+		PromptHash:   rec.PromptHash,
+		Success:      rec.Success,
+		ErrorMessage: rec.ErrorMessage,
+	}
+}
+
+// diffAccount 账户状态变化
+func diffAccount(from, to *models.DecisionRecord) AccountDiff {
+	snapshot := func(rec *models.DecisionRecord) AccountSnapshot {
+		return AccountSnapshot{
+			TotalBalance:          rec.TotalBalance,
+			AvailableBalance:      rec.AvailableBalance,
+			TotalUnrealizedProfit: rec.TotalUnrealizedProfit,
+			PositionCount:         rec.PositionCount,
+			MarginUsedPct:         rec.MarginUsedPct,
+		}
+	}
+	return AccountDiff{
+		From:               snapshot(from),
+		To:                 snapshot(to),
+		BalanceChange:      to.TotalBalance - from.TotalBalance,
+		MarginUsedPctDelta: to.MarginUsedPct - from.MarginUsedPct,
+	}
+}
+
+// diffPositions 按 币种+方向 对比两个周期的持仓
+func diffPositions(from, to []PositionSnapshot) PositionDiff {
+	diff := PositionDiff{Entered: []PositionSnapshot{}, Exited: []PositionSnapshot{}, Resized: []PositionResize{}}
+	fromByKey := make(map[string]PositionSnapshot, len(from))
+	for _, pos := range from {
+		fromByKey[pos.Symbol+"_"+pos.Side] = pos
+	}
+	toKeys := make(map[string]bool, len(to))
+	for _, pos := range to {
+		key := pos.Symbol + "_" + pos.Side
+		toKeys[key] = true
+		prev, ok := fromByKey[key]
+		if !ok {
+			diff.Entered = append(diff.Entered, pos)
+			continue
+		}
+		if math.Abs(prev.PositionAmt) != math.Abs(pos.PositionAmt) {
+			diff.Resized = append(diff.Resized, PositionResize{
+				Symbol:  pos.Symbol,
+				Side:    pos.Side,
+				FromAmt: math.Abs(prev.PositionAmt),
+				ToAmt:   math.Abs(pos.PositionAmt),
+			})
+		}
+	}
+	for _, pos := range from {
+		if !toKeys[pos.Symbol+"_"+pos.Side] {
+			diff.Exited = append(diff.Exited, pos)
+		}
+	}
+	return diff
+}
+
+// heldSymbols 持仓中的币种集合
+func heldSymbols(positions []PositionSnapshot) map[string]bool {
+	held := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		held[pos.Symbol] = true
+	}
+	return held
+}
+
+// decisionActions 币种在一个周期的动作列表（按AI输出顺序）
+func decisionActions(items []diffDecisionItem) []string {
+	actions := make([]string, 0, len(items))
+	for _, item := range items {
+		actions = append(actions, item.Action)
+	}
+	return actions
+}
+
+// primaryDecision 币种决策的最高信心度和对应的理由（没有信心度时取第一条决策的理由）
+func primaryDecision(items []diffDecisionItem) (int, string) {
+	if len(items) == 0 {
+		return 0, ""
+	}
+	best := items[0]
+	for _, item := range items[1:] {
+		if item.Confidence > best.Confidence {
+			best = item
+		}
+	}
+	return best.Confidence, best.Reasoning
+}
+
+// classifyDecisionChange 判断同一币种两个周期之间的动作变化类型
+func classifyDecisionChange(from, to []string) string {
+	switch {
+	case len(from) == 0 && len(to) == 0:
+		return DecisionChangeUnchanged
+	case len(from) == 0:
+		return DecisionChangeAdded
+	case len(to) == 0:
+		return DecisionChangeDropped
+	case strings.Join(from, ",") == strings.Join(to, ","):
+		return DecisionChangeUnchanged
+	}
+
+	fromBias, toBias := decisionBias(from), decisionBias(to)
+	if fromBias != "" && toBias != "" && fromBias != toBias {
+		return DecisionChangeReversed
+	}
+	for _, action := range to {
+		if (action == "close_long" && containsAction(from, "open_long")) ||
+			(action == "close_short" && containsAction(from, "open_short")) {
+			return DecisionChangeReverted
+		}
+	}
+	return DecisionChangeChanged
+}
+
+// decisionBias 一组动作的开仓方向（long/short，没有开仓时为空）
+func decisionBias(actions []string) string {
+	for _, action := range actions {
+		switch action {
+		case "open_long":
+			return "long"
+		case "open_short":
+			return "short"
+		}
+	}
+	return ""
+}
+
+func containsAction(actions []string, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// decisionChangeRank 变化类型的排序优先级（越小越靠前）
+func decisionChangeRank(change string) int {
+	switch change {
+	case DecisionChangeReversed:
+		return 0
+	case DecisionChangeReverted:
+		return 1
+	case DecisionChangeChanged:
+		return 2
+	case DecisionChangeAdded, DecisionChangeDropped:
+		return 3
+	}
+	return 4
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// explainTradeLinkWindow 关联交易结果的时间窗口（动作执行时间前后）
+const explainTradeLinkWindow = 5 * time.Minute
+
+// DecisionExplanation 决策解释视图（把一次决策的输入、推理、校验、执行和结果串联起来）
+type DecisionExplanation struct {
+	ID             int64                 `json:"id"`                      // 决策记录ID
+	CycleNumber    int                   `json:"cycle_number"`            // 周期编号
+	Timestamp      time.Time             `json:"timestamp"`               // 决策时间
+	AIProvider     string                `json:"ai_provider"`             // 实际使用的AI提供商
+	Cached         bool                  `json:"cached"`                  // 是否复用上一周期决策
+	Regime         string                `json:"regime"`                  // 决策时的市场状态
+	LatencyMs      int64                 `json:"latency_ms"`              // 市场快照到AI决策完成的耗时(毫秒)
+	MarketSnapshot ExplainMarketSnapshot `json:"market_snapshot"`         // 决策时的市场/账户快照
+	SystemPrompt   string                `json:"system_prompt"`           // System Prompt
+	UserPrompt     string                `json:"user_prompt"`             // User Prompt（市场数据）
+	CoTTrace       string                `json:"cot_trace"`               // AI思维链
+	Decisions      []ExplainedDecision   `json:"decisions"`               // 解析后的决策（含校验和质量结果）
+	Executions     []DecisionAction      `json:"executions"`              // 执行记录
+	TradeOutcomes  []TradeOutcome        `json:"trade_outcomes"`          // 关联的交易结果（如果已平仓）
+	CriticReview   json.RawMessage       `json:"critic_review,omitempty"` // 审核模型的审核结果（未启用两阶段审核时为空）
+	Success        bool                  `json:"success"`                 // 周期是否成功
+	ErrorMessage   string                `json:"error_message"`           // 错误信息
+}
+
+// ExplainMarketSnapshot 决策时使用的市场快照
+type ExplainMarketSnapshot struct {
+	Account        AccountSnapshot    `json:"account"`         // 账户状态
+	Positions      []PositionSnapshot `json:"positions"`       // 持仓快照
+	CandidateCoins []string           `json:"candidate_coins"` // 候选币种
+}
+
+// ExplainedDecision 单个决策及其校验、质量结果
+type ExplainedDecision struct {
+	Decision         map[string]interface{} `json:"decision"`          // AI输出的原始决策
+	ValidationPassed bool                   `json:"validation_passed"` // 是否通过校验
+	ValidationError  string                 `json:"validation_error"`  // 校验失败原因
+	Quality          interface{}            `json:"quality"`           // 质量评估结果（旧记录可能为空）
+}
+
+// ExplainDecision 组装指定决策记录的解释视图（记录不存在时返回nil）
+func (l *DecisionLogger) ExplainDecision(id int64) (*DecisionExplanation, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	dbRec, err := l.db.Decision().GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if dbRec == nil {
+		return nil, nil
+	}
+
+	explanation := &DecisionExplanation{
+		ID:           dbRec.ID,
+		CycleNumber:  dbRec.CycleNumber,
+		Timestamp:    dbRec.Timestamp,
+		AIProvider:   dbRec.AIProvider,
+		Cached:       dbRec.Cached,
+		Regime:       dbRec.Regime,
+		LatencyMs:    dbRec.LatencyMs,
+		SystemPrompt: dbRec.SystemPrompt,
+		UserPrompt:   dbRec.InputPrompt,
+		CoTTrace:     dbRec.CoTTrace,
+		Success:      dbRec.Success,
+		ErrorMessage: dbRec.ErrorMessage,
+		MarketSnapshot: ExplainMarketSnapshot{
+			Account: AccountSnapshot{
+				TotalBalance:          dbRec.TotalBalance,
+				AvailableBalance:      dbRec.AvailableBalance,
+				TotalUnrealizedProfit: dbRec.TotalUnrealizedProfit,
+				PositionCount:         dbRec.PositionCount,
+				MarginUsedPct:         dbRec.MarginUsedPct,
+			},
+		},
+	}
+	if dbRec.CriticReview != "" {
+		explanation.CriticReview = json.RawMessage(dbRec.CriticReview)
+	}
+
+	// 市场快照：持仓和候选币种
+	positions, err := l.db.Decision().GetPositionSnapshots(id)
+	if err != nil {
+		log.Printf("⚠️ 加载record %d 的持仓快照失败: %v", id, err)
+	}
+	for _, pos := range positions {
+		explanation.MarketSnapshot.Positions = append(explanation.MarketSnapshot.Positions, PositionSnapshot{
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			PositionAmt:      pos.PositionAmt,
+			EntryPrice:       pos.EntryPrice,
+			MarkPrice:        pos.MarkPrice,
+			UnrealizedProfit: pos.UnrealizedProfit,
+			Leverage:         pos.Leverage,
+			LiquidationPrice: pos.LiquidationPrice,
+		})
+	}
+	if explanation.MarketSnapshot.CandidateCoins, err = l.db.Decision().GetCandidateCoins(id); err != nil {
+		log.Printf("⚠️ 加载record %d 的候选币种失败: %v", id, err)
+	}
+
+	// 解析决策JSON：验证结果逐条记录在决策的validation_error上（包括只平仓模式下被剔除的开仓决策）
+	if dbRec.DecisionJSON != "" {
+		var rawDecisions []map[string]interface{}
+		if err := json.Unmarshal([]byte(dbRec.DecisionJSON), &rawDecisions); err != nil {
+			log.Printf("⚠️ 解析record %d 的决策JSON失败: %v", id, err)
+		}
+		legacy := true
+		for _, raw := range rawDecisions {
+			if _, ok := raw["validation_error"]; ok {
+				legacy = false
+				break
+			}
+		}
+		for i, raw := range rawDecisions {
+			quality := raw["quality"]
+			delete(raw, "quality")
+			validationError, _ := raw["validation_error"].(string)
+			delete(raw, "validation_error")
+			if legacy {
+				validationError = legacyValidationError(dbRec.ErrorMessage, i, len(rawDecisions))
+			}
+			explanation.Decisions = append(explanation.Decisions, ExplainedDecision{
+				Decision:         raw,
+				ValidationPassed: validationError == "",
+				ValidationError:  validationError,
+				Quality:          quality,
+			})
+		}
+	}
+
+	// 执行记录
+	actions, err := l.db.Decision().GetActions(id)
+	if err != nil {
+		log.Printf("⚠️ 加载record %d 的决策动作失败: %v", id, err)
+	}
+	for _, act := range actions {
+		explanation.Executions = append(explanation.Executions, DecisionAction{
+			Action:        act.Action,
+			Symbol:        act.Symbol,
+			Quantity:      act.Quantity,
+			Leverage:      act.Leverage,
+			Price:         act.Price,
+			OrderID:       act.OrderID,
+			Timestamp:     act.Timestamp,
+			Success:       act.Success,
+			Error:         act.Error,
+			WasStopLoss:   act.WasStopLoss,
+			ExecutedQty:   act.ExecutedQty,
+			AvgPrice:      act.AvgPrice,
+			PriceDriftPct: act.PriceDriftPct,
+			NotionalUSD:   act.NotionalUSD,
+			MarginUSD:     act.MarginUSD,
+			Adjustment:    act.Adjustment,
+			ErrorClass:    act.ErrorClass,
+			BookSnapshot:  rawJSON(act.BookSnapshot),
+		})
+	}
+
+	// 关联交易结果：优先使用交易结果上记录的开平仓决策
+	seen := make(map[int64]bool)
+	linked, err := l.db.Trade().GetByRecord(id)
+	if err != nil {
+		log.Printf("⚠️ 查询record %d 关联的交易结果失败: %v", id, err)
+	}
+	for _, trade := range linked {
+		seen[trade.ID] = true
+		explanation.TradeOutcomes = append(explanation.TradeOutcomes, toLoggerTrade(trade))
+	}
+
+	// 未完整关联决策的交易结果（旧记录等）：成功执行的开平仓动作，按币种和执行时间匹配
+	for _, act := range actions {
+		if !act.Success || act.Action == "hold" || act.Action == "wait" {
+			continue
+		}
+		trades, err := l.db.Trade().GetBySymbolBetween(act.Symbol,
+			act.Timestamp.Add(-explainTradeLinkWindow), act.Timestamp.Add(explainTradeLinkWindow))
+		if err != nil {
+			log.Printf("⚠️ 查询 %s 的交易结果失败: %v", act.Symbol, err)
+			continue
+		}
+		for _, trade := range trades {
+			if seen[trade.ID] || (trade.OpenRecordID > 0 && trade.CloseRecordID > 0) {
+				continue
+			}
+			seen[trade.ID] = true
+			explanation.TradeOutcomes = append(explanation.TradeOutcomes, toLoggerTrade(trade))
+		}
+	}
+
+	return explanation, nil
+}
+
+// legacyValidationError 旧记录（决策上没有逐条验证结果）从记录级错误信息中找出属于第index条决策的验证失败原因
+// 只有单条决策（操作员注入）或错误信息指明了决策序号（"决策 N 验证失败"）时才能对应，整批检查的失败不归到任何一条决策
+func legacyValidationError(errorMessage string, index, total int) string {
+	idx := strings.Index(errorMessage, "决策验证失败")
+	if idx < 0 {
+		return ""
+	}
+	validationError := errorMessage[idx:]
+	if total == 1 || strings.Contains(validationError, fmt.Sprintf("决策 %d 验证失败", index+1)) {
+		return validationError
+	}
+	return ""
+}
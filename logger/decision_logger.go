@@ -5,29 +5,53 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
-	"math"
+	"nofx/analytics"
 	"nofx/database"
 	"nofx/database/models"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
 // DecisionRecord 决策记录
 type DecisionRecord struct {
-	Timestamp      time.Time          `json:"timestamp"`       // 决策时间
-	CycleNumber    int                `json:"cycle_number"`    // 周期编号
-	SystemPrompt   string             `json:"system_prompt"`   // System Prompt（规则）
-	InputPrompt    string             `json:"input_prompt"`    // User Prompt（市场数据）
-	CoTTrace       string             `json:"cot_trace"`       // AI思维链（输出）
-	DecisionJSON   string             `json:"decision_json"`   // 决策JSON
-	AccountState   AccountSnapshot    `json:"account_state"`   // 账户状态快照
-	Positions      []PositionSnapshot `json:"positions"`       // 持仓快照
-	CandidateCoins []string           `json:"candidate_coins"` // 候选币种列表
-	Decisions      []DecisionAction   `json:"decisions"`       // 执行的决策
-	ExecutionLog   []string           `json:"execution_log"`   // 执行日志
-	Success        bool               `json:"success"`         // 是否成功
-	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
+	ID                int64              `json:"id"`                  // 数据库记录ID（用于查询决策解释）
+	Timestamp         time.Time          `json:"timestamp"`           // 决策时间
+	CycleNumber       int                `json:"cycle_number"`        // 周期编号
+	SystemPrompt      string             `json:"system_prompt"`       // System Prompt（规则）
+	InputPrompt       string             `json:"input_prompt"`        // User Prompt（市场数据）
+	CoTTrace          string             `json:"cot_trace"`           // AI思维链（输出）
+	DecisionJSON      string             `json:"decision_json"`       // 决策JSON
+	AccountState      AccountSnapshot    `json:"account_state"`       // 账户状态快照
+	Positions         []PositionSnapshot `json:"positions"`           // 持仓快照
+	CandidateCoins    []string           `json:"candidate_coins"`     // 候选币种列表
+	Decisions         []DecisionAction   `json:"decisions"`           // 执行的决策
+	ExecutionLog      []string           `json:"execution_log"`       // 执行日志
+	Success           bool               `json:"success"`             // 是否成功
+	ErrorMessage      string             `json:"error_message"`       // 错误信息（如果有）
+	AIProvider        string             `json:"ai_provider"`         // 实际使用的AI提供商（故障转移后可能为备用）
+	PromptHash        string             `json:"prompt_hash"`         // Prompt内容哈希
+	Cached            bool               `json:"cached"`              // 是否复用上一周期决策（未调用AI）
+	Regime            string             `json:"regime"`              // 决策时的市场状态（trending/ranging/high_vol/crash）
+	LatencyMs         int64              `json:"latency_ms"`          // 市场快照到AI决策完成的耗时(毫秒)
+	LearningSummaryID int64              `json:"learning_summary_id"` // 决策时生效的AI学习总结ID（0表示没有）
+	Manual            bool               `json:"manual"`              // 是否为操作员手动注入的决策
+	SchemaVersion     int                `json:"schema_version"`      // 解析AI输出使用的决策格式版本（0表示没有解析AI输出）
+	RealizedPnLDelta  float64            `json:"realized_pnl_delta"`  // 上一条决策记录之后平仓交易的已实现盈亏合计（本周期盈亏归因）
+	ClosedTrades      int                `json:"closed_trades"`       // 上一条决策记录之后平仓的交易笔数
+	AIParams          string             `json:"ai_params,omitempty"` // 本次调用AI实际使用的生成参数（JSON，用于复现）
+	CriticReview      string             `json:"critic_review,omitempty"` // 审核模型的审核结果（JSON，含原始输出和每个决策的结论）
+	CriticReviewed    int                `json:"critic_reviewed"`     // 审核模型审核的决策数
+	CriticVetoed      int                `json:"critic_vetoed"`       // 审核模型否决的决策数
+	MarketFetchMs     int64              `json:"market_fetch_ms"`     // 获取行情耗时(毫秒)
+	PromptBuildMs     int64              `json:"prompt_build_ms"`     // 构建Prompt耗时(毫秒)
+	AILatencyMs       int64              `json:"ai_latency_ms"`       // 调用AI耗时(毫秒，含审核模型)
+	ParseValidateMs   int64              `json:"parse_validate_ms"`   // 解析和验证决策耗时(毫秒)
+	ExecutionMs       int64              `json:"execution_ms"`        // 执行决策耗时(毫秒)
+	DBWriteMs         int64              `json:"db_write_ms"`         // 写入决策记录耗时(毫秒)
+	SymbolsFetched    int                `json:"symbols_fetched"`     // 成功获取行情的币种数
+	SymbolsFailed     int                `json:"symbols_failed"`      // 获取行情失败的币种数
 }
 
 // AccountSnapshot 账户状态快照
@@ -53,16 +77,26 @@ type PositionSnapshot struct {
 
 // DecisionAction 决策动作
 type DecisionAction struct {
-	Action      string    `json:"action"`        // open_long, open_short, close_long, close_short
-	Symbol      string    `json:"symbol"`        // 币种
-	Quantity    float64   `json:"quantity"`      // 数量
-	Leverage    int       `json:"leverage"`      // 杠杆（开仓时）
-	Price       float64   `json:"price"`         // 执行价格
-	OrderID     int64     `json:"order_id"`      // 订单ID
-	Timestamp   time.Time `json:"timestamp"`     // 执行时间
-	Success     bool      `json:"success"`       // 是否成功
-	Error       string    `json:"error"`         // 错误信息
-	WasStopLoss bool      `json:"was_stop_loss"` // 是否因止损触发（平仓时）
+	Action        string    `json:"action"`          // open_long, open_short, close_long, close_short
+	Symbol        string    `json:"symbol"`          // 币种
+	Quantity      float64   `json:"quantity"`        // 数量
+	Leverage      int       `json:"leverage"`        // 杠杆（开仓时）
+	Price         float64   `json:"price"`           // 执行价格
+	OrderID       int64     `json:"order_id"`        // 订单ID
+	Timestamp     time.Time `json:"timestamp"`       // 执行时间
+	Success       bool      `json:"success"`         // 是否成功
+	Error         string    `json:"error"`           // 错误信息
+	WasStopLoss   bool      `json:"was_stop_loss"`   // 是否因止损触发（平仓时）
+	ExecutedQty   float64   `json:"executed_qty"`    // 实际成交数量（订单状态轮询结果）
+	AvgPrice      float64   `json:"avg_price"`       // 实际成交均价
+	PriceDriftPct float64   `json:"price_drift_pct"` // 下单前价格相对AI分析价格的漂移(%)
+	NotionalUSD   float64   `json:"notional_usd"`    // 下单名义价值(USDT) = 数量 × 价格
+	MarginUSD     float64   `json:"margin_usd"`      // 下单保证金(USDT) = 名义价值 / 杠杆
+	ExitPolicy    string    `json:"exit_policy,omitempty"` // 触发的自动退出策略（break_even/time_stop/weekend_close/event_close/stop_guard）
+	Adjustment    string    `json:"adjustment,omitempty"`  // 执行前系统对决策参数的调整说明（如止损按强平价收紧）
+	ErrorClass    string    `json:"error_class,omitempty"` // 失败原因分类（insufficient_margin/reduce_only/rate_limit/precision/permission/other）
+	TradeOutcomeID int64    `json:"trade_outcome_id,omitempty"` // 本动作平仓产生的交易结果ID（写入决策记录时回填交易结果的平仓决策）
+	BookSnapshot  json.RawMessage `json:"book_snapshot,omitempty"` // 下单时的盘口前5档和最近成交（含预期/实际成交滑点）
 }
 
 // DecisionLogger 决策日志记录器
@@ -71,6 +105,15 @@ type DecisionLogger struct {
 	cycleNumber int
 	db          *database.DB // 数据库连接
 	traderID    string       // Trader ID
+
+	lastRecordAt time.Time // 上一条决策记录的时间（用于按周期归集已实现盈亏）
+
+	mu                  sync.Mutex                    // 串行化决策记录写入（周期编号、待补写队列）
+	cycleLoaded         bool                          // 周期编号是否已从数据库/待补写队列恢复
+	consecutiveFailures int                           // 连续写入数据库失败次数
+	failureAlert        func(failures int, err error) // 持续写入失败时的预警回调
+
+	writeFaultInjector func(op string) error // 故障注入（测试用，模拟数据库写入失败）
 }
 
 // NewDecisionLogger 创建决策日志记录器
@@ -108,25 +151,93 @@ func (l *DecisionLogger) GetDB() *database.DB {
 	return l.db
 }
 
-// LogDecision 记录决策（只保存到数据库）
+// SetWriteFaultInjector 设置数据库写入故障注入函数（返回非nil错误时放弃本次写入）
+func (l *DecisionLogger) SetWriteFaultInjector(injector func(op string) error) {
+	l.writeFaultInjector = injector
+}
+
+// LogDecision 记录决策（保存到数据库，写入失败时先写入磁盘待补写队列，数据库恢复后按顺序补写）
 func (l *DecisionLogger) LogDecision(record *DecisionRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.loadCycleNumber()
 	l.cycleNumber++
 	record.CycleNumber = l.cycleNumber
-	record.Timestamp = time.Now()
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now() // 调用方未指定决策时间（注入时钟的测试会预先填充）
+	}
+
+	// 先补写队列中的记录，保证数据库中的记录顺序
+	pending := l.replaySpool()
+
+	var err error
+	if pending > 0 {
+		err = fmt.Errorf("数据库仍不可写，还有%d条记录等待补写", pending)
+	} else {
+		err = l.writeRecord(record)
+	}
+	if err != nil {
+		return l.spoolRecord(record, err)
+	}
+
+	l.consecutiveFailures = 0
+	fmt.Printf("📝 决策记录已保存到数据库: cycle %d\n", record.CycleNumber)
+	return nil
+}
 
-	// 保存到 SQLite 数据库
+// writeRecord 归集已实现盈亏后写入一条决策记录到数据库
+// 写入失败时撤销归集：待补写队列中的记录都未归集，补写时按记录顺序重新归集
+func (l *DecisionLogger) writeRecord(record *DecisionRecord) error {
 	if l.db == nil {
 		return fmt.Errorf("数据库未初始化")
 	}
+	since := l.lastRecordAt
+	l.attributeRealizedPnL(record)
+	if err := l.saveRecord(record); err != nil {
+		record.RealizedPnLDelta = 0
+		record.ClosedTrades = 0
+		l.lastRecordAt = since
+		return err
+	}
+	return nil
+}
 
+// saveRecord 写入一条决策记录到数据库（写入失败时返回错误）
+func (l *DecisionLogger) saveRecord(record *DecisionRecord) error {
+	if l.writeFaultInjector != nil {
+		if err := l.writeFaultInjector("保存决策记录"); err != nil {
+			return fmt.Errorf("保存到数据库失败: %w", err)
+		}
+	}
 	if err := l.saveToDatabase(record); err != nil {
 		return fmt.Errorf("保存到数据库失败: %w", err)
 	}
-
-	fmt.Printf("📝 决策记录已保存到数据库: cycle %d\n", record.CycleNumber)
 	return nil
 }
 
+// attributeRealizedPnL 把上一条决策记录之后平仓的交易盈亏归集到本条记录
+// 未写入决策记录的时段（如服务停止期间）平仓的交易归集到之后的第一条记录
+func (l *DecisionLogger) attributeRealizedPnL(record *DecisionRecord) {
+	since := l.lastRecordAt
+	if since.IsZero() {
+		// 重启后从数据库中最近一条决策记录开始统计
+		if latest, err := l.db.Decision().GetLatest(1); err == nil && len(latest) > 0 {
+			since = latest[0].Timestamp
+		}
+	}
+
+	pnl, count, err := l.db.Trade().GetRealizedPnLBetween(since, record.Timestamp)
+	if err != nil {
+		// 不推进统计起点，下一条记录会包含这段时间的交易
+		log.Printf("⚠️  统计周期已实现盈亏失败: %v", err)
+		return
+	}
+	record.RealizedPnLDelta = pnl
+	record.ClosedTrades = count
+	l.lastRecordAt = record.Timestamp
+}
+
 // saveToDatabase 保存决策记录到数据库
 func (l *DecisionLogger) saveToDatabase(record *DecisionRecord) error {
 	// 转换 DecisionJSON 为字符串
@@ -135,7 +246,7 @@ func (l *DecisionLogger) saveToDatabase(record *DecisionRecord) error {
 		decisionJSON = record.DecisionJSON
 	}
 
-	// 插入主记录
+	// 主记录
 	dbRecord := &models.DecisionRecord{
 		TraderID:              l.traderID,
 		CycleNumber:           record.CycleNumber,
@@ -151,37 +262,59 @@ func (l *DecisionLogger) saveToDatabase(record *DecisionRecord) error {
 		TotalUnrealizedProfit: record.AccountState.TotalUnrealizedProfit,
 		PositionCount:         record.AccountState.PositionCount,
 		MarginUsedPct:         record.AccountState.MarginUsedPct,
-	}
-
-	recordID, err := l.db.Decision().Insert(dbRecord)
-	if err != nil {
-		return fmt.Errorf("插入决策记录失败: %w", err)
-	}
-
-	// 插入决策动作
+		AIProvider:            record.AIProvider,
+		PromptHash:            record.PromptHash,
+		Cached:                record.Cached,
+		Regime:                record.Regime,
+		LatencyMs:             record.LatencyMs,
+		LearningSummaryID:     record.LearningSummaryID,
+		Manual:                record.Manual,
+		SchemaVersion:         record.SchemaVersion,
+		RealizedPnLDelta:      record.RealizedPnLDelta,
+		ClosedTrades:          record.ClosedTrades,
+		AIParams:              record.AIParams,
+		CriticReview:          record.CriticReview,
+		CriticReviewed:        record.CriticReviewed,
+		CriticVetoed:          record.CriticVetoed,
+		MarketFetchMs:         record.MarketFetchMs,
+		PromptBuildMs:         record.PromptBuildMs,
+		AILatencyMs:           record.AILatencyMs,
+		ParseValidateMs:       record.ParseValidateMs,
+		ExecutionMs:           record.ExecutionMs,
+		SymbolsFetched:        record.SymbolsFetched,
+		SymbolsFailed:         record.SymbolsFailed,
+	}
+
+	// 决策动作
+	var dbActions []*models.DecisionAction
 	for _, action := range record.Decisions {
-		dbAction := &models.DecisionAction{
-			RecordID:    recordID,
-			Action:      action.Action,
-			Symbol:      action.Symbol,
-			Quantity:    action.Quantity,
-			Leverage:    action.Leverage,
-			Price:       action.Price,
-			OrderID:     action.OrderID,
-			Timestamp:   action.Timestamp,
-			Success:     action.Success,
-			Error:       action.Error,
-			WasStopLoss: action.WasStopLoss,
-		}
-		if err := l.db.Decision().InsertAction(dbAction); err != nil {
-			return fmt.Errorf("插入决策动作失败: %w", err)
-		}
-	}
-
-	// 插入持仓快照
+		dbActions = append(dbActions, &models.DecisionAction{
+			Action:        action.Action,
+			Symbol:        action.Symbol,
+			Quantity:      action.Quantity,
+			Leverage:      action.Leverage,
+			Price:         action.Price,
+			OrderID:       action.OrderID,
+			Timestamp:     action.Timestamp,
+			Success:       action.Success,
+			Error:         action.Error,
+			WasStopLoss:   action.WasStopLoss,
+			ExecutedQty:   action.ExecutedQty,
+			AvgPrice:      action.AvgPrice,
+			PriceDriftPct: action.PriceDriftPct,
+			NotionalUSD:   action.NotionalUSD,
+			MarginUSD:     action.MarginUSD,
+			Adjustment:    action.Adjustment,
+			ErrorClass:    action.ErrorClass,
+			BookSnapshot:  string(action.BookSnapshot),
+			TradeOutcomeID: action.TradeOutcomeID,
+		})
+	}
+
+	// 持仓快照
+	var dbPositions []*models.PositionSnapshot
 	for _, pos := range record.Positions {
-		dbPos := &models.PositionSnapshot{
-			RecordID:         recordID,
+		dbPositions = append(dbPositions, &models.PositionSnapshot{
 			Symbol:           pos.Symbol,
 			Side:             pos.Side,
 			PositionAmt:      pos.PositionAmt,
@@ -190,17 +323,21 @@ func (l *DecisionLogger) saveToDatabase(record *DecisionRecord) error {
 			UnrealizedProfit: pos.UnrealizedProfit,
 			Leverage:         pos.Leverage,
 			LiquidationPrice: pos.LiquidationPrice,
-		}
-		if err := l.db.Decision().InsertPositionSnapshot(dbPos); err != nil {
-			return fmt.Errorf("插入持仓快照失败: %w", err)
-		}
+		})
 	}
 
-	// 插入候选币种
-	for _, symbol := range record.CandidateCoins {
-		if err := l.db.Decision().InsertCandidateCoin(recordID, symbol); err != nil {
-			return fmt.Errorf("插入候选币种失败: %w", err)
-		}
+	// 主记录、动作、持仓快照、候选币种在同一事务中批量写入
+	writeStart := time.Now()
+	recordID, err := l.db.Decision().InsertWithDetails(dbRecord, dbActions, dbPositions, record.CandidateCoins)
+	if err != nil {
+		return err
+	}
+	record.ID = recordID
+
+	// 写入耗时只能在写入后回填（回填失败不影响决策记录本身）
+	record.DBWriteMs = time.Since(writeStart).Milliseconds()
+	if err := l.db.Decision().UpdateDBWriteMs(recordID, record.DBWriteMs); err != nil {
+		log.Printf("⚠️  回填决策记录写入耗时失败: %v", err)
 	}
 
 	return nil
@@ -216,55 +353,95 @@ func (l *DecisionLogger) GetLatestRecords(n int) ([]*DecisionRecord, error) {
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// 转换类型：database.DecisionRecord -> logger.DecisionRecord
 	records := make([]*DecisionRecord, len(dbRecords))
 	for i, dbRec := range dbRecords {
-		// 从数据库加载该记录的所有决策动作
-		actions, err := l.db.Decision().GetActions(dbRec.ID)
-		if err != nil {
-			log.Printf("⚠️ 加载record %d 的决策动作失败: %v", dbRec.ID, err)
-			actions = []*models.DecisionAction{} // 使用空数组
-		}
-		
-		// 转换decision actions
-		var loggerActions []DecisionAction
-		for _, act := range actions {
-			loggerActions = append(loggerActions, DecisionAction{
-				Action:      act.Action,
-				Symbol:      act.Symbol,
-				Quantity:    act.Quantity,
-				Leverage:    act.Leverage,
-				Price:       act.Price,
-				OrderID:     act.OrderID,
-				Timestamp:   act.Timestamp,
-				Success:     act.Success,
-				Error:       act.Error,
-				WasStopLoss: act.WasStopLoss,
-			})
-		}
-		
-		records[i] = &DecisionRecord{
-			Timestamp:    dbRec.Timestamp,
-			CycleNumber:  dbRec.CycleNumber,
-			InputPrompt:  dbRec.InputPrompt,
-			CoTTrace:     dbRec.CoTTrace,
-			DecisionJSON: dbRec.DecisionJSON,
-			Success:      dbRec.Success,
-			ErrorMessage: dbRec.ErrorMessage,
-			Decisions:    loggerActions, // 加载关联的决策动作
-			AccountState: AccountSnapshot{
-				TotalBalance:          dbRec.TotalBalance,
-				AvailableBalance:      dbRec.AvailableBalance,
-				TotalUnrealizedProfit: dbRec.TotalUnrealizedProfit,
-				PositionCount:         dbRec.PositionCount,
-				MarginUsedPct:         dbRec.MarginUsedPct,
-			},
-		}
+		records[i] = l.convertRecord(dbRec)
 	}
 	return records, nil
 }
 
+// convertRecord 数据库记录转换为日志记录（加载关联的决策动作）
+func (l *DecisionLogger) convertRecord(dbRec *models.DecisionRecord) *DecisionRecord {
+	// 从数据库加载该记录的所有决策动作
+	actions, err := l.db.Decision().GetActions(dbRec.ID)
+	if err != nil {
+		log.Printf("⚠️ 加载record %d 的决策动作失败: %v", dbRec.ID, err)
+		actions = []*models.DecisionAction{} // 使用空数组
+	}
+	return toLoggerRecord(dbRec, actions)
+}
+
+// toLoggerRecord 数据库记录和决策动作转换为日志记录
+func toLoggerRecord(dbRec *models.DecisionRecord, actions []*models.DecisionAction) *DecisionRecord {
+	// 转换decision actions
+	var loggerActions []DecisionAction
+	for _, act := range actions {
+		loggerActions = append(loggerActions, DecisionAction{
+			Action:        act.Action,
+			Symbol:        act.Symbol,
+			Quantity:      act.Quantity,
+			Leverage:      act.Leverage,
+			Price:         act.Price,
+			OrderID:       act.OrderID,
+			Timestamp:     act.Timestamp,
+			Success:       act.Success,
+			Error:         act.Error,
+			WasStopLoss:   act.WasStopLoss,
+			ExecutedQty:   act.ExecutedQty,
+			AvgPrice:      act.AvgPrice,
+			PriceDriftPct: act.PriceDriftPct,
+			NotionalUSD:   act.NotionalUSD,
+			MarginUSD:     act.MarginUSD,
+			Adjustment:    act.Adjustment,
+			ErrorClass:    act.ErrorClass,
+			BookSnapshot:  rawJSON(act.BookSnapshot),
+		})
+	}
+
+	return &DecisionRecord{
+		ID:                dbRec.ID,
+		Timestamp:         dbRec.Timestamp,
+		CycleNumber:       dbRec.CycleNumber,
+		InputPrompt:       dbRec.InputPrompt,
+		CoTTrace:          dbRec.CoTTrace,
+		DecisionJSON:      dbRec.DecisionJSON,
+		Success:           dbRec.Success,
+		ErrorMessage:      dbRec.ErrorMessage,
+		AIProvider:        dbRec.AIProvider,
+		PromptHash:        dbRec.PromptHash,
+		Cached:            dbRec.Cached,
+		Regime:            dbRec.Regime,
+		LatencyMs:         dbRec.LatencyMs,
+		LearningSummaryID: dbRec.LearningSummaryID,
+		Manual:            dbRec.Manual,
+		SchemaVersion:     dbRec.SchemaVersion,
+		RealizedPnLDelta:  dbRec.RealizedPnLDelta,
+		ClosedTrades:      dbRec.ClosedTrades,
+		AIParams:          dbRec.AIParams,
+		CriticReview:      dbRec.CriticReview,
+		CriticReviewed:    dbRec.CriticReviewed,
+		CriticVetoed:      dbRec.CriticVetoed,
+		MarketFetchMs:     dbRec.MarketFetchMs,
+		PromptBuildMs:     dbRec.PromptBuildMs,
+		AILatencyMs:       dbRec.AILatencyMs,
+		ParseValidateMs:   dbRec.ParseValidateMs,
+		ExecutionMs:       dbRec.ExecutionMs,
+		DBWriteMs:         dbRec.DBWriteMs,
+		SymbolsFetched:    dbRec.SymbolsFetched,
+		SymbolsFailed:     dbRec.SymbolsFailed,
+		Decisions:         loggerActions, // 加载关联的决策动作
+		AccountState: AccountSnapshot{
+			TotalBalance:          dbRec.TotalBalance,
+			AvailableBalance:      dbRec.AvailableBalance,
+			TotalUnrealizedProfit: dbRec.TotalUnrealizedProfit,
+			PositionCount:         dbRec.PositionCount,
+			MarginUsedPct:         dbRec.MarginUsedPct,
+		},
+	}
+}
+
 // GetRecordByDate 获取指定日期的所有记录
 func (l *DecisionLogger) GetRecordByDate(date time.Time) ([]*DecisionRecord, error) {
 	dateStr := date.Format("20060102")
@@ -368,6 +545,13 @@ func (l *DecisionLogger) GetStatistics() (*Statistics, error) {
 		} else {
 			stats.FailedCycles++
 		}
+
+		stats.CriticReviewed += record.CriticReviewed
+		stats.CriticVetoed += record.CriticVetoed
+	}
+
+	if stats.CriticReviewed > 0 {
+		stats.CriticVetoRate = float64(stats.CriticVetoed) / float64(stats.CriticReviewed) * 100
 	}
 
 	return stats, nil
@@ -380,10 +564,16 @@ type Statistics struct {
 	FailedCycles        int `json:"failed_cycles"`
 	TotalOpenPositions  int `json:"total_open_positions"`
 	TotalClosePositions int `json:"total_close_positions"`
+
+	// 两阶段AI审核（未启用审核模型时为0）
+	CriticReviewed int     `json:"critic_reviewed"`  // 审核模型审核的决策数
+	CriticVetoed   int     `json:"critic_vetoed"`    // 审核模型否决的决策数
+	CriticVetoRate float64 `json:"critic_veto_rate"` // 否决率（%）
 }
 
 // TradeOutcome 单笔交易结果
 type TradeOutcome struct {
+	ID            int64     `json:"id"`             // 数据库记录ID
 	Symbol        string    `json:"symbol"`         // 币种
 	Side          string    `json:"side"`           // long/short
 	Quantity      float64   `json:"quantity"`       // 仓位数量
@@ -398,18 +588,30 @@ type TradeOutcome struct {
 	DurationMinutes int64   `json:"duration_minutes"` // 持仓时长（分钟）
 	OpenTime      time.Time `json:"open_time"`      // 开仓时间
 	CloseTime     time.Time `json:"close_time"`     // 平仓时间
-	WasStopLoss   bool      `json:"was_stop_loss"`  // 是否止损
+	WasStopLoss   bool      `json:"was_stop_loss"`  // 是否止损单触发
+	TakeProfitHit bool      `json:"take_profit_hit"` // 是否止盈单触发
 	
 	// 新增：开仓时的市场状态（用于失败分析）
 	EntryMACD     float64 `json:"entry_macd"`      // 开仓时MACD
 	EntryRSI      float64 `json:"entry_rsi"`       // 开仓时RSI
 	EntryVolRatio float64 `json:"entry_vol_ratio"` // 开仓时成交量比率
 	EntryReason   string  `json:"entry_reason"`    // 开仓依据
+	Regime        string  `json:"regime"`          // 开仓时的市场状态
 	
 	// 新增：失败原因分析
 	ExitReason    string  `json:"exit_reason"`     // 退出原因: "止损" / "止盈" / "手动平仓"
 	IsPremature   bool    `json:"is_premature"`    // 是否过早平仓（<30分钟）
 	FailureType   string  `json:"failure_type"`    // 失败类型（如果亏损）
+
+	Source        string  `json:"source,omitempty"` // 记录来源：bot / import（从交易所历史成交导入）
+
+	// 关联的决策周期（0表示未关联）
+	OpenRecordID  int64 `json:"open_record_id"`  // 开仓的决策记录ID
+	OpenActionID  int64 `json:"open_action_id"`  // 开仓的决策动作ID
+	CloseRecordID int64 `json:"close_record_id"` // 平仓的决策记录ID
+	CloseActionID int64 `json:"close_action_id"` // 平仓的决策动作ID
+	OpenCycle     int   `json:"open_cycle"`      // 开仓决策的周期编号
+	CloseCycle    int   `json:"close_cycle"`     // 平仓决策的周期编号
 }
 
 // PerformanceAnalysis 交易表现分析
@@ -422,6 +624,8 @@ type PerformanceAnalysis struct {
 	AvgLoss       float64                       `json:"avg_loss"`       // 平均亏损
 	ProfitFactor  float64                       `json:"profit_factor"`  // 盈亏比
 	SharpeRatio   float64                       `json:"sharpe_ratio"`   // 夏普比率（风险调整后收益）
+	SortinoRatio  float64                       `json:"sortino_ratio"`  // 索提诺比率（只惩罚下行波动）
+	CalmarRatio   float64                       `json:"calmar_ratio"`   // 卡玛比率（区间收益/最大回撤）
 	// 新增：多空统计
 	LongTrades    int     `json:"long_trades"`     // 做多交易数
 	ShortTrades   int     `json:"short_trades"`    // 做空交易数
@@ -429,10 +633,29 @@ type PerformanceAnalysis struct {
 	ShortWinRate  float64 `json:"short_win_rate"`  // 做空胜率
 	LongAvgPnL    float64 `json:"long_avg_pnl"`    // 做多平均盈亏
 	ShortAvgPnL   float64 `json:"short_avg_pnl"`   // 做空平均盈亏
+	// 止损/止盈触发统计
+	StopLossHits      int     `json:"stop_loss_hits"`       // 止损单触发平仓数
+	TakeProfitHits    int     `json:"take_profit_hits"`     // 止盈单触发平仓数
+	StopLossHitRate   float64 `json:"stop_loss_hit_rate"`   // 止损触发率（占总交易数%）
+	TakeProfitHitRate float64 `json:"take_profit_hit_rate"` // 止盈触发率（占总交易数%）
 	RecentTrades  []TradeOutcome                `json:"recent_trades"`  // 最近N笔交易
 	SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`   // 各币种表现
 	BestSymbol    string                        `json:"best_symbol"`    // 表现最好的币种
 	WorstSymbol   string                        `json:"worst_symbol"`   // 表现最差的币种
+	RegimeStats   map[string]*RegimePerformance `json:"regime_stats,omitempty"` // 各市场状态下的表现
+}
+
+// RegimePerformance 市场状态表现统计
+type RegimePerformance struct {
+	Regime        string  `json:"regime"`         // 市场状态
+	TotalTrades   int     `json:"total_trades"`   // 交易次数
+	WinningTrades int     `json:"winning_trades"` // 盈利次数
+	WinRate       float64 `json:"win_rate"`       // 胜率
+	TotalPnL      float64 `json:"total_pnl"`      // 总盈亏
+	AvgPnL        float64 `json:"avg_pnl"`        // 平均盈亏
+	ProfitFactor  float64 `json:"profit_factor"`  // 盈亏比
+	grossProfit   float64
+	grossLoss     float64
 }
 
 // SymbolPerformance 币种表现统计
@@ -478,27 +701,7 @@ func (l *DecisionLogger) analyzePerformanceFromDB(lookbackCycles int) (*Performa
 
 	// 转换数据库记录为分析格式
 	for _, dbTrade := range dbTrades {
-		trade := TradeOutcome{
-			Symbol:          dbTrade.Symbol,
-			Side:            dbTrade.Side,
-			Quantity:        dbTrade.Quantity,
-			Leverage:        dbTrade.Leverage,
-			OpenPrice:       dbTrade.OpenPrice,
-			ClosePrice:      dbTrade.ClosePrice,
-			PositionValue:   dbTrade.PositionValue,
-			MarginUsed:      dbTrade.MarginUsed,
-			PnL:             dbTrade.PnL,
-			PnLPct:          dbTrade.PnLPct,
-			Duration:        fmt.Sprintf("%d分钟", dbTrade.DurationMinutes),
-			DurationMinutes: dbTrade.DurationMinutes,
-			OpenTime:        dbTrade.OpenTime,
-			CloseTime:       dbTrade.CloseTime,
-			WasStopLoss:     dbTrade.WasStopLoss,
-			EntryReason:     dbTrade.EntryReason,
-			ExitReason:      dbTrade.ExitReason,
-			IsPremature:     dbTrade.IsPremature,
-			FailureType:     dbTrade.FailureType,
-		}
+		trade := toLoggerTrade(dbTrade)
 
 		analysis.RecentTrades = append(analysis.RecentTrades, trade)
 		analysis.TotalTrades++
@@ -512,6 +715,13 @@ func (l *DecisionLogger) analyzePerformanceFromDB(lookbackCycles int) (*Performa
 			analysis.AvgLoss += trade.PnL
 		}
 
+		// 止损/止盈触发统计
+		if trade.WasStopLoss {
+			analysis.StopLossHits++
+		} else if trade.TakeProfitHit {
+			analysis.TakeProfitHits++
+		}
+
 		// 多空统计
 		if trade.Side == "long" {
 			analysis.LongTrades++
@@ -541,11 +751,33 @@ func (l *DecisionLogger) analyzePerformanceFromDB(lookbackCycles int) (*Performa
 		} else if trade.PnL < 0 {
 			stats.LosingTrades++
 		}
+
+		// 市场状态统计（旧记录没有状态标签，跳过）
+		if trade.Regime != "" {
+			if analysis.RegimeStats == nil {
+				analysis.RegimeStats = make(map[string]*RegimePerformance)
+			}
+			regimeStats, exists := analysis.RegimeStats[trade.Regime]
+			if !exists {
+				regimeStats = &RegimePerformance{Regime: trade.Regime}
+				analysis.RegimeStats[trade.Regime] = regimeStats
+			}
+			regimeStats.TotalTrades++
+			regimeStats.TotalPnL += trade.PnL
+			if trade.PnL > 0 {
+				regimeStats.WinningTrades++
+				regimeStats.grossProfit += trade.PnL
+			} else if trade.PnL < 0 {
+				regimeStats.grossLoss -= trade.PnL
+			}
+		}
 	}
 
 	// 计算统计指标
 	if analysis.TotalTrades > 0 {
 		analysis.WinRate = (float64(analysis.WinningTrades) / float64(analysis.TotalTrades)) * 100
+		analysis.StopLossHitRate = (float64(analysis.StopLossHits) / float64(analysis.TotalTrades)) * 100
+		analysis.TakeProfitHitRate = (float64(analysis.TakeProfitHits) / float64(analysis.TotalTrades)) * 100
 
 		totalWinAmount := analysis.AvgWin
 		totalLossAmount := analysis.AvgLoss
@@ -593,6 +825,17 @@ func (l *DecisionLogger) analyzePerformanceFromDB(lookbackCycles int) (*Performa
 		}
 	}
 
+	// 计算各市场状态胜率、平均盈亏和盈亏比
+	for _, stats := range analysis.RegimeStats {
+		stats.WinRate = (float64(stats.WinningTrades) / float64(stats.TotalTrades)) * 100
+		stats.AvgPnL = stats.TotalPnL / float64(stats.TotalTrades)
+		if stats.grossLoss > 0 {
+			stats.ProfitFactor = stats.grossProfit / stats.grossLoss
+		} else if stats.grossProfit > 0 {
+			stats.ProfitFactor = 999.0
+		}
+	}
+
 	// 只保留最近10笔交易（数据库已DESC排序，前10条就是最新的）
 	if len(analysis.RecentTrades) > 10 {
 		analysis.RecentTrades = analysis.RecentTrades[:10]
@@ -609,68 +852,28 @@ func (l *DecisionLogger) analyzePerformanceFromDB(lookbackCycles int) (*Performa
 	// 从数据库获取最近的决策记录，计算夏普比率
 	records, err := l.db.Decision().GetLatest(lookbackCycles)
 	if err == nil && len(records) > 0 {
-		analysis.SharpeRatio = l.calculateSharpeRatioFromDB(records)
+		applyRiskRatios(analysis, records, l.flowLedger())
 	}
 
 	return analysis, nil
 }
 
-// calculateSharpeRatioFromDB 从数据库记录计算夏普比率
-func (l *DecisionLogger) calculateSharpeRatioFromDB(records []*models.DecisionRecord) float64 {
-	if len(records) < 2 {
-		return 0.0
-	}
-
-	var equities []float64
-	for _, record := range records {
-		if record.TotalBalance > 0 {
-			equities = append(equities, record.TotalBalance)
-		}
-	}
-
-	if len(equities) < 2 {
-		return 0.0
-	}
-
-	// 计算周期收益率
-	var returns []float64
-	for i := 1; i < len(equities); i++ {
-		if equities[i-1] > 0 {
-			periodReturn := (equities[i] - equities[i-1]) / equities[i-1]
-			returns = append(returns, periodReturn)
-		}
-	}
-
-	if len(returns) == 0 {
-		return 0.0
-	}
-
-	// 计算平均收益率
-	sumReturns := 0.0
-	for _, r := range returns {
-		sumReturns += r
-	}
-	meanReturn := sumReturns / float64(len(returns))
-
-	// 计算标准差
-	sumSquaredDiff := 0.0
-	for _, r := range returns {
-		diff := r - meanReturn
-		sumSquaredDiff += diff * diff
-	}
-	variance := sumSquaredDiff / float64(len(returns))
-	stdDev := math.Sqrt(variance)
-
-	if stdDev == 0 {
-		if meanReturn > 0 {
-			return 999.0
-		} else if meanReturn < 0 {
-			return -999.0
-		}
-		return 0.0
+// flowLedger 资金划转累计台账（读取失败时返回nil，净值序列不做调整）
+func (l *DecisionLogger) flowLedger() *analytics.FlowLedger {
+	flows, err := l.db.BalanceFlow().GetAll()
+	if err != nil {
+		return nil
 	}
+	return analytics.NewFlowLedger(flows)
+}
 
-	return meanReturn / stdDev
+// applyRiskRatios 根据决策记录的净值序列计算风险调整后收益（与风险指标、性能监控使用同一实现，扣除入金/出金）
+func applyRiskRatios(analysis *PerformanceAnalysis, records []*models.DecisionRecord, ledger *analytics.FlowLedger) {
+	equities := analytics.EquityCurveNetOfFlows(records, ledger)
+	returns := analytics.Returns(equities)
+	analysis.SharpeRatio = analytics.SharpeRatio(returns)
+	analysis.SortinoRatio = analytics.SortinoRatio(returns)
+	analysis.CalmarRatio = analytics.CalmarRatio(equities)
 }
 
 // analyzeFromDecisionActions 从 decision_actions 表分析并生成交易记录
@@ -906,7 +1109,7 @@ func (l *DecisionLogger) analyzeFromDecisionActions(lookbackCycles int) (*Perfor
 
 	// 计算夏普比率
 	if len(records) > 0 {
-		analysis.SharpeRatio = l.calculateSharpeRatioFromDB(records)
+		applyRiskRatios(analysis, records, l.flowLedger())
 	}
 
 	log.Printf("✓ 从decision_actions分析出 %d 笔完整交易", analysis.TotalTrades)
@@ -922,12 +1125,29 @@ func (l *DecisionLogger) getActionsForRecord(recordID int64) ([]*models.Decision
 	return l.db.Decision().GetActions(recordID)
 }
 
-// SaveTradeOutcome 保存交易结果到数据库
+// rawJSON 数据库中保存的JSON字段（空字符串表示未记录）
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	return json.RawMessage(s)
+}
+
+// openActionLinkWindow 关联开仓决策时允许开仓动作早于记录的开仓时间的窗口
+const openActionLinkWindow = 10 * time.Minute
+
+// SaveTradeOutcome 保存交易结果到数据库（保存后回填trade.ID；平仓决策在写入决策记录时关联）
 func (l *DecisionLogger) SaveTradeOutcome(trade *TradeOutcome) error {
 	if l.db == nil {
 		return nil // 数据库不可用，跳过
 	}
 
+	if l.writeFaultInjector != nil {
+		if err := l.writeFaultInjector("保存交易结果"); err != nil {
+			return err
+		}
+	}
+
 	dbTrade := &models.TradeOutcome{
 		TraderID:        l.traderID,
 		Symbol:          trade.Symbol,
@@ -944,10 +1164,16 @@ func (l *DecisionLogger) SaveTradeOutcome(trade *TradeOutcome) error {
 		OpenTime:        trade.OpenTime,
 		CloseTime:       trade.CloseTime,
 		WasStopLoss:     trade.WasStopLoss,
+		TakeProfitHit:   trade.TakeProfitHit,
 		EntryReason:     trade.EntryReason,
 		ExitReason:      trade.ExitReason,
 		IsPremature:     trade.IsPremature,
 		FailureType:     trade.FailureType,
+		EntryMACD:       trade.EntryMACD,
+		EntryRSI:        trade.EntryRSI,
+		EntryVolRatio:   trade.EntryVolRatio,
+		Regime:          trade.Regime,
+		Source:          trade.Source,
 	}
 
 	dbTradeModel := &models.TradeOutcome{
@@ -966,78 +1192,39 @@ func (l *DecisionLogger) SaveTradeOutcome(trade *TradeOutcome) error {
 		OpenTime:        dbTrade.OpenTime,
 		CloseTime:       dbTrade.CloseTime,
 		WasStopLoss:     dbTrade.WasStopLoss,
+		TakeProfitHit:   dbTrade.TakeProfitHit,
 		EntryReason:     dbTrade.EntryReason,
 		ExitReason:      dbTrade.ExitReason,
 		IsPremature:     dbTrade.IsPremature,
 		FailureType:     dbTrade.FailureType,
-	}
-	return l.db.Trade().Insert(dbTradeModel)
-}
-
-// calculateSharpeRatio 计算夏普比率
-// 基于账户净值的变化计算风险调整后收益
-func (l *DecisionLogger) calculateSharpeRatio(records []*DecisionRecord) float64 {
-	if len(records) < 2 {
-		return 0.0
-	}
-
-	// 提取每个周期的账户净值
-	// 注意：TotalBalance字段实际存储的是TotalEquity（账户总净值）
-	// TotalUnrealizedProfit字段实际存储的是TotalPnL（相对初始余额的盈亏）
-	var equities []float64
-	for _, record := range records {
-		// 直接使用TotalBalance，因为它已经是完整的账户净值
-		equity := record.AccountState.TotalBalance
-		if equity > 0 {
-			equities = append(equities, equity)
+		EntryMACD:       dbTrade.EntryMACD,
+		EntryRSI:        dbTrade.EntryRSI,
+		EntryVolRatio:   dbTrade.EntryVolRatio,
+		Regime:          dbTrade.Regime,
+		Source:          dbTrade.Source,
+		OpenRecordID:    trade.OpenRecordID,
+		OpenActionID:    trade.OpenActionID,
+	}
+
+	// 关联开仓的决策动作（开仓时间前后窗口内最近一次成功的开仓，导入的历史交易没有对应决策）
+	if dbTradeModel.OpenRecordID == 0 && dbTradeModel.Source != models.TradeSourceImport {
+		from := time.Time{}
+		if !trade.OpenTime.IsZero() {
+			from = trade.OpenTime.Add(-openActionLinkWindow)
 		}
-	}
-
-	if len(equities) < 2 {
-		return 0.0
-	}
-
-	// 计算周期收益率（period returns）
-	var returns []float64
-	for i := 1; i < len(equities); i++ {
-		if equities[i-1] > 0 {
-			periodReturn := (equities[i] - equities[i-1]) / equities[i-1]
-			returns = append(returns, periodReturn)
+		recordID, actionID, err := l.db.Decision().FindOpenAction(trade.Symbol, trade.Side, from, trade.CloseTime)
+		if err != nil {
+			log.Printf("⚠️  查找 %s %s 的开仓决策失败: %v", trade.Symbol, trade.Side, err)
 		}
+		dbTradeModel.OpenRecordID = recordID
+		dbTradeModel.OpenActionID = actionID
 	}
 
-	if len(returns) == 0 {
-		return 0.0
-	}
-
-	// 计算平均收益率
-	sumReturns := 0.0
-	for _, r := range returns {
-		sumReturns += r
-	}
-	meanReturn := sumReturns / float64(len(returns))
-
-	// 计算收益率标准差
-	sumSquaredDiff := 0.0
-	for _, r := range returns {
-		diff := r - meanReturn
-		sumSquaredDiff += diff * diff
-	}
-	variance := sumSquaredDiff / float64(len(returns))
-	stdDev := math.Sqrt(variance)
-
-	// 避免除以零
-	if stdDev == 0 {
-		if meanReturn > 0 {
-			return 999.0 // 无波动的正收益
-		} else if meanReturn < 0 {
-			return -999.0 // 无波动的负收益
-		}
-		return 0.0
+	if err := l.db.Trade().Insert(dbTradeModel); err != nil {
+		return err
 	}
-
-	// 计算夏普比率（假设无风险利率为0）
-	// 注：直接返回周期级别的夏普比率（非年化），正常范围 -2 到 +2
-	sharpeRatio := meanReturn / stdDev
-	return sharpeRatio
+	trade.ID = dbTradeModel.ID
+	trade.OpenRecordID = dbTradeModel.OpenRecordID
+	trade.OpenActionID = dbTradeModel.OpenActionID
+	return nil
 }
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// spoolFileName 数据库写入失败的决策记录待补写队列（每行一条JSON）
+const spoolFileName = "pending_decisions.jsonl"
+
+// spoolAlertFailures 连续写入失败达到该次数时发出预警
+const spoolAlertFailures = 3
+
+// SetFailureAlert 设置决策记录持续写入失败时的预警回调
+func (l *DecisionLogger) SetFailureAlert(alert func(failures int, err error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failureAlert = alert
+}
+
+// spoolPath 待补写队列文件路径
+func (l *DecisionLogger) spoolPath() string {
+	return filepath.Join(l.logDir, spoolFileName)
+}
+
+// loadCycleNumber 从数据库和待补写队列恢复周期编号（重启后继续编号，不从0开始）
+// 数据库暂不可用时保留内存编号，之后每次写入都重试，取两者中较大的值
+func (l *DecisionLogger) loadCycleNumber() {
+	if l.cycleLoaded {
+		return
+	}
+	if pending, err := l.readSpool(); err == nil {
+		for _, r := range pending {
+			if r.CycleNumber > l.cycleNumber {
+				l.cycleNumber = r.CycleNumber
+			}
+		}
+	}
+	if l.db == nil {
+		return
+	}
+	maxCycle, err := l.db.Decision().GetMaxCycleNumber()
+	if err != nil {
+		log.Printf("⚠️  读取最大周期编号失败: %v", err)
+		return
+	}
+	if maxCycle > l.cycleNumber {
+		l.cycleNumber = maxCycle
+	}
+	l.cycleLoaded = true
+}
+
+// readSpool 读取待补写队列（文件不存在时返回空）
+func (l *DecisionLogger) readSpool() ([]*DecisionRecord, error) {
+	f, err := os.Open(l.spoolPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*DecisionRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024) // prompt可能很长
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record DecisionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("⚠️  待补写队列中有无法解析的记录，已跳过: %v", err)
+			continue
+		}
+		records = append(records, &record)
+	}
+	return records, scanner.Err()
+}
+
+// writeSpool 覆盖写入待补写队列（先写临时文件再替换，避免中途失败损坏队列）
+func (l *DecisionLogger) writeSpool(records []*DecisionRecord) error {
+	if len(records) == 0 {
+		if err := os.Remove(l.spoolPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	tmp := l.spoolPath() + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.spoolPath())
+}
+
+// replaySpool 按顺序把待补写队列写入数据库（按记录顺序归集已实现盈亏），遇到失败即停止，返回剩余条数
+func (l *DecisionLogger) replaySpool() int {
+	pending, err := l.readSpool()
+	if err != nil {
+		log.Printf("⚠️  读取决策记录待补写队列失败: %v", err)
+		return 0
+	}
+	if len(pending) == 0 {
+		return 0
+	}
+
+	replayed := 0
+	for _, record := range pending {
+		if err := l.writeRecord(record); err != nil {
+			break
+		}
+		replayed++
+	}
+	if replayed == 0 {
+		return len(pending)
+	}
+
+	remaining := pending[replayed:]
+	if err := l.writeSpool(remaining); err != nil {
+		// 已补写的记录仍留在队列中，下次补写会重复写入，这里直接停止补写
+		log.Printf("⚠️  更新决策记录待补写队列失败: %v", err)
+		return len(pending)
+	}
+	log.Printf("📝 已补写 %d 条决策记录到数据库（剩余 %d 条）", replayed, len(remaining))
+	return len(remaining)
+}
+
+// spoolRecord 数据库写入失败时把记录追加到待补写队列，连续失败达到阈值时发出预警
+// 写入队列成功时返回nil（记录不会丢失），队列也写入失败时返回错误
+func (l *DecisionLogger) spoolRecord(record *DecisionRecord, cause error) error {
+	l.consecutiveFailures++
+	if l.consecutiveFailures == spoolAlertFailures && l.failureAlert != nil {
+		l.failureAlert(l.consecutiveFailures, cause)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("保存决策记录失败（%v），序列化待补写记录失败: %w", cause, err)
+	}
+	f, err := os.OpenFile(l.spoolPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("保存决策记录失败（%v），写入待补写队列失败: %w", cause, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("保存决策记录失败（%v），写入待补写队列失败: %w", cause, err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("保存决策记录失败（%v），写入待补写队列失败: %w", cause, err)
+	}
+
+	log.Printf("⚠️  决策记录 cycle %d 写入数据库失败，已加入待补写队列（连续失败%d次）: %v",
+		record.CycleNumber, l.consecutiveFailures, cause)
+	return nil
+}
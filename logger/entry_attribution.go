@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/i18n"
+	"strings"
+)
+
+// EntryIndicatorBucket 按开仓指标分组的交易表现
+type EntryIndicatorBucket struct {
+	Name         string  `json:"name"`          // 分组名称，如 "RSI>70开仓"
+	Trades       int     `json:"trades"`        // 交易数
+	WinRate      float64 `json:"win_rate"`      // 胜率(%)
+	AvgPnL       float64 `json:"avg_pnl"`       // 平均盈亏(USDT)
+	AvgLoss      float64 `json:"avg_loss"`      // 平均亏损(USDT，仅亏损交易)
+	LossMultiple float64 `json:"loss_multiple"` // 平均亏损相对全部交易平均亏损的倍数
+}
+
+// EntryIndicatorAttribution 开仓指标归因报告
+type EntryIndicatorAttribution struct {
+	TotalTrades    int                    `json:"total_trades"`     // 分析的交易数
+	TradesWithData int                    `json:"trades_with_data"` // 有开仓指标快照的交易数
+	Buckets        []EntryIndicatorBucket `json:"buckets"`          // 各分组表现
+}
+
+// AttributeEntryIndicators 按开仓时的RSI/成交量/MACD方向对交易结果分组统计
+func AttributeEntryIndicators(trades []*models.TradeOutcome, cfg database.LearningConfig) *EntryIndicatorAttribution {
+	report := &EntryIndicatorAttribution{TotalTrades: len(trades)}
+
+	var withData []*models.TradeOutcome
+	for _, trade := range trades {
+		// RSI为0说明没有快照（旧数据或关闭了快照）
+		if trade.EntryRSI > 0 {
+			withData = append(withData, trade)
+		}
+	}
+	report.TradesWithData = len(withData)
+	if len(withData) == 0 {
+		return report
+	}
+
+	baseAvgLoss := avgLoss(withData)
+
+	groups := []struct {
+		name  string
+		match func(t *models.TradeOutcome) bool
+	}{
+		{fmt.Sprintf("RSI>%.0f开仓", cfg.RSIOverbought), func(t *models.TradeOutcome) bool { return t.EntryRSI > cfg.RSIOverbought }},
+		{fmt.Sprintf("RSI<%.0f开仓", cfg.RSIOversold), func(t *models.TradeOutcome) bool { return t.EntryRSI < cfg.RSIOversold }},
+		{"RSI中性区间开仓", func(t *models.TradeOutcome) bool {
+			return t.EntryRSI >= cfg.RSIOversold && t.EntryRSI <= cfg.RSIOverbought
+		}},
+		{fmt.Sprintf("放量开仓(量比>%.1f)", cfg.HighVolumeRatio), func(t *models.TradeOutcome) bool { return t.EntryVolRatio > cfg.HighVolumeRatio }},
+		{"缩量开仓(量比<1)", func(t *models.TradeOutcome) bool { return t.EntryVolRatio > 0 && t.EntryVolRatio < 1 }},
+		{"MACD顺势开仓", func(t *models.TradeOutcome) bool {
+			return (t.Side == "long" && t.EntryMACD > 0) || (t.Side == "short" && t.EntryMACD < 0)
+		}},
+		{"MACD逆势开仓", func(t *models.TradeOutcome) bool {
+			return (t.Side == "long" && t.EntryMACD < 0) || (t.Side == "short" && t.EntryMACD > 0)
+		}},
+	}
+
+	for _, g := range groups {
+		var matched []*models.TradeOutcome
+		for _, trade := range withData {
+			if g.match(trade) {
+				matched = append(matched, trade)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		wins := 0
+		totalPnL := 0.0
+		for _, trade := range matched {
+			if trade.PnL > 0 {
+				wins++
+			}
+			totalPnL += trade.PnL
+		}
+
+		bucket := EntryIndicatorBucket{
+			Name:    g.name,
+			Trades:  len(matched),
+			WinRate: float64(wins) / float64(len(matched)) * 100,
+			AvgPnL:  totalPnL / float64(len(matched)),
+			AvgLoss: avgLoss(matched),
+		}
+		if baseAvgLoss < 0 {
+			bucket.LossMultiple = bucket.AvgLoss / baseAvgLoss
+		}
+		report.Buckets = append(report.Buckets, bucket)
+	}
+
+	return report
+}
+
+// avgLoss 亏损交易的平均亏损（负数，没有亏损时为0）
+func avgLoss(trades []*models.TradeOutcome) float64 {
+	total := 0.0
+	count := 0
+	for _, trade := range trades {
+		if trade.PnL < 0 {
+			total += trade.PnL
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// FormatEntryAttribution 格式化开仓指标归因（用于AI学习prompt）
+func FormatEntryAttribution(report *EntryIndicatorAttribution, lang i18n.Lang) string {
+	if report == nil || len(report.Buckets) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "learning.attribution_title", report.TradesWithData))
+	for _, b := range report.Buckets {
+		sb.WriteString(i18n.T(lang, "learning.attribution_bucket", b.Name, b.Trades, b.WinRate, b.AvgPnL))
+		if b.LossMultiple > 0 && math.Abs(b.LossMultiple-1) >= 0.2 {
+			sb.WriteString(i18n.T(lang, "learning.attribution_loss_multiple", b.LossMultiple))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// AnalyzeEntryIndicators 分析最近N笔交易的开仓指标归因
+func (l *DecisionLogger) AnalyzeEntryIndicators(limit int) (*EntryIndicatorAttribution, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	trades, err := l.db.Trade().GetLatest(limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易记录失败: %w", err)
+	}
+	return AttributeEntryIndicators(trades, database.CurrentLearningConfig()), nil
+}
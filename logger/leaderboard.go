@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"fmt"
+	"nofx/analytics"
+	"nofx/database/repositories"
+	"time"
+)
+
+// LeaderboardSparklinePoints 排行榜迷你走势图的最大点数
+const LeaderboardSparklinePoints = 48
+
+// LeaderboardStats 排行榜使用的风险调整收益（净值扣除入金/出金）
+type LeaderboardStats struct {
+	analytics.Summary
+	Since     time.Time `json:"since"`
+	Sparkline []float64 `json:"sparkline"` // 净值走势（降采样，按时间正序）
+}
+
+// GetLeaderboardStats 统计指定时间以来的风险调整收益和净值走势
+func (l *DecisionLogger) GetLeaderboardStats(since time.Time) (*LeaderboardStats, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	// 只需要账户快照，不加载Prompt和决策动作
+	records, _, err := l.db.Decision().Query(repositories.QueryOptions{Since: since, OmitHeavy: true})
+	if err != nil {
+		return nil, fmt.Errorf("读取决策记录失败: %w", err)
+	}
+	// 查询结果最新在前，净值序列需要按时间正序
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+
+	equities := analytics.EquityCurveNetOfFlows(records, l.flowLedger())
+	return &LeaderboardStats{
+		Summary:   analytics.Summarize(equities),
+		Since:     since,
+		Sparkline: analytics.Downsample(equities, LeaderboardSparklinePoints),
+	}, nil
+}
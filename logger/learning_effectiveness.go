@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"fmt"
+	"nofx/database/repositories"
+	"time"
+)
+
+// learningMinTrades 前后对比时每段至少需要的平仓交易数（不足时结论为 inconclusive）
+const learningMinTrades = 5
+
+// learningHistoryLimit 效果报告最多统计的总结版本数
+const learningHistoryLimit = 1000
+
+// 学习总结效果结论
+const (
+	LearningHelpful      = "helpful"      // 胜率和平均盈亏均未变差，且至少一项改善
+	LearningHarmful      = "harmful"      // 胜率和平均盈亏均未改善，且至少一项变差
+	LearningMixed        = "mixed"        // 一项改善一项变差
+	LearningInconclusive = "inconclusive" // 样本不足
+)
+
+// LearningPeriodStats 一段时间内的交易表现
+type LearningPeriodStats struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Trades   int       `json:"trades"`    // 平仓交易数
+	Wins     int       `json:"wins"`      // 盈利交易数
+	WinRate  float64   `json:"win_rate"`  // 胜率(%)
+	TotalPnL float64   `json:"total_pnl"` // 总盈亏(USDT)
+	AvgPnL   float64   `json:"avg_pnl"`   // 平均盈亏(USDT)
+}
+
+// LearningEffectiveness 单个学习总结版本的效果
+// After 为该版本生效期间，Before 为生效前等长的时间段
+type LearningEffectiveness struct {
+	SummaryID           int64               `json:"summary_id"`
+	Version             int                 `json:"version"` // 版本号（按生效顺序从1开始）
+	IsActive            bool                `json:"is_active"`
+	ActivatedAt         time.Time           `json:"activated_at"`
+	DeactivatedAt       *time.Time          `json:"deactivated_at,omitempty"`
+	TradesAnalyzed      int                 `json:"trades_analyzed"`      // 生成总结时分析的交易数
+	Decisions           int                 `json:"decisions"`            // 该版本生效期间的决策周期数
+	SuccessfulDecisions int                 `json:"successful_decisions"` // 其中执行成功的周期数
+	Before              LearningPeriodStats `json:"before"`
+	After               LearningPeriodStats `json:"after"`
+	WinRateChange       float64             `json:"win_rate_change"` // 胜率变化(百分点)
+	AvgPnLChange        float64             `json:"avg_pnl_change"`  // 平均盈亏变化(USDT)
+	Verdict             string              `json:"verdict"`
+}
+
+// GetLearningEffectiveness 对比每个学习总结生效前后的胜率和盈亏（按版本从新到旧）
+func (l *DecisionLogger) GetLearningEffectiveness() ([]*LearningEffectiveness, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	summaries, err := l.db.Learning().GetAll(learningHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习总结失败: %w", err)
+	}
+
+	now := time.Now()
+	reports := make([]*LearningEffectiveness, 0, len(summaries))
+	// summaries 按时间倒序，i-1 为下一个版本
+	for i, summary := range summaries {
+		report := &LearningEffectiveness{
+			SummaryID:      summary.ID,
+			Version:        len(summaries) - i,
+			IsActive:       summary.IsActive,
+			ActivatedAt:    summary.ActivatedAt,
+			TradesAnalyzed: summary.TradesCount,
+		}
+
+		// 生效期间：激活 → 停用（旧数据没有停用时间时取下一个版本的激活时间）
+		end := now
+		switch {
+		case !summary.DeactivatedAt.IsZero():
+			end = summary.DeactivatedAt
+		case !summary.IsActive && i > 0:
+			end = summaries[i-1].ActivatedAt
+		}
+		if end.Before(summary.ActivatedAt) {
+			end = summary.ActivatedAt
+		}
+		if !summary.IsActive {
+			deactivatedAt := end
+			report.DeactivatedAt = &deactivatedAt
+		}
+
+		duration := end.Sub(summary.ActivatedAt)
+		if report.After, err = l.learningPeriodStats(summary.ActivatedAt, end); err != nil {
+			return nil, err
+		}
+		if report.Before, err = l.learningPeriodStats(summary.ActivatedAt.Add(-duration), summary.ActivatedAt); err != nil {
+			return nil, err
+		}
+		if report.Decisions, report.SuccessfulDecisions, err = l.db.Learning().GetDecisionStats(summary.ID); err != nil {
+			return nil, err
+		}
+
+		report.WinRateChange = report.After.WinRate - report.Before.WinRate
+		report.AvgPnLChange = report.After.AvgPnL - report.Before.AvgPnL
+		report.Verdict = learningVerdict(report)
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// learningPeriodStats 统计一段时间内平仓的交易
+func (l *DecisionLogger) learningPeriodStats(start, end time.Time) (LearningPeriodStats, error) {
+	stats := LearningPeriodStats{Start: start, End: end}
+	if !end.After(start) {
+		return stats, nil
+	}
+
+	trades, _, err := l.db.Trade().Query(repositories.QueryOptions{Since: start, Until: end, OmitHeavy: true})
+	if err != nil {
+		return stats, fmt.Errorf("查询交易记录失败: %w", err)
+	}
+	for _, trade := range trades {
+		stats.Trades++
+		stats.TotalPnL += trade.PnL
+		if trade.PnL > 0 {
+			stats.Wins++
+		}
+	}
+	if stats.Trades > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Trades) * 100
+		stats.AvgPnL = stats.TotalPnL / float64(stats.Trades)
+	}
+	return stats, nil
+}
+
+// learningVerdict 根据前后表现给出结论
+func learningVerdict(r *LearningEffectiveness) string {
+	if r.Before.Trades < learningMinTrades || r.After.Trades < learningMinTrades {
+		return LearningInconclusive
+	}
+	switch {
+	case r.WinRateChange >= 0 && r.AvgPnLChange >= 0:
+		return LearningHelpful
+	case r.WinRateChange <= 0 && r.AvgPnLChange <= 0:
+		return LearningHarmful
+	default:
+		return LearningMixed
+	}
+}
+
+// DeactivateLearningSummary 停用指定的学习总结（停用后Prompt中不再包含任何学习总结，直到生成新的总结）
+func (l *DecisionLogger) DeactivateLearningSummary(id int64) error {
+	if l.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	summary, err := l.db.Learning().GetByID(id)
+	if err != nil {
+		return fmt.Errorf("查询学习总结失败: %w", err)
+	}
+	if summary == nil {
+		return fmt.Errorf("学习总结 %d 不存在", id)
+	}
+	deactivated, err := l.db.Learning().Deactivate(id)
+	if err != nil {
+		return err
+	}
+	if !deactivated {
+		return fmt.Errorf("学习总结 %d 未处于激活状态", id)
+	}
+	return nil
+}
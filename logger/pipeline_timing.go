@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"nofx/database/models"
+	"sort"
+	"time"
+)
+
+// StageTimingStats 单个阶段的耗时分布(毫秒)
+type StageTimingStats struct {
+	Samples int     `json:"samples"`
+	AvgMs   float64 `json:"avg_ms"`
+	P50Ms   int64   `json:"p50_ms"`
+	P95Ms   int64   `json:"p95_ms"`
+	MaxMs   int64   `json:"max_ms"`
+}
+
+// PipelineTimingBucket 一个时间段内的决策流水线耗时统计
+type PipelineTimingBucket struct {
+	Start             time.Time        `json:"start"`
+	Cycles            int              `json:"cycles"`              // 周期数
+	FailedCycles      int              `json:"failed_cycles"`       // 失败的周期数
+	CachedCycles      int              `json:"cached_cycles"`       // 复用上一周期决策（未调用AI）的周期数
+	MarketFetch       StageTimingStats `json:"market_fetch"`        // 获取行情
+	PromptBuild       StageTimingStats `json:"prompt_build"`        // 构建Prompt
+	AILatency         StageTimingStats `json:"ai_latency"`          // 调用AI（不含复用决策的周期）
+	ParseValidate     StageTimingStats `json:"parse_validate"`      // 解析和验证决策
+	Execution         StageTimingStats `json:"execution"`           // 执行决策
+	DBWrite           StageTimingStats `json:"db_write"`            // 写入决策记录
+	Total             StageTimingStats `json:"total"`               // 各阶段合计
+	AvgSymbolsFetched float64          `json:"avg_symbols_fetched"` // 平均每周期成功获取行情的币种数
+	AvgSymbolsFailed  float64          `json:"avg_symbols_failed"`  // 平均每周期获取行情失败的币种数
+	SymbolFailRate    float64          `json:"symbol_fail_rate"`    // 行情获取失败率(%)
+}
+
+// PipelineTimingReport 决策流水线耗时趋势
+type PipelineTimingReport struct {
+	Since   time.Time               `json:"since"`
+	Bucket  string                  `json:"bucket"`  // 分组粒度（hour/day）
+	Overall PipelineTimingBucket    `json:"overall"` // 整个统计窗口
+	Buckets []*PipelineTimingBucket `json:"buckets"` // 按时间段分组（按时间正序）
+}
+
+// stageSamples 一个时间段内各阶段的耗时样本
+type stageSamples struct {
+	market, prompt, ai, parse, execution, dbWrite, total []int64
+	fetched, failed                                      int
+}
+
+func (s *stageSamples) add(t *models.CycleTiming) {
+	s.market = append(s.market, t.MarketFetchMs)
+	s.execution = append(s.execution, t.ExecutionMs)
+	s.dbWrite = append(s.dbWrite, t.DBWriteMs)
+	// 失败周期可能在构建Prompt或调用AI之前就结束了，只统计实际执行到的阶段
+	if t.PromptBuildMs > 0 {
+		s.prompt = append(s.prompt, t.PromptBuildMs)
+	}
+	if !t.Cached && t.AILatencyMs > 0 {
+		s.ai = append(s.ai, t.AILatencyMs)
+		s.parse = append(s.parse, t.ParseValidateMs)
+	}
+	s.total = append(s.total, t.MarketFetchMs+t.PromptBuildMs+t.AILatencyMs+t.ParseValidateMs+t.ExecutionMs+t.DBWriteMs)
+	s.fetched += t.SymbolsFetched
+	s.failed += t.SymbolsFailed
+}
+
+// fill 把样本统计结果写入bucket
+func (s *stageSamples) fill(b *PipelineTimingBucket) {
+	b.MarketFetch = stageTimingStats(s.market)
+	b.PromptBuild = stageTimingStats(s.prompt)
+	b.AILatency = stageTimingStats(s.ai)
+	b.ParseValidate = stageTimingStats(s.parse)
+	b.Execution = stageTimingStats(s.execution)
+	b.DBWrite = stageTimingStats(s.dbWrite)
+	b.Total = stageTimingStats(s.total)
+	if b.Cycles > 0 {
+		b.AvgSymbolsFetched = float64(s.fetched) / float64(b.Cycles)
+		b.AvgSymbolsFailed = float64(s.failed) / float64(b.Cycles)
+	}
+	if s.fetched+s.failed > 0 {
+		b.SymbolFailRate = float64(s.failed) / float64(s.fetched+s.failed) * 100
+	}
+}
+
+// stageTimingStats 计算耗时分布
+func stageTimingStats(samples []int64) StageTimingStats {
+	stats := StageTimingStats{Samples: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+	}
+	stats.AvgMs = float64(sum) / float64(len(sorted))
+	stats.P50Ms = timingPercentile(sorted, 50)
+	stats.P95Ms = timingPercentile(sorted, 95)
+	stats.MaxMs = sorted[len(sorted)-1]
+	return stats
+}
+
+// timingPercentile 已排序样本的百分位数（最近秩法）
+func timingPercentile(sorted []int64, pct float64) int64 {
+	rank := int(math.Ceil(pct/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// AnalyzePipelineTimings 按小时或天统计决策流水线各阶段耗时（timings按时间正序）
+func AnalyzePipelineTimings(timings []*models.CycleTiming, since time.Time, bucket string) *PipelineTimingReport {
+	if bucket != "day" {
+		bucket = "hour"
+	}
+	report := &PipelineTimingReport{
+		Since:   since,
+		Bucket:  bucket,
+		Overall: PipelineTimingBucket{Start: since},
+		Buckets: []*PipelineTimingBucket{},
+	}
+
+	overall := &stageSamples{}
+	var current *PipelineTimingBucket
+	var samples *stageSamples
+	for _, t := range timings {
+		start := t.Timestamp.Truncate(time.Hour)
+		if bucket == "day" {
+			y, m, d := t.Timestamp.Date()
+			start = time.Date(y, m, d, 0, 0, 0, 0, t.Timestamp.Location())
+		}
+		if current == nil || !current.Start.Equal(start) {
+			if current != nil {
+				samples.fill(current)
+			}
+			current = &PipelineTimingBucket{Start: start}
+			samples = &stageSamples{}
+			report.Buckets = append(report.Buckets, current)
+		}
+
+		for _, b := range []*PipelineTimingBucket{current, &report.Overall} {
+			b.Cycles++
+			if !t.Success {
+				b.FailedCycles++
+			}
+			if t.Cached {
+				b.CachedCycles++
+			}
+		}
+		samples.add(t)
+		overall.add(t)
+	}
+	if current != nil {
+		samples.fill(current)
+	}
+	overall.fill(&report.Overall)
+	return report
+}
+
+// AnalyzePipelineTimings 统计最近N小时的决策流水线耗时趋势
+func (l *DecisionLogger) AnalyzePipelineTimings(hours int, bucket string) (*PipelineTimingReport, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	timings, err := l.db.Decision().GetCycleTimings(since)
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzePipelineTimings(timings, since, bucket), nil
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"fmt"
+	"nofx/database/models"
+	"nofx/database/repositories"
+)
+
+// QueryRecords 分页查询决策记录（withActions=false 时不加载决策动作，减少查询次数）
+func (l *DecisionLogger) QueryRecords(opts repositories.QueryOptions, withActions bool) ([]*DecisionRecord, int, error) {
+	if l.db == nil {
+		return nil, 0, fmt.Errorf("数据库未初始化")
+	}
+
+	dbRecords, total, err := l.db.Decision().Query(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records := make([]*DecisionRecord, len(dbRecords))
+	for i, dbRec := range dbRecords {
+		if withActions {
+			records[i] = l.convertRecord(dbRec)
+		} else {
+			records[i] = toLoggerRecord(dbRec, nil)
+		}
+	}
+	return records, total, nil
+}
+
+// QueryTrades 分页查询交易结果（最新的在前）
+func (l *DecisionLogger) QueryTrades(opts repositories.QueryOptions) ([]TradeOutcome, int, error) {
+	if l.db == nil {
+		return nil, 0, fmt.Errorf("数据库未初始化")
+	}
+
+	dbTrades, total, err := l.db.Trade().Query(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	trades := make([]TradeOutcome, len(dbTrades))
+	for i, dbTrade := range dbTrades {
+		trades[i] = toLoggerTrade(dbTrade)
+	}
+	return trades, total, nil
+}
+
+// toLoggerTrade 数据库交易结果转换为日志格式
+func toLoggerTrade(dbTrade *models.TradeOutcome) TradeOutcome {
+	return TradeOutcome{
+		ID:              dbTrade.ID,
+		Symbol:          dbTrade.Symbol,
+		Side:            dbTrade.Side,
+		Quantity:        dbTrade.Quantity,
+		Leverage:        dbTrade.Leverage,
+		OpenPrice:       dbTrade.OpenPrice,
+		ClosePrice:      dbTrade.ClosePrice,
+		PositionValue:   dbTrade.PositionValue,
+		MarginUsed:      dbTrade.MarginUsed,
+		PnL:             dbTrade.PnL,
+		PnLPct:          dbTrade.PnLPct,
+		Duration:        fmt.Sprintf("%d分钟", dbTrade.DurationMinutes),
+		DurationMinutes: dbTrade.DurationMinutes,
+		OpenTime:        dbTrade.OpenTime,
+		CloseTime:       dbTrade.CloseTime,
+		WasStopLoss:     dbTrade.WasStopLoss,
+		TakeProfitHit:   dbTrade.TakeProfitHit,
+		EntryReason:     dbTrade.EntryReason,
+		ExitReason:      dbTrade.ExitReason,
+		IsPremature:     dbTrade.IsPremature,
+		FailureType:     dbTrade.FailureType,
+		EntryMACD:       dbTrade.EntryMACD,
+		EntryRSI:        dbTrade.EntryRSI,
+		EntryVolRatio:   dbTrade.EntryVolRatio,
+		Regime:          dbTrade.Regime,
+		Source:          dbTrade.Source,
+		OpenRecordID:    dbTrade.OpenRecordID,
+		OpenActionID:    dbTrade.OpenActionID,
+		CloseRecordID:   dbTrade.CloseRecordID,
+		CloseActionID:   dbTrade.CloseActionID,
+		OpenCycle:       dbTrade.OpenCycle,
+		CloseCycle:      dbTrade.CloseCycle,
+	}
+}
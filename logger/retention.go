@@ -0,0 +1,273 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/database/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// retentionBatchSize 每批归档/清理的决策记录数
+const retentionBatchSize = 500
+
+// RetentionResult 一次归档清理的结果
+type RetentionResult struct {
+	Cutoff          time.Time `json:"cutoff"`           // 清理该时间之前的记录
+	ArchivedRecords int       `json:"archived_records"` // 写入归档文件的记录数
+	DeletedRecords  int       `json:"deleted_records"`  // 从数据库删除的记录数
+	EquityPoints    int       `json:"equity_points"`    // 保留的净值摘要点数
+	ArchiveFiles    []string  `json:"archive_files"`    // 写入的归档文件
+	Vacuumed        bool      `json:"vacuumed"`         // 是否执行了VACUUM
+}
+
+// ArchiveFileInfo 归档文件信息
+type ArchiveFileInfo struct {
+	Name    string    `json:"name"`
+	Bytes   int64     `json:"bytes"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// StorageUsage 决策历史存储用量
+type StorageUsage struct {
+	DBPath       string            `json:"db_path"`
+	DBBytes      int64             `json:"db_bytes"`      // 主库文件大小
+	WALBytes     int64             `json:"wal_bytes"`     // WAL文件大小
+	ArchiveBytes int64             `json:"archive_bytes"` // 归档文件总大小
+	ArchiveFiles []ArchiveFileInfo `json:"archive_files"`
+	TableCounts  map[string]int64  `json:"table_counts"`  // 各表行数
+	OldestRecord *time.Time        `json:"oldest_record"` // 最早的决策记录时间
+}
+
+// archiveDir 归档目录（与数据库同在trader目录下）
+func (l *DecisionLogger) archiveDir() string {
+	return filepath.Join(filepath.Dir(l.db.Path()), "archive")
+}
+
+// ApplyRetention 归档并清理 days 天前的决策记录，同时保留小时级净值摘要
+// archive=false 时直接删除不归档；vacuum=true 时清理后压缩数据库文件
+func (l *DecisionLogger) ApplyRetention(days int, archive, vacuum bool) (*RetentionResult, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if days <= 0 {
+		return nil, fmt.Errorf("保留天数必须大于0")
+	}
+
+	result := &RetentionResult{Cutoff: time.Now().AddDate(0, 0, -days)}
+	files := make(map[string]bool)
+	lastHour := ""
+
+	for {
+		records, err := l.db.Retention().GetRecordsBefore(result.Cutoff, retentionBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		// 1. 归档完整记录（按月追加到gzip文件）
+		if archive {
+			written, err := l.archiveRecords(records)
+			if err != nil {
+				return result, err
+			}
+			for _, f := range written {
+				files[f] = true
+			}
+			result.ArchivedRecords += len(records)
+		}
+
+		// 2. 保留小时级净值摘要（每小时最后一条记录）
+		var points []*models.EquityPoint
+		for i, rec := range records {
+			hour := rec.Timestamp.Format("2006-01-02 15")
+			nextHour := ""
+			if i+1 < len(records) {
+				nextHour = records[i+1].Timestamp.Format("2006-01-02 15")
+			}
+			if hour == nextHour || hour == lastHour {
+				continue
+			}
+			points = append(points, &models.EquityPoint{
+				Timestamp:        rec.Timestamp,
+				CycleNumber:      rec.CycleNumber,
+				TotalEquity:      rec.TotalBalance,
+				AvailableBalance: rec.AvailableBalance,
+				TotalPnL:         rec.TotalUnrealizedProfit, // 该字段实际存储的是总盈亏
+				PositionCount:    rec.PositionCount,
+				MarginUsedPct:    rec.MarginUsedPct,
+			})
+		}
+		if len(points) > 0 && len(records) == retentionBatchSize {
+			// 批次末尾的小时可能延续到下一批，留到下一批再写
+			last := points[len(points)-1]
+			if last.Timestamp.Format("2006-01-02 15") == records[len(records)-1].Timestamp.Format("2006-01-02 15") {
+				points = points[:len(points)-1]
+			}
+		}
+		if err := l.db.Retention().InsertEquityPoints(points); err != nil {
+			return result, err
+		}
+		result.EquityPoints += len(points)
+		if len(points) > 0 {
+			lastHour = points[len(points)-1].Timestamp.Format("2006-01-02 15")
+		}
+
+		// 3. 归档成功后删除
+		ids := make([]int64, len(records))
+		for i, rec := range records {
+			ids[i] = rec.ID
+		}
+		if err := l.db.Retention().DeleteRecords(ids); err != nil {
+			return result, err
+		}
+		result.DeletedRecords += len(records)
+
+		if len(records) < retentionBatchSize {
+			break
+		}
+	}
+
+	for f := range files {
+		result.ArchiveFiles = append(result.ArchiveFiles, f)
+	}
+	sort.Strings(result.ArchiveFiles)
+
+	if result.DeletedRecords > 0 {
+		if err := l.db.Retention().Checkpoint(); err != nil {
+			log.Printf("⚠️ WAL检查点失败: %v", err)
+		}
+	}
+	if vacuum {
+		if err := l.db.Retention().Vacuum(); err != nil {
+			return result, fmt.Errorf("压缩数据库失败: %w", err)
+		}
+		result.Vacuumed = true
+	}
+
+	if result.DeletedRecords > 0 {
+		log.Printf("🗄️  [%s] 已清理 %d 条 %s 之前的决策记录（归档 %d 条，保留净值点 %d 个）",
+			l.traderID, result.DeletedRecords, result.Cutoff.Format("2006-01-02"), result.ArchivedRecords, result.EquityPoints)
+	}
+	return result, nil
+}
+
+// archiveRecords 把决策记录按月追加写入 archive/decisions_YYYY-MM.jsonl.gz
+func (l *DecisionLogger) archiveRecords(records []*models.DecisionRecord) ([]string, error) {
+	if err := os.MkdirAll(l.archiveDir(), 0755); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %w", err)
+	}
+
+	byMonth := make(map[string][]*models.DecisionRecord)
+	for _, rec := range records {
+		month := rec.Timestamp.Format("2006-01")
+		byMonth[month] = append(byMonth[month], rec)
+	}
+
+	var written []string
+	for month, recs := range byMonth {
+		path := filepath.Join(l.archiveDir(), fmt.Sprintf("decisions_%s.jsonl.gz", month))
+		if err := l.appendArchive(path, recs); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// appendArchive 追加一个gzip分段（gzip读取时会自动拼接多个分段）
+func (l *DecisionLogger) appendArchive(path string, records []*models.DecisionRecord) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开归档文件失败: %w", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	enc := json.NewEncoder(gz)
+	for _, dbRec := range records {
+		record := l.convertRecord(dbRec)
+		record.SystemPrompt = dbRec.SystemPrompt
+
+		positions, _ := l.db.Decision().GetPositionSnapshots(dbRec.ID)
+		for _, pos := range positions {
+			record.Positions = append(record.Positions, PositionSnapshot{
+				Symbol:           pos.Symbol,
+				Side:             pos.Side,
+				PositionAmt:      pos.PositionAmt,
+				EntryPrice:       pos.EntryPrice,
+				MarkPrice:        pos.MarkPrice,
+				UnrealizedProfit: pos.UnrealizedProfit,
+				Leverage:         pos.Leverage,
+				LiquidationPrice: pos.LiquidationPrice,
+			})
+		}
+		record.CandidateCoins, _ = l.db.Decision().GetCandidateCoins(dbRec.ID)
+
+		if err := enc.Encode(record); err != nil {
+			gz.Close()
+			return fmt.Errorf("写入归档失败: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("写入归档失败: %w", err)
+	}
+	return f.Sync()
+}
+
+// GetStorageUsage 获取决策历史存储用量
+func (l *DecisionLogger) GetStorageUsage() (*StorageUsage, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	usage := &StorageUsage{DBPath: l.db.Path()}
+	if info, err := os.Stat(usage.DBPath); err == nil {
+		usage.DBBytes = info.Size()
+	}
+	if info, err := os.Stat(usage.DBPath + "-wal"); err == nil {
+		usage.WALBytes = info.Size()
+	}
+
+	if entries, err := os.ReadDir(l.archiveDir()); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl.gz") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			usage.ArchiveBytes += info.Size()
+			usage.ArchiveFiles = append(usage.ArchiveFiles, ArchiveFileInfo{
+				Name:    entry.Name(),
+				Bytes:   info.Size(),
+				ModTime: info.ModTime(),
+			})
+		}
+	}
+
+	var err error
+	if usage.TableCounts, err = l.db.Retention().TableCounts(); err != nil {
+		return nil, err
+	}
+	if usage.OldestRecord, err = l.db.Retention().OldestRecordTime(); err != nil {
+		return nil, fmt.Errorf("查询最早记录失败: %w", err)
+	}
+	return usage, nil
+}
+
+// GetArchivedEquityPoints 获取归档后保留的净值摘要点
+func (l *DecisionLogger) GetArchivedEquityPoints() ([]*models.EquityPoint, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	return l.db.Retention().GetEquityPoints()
+}
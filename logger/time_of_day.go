@@ -0,0 +1,19 @@
+package logger
+
+import (
+	"fmt"
+	"nofx/analytics"
+)
+
+// AnalyzeTimeOfDay 按开仓时间（UTC小时/星期几）统计最近N笔交易的表现
+func (l *DecisionLogger) AnalyzeTimeOfDay(limit, minTrades int) (*analytics.TimeOfDayReport, error) {
+	if l.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	trades, err := l.db.Trade().GetLatest(limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易记录失败: %w", err)
+	}
+	return analytics.AnalyzeTimeOfDay(trades, minTrades), nil
+}
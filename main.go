@@ -8,6 +8,7 @@ import (
 	"nofx/manager"
 	"nofx/market"
 	"nofx/pool"
+	"nofx/sharedstate"
 	"os"
 	"os/signal"
 	"strings"
@@ -29,6 +30,40 @@ func main() {
 
 	log.Printf("✓ 配置加载成功，共%d个trader参赛", len(cfg.Traders))
 
+	// 初始化全局运行时配置（system_configs中的可调参数，修改后无需重启）
+	sysConn, err := database.NewSystemConnection()
+	if err != nil {
+		log.Printf("⚠️ 连接系统数据库失败，运行时配置将使用默认值: %v", err)
+	} else {
+		defer sysConn.Close()
+		database.InitGlobalConfig(sysConn.DB())
+	}
+
+	// K线持久化（拉取的K线去重落盘，供离线回测/回放，并在周期内复用）
+	if rc := database.GetGlobalConfig(); rc != nil && rc.GetKlineStoreConfig().Enabled {
+		klineStore, err := database.NewKlineStore()
+		if err != nil {
+			log.Printf("⚠️ 初始化K线持久化失败，将只从交易所拉取: %v", err)
+		} else {
+			defer klineStore.Close()
+			market.SetKlineStore(klineStore)
+			log.Printf("✓ 已启用K线持久化")
+		}
+	}
+
+	// 共享状态（多进程/多主机部署时通过Redis共享行情缓存、限流计数和暂停/停止开关）
+	if rc := database.GetGlobalConfig(); rc != nil {
+		if shared := rc.GetSharedStateConfig(); shared.Backend == "redis" {
+			store, err := sharedstate.NewRedisStore(shared.RedisAddr, shared.RedisPassword, shared.RedisDB, shared.KeyPrefix)
+			if err != nil {
+				log.Printf("⚠️ 初始化Redis共享状态失败，将使用进程内存: %v", err)
+			} else {
+				defer store.Close()
+				sharedstate.Use(store)
+			}
+		}
+	}
+
 	// 设置市场数据K线配置
 	log.Printf("[DEBUG] MarketData.Klines length: %d", len(cfg.MarketData.Klines))
 	for i, k := range cfg.MarketData.Klines {
@@ -49,6 +84,14 @@ func main() {
 	} else {
 		log.Printf("⚠️ 未配置K线数据，将使用默认值")
 	}
+
+	// 订阅全市场强平订单（爆仓统计用于市场情绪和连环爆仓风控）
+	market.StartLiquidationFeed()
+	log.Printf("✓ 已启动爆仓数据流订阅")
+
+	// 订阅全市场标记价格（持仓接口按实时标记价格计算未实现盈亏和强平距离）
+	market.StartMarkPriceFeed()
+	log.Printf("✓ 已启动标记价格数据流订阅")
 	fmt.Println()
 
 	// 设置默认主流币种列表
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"fmt"
+	"nofx/logger"
+	"sort"
+)
+
+// 排行榜排序指标
+const (
+	RankByPnL         = "pnl"          // 总盈亏（默认）
+	RankBySharpe      = "sharpe"       // 夏普比率
+	RankByCalmar      = "calmar"       // 卡玛比率
+	RankByPnLDrawdown = "pnl_drawdown" // 单位最大回撤的盈亏
+	RankByConsistency = "consistency"  // 收益一致性评分
+)
+
+// minRankPeriods 风险调整指标参与排名所需的最少收益率样本数，样本不足的trader排在后面
+const minRankPeriods = 10
+
+// ParseRankBy 校验排行榜排序指标（空字符串为按总盈亏）
+func ParseRankBy(rankBy string) (string, error) {
+	switch rankBy {
+	case "":
+		return RankByPnL, nil
+	case RankByPnL, RankBySharpe, RankByCalmar, RankByPnLDrawdown, RankByConsistency:
+		return rankBy, nil
+	}
+	return "", fmt.Errorf("rank_by只能为 %s/%s/%s/%s/%s", RankByPnL, RankBySharpe, RankByCalmar, RankByPnLDrawdown, RankByConsistency)
+}
+
+// rankScore 按排序指标取分数，返回是否参与排名（风险调整指标样本不足时不参与）
+func rankScore(rankBy string, totalPnL float64, stats *logger.LeaderboardStats) (float64, bool) {
+	if rankBy == RankByPnL {
+		return totalPnL, true
+	}
+	if stats == nil || stats.Periods < minRankPeriods {
+		return 0, false
+	}
+	switch rankBy {
+	case RankBySharpe:
+		return stats.SharpeRatio, true
+	case RankByCalmar:
+		return stats.CalmarRatio, true
+	case RankByPnLDrawdown:
+		return stats.PnLToMaxDrawdown, true
+	default:
+		return stats.ConsistencyScore, true
+	}
+}
+
+// leaderboardEntry 排名用的trader条目
+type leaderboardEntry struct {
+	data     map[string]interface{}
+	score    float64
+	eligible bool
+	totalPnL float64
+}
+
+// rankEntries 按分数从高到低排名，不参与排名的按总盈亏排在后面，写入 rank / rank_score / rank_eligible
+func rankEntries(entries []leaderboardEntry) []map[string]interface{} {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.eligible != b.eligible {
+			return a.eligible
+		}
+		if a.eligible && a.score != b.score {
+			return a.score > b.score
+		}
+		return a.totalPnL > b.totalPnL
+	})
+
+	result := make([]map[string]interface{}, len(entries))
+	for i, e := range entries {
+		e.data["rank"] = i + 1
+		e.data["rank_score"] = e.score
+		e.data["rank_eligible"] = e.eligible
+		result[i] = e.data
+	}
+	return result
+}
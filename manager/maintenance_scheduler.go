@@ -0,0 +1,30 @@
+package manager
+
+import (
+	"log"
+	"time"
+)
+
+// maintenanceCheckInterval 维护窗口检查间隔
+const maintenanceCheckInterval = 30 * time.Second
+
+// runMaintenanceScheduler 定时按维护窗口自动暂停/恢复所有trader（直到StopAll）
+// 窗口配置热生效：每次检查都重新读取运行时配置，热重载新增的trader也会被覆盖
+func (tm *TraderManager) runMaintenanceScheduler(stop <-chan struct{}) {
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		for _, t := range tm.GetAllTraders() {
+			t.CheckMaintenanceWindow(now)
+		}
+
+		select {
+		case <-stop:
+			log.Println("⏹  维护窗口调度已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
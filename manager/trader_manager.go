@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"nofx/config"
+	"nofx/i18n"
 	"nofx/trader"
+	"reflect"
 	"sync"
 	"time"
 )
@@ -13,6 +15,9 @@ import (
 type TraderManager struct {
 	traders map[string]*trader.AutoTrader // key: trader ID
 	mu      sync.RWMutex
+
+	maintenanceStop chan struct{} // 关闭时停止维护窗口调度
+	watchdogStop    chan struct{} // 关闭时停止交易循环看门狗
 }
 
 // NewTraderManager 创建trader管理器
@@ -49,6 +54,17 @@ func (tm *TraderManager) AddTrader(cfg config.TraderConfig, coinPoolURL string,
 		AsterSigner:           cfg.AsterSigner,
 		AsterPrivateKey:       cfg.AsterPrivateKey,
 		CoinPoolAPIURL:        coinPoolURL,
+		CoinSource:            cfg.CoinSource,
+		TraderCoinPoolAPIURL:  cfg.CoinPoolAPIURL,
+		TraderOITopAPIURL:     cfg.OITopAPIURL,
+		CoinPoolAuthHeader:    cfg.CoinPoolAuthHeader,
+		CoinPoolRefreshInterval: time.Duration(cfg.CoinPoolRefreshSeconds) * time.Second,
+		MarketDataSource:      cfg.MarketDataSource,
+		PromptLanguage:        cfg.PromptLanguage,
+		AIParams:              cfg.GetAIParams(),
+		CriticAIModel:         cfg.GetCritic().AIModel,
+		CriticModelName:       cfg.GetCritic().ModelName,
+		CriticRules:           cfg.GetCritic().Rules,
 		UseQwen:               cfg.AIModel == "qwen",
 		DeepSeekKey:           cfg.DeepSeekKey,
 		QwenKey:               cfg.QwenKey,
@@ -118,10 +134,25 @@ func (tm *TraderManager) GetTraderIDs() []string {
 
 // StartAll 启动所有trader
 func (tm *TraderManager) StartAll() {
-	tm.mu.RLock()
-	defer tm.mu.RUnlock()
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
 	log.Println("🚀 启动所有Trader...")
+
+	// 先同步一次维护窗口状态，避免trader启动后首个周期在维护窗口内执行
+	now := time.Now()
+	for _, t := range tm.traders {
+		t.CheckMaintenanceWindow(now)
+	}
+	if tm.maintenanceStop == nil {
+		tm.maintenanceStop = make(chan struct{})
+		go tm.runMaintenanceScheduler(tm.maintenanceStop)
+	}
+	if tm.watchdogStop == nil {
+		tm.watchdogStop = make(chan struct{})
+		go tm.runWatchdog(tm.watchdogStop)
+	}
+
 	for id, t := range tm.traders {
 		go func(traderID string, at *trader.AutoTrader) {
 			log.Printf("▶️  启动 %s...", at.GetName())
@@ -134,22 +165,30 @@ func (tm *TraderManager) StartAll() {
 
 // StopAll 停止所有trader
 func (tm *TraderManager) StopAll() {
-	tm.mu.RLock()
-	defer tm.mu.RUnlock()
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
 
 	log.Println("⏹  停止所有Trader...")
+	if tm.maintenanceStop != nil {
+		close(tm.maintenanceStop)
+		tm.maintenanceStop = nil
+	}
+	if tm.watchdogStop != nil {
+		close(tm.watchdogStop)
+		tm.watchdogStop = nil
+	}
 	for _, t := range tm.traders {
 		t.Stop()
 	}
 }
 
-// GetComparisonData 获取对比数据
-func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
+// GetComparisonData 获取对比数据（按rankBy指标排名，风险调整指标和走势图统计since以来的净值）
+func (tm *TraderManager) GetComparisonData(rankBy string, since time.Time) (map[string]interface{}, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
 	comparison := make(map[string]interface{})
-	traders := make([]map[string]interface{}, 0, len(tm.traders))
+	entries := make([]leaderboardEntry, 0, len(tm.traders))
 
 	for _, t := range tm.traders {
 		account, err := t.GetAccountInfo()
@@ -160,24 +199,68 @@ func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
 		status := t.GetStatus()
 		isPaused := t.IsPaused()
 
-		traders = append(traders, map[string]interface{}{
-			"trader_id":       t.GetID(),
-			"trader_name":     t.GetName(),
-			"ai_model":        t.GetAIModel(),
-			"exchange":        status["exchange"],
-			"total_equity":    account["total_equity"],
-			"total_pnl":       account["total_pnl"],
-			"total_pnl_pct":   account["total_pnl_pct"],
-			"position_count":  account["position_count"],
-			"margin_used_pct": account["margin_used_pct"],
-			"call_count":      status["call_count"],
-			"is_running":      status["is_running"].(bool) && !isPaused,
-			"is_paused":       isPaused,
+		// 风险评分（未启用性能监控时为nil）
+		var riskScore interface{}
+		if score, ok := t.GetRiskScore(); ok {
+			riskScore = score
+		}
+
+		// 净值和盈亏按报告币种折算，不同结算资产的交易所可以直接对比
+		reporting, _ := account["reporting"].(map[string]interface{})
+		if reporting == nil {
+			reporting = account
+		}
+
+		// 风险调整收益和净值走势（读取失败时该trader不参与风险调整排名）
+		stats, err := t.GetDecisionLogger().GetLeaderboardStats(since)
+		if err != nil {
+			log.Printf("⚠️  [%s] 统计排行榜指标失败: %v", t.GetName(), err)
+		}
+		var riskAdjusted interface{}
+		var sparkline []float64
+		if stats != nil {
+			riskAdjusted = stats.Summary
+			sparkline = stats.Sparkline
+		}
+
+		totalPnL, _ := reporting["total_pnl"].(float64)
+		score, eligible := rankScore(rankBy, totalPnL, stats)
+		entries = append(entries, leaderboardEntry{
+			score:    score,
+			eligible: eligible,
+			totalPnL: totalPnL,
+			data: map[string]interface{}{
+				"trader_id":        t.GetID(),
+				"trader_name":      t.GetName(),
+				"ai_model":         t.GetAIModel(),
+				"exchange":         status["exchange"],
+				"total_equity":     reporting["total_equity"],
+				"total_pnl":        reporting["total_pnl"],
+				"total_notional":   reporting["total_notional"],
+				"currency":         reporting["currency"],
+				"settlement_asset": account["settlement_asset"],
+				"total_pnl_pct":    account["total_pnl_pct"],
+				"position_count":   account["position_count"],
+				"margin_used_pct":  account["margin_used_pct"],
+				"call_count":       status["call_count"],
+				"is_running":       status["is_running"].(bool) && !isPaused,
+				"is_paused":        isPaused,
+				"state":            status["state"],
+				"close_only":       status["close_only"],
+				"drawdown_lock":    status["drawdown_lock"],
+				"risk_score":       riskScore,
+				"risk_adjusted":    riskAdjusted, // 夏普、卡玛、单位回撤盈亏、一致性评分等（净值扣除入金/出金）
+				"sparkline":        sparkline,    // 净值走势（降采样）
+			},
 		})
 	}
 
+	traders := rankEntries(entries)
 	comparison["traders"] = traders
 	comparison["count"] = len(traders)
+	comparison["currency"] = trader.ReportingCurrency()
+	comparison["rank_by"] = rankBy
+	comparison["since"] = since
 
 	return comparison, nil
 }
@@ -218,6 +301,11 @@ func (tm *TraderManager) ReloadConfig(newConfig *config.Config) error {
 			// 检查关键配置是否改变（API密钥、交易所等）
 			status := existingTrader.GetStatus()
 			if traderCfg.Exchange != status["exchange"] ||
+				traderCfg.CoinSource != "" && traderCfg.CoinSource != status["coin_source"] ||
+				traderCfg.MarketDataSource != "" && traderCfg.MarketDataSource != status["market_data_source"] ||
+				string(i18n.Parse(traderCfg.PromptLanguage)) != status["prompt_language"] ||
+				!reflect.DeepEqual(traderCfg.GetAIParams().Effective(), existingTrader.GetRuntimeSettings().AIParams) ||
+				criticChanged(traderCfg.GetCritic(), existingTrader.GetRuntimeSettings().Critic) ||
 				traderCfg.BinanceAPIKey != "" && !isMaskedKey(traderCfg.BinanceAPIKey) ||
 				traderCfg.BinanceSecretKey != "" && !isMaskedKey(traderCfg.BinanceSecretKey) ||
 				traderCfg.HyperliquidPrivateKey != "" && !isMaskedKey(traderCfg.HyperliquidPrivateKey) ||
@@ -230,6 +318,7 @@ func (tm *TraderManager) ReloadConfig(newConfig *config.Config) error {
 				needRecreate = true
 			} else {
 				log.Printf("✓ Trader '%s' 配置无变化，保留", traderCfg.ID)
+				existingTrader.SetCompactMode(traderCfg.CompactMode)
 				newTraders[traderCfg.ID] = existingTrader
 				delete(oldTraders, traderCfg.ID)
 			}
@@ -268,6 +357,16 @@ func (tm *TraderManager) ReloadConfig(newConfig *config.Config) error {
 	return nil
 }
 
+// criticChanged 审核模型配置是否改变
+func criticChanged(cfg config.CriticConfig, current *trader.CriticSettings) bool {
+	if current == nil {
+		return cfg.AIModel != ""
+	}
+	return cfg.AIModel != current.AIModel ||
+		cfg.ModelName != "" && cfg.ModelName != current.ModelName ||
+		!reflect.DeepEqual(cfg.Rules, current.Rules)
+}
+
 // isMaskedKey 检查密钥是否是脱敏后的值
 func isMaskedKey(key string) bool {
 	return key == "****" || len(key) > 4 && key[len(key)/2-2:len(key)/2+2] == "****"
@@ -297,6 +396,17 @@ func (tm *TraderManager) addTraderUnlocked(cfg config.TraderConfig, coinPoolURL
 		AsterSigner:           cfg.AsterSigner,
 		AsterPrivateKey:       cfg.AsterPrivateKey,
 		CoinPoolAPIURL:        coinPoolURL,
+		CoinSource:            cfg.CoinSource,
+		TraderCoinPoolAPIURL:  cfg.CoinPoolAPIURL,
+		TraderOITopAPIURL:     cfg.OITopAPIURL,
+		CoinPoolAuthHeader:    cfg.CoinPoolAuthHeader,
+		CoinPoolRefreshInterval: time.Duration(cfg.CoinPoolRefreshSeconds) * time.Second,
+		MarketDataSource:      cfg.MarketDataSource,
+		PromptLanguage:        cfg.PromptLanguage,
+		AIParams:              cfg.GetAIParams(),
+		CriticAIModel:         cfg.GetCritic().AIModel,
+		CriticModelName:       cfg.GetCritic().ModelName,
+		CriticRules:           cfg.GetCritic().Rules,
 		UseQwen:               cfg.AIModel == "qwen",
 		DeepSeekKey:           cfg.DeepSeekKey,
 		QwenKey:               cfg.QwenKey,
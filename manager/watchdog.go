@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"log"
+	"nofx/database"
+	"nofx/trader"
+	"time"
+)
+
+// watchdogCheckInterval 看门狗检查间隔
+const watchdogCheckInterval = time.Minute
+
+// runWatchdog 定时检查各trader的交易循环是否卡死（直到StopAll）
+// 卡死时发出危险预警；开启自动重启时重建trader实例
+func (tm *TraderManager) runWatchdog(stop <-chan struct{}) {
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			log.Println("⏹  交易循环看门狗已停止")
+			return
+		case <-ticker.C:
+		}
+
+		cfg := database.LoadRuntimeConfig().GetWatchdogConfig()
+		if !cfg.Enabled {
+			continue
+		}
+		now := time.Now()
+		for id, t := range tm.GetAllTraders() {
+			if t.CheckCycleStall(now, cfg.StallMultiplier) && cfg.AutoRestart {
+				tm.restartStalledTrader(id, t)
+			}
+		}
+	}
+}
+
+// restartStalledTrader 用相同配置重建卡死的trader
+// 卡死的goroutine无法强制结束：旧实例被停止后，即使请求恢复也不会再下单，新实例从头开始交易循环
+func (tm *TraderManager) restartStalledTrader(id string, stalled *trader.AutoTrader) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.traders[id] != stalled {
+		return // 已被热重载替换或删除
+	}
+
+	log.Printf("🔄 看门狗重建卡死的Trader: %s", stalled.GetName())
+	stalled.Stop()
+	at, err := trader.NewAutoTrader(stalled.Config())
+	if err != nil {
+		log.Printf("❌ 重建Trader %s 失败: %v（旧实例已停止）", id, err)
+		delete(tm.traders, id)
+		return
+	}
+	at.CheckMaintenanceWindow(time.Now())
+	tm.traders[id] = at
+
+	go func() {
+		if err := at.Run(); err != nil {
+			log.Printf("❌ %s 运行错误: %v", at.GetName(), err)
+		}
+	}()
+}
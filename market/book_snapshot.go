@@ -0,0 +1,161 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// BookSnapshotLevels 下单时记录的盘口档位数
+const BookSnapshotLevels = 5
+
+// bookSnapshotTimeout 获取盘口快照的超时（下单前同步获取，不能拖慢下单）
+const bookSnapshotTimeout = 2 * time.Second
+
+// BookLevel 盘口一档
+type BookLevel struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+// BookTrade 一笔成交
+type BookTrade struct {
+	Price      float64   `json:"price"`
+	Qty        float64   `json:"qty"`
+	BuyerMaker bool      `json:"buyer_maker"` // true=主动卖出
+	Time       time.Time `json:"time"`
+}
+
+// BookSnapshot 下单时的盘口快照（用于事后分析成交质量和校验滑点估算）
+type BookSnapshot struct {
+	Source              string      `json:"source"` // 盘口数据来源（交易所不提供时使用Binance公开行情）
+	At                  time.Time   `json:"at"`
+	Bids                []BookLevel `json:"bids"`             // 买盘前N档（价格从高到低）
+	Asks                []BookLevel `json:"asks"`             // 卖盘前N档（价格从低到高）
+	Trades              []BookTrade `json:"trades,omitempty"` // 最近成交（从新到旧）
+	Mid                 float64     `json:"mid"`
+	SpreadBps           float64     `json:"spread_bps"`
+	OrderQty            float64     `json:"order_qty,omitempty"`             // 下单数量
+	ExpectedPrice       float64     `json:"expected_price,omitempty"`        // 按快照盘口吃单的预期成交均价（超出前N档的部分按最后一档计算）
+	ExpectedSlippageBps float64     `json:"expected_slippage_bps,omitempty"` // 预期成交价相对中间价的滑点（正值=不利）
+	FillPrice           float64     `json:"fill_price,omitempty"`            // 实际成交均价
+	FillSlippageBps     float64     `json:"fill_slippage_bps,omitempty"`     // 实际成交均价相对中间价的滑点（正值=不利）
+}
+
+// NewBookSnapshot 由盘口档位生成快照（只保留前N档，计算中间价和价差）
+func NewBookSnapshot(source string, bids, asks []BookLevel) *BookSnapshot {
+	if len(bids) > BookSnapshotLevels {
+		bids = bids[:BookSnapshotLevels]
+	}
+	if len(asks) > BookSnapshotLevels {
+		asks = asks[:BookSnapshotLevels]
+	}
+	s := &BookSnapshot{Source: source, At: time.Now(), Bids: bids, Asks: asks}
+	if len(bids) > 0 && len(asks) > 0 && bids[0].Price > 0 && asks[0].Price > 0 {
+		s.Mid = (bids[0].Price + asks[0].Price) / 2
+		s.SpreadBps = (asks[0].Price - bids[0].Price) / s.Mid * 10000
+	}
+	return s
+}
+
+// EstimateFill 按快照盘口估算市价单的成交均价（buy=true时吃卖盘）
+func (s *BookSnapshot) EstimateFill(buy bool, qty float64) {
+	if s == nil || qty <= 0 {
+		return
+	}
+	levels := s.Bids
+	if buy {
+		levels = s.Asks
+	}
+	if len(levels) == 0 {
+		return
+	}
+	s.OrderQty = qty
+	remaining, cost := qty, 0.0
+	for _, level := range levels {
+		take := level.Qty
+		if take > remaining {
+			take = remaining
+		}
+		cost += take * level.Price
+		remaining -= take
+		if remaining <= 0 {
+			break
+		}
+	}
+	if remaining > 0 {
+		cost += remaining * levels[len(levels)-1].Price
+	}
+	s.ExpectedPrice = cost / qty
+	s.ExpectedSlippageBps = s.slippageBps(buy, s.ExpectedPrice)
+}
+
+// RecordFill 记录实际成交均价和相对中间价的滑点
+func (s *BookSnapshot) RecordFill(buy bool, avgPrice float64) {
+	if s == nil || avgPrice <= 0 {
+		return
+	}
+	s.FillPrice = avgPrice
+	s.FillSlippageBps = s.slippageBps(buy, avgPrice)
+}
+
+// slippageBps 成交价相对中间价的滑点（买入高于中间价、卖出低于中间价为正）
+func (s *BookSnapshot) slippageBps(buy bool, price float64) float64 {
+	if s.Mid <= 0 {
+		return 0
+	}
+	if buy {
+		return (price - s.Mid) / s.Mid * 10000
+	}
+	return (s.Mid - price) / s.Mid * 10000
+}
+
+// FetchBookSnapshot 从Binance公开行情获取盘口前N档和最近成交（trades<=0时不获取成交）
+func FetchBookSnapshot(symbol string, trades int) (*BookSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookSnapshotTimeout)
+	defer cancel()
+
+	client := futures.NewClient("", "")
+	depth, err := client.NewDepthService().Symbol(symbol).Limit(BookSnapshotLevels).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s盘口失败: %w", symbol, err)
+	}
+	bids := make([]BookLevel, 0, len(depth.Bids))
+	for _, level := range depth.Bids {
+		price, qty := parseLevel(level.Price, level.Quantity)
+		bids = append(bids, BookLevel{Price: price, Qty: qty})
+	}
+	asks := make([]BookLevel, 0, len(depth.Asks))
+	for _, level := range depth.Asks {
+		price, qty := parseLevel(level.Price, level.Quantity)
+		asks = append(asks, BookLevel{Price: price, Qty: qty})
+	}
+	snapshot := NewBookSnapshot(ProviderBinance, bids, asks)
+	if trades > 0 {
+		snapshot.Trades, err = FetchRecentTrades(symbol, trades)
+		if err != nil {
+			return snapshot, err
+		}
+	}
+	return snapshot, nil
+}
+
+// FetchRecentTrades 从Binance公开行情获取最近成交（从新到旧）
+func FetchRecentTrades(symbol string, limit int) ([]BookTrade, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookSnapshotTimeout)
+	defer cancel()
+
+	recent, err := futures.NewClient("", "").NewRecentTradesService().Symbol(symbol).Limit(limit).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s最近成交失败: %w", symbol, err)
+	}
+	trades := make([]BookTrade, 0, len(recent))
+	for i := len(recent) - 1; i >= 0; i-- {
+		t := recent[i]
+		price, qty := parseLevel(t.Price, t.Quantity)
+		trades = append(trades, BookTrade{Price: price, Qty: qty, BuyerMaker: t.IsBuyerMaker, Time: time.UnixMilli(t.Time)})
+	}
+	return trades, nil
+}
@@ -81,6 +81,3 @@ const (
 	FearGreedGreed         = 55 // 贪婪阈值
 	FearGreedExtremeGreed  = 75 // 极度贪婪阈值
 )
-
-// CompactMode 紧凑模式开关（减少发送给AI的数据量）
-var CompactMode = true
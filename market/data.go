@@ -7,6 +7,7 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"nofx/i18n"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,8 @@ type Data struct {
 	CurrentRSI7       float64
 	OpenInterest      *OIData
 	FundingRate       float64
+	NextFundingTime   time.Time // 下次资金费结算时间（数据源不提供时为零值）
+	FundingInterval   time.Duration // 资金费结算间隔（FundingRate统一为8小时费率，按间隔折算每次结算的费用）
 	IntradaySeries    *IntradayData
 	LongerTermContext *LongerTermData
 	AllTimeframes     []*TimeframeData // 所有配置的时间框架数据
@@ -33,6 +36,12 @@ type Data struct {
 	
 	// 多空比数据（多时间周期）
 	LongShortRatios map[string]*LongShortRatioData `json:"long_short_ratios,omitempty"`
+	
+	// 行情数据质量（缺失K线、异常跳变、数据过期）
+	Quality *DataQuality `json:"quality,omitempty"`
+
+	// 爆仓统计（来自全市场强平订单数据流，未启动时为nil）
+	Liquidations *LiquidationStats `json:"liquidations,omitempty"`
 }
 
 // LongShortRatioData 多空比数据
@@ -46,8 +55,13 @@ type LongShortRatioData struct {
 
 // OIData Open Interest数据
 type OIData struct {
-	Latest  float64
-	Average float64
+	Latest    float64
+	Average   float64   // 历史序列的平均持仓量（历史获取失败时等于Latest）
+	Period    string    // 历史序列周期
+	Series    []OIPoint // 持仓量历史序列（从旧到新）
+	Change1h  float64   // 1小时持仓量变化(%)
+	Change4h  float64   // 4小时持仓量变化(%)
+	Change24h float64   // 24小时持仓量变化(%)
 }
 
 // KlinePoint 完整K线数据点
@@ -73,6 +87,7 @@ type IntradayData struct {
 	LowestPrice  float64      // 最低价
 	PriceRange   float64      // 价格区间
 	Patterns     []string     // K线形态
+	PatternSignals []PatternSignal // K线形态（含置信度和历史胜率，历史胜率以同一组K线计算）
 }
 
 // LongerTermData 长期数据(4小时时间框架)
@@ -90,20 +105,21 @@ type LongerTermData struct {
 
 // TimeframeData 单个时间框架的完整数据
 type TimeframeData struct {
-	Interval      string       // 时间周期 (3m, 15m, 4h等)
-	Limit         int          // 配置的K线数量
-	ShowTable     bool         // 是否显示K线数据
-	Klines        []KlinePoint // K线数据
-	EMA20         float64
-	EMA50         float64
-	MACD          float64
-	RSI7          float64
-	RSI14         float64
-	ATR3          float64
-	ATR14         float64
-	CurrentVolume float64
-	AverageVolume float64
-	Patterns      []string // K线形态
+	Interval       string          // 时间周期 (3m, 15m, 4h等)
+	Limit          int             // 配置的K线数量
+	ShowTable      bool            // 是否显示K线数据
+	Klines         []KlinePoint    // K线数据
+	EMA20          float64
+	EMA50          float64
+	MACD           float64
+	RSI7           float64
+	RSI14          float64
+	ATR3           float64
+	ATR14          float64
+	CurrentVolume  float64
+	AverageVolume  float64
+	Patterns       []string        // K线形态
+	PatternSignals []PatternSignal // K线形态（含置信度和历史胜率）
 }
 
 // Kline K线数据
@@ -186,25 +202,36 @@ func getIntervalName(interval string) string {
 	return interval
 }
 
-// Get 获取指定代币的市场数据
+// Get 获取指定代币的市场数据（默认Binance行情）
 func Get(symbol string) (*Data, error) {
+	return GetWith(nil, symbol)
+}
+
+// GetWith 从指定数据源获取市场数据（provider为nil时使用默认Binance行情）
+func GetWith(provider Provider, symbol string) (*Data, error) {
+	if provider == nil {
+		provider = DefaultProvider
+	}
+
 	// 标准化symbol
 	symbol = Normalize(symbol)
 
 	// 根据配置获取K线数据（第一个配置作为短期，第二个作为长期）
 	var klines3m, klines4h []Kline
 	var err error
+	shortInterval, longInterval := "3m", "4h"
 
 	if len(DefaultKlineSettings) > 0 {
 		// 短期K线
 		shortTerm := DefaultKlineSettings[0]
-		klines3m, err = getKlines(symbol, shortTerm.Interval, shortTerm.Limit+20) // 多获取20根用于计算指标
+		shortInterval = shortTerm.Interval
+		klines3m, err = getProviderKlines(provider, symbol, shortTerm.Interval, shortTerm.Limit+20) // 多获取20根用于计算指标
 		if err != nil {
 			return nil, fmt.Errorf("获取%s K线失败: %v", shortTerm.Interval, err)
 		}
 	} else {
 		// fallback 到默认值
-		klines3m, err = getKlines(symbol, "3m", 40)
+		klines3m, err = getProviderKlines(provider, symbol, "3m", 40)
 		if err != nil {
 			return nil, fmt.Errorf("获取3分钟K线失败: %v", err)
 		}
@@ -213,23 +240,40 @@ func Get(symbol string) (*Data, error) {
 	if len(DefaultKlineSettings) > 1 {
 		// 长期K线
 		longTerm := DefaultKlineSettings[1]
-		klines4h, err = getKlines(symbol, longTerm.Interval, longTerm.Limit)
+		longInterval = longTerm.Interval
+		klines4h, err = getProviderKlines(provider, symbol, longTerm.Interval, longTerm.Limit)
 		if err != nil {
 			return nil, fmt.Errorf("获取%s K线失败: %v", longTerm.Interval, err)
 		}
 	} else {
 		// fallback 到默认值
-		klines4h, err = getKlines(symbol, "4h", 60)
+		klines4h, err = getProviderKlines(provider, symbol, "4h", 60)
 		if err != nil {
 			return nil, fmt.Errorf("获取4小时K线失败: %v", err)
 		}
 	}
 
+	if len(klines3m) == 0 {
+		return nil, fmt.Errorf("%s K线数据为空", shortInterval)
+	}
+
+	// 数据质量检查（在计算指标之前，异常数据会污染指标）
+	quality := assessDataQuality(klines3m, shortInterval, klines4h, longInterval)
+	if quality.Degraded {
+		log.Printf("⚠️  %s 行情数据质量降级: %s", symbol, strings.Join(quality.Issues, "; "))
+	}
+
+	// 逐根K线的指标值（增量计算：每个周期只折叠新收盘的K线）
+	now := time.Now()
+	points3m := indicatorSeries(provider, symbol, shortInterval, klines3m, now)
+	points4h := indicatorSeries(provider, symbol, longInterval, klines4h, now)
+
 	// 计算当前指标 (基于3分钟最新数据)
 	currentPrice := klines3m[len(klines3m)-1].Close
-	currentEMA20 := calculateEMA(klines3m, 20)
-	currentMACD := calculateMACD(klines3m)
-	currentRSI7 := calculateRSI(klines3m, 7)
+	latest3m := points3m[len(points3m)-1]
+	currentEMA20 := latest3m.EMA20
+	currentMACD := latest3m.MACD
+	currentRSI7 := latest3m.RSI7
 
 	// 计算价格变化百分比
 	// 1小时价格变化 = 20个3分钟K线前的价格
@@ -257,19 +301,22 @@ func Get(symbol string) (*Data, error) {
 		oiData = &OIData{Latest: 0, Average: 0}
 	}
 
-	// 获取Funding Rate
-	fundingRate, _ := getFundingRate(symbol)
+	// 获取Funding Rate（含下次结算时间）
+	funding, err := fetchFunding(provider, symbol)
+	if err != nil && provider != DefaultProvider {
+		log.Printf("⚠️ 从%s获取%s资金费率失败: %v", provider.Name(), symbol, err)
+	}
 
 	// 计算日内系列数据
-	intradayData := calculateIntradaySeries(klines3m)
+	intradayData := intradaySeries(klines3m, points3m)
 
 	// 计算长期数据
-	longerTermData := calculateLongerTermData(klines4h)
+	longerTermData := longerTermSeries(klines4h, points4h)
 
 	// 获取所有配置的时间框架数据
 	allTimeframes := make([]*TimeframeData, 0, len(DefaultKlineSettings))
 	for _, setting := range DefaultKlineSettings {
-		tfData, err := fetchTimeframeData(symbol, setting)
+		tfData, err := fetchTimeframeData(provider, symbol, setting)
 		if err != nil {
 			log.Printf("⚠️ 获取%s时间框架数据失败: %v", setting.Interval, err)
 			continue
@@ -293,11 +340,14 @@ func Get(symbol string) (*Data, error) {
 		CurrentMACD:       currentMACD,
 		CurrentRSI7:       currentRSI7,
 		OpenInterest:      oiData,
-		FundingRate:       fundingRate,
+		FundingRate:       funding.Rate,
+		NextFundingTime:   funding.NextTime,
+		FundingInterval:   funding.Interval,
 		IntradaySeries:    intradayData,
 		LongerTermContext: longerTermData,
 		AllTimeframes:     allTimeframes,
 		EnhancedIndicators: enhancedIndicators,
+		Quality:            quality,
 	}
 	
 	// 获取多空比数据（多时间周期）
@@ -307,7 +357,10 @@ func Get(symbol string) (*Data, error) {
 	} else {
 		data.LongShortRatios = longShortRatios
 	}
-	
+
+	// 爆仓统计（滚动窗口）
+	data.Liquidations = GetLiquidationStats(symbol)
+
 	// 计算市场情绪分析
 	if enhancedIndicators != nil {
 		data.MarketSentiment = AnalyzeMarketSentiment(data, enhancedIndicators)
@@ -317,9 +370,9 @@ func Get(symbol string) (*Data, error) {
 }
 
 // fetchTimeframeData 获取单个时间框架的完整数据
-func fetchTimeframeData(symbol string, setting KlineSettings) (*TimeframeData, error) {
+func fetchTimeframeData(provider Provider, symbol string, setting KlineSettings) (*TimeframeData, error) {
 	// 获取K线数据（多获取20根用于计算指标）
-	klines, err := getKlines(symbol, setting.Interval, setting.Limit+20)
+	klines, err := getProviderKlines(provider, symbol, setting.Interval, setting.Limit+20)
 	if err != nil {
 		return nil, err
 	}
@@ -327,7 +380,17 @@ func fetchTimeframeData(symbol string, setting KlineSettings) (*TimeframeData, e
 	if len(klines) == 0 {
 		return nil, fmt.Errorf("没有获取到K线数据")
 	}
-	
+	points := indicatorSeries(provider, symbol, setting.Interval, klines, time.Now())
+	return timeframeData(setting, klines, points, patternHistory(symbol, setting.Interval, klines)), nil
+}
+
+// buildTimeframeData 由K线计算单个时间框架的数据（history用于统计形态的历史命中率）
+func buildTimeframeData(setting KlineSettings, klines []Kline, history []Kline) *TimeframeData {
+	return timeframeData(setting, klines, computeIndicatorPoints(klines), history)
+}
+
+// timeframeData 由K线和逐根K线的指标值构建单个时间框架的数据
+func timeframeData(setting KlineSettings, klines []Kline, points []indicatorPoint, history []Kline) *TimeframeData {
 	tfData := &TimeframeData{
 		Interval:  setting.Interval,
 		Limit:     setting.Limit,
@@ -352,14 +415,15 @@ func fetchTimeframeData(symbol string, setting KlineSettings) (*TimeframeData, e
 		})
 	}
 	
-	// 计算技术指标
-	tfData.EMA20 = calculateEMA(klines, 20)
-	tfData.EMA50 = calculateEMA(klines, 50)
-	tfData.MACD = calculateMACD(klines)
-	tfData.RSI7 = calculateRSI(klines, 7)
-	tfData.RSI14 = calculateRSI(klines, 14)
-	tfData.ATR3 = calculateATR(klines, 3)
-	tfData.ATR14 = calculateATR(klines, 14)
+	// 技术指标取最后一根K线的值
+	latest := points[len(points)-1]
+	tfData.EMA20 = latest.EMA20
+	tfData.EMA50 = latest.EMA50
+	tfData.MACD = latest.MACD
+	tfData.RSI7 = latest.RSI7
+	tfData.RSI14 = latest.RSI14
+	tfData.ATR3 = latest.ATR3
+	tfData.ATR14 = latest.ATR14
 	
 	// 计算成交量
 	if len(klines) > 0 {
@@ -371,14 +435,15 @@ func fetchTimeframeData(symbol string, setting KlineSettings) (*TimeframeData, e
 		tfData.AverageVolume = sum / float64(len(klines))
 	}
 	
-	// K线形态识别
-	tfData.Patterns = identifyPatterns(klines)
+	// K线形态识别（附带该周期的历史胜率统计）
+	tfData.PatternSignals = DetectPatterns(klines, history)
+	tfData.Patterns = FormatPatternSignals(tfData.PatternSignals)
 	
-	return tfData, nil
+	return tfData
 }
 
-// getKlines 从Binance获取K线数据
-func getKlines(symbol, interval string, limit int) ([]Kline, error) {
+// fetchKlines 从Binance获取K线数据
+func fetchKlines(symbol, interval string, limit int) ([]Kline, error) {
 	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/klines?symbol=%s&interval=%s&limit=%d",
 		symbol, interval, limit)
 
@@ -538,6 +603,11 @@ func calculateATR(klines []Kline, period int) float64 {
 
 // calculateIntradaySeries 计算日内系列数据
 func calculateIntradaySeries(klines []Kline) *IntradayData {
+	return intradaySeries(klines, computeIndicatorPoints(klines))
+}
+
+// intradaySeries 由K线和逐根K线的指标值构建日内系列数据
+func intradaySeries(klines []Kline, points []indicatorPoint) *IntradayData {
 	data := &IntradayData{
 		MidPrices:   make([]float64, 0, 20),
 		EMA20Values: make([]float64, 0, 20),
@@ -585,26 +655,22 @@ func calculateIntradaySeries(klines []Kline) *IntradayData {
 			data.LowestPrice = klines[i].Low
 		}
 
-		// 计算每个点的EMA20
+		// 每个点的EMA20
 		if i >= 19 {
-			ema20 := calculateEMA(klines[:i+1], 20)
-			data.EMA20Values = append(data.EMA20Values, ema20)
+			data.EMA20Values = append(data.EMA20Values, points[i].EMA20)
 		}
 
-		// 计算每个点的MACD
+		// 每个点的MACD
 		if i >= 25 {
-			macd := calculateMACD(klines[:i+1])
-			data.MACDValues = append(data.MACDValues, macd)
+			data.MACDValues = append(data.MACDValues, points[i].MACD)
 		}
 
-		// 计算每个点的RSI
+		// 每个点的RSI
 		if i >= 7 {
-			rsi7 := calculateRSI(klines[:i+1], 7)
-			data.RSI7Values = append(data.RSI7Values, rsi7)
+			data.RSI7Values = append(data.RSI7Values, points[i].RSI7)
 		}
 		if i >= 14 {
-			rsi14 := calculateRSI(klines[:i+1], 14)
-			data.RSI14Values = append(data.RSI14Values, rsi14)
+			data.RSI14Values = append(data.RSI14Values, points[i].RSI14)
 		}
 	}
 	
@@ -612,13 +678,19 @@ func calculateIntradaySeries(klines []Kline) *IntradayData {
 	data.PriceRange = data.HighestPrice - data.LowestPrice
 	
 	// 识别K线形态
-	data.Patterns = identifyPatterns(klines[start:])
+	data.PatternSignals = DetectPatterns(klines, klines)
+	data.Patterns = FormatPatternSignals(data.PatternSignals)
 
 	return data
 }
 
 // calculateLongerTermData 计算长期数据
 func calculateLongerTermData(klines []Kline) *LongerTermData {
+	return longerTermSeries(klines, computeIndicatorPoints(klines))
+}
+
+// longerTermSeries 由K线和逐根K线的指标值构建长期数据
+func longerTermSeries(klines []Kline, points []indicatorPoint) *LongerTermData {
 	data := &LongerTermData{
 		Klines:      make([]KlinePoint, 0, len(klines)),
 		MACDValues:  make([]float64, 0, 10),
@@ -642,13 +714,14 @@ func calculateLongerTermData(klines []Kline) *LongerTermData {
 		})
 	}
 
-	// 计算EMA
-	data.EMA20 = calculateEMA(klines, 20)
-	data.EMA50 = calculateEMA(klines, 50)
-
-	// 计算ATR
-	data.ATR3 = calculateATR(klines, 3)
-	data.ATR14 = calculateATR(klines, 14)
+	// EMA和ATR取最后一根K线的值
+	if len(points) > 0 {
+		latest := points[len(points)-1]
+		data.EMA20 = latest.EMA20
+		data.EMA50 = latest.EMA50
+		data.ATR3 = latest.ATR3
+		data.ATR14 = latest.ATR14
+	}
 
 	// 计算成交量
 	if len(klines) > 0 {
@@ -669,64 +742,44 @@ func calculateLongerTermData(klines []Kline) *LongerTermData {
 
 	for i := start; i < len(klines); i++ {
 		if i >= 25 {
-			macd := calculateMACD(klines[:i+1])
-			data.MACDValues = append(data.MACDValues, macd)
+			data.MACDValues = append(data.MACDValues, points[i].MACD)
 		}
 		if i >= 14 {
-			rsi14 := calculateRSI(klines[:i+1], 14)
-			data.RSI14Values = append(data.RSI14Values, rsi14)
+			data.RSI14Values = append(data.RSI14Values, points[i].RSI14)
 		}
 	}
 
 	return data
 }
 
-// getOpenInterestData 获取OI数据
-func getOpenInterestData(symbol string) (*OIData, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/openInterest?symbol=%s", symbol)
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var result struct {
-		OpenInterest string `json:"openInterest"`
-		Symbol       string `json:"symbol"`
-		Time         int64  `json:"time"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
+// NextFundingPayment 下次结算时多单按名义价值支付的资金费（USDT，正数表示多单支付、空单收取）
+func (d *Data) NextFundingPayment(notionalUSD float64) float64 {
+	interval := d.FundingInterval
+	if interval <= 0 {
+		interval = 8 * time.Hour
 	}
-
-	oi, _ := strconv.ParseFloat(result.OpenInterest, 64)
-
-	return &OIData{
-		Latest:  oi,
-		Average: oi * 0.999, // 近似平均值
-	}, nil
+	return d.FundingRate * interval.Hours() / 8 * notionalUSD
 }
 
 // getFundingRate 获取资金费率
 func getFundingRate(symbol string) (float64, error) {
+	info, err := getFundingInfo(symbol)
+	return info.Rate, err
+}
+
+// getFundingInfo 获取资金费率和下次结算时间
+func getFundingInfo(symbol string) (FundingInfo, error) {
 	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/premiumIndex?symbol=%s", symbol)
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return 0, err
+		return FundingInfo{}, err
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return FundingInfo{}, err
 	}
 
 	var result struct {
@@ -740,11 +793,15 @@ func getFundingRate(symbol string) (float64, error) {
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, err
+		return FundingInfo{}, err
 	}
 
 	rate, _ := strconv.ParseFloat(result.LastFundingRate, 64)
-	return rate, nil
+	info := FundingInfo{Rate: rate, Interval: 8 * time.Hour}
+	if result.NextFundingTime > 0 {
+		info.NextTime = time.UnixMilli(result.NextFundingTime)
+	}
+	return info, nil
 }
 
 // Format 格式化输出市场数据
@@ -759,7 +816,13 @@ func FormatSimple(data *Data) string {
 
 // FormatCompact 格式化市场数据为紧凑格式（英文+压缩空格，保留所有数据）
 func FormatCompact(data *Data) string {
+	return FormatCompactIn(data, i18n.Default)
+}
+
+// FormatCompactIn 按prompt语言格式化紧凑市场数据（指标名固定为英文，数据降级提示和K线形态按语言输出）
+func FormatCompactIn(data *Data, lang i18n.Lang) string {
 	var sb strings.Builder
+	sb.WriteString(formatQualityWarning(data, lang))
 	
 	// 基础指标（英文，一行）
 	sb.WriteString(fmt.Sprintf("Price:%.2f EMA20:%.2f MACD:%.3f RSI7:%.1f",
@@ -773,8 +836,16 @@ func FormatCompact(data *Data) string {
 	if data.OpenInterest != nil {
 		sb.WriteString(fmt.Sprintf("OI:%.0fM(avg:%.0fM) ", 
 			data.OpenInterest.Latest/1000000, data.OpenInterest.Average/1000000))
+		if len(data.OpenInterest.Series) > 0 {
+			sb.WriteString(fmt.Sprintf("OIΔ1h:%+.2f%% 4h:%+.2f%% 24h:%+.2f%% ",
+				data.OpenInterest.Change1h, data.OpenInterest.Change4h, data.OpenInterest.Change24h))
+		}
 	}
 	sb.WriteString(fmt.Sprintf("FR:%.4f%%\n", data.FundingRate*100))
+	if data.OpenInterest != nil && len(data.OpenInterest.Series) > 0 {
+		sb.WriteString(fmt.Sprintf("OIValue(%s,M USDT):%s\n", data.OpenInterest.Period,
+			formatFloatSliceCompact(data.OpenInterest.RecentSeries(OIHistory.SeriesShown))))
+	}
 	
 	// 日内序列数据（压缩格式）
 	if data.IntradaySeries != nil {
@@ -818,7 +889,9 @@ func FormatCompact(data *Data) string {
 		}
 		
 		// K线形态
-		if len(data.IntradaySeries.Patterns) > 0 {
+		if len(data.IntradaySeries.PatternSignals) > 0 {
+			sb.WriteString(fmt.Sprintf(" Patterns:%s", strings.Join(FormatPatternSignalsIn(data.IntradaySeries.PatternSignals, lang), ",")))
+		} else if len(data.IntradaySeries.Patterns) > 0 {
 			sb.WriteString(fmt.Sprintf(" Patterns:%s", strings.Join(data.IntradaySeries.Patterns, ",")))
 		}
 		sb.WriteString("\n")
@@ -886,14 +959,18 @@ func FormatCompact(data *Data) string {
 	
 	// 市场情绪（压缩）
 	if data.MarketSentiment != nil {
-		sb.WriteString(fmt.Sprintf("Sentiment: FG:%d L/S:%.2f Vol:%s Mom:%s Overall:%s\n",
+		sb.WriteString(fmt.Sprintf("Sentiment: FG:%d L/S:%.2f Vol:%s Mom:%s Overall:%s Liq:%s\n",
 			data.MarketSentiment.FearGreedIndex,
 			data.MarketSentiment.BullBearRatio,
 			data.MarketSentiment.VolumeStrength,
 			data.MarketSentiment.MomentumSignal,
-			data.MarketSentiment.OverallSentiment))
+			data.MarketSentiment.OverallSentiment,
+			data.MarketSentiment.LiquidationBias))
 	}
-	
+
+	// 爆仓（压缩）
+	sb.WriteString(formatLiquidationLine(data.Liquidations))
+
 	// 多空比详细数据（压缩）
 	if data.LongShortRatios != nil && len(data.LongShortRatios) > 0 {
 		sb.WriteString("L/S_Ratios: ")
@@ -916,20 +993,10 @@ func FormatCompact(data *Data) string {
 	return sb.String()
 }
 
-// formatFloatSliceCompact 格式化浮点数数组为紧凑格式
+// formatFloatSliceCompact 格式化浮点数数组为紧凑格式（保留全部数据，紧凑模式改用 FormatSummaryIn 不输出序列）
 func formatFloatSliceCompact(values []float64) string {
-	maxValues := 8
-	if !CompactMode {
-		maxValues = len(values)
-	}
-	
-	startIdx := 0
-	if len(values) > maxValues {
-		startIdx = len(values) - maxValues
-	}
-	
 	var parts []string
-	for i := startIdx; i < len(values); i++ {
+	for i := 0; i < len(values); i++ {
 		parts = append(parts, fmt.Sprintf("%.2f", values[i]))
 	}
 	return "[" + strings.Join(parts, ",") + "]"
@@ -953,6 +1020,7 @@ func getTrendDirection(price, ema float64) string {
 // FormatWithKlineTable 格式化市场数据，可选是否包含K线表格
 func FormatWithKlineTable(data *Data, showKlineTable bool) string {
 	var sb strings.Builder
+	sb.WriteString(formatQualityWarning(data, i18n.Default))
 
 	sb.WriteString(fmt.Sprintf("current_price = %.2f, current_ema20 = %.3f, current_macd = %.3f, current_rsi (7 period) = %.3f\n\n",
 		data.CurrentPrice, data.CurrentEMA20, data.CurrentMACD, data.CurrentRSI7))
@@ -963,6 +1031,12 @@ func FormatWithKlineTable(data *Data, showKlineTable bool) string {
 	if data.OpenInterest != nil {
 		sb.WriteString(fmt.Sprintf("Open Interest: Latest: %.2f Average: %.2f\n\n",
 			data.OpenInterest.Latest, data.OpenInterest.Average))
+		if len(data.OpenInterest.Series) > 0 {
+			sb.WriteString(fmt.Sprintf("Open Interest Change: 1h: %+.2f%% 4h: %+.2f%% 24h: %+.2f%%\n\n",
+				data.OpenInterest.Change1h, data.OpenInterest.Change4h, data.OpenInterest.Change24h))
+			sb.WriteString(fmt.Sprintf("Open Interest Value (%s, million USDT): %s\n\n", data.OpenInterest.Period,
+				formatFloatSlice(data.OpenInterest.RecentSeries(OIHistory.SeriesShown))))
+		}
 	}
 
 	sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
@@ -1220,7 +1294,13 @@ func FormatWithKlineTable(data *Data, showKlineTable bool) string {
 		sb.WriteString(fmt.Sprintf("成交量强度: %s | 动量信号: %s\n", 
 			data.MarketSentiment.VolumeStrength, 
 			data.MarketSentiment.MomentumSignal))
-		sb.WriteString(fmt.Sprintf("整体情绪: %s\n\n", data.MarketSentiment.OverallSentiment))
+		sb.WriteString(fmt.Sprintf("整体情绪: %s | 爆仓方向(1h): %s\n\n", data.MarketSentiment.OverallSentiment, data.MarketSentiment.LiquidationBias))
+	}
+
+	// 爆仓统计
+	if line := formatLiquidationLine(data.Liquidations); line != "" {
+		sb.WriteString("**💥 爆仓统计**\n")
+		sb.WriteString(line + "\n")
 	}
 	
 	// 多空比详细数据（多时间周期）
@@ -1251,20 +1331,9 @@ func FormatWithKlineTable(data *Data, showKlineTable bool) string {
 
 // formatFloatSlice 格式化float64切片为字符串
 func formatFloatSlice(values []float64) string {
-	// 优化：紧凑模式只取最后8个值，减少token消耗
-	maxValues := 8
-	if !CompactMode {
-		maxValues = len(values) // 完整模式显示全部
-	}
-	
-	startIdx := 0
-	if len(values) > maxValues {
-		startIdx = len(values) - maxValues
-	}
-	
-	strValues := make([]string, len(values)-startIdx)
-	for i, idx := 0, startIdx; idx < len(values); i, idx = i+1, idx+1 {
-		strValues[i] = fmt.Sprintf("%.2f", values[idx])
+	strValues := make([]string, len(values))
+	for i, v := range values {
+		strValues[i] = fmt.Sprintf("%.2f", v)
 	}
 	return "[" + strings.Join(strValues, ", ") + "]"
 }
@@ -1276,63 +1345,6 @@ func formatTimestamp(timestamp int64) string {
 	return t.Format("15:04")
 }
 
-// identifyPatterns 识别K线形态
-func identifyPatterns(klines []Kline) []string {
-	patterns := []string{}
-	
-	if len(klines) < 3 {
-		return patterns
-	}
-	
-	last := klines[len(klines)-1]
-	prev := klines[len(klines)-2]
-	
-	// 锤子线（看涨）
-	if isHammer(last) {
-		patterns = append(patterns, "🔨 锤子线（看涨信号）")
-	}
-	
-	// 倒锤子（潜在反转）
-	if isInvertedHammer(last) {
-		patterns = append(patterns, "🔨 倒锤子（潜在反转）")
-	}
-	
-	// 看涨吞没
-	if isBullishEngulfing(prev, last) {
-		patterns = append(patterns, "📈 看涨吞没（强烈看涨）")
-	}
-	
-	// 看跌吞没
-	if isBearishEngulfing(prev, last) {
-		patterns = append(patterns, "📉 看跌吞没（强烈看跌）")
-	}
-	
-	// 十字星（犹豫）
-	if isDoji(last) {
-		patterns = append(patterns, "✨ 十字星（方向不明）")
-	}
-	
-	// 射击之星（看跌）
-	if isShootingStar(last) {
-		patterns = append(patterns, "💫 射击之星（看跌信号）")
-	}
-	
-	// 三连阳
-	if len(klines) >= 3 {
-		prev2 := klines[len(klines)-3]
-		if isThreeWhiteSoldiers(prev2, prev, last) {
-			patterns = append(patterns, "🚀 三连阳（强势上涨）")
-		}
-		
-		// 三连阴
-		if isThreeBlackCrows(prev2, prev, last) {
-			patterns = append(patterns, "💀 三连阴（强势下跌）")
-		}
-	}
-	
-	return patterns
-}
-
 // isHammer 判断是否为锤子线
 func isHammer(k Kline) bool {
 	body := math.Abs(k.Close - k.Open)
@@ -1456,15 +1468,6 @@ func isThreeBlackCrows(k1, k2, k3 Kline) bool {
 	return all3Red && descending && consistent
 }
 
-// Normalize 标准化symbol,确保是USDT交易对
-func Normalize(symbol string) string {
-	symbol = strings.ToUpper(symbol)
-	if strings.HasSuffix(symbol, "USDT") {
-		return symbol
-	}
-	return symbol + "USDT"
-}
-
 // getLongShortRatios 获取多时间周期多空比数据
 func getLongShortRatios(symbol string) (map[string]*LongShortRatioData, error) {
 	periods := []string{"5m", "15m", "1h", "4h"}
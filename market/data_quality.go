@@ -0,0 +1,173 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"nofx/i18n"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DataQualitySettings 行情数据质量检查配置（由trader在每个周期根据运行时配置更新）
+type DataQualitySettings struct {
+	OutlierSigma      float64 // 收益率偏离中位数超过N倍稳健标准差视为异常价格
+	MaxMissingCandles int     // 缺失/零成交K线超过该数量视为数据降级
+	StaleIntervals    int     // 最新K线落后超过N个周期视为数据过期
+	ExcludeDegraded   bool    // 数据降级的币种是否禁止新开仓
+}
+
+// QualitySettings 当前生效的数据质量检查配置
+var QualitySettings = DataQualitySettings{
+	OutlierSigma:      8.0,
+	MaxMissingCandles: 2,
+	StaleIntervals:    2,
+	ExcludeDegraded:   true,
+}
+
+// DataQuality 单个币种的行情数据质量
+type DataQuality struct {
+	Degraded       bool     `json:"degraded"`        // 是否降级（存在影响指标可信度的问题）
+	MissingCandles int      `json:"missing_candles"` // 缺失或零成交的K线数量
+	Outliers       int      `json:"outliers"`        // 异常跳变的K线数量
+	StaleSeconds   int64    `json:"stale_seconds"`   // 最新K线的落后时间（秒，超过阈值才记录）
+	Issues         []string `json:"issues"`          // 问题描述
+}
+
+// checkKlineQuality 检查一组K线的数据质量，问题追加到 q
+func checkKlineQuality(q *DataQuality, klines []Kline, interval string, now time.Time) {
+	if len(klines) == 0 {
+		q.Issues = append(q.Issues, fmt.Sprintf("%s 无K线数据", interval))
+		q.Degraded = true
+		return
+	}
+	step := int64(getIntervalMinutes(interval)) * 60 * 1000
+	settings := QualitySettings
+
+	// 1. 缺失K线（时间间隔跳空）和零成交K线（交易所补齐的空K线）
+	missing, zeroVolume := 0, 0
+	for i, k := range klines {
+		if i > 0 {
+			if gap := k.OpenTime - klines[i-1].OpenTime; gap > step {
+				missing += int(gap/step) - 1
+			}
+		}
+		// 最后一根K线可能刚开盘，不计入零成交
+		if k.Volume == 0 && i < len(klines)-1 {
+			zeroVolume++
+		}
+	}
+	if missing > 0 {
+		q.Issues = append(q.Issues, fmt.Sprintf("%s 缺失%d根K线", interval, missing))
+	}
+	if zeroVolume > 0 {
+		q.Issues = append(q.Issues, fmt.Sprintf("%s 有%d根零成交K线", interval, zeroVolume))
+	}
+	q.MissingCandles += missing + zeroVolume
+	if missing+zeroVolume > settings.MaxMissingCandles {
+		q.Degraded = true
+	}
+
+	// 2. 异常价格跳变（收益率相对中位数的偏离超过N倍MAD稳健标准差）
+	if outliers := countPriceOutliers(klines, settings.OutlierSigma); outliers > 0 {
+		q.Outliers += outliers
+		q.Issues = append(q.Issues, fmt.Sprintf("%s 有%d根K线价格异常跳变(>%.0fσ)", interval, outliers, settings.OutlierSigma))
+		q.Degraded = true
+	}
+
+	// 3. 数据过期（最新K线开盘时间落后当前时间超过N个周期）
+	if settings.StaleIntervals > 0 {
+		lag := now.UnixMilli() - klines[len(klines)-1].OpenTime
+		if lag > step*int64(settings.StaleIntervals) {
+			q.StaleSeconds = lag / 1000
+			q.Issues = append(q.Issues, fmt.Sprintf("%s 最新K线已落后%s", interval, time.Duration(lag)*time.Millisecond))
+			q.Degraded = true
+		}
+	}
+}
+
+// countPriceOutliers 统计异常跳变的K线数量
+// 真实的大行情会延续，API故障导致的异常价格通常是单根K线跳变后立即回归，因此只统计：
+// 1. 收盘价跳变超过N倍稳健标准差，且下一根K线反向回吐一半以上
+// 2. 最高/最低价偏离前收盘价超过2N倍稳健标准差，而收盘价正常（极端插针）
+func countPriceOutliers(klines []Kline, sigma float64) int {
+	if sigma <= 0 || len(klines) < 10 {
+		return 0
+	}
+
+	returns := make([]float64, len(klines))
+	valid := make([]float64, 0, len(klines)-1)
+	for i := 1; i < len(klines); i++ {
+		if klines[i-1].Close > 0 && klines[i].Close > 0 {
+			returns[i] = math.Log(klines[i].Close / klines[i-1].Close)
+			valid = append(valid, returns[i])
+		}
+	}
+	median, mad := medianAndMAD(valid)
+	robustSigma := 1.4826 * mad
+	if robustSigma <= 0 {
+		return 0
+	}
+	limit := sigma * robustSigma
+
+	outliers := 0
+	for i := 1; i < len(klines); i++ {
+		k, prev := klines[i], klines[i-1]
+		if prev.Close <= 0 || k.Close <= 0 || k.Low <= 0 {
+			outliers++
+			continue
+		}
+		r := returns[i]
+		if math.Abs(r-median) > limit && i+1 < len(klines) {
+			next := returns[i+1]
+			if next*r < 0 && math.Abs(next) > 0.5*math.Abs(r) {
+				outliers++
+				continue
+			}
+		}
+		wick := math.Max(math.Log(k.High/prev.Close), -math.Log(k.Low/prev.Close))
+		if math.Abs(r-median) <= limit && wick > 2*limit {
+			outliers++
+		}
+	}
+	return outliers
+}
+
+// medianAndMAD 计算中位数和中位数绝对偏差
+func medianAndMAD(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	deviations := make([]float64, len(sorted))
+	for i, v := range sorted {
+		deviations[i] = math.Abs(v - median)
+	}
+	sort.Float64s(deviations)
+	return median, deviations[len(deviations)/2]
+}
+
+// assessDataQuality 检查短期和长期K线的数据质量
+func assessDataQuality(shortKlines []Kline, shortInterval string, longKlines []Kline, longInterval string) *DataQuality {
+	q := &DataQuality{}
+	now := time.Now()
+	checkKlineQuality(q, shortKlines, shortInterval, now)
+	checkKlineQuality(q, longKlines, longInterval, now)
+	return q
+}
+
+// IsDegraded 数据是否降级
+func (d *Data) IsDegraded() bool {
+	return d != nil && d.Quality != nil && d.Quality.Degraded
+}
+
+// formatQualityWarning 数据降级时在Prompt中的提示
+func formatQualityWarning(data *Data, lang i18n.Lang) string {
+	if !data.IsDegraded() {
+		return ""
+	}
+	return i18n.T(lang, "market.degraded", strings.Join(data.Quality.Issues, "; "))
+}
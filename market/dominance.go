@@ -0,0 +1,72 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BTCDominanceCacheTTL BTC市值占比缓存时间（CoinGecko免费接口有频率限制）
+const BTCDominanceCacheTTL = 10 * time.Minute
+
+var (
+	dominanceMu        sync.Mutex
+	dominanceCache     float64
+	dominanceFetchedAt time.Time
+)
+
+// GetBTCDominance 获取BTC市值占比(%)（带缓存，获取失败时返回上次的缓存）
+func GetBTCDominance() (float64, error) {
+	dominanceMu.Lock()
+	defer dominanceMu.Unlock()
+
+	if dominanceCache > 0 && time.Since(dominanceFetchedAt) < BTCDominanceCacheTTL {
+		return dominanceCache, nil
+	}
+
+	dominance, err := fetchBTCDominance()
+	if err != nil {
+		if dominanceCache > 0 {
+			return dominanceCache, nil
+		}
+		return 0, err
+	}
+	dominanceCache = dominance
+	dominanceFetchedAt = time.Now()
+	return dominance, nil
+}
+
+// fetchBTCDominance 从CoinGecko全局数据获取BTC市值占比
+func fetchBTCDominance() (float64, error) {
+	resp, err := http.Get("https://api.coingecko.com/api/v3/global")
+	if err != nil {
+		return 0, fmt.Errorf("获取全局市场数据失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取全局市场数据失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("获取全局市场数据失败: HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			MarketCapPercentage map[string]float64 `json:"market_cap_percentage"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("解析全局市场数据失败: %w", err)
+	}
+
+	dominance, ok := result.Data.MarketCapPercentage["btc"]
+	if !ok || dominance <= 0 {
+		return 0, fmt.Errorf("全局市场数据中没有BTC市值占比")
+	}
+	return dominance, nil
+}
@@ -85,11 +85,13 @@ type PivotPoints struct {
 
 // MarketSentiment 市场情绪分析
 type MarketSentiment struct {
-	FearGreedIndex    int     // 0-100, 恐慌贪婪指数
-	BullBearRatio     float64 // 多空比例
-	VolumeStrength    string  // "strong", "weak", "normal"
-	MomentumSignal    string  // "bullish", "bearish", "neutral"
-	OverallSentiment  string  // "extreme_fear", "fear", "neutral", "greed", "extreme_greed"
+	FearGreedIndex     int     // 0-100, 恐慌贪婪指数
+	BullBearRatio      float64 // 多空比例
+	VolumeStrength     string  // "strong", "weak", "normal"
+	MomentumSignal     string  // "bullish", "bearish", "neutral"
+	OverallSentiment   string  // "extreme_fear", "fear", "neutral", "greed", "extreme_greed"
+	LiquidationBias    string  // "longs_flushed", "shorts_squeezed", "mixed", "none"（近1小时爆仓方向）
+	LiquidationCascade bool    // 是否正在连环爆仓
 }
 
 // CalculateEnhancedIndicators 计算增强技术指标
@@ -453,6 +455,18 @@ func AnalyzeMarketSentiment(data *Data, indicators *EnhancedIndicators) *MarketS
 	// 计算恐慌贪婪指数 (简化版)
 	sentiment.FearGreedIndex = calculateFearGreedIndex(data, indicators)
 	
+	// 爆仓方向（多头连环爆仓加剧恐慌，空头被挤压推升贪婪）
+	sentiment.LiquidationBias = analyzeLiquidationBias(data.Liquidations)
+	if data.Liquidations != nil && data.Liquidations.Cascade {
+		sentiment.LiquidationCascade = true
+		switch sentiment.LiquidationBias {
+		case "longs_flushed":
+			sentiment.FearGreedIndex = clampSentimentScore(sentiment.FearGreedIndex - 15)
+		case "shorts_squeezed":
+			sentiment.FearGreedIndex = clampSentimentScore(sentiment.FearGreedIndex + 15)
+		}
+	}
+	
 	// 计算多空比（使用1小时数据，更能反映当前市场情绪）
 	sentiment.BullBearRatio = calculateBullBearRatio(data)
 	
@@ -600,6 +614,31 @@ func analyzeMomentumSignal(data *Data, indicators *EnhancedIndicators) string {
 	}
 }
 
+// analyzeLiquidationBias 分析近1小时爆仓方向（一侧爆仓金额超过另一侧2倍视为单边）
+func analyzeLiquidationBias(stats *LiquidationStats) string {
+	if stats == nil || stats.Count1h == 0 {
+		return "none"
+	}
+	if stats.Long1h > 2*stats.Short1h {
+		return "longs_flushed"
+	}
+	if stats.Short1h > 2*stats.Long1h {
+		return "shorts_squeezed"
+	}
+	return "mixed"
+}
+
+// clampSentimentScore 限制恐慌贪婪指数在0-100范围内
+func clampSentimentScore(score int) int {
+	if score > 100 {
+		return 100
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
 // assessOverallSentiment 评估整体情绪
 func assessOverallSentiment(fearGreedIndex int) string {
 	if fearGreedIndex >= 80 {
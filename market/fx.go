@@ -0,0 +1,99 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexPriceTTL 指数价格缓存时间（报告币种折算不需要实时价格）
+const indexPriceTTL = time.Minute
+
+// USDReferenceAsset 折算USD使用的参考资产：指数价格以USDT计价，USD与USDT按1:1处理
+const USDReferenceAsset = "USDT"
+
+type cachedIndexPrice struct {
+	price     float64
+	fetchedAt time.Time
+}
+
+var (
+	indexPriceMu    sync.Mutex
+	indexPriceCache = make(map[string]cachedIndexPrice)
+)
+
+// GetIndexPrice 获取币安U本位合约的指数价格（缓存1分钟；刷新失败时返回上次的价格）
+func GetIndexPrice(symbol string) (float64, error) {
+	indexPriceMu.Lock()
+	cached, ok := indexPriceCache[symbol]
+	indexPriceMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < indexPriceTTL {
+		return cached.price, nil
+	}
+
+	price, err := fetchIndexPrice(symbol)
+	if err != nil {
+		if ok {
+			return cached.price, nil
+		}
+		return 0, err
+	}
+	indexPriceMu.Lock()
+	indexPriceCache[symbol] = cachedIndexPrice{price: price, fetchedAt: time.Now()}
+	indexPriceMu.Unlock()
+	return price, nil
+}
+
+// fetchIndexPrice 从 premiumIndex 接口获取指数价格
+func fetchIndexPrice(symbol string) (float64, error) {
+	resp, err := providerHTTPClient.Get(fmt.Sprintf("https://fapi.binance.com/fapi/v1/premiumIndex?symbol=%s", symbol))
+	if err != nil {
+		return 0, fmt.Errorf("获取%s指数价格失败: %w", symbol, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取%s指数价格失败: %w", symbol, err)
+	}
+	var result struct {
+		IndexPrice string `json:"indexPrice"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("解析%s指数价格失败: %w", symbol, err)
+	}
+	price, err := strconv.ParseFloat(result.IndexPrice, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("%s指数价格无效: %s", symbol, result.IndexPrice)
+	}
+	return price, nil
+}
+
+// referenceValue 1单位资产的参考资产(USDT)价值
+func referenceValue(asset string) (float64, error) {
+	asset = strings.ToUpper(asset)
+	if asset == USDReferenceAsset || asset == "USD" {
+		return 1, nil
+	}
+	return GetIndexPrice(asset + USDReferenceAsset)
+}
+
+// ConversionRate 资产折算汇率：1单位from资产 = rate单位to资产（按指数价格经USDT中转）
+func ConversionRate(from, to string) (float64, error) {
+	if strings.EqualFold(from, to) {
+		return 1, nil
+	}
+	fromValue, err := referenceValue(from)
+	if err != nil {
+		return 0, err
+	}
+	toValue, err := referenceValue(to)
+	if err != nil {
+		return 0, err
+	}
+	return fromValue / toValue, nil
+}
@@ -0,0 +1,339 @@
+package market
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IndicatorCacheSettings 增量指标计算配置（由trader在每个周期根据运行时配置更新）
+type IndicatorCacheSettings struct {
+	Incremental bool          // 按 (数据源, 币种, K线周期) 保存指标状态，每个周期只折叠新收盘的K线
+	StateTTL    time.Duration // 超过该时长未使用的指标状态会被清理（币种移出候选池后释放内存）
+}
+
+// IndicatorCache 当前生效的增量指标配置
+var IndicatorCache = IndicatorCacheSettings{
+	Incremental: true,
+	StateTTL:    6 * time.Hour,
+}
+
+// indicatorPoint 单根K线收盘时的指标值（数据不足时为0，与 calculateEMA 等函数一致）
+type indicatorPoint struct {
+	EMA20 float64
+	EMA50 float64
+	MACD  float64
+	RSI7  float64
+	RSI14 float64
+	ATR3  float64
+	ATR14 float64
+}
+
+// emaAcc EMA累加器：前period根收盘价的SMA作为初始值，之后逐根平滑
+type emaAcc struct {
+	period int
+	n      int
+	sum    float64
+	value  float64
+}
+
+func (a *emaAcc) add(close float64) {
+	a.n++
+	if a.n <= a.period {
+		a.sum += close
+		if a.n == a.period {
+			a.value = a.sum / float64(a.period)
+		}
+		return
+	}
+	multiplier := 2.0 / float64(a.period+1)
+	a.value = (close-a.value)*multiplier + a.value
+}
+
+func (a *emaAcc) current() float64 {
+	if a.n < a.period {
+		return 0
+	}
+	return a.value
+}
+
+// rsiAcc RSI累加器（Wilder平滑）
+type rsiAcc struct {
+	period    int
+	n         int // 已累计的涨跌次数
+	prevClose float64
+	started   bool
+	avgGain   float64
+	avgLoss   float64
+}
+
+func (a *rsiAcc) add(close float64) {
+	if !a.started {
+		a.prevClose, a.started = close, true
+		return
+	}
+	change := close - a.prevClose
+	a.prevClose = close
+	a.n++
+
+	gain, loss := 0.0, 0.0
+	if change > 0 {
+		gain = change
+	} else {
+		loss = -change
+	}
+	p := float64(a.period)
+	if a.n <= a.period {
+		a.avgGain += gain
+		a.avgLoss += loss
+		if a.n == a.period {
+			a.avgGain /= p
+			a.avgLoss /= p
+		}
+		return
+	}
+	a.avgGain = (a.avgGain*(p-1) + gain) / p
+	a.avgLoss = (a.avgLoss*(p-1) + loss) / p
+}
+
+func (a *rsiAcc) current() float64 {
+	if a.n < a.period {
+		return 0
+	}
+	if a.avgLoss == 0 {
+		return 100
+	}
+	rs := a.avgGain / a.avgLoss
+	return 100 - (100 / (1 + rs))
+}
+
+// atrAcc ATR累加器（Wilder平滑）
+type atrAcc struct {
+	period    int
+	n         int // 已累计的真实波幅个数
+	prevClose float64
+	started   bool
+	value     float64
+}
+
+func (a *atrAcc) add(k Kline) {
+	if !a.started {
+		a.prevClose, a.started = k.Close, true
+		return
+	}
+	tr := math.Max(k.High-k.Low, math.Max(math.Abs(k.High-a.prevClose), math.Abs(k.Low-a.prevClose)))
+	a.prevClose = k.Close
+	a.n++
+
+	p := float64(a.period)
+	if a.n <= a.period {
+		a.value += tr
+		if a.n == a.period {
+			a.value /= p
+		}
+		return
+	}
+	a.value = (a.value*(p-1) + tr) / p
+}
+
+func (a *atrAcc) current() float64 {
+	if a.n < a.period {
+		return 0
+	}
+	return a.value
+}
+
+// indicatorAccs 一个K线序列的全部指标累加器（值类型，复制后可试算未收盘K线而不影响原状态）
+type indicatorAccs struct {
+	ema12, ema20, ema26, ema50 emaAcc
+	rsi7, rsi14                rsiAcc
+	atr3, atr14                atrAcc
+	n                          int
+}
+
+func newIndicatorAccs() indicatorAccs {
+	return indicatorAccs{
+		ema12: emaAcc{period: 12},
+		ema20: emaAcc{period: 20},
+		ema26: emaAcc{period: 26},
+		ema50: emaAcc{period: 50},
+		rsi7:  rsiAcc{period: 7},
+		rsi14: rsiAcc{period: 14},
+		atr3:  atrAcc{period: 3},
+		atr14: atrAcc{period: 14},
+	}
+}
+
+func (a *indicatorAccs) add(k Kline) {
+	a.n++
+	a.ema12.add(k.Close)
+	a.ema20.add(k.Close)
+	a.ema26.add(k.Close)
+	a.ema50.add(k.Close)
+	a.rsi7.add(k.Close)
+	a.rsi14.add(k.Close)
+	a.atr3.add(k)
+	a.atr14.add(k)
+}
+
+func (a *indicatorAccs) point() indicatorPoint {
+	p := indicatorPoint{
+		EMA20: a.ema20.current(),
+		EMA50: a.ema50.current(),
+		RSI7:  a.rsi7.current(),
+		RSI14: a.rsi14.current(),
+		ATR3:  a.atr3.current(),
+		ATR14: a.atr14.current(),
+	}
+	if a.n >= 26 {
+		p.MACD = a.ema12.current() - a.ema26.current()
+	}
+	return p
+}
+
+// computeIndicatorPoints 从头计算K线序列每根K线的指标值（与逐根调用 calculateEMA 等函数的结果一致）
+func computeIndicatorPoints(klines []Kline) []indicatorPoint {
+	accs := newIndicatorAccs()
+	points := make([]indicatorPoint, len(klines))
+	for i, k := range klines {
+		accs.add(k)
+		points[i] = accs.point()
+	}
+	return points
+}
+
+// indicatorState 一个 (数据源, 币种, K线周期) 的增量指标状态
+type indicatorState struct {
+	accs      indicatorAccs    // 折叠到最后一根已收盘K线的累加器
+	openTimes []int64          // 已收盘K线的开盘时间（按时间正序）
+	points    []indicatorPoint // 与openTimes对齐的指标值
+	keep      int              // 保留的历史指标个数（取各次请求K线数量的最大值）
+	lastUsed  time.Time
+}
+
+// lookup 已收盘K线的指标值
+func (s *indicatorState) lookup(openTime int64) (indicatorPoint, bool) {
+	i := sort.Search(len(s.openTimes), func(i int) bool { return s.openTimes[i] >= openTime })
+	if i < len(s.openTimes) && s.openTimes[i] == openTime {
+		return s.points[i], true
+	}
+	return indicatorPoint{}, false
+}
+
+// fold 折叠一根新收盘的K线
+func (s *indicatorState) fold(k Kline) {
+	s.accs.add(k)
+	s.openTimes = append(s.openTimes, k.OpenTime)
+	s.points = append(s.points, s.accs.point())
+}
+
+// trim 只保留最近keep根K线的指标值
+func (s *indicatorState) trim() {
+	if extra := len(s.openTimes) - s.keep; extra > 0 {
+		s.openTimes = append([]int64(nil), s.openTimes[extra:]...)
+		s.points = append([]indicatorPoint(nil), s.points[extra:]...)
+	}
+}
+
+// indicatorStateCache 全部增量指标状态
+type indicatorStateCache struct {
+	mu        sync.Mutex
+	states    map[string]*indicatorState
+	lastSweep time.Time
+}
+
+var indicatorStates = &indicatorStateCache{states: make(map[string]*indicatorState)}
+
+// indicatorSeries 计算K线序列每根K线的指标值
+// 启用增量计算时，已收盘的K线沿用上一次的指标状态，只折叠新收盘的K线，未收盘的K线在状态副本上试算；
+// 状态无法覆盖本次K线（首次请求、K线不连续、请求了更早的K线）时按本次K线从头计算并重建状态
+func indicatorSeries(provider Provider, symbol, interval string, klines []Kline, now time.Time) []indicatorPoint {
+	settings := IndicatorCache
+	if !settings.Incremental || provider == nil {
+		return computeIndicatorPoints(klines)
+	}
+
+	// 已收盘的K线数量（未收盘的K线只会出现在末尾）
+	// 最后一根K线总是试算不折叠：缓存或本地K线可能是收盘前拉取的，收盘后的最终值要等下次拉取
+	nowMs := now.UnixMilli()
+	closed := len(klines) - 1
+	for closed > 0 && klines[closed-1].CloseTime >= nowMs {
+		closed--
+	}
+	if closed <= 0 {
+		return computeIndicatorPoints(klines)
+	}
+
+	c := indicatorStates
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep(now, settings.StateTTL)
+
+	key := provider.Name() + ":" + symbol + ":" + interval
+	state := c.states[key]
+
+	if state == nil || !state.covers(klines[:closed]) {
+		state = &indicatorState{accs: newIndicatorAccs()}
+		for _, k := range klines[:closed] {
+			state.fold(k)
+		}
+		c.states[key] = state
+	}
+	if len(klines) > state.keep {
+		state.keep = len(klines)
+	}
+	state.lastUsed = now
+
+	points := make([]indicatorPoint, len(klines))
+	for i, k := range klines[:closed] {
+		p, ok := state.lookup(k.OpenTime)
+		if !ok {
+			// covers 已保证能找到，这里只做兜底
+			return computeIndicatorPoints(klines)
+		}
+		points[i] = p
+	}
+	if closed < len(klines) {
+		preview := state.accs
+		for i := closed; i < len(klines); i++ {
+			preview.add(klines[i])
+			points[i] = preview.point()
+		}
+	}
+	state.trim()
+	return points
+}
+
+// covers 状态能否覆盖这些已收盘K线：状态包含第一根K线，且之后新收盘的K线与状态末尾相连，覆盖时折叠新收盘的K线
+func (s *indicatorState) covers(closed []Kline) bool {
+	if len(s.openTimes) == 0 || closed[0].OpenTime < s.openTimes[0] {
+		return false
+	}
+
+	last := s.openTimes[len(s.openTimes)-1]
+	next := sort.Search(len(closed), func(i int) bool { return closed[i].OpenTime > last })
+	if next == 0 {
+		return false // 本次K线全部晚于状态末尾，中间有缺口
+	}
+	if closed[next-1].OpenTime != last {
+		return false // 状态末尾的K线不在本次数据中（K线不连续）
+	}
+	for _, k := range closed[next:] {
+		s.fold(k)
+	}
+	return true
+}
+
+// sweep 清理长时间未使用的指标状态（调用方持有锁）
+func (c *indicatorStateCache) sweep(now time.Time, ttl time.Duration) {
+	if ttl <= 0 || now.Sub(c.lastSweep) < time.Minute {
+		return
+	}
+	c.lastSweep = now
+	for key, state := range c.states {
+		if now.Sub(state.lastUsed) > ttl {
+			delete(c.states, key)
+		}
+	}
+}
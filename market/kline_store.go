@@ -0,0 +1,117 @@
+package market
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// KlineStore K线本地持久化存储（按 symbol + interval + open_time 去重）
+type KlineStore interface {
+	// SaveKlines 写入K线（已存在的K线覆盖更新，未收盘的K线会随拉取不断刷新）
+	SaveKlines(symbol, interval string, klines []Kline) error
+	// LoadKlines 读取最近limit根K线（按时间正序）及最近一次写入时间
+	LoadKlines(symbol, interval string, limit int) ([]Kline, time.Time, error)
+	// LoadKlineRange 读取时间范围内的K线（按开盘时间正序，用于回测/回放）
+	LoadKlineRange(symbol, interval string, from, to time.Time) ([]Kline, error)
+}
+
+// KlineStorageSettings K线持久化配置（由trader在每个周期根据运行时配置更新）
+type KlineStorageSettings struct {
+	ServeFromDisk bool          // 当前周期的K线已落盘且足够新时直接从本地读取，不再请求交易所
+	MaxAge        time.Duration // 本地K线的最大复用时长（同时不超过一个K线周期）
+}
+
+// KlineStorage 当前生效的K线持久化配置
+var KlineStorage = KlineStorageSettings{
+	ServeFromDisk: true,
+	MaxAge:        60 * time.Second,
+}
+
+var (
+	klineStoreMu sync.RWMutex
+	klineStore   KlineStore
+)
+
+// SetKlineStore 设置K线持久化存储（nil表示关闭，由main在启动时调用）
+func SetKlineStore(store KlineStore) {
+	klineStoreMu.Lock()
+	defer klineStoreMu.Unlock()
+	klineStore = store
+}
+
+// getKlineStore 获取当前K线存储（未启用时返回nil）
+func getKlineStore() KlineStore {
+	klineStoreMu.RLock()
+	defer klineStoreMu.RUnlock()
+	return klineStore
+}
+
+// LoadStoredKlines 从本地存储读取历史K线（离线回测/回放使用，不访问网络）
+func LoadStoredKlines(symbol, interval string, from, to time.Time) ([]Kline, error) {
+	store := getKlineStore()
+	if store == nil {
+		return nil, fmt.Errorf("K线持久化未启用（kline_store_enabled=false）")
+	}
+	klines, err := store.LoadKlineRange(symbol, interval, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("读取本地K线失败: %w", err)
+	}
+	return klines, nil
+}
+
+// getProviderKlines 从指定数据源获取K线数据（启用持久化时优先复用本地的当前周期K线，拉取结果写入本地）
+// 本地K线库只保存默认的Binance行情，其他数据源只使用共享缓存
+func getProviderKlines(p Provider, symbol, interval string, limit int) ([]Kline, error) {
+	if _, ok := p.(*compositeProvider); ok {
+		return p.Klines(symbol, interval, limit) // 组合数据源由各子数据源分别缓存
+	}
+	store := getKlineStore()
+	if store == nil || p.Name() != ProviderBinance {
+		return fetchSharedKlines(p, symbol, interval, limit)
+	}
+
+	if cached, ok := loadFreshKlines(store, symbol, interval, limit, time.Now()); ok {
+		return cached, nil
+	}
+
+	klines, err := fetchSharedKlines(p, symbol, interval, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.SaveKlines(symbol, interval, klines); err != nil {
+		log.Printf("⚠️ 保存K线失败 %s %s: %v", symbol, interval, err)
+	}
+	return klines, nil
+}
+
+// loadFreshKlines 本地已有当前周期的K线且写入时间在复用窗口内时返回本地数据
+func loadFreshKlines(store KlineStore, symbol, interval string, limit int, now time.Time) ([]Kline, bool) {
+	settings := KlineStorage
+	if !settings.ServeFromDisk || settings.MaxAge <= 0 {
+		return nil, false
+	}
+
+	period := time.Duration(getIntervalMinutes(interval)) * time.Minute
+	maxAge := settings.MaxAge
+	if period < maxAge {
+		maxAge = period
+	}
+
+	cached, updatedAt, err := store.LoadKlines(symbol, interval, limit)
+	if err != nil {
+		log.Printf("⚠️ 读取本地K线失败 %s %s: %v", symbol, interval, err)
+		return nil, false
+	}
+	if len(cached) < limit || now.Sub(updatedAt) > maxAge {
+		return nil, false
+	}
+
+	// 最新一根必须是当前周期的K线，否则本地数据已落后
+	currentOpen := now.Truncate(period).UnixMilli()
+	if cached[len(cached)-1].OpenTime != currentOpen {
+		return nil, false
+	}
+	return cached, true
+}
@@ -0,0 +1,257 @@
+package market
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// LiquidationSettings 爆仓监控配置（由trader在每个周期根据运行时配置更新）
+type LiquidationSettings struct {
+	CascadeUSD       float64 // 全市场5分钟爆仓金额超过该值视为连环爆仓
+	SymbolCascadeUSD float64 // 单币种5分钟爆仓金额超过该值视为该币种连环爆仓
+	PauseEntries     bool    // 连环爆仓期间是否暂停新开仓
+}
+
+// LiquidationCascade 当前生效的爆仓监控配置
+var LiquidationCascade = LiquidationSettings{
+	CascadeUSD:       20000000,
+	SymbolCascadeUSD: 2000000,
+	PauseEntries:     true,
+}
+
+// 爆仓数据流参数
+const (
+	liquidationWindow         = time.Hour       // 保留的爆仓事件时长
+	liquidationCascadeWindow  = 5 * time.Minute // 连环爆仓判断窗口
+	liquidationReconnectDelay = 5 * time.Second // 断线重连间隔
+	liquidationTopSymbols     = 5               // 汇总中展示的爆仓最多币种数
+)
+
+// liquidationEvent 单笔强平订单
+type liquidationEvent struct {
+	at       time.Time
+	long     bool    // true=多头被强平（强平单为SELL），false=空头被强平（强平单为BUY）
+	notional float64 // 成交金额（USDT）
+}
+
+// LiquidationStats 单个币种的爆仓统计
+type LiquidationStats struct {
+	Symbol  string  `json:"symbol"`
+	Long5m  float64 `json:"long_5m"`  // 5分钟多头爆仓金额(USDT)
+	Short5m float64 `json:"short_5m"` // 5分钟空头爆仓金额(USDT)
+	Long1h  float64 `json:"long_1h"`  // 1小时多头爆仓金额(USDT)
+	Short1h float64 `json:"short_1h"` // 1小时空头爆仓金额(USDT)
+	Count1h int     `json:"count_1h"` // 1小时强平订单数
+	Cascade bool    `json:"cascade"`  // 是否正在连环爆仓
+}
+
+// Total5m 5分钟爆仓总金额
+func (s *LiquidationStats) Total5m() float64 {
+	return s.Long5m + s.Short5m
+}
+
+// Total1h 1小时爆仓总金额
+func (s *LiquidationStats) Total1h() float64 {
+	return s.Long1h + s.Short1h
+}
+
+// LiquidationSummary 全市场爆仓汇总
+type LiquidationSummary struct {
+	Connected       bool               `json:"connected"`        // 数据流是否在线
+	CoverageMinutes int                `json:"coverage_minutes"` // 已覆盖的统计时长（启动不足1小时时窗口不完整）
+	Long5m          float64            `json:"long_5m"`
+	Short5m         float64            `json:"short_5m"`
+	Long1h          float64            `json:"long_1h"`
+	Short1h         float64            `json:"short_1h"`
+	Cascade         bool               `json:"cascade"`         // 全市场或任一币种正在连环爆仓
+	CascadeSymbols  []LiquidationStats `json:"cascade_symbols"` // 正在连环爆仓的币种
+	TopSymbols      []LiquidationStats `json:"top_symbols"`     // 1小时爆仓金额最多的币种
+}
+
+// liquidationFeed 全市场强平订单数据流（Binance !forceOrder@arr）
+type liquidationFeed struct {
+	mu        sync.RWMutex
+	events    map[string][]liquidationEvent
+	startedAt time.Time
+	connected bool
+}
+
+var (
+	liqFeed     = &liquidationFeed{events: make(map[string][]liquidationEvent)}
+	liqFeedOnce sync.Once
+)
+
+// StartLiquidationFeed 启动全市场强平订单订阅（只会启动一次，断线自动重连）
+func StartLiquidationFeed() {
+	liqFeedOnce.Do(func() {
+		liqFeed.mu.Lock()
+		liqFeed.startedAt = time.Now()
+		liqFeed.mu.Unlock()
+		go liqFeed.run()
+	})
+}
+
+// run 订阅循环
+func (f *liquidationFeed) run() {
+	for {
+		doneC, _, err := futures.WsAllLiquidationOrderServe(f.handle, func(err error) {
+			log.Printf("⚠️  爆仓数据流错误: %v", err)
+		})
+		if err != nil {
+			log.Printf("⚠️  订阅爆仓数据流失败: %v，%v后重试", err, liquidationReconnectDelay)
+			time.Sleep(liquidationReconnectDelay)
+			continue
+		}
+		f.setConnected(true)
+		log.Printf("💥 爆仓数据流已连接")
+		<-doneC
+		f.setConnected(false)
+		log.Printf("⚠️  爆仓数据流断开，%v后重连", liquidationReconnectDelay)
+		time.Sleep(liquidationReconnectDelay)
+	}
+}
+
+func (f *liquidationFeed) setConnected(connected bool) {
+	f.mu.Lock()
+	f.connected = connected
+	f.mu.Unlock()
+}
+
+// handle 处理一笔强平订单
+func (f *liquidationFeed) handle(event *futures.WsLiquidationOrderEvent) {
+	order := event.LiquidationOrder
+	price, _ := strconv.ParseFloat(order.AvgPrice, 64)
+	qty, _ := strconv.ParseFloat(order.AccumulatedFilledQty, 64)
+	if price <= 0 || qty <= 0 {
+		price, _ = strconv.ParseFloat(order.Price, 64)
+		qty, _ = strconv.ParseFloat(order.OrigQuantity, 64)
+	}
+	if price <= 0 || qty <= 0 {
+		return
+	}
+
+	at := time.UnixMilli(order.TradeTime)
+	if order.TradeTime == 0 {
+		at = time.Now()
+	}
+	ev := liquidationEvent{
+		at:       at,
+		long:     order.Side == futures.SideTypeSell,
+		notional: price * qty,
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cutoff := time.Now().Add(-liquidationWindow)
+	events := append(f.events[order.Symbol], ev)
+	for len(events) > 0 && events[0].at.Before(cutoff) {
+		events = events[1:]
+	}
+	f.events[order.Symbol] = events
+}
+
+// statsLocked 计算单个币种的爆仓统计（调用方持有读锁）
+func (f *liquidationFeed) statsLocked(symbol string, now time.Time) LiquidationStats {
+	stats := LiquidationStats{Symbol: symbol}
+	hourAgo := now.Add(-liquidationWindow)
+	cascadeFrom := now.Add(-liquidationCascadeWindow)
+	for _, ev := range f.events[symbol] {
+		if ev.at.Before(hourAgo) {
+			continue
+		}
+		stats.Count1h++
+		if ev.long {
+			stats.Long1h += ev.notional
+		} else {
+			stats.Short1h += ev.notional
+		}
+		if !ev.at.Before(cascadeFrom) {
+			if ev.long {
+				stats.Long5m += ev.notional
+			} else {
+				stats.Short5m += ev.notional
+			}
+		}
+	}
+	threshold := LiquidationCascade.SymbolCascadeUSD
+	stats.Cascade = threshold > 0 && stats.Total5m() >= threshold
+	return stats
+}
+
+// GetLiquidationStats 获取单个币种的爆仓统计（数据流未启动时返回nil）
+func GetLiquidationStats(symbol string) *LiquidationStats {
+	liqFeed.mu.RLock()
+	defer liqFeed.mu.RUnlock()
+	if liqFeed.startedAt.IsZero() {
+		return nil
+	}
+	stats := liqFeed.statsLocked(symbol, time.Now())
+	return &stats
+}
+
+// GetLiquidationSummary 获取全市场爆仓汇总（数据流未启动时返回nil）
+func GetLiquidationSummary() *LiquidationSummary {
+	liqFeed.mu.RLock()
+	defer liqFeed.mu.RUnlock()
+	if liqFeed.startedAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	summary := &LiquidationSummary{Connected: liqFeed.connected}
+	summary.CoverageMinutes = int(now.Sub(liqFeed.startedAt).Minutes())
+	if summary.CoverageMinutes > int(liquidationWindow.Minutes()) {
+		summary.CoverageMinutes = int(liquidationWindow.Minutes())
+	}
+
+	var all []LiquidationStats
+	for symbol := range liqFeed.events {
+		stats := liqFeed.statsLocked(symbol, now)
+		if stats.Count1h == 0 {
+			continue
+		}
+		summary.Long5m += stats.Long5m
+		summary.Short5m += stats.Short5m
+		summary.Long1h += stats.Long1h
+		summary.Short1h += stats.Short1h
+		if stats.Cascade {
+			summary.CascadeSymbols = append(summary.CascadeSymbols, stats)
+		}
+		all = append(all, stats)
+	}
+
+	sort.Slice(summary.CascadeSymbols, func(i, j int) bool {
+		return summary.CascadeSymbols[i].Total5m() > summary.CascadeSymbols[j].Total5m()
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Total1h() > all[j].Total1h()
+	})
+	if len(all) > liquidationTopSymbols {
+		all = all[:liquidationTopSymbols]
+	}
+	summary.TopSymbols = all
+
+	threshold := LiquidationCascade.CascadeUSD
+	summary.Cascade = len(summary.CascadeSymbols) > 0 ||
+		(threshold > 0 && summary.Long5m+summary.Short5m >= threshold)
+	return summary
+}
+
+// formatLiquidationLine 单个币种爆仓统计的Prompt内容（无爆仓时为空）
+func formatLiquidationLine(stats *LiquidationStats) string {
+	if stats == nil || stats.Count1h == 0 {
+		return ""
+	}
+	line := fmt.Sprintf("Liquidations: 1h long %.2fM / short %.2fM (%d orders) | 5m long %.2fM / short %.2fM",
+		stats.Long1h/1e6, stats.Short1h/1e6, stats.Count1h, stats.Long5m/1e6, stats.Short5m/1e6)
+	if stats.Cascade {
+		line += " ⚠️ CASCADE"
+	}
+	return line + "\n"
+}
@@ -0,0 +1,128 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 流动性数据参数
+const (
+	LiquidityCacheTTL     = time.Minute // 24h成交额和盘口价差的刷新间隔
+	liquiditySpreadWindow = 30          // 平均价差使用最近N次刷新的价差样本（约30分钟）
+)
+
+// Liquidity 交易对的流动性指标（Binance合约全市场ticker）
+type Liquidity struct {
+	QuoteVolume24h float64   `json:"quote_volume_24h"` // 24小时成交额(计价币种)
+	SpreadBps      float64   `json:"spread_bps"`       // 最新买一卖一价差(基点)
+	AvgSpreadBps   float64   `json:"avg_spread_bps"`   // 最近几次刷新的平均价差(基点)
+	SpreadSamples  int       `json:"spread_samples"`   // 平均价差的样本数
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// liquidityCache 全市场流动性缓存
+type liquidityCache struct {
+	mu        sync.Mutex
+	volumes   map[string]float64
+	spreads   map[string][]float64 // 价差样本（从旧到新）
+	fetchedAt time.Time
+}
+
+var liquidity = &liquidityCache{
+	volumes: make(map[string]float64),
+	spreads: make(map[string][]float64),
+}
+
+// GetLiquidity 获取交易对的24h成交额和平均价差（缓存过期时刷新，刷新失败时返回上次的数据）
+func GetLiquidity(symbol string) (*Liquidity, bool) {
+	liquidity.mu.Lock()
+	defer liquidity.mu.Unlock()
+
+	if time.Since(liquidity.fetchedAt) > LiquidityCacheTTL {
+		if err := liquidity.refreshLocked(); err != nil {
+			log.Printf("⚠️  刷新流动性数据失败: %v", err)
+		}
+	}
+
+	volume, ok := liquidity.volumes[symbol]
+	samples := liquidity.spreads[symbol]
+	if !ok || len(samples) == 0 {
+		return nil, false
+	}
+	l := &Liquidity{
+		QuoteVolume24h: volume,
+		SpreadBps:      samples[len(samples)-1],
+		SpreadSamples:  len(samples),
+		UpdatedAt:      liquidity.fetchedAt,
+	}
+	for _, s := range samples {
+		l.AvgSpreadBps += s
+	}
+	l.AvgSpreadBps /= float64(len(samples))
+	return l, true
+}
+
+// refreshLocked 拉取全市场24h成交额和盘口价差（调用方需持有锁）
+func (c *liquidityCache) refreshLocked() error {
+	// 无论成功与否都推进刷新时间，避免接口异常时每个币种都重试一次
+	c.fetchedAt = time.Now()
+
+	var tickers []struct {
+		Symbol      string `json:"symbol"`
+		QuoteVolume string `json:"quoteVolume"`
+	}
+	if err := getBinanceJSON("https://fapi.binance.com/fapi/v1/ticker/24hr", &tickers); err != nil {
+		return fmt.Errorf("获取24h行情失败: %w", err)
+	}
+	var books []struct {
+		Symbol   string `json:"symbol"`
+		BidPrice string `json:"bidPrice"`
+		AskPrice string `json:"askPrice"`
+	}
+	if err := getBinanceJSON("https://fapi.binance.com/fapi/v1/ticker/bookTicker", &books); err != nil {
+		return fmt.Errorf("获取盘口价格失败: %w", err)
+	}
+
+	for _, t := range tickers {
+		volume, _ := strconv.ParseFloat(t.QuoteVolume, 64)
+		c.volumes[t.Symbol] = volume
+	}
+	for _, b := range books {
+		bid, _ := strconv.ParseFloat(b.BidPrice, 64)
+		ask, _ := strconv.ParseFloat(b.AskPrice, 64)
+		if bid <= 0 || ask < bid {
+			continue
+		}
+		spread := (ask - bid) / ((ask + bid) / 2) * 10000
+		samples := append(c.spreads[b.Symbol], spread)
+		if len(samples) > liquiditySpreadWindow {
+			samples = samples[len(samples)-liquiditySpreadWindow:]
+		}
+		c.spreads[b.Symbol] = samples
+	}
+	return nil
+}
+
+// getBinanceJSON GET请求并解析JSON响应
+func getBinanceJSON(url string, out interface{}) error {
+	resp, err := providerHTTPClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,103 @@
+package market
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// 标记价格数据流参数
+const (
+	markPriceReconnectDelay = 5 * time.Second  // 断线重连间隔
+	markPriceMaxAge         = 15 * time.Second // 超过该时长未更新的标记价格视为过期，调用方回退到REST数据
+)
+
+// MarkPrice 数据流推送的标记价格
+type MarkPrice struct {
+	Price       float64   `json:"price"`
+	IndexPrice  float64   `json:"index_price"`
+	FundingRate float64   `json:"funding_rate"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// markPriceFeed 全市场标记价格数据流（Binance !markPrice@arr@1s）
+type markPriceFeed struct {
+	mu        sync.RWMutex
+	prices    map[string]MarkPrice
+	connected bool
+}
+
+var (
+	markFeed     = &markPriceFeed{prices: make(map[string]MarkPrice)}
+	markFeedOnce sync.Once
+)
+
+// StartMarkPriceFeed 启动全市场标记价格订阅（每秒推送，只会启动一次，断线自动重连）
+func StartMarkPriceFeed() {
+	markFeedOnce.Do(func() {
+		go markFeed.run()
+	})
+}
+
+// run 订阅循环
+func (f *markPriceFeed) run() {
+	for {
+		doneC, _, err := futures.WsAllMarkPriceServeWithRate(time.Second, f.handle, func(err error) {
+			log.Printf("⚠️  标记价格数据流错误: %v", err)
+		})
+		if err != nil {
+			log.Printf("⚠️  订阅标记价格数据流失败: %v，%v后重试", err, markPriceReconnectDelay)
+			time.Sleep(markPriceReconnectDelay)
+			continue
+		}
+		f.setConnected(true)
+		log.Printf("📡 标记价格数据流已连接")
+		<-doneC
+		f.setConnected(false)
+		log.Printf("⚠️  标记价格数据流断开，%v后重连", markPriceReconnectDelay)
+		time.Sleep(markPriceReconnectDelay)
+	}
+}
+
+func (f *markPriceFeed) setConnected(connected bool) {
+	f.mu.Lock()
+	f.connected = connected
+	f.mu.Unlock()
+}
+
+// handle 处理一批标记价格推送
+func (f *markPriceFeed) handle(event futures.WsAllMarkPriceEvent) {
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range event {
+		price, _ := strconv.ParseFloat(e.MarkPrice, 64)
+		if price <= 0 {
+			continue
+		}
+		mp := MarkPrice{Price: price, UpdatedAt: now}
+		mp.IndexPrice, _ = strconv.ParseFloat(e.IndexPrice, 64)
+		mp.FundingRate, _ = strconv.ParseFloat(e.FundingRate, 64)
+		if e.Time > 0 {
+			mp.UpdatedAt = time.UnixMilli(e.Time)
+		}
+		f.prices[e.Symbol] = mp
+	}
+}
+
+// GetMarkPrice 获取数据流中的最新标记价格（数据流断开、没有该币种或价格已过期时返回false）
+func GetMarkPrice(symbol string) (MarkPrice, bool) {
+	markFeed.mu.RLock()
+	defer markFeed.mu.RUnlock()
+	if !markFeed.connected {
+		return MarkPrice{}, false
+	}
+	mp, ok := markFeed.prices[symbol]
+	if !ok || time.Since(mp.UpdatedAt) > markPriceMaxAge {
+		return MarkPrice{}, false
+	}
+	return mp, true
+}
@@ -0,0 +1,376 @@
+package market
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 新闻来源
+const (
+	NewsSourceRSS         = "rss"         // 通用RSS/Atom新闻源（按标题中的币种代码/名称匹配）
+	NewsSourceCryptoPanic = "cryptopanic" // CryptoPanic API（按接口返回的币种标签匹配）
+)
+
+// cryptoPanicDefaultURL CryptoPanic新闻接口默认地址
+const cryptoPanicDefaultURL = "https://cryptopanic.com/api/v1/posts/"
+
+// NewsSettings 候选币种新闻配置（由trader在每个周期根据运行时配置更新）
+type NewsSettings struct {
+	Enabled      bool
+	Source       string        // rss / cryptopanic
+	URLs         []string      // RSS地址（source=rss）；source=cryptopanic时为接口地址（为空使用默认地址）
+	APIKey       string        // CryptoPanic auth_token
+	MaxHeadlines int           // 每个币种最多附带的新闻数
+	MaxAge       time.Duration // 只附带该时长内发布的新闻
+	CacheTTL     time.Duration // 新闻缓存时间（新闻源通常有频率限制）
+}
+
+// News 当前生效的新闻配置
+var News = NewsSettings{
+	Source:       NewsSourceRSS,
+	MaxHeadlines: 3,
+	MaxAge:       24 * time.Hour,
+	CacheTTL:     15 * time.Minute,
+}
+
+// Headline 一条新闻标题
+type Headline struct {
+	Title       string    `json:"title"`
+	Source      string    `json:"source"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// newsAliases 常见币种在新闻标题中的名称（标题中多用全称而不是代码）
+var newsAliases = map[string][]string{
+	"BTC":  {"Bitcoin"},
+	"ETH":  {"Ethereum", "Ether"},
+	"SOL":  {"Solana"},
+	"XRP":  {"Ripple"},
+	"BNB":  {"Binance Coin", "BNB Chain"},
+	"DOGE": {"Dogecoin"},
+	"ADA":  {"Cardano"},
+	"AVAX": {"Avalanche"},
+	"LINK": {"Chainlink"},
+	"DOT":  {"Polkadot"},
+	"TRX":  {"Tron"},
+	"LTC":  {"Litecoin"},
+	"SUI":  {"Sui"},
+	"ARB":  {"Arbitrum"},
+	"OP":   {"Optimism"},
+	"NEAR": {"NEAR Protocol"},
+	"APT":  {"Aptos"},
+	"TON":  {"Toncoin"},
+	"HYPE": {"Hyperliquid"},
+}
+
+// cachedHeadlines 缓存的新闻（RSS按源地址缓存，API按币种缓存）
+type cachedHeadlines struct {
+	items     []Headline
+	fetchedAt time.Time
+}
+
+var (
+	newsMu         sync.Mutex
+	newsFeedCache  = make(map[string]cachedHeadlines)
+	newsCoinCache  = make(map[string]cachedHeadlines)
+	newsTagPattern = regexp.MustCompile(`<[^>]*>`)
+)
+
+// GetHeadlines 获取候选币种的近期新闻（未启用时返回nil；新闻源获取失败时使用上次的缓存，没有新闻的币种不出现在结果中）
+func GetHeadlines(symbols []string) map[string][]Headline {
+	settings := News
+	if !settings.Enabled || len(symbols) == 0 {
+		return nil
+	}
+
+	var byCoin map[string][]Headline
+	switch settings.Source {
+	case NewsSourceCryptoPanic:
+		byCoin = cryptoPanicHeadlines(settings, symbols)
+	default:
+		byCoin = rssHeadlines(settings, symbols)
+	}
+
+	cutoff := time.Now().Add(-settings.MaxAge)
+	result := make(map[string][]Headline)
+	for _, symbol := range symbols {
+		var recent []Headline
+		for _, h := range byCoin[newsBaseAsset(symbol)] {
+			if settings.MaxAge > 0 && h.PublishedAt.Before(cutoff) {
+				continue
+			}
+			recent = append(recent, h)
+		}
+		sort.SliceStable(recent, func(i, j int) bool { return recent[i].PublishedAt.After(recent[j].PublishedAt) })
+		if settings.MaxHeadlines > 0 && len(recent) > settings.MaxHeadlines {
+			recent = recent[:settings.MaxHeadlines]
+		}
+		if len(recent) > 0 {
+			result[symbol] = recent
+		}
+	}
+	return result
+}
+
+// newsBaseAsset 新闻匹配使用的币种代码（BTCUSDT -> BTC，1000PEPEUSDT -> PEPE）
+func newsBaseAsset(symbol string) string {
+	base := hyperliquidCoin(symbol)
+	if reg := loadedSymbolRegistry(); reg != nil {
+		if s, ok := reg.bySymbol[symbol]; ok && s.BaseAsset != "" {
+			base = s.BaseAsset
+		}
+	}
+	for _, prefix := range []string{"1000000", "1000"} {
+		if strings.HasPrefix(base, prefix) && len(base) > len(prefix) {
+			return strings.TrimPrefix(base, prefix)
+		}
+	}
+	return base
+}
+
+// rssHeadlines 拉取所有RSS源（按源缓存），按标题中的币种代码或名称匹配到币种
+func rssHeadlines(settings NewsSettings, symbols []string) map[string][]Headline {
+	var items []Headline
+	for _, feedURL := range settings.URLs {
+		newsMu.Lock()
+		cached, ok := newsFeedCache[feedURL]
+		newsMu.Unlock()
+		if !ok || time.Since(cached.fetchedAt) >= settings.CacheTTL {
+			fetched, err := fetchRSS(feedURL)
+			if err != nil {
+				log.Printf("⚠️  获取新闻源失败（使用缓存）: %v", err)
+			} else {
+				cached = cachedHeadlines{items: fetched, fetchedAt: time.Now()}
+				newsMu.Lock()
+				newsFeedCache[feedURL] = cached
+				newsMu.Unlock()
+			}
+		}
+		items = append(items, cached.items...)
+	}
+
+	byCoin := make(map[string][]Headline)
+	for _, symbol := range symbols {
+		base := newsBaseAsset(symbol)
+		if _, done := byCoin[base]; done {
+			continue
+		}
+		pattern := newsMatchPattern(base)
+		matched := []Headline{}
+		for _, h := range items {
+			if pattern.MatchString(h.Title) {
+				matched = append(matched, h)
+			}
+		}
+		byCoin[base] = matched
+	}
+	return byCoin
+}
+
+// newsMatchPattern 标题匹配规则：币种代码区分大小写整词匹配（避免 "OP" 匹配 "open"），名称不区分大小写
+func newsMatchPattern(base string) *regexp.Regexp {
+	alternatives := []string{`\b` + regexp.QuoteMeta(base) + `\b`}
+	for _, alias := range newsAliases[base] {
+		alternatives = append(alternatives, `(?i:\b`+regexp.QuoteMeta(alias)+`\b)`)
+	}
+	return regexp.MustCompile(strings.Join(alternatives, "|"))
+}
+
+// fetchRSS 拉取并解析RSS 2.0或Atom新闻源
+func fetchRSS(feedURL string) ([]Headline, error) {
+	resp, err := providerHTTPClient.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求 %s 失败: %w", feedURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", feedURL, err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("请求 %s 失败: HTTP %d", feedURL, resp.StatusCode)
+	}
+	return parseFeed(body, feedURL)
+}
+
+// parseFeed 解析RSS 2.0（channel/item）或Atom（feed/entry）
+func parseFeed(body []byte, feedURL string) ([]Headline, error) {
+	var feed struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title   string `xml:"title"`
+				Link    string `xml:"link"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+		Title   string `xml:"title"`
+		Entries []struct {
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Link      struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("解析新闻源 %s 失败: %w", feedURL, err)
+	}
+
+	source := feed.Channel.Title
+	if source == "" {
+		source = feed.Title
+	}
+	if source == "" {
+		if u, err := url.Parse(feedURL); err == nil {
+			source = u.Host
+		}
+	}
+
+	var headlines []Headline
+	for _, item := range feed.Channel.Items {
+		if published, ok := parseNewsTime(item.PubDate); ok {
+			headlines = append(headlines, Headline{Title: cleanHeadline(item.Title), Source: source, URL: strings.TrimSpace(item.Link), PublishedAt: published})
+		}
+	}
+	for _, entry := range feed.Entries {
+		published, ok := parseNewsTime(entry.Published)
+		if !ok {
+			published, ok = parseNewsTime(entry.Updated)
+		}
+		if ok {
+			headlines = append(headlines, Headline{Title: cleanHeadline(entry.Title), Source: source, URL: entry.Link.Href, PublishedAt: published})
+		}
+	}
+	return headlines, nil
+}
+
+// parseNewsTime 解析新闻源中的发布时间（没有发布时间的新闻无法判断是否过期，直接丢弃）
+func parseNewsTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// cleanHeadline 去掉标题中的HTML标签和多余空白
+func cleanHeadline(title string) string {
+	return strings.Join(strings.Fields(newsTagPattern.ReplaceAllString(title, "")), " ")
+}
+
+// cryptoPanicHeadlines 按币种请求CryptoPanic新闻（只请求缓存过期的币种，一次请求多个币种）
+func cryptoPanicHeadlines(settings NewsSettings, symbols []string) map[string][]Headline {
+	byCoin := make(map[string][]Headline)
+	var missing []string
+	newsMu.Lock()
+	for _, symbol := range symbols {
+		base := newsBaseAsset(symbol)
+		cached, ok := newsCoinCache[base]
+		if ok {
+			byCoin[base] = cached.items
+		}
+		if !ok || time.Since(cached.fetchedAt) >= settings.CacheTTL {
+			missing = append(missing, base)
+		}
+	}
+	newsMu.Unlock()
+	if len(missing) == 0 {
+		return byCoin
+	}
+
+	fetched, err := fetchCryptoPanic(settings, missing)
+	if err != nil {
+		log.Printf("⚠️  获取CryptoPanic新闻失败（使用缓存）: %v", err)
+		return byCoin
+	}
+	now := time.Now()
+	newsMu.Lock()
+	for _, base := range missing {
+		items := fetched[base]
+		newsCoinCache[base] = cachedHeadlines{items: items, fetchedAt: now}
+		byCoin[base] = items
+	}
+	newsMu.Unlock()
+	return byCoin
+}
+
+// fetchCryptoPanic 请求CryptoPanic新闻接口，按新闻的币种标签分组
+func fetchCryptoPanic(settings NewsSettings, bases []string) (map[string][]Headline, error) {
+	if settings.APIKey == "" {
+		return nil, fmt.Errorf("未配置 news_api_key")
+	}
+	endpoint := cryptoPanicDefaultURL
+	if len(settings.URLs) > 0 && settings.URLs[0] != "" {
+		endpoint = settings.URLs[0]
+	}
+	query := url.Values{}
+	query.Set("auth_token", settings.APIKey)
+	query.Set("currencies", strings.Join(bases, ","))
+	query.Set("kind", "news")
+	query.Set("public", "true")
+
+	resp, err := providerHTTPClient.Get(endpoint + "?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("请求新闻接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取新闻接口失败: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("请求新闻接口失败: HTTP %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			PublishedAt string `json:"published_at"`
+			Source      struct {
+				Title string `json:"title"`
+			} `json:"source"`
+			Currencies []struct {
+				Code string `json:"code"`
+			} `json:"currencies"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析新闻接口失败: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(bases))
+	for _, base := range bases {
+		wanted[base] = true
+	}
+	byCoin := make(map[string][]Headline)
+	for _, post := range result.Results {
+		published, ok := parseNewsTime(post.PublishedAt)
+		if !ok {
+			continue
+		}
+		h := Headline{Title: cleanHeadline(post.Title), Source: post.Source.Title, URL: post.URL, PublishedAt: published}
+		for _, c := range post.Currencies {
+			if code := strings.ToUpper(c.Code); wanted[code] {
+				byCoin[code] = append(byCoin[code], h)
+			}
+		}
+	}
+	return byCoin, nil
+}
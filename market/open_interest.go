@@ -0,0 +1,176 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OIHistorySettings 持仓量历史配置（由trader在每个周期根据运行时配置更新）
+type OIHistorySettings struct {
+	Period      string // openInterestHist 周期（5m/15m/30m/1h/2h/4h）
+	SeriesShown int    // Prompt中展示的序列长度
+}
+
+// OIHistory 当前生效的持仓量历史配置
+var OIHistory = OIHistorySettings{
+	Period:      "1h",
+	SeriesShown: 12,
+}
+
+// oiHistoryMaxLimit openInterestHist 单次最多返回的数据点
+const oiHistoryMaxLimit = 500
+
+// oiHistoryPeriods openInterestHist 支持的周期
+var oiHistoryPeriods = map[string]bool{
+	"5m": true, "15m": true, "30m": true, "1h": true, "2h": true, "4h": true,
+}
+
+// OIPoint 持仓量历史数据点
+type OIPoint struct {
+	Timestamp    int64   // 时间戳（毫秒）
+	OpenInterest float64 // 持仓量（币）
+	Value        float64 // 持仓价值（USDT）
+}
+
+// getOpenInterestData 获取OI数据（实时持仓量 + 覆盖24小时的历史序列）
+func getOpenInterestData(symbol string) (*OIData, error) {
+	latest, err := getOpenInterest(symbol)
+	if err != nil {
+		return nil, err
+	}
+	oiData := &OIData{Latest: latest, Average: latest}
+
+	settings := OIHistory
+	if !oiHistoryPeriods[settings.Period] {
+		settings.Period = "1h"
+	}
+	periodMinutes := getIntervalMinutes(settings.Period)
+	limit := 24*60/periodMinutes + 1
+	if limit > oiHistoryMaxLimit {
+		limit = oiHistoryMaxLimit
+	}
+	series, err := getOpenInterestHist(symbol, settings.Period, limit)
+	if err != nil || len(series) == 0 {
+		// 历史获取失败不影响实时持仓量
+		return oiData, nil
+	}
+
+	oiData.Period = settings.Period
+	oiData.Series = series
+	sum := 0.0
+	for _, p := range series {
+		sum += p.OpenInterest
+	}
+	oiData.Average = sum / float64(len(series))
+
+	now := time.Now()
+	oiData.Change1h = oiChangeSince(series, latest, now.Add(-time.Hour), periodMinutes)
+	oiData.Change4h = oiChangeSince(series, latest, now.Add(-4*time.Hour), periodMinutes)
+	oiData.Change24h = oiChangeSince(series, latest, now.Add(-24*time.Hour), periodMinutes)
+	return oiData, nil
+}
+
+// oiChangeSince 计算当前持仓量相对指定时间点的变化百分比
+// 取不晚于该时间点的最近一个数据点，没有或偏差超过一个周期时返回0
+func oiChangeSince(series []OIPoint, latest float64, since time.Time, periodMinutes int) float64 {
+	target := since.UnixMilli()
+	tolerance := int64(periodMinutes) * 60 * 1000
+	for i := len(series) - 1; i >= 0; i-- {
+		p := series[i]
+		if p.Timestamp > target {
+			continue
+		}
+		if target-p.Timestamp > tolerance || p.OpenInterest <= 0 {
+			return 0
+		}
+		return (latest/p.OpenInterest - 1) * 100
+	}
+	return 0
+}
+
+// getOpenInterest 获取实时持仓量
+func getOpenInterest(symbol string) (float64, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/openInterest?symbol=%s", symbol)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		OpenInterest string `json:"openInterest"`
+		Symbol       string `json:"symbol"`
+		Time         int64  `json:"time"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, err
+	}
+
+	oi, _ := strconv.ParseFloat(result.OpenInterest, 64)
+	return oi, nil
+}
+
+// getOpenInterestHist 获取持仓量历史（Binance只保留最近30天）
+func getOpenInterestHist(symbol, period string, limit int) ([]OIPoint, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/futures/data/openInterestHist?symbol=%s&period=%s&limit=%d",
+		symbol, period, limit)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取持仓量历史失败: HTTP %d %s", resp.StatusCode, string(body))
+	}
+
+	var rawData []struct {
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+		Timestamp            int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &rawData); err != nil {
+		return nil, err
+	}
+
+	series := make([]OIPoint, 0, len(rawData))
+	for _, item := range rawData {
+		oi, _ := strconv.ParseFloat(item.SumOpenInterest, 64)
+		value, _ := strconv.ParseFloat(item.SumOpenInterestValue, 64)
+		series = append(series, OIPoint{
+			Timestamp:    item.Timestamp,
+			OpenInterest: oi,
+			Value:        value,
+		})
+	}
+	return series, nil
+}
+
+// RecentSeries 最近N个数据点的持仓价值（百万USDT）
+func (o *OIData) RecentSeries(n int) []float64 {
+	start := len(o.Series) - n
+	if start < 0 {
+		start = 0
+	}
+	values := make([]float64, 0, len(o.Series)-start)
+	for _, p := range o.Series[start:] {
+		values = append(values, p.Value/1000000)
+	}
+	return values
+}
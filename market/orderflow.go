@@ -0,0 +1,376 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// OrderFlowSettings 订单流（盘口微观结构）配置（由trader在每个周期根据运行时配置更新）
+type OrderFlowSettings struct {
+	Enabled      bool    // 是否订阅持仓币种的深度增量和逐笔成交
+	ProximityPct float64 // 持仓当前价距止损/止盈不超过该百分比时，在Prompt中提供订单流信号
+}
+
+// OrderFlow 当前生效的订单流配置
+var OrderFlow = OrderFlowSettings{
+	Enabled:      false,
+	ProximityPct: 1.0,
+}
+
+// 订单流参数
+const (
+	orderFlowWindow         = 5 * time.Minute  // 保留的订单流时长
+	orderFlowShortWindow    = time.Minute      // 短周期窗口
+	orderFlowBandPct        = 0.5              // 只统计距当前价0.5%以内的挂单变化
+	orderFlowSnapshotLimit  = 1000             // 深度快照档位数
+	orderFlowIdleTimeout    = 15 * time.Minute // 超过该时长未被请求的币种自动退订
+	orderFlowReconnectDelay = 5 * time.Second  // 断线重连间隔
+)
+
+// flowBucket 1秒内的订单流汇总
+type flowBucket struct {
+	sec          int64
+	bidDelta     float64 // 买盘挂单净变化(USDT)
+	askDelta     float64 // 卖盘挂单净变化(USDT)
+	absDelta     float64 // 挂单变化绝对值之和(USDT)
+	buyNotional  float64 // 主动买入成交额(USDT)
+	sellNotional float64 // 主动卖出成交额(USDT)
+}
+
+// OrderFlowStats 单个币种的短周期订单流信号
+type OrderFlowStats struct {
+	Symbol          string  `json:"symbol"`
+	Connected       bool    `json:"connected"`        // 深度数据流是否在线且已与快照同步
+	CoverageSeconds int     `json:"coverage_seconds"` // 已覆盖的统计时长
+	BookImbalance   float64 `json:"book_imbalance"`   // 当前盘口失衡 (买-卖)/(买+卖)，范围-1~1，基于当前价±0.5%挂单
+	OFI1m           float64 `json:"ofi_1m"`           // 1分钟订单流失衡（挂单增减），范围-1~1，正值=买方加码
+	OFI5m           float64 `json:"ofi_5m"`           // 5分钟订单流失衡
+	AggBuyRatio1m   float64 `json:"agg_buy_ratio_1m"` // 1分钟主动买入占比(%)
+	AggBuyRatio5m   float64 `json:"agg_buy_ratio_5m"` // 5分钟主动买入占比(%)
+	TradeNotional1m float64 `json:"trade_notional_1m"`
+	TradeNotional5m float64 `json:"trade_notional_5m"`
+}
+
+// orderFlowStream 单个币种的订单簿和订单流
+type orderFlowStream struct {
+	symbol string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu           sync.Mutex
+	bids         map[float64]float64
+	asks         map[float64]float64
+	lastUpdateID int64
+	synced       bool
+	firstApplied bool
+	lastPrice    float64
+	buckets      []flowBucket
+	startedAt    time.Time
+	lastWatched  time.Time
+}
+
+var (
+	orderFlowMu      sync.Mutex
+	orderFlowStreams = make(map[string]*orderFlowStream)
+)
+
+// WatchOrderFlow 确保订阅这些币种的订单流（多个trader共享订阅，长时间未请求的币种自动退订）
+func WatchOrderFlow(symbols []string) {
+	orderFlowMu.Lock()
+	defer orderFlowMu.Unlock()
+
+	if !OrderFlow.Enabled {
+		symbols = nil // 关闭后退订全部币种
+	}
+	now := time.Now()
+	for _, symbol := range symbols {
+		if s, ok := orderFlowStreams[symbol]; ok {
+			s.mu.Lock()
+			s.lastWatched = now
+			s.mu.Unlock()
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s := &orderFlowStream{
+			symbol:      symbol,
+			ctx:         ctx,
+			cancel:      cancel,
+			startedAt:   now,
+			lastWatched: now,
+		}
+		orderFlowStreams[symbol] = s
+		go s.runDepth()
+		go s.runTrades()
+		log.Printf("📶 订阅 %s 订单流（深度增量 + 逐笔成交）", symbol)
+	}
+
+	for symbol, s := range orderFlowStreams {
+		s.mu.Lock()
+		idle := now.Sub(s.lastWatched) > orderFlowIdleTimeout
+		s.mu.Unlock()
+		if idle || !OrderFlow.Enabled {
+			s.cancel()
+			delete(orderFlowStreams, symbol)
+			log.Printf("📴 退订 %s 订单流", symbol)
+		}
+	}
+}
+
+// GetOrderFlowStats 获取单个币种的订单流信号（未订阅时返回nil）
+func GetOrderFlowStats(symbol string) *OrderFlowStats {
+	orderFlowMu.Lock()
+	s, ok := orderFlowStreams[symbol]
+	orderFlowMu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.stats(time.Now())
+}
+
+// serve 订阅循环（断线自动重连，退订后退出）
+func (s *orderFlowStream) serve(name string, subscribe func() (chan struct{}, chan struct{}, error), onDisconnect func()) {
+	for s.ctx.Err() == nil {
+		doneC, stopC, err := subscribe()
+		if err != nil {
+			log.Printf("⚠️  订阅 %s %s失败: %v，%v后重试", s.symbol, name, err, orderFlowReconnectDelay)
+		} else {
+			select {
+			case <-doneC:
+				log.Printf("⚠️  %s %s断开，%v后重连", s.symbol, name, orderFlowReconnectDelay)
+			case <-s.ctx.Done():
+				close(stopC)
+				<-doneC
+			}
+			onDisconnect()
+		}
+		select {
+		case <-time.After(orderFlowReconnectDelay):
+		case <-s.ctx.Done():
+		}
+	}
+}
+
+// runDepth 订阅深度增量
+func (s *orderFlowStream) runDepth() {
+	s.serve("深度数据流", func() (chan struct{}, chan struct{}, error) {
+		return futures.WsDiffDepthServe(s.symbol, s.handleDepth, func(err error) {
+			log.Printf("⚠️  %s 深度数据流错误: %v", s.symbol, err)
+		})
+	}, func() {
+		s.mu.Lock()
+		s.synced = false
+		s.mu.Unlock()
+	})
+}
+
+// runTrades 订阅逐笔成交（归集）
+func (s *orderFlowStream) runTrades() {
+	s.serve("成交数据流", func() (chan struct{}, chan struct{}, error) {
+		return futures.WsAggTradeServe(s.symbol, s.handleTrade, func(err error) {
+			log.Printf("⚠️  %s 成交数据流错误: %v", s.symbol, err)
+		})
+	}, func() {})
+}
+
+// loadSnapshot 获取深度快照作为本地订单簿的起点
+func (s *orderFlowStream) loadSnapshot() error {
+	depth, err := futures.NewClient("", "").NewDepthService().Symbol(s.symbol).Limit(orderFlowSnapshotLimit).Do(s.ctx)
+	if err != nil {
+		return fmt.Errorf("获取 %s 深度快照失败: %w", s.symbol, err)
+	}
+
+	s.bids = make(map[float64]float64, len(depth.Bids))
+	s.asks = make(map[float64]float64, len(depth.Asks))
+	for _, level := range depth.Bids {
+		if price, qty := parseLevel(level.Price, level.Quantity); price > 0 && qty > 0 {
+			s.bids[price] = qty
+		}
+	}
+	for _, level := range depth.Asks {
+		if price, qty := parseLevel(level.Price, level.Quantity); price > 0 && qty > 0 {
+			s.asks[price] = qty
+		}
+	}
+	if len(depth.Bids) > 0 && len(depth.Asks) > 0 && s.lastPrice <= 0 {
+		bestBid, _ := parseLevel(depth.Bids[0].Price, depth.Bids[0].Quantity)
+		bestAsk, _ := parseLevel(depth.Asks[0].Price, depth.Asks[0].Quantity)
+		s.lastPrice = (bestBid + bestAsk) / 2
+	}
+	s.lastUpdateID = depth.LastUpdateID
+	s.synced = true
+	s.firstApplied = false
+	return nil
+}
+
+// handleDepth 按Binance本地订单簿规则应用深度增量（事件不连续时重新获取快照）
+func (s *orderFlowStream) handleDepth(event *futures.WsDepthEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.synced {
+		if err := s.loadSnapshot(); err != nil {
+			log.Printf("⚠️  %v", err)
+			return
+		}
+	}
+	if event.LastUpdateID < s.lastUpdateID {
+		return // 快照之前的事件
+	}
+	if !s.firstApplied {
+		if event.FirstUpdateID > s.lastUpdateID {
+			s.synced = false // 快照与事件之间有缺口
+			return
+		}
+		s.firstApplied = true
+	} else if event.PrevLastUpdateID != s.lastUpdateID {
+		log.Printf("⚠️  %s 深度事件不连续，重新同步订单簿", s.symbol)
+		s.synced = false
+		return
+	}
+
+	bucket := s.bucketLocked(time.UnixMilli(event.Time))
+	for _, level := range event.Bids {
+		price, qty := parseLevel(level.Price, level.Quantity)
+		delta := (qty - s.bids[price]) * price
+		s.setLevel(s.bids, price, qty)
+		if s.inBand(price) {
+			bucket.bidDelta += delta
+			bucket.absDelta += math.Abs(delta)
+		}
+	}
+	for _, level := range event.Asks {
+		price, qty := parseLevel(level.Price, level.Quantity)
+		delta := (qty - s.asks[price]) * price
+		s.setLevel(s.asks, price, qty)
+		if s.inBand(price) {
+			bucket.askDelta += delta
+			bucket.absDelta += math.Abs(delta)
+		}
+	}
+	s.lastUpdateID = event.LastUpdateID
+}
+
+// handleTrade 统计主动买卖成交额（买方为maker=主动卖出）
+func (s *orderFlowStream) handleTrade(event *futures.WsAggTradeEvent) {
+	price, qty := parseLevel(event.Price, event.Quantity)
+	if price <= 0 || qty <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastPrice = price
+	bucket := s.bucketLocked(time.UnixMilli(event.TradeTime))
+	if event.Maker {
+		bucket.sellNotional += price * qty
+	} else {
+		bucket.buyNotional += price * qty
+	}
+}
+
+// bucketLocked 获取事件所在秒的汇总桶，并清理超出窗口的数据（调用方持有锁）
+func (s *orderFlowStream) bucketLocked(at time.Time) *flowBucket {
+	sec := at.Unix()
+	n := len(s.buckets)
+	if n == 0 || s.buckets[n-1].sec < sec {
+		s.buckets = append(s.buckets, flowBucket{sec: sec})
+		cutoff := time.Now().Add(-orderFlowWindow).Unix()
+		for len(s.buckets) > 1 && s.buckets[0].sec < cutoff {
+			s.buckets = s.buckets[1:]
+		}
+	}
+	return &s.buckets[len(s.buckets)-1]
+}
+
+// setLevel 更新订单簿档位（数量为0表示删除）
+func (s *orderFlowStream) setLevel(book map[float64]float64, price, qty float64) {
+	if qty <= 0 {
+		delete(book, price)
+		return
+	}
+	book[price] = qty
+}
+
+// inBand 价格是否在当前价附近的统计范围内
+func (s *orderFlowStream) inBand(price float64) bool {
+	if s.lastPrice <= 0 {
+		return false
+	}
+	return math.Abs(price-s.lastPrice)/s.lastPrice*100 <= orderFlowBandPct
+}
+
+// stats 计算订单流信号
+func (s *orderFlowStream) stats(now time.Time) *OrderFlowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &OrderFlowStats{
+		Symbol:          s.symbol,
+		Connected:       s.synced && s.firstApplied,
+		CoverageSeconds: int(math.Min(now.Sub(s.startedAt).Seconds(), orderFlowWindow.Seconds())),
+	}
+
+	var bidDepth, askDepth float64
+	for price, qty := range s.bids {
+		if s.inBand(price) {
+			bidDepth += price * qty
+		}
+	}
+	for price, qty := range s.asks {
+		if s.inBand(price) {
+			askDepth += price * qty
+		}
+	}
+	if bidDepth+askDepth > 0 {
+		stats.BookImbalance = (bidDepth - askDepth) / (bidDepth + askDepth)
+	}
+
+	var flow1m, abs1m, flow5m, abs5m, buy1m, sell1m, buy5m, sell5m float64
+	shortFrom := now.Add(-orderFlowShortWindow).Unix()
+	longFrom := now.Add(-orderFlowWindow).Unix()
+	for _, b := range s.buckets {
+		if b.sec < longFrom {
+			continue
+		}
+		// 买盘增加/卖盘减少 = 买方压力，反之为卖方压力
+		flow5m += b.bidDelta - b.askDelta
+		abs5m += b.absDelta
+		buy5m += b.buyNotional
+		sell5m += b.sellNotional
+		if b.sec >= shortFrom {
+			flow1m += b.bidDelta - b.askDelta
+			abs1m += b.absDelta
+			buy1m += b.buyNotional
+			sell1m += b.sellNotional
+		}
+	}
+	if abs1m > 0 {
+		stats.OFI1m = flow1m / abs1m
+	}
+	if abs5m > 0 {
+		stats.OFI5m = flow5m / abs5m
+	}
+	stats.TradeNotional1m = buy1m + sell1m
+	stats.TradeNotional5m = buy5m + sell5m
+	if stats.TradeNotional1m > 0 {
+		stats.AggBuyRatio1m = buy1m / stats.TradeNotional1m * 100
+	}
+	if stats.TradeNotional5m > 0 {
+		stats.AggBuyRatio5m = buy5m / stats.TradeNotional5m * 100
+	}
+	return stats
+}
+
+// parseLevel 解析价格和数量
+func parseLevel(priceStr, qtyStr string) (float64, float64) {
+	price, _ := strconv.ParseFloat(priceStr, 64)
+	qty, _ := strconv.ParseFloat(qtyStr, 64)
+	return price, qty
+}
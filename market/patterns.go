@@ -0,0 +1,474 @@
+package market
+
+import (
+	"math"
+	"nofx/i18n"
+	"sort"
+)
+
+// PatternStatsSettings 形态历史胜率统计配置
+type PatternStatsSettings struct {
+	Horizon    int // 形态出现后观察的K线根数（第N根收盘价朝形态方向运动视为命中）
+	Lookback   int // 从本地K线存储读取的历史K线数量
+	MinSamples int // 样本数不足时不展示胜率
+}
+
+// PatternStats 当前生效的形态统计配置
+var PatternStats = PatternStatsSettings{
+	Horizon:    3,
+	Lookback:   500,
+	MinSamples: 5,
+}
+
+// 形态方向
+const (
+	PatternBullish = "bullish"
+	PatternBearish = "bearish"
+	PatternNeutral = "neutral"
+)
+
+// PatternSignal 识别出的K线形态
+type PatternSignal struct {
+	Name       string  `json:"name"`       // 形态标识（如 morning_star）
+	Label      string  `json:"label"`      // 展示名称
+	Direction  string  `json:"direction"`  // bullish/bearish/neutral
+	Confidence float64 `json:"confidence"` // 置信度(0-1)，结合形态质量、趋势背景和成交量
+	HitRate    float64 `json:"hit_rate"`   // 历史命中率(%)，样本不足时为0
+	Samples    int     `json:"samples"`    // 历史出现次数（有后续K线可验证的）
+}
+
+// String 形态描述（Prompt展示用）
+func (s PatternSignal) String() string {
+	return s.Localized(i18n.Default)
+}
+
+// Localized 按语言生成形态描述（该语言没有形态名称时使用中文名称）
+func (s PatternSignal) Localized(lang i18n.Lang) string {
+	label := s.Label
+	if key := "pattern." + s.Name; i18n.Has(lang, key) {
+		label = i18n.T(lang, key)
+	}
+	text := i18n.T(lang, "pattern.confidence", label, s.Confidence*100)
+	if s.Direction != PatternNeutral && s.Samples >= PatternStats.MinSamples {
+		text += i18n.T(lang, "pattern.hit_rate", s.HitRate, s.Samples)
+	}
+	return text
+}
+
+// patternDetector 形态检测器：检查以 klines[i] 结尾的K线是否构成形态，返回置信度
+type patternDetector struct {
+	name      string
+	label     string
+	direction string
+	detect    func(klines []Kline, i int) (float64, bool)
+}
+
+// patternDetectors 全部形态检测器（顺序即展示顺序）
+var patternDetectors = []patternDetector{
+	{"hammer", "🔨 锤子线（看涨信号）", PatternBullish, detectHammer},
+	{"inverted_hammer", "🔨 倒锤子（潜在反转）", PatternBullish, detectInvertedHammer},
+	{"bullish_engulfing", "📈 看涨吞没（强烈看涨）", PatternBullish, detectBullishEngulfing},
+	{"bearish_engulfing", "📉 看跌吞没（强烈看跌）", PatternBearish, detectBearishEngulfing},
+	{"doji", "✨ 十字星（方向不明）", PatternNeutral, detectDoji},
+	{"shooting_star", "💫 射击之星（看跌信号）", PatternBearish, detectShootingStar},
+	{"three_white_soldiers", "🚀 三连阳（强势上涨）", PatternBullish, detectThreeWhiteSoldiers},
+	{"three_black_crows", "💀 三连阴（强势下跌）", PatternBearish, detectThreeBlackCrows},
+	{"morning_star", "🌅 启明星（底部反转）", PatternBullish, detectMorningStar},
+	{"evening_star", "🌆 黄昏星（顶部反转）", PatternBearish, detectEveningStar},
+	{"tweezer_bottom", "🥢 镊子底（支撑确认）", PatternBullish, detectTweezerBottom},
+	{"tweezer_top", "🥢 镊子顶（阻力确认）", PatternBearish, detectTweezerTop},
+	{"inside_bar", "📦 内包线（波动收敛）", PatternNeutral, detectInsideBar},
+	{"bullish_outside_bar", "📈 看涨外包线", PatternBullish, detectBullishOutsideBar},
+	{"bearish_outside_bar", "📉 看跌外包线", PatternBearish, detectBearishOutsideBar},
+	{"bull_flag", "🚩 上升旗形（趋势延续）", PatternBullish, detectBullFlag},
+	{"bear_flag", "🚩 下降旗形（趋势延续）", PatternBearish, detectBearFlag},
+	{"range_breakout_up", "⬆️ 区间向上突破", PatternBullish, detectRangeBreakoutUp},
+	{"range_breakout_down", "⬇️ 区间向下突破", PatternBearish, detectRangeBreakoutDown},
+}
+
+// DetectPatterns 识别最新K线上的形态，并用 history 统计这些形态的历史命中率
+func DetectPatterns(klines, history []Kline) []PatternSignal {
+	if len(klines) < 3 {
+		return nil
+	}
+
+	last := len(klines) - 1
+	var signals []PatternSignal
+	for _, d := range patternDetectors {
+		confidence, ok := d.detect(klines, last)
+		if !ok {
+			continue
+		}
+		signal := PatternSignal{
+			Name:       d.name,
+			Label:      d.label,
+			Direction:  d.direction,
+			Confidence: math.Min(confidence, 0.95),
+		}
+		if d.direction != PatternNeutral {
+			signal.HitRate, signal.Samples = patternHitRate(d, history)
+		}
+		signals = append(signals, signal)
+	}
+
+	// 置信度高的排在前面
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].Confidence > signals[j].Confidence
+	})
+	return signals
+}
+
+// FormatPatternSignals 把形态信号转为展示文本
+func FormatPatternSignals(signals []PatternSignal) []string {
+	return FormatPatternSignalsIn(signals, i18n.Default)
+}
+
+// FormatPatternSignalsIn 按语言把形态信号转为展示文本
+func FormatPatternSignalsIn(signals []PatternSignal, lang i18n.Lang) []string {
+	patterns := make([]string, 0, len(signals))
+	for _, s := range signals {
+		patterns = append(patterns, s.Localized(lang))
+	}
+	return patterns
+}
+
+// patternHitRate 统计形态在历史K线中出现后 Horizon 根K线内朝形态方向运动的比例
+func patternHitRate(d patternDetector, history []Kline) (float64, int) {
+	horizon := PatternStats.Horizon
+	if horizon <= 0 {
+		horizon = 3
+	}
+
+	hits, samples := 0, 0
+	for i := 2; i+horizon < len(history); i++ {
+		if _, ok := d.detect(history, i); !ok {
+			continue
+		}
+		samples++
+		move := history[i+horizon].Close - history[i].Close
+		if (d.direction == PatternBullish && move > 0) || (d.direction == PatternBearish && move < 0) {
+			hits++
+		}
+	}
+	if samples == 0 {
+		return 0, 0
+	}
+	return float64(hits) / float64(samples) * 100, samples
+}
+
+// patternHistory 形态统计使用的历史K线（启用K线持久化时读取更长的本地历史）
+func patternHistory(symbol, interval string, klines []Kline) []Kline {
+	store := getKlineStore()
+	if store == nil || PatternStats.Lookback <= len(klines) {
+		return klines
+	}
+	stored, _, err := store.LoadKlines(symbol, interval, PatternStats.Lookback)
+	if err != nil || len(stored) <= len(klines) {
+		return klines
+	}
+	return stored
+}
+
+// ========== 辅助函数 ==========
+
+// candleBody K线实体大小
+func candleBody(k Kline) float64 {
+	return math.Abs(k.Close - k.Open)
+}
+
+// isGreen 是否阳线
+func isGreen(k Kline) bool {
+	return k.Close > k.Open
+}
+
+// isRed 是否阴线
+func isRed(k Kline) bool {
+	return k.Close < k.Open
+}
+
+// averageRange 以 klines[i] 结尾（不含）的前 n 根K线平均振幅
+func averageRange(klines []Kline, i, n int) float64 {
+	start := i - n
+	if start < 0 {
+		start = 0
+	}
+	if start >= i {
+		return 0
+	}
+	sum := 0.0
+	for j := start; j < i; j++ {
+		sum += klines[j].High - klines[j].Low
+	}
+	return sum / float64(i-start)
+}
+
+// volumeConfirmed 当前K线成交量是否高于之前20根的平均值
+func volumeConfirmed(klines []Kline, i int) bool {
+	start := i - 20
+	if start < 0 {
+		start = 0
+	}
+	if start >= i {
+		return false
+	}
+	sum := 0.0
+	for j := start; j < i; j++ {
+		sum += klines[j].Volume
+	}
+	return klines[i].Volume > sum/float64(i-start)
+}
+
+// priorTrend 形态之前 n 根K线的走势（>0 上涨，<0 下跌），end 为形态第一根K线的下标
+func priorTrend(klines []Kline, end, n int) float64 {
+	start := end - n
+	if start < 0 || end <= 0 {
+		return 0
+	}
+	return klines[end-1].Close - klines[start].Close
+}
+
+// withContext 在基础置信度上叠加趋势背景和成交量确认
+func withContext(base float64, trendAligned, volume bool) float64 {
+	if trendAligned {
+		base += 0.1
+	}
+	if volume {
+		base += 0.1
+	}
+	return base
+}
+
+// ========== 单根/双根/三根K线形态 ==========
+
+func detectHammer(klines []Kline, i int) (float64, bool) {
+	if !isHammer(klines[i]) {
+		return 0, false
+	}
+	return withContext(0.5, priorTrend(klines, i, 5) < 0, volumeConfirmed(klines, i)), true
+}
+
+func detectInvertedHammer(klines []Kline, i int) (float64, bool) {
+	if !isInvertedHammer(klines[i]) {
+		return 0, false
+	}
+	return withContext(0.45, priorTrend(klines, i, 5) < 0, volumeConfirmed(klines, i)), true
+}
+
+func detectShootingStar(klines []Kline, i int) (float64, bool) {
+	if !isShootingStar(klines[i]) {
+		return 0, false
+	}
+	return withContext(0.5, priorTrend(klines, i, 5) > 0, volumeConfirmed(klines, i)), true
+}
+
+func detectDoji(klines []Kline, i int) (float64, bool) {
+	if !isDoji(klines[i]) {
+		return 0, false
+	}
+	return 0.5, true
+}
+
+func detectBullishEngulfing(klines []Kline, i int) (float64, bool) {
+	if i < 1 || !isBullishEngulfing(klines[i-1], klines[i]) {
+		return 0, false
+	}
+	return withContext(0.6, priorTrend(klines, i-1, 5) < 0, volumeConfirmed(klines, i)), true
+}
+
+func detectBearishEngulfing(klines []Kline, i int) (float64, bool) {
+	if i < 1 || !isBearishEngulfing(klines[i-1], klines[i]) {
+		return 0, false
+	}
+	return withContext(0.6, priorTrend(klines, i-1, 5) > 0, volumeConfirmed(klines, i)), true
+}
+
+func detectThreeWhiteSoldiers(klines []Kline, i int) (float64, bool) {
+	if i < 2 || !isThreeWhiteSoldiers(klines[i-2], klines[i-1], klines[i]) {
+		return 0, false
+	}
+	return withContext(0.6, false, volumeConfirmed(klines, i)), true
+}
+
+func detectThreeBlackCrows(klines []Kline, i int) (float64, bool) {
+	if i < 2 || !isThreeBlackCrows(klines[i-2], klines[i-1], klines[i]) {
+		return 0, false
+	}
+	return withContext(0.6, false, volumeConfirmed(klines, i)), true
+}
+
+// detectMorningStar 启明星：长阴线 + 低位小实体 + 收复第一根实体一半以上的阳线
+func detectMorningStar(klines []Kline, i int) (float64, bool) {
+	if i < 2 {
+		return 0, false
+	}
+	k1, k2, k3 := klines[i-2], klines[i-1], klines[i]
+	body1 := candleBody(k1)
+	if !isRed(k1) || !isGreen(k3) || body1 == 0 || body1 < (k1.High-k1.Low)*0.5 {
+		return 0, false
+	}
+	if candleBody(k2) > body1*0.3 || math.Max(k2.Open, k2.Close) > k1.Close {
+		return 0, false
+	}
+	if k3.Close < k1.Close+body1*0.5 {
+		return 0, false
+	}
+	return withContext(0.65, priorTrend(klines, i-2, 5) < 0, volumeConfirmed(klines, i)), true
+}
+
+// detectEveningStar 黄昏星：长阳线 + 高位小实体 + 跌破第一根实体一半以上的阴线
+func detectEveningStar(klines []Kline, i int) (float64, bool) {
+	if i < 2 {
+		return 0, false
+	}
+	k1, k2, k3 := klines[i-2], klines[i-1], klines[i]
+	body1 := candleBody(k1)
+	if !isGreen(k1) || !isRed(k3) || body1 == 0 || body1 < (k1.High-k1.Low)*0.5 {
+		return 0, false
+	}
+	if candleBody(k2) > body1*0.3 || math.Min(k2.Open, k2.Close) < k1.Close {
+		return 0, false
+	}
+	if k3.Close > k1.Close-body1*0.5 {
+		return 0, false
+	}
+	return withContext(0.65, priorTrend(klines, i-2, 5) > 0, volumeConfirmed(klines, i)), true
+}
+
+// detectTweezerBottom 镊子底：阴线后接阳线，两根最低价几乎相同
+func detectTweezerBottom(klines []Kline, i int) (float64, bool) {
+	if i < 1 {
+		return 0, false
+	}
+	k1, k2 := klines[i-1], klines[i]
+	tolerance := averageRange(klines, i, 10) * 0.1
+	if !isRed(k1) || !isGreen(k2) || tolerance == 0 || math.Abs(k1.Low-k2.Low) > tolerance {
+		return 0, false
+	}
+	return withContext(0.5, priorTrend(klines, i-1, 5) < 0, volumeConfirmed(klines, i)), true
+}
+
+// detectTweezerTop 镊子顶：阳线后接阴线，两根最高价几乎相同
+func detectTweezerTop(klines []Kline, i int) (float64, bool) {
+	if i < 1 {
+		return 0, false
+	}
+	k1, k2 := klines[i-1], klines[i]
+	tolerance := averageRange(klines, i, 10) * 0.1
+	if !isGreen(k1) || !isRed(k2) || tolerance == 0 || math.Abs(k1.High-k2.High) > tolerance {
+		return 0, false
+	}
+	return withContext(0.5, priorTrend(klines, i-1, 5) > 0, volumeConfirmed(klines, i)), true
+}
+
+// detectInsideBar 内包线：最高价和最低价都在前一根K线范围内
+func detectInsideBar(klines []Kline, i int) (float64, bool) {
+	if i < 1 {
+		return 0, false
+	}
+	prev, curr := klines[i-1], klines[i]
+	if curr.High >= prev.High || curr.Low <= prev.Low {
+		return 0, false
+	}
+	return 0.5, true
+}
+
+// isOutsideBar 外包线：最高价和最低价都超出前一根K线
+func isOutsideBar(prev, curr Kline) bool {
+	return curr.High > prev.High && curr.Low < prev.Low
+}
+
+func detectBullishOutsideBar(klines []Kline, i int) (float64, bool) {
+	if i < 1 || !isOutsideBar(klines[i-1], klines[i]) || !isGreen(klines[i]) {
+		return 0, false
+	}
+	return withContext(0.5, false, volumeConfirmed(klines, i)), true
+}
+
+func detectBearishOutsideBar(klines []Kline, i int) (float64, bool) {
+	if i < 1 || !isOutsideBar(klines[i-1], klines[i]) || !isRed(klines[i]) {
+		return 0, false
+	}
+	return withContext(0.5, false, volumeConfirmed(klines, i)), true
+}
+
+// ========== 多根K线结构形态 ==========
+
+const (
+	flagPoleBars  = 5  // 旗杆K线数
+	flagBars      = 5  // 旗面K线数
+	rangeLookback = 20 // 区间突破观察的K线数
+)
+
+// flagShape 旗形结构：旗杆涨跌幅、旗面振幅和旗面漂移（旗面为 klines[i-flagBars+1..i]）
+func flagShape(klines []Kline, i int) (pole, flagRange, drift, atr float64, ok bool) {
+	poleStart := i - flagBars - flagPoleBars
+	if poleStart < 0 {
+		return 0, 0, 0, 0, false
+	}
+	poleEnd := i - flagBars
+	pole = klines[poleEnd].Close - klines[poleStart].Close
+
+	high, low := klines[poleEnd+1].High, klines[poleEnd+1].Low
+	for j := poleEnd + 1; j <= i; j++ {
+		high = math.Max(high, klines[j].High)
+		low = math.Min(low, klines[j].Low)
+	}
+	flagRange = high - low
+	drift = klines[i].Close - klines[poleEnd].Close
+	atr = averageRange(klines, poleStart, rangeLookback)
+	if atr == 0 {
+		atr = averageRange(klines, poleEnd, flagPoleBars)
+	}
+	return pole, flagRange, drift, atr, atr > 0
+}
+
+// detectBullFlag 上升旗形：急涨旗杆 + 窄幅回调/横盘旗面
+func detectBullFlag(klines []Kline, i int) (float64, bool) {
+	pole, flagRange, drift, atr, ok := flagShape(klines, i)
+	if !ok || pole < atr*3 || flagRange > pole*0.5 || drift > 0 || -drift > pole*0.5 {
+		return 0, false
+	}
+	return withContext(0.55, false, volumeConfirmed(klines, i)), true
+}
+
+// detectBearFlag 下降旗形：急跌旗杆 + 窄幅反弹/横盘旗面
+func detectBearFlag(klines []Kline, i int) (float64, bool) {
+	pole, flagRange, drift, atr, ok := flagShape(klines, i)
+	if !ok || -pole < atr*3 || flagRange > -pole*0.5 || drift < 0 || drift > -pole*0.5 {
+		return 0, false
+	}
+	return withContext(0.55, false, volumeConfirmed(klines, i)), true
+}
+
+// priorRange 以 klines[i] 结尾（不含）的前 rangeLookback 根K线的最高/最低价
+func priorRange(klines []Kline, i int) (high, low float64, ok bool) {
+	if i < rangeLookback {
+		return 0, 0, false
+	}
+	high, low = klines[i-rangeLookback].High, klines[i-rangeLookback].Low
+	for j := i - rangeLookback; j < i; j++ {
+		high = math.Max(high, klines[j].High)
+		low = math.Min(low, klines[j].Low)
+	}
+	return high, low, true
+}
+
+// detectRangeBreakoutUp 收盘价突破前20根K线的最高价（区间越窄突破越可信）
+func detectRangeBreakoutUp(klines []Kline, i int) (float64, bool) {
+	high, low, ok := priorRange(klines, i)
+	if !ok || klines[i].Close <= high {
+		return 0, false
+	}
+	tight := high-low < averageRange(klines, i, rangeLookback)*6
+	return withContext(0.5, tight, volumeConfirmed(klines, i)), true
+}
+
+// detectRangeBreakoutDown 收盘价跌破前20根K线的最低价
+func detectRangeBreakoutDown(klines []Kline, i int) (float64, bool) {
+	high, low, ok := priorRange(klines, i)
+	if !ok || klines[i].Close >= low {
+		return 0, false
+	}
+	tight := high-low < averageRange(klines, i, rangeLookback)*6
+	return withContext(0.5, tight, volumeConfirmed(klines, i)), true
+}
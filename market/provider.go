@@ -0,0 +1,488 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider 行情数据源（K线和资金费率）
+// 持仓量、多空比、爆仓统计等衍生数据目前只有Binance来源，与所选数据源无关
+type Provider interface {
+	// Name 数据源名称（用于日志、缓存键和限流键）
+	Name() string
+	// Klines 获取K线（按开盘时间升序）
+	Klines(symbol, interval string, limit int) ([]Kline, error)
+	// FundingRate 获取最新资金费率（统一换算为8小时费率）
+	FundingRate(symbol string) (float64, error)
+}
+
+// FundingInfo 资金费率及结算时间
+type FundingInfo struct {
+	Rate     float64       // 8小时费率
+	NextTime time.Time     // 下次结算时间（零值表示未知）
+	Interval time.Duration // 结算间隔
+}
+
+// fundingSource 可同时提供下次结算时间的数据源
+type fundingSource interface {
+	Funding(symbol string) (FundingInfo, error)
+}
+
+// fetchFunding 获取资金费率；数据源不提供结算时间时只返回费率
+func fetchFunding(p Provider, symbol string) (FundingInfo, error) {
+	if fs, ok := p.(fundingSource); ok {
+		return fs.Funding(symbol)
+	}
+	rate, err := p.FundingRate(symbol)
+	return FundingInfo{Rate: rate}, err
+}
+
+// 数据源名称
+const (
+	ProviderBinance     = "binance"
+	ProviderBybit       = "bybit"
+	ProviderHyperliquid = "hyperliquid"
+)
+
+// 组合数据源模式
+const (
+	CompositePrimary = "primary" // 按顺序使用第一个成功的数据源
+	CompositeAverage = "average" // 对所有成功的数据源按开盘时间对齐后取平均
+)
+
+// providerHTTPClient 非Binance数据源使用的HTTP客户端
+var providerHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// DefaultProvider 未配置数据源时使用的Binance行情
+var DefaultProvider Provider = binanceProvider{}
+
+// NewProvider 按配置创建数据源
+// 格式: "binance" / "bybit" / "hyperliquid" / "primary:hyperliquid,binance" / "average:binance,bybit"
+// 空字符串表示使用默认的Binance行情
+func NewProvider(spec string) (Provider, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" {
+		return DefaultProvider, nil
+	}
+
+	mode, list, composite := strings.Cut(spec, ":")
+	if !composite {
+		return newSingleProvider(spec)
+	}
+	if mode != CompositePrimary && mode != CompositeAverage {
+		return nil, fmt.Errorf("未知的行情组合模式: %s（可选 primary / average）", mode)
+	}
+
+	var providers []Provider
+	for _, name := range strings.Split(list, ",") {
+		p, err := newSingleProvider(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("行情组合数据源为空: %s", spec)
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return &compositeProvider{mode: mode, providers: providers}, nil
+}
+
+// newSingleProvider 创建单个数据源
+func newSingleProvider(name string) (Provider, error) {
+	switch name {
+	case ProviderBinance:
+		return binanceProvider{}, nil
+	case ProviderBybit:
+		return bybitProvider{}, nil
+	case ProviderHyperliquid:
+		return hyperliquidProvider{}, nil
+	}
+	return nil, fmt.Errorf("未知的行情数据源: %s（可选 binance / bybit / hyperliquid）", name)
+}
+
+// binanceProvider Binance合约行情（默认数据源）
+type binanceProvider struct{}
+
+func (binanceProvider) Name() string { return ProviderBinance }
+
+func (binanceProvider) Klines(symbol, interval string, limit int) ([]Kline, error) {
+	return fetchKlines(symbol, interval, limit)
+}
+
+func (binanceProvider) FundingRate(symbol string) (float64, error) {
+	return getFundingRate(symbol)
+}
+
+func (binanceProvider) Funding(symbol string) (FundingInfo, error) {
+	return getFundingInfo(symbol)
+}
+
+// bybitProvider Bybit USDT永续行情（v5 API）
+type bybitProvider struct{}
+
+// bybitIntervals Binance周期写法到Bybit周期参数
+var bybitIntervals = map[string]string{
+	"1m": "1", "3m": "3", "5m": "5", "15m": "15", "30m": "30",
+	"1h": "60", "2h": "120", "4h": "240", "6h": "360", "12h": "720",
+	"1d": "D", "1w": "W",
+}
+
+func (bybitProvider) Name() string { return ProviderBybit }
+
+// bybitGet 请求Bybit公开接口并检查retCode
+func bybitGet(path string, result interface{}) error {
+	resp, err := providerHTTPClient.Get("https://api.bybit.com" + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var envelope struct {
+		RetCode int             `json:"retCode"`
+		RetMsg  string          `json:"retMsg"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("解析Bybit响应失败: %w", err)
+	}
+	if envelope.RetCode != 0 {
+		return fmt.Errorf("Bybit返回错误 %d: %s", envelope.RetCode, envelope.RetMsg)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+func (bybitProvider) Klines(symbol, interval string, limit int) ([]Kline, error) {
+	bybitInterval, ok := bybitIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("Bybit不支持K线周期 %s", interval)
+	}
+	var result struct {
+		List [][]string `json:"list"` // [startTime, open, high, low, close, volume, turnover]，按时间倒序
+	}
+	path := fmt.Sprintf("/v5/market/kline?category=linear&symbol=%s&interval=%s&limit=%d", symbol, bybitInterval, limit)
+	if err := bybitGet(path, &result); err != nil {
+		return nil, err
+	}
+
+	period := int64(getIntervalMinutes(interval)) * 60 * 1000
+	klines := make([]Kline, 0, len(result.List))
+	for i := len(result.List) - 1; i >= 0; i-- {
+		item := result.List[i]
+		if len(item) < 6 {
+			continue
+		}
+		openTime, _ := strconv.ParseInt(item[0], 10, 64)
+		open, _ := strconv.ParseFloat(item[1], 64)
+		high, _ := strconv.ParseFloat(item[2], 64)
+		low, _ := strconv.ParseFloat(item[3], 64)
+		close, _ := strconv.ParseFloat(item[4], 64)
+		volume, _ := strconv.ParseFloat(item[5], 64)
+		klines = append(klines, Kline{
+			OpenTime:  openTime,
+			Open:      open,
+			High:      high,
+			Low:       low,
+			Close:     close,
+			Volume:    volume,
+			CloseTime: openTime + period - 1,
+		})
+	}
+	return klines, nil
+}
+
+func (p bybitProvider) FundingRate(symbol string) (float64, error) {
+	info, err := p.Funding(symbol)
+	return info.Rate, err
+}
+
+func (bybitProvider) Funding(symbol string) (FundingInfo, error) {
+	var result struct {
+		List []struct {
+			FundingRate     string `json:"fundingRate"`
+			NextFundingTime string `json:"nextFundingTime"`
+		} `json:"list"`
+	}
+	if err := bybitGet("/v5/market/tickers?category=linear&symbol="+symbol, &result); err != nil {
+		return FundingInfo{}, err
+	}
+	if len(result.List) == 0 {
+		return FundingInfo{}, fmt.Errorf("Bybit没有 %s 的行情", symbol)
+	}
+	rate, err := strconv.ParseFloat(result.List[0].FundingRate, 64)
+	if err != nil {
+		return FundingInfo{}, err
+	}
+	info := FundingInfo{Rate: rate, Interval: 8 * time.Hour}
+	if ms, err := strconv.ParseInt(result.List[0].NextFundingTime, 10, 64); err == nil && ms > 0 {
+		info.NextTime = time.UnixMilli(ms)
+	}
+	return info, nil
+}
+
+// hyperliquidProvider Hyperliquid永续行情（info API）
+type hyperliquidProvider struct{}
+
+func (hyperliquidProvider) Name() string { return ProviderHyperliquid }
+
+// hyperliquidCoin 标准symbol转换为Hyperliquid币种名（BTCUSDT -> BTC）
+func hyperliquidCoin(symbol string) string {
+	for _, quote := range []string{"USDT", "USDC"} {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return strings.TrimSuffix(symbol, quote)
+		}
+	}
+	return symbol
+}
+
+// hyperliquidInfo 请求Hyperliquid info接口
+func hyperliquidInfo(request interface{}, result interface{}) error {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	resp, err := providerHTTPClient.Post("https://api.hyperliquid.xyz/info", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Hyperliquid返回HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("解析Hyperliquid响应失败: %w", err)
+	}
+	return nil
+}
+
+func (hyperliquidProvider) Klines(symbol, interval string, limit int) ([]Kline, error) {
+	period := time.Duration(getIntervalMinutes(interval)) * time.Minute
+	end := time.Now()
+	start := end.Add(-period * time.Duration(limit))
+
+	var candles []struct {
+		OpenTime  int64  `json:"t"`
+		CloseTime int64  `json:"T"`
+		Open      string `json:"o"`
+		High      string `json:"h"`
+		Low       string `json:"l"`
+		Close     string `json:"c"`
+		Volume    string `json:"v"`
+	}
+	request := map[string]interface{}{
+		"type": "candleSnapshot",
+		"req": map[string]interface{}{
+			"coin":      hyperliquidCoin(symbol),
+			"interval":  interval,
+			"startTime": start.UnixMilli(),
+			"endTime":   end.UnixMilli(),
+		},
+	}
+	if err := hyperliquidInfo(request, &candles); err != nil {
+		return nil, err
+	}
+
+	klines := make([]Kline, 0, len(candles))
+	for _, c := range candles {
+		open, _ := strconv.ParseFloat(c.Open, 64)
+		high, _ := strconv.ParseFloat(c.High, 64)
+		low, _ := strconv.ParseFloat(c.Low, 64)
+		close, _ := strconv.ParseFloat(c.Close, 64)
+		volume, _ := strconv.ParseFloat(c.Volume, 64)
+		klines = append(klines, Kline{
+			OpenTime:  c.OpenTime,
+			Open:      open,
+			High:      high,
+			Low:       low,
+			Close:     close,
+			Volume:    volume,
+			CloseTime: c.CloseTime,
+		})
+	}
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+func (hyperliquidProvider) FundingRate(symbol string) (float64, error) {
+	var result []json.RawMessage // [meta, assetCtxs]
+	if err := hyperliquidInfo(map[string]string{"type": "metaAndAssetCtxs"}, &result); err != nil {
+		return 0, err
+	}
+	if len(result) < 2 {
+		return 0, fmt.Errorf("Hyperliquid metaAndAssetCtxs 响应格式异常")
+	}
+	var meta struct {
+		Universe []struct {
+			Name string `json:"name"`
+		} `json:"universe"`
+	}
+	var ctxs []struct {
+		Funding string `json:"funding"`
+	}
+	if err := json.Unmarshal(result[0], &meta); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(result[1], &ctxs); err != nil {
+		return 0, err
+	}
+
+	coin := hyperliquidCoin(symbol)
+	for i, asset := range meta.Universe {
+		if asset.Name == coin && i < len(ctxs) {
+			hourly, err := strconv.ParseFloat(ctxs[i].Funding, 64)
+			if err != nil {
+				return 0, err
+			}
+			// Hyperliquid每小时结算一次，换算为8小时费率与其他交易所对齐
+			return hourly * 8, nil
+		}
+	}
+	return 0, fmt.Errorf("Hyperliquid没有 %s 的行情", coin)
+}
+
+func (p hyperliquidProvider) Funding(symbol string) (FundingInfo, error) {
+	rate, err := p.FundingRate(symbol)
+	if err != nil {
+		return FundingInfo{}, err
+	}
+	// 每个整点结算
+	return FundingInfo{Rate: rate, NextTime: time.Now().Truncate(time.Hour).Add(time.Hour), Interval: time.Hour}, nil
+}
+
+// compositeProvider 组合多个数据源（主备切换或取平均）
+type compositeProvider struct {
+	mode      string
+	providers []Provider
+}
+
+func (c *compositeProvider) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return c.mode + ":" + strings.Join(names, ",")
+}
+
+func (c *compositeProvider) Klines(symbol, interval string, limit int) ([]Kline, error) {
+	var results [][]Kline
+	var errs []string
+	for _, p := range c.providers {
+		klines, err := getProviderKlines(p, symbol, interval, limit)
+		if err != nil || len(klines) == 0 {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+			continue
+		}
+		if c.mode == CompositePrimary {
+			if len(errs) > 0 {
+				log.Printf("⚠️  %s %s 主数据源不可用，已切换到 %s（%s）", symbol, interval, p.Name(), strings.Join(errs, "; "))
+			}
+			return klines, nil
+		}
+		results = append(results, klines)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("所有行情数据源均失败: %s", strings.Join(errs, "; "))
+	}
+	return averageKlines(results), nil
+}
+
+func (c *compositeProvider) FundingRate(symbol string) (float64, error) {
+	info, err := c.Funding(symbol)
+	return info.Rate, err
+}
+
+// Funding 主备模式取第一个可用数据源；平均模式取费率平均值，结算时间以第一个可用数据源为准
+func (c *compositeProvider) Funding(symbol string) (FundingInfo, error) {
+	var result FundingInfo
+	var sum float64
+	var count int
+	var errs []string
+	for _, p := range c.providers {
+		info, err := fetchFunding(p, symbol)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+			continue
+		}
+		if c.mode == CompositePrimary {
+			return info, nil
+		}
+		if count == 0 {
+			result = info
+		}
+		sum += info.Rate
+		count++
+	}
+	if count == 0 {
+		return FundingInfo{}, fmt.Errorf("所有资金费率数据源均失败: %s", strings.Join(errs, "; "))
+	}
+	result.Rate = sum / float64(count)
+	return result, nil
+}
+
+// averageKlines 按开盘时间对齐多个数据源的K线并取平均（只保留多数数据源都有的K线）
+func averageKlines(results [][]Kline) []Kline {
+	if len(results) == 1 {
+		return results[0]
+	}
+	type bucket struct {
+		sum   Kline
+		count int
+	}
+	buckets := make(map[int64]*bucket)
+	for _, klines := range results {
+		for _, k := range klines {
+			b, ok := buckets[k.OpenTime]
+			if !ok {
+				b = &bucket{sum: Kline{OpenTime: k.OpenTime, CloseTime: k.CloseTime}}
+				buckets[k.OpenTime] = b
+			}
+			b.sum.Open += k.Open
+			b.sum.High += k.High
+			b.sum.Low += k.Low
+			b.sum.Close += k.Close
+			b.sum.Volume += k.Volume
+			b.count++
+		}
+	}
+
+	quorum := len(results)/2 + 1
+	merged := make([]Kline, 0, len(buckets))
+	for _, b := range buckets {
+		if b.count < quorum {
+			continue
+		}
+		n := float64(b.count)
+		merged = append(merged, Kline{
+			OpenTime:  b.sum.OpenTime,
+			Open:      b.sum.Open / n,
+			High:      b.sum.High / n,
+			Low:       b.sum.Low / n,
+			Close:     b.sum.Close / n,
+			Volume:    b.sum.Volume / n,
+			CloseTime: b.sum.CloseTime,
+		})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].OpenTime < merged[j].OpenTime })
+	return merged
+}
@@ -0,0 +1,93 @@
+package market
+
+import "strings"
+
+// MarginType 合约保证金类型
+type MarginType string
+
+const (
+	MarginUSDT MarginType = "usdt" // U本位，USDT保证金和结算
+	MarginUSDC MarginType = "usdc" // U本位，USDC保证金和结算
+	MarginCoin MarginType = "coin" // 币本位（反向合约），以基础币种作为保证金和结算资产
+)
+
+// CoinMarginedSuffix 币本位永续合约的交易对后缀（币安 COIN-M：BTCUSD_PERP）
+const CoinMarginedSuffix = "USD_PERP"
+
+// stableQuoteAssets U本位合约支持的稳定币计价资产（按1:1折算为USD）
+var stableQuoteAssets = []string{"USDT", "USDC"}
+
+// SymbolQuote 交易对的计价和保证金信息
+type SymbolQuote struct {
+	Base        string     // 基础币种（BTC）
+	Quote       string     // 计价币种（USDT/USDC，币本位为USD）
+	Margin      MarginType // 保证金类型
+	SettleAsset string     // 保证金和盈亏结算资产（USDT/USDC/基础币种）
+}
+
+// IsStableAsset 是否为按1:1折算为USD的稳定币
+func IsStableAsset(asset string) bool {
+	for _, s := range stableQuoteAssets {
+		if asset == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCoinMargined 是否为币本位合约
+func IsCoinMargined(symbol string) bool {
+	return strings.HasSuffix(symbol, CoinMarginedSuffix) && len(symbol) > len(CoinMarginedSuffix)
+}
+
+// ParseSymbolQuote 解析交易对的计价和保证金信息
+// 已加载 exchangeInfo 时使用交易所返回的基础/计价币种，否则按后缀推断
+func ParseSymbolQuote(symbol string) SymbolQuote {
+	if IsCoinMargined(symbol) {
+		base := strings.TrimSuffix(symbol, CoinMarginedSuffix)
+		return SymbolQuote{Base: base, Quote: "USD", Margin: MarginCoin, SettleAsset: base}
+	}
+
+	quote := SymbolQuote{Quote: DefaultQuoteAsset}
+	if reg := loadedSymbolRegistry(); reg != nil {
+		if s, ok := reg.bySymbol[symbol]; ok && s.BaseAsset != "" && s.QuoteAsset != "" {
+			quote.Base, quote.Quote = s.BaseAsset, s.QuoteAsset
+		}
+	}
+	if quote.Base == "" {
+		for _, q := range stableQuoteAssets {
+			if strings.HasSuffix(symbol, q) && len(symbol) > len(q) {
+				quote.Base, quote.Quote = strings.TrimSuffix(symbol, q), q
+				break
+			}
+		}
+	}
+
+	quote.SettleAsset = quote.Quote
+	quote.Margin = MarginUSDT
+	if quote.Quote == "USDC" {
+		quote.Margin = MarginUSDC
+	}
+	return quote
+}
+
+// CoinContractSize 币本位合约每张面值(USD)：BTC为100美元，其他币种为10美元
+func CoinContractSize(base string) float64 {
+	if base == "BTC" {
+		return 100
+	}
+	return 10
+}
+
+// hasKnownQuoteSuffix symbol是否已带有支持的计价后缀（未加载 exchangeInfo 时使用）
+func hasKnownQuoteSuffix(symbol string) bool {
+	if IsCoinMargined(symbol) {
+		return true
+	}
+	for _, q := range stableQuoteAssets {
+		if strings.HasSuffix(symbol, q) && len(symbol) > len(q) {
+			return true
+		}
+	}
+	return false
+}
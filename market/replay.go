@@ -0,0 +1,113 @@
+package market
+
+import (
+	"fmt"
+	"time"
+)
+
+// replayKlineSettings 回放使用的短期/长期K线配置（与GetWith的取数方式一致）
+func replayKlineSettings() (KlineSettings, KlineSettings) {
+	short := KlineSettings{Interval: "3m", Limit: 20}
+	long := KlineSettings{Interval: "4h", Limit: 60}
+	if len(DefaultKlineSettings) > 0 {
+		short = DefaultKlineSettings[0]
+	}
+	if len(DefaultKlineSettings) > 1 {
+		long = DefaultKlineSettings[1]
+	}
+	return short, long
+}
+
+// loadClosedKlines 从本地存储读取at之前已收盘的最近limit根K线
+func loadClosedKlines(symbol, interval string, limit int, at time.Time) ([]Kline, error) {
+	period := time.Duration(getIntervalMinutes(interval)) * time.Minute
+	klines, err := LoadStoredKlines(symbol, interval, at.Add(-period*time.Duration(limit+1)), at)
+	if err != nil {
+		return nil, err
+	}
+	closed := klines[:0]
+	for _, k := range klines {
+		if time.UnixMilli(k.OpenTime).Add(period).After(at) {
+			break // 未收盘的K线在回放时点还看不到收盘价
+		}
+		closed = append(closed, k)
+	}
+	if len(closed) > limit {
+		closed = closed[len(closed)-limit:]
+	}
+	return closed, nil
+}
+
+// SnapshotAt 用本地存储的K线重建某一时刻的行情数据（离线回放使用，不访问网络）
+// 只使用at之前已收盘的K线，持仓量、资金费率、多空比和爆仓统计没有历史存档，回放时为空
+func SnapshotAt(symbol string, at time.Time) (*Data, error) {
+	symbol = Normalize(symbol)
+	shortSetting, longSetting := replayKlineSettings()
+
+	shortKlines, err := loadClosedKlines(symbol, shortSetting.Interval, shortSetting.Limit+20, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(shortKlines) == 0 {
+		return nil, fmt.Errorf("%s 在 %s 之前没有本地%s K线", symbol, at.Format("2006-01-02 15:04"), shortSetting.Interval)
+	}
+	longKlines, err := loadClosedKlines(symbol, longSetting.Interval, longSetting.Limit, at)
+	if err != nil {
+		return nil, err
+	}
+
+	currentPrice := shortKlines[len(shortKlines)-1].Close
+	data := &Data{
+		Symbol:            symbol,
+		CurrentPrice:      currentPrice,
+		CurrentEMA20:      calculateEMA(shortKlines, 20),
+		CurrentMACD:       calculateMACD(shortKlines),
+		CurrentRSI7:       calculateRSI(shortKlines, 7),
+		OpenInterest:      &OIData{},
+		IntradaySeries:    calculateIntradaySeries(shortKlines),
+		LongerTermContext: calculateLongerTermData(longKlines),
+	}
+	if len(shortKlines) >= 21 {
+		if price1hAgo := shortKlines[len(shortKlines)-21].Close; price1hAgo > 0 {
+			data.PriceChange1h = (currentPrice - price1hAgo) / price1hAgo * 100
+		}
+	}
+	if len(longKlines) >= 2 {
+		if price4hAgo := longKlines[len(longKlines)-2].Close; price4hAgo > 0 {
+			data.PriceChange4h = (currentPrice - price4hAgo) / price4hAgo * 100
+		}
+	}
+
+	for _, setting := range DefaultKlineSettings {
+		klines, err := loadClosedKlines(symbol, setting.Interval, setting.Limit+20, at)
+		if err != nil || len(klines) == 0 {
+			continue
+		}
+		// 形态历史胜率只用回放时点之前的K线统计，避免用到未来数据
+		data.AllTimeframes = append(data.AllTimeframes, buildTimeframeData(setting, klines, klines))
+	}
+
+	if len(longKlines) >= 50 {
+		data.EnhancedIndicators = CalculateEnhancedIndicators(longKlines)
+		data.MarketSentiment = AnalyzeMarketSentiment(data, data.EnhancedIndicators)
+	}
+	return data, nil
+}
+
+// ReplayBars 读取[from, to)内已收盘的短期K线（用于回放时逐根检查止损/止盈触发）
+func ReplayBars(symbol string, from, to time.Time) ([]Kline, error) {
+	shortSetting, _ := replayKlineSettings()
+	period := time.Duration(getIntervalMinutes(shortSetting.Interval)) * time.Minute
+	klines, err := LoadStoredKlines(Normalize(symbol), shortSetting.Interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	bars := klines[:0]
+	for _, k := range klines {
+		if time.UnixMilli(k.OpenTime).Add(period).After(to) {
+			break
+		}
+		bars = append(bars, k)
+	}
+	return bars, nil
+}
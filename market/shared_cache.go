@@ -0,0 +1,61 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/sharedstate"
+	"time"
+)
+
+// SharedCacheSettings 共享行情缓存和限流配置（由trader在每个周期根据运行时配置更新）
+type SharedCacheSettings struct {
+	TTL                time.Duration // K线缓存时长，0表示不缓存（多个trader/进程拉取同一K线时复用）
+	RateLimitPerMinute int           // 所有进程合计每分钟请求交易所K线接口的次数上限，0表示不限流
+	MaxWait            time.Duration // 达到限流时最多等待的时间
+}
+
+// SharedCache 当前生效的共享行情缓存配置（默认关闭，行为与单进程直接拉取一致）
+var SharedCache = SharedCacheSettings{
+	MaxWait: 30 * time.Second,
+}
+
+// klineRateLimitKey 数据源K线接口的限流键（各数据源分别计数）
+func klineRateLimitKey(p Provider) string {
+	return p.Name() + "_klines"
+}
+
+// fetchSharedKlines 拉取K线：优先使用共享缓存，未命中时在限流内请求数据源并写入缓存
+func fetchSharedKlines(p Provider, symbol, interval string, limit int) ([]Kline, error) {
+	settings := SharedCache
+	store := sharedstate.Current()
+	key := fmt.Sprintf("klines:%s:%s:%s:%d", p.Name(), symbol, interval, limit)
+
+	if settings.TTL > 0 {
+		if raw, ok, err := store.Get(key); err != nil {
+			log.Printf("⚠️ 读取共享K线缓存失败 %s %s: %v", symbol, interval, err)
+		} else if ok {
+			var klines []Kline
+			if err := json.Unmarshal(raw, &klines); err == nil && len(klines) > 0 {
+				return klines, nil
+			}
+		}
+	}
+
+	if !sharedstate.Wait(klineRateLimitKey(p), settings.RateLimitPerMinute, time.Minute, settings.MaxWait) {
+		return nil, fmt.Errorf("%s K线接口限流（每分钟%d次），等待%s后仍未放行", p.Name(), settings.RateLimitPerMinute, settings.MaxWait)
+	}
+	klines, err := p.Klines(symbol, interval, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.TTL > 0 && len(klines) > 0 {
+		if raw, err := json.Marshal(klines); err == nil {
+			if err := store.Set(key, raw, settings.TTL); err != nil {
+				log.Printf("⚠️ 写入共享K线缓存失败 %s %s: %v", symbol, interval, err)
+			}
+		}
+	}
+	return klines, nil
+}
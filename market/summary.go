@@ -0,0 +1,129 @@
+package market
+
+import (
+	"fmt"
+	"nofx/i18n"
+	"strings"
+)
+
+// FormatSummaryIn 紧凑模式的行情摘要：只保留最新指标值和变化方向，不输出K线和指标序列
+// 与 FormatCompactIn 使用相同的指标名，数据降级提示和K线形态按prompt语言输出
+func FormatSummaryIn(data *Data, lang i18n.Lang) string {
+	var sb strings.Builder
+	sb.WriteString(formatQualityWarning(data, lang))
+
+	// 价格与短周期指标
+	sb.WriteString(fmt.Sprintf("Price:%.2f 1h:%+.2f%% 4h:%+.2f%% EMA20:%.2f(%+.2f%%) MACD:%.3f RSI7:%.1f",
+		data.CurrentPrice, data.PriceChange1h, data.PriceChange4h,
+		data.CurrentEMA20, pctFrom(data.CurrentEMA20, data.CurrentPrice), data.CurrentMACD, data.CurrentRSI7))
+	if s := data.IntradaySeries; s != nil {
+		if n := len(s.RSI14Values); n > 0 {
+			sb.WriteString(fmt.Sprintf(" RSI14:%.1f", s.RSI14Values[n-1]))
+		}
+		if slope, ok := seriesSlope(s.MACDValues); ok {
+			sb.WriteString(" MACDΔ:" + slope)
+		}
+		if slope, ok := seriesSlope(s.EMA20Values); ok {
+			sb.WriteString(" EMA20Δ:" + slope)
+		}
+		if s.HighestPrice > 0 && s.LowestPrice > 0 {
+			sb.WriteString(fmt.Sprintf(" Range:[%.2f,%.2f]", s.LowestPrice, s.HighestPrice))
+		}
+	}
+	sb.WriteString("\n")
+
+	// 长周期指标
+	if lt := data.LongerTermContext; lt != nil {
+		interval := "4h"
+		if len(DefaultKlineSettings) > 1 {
+			interval = DefaultKlineSettings[1].Interval
+		}
+		sb.WriteString(fmt.Sprintf("LongTerm(%s): EMA20:%.2f EMA50:%.2f ATR14:%.2f(%.2f%%)",
+			interval, lt.EMA20, lt.EMA50, lt.ATR14, pctOf(lt.ATR14, data.CurrentPrice)))
+		if lt.AverageVolume > 0 {
+			sb.WriteString(fmt.Sprintf(" VolRatio:%.2f", lt.CurrentVolume/lt.AverageVolume))
+		}
+		if n := len(lt.MACDValues); n > 0 {
+			sb.WriteString(fmt.Sprintf(" MACD:%.3f", lt.MACDValues[n-1]))
+		}
+		if n := len(lt.RSI14Values); n > 0 {
+			sb.WriteString(fmt.Sprintf(" RSI14:%.1f", lt.RSI14Values[n-1]))
+		}
+		sb.WriteString("\n")
+	}
+
+	// OI和资金费率
+	if oi := data.OpenInterest; oi != nil {
+		sb.WriteString(fmt.Sprintf("OI:%.0fM ", oi.Latest/1000000))
+		if len(oi.Series) > 0 {
+			sb.WriteString(fmt.Sprintf("OIΔ1h:%+.2f%% 4h:%+.2f%% 24h:%+.2f%% ", oi.Change1h, oi.Change4h, oi.Change24h))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("FR:%.4f%%", data.FundingRate*100))
+	if ratio, ok := data.LongShortRatios["1h"]; ok && ratio != nil {
+		sb.WriteString(fmt.Sprintf(" L/S(1h):%.2f", ratio.LongShortRatio))
+	}
+	sb.WriteString("\n")
+
+	// 关键价位与波动
+	if ind := data.EnhancedIndicators; ind != nil {
+		var parts []string
+		if bb := ind.BollingerBands; bb != nil && bb.Upper > bb.Lower {
+			parts = append(parts, fmt.Sprintf("BB%%:%.0f", (data.CurrentPrice-bb.Lower)/(bb.Upper-bb.Lower)*100))
+		}
+		if len(ind.SupportLevels) > 0 && len(ind.ResistanceLevels) > 0 {
+			parts = append(parts, fmt.Sprintf("Support:%.2f Resist:%.2f", ind.SupportLevels[0], ind.ResistanceLevels[0]))
+		}
+		parts = append(parts, fmt.Sprintf("HVol:%.2f%%", ind.HistoricalVol*100))
+		sb.WriteString("Levels: " + strings.Join(parts, " ") + "\n")
+	}
+
+	// K线形态和市场情绪
+	if s := data.IntradaySeries; s != nil && len(s.PatternSignals) > 0 {
+		sb.WriteString(fmt.Sprintf("Patterns:%s\n", strings.Join(FormatPatternSignalsIn(s.PatternSignals, lang), ",")))
+	}
+	if m := data.MarketSentiment; m != nil {
+		sb.WriteString(fmt.Sprintf("Sentiment:%s Mom:%s\n", m.OverallSentiment, m.MomentumSignal))
+	}
+	sb.WriteString(formatLiquidationLine(data.Liquidations))
+	return sb.String()
+}
+
+// seriesSlope 指标序列最后两个值的变化方向（up/down/flat）
+func seriesSlope(values []float64) (string, bool) {
+	n := len(values)
+	if n < 2 {
+		return "", false
+	}
+	switch diff := values[n-1] - values[n-2]; {
+	case diff > 0:
+		return "up", true
+	case diff < 0:
+		return "down", true
+	}
+	return "flat", true
+}
+
+// pctFrom 价格相对参考值的偏离(%)
+func pctFrom(ref, price float64) float64 {
+	if ref <= 0 {
+		return 0
+	}
+	return (price - ref) / ref * 100
+}
+
+// pctOf 数值占价格的比例(%)
+func pctOf(value, price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	return value / price * 100
+}
+
+// FormatForPrompt 行情数据在提示词中的格式（紧凑模式输出指标摘要，否则输出包含K线和指标序列的完整数据）
+func FormatForPrompt(data *Data, lang i18n.Lang, compact bool) string {
+	if compact {
+		return FormatSummaryIn(data, lang)
+	}
+	return FormatCompactIn(data, lang)
+}
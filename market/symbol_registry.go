@@ -0,0 +1,117 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultQuoteAsset 只给出基础币种（如 "BTC"）时优先使用的计价币种
+const DefaultQuoteAsset = "USDT"
+
+// symbolSeparators AI或用户输入中常见的分隔写法（BTC/USDT、BTC-USDT、BTC USDT）
+var symbolSeparators = strings.NewReplacer("/", "", "-", "", " ", "")
+
+// symbolRegistry exchangeInfo 交易对索引（用于symbol标准化、校验和展示）
+type symbolRegistry struct {
+	bySymbol map[string]*SymbolStatus
+	byBase   map[string]*SymbolStatus // 基础币种 → 首选交易对
+	quotes   map[string]bool          // 交易所支持的计价币种
+}
+
+func newSymbolRegistry(statuses map[string]*SymbolStatus) *symbolRegistry {
+	r := &symbolRegistry{
+		bySymbol: statuses,
+		byBase:   make(map[string]*SymbolStatus),
+		quotes:   make(map[string]bool),
+	}
+	for _, s := range statuses {
+		if s.QuoteAsset != "" {
+			r.quotes[s.QuoteAsset] = true
+		}
+		if s.BaseAsset == "" {
+			continue
+		}
+		if current, ok := r.byBase[s.BaseAsset]; !ok || preferSymbol(s, current) {
+			r.byBase[s.BaseAsset] = s
+		}
+	}
+	return r
+}
+
+// preferSymbol 同一基础币种有多个交易对时的优先级：正常交易 > 永续合约 > 默认计价币种
+func preferSymbol(candidate, current *SymbolStatus) bool {
+	if candidate.IsTrading() != current.IsTrading() {
+		return candidate.IsTrading()
+	}
+	if perp := candidate.ContractType == "PERPETUAL"; perp != (current.ContractType == "PERPETUAL") {
+		return perp
+	}
+	if def := candidate.QuoteAsset == DefaultQuoteAsset; def != (current.QuoteAsset == DefaultQuoteAsset) {
+		return def
+	}
+	return candidate.Symbol < current.Symbol
+}
+
+// hasQuoteSuffix symbol是否已带有交易所支持的计价币种后缀
+func (r *symbolRegistry) hasQuoteSuffix(symbol string) bool {
+	for quote := range r.quotes {
+		if len(symbol) > len(quote) && strings.HasSuffix(symbol, quote) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadedSymbolRegistry 已加载的交易对索引（不触发网络请求，未加载时返回nil）
+func loadedSymbolRegistry() *symbolRegistry {
+	symbolStatusMu.RLock()
+	defer symbolStatusMu.RUnlock()
+	return symbolRegistryCache
+}
+
+// Normalize 标准化symbol
+// 已加载 exchangeInfo 时按交易所实际的交易对解析（"btc" → BTCUSDT，"ETH/USDC" → ETHUSDC），
+// 未加载时退化为识别USDT/USDC后缀或补全默认计价币种；币本位写法（"BTC/USD"、"BTCUSD_PERP"）统一为 BTCUSD_PERP
+func Normalize(symbol string) string {
+	symbol = symbolSeparators.Replace(strings.ToUpper(strings.TrimSpace(symbol)))
+	if symbol == "" {
+		return symbol
+	}
+	reg := loadedSymbolRegistry()
+	if reg != nil {
+		if _, ok := reg.bySymbol[symbol]; ok {
+			return symbol
+		}
+	}
+	if IsCoinMargined(symbol) {
+		return symbol
+	}
+	if strings.HasSuffix(symbol, "USD") && len(symbol) > len("USD") {
+		return strings.TrimSuffix(symbol, "USD") + CoinMarginedSuffix
+	}
+
+	if reg != nil {
+		if s, ok := reg.byBase[symbol]; ok {
+			return s.Symbol
+		}
+		if reg.hasQuoteSuffix(symbol) {
+			return symbol
+		}
+	} else if hasKnownQuoteSuffix(symbol) {
+		return symbol
+	}
+	return symbol + DefaultQuoteAsset
+}
+
+// DisplaySymbol 提示词中展示的交易对名称（如 "BTCUSDT (BTC/USDT)"，未知交易对原样返回）
+func DisplaySymbol(symbol string) string {
+	reg := loadedSymbolRegistry()
+	if reg == nil {
+		return symbol
+	}
+	s, ok := reg.bySymbol[symbol]
+	if !ok || s.BaseAsset == "" || s.QuoteAsset == "" {
+		return symbol
+	}
+	return fmt.Sprintf("%s (%s/%s)", symbol, s.BaseAsset, s.QuoteAsset)
+}
@@ -0,0 +1,153 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 交易对状态（Binance合约 exchangeInfo 中的 status 字段）
+const (
+	SymbolStatusTrading = "TRADING" // 正常交易
+)
+
+// 交易对状态监控配置
+const (
+	SymbolStatusCacheTTL    = 10 * time.Minute // exchangeInfo 缓存时间
+	DelistingAlertWindow    = 72 * time.Hour   // 距下架/交割时间小于该值时预警
+	perpetualDeliveryMarker = 4133404800000    // 永续合约的默认交割时间（2100-12-25），不视为下架
+)
+
+// SymbolStatus 交易对状态
+type SymbolStatus struct {
+	Symbol       string    `json:"symbol"`
+	Status       string    `json:"status"`        // TRADING, PENDING_TRADING, PRE_SETTLE, SETTLING, CLOSE 等
+	ContractType string    `json:"contract_type"` // PERPETUAL, CURRENT_QUARTER 等
+	DeliveryDate time.Time `json:"delivery_date"` // 交割/下架时间（永续合约为零值）
+
+	BaseAsset         string `json:"base_asset"`         // 基础币种，如 BTC
+	QuoteAsset        string `json:"quote_asset"`        // 计价币种，如 USDT / USDC
+	PricePrecision    int    `json:"price_precision"`    // 价格小数位
+	QuantityPrecision int    `json:"quantity_precision"` // 数量小数位
+}
+
+// IsTrading 是否可以正常交易
+func (s *SymbolStatus) IsTrading() bool {
+	return s.Status == SymbolStatusTrading
+}
+
+// DelistingWithin 是否将在指定时间内下架/交割
+func (s *SymbolStatus) DelistingWithin(window time.Duration) bool {
+	if s.DeliveryDate.IsZero() {
+		return false
+	}
+	return time.Until(s.DeliveryDate) <= window
+}
+
+var (
+	symbolStatusMu        sync.RWMutex
+	symbolStatusCache     map[string]*SymbolStatus
+	symbolRegistryCache   *symbolRegistry
+	symbolStatusFetchedAt time.Time
+)
+
+// GetSymbolStatuses 获取所有合约交易对的状态（带缓存，获取失败时返回上次的缓存）
+func GetSymbolStatuses() (map[string]*SymbolStatus, error) {
+	symbolStatusMu.RLock()
+	if symbolStatusCache != nil && time.Since(symbolStatusFetchedAt) < SymbolStatusCacheTTL {
+		cached := symbolStatusCache
+		symbolStatusMu.RUnlock()
+		return cached, nil
+	}
+	symbolStatusMu.RUnlock()
+
+	statuses, err := fetchSymbolStatuses()
+
+	symbolStatusMu.Lock()
+	defer symbolStatusMu.Unlock()
+	if err != nil {
+		if symbolStatusCache != nil {
+			log.Printf("⚠️  刷新交易对状态失败，使用缓存数据: %v", err)
+			return symbolStatusCache, nil
+		}
+		return nil, err
+	}
+	symbolStatusCache = statuses
+	symbolRegistryCache = newSymbolRegistry(statuses)
+	symbolStatusFetchedAt = time.Now()
+	return statuses, nil
+}
+
+// GetSymbolStatus 获取单个交易对的状态（不存在时返回false）
+func GetSymbolStatus(symbol string) (*SymbolStatus, bool) {
+	statuses, err := GetSymbolStatuses()
+	if err != nil {
+		return nil, false
+	}
+	status, ok := statuses[Normalize(symbol)]
+	return status, ok
+}
+
+// ValidateSymbol 校验交易所是否存在该交易对（exchangeInfo 获取失败时不校验）
+func ValidateSymbol(symbol string) error {
+	statuses, err := GetSymbolStatuses()
+	if err != nil {
+		return nil
+	}
+	if _, ok := statuses[symbol]; !ok {
+		return fmt.Errorf("交易所不存在交易对 %s", symbol)
+	}
+	return nil
+}
+
+// fetchSymbolStatuses 从 exchangeInfo 获取交易对状态
+func fetchSymbolStatuses() (map[string]*SymbolStatus, error) {
+	resp, err := http.Get("https://fapi.binance.com/fapi/v1/exchangeInfo")
+	if err != nil {
+		return nil, fmt.Errorf("获取交易规则失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取交易规则失败: %w", err)
+	}
+
+	var result struct {
+		Symbols []struct {
+			Symbol            string `json:"symbol"`
+			Status            string `json:"status"`
+			ContractType      string `json:"contractType"`
+			DeliveryDate      int64  `json:"deliveryDate"`
+			BaseAsset         string `json:"baseAsset"`
+			QuoteAsset        string `json:"quoteAsset"`
+			PricePrecision    int    `json:"pricePrecision"`
+			QuantityPrecision int    `json:"quantityPrecision"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析交易规则失败: %w", err)
+	}
+
+	statuses := make(map[string]*SymbolStatus, len(result.Symbols))
+	for _, s := range result.Symbols {
+		status := &SymbolStatus{
+			Symbol:            s.Symbol,
+			Status:            s.Status,
+			ContractType:      s.ContractType,
+			BaseAsset:         s.BaseAsset,
+			QuoteAsset:        s.QuoteAsset,
+			PricePrecision:    s.PricePrecision,
+			QuantityPrecision: s.QuantityPrecision,
+		}
+		if s.DeliveryDate > 0 && s.DeliveryDate < perpetualDeliveryMarker {
+			status.DeliveryDate = time.UnixMilli(s.DeliveryDate)
+		}
+		statuses[s.Symbol] = status
+	}
+	return statuses, nil
+}
@@ -0,0 +1,60 @@
+package market
+
+import "math"
+
+// Volatility 标准化的波动率指标（统一基于长周期K线计算，便于不同币种横向比较）
+type Volatility struct {
+	Interval         string  // 计算使用的K线周期
+	ATR14            float64 // 14周期ATR（价格单位）
+	ATRPct           float64 // ATR14 占当前价格的百分比
+	RealizedVolPct   float64 // 单根K线对数收益率的标准差(%)
+	AnnualizedVolPct float64 // 年化历史波动率(%)
+}
+
+// CalculateVolatility 计算币种的ATR%和历史波动率（长周期K线不足时返回nil）
+func CalculateVolatility(data *Data) *Volatility {
+	if data == nil || data.CurrentPrice <= 0 || data.LongerTermContext == nil {
+		return nil
+	}
+	lt := data.LongerTermContext
+	interval := ""
+	if len(DefaultKlineSettings) > 1 {
+		interval = DefaultKlineSettings[1].Interval
+	}
+
+	v := &Volatility{
+		Interval: interval,
+		ATR14:    lt.ATR14,
+		ATRPct:   lt.ATR14 / data.CurrentPrice * 100,
+	}
+
+	var returns []float64
+	for i := 1; i < len(lt.Klines); i++ {
+		prev, cur := lt.Klines[i-1].Close, lt.Klines[i].Close
+		if prev > 0 && cur > 0 {
+			returns = append(returns, math.Log(cur/prev))
+		}
+	}
+	if len(returns) < 2 {
+		return v
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)-1))
+	v.RealizedVolPct = stdDev * 100
+
+	// 按K线周期年化（加密货币全年无休）
+	if minutes := getIntervalMinutes(interval); minutes > 0 {
+		barsPerYear := 365 * 24 * 60 / float64(minutes)
+		v.AnnualizedVolPct = v.RealizedVolPct * math.Sqrt(barsPerYear)
+	}
+	return v
+}
@@ -28,6 +28,12 @@ type Client struct {
 	Model      string
 	Timeout    time.Duration
 	UseFullURL bool // 是否使用完整URL（不添加/chat/completions）
+
+	params GenerationParams // 生成参数（temperature/max_tokens等，故障转移时备用提供商也使用该参数）
+
+	failover *failoverState // 备用提供商及健康度（见 failover.go）
+
+	faultInjector func(response string) string // 故障注入（测试用，替换AI响应）
 }
 
 func New() *Client {
@@ -84,8 +90,52 @@ func (cfg *Client) SetClient(Client Client) {
 	cfg = &Client
 }
 
+// SetGenerationParams 设置生成参数
+func (cfg *Client) SetGenerationParams(params GenerationParams) {
+	cfg.params = params
+}
+
+// GenerationParams 实际发送的生成参数（已补齐默认值）
+func (cfg *Client) GenerationParams() GenerationParams {
+	return cfg.params.Effective()
+}
+
+// SetFaultInjector 设置故障注入函数（用于在非实盘模式下模拟AI返回乱码）
+func (cfg *Client) SetFaultInjector(injector func(response string) string) {
+	cfg.faultInjector = injector
+}
+
 // CallWithMessages 使用 system + user prompt 调用AI API（推荐）
+// 配置了备用提供商时，主提供商失败后按健康度依次故障转移
 func (cfg *Client) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	candidates := cfg.orderedCandidates()
+
+	var errs []string
+	for _, client := range candidates {
+		result, err := client.callWithRetry(cfg.params, systemPrompt, userPrompt)
+		if err == nil {
+			cfg.recordSuccess(client)
+			if cfg.faultInjector != nil {
+				result = cfg.faultInjector(result)
+			}
+			return result, nil
+		}
+
+		cfg.recordFailure(client, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", client.ProviderName(), err))
+		if len(candidates) > 1 {
+			fmt.Printf("⚠️  AI提供商 %s 调用失败，尝试下一个: %v\n", client.ProviderName(), err)
+		}
+	}
+
+	if len(errs) == 1 {
+		return "", fmt.Errorf("%s", errs[0])
+	}
+	return "", fmt.Errorf("所有AI提供商均调用失败: %s", strings.Join(errs, "; "))
+}
+
+// callWithRetry 调用单个提供商（网络错误时重试）
+func (cfg *Client) callWithRetry(params GenerationParams, systemPrompt, userPrompt string) (string, error) {
 	if cfg.APIKey == "" {
 		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
 	}
@@ -99,7 +149,7 @@ func (cfg *Client) CallWithMessages(systemPrompt, userPrompt string) (string, er
 			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt, maxRetries)
 		}
 
-		result, err := cfg.callOnce(systemPrompt, userPrompt)
+		result, err := cfg.callOnce(params, systemPrompt, userPrompt)
 		if err == nil {
 			if attempt > 1 {
 				fmt.Printf("✓ AI API重试成功\n")
@@ -125,7 +175,7 @@ func (cfg *Client) CallWithMessages(systemPrompt, userPrompt string) (string, er
 }
 
 // callOnce 单次调用AI API（内部使用）
-func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
+func (cfg *Client) callOnce(params GenerationParams, systemPrompt, userPrompt string) (string, error) {
 	// 构建 messages 数组
 	messages := []map[string]string{}
 
@@ -145,11 +195,10 @@ func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 
 	// 构建请求体
 	requestBody := map[string]interface{}{
-		"model":       cfg.Model,
-		"messages":    messages,
-		"temperature": 0.5, // 降低temperature以提高JSON格式稳定性
-		"max_tokens":  2000,
+		"model":    cfg.Model,
+		"messages": messages,
 	}
+	params.applyTo(requestBody)
 
 	// 注意：response_format 参数仅 OpenAI 支持，DeepSeek/Qwen 不支持
 	// 我们通过强化 prompt 和后处理来确保 JSON 格式正确
@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 健康度评分参数
+const (
+	healthScoreMax       = 100.0
+	healthScoreRecover   = 10.0            // 每次成功恢复的分数
+	healthScorePenalty   = 25.0            // 每次失败扣除的分数
+	healthFailureLimit   = 2               // 连续失败多少次后进入冷却
+	healthCooldownPeriod = 5 * time.Minute // 冷却时长（冷却结束后自动切回主提供商）
+	healthUnhealthyScore = 40.0            // 低于该分数视为不健康
+)
+
+// providerHealth 单个AI提供商的健康状态
+type providerHealth struct {
+	Score               float64
+	ConsecutiveFailures int
+	LastError           string
+	LastFailure         time.Time
+	CooldownUntil       time.Time
+}
+
+// failoverState 故障转移链状态（指针保存，避免Client被复制时复制锁）
+type failoverState struct {
+	mu        sync.Mutex
+	fallbacks []*Client
+	health    map[*Client]*providerHealth
+	lastUsed  string
+}
+
+// ProviderName 返回提供商和模型的可读名称，如 deepseek/deepseek-chat
+func (cfg *Client) ProviderName() string {
+	return fmt.Sprintf("%s/%s", cfg.Provider, cfg.Model)
+}
+
+// AddFallback 添加备用AI提供商（按添加顺序作为故障转移优先级）
+func (cfg *Client) AddFallback(fallback *Client) {
+	if fallback == nil || fallback == cfg {
+		return
+	}
+	state := cfg.failoverState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.fallbacks = append(state.fallbacks, fallback)
+}
+
+// LastUsedProvider 返回最近一次成功调用实际使用的提供商
+func (cfg *Client) LastUsedProvider() string {
+	state := cfg.failoverState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.lastUsed == "" {
+		return cfg.ProviderName()
+	}
+	return state.lastUsed
+}
+
+// HealthStatus 返回故障转移链中各提供商的健康状态
+func (cfg *Client) HealthStatus() []map[string]interface{} {
+	state := cfg.failoverState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	var result []map[string]interface{}
+	for i, client := range cfg.chainLocked(state) {
+		h := state.healthLocked(client)
+		result = append(result, map[string]interface{}{
+			"provider":             client.ProviderName(),
+			"primary":              i == 0,
+			"score":                h.Score,
+			"consecutive_failures": h.ConsecutiveFailures,
+			"last_error":           h.LastError,
+			"cooling_down":         time.Now().Before(h.CooldownUntil),
+		})
+	}
+	return result
+}
+
+// failoverState 懒加载故障转移状态
+func (cfg *Client) failoverState() *failoverState {
+	failoverInitMu.Lock()
+	defer failoverInitMu.Unlock()
+	if cfg.failover == nil {
+		cfg.failover = &failoverState{health: make(map[*Client]*providerHealth)}
+	}
+	return cfg.failover
+}
+
+// failoverInitMu 保护 failover 字段的懒加载
+var failoverInitMu sync.Mutex
+
+// chainLocked 返回完整的调用链（主提供商在前）
+func (cfg *Client) chainLocked(state *failoverState) []*Client {
+	return append([]*Client{cfg}, state.fallbacks...)
+}
+
+// healthLocked 获取提供商健康状态（不存在则初始化为满分）
+func (s *failoverState) healthLocked(client *Client) *providerHealth {
+	h, ok := s.health[client]
+	if !ok {
+		h = &providerHealth{Score: healthScoreMax}
+		s.health[client] = h
+	}
+	return h
+}
+
+// orderedCandidates 按优先级返回本次可尝试的提供商
+// 冷却中或评分过低的提供商排到最后，冷却结束后主提供商自动恢复首位
+func (cfg *Client) orderedCandidates() []*Client {
+	state := cfg.failoverState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	now := time.Now()
+	var healthy, degraded []*Client
+	for _, client := range cfg.chainLocked(state) {
+		h := state.healthLocked(client)
+		if now.Before(h.CooldownUntil) || h.Score < healthUnhealthyScore {
+			degraded = append(degraded, client)
+			continue
+		}
+		healthy = append(healthy, client)
+	}
+	return append(healthy, degraded...)
+}
+
+// recordSuccess 记录调用成功
+func (cfg *Client) recordSuccess(client *Client) {
+	state := cfg.failoverState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	h := state.healthLocked(client)
+	h.ConsecutiveFailures = 0
+	h.CooldownUntil = time.Time{}
+	h.Score += healthScoreRecover
+	if h.Score < healthUnhealthyScore {
+		// 冷却结束后调用成功，直接恢复为健康
+		h.Score = healthUnhealthyScore
+	}
+	if h.Score > healthScoreMax {
+		h.Score = healthScoreMax
+	}
+
+	name := client.ProviderName()
+	if state.lastUsed != "" && state.lastUsed != name {
+		if client == cfg {
+			fmt.Printf("✓ AI提供商已切回主提供商: %s\n", name)
+		} else {
+			fmt.Printf("🔀 AI提供商已切换为备用: %s\n", name)
+		}
+	}
+	state.lastUsed = name
+}
+
+// recordFailure 记录调用失败
+func (cfg *Client) recordFailure(client *Client, err error) {
+	state := cfg.failoverState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	h := state.healthLocked(client)
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	h.LastFailure = time.Now()
+	h.Score -= healthScorePenalty
+	if h.Score < 0 {
+		h.Score = 0
+	}
+	if h.ConsecutiveFailures >= healthFailureLimit {
+		h.CooldownUntil = time.Now().Add(healthCooldownPeriod)
+		fmt.Printf("⚠️  AI提供商 %s 连续失败%d次，冷却%v\n", client.ProviderName(), h.ConsecutiveFailures, healthCooldownPeriod)
+	}
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 默认生成参数（未配置时使用）
+const (
+	DefaultTemperature = 0.5 // 降低temperature以提高JSON格式稳定性
+	DefaultMaxTokens   = 2000
+)
+
+// GenerationParams AI生成参数（未设置的字段使用默认值）
+// 设置了ReasoningEffort时按o系列推理模型的参数格式请求：不发送temperature/top_p，max_tokens改为max_completion_tokens
+type GenerationParams struct {
+	Temperature     *float64 `json:"temperature,omitempty"`      // 0-2，默认0.5
+	TopP            *float64 `json:"top_p,omitempty"`            // (0,1]，默认不发送
+	MaxTokens       int      `json:"max_tokens,omitempty"`       // 默认2000
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"` // low / medium / high，空=非推理模型
+}
+
+// ParseGenerationParams 解析JSON格式的生成参数（空字符串返回零值，即全部使用默认值）
+func ParseGenerationParams(s string) (GenerationParams, error) {
+	var p GenerationParams
+	if strings.TrimSpace(s) == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		return p, fmt.Errorf("解析AI生成参数失败: %w", err)
+	}
+	return p, p.Validate()
+}
+
+// Validate 校验生成参数范围
+func (p GenerationParams) Validate() error {
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+		return fmt.Errorf("temperature 必须在0-2之间，当前: %.2f", *p.Temperature)
+	}
+	if p.TopP != nil && (*p.TopP <= 0 || *p.TopP > 1) {
+		return fmt.Errorf("top_p 必须在(0,1]之间，当前: %.2f", *p.TopP)
+	}
+	if p.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens 不能为负数，当前: %d", p.MaxTokens)
+	}
+	switch p.ReasoningEffort {
+	case "", "low", "medium", "high":
+	default:
+		return fmt.Errorf("reasoning_effort 只支持 low/medium/high，当前: %s", p.ReasoningEffort)
+	}
+	return nil
+}
+
+// Effective 补齐默认值后实际发送的参数（推理模型不发送temperature/top_p）
+func (p GenerationParams) Effective() GenerationParams {
+	if p.MaxTokens == 0 {
+		p.MaxTokens = DefaultMaxTokens
+	}
+	if p.ReasoningEffort != "" {
+		p.Temperature = nil
+		p.TopP = nil
+		return p
+	}
+	if p.Temperature == nil {
+		t := DefaultTemperature
+		p.Temperature = &t
+	}
+	return p
+}
+
+// IsZero 是否全部使用默认值
+func (p GenerationParams) IsZero() bool {
+	return p.Temperature == nil && p.TopP == nil && p.MaxTokens == 0 && p.ReasoningEffort == ""
+}
+
+// JSON 序列化为JSON（nil或全部使用默认值时返回空字符串）
+func (p *GenerationParams) JSON() string {
+	if p == nil || p.IsZero() {
+		return ""
+	}
+	data, _ := json.Marshal(p)
+	return string(data)
+}
+
+// applyTo 把生成参数写入请求体
+func (p GenerationParams) applyTo(body map[string]interface{}) {
+	p = p.Effective()
+	if p.ReasoningEffort != "" {
+		body["reasoning_effort"] = p.ReasoningEffort
+		body["max_completion_tokens"] = p.MaxTokens
+		return
+	}
+	body["temperature"] = *p.Temperature
+	if p.TopP != nil {
+		body["top_p"] = *p.TopP
+	}
+	body["max_tokens"] = p.MaxTokens
+}
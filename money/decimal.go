@@ -0,0 +1,114 @@
+// Package money 价格、数量和盈亏的十进制计算
+// 结构体和数据库仍使用float64存储，但所有乘除、取整和累加都经过十进制运算，
+// 避免 0.1+0.2 这类二进制浮点误差在仓位计算和盈亏统计中累积
+package money
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// D 把float64转换为十进制数（按最短十进制表示，0.1 → 0.1 而非 0.1000000000000000055）
+func D(v float64) decimal.Decimal {
+	return decimal.NewFromFloat(v)
+}
+
+// F 十进制数转回float64
+func F(d decimal.Decimal) float64 {
+	f, _ := d.Float64()
+	return f
+}
+
+// Mul a × b
+func Mul(a, b float64) float64 {
+	return F(D(a).Mul(D(b)))
+}
+
+// Div a / b（b为0时返回0）
+func Div(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return F(D(a).Div(D(b)))
+}
+
+// Sum 累加（用于盈亏、手续费等多笔汇总）
+func Sum(values ...float64) float64 {
+	total := decimal.Zero
+	for _, v := range values {
+		total = total.Add(D(v))
+	}
+	return F(total)
+}
+
+// FloorToStep 向下取整到步进值（下单数量取整，不会放大仓位）
+// step<=0 表示不限制
+func FloorToStep(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	s := D(step)
+	return F(D(v).Div(s).Floor().Mul(s))
+}
+
+// RoundToStep 四舍五入到步进值（价格取整到tickSize）
+func RoundToStep(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	s := D(step)
+	return F(D(v).Div(s).Round(0).Mul(s))
+}
+
+// CeilToStep 向上取整到步进值（价格按方向取整到tickSize）
+// step<=0 表示不限制
+func CeilToStep(v, step float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	s := D(step)
+	return F(D(v).Div(s).Ceil().Mul(s))
+}
+
+// RoundToPlaces 四舍五入到指定小数位
+func RoundToPlaces(v float64, places int) float64 {
+	return F(D(v).Round(int32(places)))
+}
+
+// FloorToPlaces 向下截断到指定小数位
+func FloorToPlaces(v float64, places int) float64 {
+	return F(D(v).RoundFloor(int32(places)))
+}
+
+// FormatFixed 按指定小数位格式化（四舍五入，用于提交给交易所的数量/价格字符串）
+func FormatFixed(v float64, places int) string {
+	return D(v).StringFixed(int32(places))
+}
+
+// PnL 按开平仓价计算盈亏(USDT)：多仓 (平仓价-开仓价)×数量，空仓取反
+func PnL(side string, openPrice, closePrice, quantity float64) float64 {
+	diff := D(closePrice).Sub(D(openPrice))
+	if side == "short" {
+		diff = diff.Neg()
+	}
+	return F(diff.Mul(D(quantity)))
+}
+
+// InversePnL 币本位（反向）合约盈亏，以基础币种计：多仓 张数×面值×(1/开仓价 - 1/平仓价)，空仓取反
+func InversePnL(side string, openPrice, closePrice, contracts, contractSize float64) float64 {
+	if openPrice == 0 || closePrice == 0 {
+		return 0
+	}
+	diff := decimal.NewFromInt(1).Div(D(openPrice)).Sub(decimal.NewFromInt(1).Div(D(closePrice)))
+	if side == "short" {
+		diff = diff.Neg()
+	}
+	return F(diff.Mul(D(contracts)).Mul(D(contractSize)))
+}
+
+// Pct a 占 b 的百分比（b为0时返回0）
+func Pct(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return F(D(a).Div(D(b)).Mul(decimal.NewFromInt(100)))
+}
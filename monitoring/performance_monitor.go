@@ -3,10 +3,10 @@ package monitoring
 import (
 	"fmt"
 	"log"
-	"math"
 	"sync"
 	"time"
 
+	"nofx/analytics"
 	"nofx/database"
 	"nofx/database/models"
 	"nofx/logger"
@@ -24,6 +24,7 @@ type PerformanceMonitor struct {
 	alertHandlers     []AlertHandler
 	monitoringEnabled bool
 	stopChan          chan struct{}
+	unsubscribeConfig func() // 取消配置重载监听
 }
 
 // PerformanceMetrics 性能指标
@@ -33,12 +34,15 @@ type PerformanceMetrics struct {
 	WinRate           float64 `json:"win_rate"`
 	ProfitFactor      float64 `json:"profit_factor"`
 	SharpeRatio       float64 `json:"sharpe_ratio"`
+	SortinoRatio      float64 `json:"sortino_ratio"`
+	CalmarRatio       float64 `json:"calmar_ratio"`
 	MaxDrawdown       float64 `json:"max_drawdown"`
 	CurrentDrawdown   float64 `json:"current_drawdown"`
 	
 	// 风险指标
 	VaR95             float64 `json:"var_95"`
 	VaR99             float64 `json:"var_99"`
+	DownsideDeviation float64 `json:"downside_deviation"`
 	RiskScore         int     `json:"risk_score"`         // 0-100
 	MarginUsageRate   float64 `json:"margin_usage_rate"`
 	LiquidationRisk   float64 `json:"liquidation_risk"`   // 距离强平的百分比
@@ -57,7 +61,8 @@ type PerformanceMetrics struct {
 	// 系统性能指标
 	APILatency        float64 `json:"api_latency"`        // 毫秒
 	DecisionLatency   float64 `json:"decision_latency"`   // 毫秒
-	ErrorRate         float64 `json:"error_rate"`         // 百分比
+	ErrorRate         float64 `json:"error_rate"`         // 百分比（交易所执行失败的动作 / 执行的动作）
+	ErrorClasses      map[string]int `json:"error_classes,omitempty"` // 执行失败按原因分类的次数
 	SystemUptime      float64 `json:"system_uptime"`      // 小时
 	
 	// 时间戳
@@ -107,6 +112,7 @@ func NewPerformanceMonitor(traderID string, db *database.DB, logger *logger.Deci
 		db:                db,
 		logger:            logger,
 		metrics:           &PerformanceMetrics{},
+		runtimeConfig:     database.LoadRuntimeConfig(),
 		alerts:            make([]Alert, 0),
 		alertHandlers:     make([]AlertHandler, 0),
 		monitoringEnabled: false,
@@ -124,6 +130,7 @@ func (pm *PerformanceMonitor) Start() {
 	}
 	
 	pm.monitoringEnabled = true
+	pm.unsubscribeConfig = database.OnRuntimeConfigReload(pm.SetRuntimeConfig)
 	log.Printf("🔍 [%s] 性能监控器启动", pm.traderID)
 	
 	// 启动监控协程
@@ -140,15 +147,41 @@ func (pm *PerformanceMonitor) Stop() {
 	}
 	
 	pm.monitoringEnabled = false
+	if pm.unsubscribeConfig != nil {
+		pm.unsubscribeConfig()
+		pm.unsubscribeConfig = nil
+	}
 	close(pm.stopChan)
 	log.Printf("🔍 [%s] 性能监控器停止", pm.traderID)
 }
 
+// SetRuntimeConfig 设置运行时配置（nil时使用默认值配置），配置热重载时自动调用
+func (pm *PerformanceMonitor) SetRuntimeConfig(rc *database.RuntimeConfig) {
+	if rc == nil {
+		rc = database.NewDefaultRuntimeConfig()
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.runtimeConfig = rc
+}
+
+// config 获取当前运行时配置（调用方需持有锁），未设置时回退到默认值配置
+func (pm *PerformanceMonitor) config() *database.RuntimeConfig {
+	if pm.runtimeConfig == nil {
+		pm.runtimeConfig = database.NewDefaultRuntimeConfig()
+	}
+	return pm.runtimeConfig
+}
+
 // monitoringLoop 监控循环
 func (pm *PerformanceMonitor) monitoringLoop() {
 	ticker := time.NewTicker(30 * time.Second) // 每30秒更新一次
 	defer ticker.Stop()
 	
+	// 启动后立即更新一次，避免API在首个周期前返回空指标
+	pm.updateMetrics()
+	pm.checkAlerts()
+	
 	for {
 		select {
 		case <-ticker.C:
@@ -165,8 +198,12 @@ func (pm *PerformanceMonitor) updateMetrics() {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 	
+	if pm.db == nil || pm.logger == nil {
+		return
+	}
+	
 	// 从配置获取查询限制
-	queryLimits := pm.runtimeConfig.GetQueryLimits()
+	queryLimits := pm.config().GetQueryLimits()
 	
 	// 获取交易表现分析
 	performance, err := pm.logger.AnalyzePerformance(queryLimits.PerformanceLimit)
@@ -187,12 +224,17 @@ func (pm *PerformanceMonitor) updateMetrics() {
 	pm.metrics.WinRate = performance.WinRate
 	pm.metrics.ProfitFactor = performance.ProfitFactor
 	pm.metrics.SharpeRatio = performance.SharpeRatio
+	pm.metrics.SortinoRatio = performance.SortinoRatio
+	pm.metrics.CalmarRatio = performance.CalmarRatio
 	
 	// 计算风险指标
 	pm.calculateRiskMetrics(records)
 	
 	// 计算交易频率指标
 	pm.calculateTradingFrequencyMetrics(records)
+
+	// 计算执行错误率（按错误分类统计）
+	pm.calculateErrorMetrics(records)
 	
 	// 更新时间戳
 	pm.metrics.LastUpdated = time.Now()
@@ -201,126 +243,36 @@ func (pm *PerformanceMonitor) updateMetrics() {
 		pm.traderID, pm.metrics.WinRate, pm.metrics.SharpeRatio, pm.metrics.RiskScore)
 }
 
-// calculateRiskMetrics 计算风险指标
+// calculateRiskMetrics 计算风险指标（回撤和VaR使用 analytics 包的统一实现）
 func (pm *PerformanceMonitor) calculateRiskMetrics(records []*models.DecisionRecord) {
 	if len(records) == 0 {
 		return
 	}
 	
-	// 计算最大回撤
-	var maxBalance, minBalance float64
-	var balances []float64
-	
-	for i, record := range records {
-		balance := record.TotalBalance
-		balances = append(balances, balance)
-		
-		if i == 0 {
-			maxBalance = balance
-			minBalance = balance
-		} else {
-			if balance > maxBalance {
-				maxBalance = balance
-			}
-			if balance < minBalance {
-				minBalance = balance
-			}
+	// 回撤和收益率扣除入金/出金，避免资金划转被当作盈亏
+	var ledger *analytics.FlowLedger
+	if pm.db != nil {
+		if flows, err := pm.db.BalanceFlow().GetAll(); err == nil {
+			ledger = analytics.NewFlowLedger(flows)
 		}
 	}
-	
-	// 计算最大回撤
-	pm.metrics.MaxDrawdown = pm.calculateMaxDrawdown(balances)
-	
-	// 计算当前回撤
-	if len(balances) > 0 {
-		currentBalance := balances[len(balances)-1]
-		pm.metrics.CurrentBalance = currentBalance
-		pm.metrics.CurrentDrawdown = (maxBalance - currentBalance) / maxBalance * 100
-	}
-	
-	// 计算VaR
-	pm.calculateVaR(balances)
+	summary := analytics.Summarize(analytics.EquityCurveNetOfFlows(records, ledger))
+	pm.metrics.MaxDrawdown = summary.MaxDrawdownPct
+	pm.metrics.CurrentDrawdown = summary.CurrentDrawdownPct
+	pm.metrics.CurrentBalance = summary.CurrentEquity + ledger.NetAt(records[len(records)-1].Timestamp) // 实际净值（含入金/出金）
+	pm.metrics.DownsideDeviation = summary.DownsideDeviation
+	pm.metrics.VaR95 = summary.VaR95
+	pm.metrics.VaR99 = summary.VaR99
 	
 	// 计算风险评分
 	pm.calculateRiskScore(records)
 }
 
-// calculateMaxDrawdown 计算最大回撤
-func (pm *PerformanceMonitor) calculateMaxDrawdown(balances []float64) float64 {
-	if len(balances) < 2 {
-		return 0
-	}
-	
-	var maxDrawdown float64
-	peak := balances[0]
-	
-	for _, balance := range balances {
-		if balance > peak {
-			peak = balance
-		}
-		
-		drawdown := (peak - balance) / peak * 100
-		if drawdown > maxDrawdown {
-			maxDrawdown = drawdown
-		}
-	}
-	
-	return maxDrawdown
-}
-
-// calculateVaR 计算风险价值
-func (pm *PerformanceMonitor) calculateVaR(balances []float64) {
-	if len(balances) < 10 {
-		return
-	}
-	
-	// 计算收益率序列
-	returns := make([]float64, len(balances)-1)
-	for i := 1; i < len(balances); i++ {
-		returns[i-1] = (balances[i] - balances[i-1]) / balances[i-1]
-	}
-	
-	// 计算VaR (简化版本，使用正态分布假设)
-	mean := pm.calculateMean(returns)
-	std := pm.calculateStd(returns, mean)
-	
-	// VaR95 = mean - 1.645 * std
-	// VaR99 = mean - 2.326 * std
-	pm.metrics.VaR95 = math.Abs(mean - 1.645*std) * pm.metrics.CurrentBalance
-	pm.metrics.VaR99 = math.Abs(mean - 2.326*std) * pm.metrics.CurrentBalance
-}
-
-// calculateMean 计算均值
-func (pm *PerformanceMonitor) calculateMean(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	
-	sum := 0.0
-	for _, v := range values {
-		sum += v
-	}
-	return sum / float64(len(values))
-}
-
-// calculateStd 计算标准差
-func (pm *PerformanceMonitor) calculateStd(values []float64, mean float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	
-	sumSquares := 0.0
-	for _, v := range values {
-		sumSquares += (v - mean) * (v - mean)
-	}
-	return math.Sqrt(sumSquares / float64(len(values)))
-}
-
 // calculateRiskScore 计算风险评分 (0-100)
 func (pm *PerformanceMonitor) calculateRiskScore(records []*models.DecisionRecord) {
 	// 获取风险阈值和评分配置
-	thresholds := pm.runtimeConfig.GetRiskThresholds()
-	scores := pm.runtimeConfig.GetRiskScores()
+	thresholds := pm.config().GetRiskThresholds()
+	scores := pm.config().GetRiskScores()
 	
 	if len(records) == 0 {
 		pm.metrics.RiskScore = 50
@@ -388,13 +340,33 @@ func (pm *PerformanceMonitor) calculateTradingFrequencyMetrics(records []*models
 	}
 }
 
+// calculateErrorMetrics 统计监控窗口内决策动作的执行错误率和错误分类
+func (pm *PerformanceMonitor) calculateErrorMetrics(records []*models.DecisionRecord) {
+	if len(records) == 0 {
+		return
+	}
+	stats, err := pm.db.Decision().GetActionErrorStats(records[0].Timestamp)
+	if err != nil {
+		log.Printf("⚠️ [%s] 统计执行错误失败: %v", pm.traderID, err)
+		return
+	}
+	pm.metrics.ErrorRate = 0
+	if stats.Total > 0 {
+		pm.metrics.ErrorRate = float64(stats.Failed) / float64(stats.Total) * 100
+	}
+	pm.metrics.ErrorClasses = make(map[string]int, len(stats.Classes))
+	for _, c := range stats.Classes {
+		pm.metrics.ErrorClasses[c.Class] = c.Count
+	}
+}
+
 // checkAlerts 检查预警条件
 func (pm *PerformanceMonitor) checkAlerts() {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 	
 	// 获取风险阈值配置
-	thresholds := pm.runtimeConfig.GetRiskThresholds()
+	thresholds := pm.config().GetRiskThresholds()
 	
 	// 检查风险预警
 	pm.checkRiskAlerts(thresholds)
@@ -514,7 +486,7 @@ func (pm *PerformanceMonitor) checkSystemAlerts(thresholds database.RiskThreshol
 			Type:      AlertTypeSystem,
 			Level:     AlertLevelWarning,
 			Title:     "系统错误率过高",
-			Message:   fmt.Sprintf("错误率 %.1f%%，系统可能存在问题", pm.metrics.ErrorRate),
+			Message:   fmt.Sprintf("错误率 %.1f%%，系统可能存在问题（错误分类: %v）", pm.metrics.ErrorRate, pm.metrics.ErrorClasses),
 			Timestamp: time.Now(),
 		})
 	}
@@ -543,6 +515,25 @@ func (pm *PerformanceMonitor) addAlert(alert Alert) {
 	log.Printf("🚨 [%s] %s: %s - %s", pm.traderID, alert.Level, alert.Title, alert.Message)
 }
 
+// RaiseAlert 由外部模块发出预警（如待审批的大额交易），不做同类型去重
+func (pm *PerformanceMonitor) RaiseAlert(alert Alert) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if alert.Timestamp.IsZero() {
+		alert.Timestamp = time.Now()
+	}
+	pm.alerts = append(pm.alerts, alert)
+	for _, handler := range pm.alertHandlers {
+		go func(h AlertHandler, a Alert) {
+			if err := h.HandleAlert(a); err != nil {
+				log.Printf("⚠️ [%s] 预警处理失败: %v", pm.traderID, err)
+			}
+		}(handler, alert)
+	}
+	log.Printf("🚨 [%s] %s: %s - %s", pm.traderID, alert.Level, alert.Title, alert.Message)
+}
+
 // GetMetrics 获取性能指标
 func (pm *PerformanceMonitor) GetMetrics() *PerformanceMetrics {
 	pm.mu.RLock()
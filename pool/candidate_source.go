@@ -0,0 +1,98 @@
+package pool
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 候选币种来源
+const (
+	SourcePool   = "pool"   // 币种池服务（AI500 + OI Top）
+	SourceStatic = "static" // 仅使用配置的默认币种列表，不请求币种池服务
+)
+
+// CandidateSource 候选币种来源（每个Trader选择一个）
+type CandidateSource interface {
+	Name() string
+	GetCandidates() (*MergedCoinPool, error)
+}
+
+// PoolSource 从币种池服务获取候选币种（AI500前N + OI Top，去重）
+type PoolSource struct {
+	AI500Limit int
+	Client     *Client // Trader独立的币种池客户端（nil=全局配置）
+}
+
+// Name 来源名称
+func (s *PoolSource) Name() string {
+	return SourcePool
+}
+
+// GetCandidates 获取候选币种
+func (s *PoolSource) GetCandidates() (*MergedCoinPool, error) {
+	if s.Client != nil {
+		return s.Client.GetMergedCoinPool(s.AI500Limit)
+	}
+	return GetMergedCoinPool(s.AI500Limit)
+}
+
+// StaticSource 静态币种列表：不访问任何币种池服务，直接使用给定币种（为空时使用默认币种列表）
+type StaticSource struct {
+	Symbols []string
+}
+
+// Name 来源名称
+func (s *StaticSource) Name() string {
+	return SourceStatic
+}
+
+// GetCandidates 获取候选币种（保持列表顺序，去重）
+func (s *StaticSource) GetCandidates() (*MergedCoinPool, error) {
+	symbols := s.Symbols
+	if len(symbols) == 0 {
+		// 每次读取，热重载后的默认币种列表立即生效
+		symbols = defaultMainstreamCoins
+	}
+
+	merged := &MergedCoinPool{
+		SymbolSources: make(map[string][]string),
+	}
+	for _, symbol := range symbols {
+		symbol = normalizeSymbol(symbol)
+		if symbol == "" || merged.SymbolSources[symbol] != nil {
+			continue
+		}
+		merged.AllSymbols = append(merged.AllSymbols, symbol)
+		merged.SymbolSources[symbol] = []string{"default"}
+	}
+	if len(merged.AllSymbols) == 0 {
+		return nil, fmt.Errorf("默认币种列表为空")
+	}
+	merged.AI500Coins = convertSymbolsToCoins(merged.AllSymbols)
+
+	log.Printf("📊 使用静态币种列表: 共%d个币种", len(merged.AllSymbols))
+	return merged, nil
+}
+
+// NewCandidateSource 按名称创建候选币种来源
+// name为空时跟随全局 use_default_coins 配置（配置了独立币种池API的客户端始终使用币种池）
+func NewCandidateSource(name string, ai500Limit int, client *Client) (CandidateSource, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		if coinPoolConfig.UseDefaultCoins && (client == nil || !client.HasOverrides()) {
+			name = SourceStatic
+		} else {
+			name = SourcePool
+		}
+	}
+
+	switch name {
+	case SourcePool:
+		return &PoolSource{AI500Limit: ai500Limit, Client: client}, nil
+	case SourceStatic:
+		return &StaticSource{}, nil
+	default:
+		return nil, fmt.Errorf("未知的候选币种来源: %s（可选: %s/%s）", name, SourcePool, SourceStatic)
+	}
+}
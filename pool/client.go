@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientConfig 币种池客户端配置（每个Trader可独立配置，留空的URL使用全局配置）
+type ClientConfig struct {
+	CoinPoolAPIURL  string        // AI500币种池API
+	OITopAPIURL     string        // OI Top API
+	AuthHeader      string        // 认证头："Bearer xxx"（作为Authorization）或 "X-API-Key: xxx"
+	RefreshInterval time.Duration // 刷新间隔（间隔内复用上次结果，0=每次都请求）
+	CacheDir        string        // 缓存目录（空=全局缓存目录）
+}
+
+// Client 币种池客户端：每个Trader持有独立实例，可同时交易完全不同的币种范围
+type Client struct {
+	config          ClientConfig
+	useDefaultCoins bool // 仅全局客户端跟随 use_default_coins（Trader级别由候选币种来源控制）
+
+	mu             sync.Mutex
+	coins          []CoinInfo
+	coinsFetchedAt time.Time
+	oiPositions    []OIPosition
+	oiFetchedAt    time.Time
+}
+
+// NewClient 创建币种池客户端
+func NewClient(config ClientConfig) *Client {
+	return &Client{config: config}
+}
+
+// globalClient 使用全局配置（SetCoinPoolAPI/SetOITopAPI）的客户端
+func globalClient() *Client {
+	return &Client{useDefaultCoins: coinPoolConfig.UseDefaultCoins}
+}
+
+// HasOverrides 是否配置了独立的币种池API（否则与全局配置共享同一币种范围）
+func (c *Client) HasOverrides() bool {
+	return c.config.CoinPoolAPIURL != "" || c.config.OITopAPIURL != ""
+}
+
+// coinPoolURL 当前生效的AI500币种池API
+func (c *Client) coinPoolURL() string {
+	if c.config.CoinPoolAPIURL != "" {
+		return c.config.CoinPoolAPIURL
+	}
+	return coinPoolConfig.APIURL
+}
+
+// oiTopURL 当前生效的OI Top API
+func (c *Client) oiTopURL() string {
+	if c.config.OITopAPIURL != "" {
+		return c.config.OITopAPIURL
+	}
+	return oiTopConfig.APIURL
+}
+
+// cacheDir 缓存目录（独立配置的客户端使用独立目录，避免互相覆盖）
+func (c *Client) cacheDir() string {
+	if c.config.CacheDir != "" {
+		return c.config.CacheDir
+	}
+	return coinPoolConfig.CacheDir
+}
+
+// get 发起GET请求（withAuth=true 时携带认证头，认证头只发给Trader自己配置的API，不会泄露给全局API）
+func (c *Client) get(url string, withAuth bool) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if withAuth && c.config.AuthHeader != "" {
+		name, value := parseAuthHeader(c.config.AuthHeader)
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{
+		Timeout: coinPoolConfig.Timeout,
+	}
+	return client.Do(req)
+}
+
+// parseAuthHeader 解析认证头配置："Name: value" 使用指定头名，否则作为 Authorization 的值
+func parseAuthHeader(header string) (string, string) {
+	if name, value, ok := strings.Cut(header, ":"); ok {
+		name = strings.TrimSpace(name)
+		if name != "" && !strings.ContainsAny(name, " \t") {
+			return name, strings.TrimSpace(value)
+		}
+	}
+	return "Authorization", strings.TrimSpace(header)
+}
@@ -91,20 +91,39 @@ func SetDefaultCoins(coins []string) {
 	}
 }
 
-// GetCoinPool 获取币种池列表（带重试和缓存机制）
+// GetDefaultCoins 获取默认主流币种列表（副本）
+func GetDefaultCoins() []string {
+	return append([]string(nil), defaultMainstreamCoins...)
+}
+
+// GetCoinPool 获取币种池列表（全局配置）
 func GetCoinPool() ([]CoinInfo, error) {
+	return globalClient().GetCoinPool()
+}
+
+// GetCoinPool 获取币种池列表（带重试和缓存机制）
+func (c *Client) GetCoinPool() ([]CoinInfo, error) {
 	// 优先检查是否启用默认币种列表
-	if coinPoolConfig.UseDefaultCoins {
+	if c.useDefaultCoins {
 		log.Printf("✓ 已启用默认主流币种列表")
 		return convertSymbolsToCoins(defaultMainstreamCoins), nil
 	}
 
 	// 检查API URL是否配置
-	if strings.TrimSpace(coinPoolConfig.APIURL) == "" {
+	if strings.TrimSpace(c.coinPoolURL()) == "" {
 		log.Printf("⚠️  未配置币种池API URL，使用默认主流币种列表")
 		return convertSymbolsToCoins(defaultMainstreamCoins), nil
 	}
 
+	// 刷新间隔内直接使用内存中的结果
+	c.mu.Lock()
+	if c.coins != nil && time.Since(c.coinsFetchedAt) < c.config.RefreshInterval {
+		coins := c.coins
+		c.mu.Unlock()
+		return coins, nil
+	}
+	c.mu.Unlock()
+
 	maxRetries := 3
 	var lastErr error
 
@@ -115,15 +134,19 @@ func GetCoinPool() ([]CoinInfo, error) {
 			time.Sleep(2 * time.Second) // 重试前等待2秒
 		}
 
-		coins, err := fetchCoinPool()
+		coins, err := c.fetchCoinPool()
 		if err == nil {
 			if attempt > 1 {
 				log.Printf("✓ 第%d次重试成功", attempt)
 			}
 			// 成功获取后保存到缓存
-			if err := saveCoinPoolCache(coins); err != nil {
+			if err := c.saveCoinPoolCache(coins); err != nil {
 				log.Printf("⚠️  保存币种池缓存失败: %v", err)
 			}
+			c.mu.Lock()
+			c.coins = coins
+			c.coinsFetchedAt = time.Now()
+			c.mu.Unlock()
 			return coins, nil
 		}
 
@@ -133,7 +156,7 @@ func GetCoinPool() ([]CoinInfo, error) {
 
 	// API获取失败，尝试使用缓存
 	log.Printf("⚠️  API请求全部失败，尝试使用历史缓存数据...")
-	cachedCoins, err := loadCoinPoolCache()
+	cachedCoins, err := c.loadCoinPoolCache()
 	if err == nil {
 		log.Printf("✓ 使用历史缓存数据（共%d个币种）", len(cachedCoins))
 		return cachedCoins, nil
@@ -145,14 +168,10 @@ func GetCoinPool() ([]CoinInfo, error) {
 }
 
 // fetchCoinPool 实际执行币种池请求
-func fetchCoinPool() ([]CoinInfo, error) {
+func (c *Client) fetchCoinPool() ([]CoinInfo, error) {
 	log.Printf("🔄 正在请求AI500币种池...")
 
-	client := &http.Client{
-		Timeout: coinPoolConfig.Timeout,
-	}
-
-	resp, err := client.Get(coinPoolConfig.APIURL)
+	resp, err := c.get(c.coinPoolURL(), c.config.CoinPoolAPIURL != "")
 	if err != nil {
 		return nil, fmt.Errorf("请求币种池API失败: %w", err)
 	}
@@ -192,9 +211,9 @@ func fetchCoinPool() ([]CoinInfo, error) {
 }
 
 // saveCoinPoolCache 保存币种池到缓存文件
-func saveCoinPoolCache(coins []CoinInfo) error {
+func (c *Client) saveCoinPoolCache(coins []CoinInfo) error {
 	// 确保缓存目录存在
-	if err := os.MkdirAll(coinPoolConfig.CacheDir, 0755); err != nil {
+	if err := os.MkdirAll(c.cacheDir(), 0755); err != nil {
 		return fmt.Errorf("创建缓存目录失败: %w", err)
 	}
 
@@ -209,7 +228,7 @@ func saveCoinPoolCache(coins []CoinInfo) error {
 		return fmt.Errorf("序列化缓存数据失败: %w", err)
 	}
 
-	cachePath := filepath.Join(coinPoolConfig.CacheDir, "latest.json")
+	cachePath := filepath.Join(c.cacheDir(), "latest.json")
 	if err := ioutil.WriteFile(cachePath, data, 0644); err != nil {
 		return fmt.Errorf("写入缓存文件失败: %w", err)
 	}
@@ -219,8 +238,8 @@ func saveCoinPoolCache(coins []CoinInfo) error {
 }
 
 // loadCoinPoolCache 从缓存文件加载币种池
-func loadCoinPoolCache() ([]CoinInfo, error) {
-	cachePath := filepath.Join(coinPoolConfig.CacheDir, "latest.json")
+func (c *Client) loadCoinPoolCache() ([]CoinInfo, error) {
+	cachePath := filepath.Join(c.cacheDir(), "latest.json")
 
 	// 检查文件是否存在
 	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
@@ -273,9 +292,14 @@ func GetAvailableCoins() ([]string, error) {
 	return symbols, nil
 }
 
-// GetTopRatedCoins 获取评分最高的N个币种（按评分从大到小排序）
+// GetTopRatedCoins 获取评分最高的N个币种（全局配置）
 func GetTopRatedCoins(limit int) ([]string, error) {
-	coins, err := GetCoinPool()
+	return globalClient().GetTopRatedCoins(limit)
+}
+
+// GetTopRatedCoins 获取评分最高的N个币种（按评分从大到小排序）
+func (c *Client) GetTopRatedCoins(limit int) ([]string, error) {
+	coins, err := c.GetCoinPool()
 	if err != nil {
 		return nil, err
 	}
@@ -324,8 +348,8 @@ func normalizeSymbol(symbol string) string {
 	// 转为大写
 	symbol = toUpper(symbol)
 
-	// 确保以USDT结尾
-	if !endsWith(symbol, "USDT") {
+	// 确保带有计价后缀（USDT/USDC本位或币本位 _PERP），只有基础币种时补全USDT
+	if !endsWith(symbol, "USDT") && !endsWith(symbol, "USDC") && !endsWith(symbol, "_PERP") {
 		symbol = symbol + "USDT"
 	}
 
@@ -418,14 +442,28 @@ var oiTopConfig = struct {
 	CacheDir: "coin_pool_cache",
 }
 
-// GetOITopPositions 获取持仓量增长Top20数据（带重试和缓存）
+// GetOITopPositions 获取持仓量增长Top20数据（全局配置）
 func GetOITopPositions() ([]OIPosition, error) {
+	return globalClient().GetOITopPositions()
+}
+
+// GetOITopPositions 获取持仓量增长Top20数据（带重试和缓存）
+func (c *Client) GetOITopPositions() ([]OIPosition, error) {
 	// 检查API URL是否配置
-	if strings.TrimSpace(oiTopConfig.APIURL) == "" {
+	if strings.TrimSpace(c.oiTopURL()) == "" {
 		log.Printf("⚠️  未配置OI Top API URL，跳过OI Top数据获取")
 		return []OIPosition{}, nil // 返回空列表，不是错误
 	}
 
+	// 刷新间隔内直接使用内存中的结果
+	c.mu.Lock()
+	if c.oiPositions != nil && time.Since(c.oiFetchedAt) < c.config.RefreshInterval {
+		positions := c.oiPositions
+		c.mu.Unlock()
+		return positions, nil
+	}
+	c.mu.Unlock()
+
 	maxRetries := 3
 	var lastErr error
 
@@ -436,15 +474,19 @@ func GetOITopPositions() ([]OIPosition, error) {
 			time.Sleep(2 * time.Second)
 		}
 
-		positions, err := fetchOITop()
+		positions, err := c.fetchOITop()
 		if err == nil {
 			if attempt > 1 {
 				log.Printf("✓ 第%d次重试成功", attempt)
 			}
 			// 成功获取后保存到缓存
-			if err := saveOITopCache(positions); err != nil {
+			if err := c.saveOITopCache(positions); err != nil {
 				log.Printf("⚠️  保存OI Top缓存失败: %v", err)
 			}
+			c.mu.Lock()
+			c.oiPositions = positions
+			c.oiFetchedAt = time.Now()
+			c.mu.Unlock()
 			return positions, nil
 		}
 
@@ -454,7 +496,7 @@ func GetOITopPositions() ([]OIPosition, error) {
 
 	// API获取失败，尝试使用缓存
 	log.Printf("⚠️  OI Top API请求全部失败，尝试使用历史缓存数据...")
-	cachedPositions, err := loadOITopCache()
+	cachedPositions, err := c.loadOITopCache()
 	if err == nil {
 		log.Printf("✓ 使用历史OI Top缓存数据（共%d个币种）", len(cachedPositions))
 		return cachedPositions, nil
@@ -466,14 +508,10 @@ func GetOITopPositions() ([]OIPosition, error) {
 }
 
 // fetchOITop 实际执行OI Top请求
-func fetchOITop() ([]OIPosition, error) {
+func (c *Client) fetchOITop() ([]OIPosition, error) {
 	log.Printf("🔄 正在请求OI Top数据...")
 
-	client := &http.Client{
-		Timeout: oiTopConfig.Timeout,
-	}
-
-	resp, err := client.Get(oiTopConfig.APIURL)
+	resp, err := c.get(c.oiTopURL(), c.config.OITopAPIURL != "")
 	if err != nil {
 		return nil, fmt.Errorf("请求OI Top API失败: %w", err)
 	}
@@ -508,8 +546,8 @@ func fetchOITop() ([]OIPosition, error) {
 }
 
 // saveOITopCache 保存OI Top数据到缓存
-func saveOITopCache(positions []OIPosition) error {
-	if err := os.MkdirAll(oiTopConfig.CacheDir, 0755); err != nil {
+func (c *Client) saveOITopCache(positions []OIPosition) error {
+	if err := os.MkdirAll(c.cacheDir(), 0755); err != nil {
 		return fmt.Errorf("创建缓存目录失败: %w", err)
 	}
 
@@ -524,7 +562,7 @@ func saveOITopCache(positions []OIPosition) error {
 		return fmt.Errorf("序列化OI Top缓存数据失败: %w", err)
 	}
 
-	cachePath := filepath.Join(oiTopConfig.CacheDir, "oi_top_latest.json")
+	cachePath := filepath.Join(c.cacheDir(), "oi_top_latest.json")
 	if err := ioutil.WriteFile(cachePath, data, 0644); err != nil {
 		return fmt.Errorf("写入OI Top缓存文件失败: %w", err)
 	}
@@ -534,8 +572,8 @@ func saveOITopCache(positions []OIPosition) error {
 }
 
 // loadOITopCache 从缓存加载OI Top数据
-func loadOITopCache() ([]OIPosition, error) {
-	cachePath := filepath.Join(oiTopConfig.CacheDir, "oi_top_latest.json")
+func (c *Client) loadOITopCache() ([]OIPosition, error) {
+	cachePath := filepath.Join(c.cacheDir(), "oi_top_latest.json")
 
 	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("OI Top缓存文件不存在")
@@ -563,9 +601,14 @@ func loadOITopCache() ([]OIPosition, error) {
 	return cache.Positions, nil
 }
 
-// GetOITopSymbols 获取OI Top的币种符号列表
+// GetOITopSymbols 获取OI Top的币种符号列表（全局配置）
 func GetOITopSymbols() ([]string, error) {
-	positions, err := GetOITopPositions()
+	return globalClient().GetOITopSymbols()
+}
+
+// GetOITopSymbols 获取OI Top的币种符号列表
+func (c *Client) GetOITopSymbols() ([]string, error) {
+	positions, err := c.GetOITopPositions()
 	if err != nil {
 		return nil, err
 	}
@@ -587,17 +630,22 @@ type MergedCoinPool struct {
 	SymbolSources map[string][]string // 每个币种的来源（"ai500"/"oi_top"）
 }
 
-// GetMergedCoinPool 获取合并后的币种池（AI500 + OI Top，去重）
+// GetMergedCoinPool 获取合并后的币种池（全局配置）
 func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
+	return globalClient().GetMergedCoinPool(ai500Limit)
+}
+
+// GetMergedCoinPool 获取合并后的币种池（AI500 + OI Top，去重）
+func (c *Client) GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
 	// 1. 获取AI500数据
-	ai500TopSymbols, err := GetTopRatedCoins(ai500Limit)
+	ai500TopSymbols, err := c.GetTopRatedCoins(ai500Limit)
 	if err != nil {
 		log.Printf("⚠️  获取AI500数据失败: %v", err)
 		ai500TopSymbols = []string{} // 失败时用空列表
 	}
 
 	// 2. 获取OI Top数据
-	oiTopSymbols, err := GetOITopSymbols()
+	oiTopSymbols, err := c.GetOITopSymbols()
 	if err != nil {
 		log.Printf("⚠️  获取OI Top数据失败: %v", err)
 		oiTopSymbols = []string{} // 失败时用空列表
@@ -628,8 +676,8 @@ func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
 	}
 
 	// 获取完整数据
-	ai500Coins, _ := GetCoinPool()
-	oiTopPositions, _ := GetOITopPositions()
+	ai500Coins, _ := c.GetCoinPool()
+	oiTopPositions, _ := c.GetOITopPositions()
 
 	merged := &MergedCoinPool{
 		AI500Coins:    ai500Coins,
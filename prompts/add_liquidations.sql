@@ -0,0 +1,11 @@
+-- 添加爆仓监控section（用户提示词，爆仓统计由系统动态生成）
+INSERT INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type) VALUES
+('liquidations', '💥 爆仓监控', 
+'## 💥 爆仓监控
+
+全市场强平订单统计：多头集中爆仓往往伴随急跌（可能是恐慌底部，也可能继续踩踏），空头集中爆仓往往伴随轧空急涨。连环爆仓期间价格波动剧烈、滑点大，不要追单。
+（统计数据由系统动态生成）',
+1, -- 默认启用
+3, -- 显示顺序（在市场数据之前）
+'user'
+);
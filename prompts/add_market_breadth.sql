@@ -0,0 +1,11 @@
+-- 添加市场广度section（用户提示词，统计数据由系统动态生成）
+INSERT INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type) VALUES
+('market_breadth', '🌐 市场广度', 
+'## 🌐 市场广度
+
+全市场视角的参考（不要只看BTCUSDT的走势）：BTC市值占比上升时山寨币通常跑输BTC；EMA50上方币种比例反映普涨/普跌；全市场OI上升代表新资金入场，OI下降代表去杠杆。
+（统计数据由系统动态生成）',
+1, -- 默认启用
+3, -- 显示顺序（在市场数据之前）
+'user'
+);
@@ -0,0 +1,11 @@
+-- 添加日风险预算section（用户提示词，内容中的占用明细由系统动态生成）
+INSERT INTO prompt_configs (section_name, title, content, enabled, display_order, prompt_type) VALUES
+('risk_budget', '🎯 风险预算', 
+'## 🎯 风险预算
+
+每笔开仓按 |入场价-止损价| × 数量 占用日风险预算，平仓后释放；当日已实现亏损计入已用预算，每日零点重置。
+（预算余额和占用明细由系统动态生成）',
+1, -- 默认启用
+10, -- 显示顺序（在其他sections之后）
+'user'
+);
@@ -0,0 +1,65 @@
+package sharedstate
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// killSwitchKey 全局停止开关（开启后所有进程的trader都不再执行交易周期）
+const killSwitchKey = "flag:kill_switch"
+
+// pauseKey trader暂停标记
+func pauseKey(traderID string) string {
+	return "flag:pause:" + traderID
+}
+
+// setFlag 设置或清除标记
+func setFlag(key string, on bool) error {
+	store := Current()
+	if !on {
+		return store.Delete(key)
+	}
+	return store.Set(key, []byte(time.Now().UTC().Format(time.RFC3339)), 0)
+}
+
+// flagSet 标记是否已设置（存储不可用时视为未设置，并记录警告）
+func flagSet(key string) bool {
+	_, ok, err := Current().Get(key)
+	if err != nil {
+		log.Printf("⚠️ 读取共享标记 %s 失败: %v", key, err)
+		return false
+	}
+	return ok
+}
+
+// SetKillSwitch 开启或关闭全局停止开关
+func SetKillSwitch(on bool) error {
+	if err := setFlag(killSwitchKey, on); err != nil {
+		return fmt.Errorf("设置全局停止开关失败: %w", err)
+	}
+	if on {
+		log.Printf("🛑 全局停止开关已开启（%s）", Current().Backend())
+	} else {
+		log.Printf("▶️  全局停止开关已关闭（%s）", Current().Backend())
+	}
+	return nil
+}
+
+// KillSwitchActive 全局停止开关是否开启
+func KillSwitchActive() bool {
+	return flagSet(killSwitchKey)
+}
+
+// SetPaused 设置trader的共享暂停标记（其他进程中的同一trader也会暂停）
+func SetPaused(traderID string, paused bool) error {
+	if err := setFlag(pauseKey(traderID), paused); err != nil {
+		return fmt.Errorf("设置共享暂停标记失败: %w", err)
+	}
+	return nil
+}
+
+// IsPaused trader是否被（任意进程）暂停
+func IsPaused(traderID string) bool {
+	return flagSet(pauseKey(traderID))
+}
@@ -0,0 +1,43 @@
+package sharedstate
+
+import (
+	"log"
+	"strconv"
+	"time"
+)
+
+// Allow 固定窗口限流：当前窗口内的请求数不超过limit时放行（limit<=0表示不限流）
+// 使用Redis时多个进程共享同一计数，合计请求数受限
+func Allow(key string, limit int, window time.Duration) (bool, error) {
+	if limit <= 0 || window <= 0 {
+		return true, nil
+	}
+	bucket := time.Now().UnixMilli() / window.Milliseconds()
+	count, err := Current().IncrWindow("ratelimit:"+key+":"+strconv.FormatInt(bucket, 10), window)
+	if err != nil {
+		return true, err
+	}
+	return count <= int64(limit), nil
+}
+
+// Wait 等待限流放行，最多等待maxWait（存储不可用时直接放行，不阻塞业务）
+func Wait(key string, limit int, window, maxWait time.Duration) bool {
+	deadline := time.Now().Add(maxWait)
+	for {
+		ok, err := Allow(key, limit, window)
+		if err != nil {
+			log.Printf("⚠️ 限流计数 %s 失败，直接放行: %v", key, err)
+			return true
+		}
+		if ok {
+			return true
+		}
+		// 等到下一个窗口开始
+		now := time.Now()
+		next := now.Truncate(window).Add(window)
+		if next.After(deadline) {
+			return false
+		}
+		time.Sleep(next.Sub(now))
+	}
+}
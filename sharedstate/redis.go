@@ -0,0 +1,247 @@
+package sharedstate
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// incrWindowScript 计数器加1，首次创建时设置过期时间（原子执行，避免进程在两条命令之间退出留下永不过期的计数）
+const incrWindowScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return n`
+
+// redisTimeout 单条命令的读写超时
+const redisTimeout = 3 * time.Second
+
+// RedisStore 基于Redis的共享状态存储（RESP协议，单连接串行执行，断线后下次调用自动重连）
+type RedisStore struct {
+	addr     string
+	password string
+	db       int
+	prefix   string                   // 键前缀，多套部署共用一个Redis时区分
+	dial     func() (net.Conn, error) // 建立连接（nil表示TCP连接addr，测试中替换为net.Pipe）
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedisStore 连接Redis并检查连通性
+func NewRedisStore(addr, password string, db int, prefix string) (*RedisStore, error) {
+	r := &RedisStore{addr: addr, password: password, db: db, prefix: prefix}
+	if _, err := r.do("PING"); err != nil {
+		return nil, fmt.Errorf("连接Redis %s 失败: %w", addr, err)
+	}
+	return r, nil
+}
+
+// redisError Redis返回的错误回复（连接本身正常，不需要重连）
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// dialLocked 建立连接并完成认证和选库（调用方需持有锁）
+func (r *RedisStore) dialLocked() error {
+	dial := r.dial
+	if dial == nil {
+		dial = func() (net.Conn, error) { return net.DialTimeout("tcp", r.addr, redisTimeout) }
+	}
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	r.rd = bufio.NewReader(conn)
+	if r.password != "" {
+		if _, err := r.roundTripLocked("AUTH", r.password); err != nil {
+			r.closeLocked()
+			return fmt.Errorf("认证失败: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := r.roundTripLocked("SELECT", strconv.Itoa(r.db)); err != nil {
+			r.closeLocked()
+			return fmt.Errorf("选择数据库%d失败: %w", r.db, err)
+		}
+	}
+	return nil
+}
+
+// closeLocked 关闭当前连接（调用方需持有锁）
+func (r *RedisStore) closeLocked() {
+	if r.conn != nil {
+		r.conn.Close()
+	}
+	r.conn = nil
+	r.rd = nil
+}
+
+// idempotentCommands 重复执行结果不变的命令（读取回复失败时可以重发）
+var idempotentCommands = map[string]bool{"PING": true, "GET": true, "SET": true, "DEL": true}
+
+// do 执行一条命令，网络错误时重连后重试一次
+// 命令已发出但读取回复失败时，服务端可能已经执行过，只有幂等命令才重发（EVAL计数重发会重复计数）
+func (r *RedisStore) do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		if r.conn == nil {
+			if err := r.dialLocked(); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		if err := r.writeLocked(args...); err != nil {
+			// 命令未完整发出，服务端不会执行，重连后重发是安全的
+			r.closeLocked()
+			lastErr = err
+			continue
+		}
+		reply, err := readReply(r.rd)
+		var replyErr redisError
+		if err == nil || errors.As(err, &replyErr) {
+			return reply, err
+		}
+		r.closeLocked()
+		if !idempotentCommands[args[0]] {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// roundTripLocked 发送命令并读取回复（调用方需持有锁）
+func (r *RedisStore) roundTripLocked(args ...string) (interface{}, error) {
+	if err := r.writeLocked(args...); err != nil {
+		return nil, err
+	}
+	return readReply(r.rd)
+}
+
+// writeLocked 发送一条命令（调用方需持有锁）
+func (r *RedisStore) writeLocked(args ...string) error {
+	r.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	_, err := r.conn.Write(buf)
+	return err
+}
+
+// readReply 解析一条RESP回复（nil表示空值）
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("无效的Redis回复: %q", line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("未知的Redis回复类型: %q", line)
+}
+
+// Get 读取键值
+func (r *RedisStore) Get(key string) ([]byte, bool, error) {
+	reply, err := r.do("GET", r.prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("GET %s 返回了非字符串值", key)
+	}
+	return value, true, nil
+}
+
+// Set 写入键值
+func (r *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", r.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+// Delete 删除键
+func (r *RedisStore) Delete(key string) error {
+	_, err := r.do("DEL", r.prefix+key)
+	return err
+}
+
+// IncrWindow 固定窗口计数器
+func (r *RedisStore) IncrWindow(key string, window time.Duration) (int64, error) {
+	reply, err := r.do("EVAL", incrWindowScript, "1", r.prefix+key, strconv.FormatInt(window.Milliseconds(), 10))
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("计数器 %s 返回了非整数值", key)
+	}
+	return count, nil
+}
+
+// Backend 存储类型
+func (r *RedisStore) Backend() string {
+	return "redis(" + r.addr + ")"
+}
+
+// Close 关闭连接
+func (r *RedisStore) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeLocked()
+	return nil
+}
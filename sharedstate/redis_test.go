@@ -0,0 +1,237 @@
+package sharedstate
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{"simple", "+OK\r\n", "OK"},
+		{"integer", ":42\r\n", int64(42)},
+		{"bulk", "$5\r\nhello\r\n", []byte("hello")},
+		{"empty_bulk", "$0\r\n\r\n", []byte{}},
+		{"nil_bulk", "$-1\r\n", nil},
+		{"array", "*3\r\n$3\r\nfoo\r\n:7\r\n$-1\r\n", []interface{}{[]byte("foo"), int64(7), nil}},
+		{"nested_array", "*1\r\n*1\r\n+OK\r\n", []interface{}{[]interface{}{"OK"}}},
+		{"nil_array", "*-1\r\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReply(bufio.NewReader(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readReply = %#v, 期望 %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadReplyErrors(t *testing.T) {
+	reply, err := readReply(bufio.NewReader(strings.NewReader("-ERR unknown command\r\n")))
+	var replyErr redisError
+	if reply != nil || !errors.As(err, &replyErr) || string(replyErr) != "ERR unknown command" {
+		t.Errorf("错误回复应返回redisError: %v, %v", reply, err)
+	}
+
+	for _, input := range []string{"+OK\n", "?x\r\n", "$5\r\nhi\r\n", "*2\r\n:1\r\n", ":abc\r\n"} {
+		if _, err := readReply(bufio.NewReader(strings.NewReader(input))); err == nil || errors.As(err, &replyErr) {
+			t.Errorf("%q 应返回解析错误: %v", input, err)
+		}
+	}
+}
+
+// fakeRedis 基于net.Pipe的Redis服务端：记录收到的命令，按handler返回回复
+type fakeRedis struct {
+	mu       sync.Mutex
+	dials    int
+	commands []string
+	// handler 返回RESP回复；drop=true时不回复直接断开，closeAfter=true时回复后断开
+	handler func(cmd []string) (reply string, drop, closeAfter bool)
+}
+
+func (f *fakeRedis) dial() (net.Conn, error) {
+	client, server := net.Pipe()
+	f.mu.Lock()
+	f.dials++
+	f.mu.Unlock()
+	go f.serve(server)
+	return client, nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		req, err := readReply(rd)
+		if err != nil {
+			return
+		}
+		var cmd []string
+		for _, arg := range req.([]interface{}) {
+			cmd = append(cmd, string(arg.([]byte)))
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd[0])
+		f.mu.Unlock()
+
+		reply, drop, closeAfter := f.handler(cmd)
+		if drop {
+			return
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil || closeAfter {
+			return
+		}
+	}
+}
+
+// count 收到的指定命令次数
+func (f *fakeRedis) count(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.commands {
+		if c == name {
+			n++
+		}
+	}
+	return n
+}
+
+func newFakeRedisStore(t *testing.T, f *fakeRedis) *RedisStore {
+	t.Helper()
+	r := &RedisStore{addr: "pipe", prefix: "test:", dial: f.dial}
+	if _, err := r.do("PING"); err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRedisStoreCommands(t *testing.T) {
+	values := map[string]string{}
+	f := &fakeRedis{handler: func(cmd []string) (string, bool, bool) {
+		switch cmd[0] {
+		case "PING":
+			return "+PONG\r\n", false, false
+		case "SET":
+			values[cmd[1]] = cmd[2]
+			return "+OK\r\n", false, false
+		case "GET":
+			if v, ok := values[cmd[1]]; ok {
+				return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v), false, false
+			}
+			return "$-1\r\n", false, false
+		case "EVAL":
+			return ":1\r\n", false, false
+		}
+		return "-ERR unknown command '" + cmd[0] + "'\r\n", false, false
+	}}
+	r := newFakeRedisStore(t, f)
+
+	if err := r.Set("k", []byte("hello"), time.Minute); err != nil {
+		t.Fatalf("SET失败: %v", err)
+	}
+	value, ok, err := r.Get("k")
+	if err != nil || !ok || string(value) != "hello" {
+		t.Fatalf("GET = %q, %v, %v", value, ok, err)
+	}
+	if _, ok, err := r.Get("missing"); err != nil || ok {
+		t.Fatalf("不存在的键应返回ok=false: %v, %v", ok, err)
+	}
+	if n, err := r.IncrWindow("c", time.Minute); err != nil || n != 1 {
+		t.Fatalf("IncrWindow = %d, %v", n, err)
+	}
+
+	// 错误回复不断开连接，也不重试
+	var replyErr redisError
+	if err := r.Delete("k"); !errors.As(err, &replyErr) {
+		t.Fatalf("DEL应返回Redis错误回复: %v", err)
+	}
+	if f.count("DEL") != 1 || f.dials != 1 {
+		t.Errorf("错误回复不应重连或重发: DEL %d次, 连接%d次", f.count("DEL"), f.dials)
+	}
+}
+
+func TestRedisStoreDoesNotResendIncrWindow(t *testing.T) {
+	evals := 0
+	f := &fakeRedis{handler: func(cmd []string) (string, bool, bool) {
+		if cmd[0] == "EVAL" {
+			evals++
+			// 第一次执行后连接断开，客户端收不到回复
+			return fmt.Sprintf(":%d\r\n", evals), evals == 1, false
+		}
+		return "+PONG\r\n", false, false
+	}}
+	r := newFakeRedisStore(t, f)
+
+	if _, err := r.IncrWindow("c", time.Minute); err == nil {
+		t.Fatal("读取回复失败时应返回错误")
+	}
+	if f.count("EVAL") != 1 {
+		t.Fatalf("计数命令可能已执行，不应重发: EVAL %d次", f.count("EVAL"))
+	}
+
+	// 下次调用重新连接
+	if n, err := r.IncrWindow("c", time.Minute); err != nil || n != 2 {
+		t.Fatalf("重连后IncrWindow = %d, %v", n, err)
+	}
+	if f.dials != 2 {
+		t.Errorf("应重新连接一次，实际连接%d次", f.dials)
+	}
+}
+
+func TestRedisStoreRetriesIdempotentRead(t *testing.T) {
+	gets := 0
+	f := &fakeRedis{handler: func(cmd []string) (string, bool, bool) {
+		if cmd[0] == "GET" {
+			gets++
+			return "$2\r\nok\r\n", gets == 1, false
+		}
+		return "+PONG\r\n", false, false
+	}}
+	r := newFakeRedisStore(t, f)
+
+	value, ok, err := r.Get("k")
+	if err != nil || !ok || string(value) != "ok" {
+		t.Fatalf("GET应在重连后重试成功: %q, %v, %v", value, ok, err)
+	}
+	if f.count("GET") != 2 || f.dials != 2 {
+		t.Errorf("GET %d次, 连接%d次", f.count("GET"), f.dials)
+	}
+}
+
+func TestRedisStoreRetriesUnsentCommand(t *testing.T) {
+	f := &fakeRedis{}
+	f.handler = func(cmd []string) (string, bool, bool) {
+		if cmd[0] == "EVAL" {
+			return ":1\r\n", false, false
+		}
+		// 第一个连接回复PING后断开，后续命令写入失败
+		f.mu.Lock()
+		first := f.dials == 1
+		f.mu.Unlock()
+		return "+PONG\r\n", false, first
+	}
+	r := newFakeRedisStore(t, f)
+
+	if n, err := r.IncrWindow("c", time.Minute); err != nil || n != 1 {
+		t.Fatalf("命令未发出时应重连后重发: %d, %v", n, err)
+	}
+	if f.count("EVAL") != 1 || f.dials != 2 {
+		t.Errorf("EVAL %d次, 连接%d次", f.count("EVAL"), f.dials)
+	}
+}
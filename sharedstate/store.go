@@ -0,0 +1,152 @@
+package sharedstate
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store 共享状态存储（默认为进程内存；配置Redis后多个进程/主机共享行情缓存、限流计数和暂停/停止开关）
+type Store interface {
+	// Get 读取键值（不存在或已过期时返回false）
+	Get(key string) ([]byte, bool, error)
+	// Set 写入键值，ttl<=0 表示不过期
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete 删除键
+	Delete(key string) error
+	// IncrWindow 计数器加1并返回当前值，计数器首次创建时设置window后过期（固定窗口限流）
+	IncrWindow(key string, window time.Duration) (int64, error)
+	// Backend 存储类型（memory / redis）
+	Backend() string
+	// Close 释放连接
+	Close() error
+}
+
+var (
+	currentMu sync.RWMutex
+	current   Store = NewMemoryStore()
+)
+
+// Use 替换当前共享状态存储（由main在启动时根据配置调用）
+func Use(store Store) {
+	if store == nil {
+		return
+	}
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = store
+	log.Printf("✓ 共享状态存储: %s", store.Backend())
+}
+
+// Current 当前共享状态存储
+func Current() Store {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current
+}
+
+// memoryEntry 内存存储的键值
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // 零值表示不过期
+}
+
+// MemoryStore 进程内存存储（单进程部署的默认实现）
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore 创建进程内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// getLocked 读取未过期的键值（调用方需持有锁）
+func (m *MemoryStore) getLocked(key string, now time.Time) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// Get 读取键值
+func (m *MemoryStore) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.getLocked(key, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set 写入键值
+func (m *MemoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+	m.pruneLocked()
+	return nil
+}
+
+// Delete 删除键
+func (m *MemoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// IncrWindow 固定窗口计数器
+func (m *MemoryStore) IncrWindow(key string, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	// 与Redis的INCR一致，计数以十进制字符串保存
+	var count int64
+	entry, ok := m.getLocked(key, now)
+	if ok {
+		count, _ = strconv.ParseInt(string(entry.value), 10, 64)
+	} else {
+		entry = memoryEntry{expiresAt: now.Add(window)}
+	}
+	count++
+	entry.value = []byte(strconv.FormatInt(count, 10))
+	m.entries[key] = entry
+	m.pruneLocked()
+	return count, nil
+}
+
+// pruneLocked 键数量较多时清理已过期的键（调用方需持有锁）
+func (m *MemoryStore) pruneLocked() {
+	if len(m.entries) < 1024 {
+		return
+	}
+	now := time.Now()
+	for key, entry := range m.entries {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// Backend 存储类型
+func (m *MemoryStore) Backend() string {
+	return "memory"
+}
+
+// Close 内存存储无需释放
+func (m *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,193 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/mcp"
+	"strings"
+	"time"
+)
+
+// 切换模型时的连通性测试Prompt
+const (
+	aiModelTestSystemPrompt = "这是一次连通性测试，请只回复 OK。"
+	aiModelTestUserPrompt   = "OK?"
+)
+
+// AIModelSwitch 运行时切换AI模型的请求（空字段沿用trader已配置的值）
+type AIModelSwitch struct {
+	AIModel      string `json:"ai_model"`       // deepseek / qwen / custom
+	APIKey       string `json:"api_key"`        // 对应提供商的API密钥
+	CustomAPIURL string `json:"custom_api_url"` // 自定义API地址（custom）
+	ModelName    string `json:"model_name"`     // 模型名称（custom必填；deepseek/qwen可选，覆盖默认模型）
+}
+
+// AIModelSwitchResult 切换结果
+type AIModelSwitchResult struct {
+	From          string `json:"from"`            // 切换前的提供商/模型
+	To            string `json:"to"`              // 切换后的提供商/模型
+	TestLatencyMs int64  `json:"test_latency_ms"` // 连通性测试耗时(毫秒)
+	TestResponse  string `json:"test_response"`   // 连通性测试的模型回复
+	RecordID      int64  `json:"record_id"`       // 记录切换事件的决策记录ID（写入失败时为0）
+}
+
+// newPrimaryAIClient 按配置创建主AI客户端
+func newPrimaryAIClient(config AutoTraderConfig) *mcp.Client {
+	client := mcp.New()
+	if config.AIModel == "custom" {
+		// 使用自定义API
+		client.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
+		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+		return client
+	}
+	if config.UseQwen || config.AIModel == "qwen" {
+		// 使用Qwen
+		client.SetQwenAPIKey(config.QwenKey, "")
+		log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
+	} else {
+		// 默认使用DeepSeek
+		client.SetDeepSeekAPIKey(config.DeepSeekKey)
+		log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+	}
+	if config.AIModelName != "" {
+		client.Model = config.AIModelName
+	}
+	return client
+}
+
+// addAIFallbacks 为主AI客户端配置备用提供商（主提供商连续失败时自动故障转移，恢复后自动切回）
+func addAIFallbacks(client *mcp.Client, config AutoTraderConfig) {
+	primaryIsQwen := config.AIModel == "qwen" || (config.UseQwen && config.AIModel != "custom")
+	if config.DeepSeekKey != "" && (config.AIModel == "custom" || primaryIsQwen) {
+		fallback := mcp.New()
+		fallback.SetDeepSeekAPIKey(config.DeepSeekKey)
+		client.AddFallback(fallback)
+		log.Printf("🔀 [%s] 备用AI提供商: %s", config.Name, fallback.ProviderName())
+	}
+	if config.QwenKey != "" && !primaryIsQwen {
+		fallback := mcp.New()
+		fallback.SetQwenAPIKey(config.QwenKey, "")
+		client.AddFallback(fallback)
+		log.Printf("🔀 [%s] 备用AI提供商: %s", config.Name, fallback.ProviderName())
+	}
+}
+
+// aiClient 当前使用的AI客户端
+func (at *AutoTrader) aiClient() *mcp.Client {
+	at.aiMu.RLock()
+	defer at.aiMu.RUnlock()
+	return at.mcpClient
+}
+
+// apply 把切换请求合并到trader配置
+func (req AIModelSwitch) apply(config *AutoTraderConfig) error {
+	switch req.AIModel {
+	case "deepseek":
+		if req.APIKey != "" {
+			config.DeepSeekKey = req.APIKey
+		}
+		if config.DeepSeekKey == "" {
+			return fmt.Errorf("切换到deepseek需要提供DeepSeek密钥")
+		}
+		config.UseQwen = false
+		config.AIModelName = req.ModelName
+	case "qwen":
+		if req.APIKey != "" {
+			config.QwenKey = req.APIKey
+		}
+		if config.QwenKey == "" {
+			return fmt.Errorf("切换到qwen需要提供Qwen密钥")
+		}
+		config.UseQwen = true
+		config.AIModelName = req.ModelName
+	case "custom":
+		if req.APIKey != "" {
+			config.CustomAPIKey = req.APIKey
+		}
+		if req.CustomAPIURL != "" {
+			config.CustomAPIURL = req.CustomAPIURL
+		}
+		if req.ModelName != "" {
+			config.CustomModelName = req.ModelName
+		}
+		if config.CustomAPIURL == "" || config.CustomAPIKey == "" || config.CustomModelName == "" {
+			return fmt.Errorf("切换到custom需要提供自定义API地址、密钥和模型名称")
+		}
+		config.UseQwen = false
+	default:
+		return fmt.Errorf("不支持的AI模型: %s（可选 deepseek/qwen/custom）", req.AIModel)
+	}
+	config.AIModel = req.AIModel
+	return nil
+}
+
+// SwitchAIModel 运行时切换AI模型（无需重启）
+// 新客户端先做一次连通性测试，测试通过后等待当前交易周期结束再替换，并在决策日志中记录切换事件
+func (at *AutoTrader) SwitchAIModel(req AIModelSwitch) (*AIModelSwitchResult, error) {
+	req.AIModel = strings.ToLower(strings.TrimSpace(req.AIModel))
+	at.aiMu.RLock()
+	config := at.config
+	at.aiMu.RUnlock()
+	if err := req.apply(&config); err != nil {
+		return nil, err
+	}
+
+	// 只测试新的主提供商（测试通过后才配置备用提供商，避免故障转移掩盖连接失败）
+	client := newPrimaryAIClient(config)
+	client.SetGenerationParams(config.AIParams)
+	start := time.Now()
+	response, err := client.CallWithMessages(aiModelTestSystemPrompt, aiModelTestUserPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("新模型连通性测试失败: %w", err)
+	}
+	result := &AIModelSwitchResult{
+		To:            client.ProviderName(),
+		TestLatencyMs: time.Since(start).Milliseconds(),
+		TestResponse:  strings.TrimSpace(response),
+	}
+	addAIFallbacks(client, config)
+	if at.chaosEnabled() {
+		client.SetFaultInjector(chaosAIResponse)
+	}
+
+	// 等待当前交易周期结束，保证一个周期内只使用同一个模型
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	at.aiMu.Lock()
+	result.From = at.mcpClient.ProviderName()
+	at.mcpClient = client
+	at.aiModel = config.AIModel
+	at.config.AIModel = config.AIModel
+	at.config.UseQwen = config.UseQwen
+	at.config.AIModelName = config.AIModelName
+	at.config.DeepSeekKey = config.DeepSeekKey
+	at.config.QwenKey = config.QwenKey
+	at.config.CustomAPIURL = config.CustomAPIURL
+	at.config.CustomAPIKey = config.CustomAPIKey
+	at.config.CustomModelName = config.CustomModelName
+	at.aiMu.Unlock()
+
+	// 旧模型的决策不再复用
+	at.promptCache = decision.NewPromptCache()
+
+	log.Printf("🔁 [%s] AI模型已切换: %s → %s（连通性测试 %dms）", at.name, result.From, result.To, result.TestLatencyMs)
+
+	// 记录切换事件（之后的决策记录按ai_provider归因到新模型）
+	record := &logger.DecisionRecord{
+		Success:    true,
+		Manual:     true,
+		AIProvider: result.To,
+		CoTTrace:   fmt.Sprintf("🔁 AI模型切换: %s → %s", result.From, result.To),
+		ExecutionLog: []string{
+			fmt.Sprintf("✓ 连通性测试通过（%dms）", result.TestLatencyMs),
+		},
+	}
+	if err := at.decisionLogger.LogDecision(record); err != nil {
+		log.Printf("⚠ 保存模型切换记录失败: %v", err)
+	}
+	result.RecordID = record.ID
+	return result, nil
+}
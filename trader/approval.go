@@ -0,0 +1,279 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/monitoring"
+	"nofx/sharedstate"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 审批状态
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalExpired  = "expired"
+	ApprovalFailed   = "failed" // 审批通过但执行失败
+)
+
+// approvalHistoryLimit 保留的已处理审批数量
+const approvalHistoryLimit = 100
+
+// PendingApproval 等待人工审批的决策
+type PendingApproval struct {
+	ID          string            `json:"id"`
+	Decision    decision.Decision `json:"decision"`
+	Reason      string            `json:"reason"` // 触发审批的原因
+	NotionalUSD float64           `json:"notional_usd"`
+	RiskUSD     float64           `json:"risk_usd"`
+	CycleNumber int               `json:"cycle_number"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	ResolvedAt  *time.Time        `json:"resolved_at,omitempty"`
+}
+
+// approvalQueue 待审批队列（内存保存，重启后待审批的决策作废）
+type approvalQueue struct {
+	mu    sync.Mutex
+	items []*PendingApproval
+	seq   int
+}
+
+// approvalConfig 获取审批配置（全局配置未初始化时不启用）
+func approvalConfig() database.ApprovalConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetApprovalConfig()
+	}
+	return database.ApprovalConfig{TimeoutMinutes: 10}
+}
+
+// approvalReason 判断开仓决策是否需要人工审批，返回原因、名义价值和估算的止损风险
+func (at *AutoTrader) approvalReason(d *decision.Decision, cfg database.ApprovalConfig) (string, float64, float64) {
+	if !cfg.Enabled || (d.Action != "open_long" && d.Action != "open_short") {
+		return "", 0, 0
+	}
+
+	notional := d.NotionalUSD
+	risk := d.RiskUSD
+	if data, ok := at.lastMarketData[d.Symbol]; ok && data != nil && data.CurrentPrice > 0 && d.StopLoss > 0 {
+		risk = notional * math.Abs(data.CurrentPrice-d.StopLoss) / data.CurrentPrice
+	}
+
+	var reasons []string
+	if cfg.NotionalThreshold > 0 && notional > cfg.NotionalThreshold {
+		reasons = append(reasons, fmt.Sprintf("名义价值%.2f > %.2f USDT", notional, cfg.NotionalThreshold))
+	}
+	if cfg.RiskThreshold > 0 && risk > cfg.RiskThreshold {
+		reasons = append(reasons, fmt.Sprintf("止损风险%.2f > %.2f USDT", risk, cfg.RiskThreshold))
+	}
+	return strings.Join(reasons, "，"), notional, risk
+}
+
+// parkForApproval 超过阈值的开仓决策放入待审批队列（返回nil表示无需审批，可以直接执行）
+func (at *AutoTrader) parkForApproval(d *decision.Decision) *PendingApproval {
+	cfg := approvalConfig()
+	reason, notional, risk := at.approvalReason(d, cfg)
+	if reason == "" {
+		return nil
+	}
+
+	timeout := time.Duration(cfg.TimeoutMinutes) * time.Minute
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+
+	at.approvals.mu.Lock()
+	at.approvals.seq++
+	now := time.Now()
+	pending := &PendingApproval{
+		ID:          fmt.Sprintf("%d-%d", now.Unix(), at.approvals.seq),
+		Decision:    *d,
+		Reason:      reason,
+		NotionalUSD: notional,
+		RiskUSD:     risk,
+		CycleNumber: at.callCount,
+		Status:      ApprovalPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(timeout),
+	}
+	at.approvals.items = append(at.approvals.items, pending)
+	at.approvals.mu.Unlock()
+
+	log.Printf("[%s] ⏸️ %s %s 需人工审批（%s），审批ID=%s，%.0f分钟内有效",
+		at.name, d.Symbol, d.Action, reason, pending.ID, timeout.Minutes())
+	if at.monitor != nil {
+		at.monitor.RaiseAlert(monitoring.Alert{
+			ID:      "approval_" + pending.ID,
+			Type:    monitoring.AlertTypeTrade,
+			Level:   monitoring.AlertLevelWarning,
+			Title:   "大额交易待审批",
+			Message: fmt.Sprintf("%s %s（%s），审批ID=%s，%s前有效", d.Symbol, d.Action, reason, pending.ID, pending.ExpiresAt.Format("15:04:05")),
+		})
+	}
+	return pending
+}
+
+// expireApprovals 把超时未审批的决策标记为过期，并记录为跳过
+func (at *AutoTrader) expireApprovals() {
+	now := time.Now()
+	var expired []*PendingApproval
+
+	at.approvals.mu.Lock()
+	for _, p := range at.approvals.items {
+		if p.Status == ApprovalPending && now.After(p.ExpiresAt) {
+			p.Status = ApprovalExpired
+			resolvedAt := now
+			p.ResolvedAt = &resolvedAt
+			copied := *p
+			expired = append(expired, &copied)
+		}
+	}
+	at.approvals.pruneLocked()
+	at.approvals.mu.Unlock()
+
+	for _, p := range expired {
+		log.Printf("[%s] ⌛ 审批超时，决策已跳过: %s %s (ID=%s)", at.name, p.Decision.Symbol, p.Decision.Action, p.ID)
+		msg := fmt.Sprintf("人工审批超时（%s），决策已跳过", p.Reason)
+		record := &logger.DecisionRecord{
+			ExecutionLog: []string{fmt.Sprintf("⌛ %s %s %s", p.Decision.Symbol, p.Decision.Action, msg)},
+			Success:      false,
+			Manual:       true,
+			ErrorMessage: msg,
+			CoTTrace:     fmt.Sprintf("⏸️ 等待审批的AI决策 #%s（周期 #%d）\n%s", p.ID, p.CycleNumber, p.Decision.Reasoning),
+			Decisions: []logger.DecisionAction{{
+				Action:    p.Decision.Action,
+				Symbol:    p.Decision.Symbol,
+				Leverage:  p.Decision.Leverage,
+				Timestamp: now,
+				Error:     msg,
+			}},
+		}
+		if err := at.decisionLogger.LogDecision(record); err != nil {
+			log.Printf("⚠ 保存决策记录失败: %v", err)
+		}
+	}
+}
+
+// pruneLocked 只保留最近的已处理审批（调用方需持有锁）
+func (q *approvalQueue) pruneLocked() {
+	resolved := 0
+	for _, p := range q.items {
+		if p.Status != ApprovalPending {
+			resolved++
+		}
+	}
+	if resolved <= approvalHistoryLimit {
+		return
+	}
+	drop := resolved - approvalHistoryLimit
+	kept := q.items[:0]
+	for _, p := range q.items {
+		if p.Status != ApprovalPending && drop > 0 {
+			drop--
+			continue
+		}
+		kept = append(kept, p)
+	}
+	q.items = kept
+}
+
+// takePending 取出待审批的决策并标记为已处理（不存在、已处理或已过期时返回错误）
+func (at *AutoTrader) takePending(id, status string) (*PendingApproval, error) {
+	at.approvals.mu.Lock()
+	defer at.approvals.mu.Unlock()
+
+	for _, p := range at.approvals.items {
+		if p.ID != id {
+			continue
+		}
+		if p.Status != ApprovalPending {
+			return nil, fmt.Errorf("审批 %s 已处理（%s）", id, p.Status)
+		}
+		now := time.Now()
+		if now.After(p.ExpiresAt) {
+			return nil, fmt.Errorf("审批 %s 已超时", id)
+		}
+		p.Status = status
+		p.ResolvedAt = &now
+		copied := *p
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("审批 %s 不存在", id)
+}
+
+// setApprovalResult 记录审批通过后的执行结果
+func (at *AutoTrader) setApprovalResult(id string, err error) {
+	if err == nil {
+		return
+	}
+	at.approvals.mu.Lock()
+	defer at.approvals.mu.Unlock()
+	for _, p := range at.approvals.items {
+		if p.ID == id {
+			p.Status = ApprovalFailed
+			p.Error = err.Error()
+		}
+	}
+}
+
+// ApproveDecision 操作员审批通过，按当前账户状态重新验证后执行
+func (at *AutoTrader) ApproveDecision(id string) (*logger.DecisionRecord, error) {
+	at.expireApprovals()
+	if sharedstate.KillSwitchActive() {
+		return nil, fmt.Errorf("全局停止开关已开启，暂不能执行审批通过的决策")
+	}
+
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	pending, err := at.takePending(id, ApprovalApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[%s] ✅ 审批通过: %s %s (ID=%s)", at.name, pending.Decision.Symbol, pending.Decision.Action, id)
+	d := pending.Decision
+	cotTrace := fmt.Sprintf("✅ 操作员审批通过AI决策 #%s（周期 #%d，%s）\n%s", id, pending.CycleNumber, pending.Reason, d.Reasoning)
+	record, err := at.executeOperatorDecision(&d, cotTrace)
+	at.setApprovalResult(id, err)
+	return record, err
+}
+
+// RejectDecision 操作员拒绝待审批的决策
+func (at *AutoTrader) RejectDecision(id string) error {
+	pending, err := at.takePending(id, ApprovalRejected)
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] 🚫 审批拒绝: %s %s (ID=%s)", at.name, pending.Decision.Symbol, pending.Decision.Action, id)
+	return nil
+}
+
+// GetApprovals 获取审批队列（待审批的在前，其余按时间倒序）
+func (at *AutoTrader) GetApprovals() []PendingApproval {
+	at.expireApprovals()
+
+	at.approvals.mu.Lock()
+	defer at.approvals.mu.Unlock()
+
+	pending := []PendingApproval{}
+	var resolved []PendingApproval
+	for i := len(at.approvals.items) - 1; i >= 0; i-- {
+		p := *at.approvals.items[i]
+		if p.Status == ApprovalPending {
+			pending = append(pending, p)
+		} else {
+			resolved = append(resolved, p)
+		}
+	}
+	return append(pending, resolved...)
+}
@@ -9,10 +9,12 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"math/big"
 	"net/http"
 	"net/url"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/money"
 	"sort"
 	"strconv"
 	"strings"
@@ -44,6 +46,9 @@ type SymbolPrecision struct {
 	QuantityPrecision int
 	TickSize          float64 // 价格步进值
 	StepSize          float64 // 数量步进值
+	MinQty            float64 // 最小下单数量
+	MaxQty            float64 // 最大下单数量
+	MinNotional       float64 // 最小名义价值
 }
 
 // NewAsterTrader 创建Aster交易器
@@ -130,6 +135,16 @@ func (t *AsterTrader) getPrecision(symbol string) (SymbolPrecision, error) {
 				if stepSizeStr, ok := filter["stepSize"].(string); ok {
 					prec.StepSize, _ = strconv.ParseFloat(stepSizeStr, 64)
 				}
+				if minQtyStr, ok := filter["minQty"].(string); ok {
+					prec.MinQty, _ = strconv.ParseFloat(minQtyStr, 64)
+				}
+				if maxQtyStr, ok := filter["maxQty"].(string); ok {
+					prec.MaxQty, _ = strconv.ParseFloat(maxQtyStr, 64)
+				}
+			case "MIN_NOTIONAL":
+				if notionalStr, ok := filter["notional"].(string); ok {
+					prec.MinNotional, _ = strconv.ParseFloat(notionalStr, 64)
+				}
 			}
 		}
 
@@ -146,15 +161,7 @@ func (t *AsterTrader) getPrecision(symbol string) (SymbolPrecision, error) {
 
 // roundToTickSize 将价格/数量四舍五入到tick size/step size的整数倍
 func roundToTickSize(value float64, tickSize float64) float64 {
-	if tickSize <= 0 {
-		return value
-	}
-	// 计算有多少个tick size
-	steps := value / tickSize
-	// 四舍五入到最近的整数
-	roundedSteps := math.Round(steps)
-	// 乘回tick size
-	return roundedSteps * tickSize
+	return money.RoundToStep(value, tickSize)
 }
 
 // formatPrice 格式化价格到正确精度和tick size
@@ -170,8 +177,7 @@ func (t *AsterTrader) formatPrice(symbol string, price float64) (float64, error)
 	}
 
 	// 如果没有tick size，则按精度四舍五入
-	multiplier := math.Pow10(prec.PricePrecision)
-	return math.Round(price*multiplier) / multiplier, nil
+	return money.RoundToPlaces(price, prec.PricePrecision), nil
 }
 
 // formatQuantity 格式化数量到正确精度和step size
@@ -187,8 +193,7 @@ func (t *AsterTrader) formatQuantity(symbol string, quantity float64) (float64,
 	}
 
 	// 如果没有step size，则按精度四舍五入
-	multiplier := math.Pow10(prec.QuantityPrecision)
-	return math.Round(quantity*multiplier) / multiplier, nil
+	return money.RoundToPlaces(quantity, prec.QuantityPrecision), nil
 }
 
 // formatFloatWithPrecision 将浮点数格式化为指定精度的字符串（去除末尾的0）
@@ -426,114 +431,144 @@ func (t *AsterTrader) doRequest(method, endpoint string, params map[string]inter
 }
 
 // GetBalance 获取账户余额
-func (t *AsterTrader) GetBalance() (map[string]interface{}, error) {
+func (t *AsterTrader) GetBalance() (*Balance, error) {
 	params := make(map[string]interface{})
 	body, err := t.request("GET", "/fapi/v3/balance", params)
 	if err != nil {
 		return nil, err
 	}
 
-	var balances []map[string]interface{}
+	var balances []struct {
+		Asset            string `json:"asset"`
+		Balance          string `json:"balance"`
+		AvailableBalance string `json:"availableBalance"`
+		CrossUnPnl       string `json:"crossUnPnl"`
+	}
 	if err := json.Unmarshal(body, &balances); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("解析余额失败: %w", err)
 	}
 
-	// 查找USDT余额
-	totalBalance := 0.0
-	availableBalance := 0.0
-	crossUnPnl := 0.0
-
+	// 各资产余额，稳定币（USDT/USDC）按1:1计入总额
+	result := &Balance{Assets: make(map[string]AssetBalance)}
 	for _, bal := range balances {
-		if asset, ok := bal["asset"].(string); ok && asset == "USDT" {
-			if wb, ok := bal["balance"].(string); ok {
-				totalBalance, _ = strconv.ParseFloat(wb, 64)
-			}
-			if avail, ok := bal["availableBalance"].(string); ok {
-				availableBalance, _ = strconv.ParseFloat(avail, 64)
-			}
-			if unpnl, ok := bal["crossUnPnl"].(string); ok {
-				crossUnPnl, _ = strconv.ParseFloat(unpnl, 64)
-			}
-			break
+		asset := AssetBalance{Asset: bal.Asset}
+		asset.WalletBalance, _ = strconv.ParseFloat(bal.Balance, 64)
+		asset.AvailableBalance, _ = strconv.ParseFloat(bal.AvailableBalance, 64)
+		asset.UnrealizedProfit, _ = strconv.ParseFloat(bal.CrossUnPnl, 64)
+		if asset.WalletBalance == 0 && asset.UnrealizedProfit == 0 {
+			continue
+		}
+		result.Assets[bal.Asset] = asset
+		if market.IsStableAsset(bal.Asset) {
+			result.TotalWalletBalance += asset.WalletBalance
+			result.AvailableBalance += asset.AvailableBalance
+			result.TotalUnrealizedProfit += asset.UnrealizedProfit
 		}
 	}
 
-	// 返回与Binance相同的字段名，确保AutoTrader能正确解析
-	return map[string]interface{}{
-		"totalWalletBalance":    totalBalance,
-		"availableBalance":      availableBalance,
-		"totalUnrealizedProfit": crossUnPnl,
-	}, nil
+	return result, nil
 }
 
 // GetAccountTrades 获取账户历史成交（Aster暂未实现）
-func (t *AsterTrader) GetAccountTrades(symbol string, limit int) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil // 暂不支持
+func (t *AsterTrader) GetAccountTrades(symbol string, limit int) ([]Fill, error) {
+	return []Fill{}, nil // 暂不支持
+}
+
+// GetTransfers 获取资金划转记录（Aster暂未实现）
+func (t *AsterTrader) GetTransfers(startTime int64) ([]Transfer, error) {
+	return []Transfer{}, nil // 暂不支持
 }
 
 // GetPositions 获取持仓信息
-func (t *AsterTrader) GetPositions() ([]map[string]interface{}, error) {
+func (t *AsterTrader) GetPositions() ([]Position, error) {
 	params := make(map[string]interface{})
 	body, err := t.request("GET", "/fapi/v3/positionRisk", params)
 	if err != nil {
 		return nil, err
 	}
 
-	var positions []map[string]interface{}
+	var positions []struct {
+		Symbol           string `json:"symbol"`
+		PositionAmt      string `json:"positionAmt"`
+		EntryPrice       string `json:"entryPrice"`
+		MarkPrice        string `json:"markPrice"`
+		UnRealizedProfit string `json:"unRealizedProfit"`
+		Leverage         string `json:"leverage"`
+		LiquidationPrice string `json:"liquidationPrice"`
+		MarginType       string `json:"marginType"`
+		IsolatedMargin   string `json:"isolatedMargin"`
+	}
 	if err := json.Unmarshal(body, &positions); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("解析持仓失败: %w", err)
 	}
 
-	result := []map[string]interface{}{}
+	result := []Position{}
 	for _, pos := range positions {
-		posAmtStr, ok := pos["positionAmt"].(string)
-		if !ok {
-			continue
-		}
-
-		posAmt, _ := strconv.ParseFloat(posAmtStr, 64)
+		posAmt, _ := strconv.ParseFloat(pos.PositionAmt, 64)
 		if posAmt == 0 {
 			continue // 跳过空仓位
 		}
 
-		entryPrice, _ := strconv.ParseFloat(pos["entryPrice"].(string), 64)
-		markPrice, _ := strconv.ParseFloat(pos["markPrice"].(string), 64)
-		unRealizedProfit, _ := strconv.ParseFloat(pos["unRealizedProfit"].(string), 64)
-		leverageVal, _ := strconv.ParseFloat(pos["leverage"].(string), 64)
-		liquidationPrice, _ := strconv.ParseFloat(pos["liquidationPrice"].(string), 64)
+		p := Position{Symbol: pos.Symbol, Side: "long", Quantity: posAmt}
+		p.EntryPrice, _ = strconv.ParseFloat(pos.EntryPrice, 64)
+		p.MarkPrice, _ = strconv.ParseFloat(pos.MarkPrice, 64)
+		p.UnrealizedProfit, _ = strconv.ParseFloat(pos.UnRealizedProfit, 64)
+		p.LiquidationPrice, _ = strconv.ParseFloat(pos.LiquidationPrice, 64)
+		p.Leverage, _ = strconv.Atoi(pos.Leverage)
+		p.MarginMode = normalizeMarginMode(pos.MarginType)
+		if p.MarginMode == decision.MarginModeIsolated {
+			p.IsolatedMargin, _ = strconv.ParseFloat(pos.IsolatedMargin, 64)
+		}
 
 		// 判断方向（与Binance一致）
-		side := "long"
 		if posAmt < 0 {
-			side = "short"
-			posAmt = -posAmt
+			p.Side = "short"
+			p.Quantity = -posAmt
 		}
 
-		// 返回与Binance相同的字段名
-		result = append(result, map[string]interface{}{
-			"symbol":            pos["symbol"],
-			"side":              side,
-			"positionAmt":       posAmt,
-			"entryPrice":        entryPrice,
-			"markPrice":         markPrice,
-			"unRealizedProfit":  unRealizedProfit,
-			"leverage":          leverageVal,
-			"liquidationPrice":  liquidationPrice,
-		})
+		result = append(result, p)
 	}
 
 	return result, nil
 }
 
+// asterOrderResponse Aster下单/查单响应（数量和价格为字符串）
+type asterOrderResponse struct {
+	OrderID     int64  `json:"orderId"`
+	Symbol      string `json:"symbol"`
+	Status      string `json:"status"`
+	OrigQty     string `json:"origQty"`
+	ExecutedQty string `json:"executedQty"`
+	AvgPrice    string `json:"avgPrice"`
+	Type        string `json:"type"`
+	OrigType    string `json:"origType"` // 条件单触发后type可能变为MARKET，origType保留原始类型
+}
+
+// parseAsterOrder 解析订单响应
+func parseAsterOrder(body []byte) (*Order, error) {
+	var resp asterOrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析订单响应失败: %w", err)
+	}
+	order := &Order{OrderID: resp.OrderID, Symbol: resp.Symbol, Status: resp.Status, Type: resp.OrigType}
+	if order.Type == "" {
+		order.Type = resp.Type
+	}
+	order.OrigQty, _ = strconv.ParseFloat(resp.OrigQty, 64)
+	order.ExecutedQty, _ = strconv.ParseFloat(resp.ExecutedQty, 64)
+	order.AvgPrice, _ = strconv.ParseFloat(resp.AvgPrice, 64)
+	return order, nil
+}
+
 // OpenLong 开多单
-func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (*Order, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
 	if err := t.CancelAllOrders(symbol); err != nil {
 		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
 	}
 
 	// 先设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if _, err := t.SetLeverage(symbol, leverage); err != nil {
 		return nil, fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
@@ -584,23 +619,18 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return parseAsterOrder(body)
 }
 
 // OpenShort 开空单
-func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (*Order, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
 	if err := t.CancelAllOrders(symbol); err != nil {
 		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
 	}
 
 	// 先设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if _, err := t.SetLeverage(symbol, leverage); err != nil {
 		return nil, fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
@@ -651,16 +681,11 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return parseAsterOrder(body)
 }
 
 // CloseLong 平多单
-func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+func (t *AsterTrader) CloseLong(symbol string, quantity float64) (*Order, error) {
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -669,8 +694,8 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		}
 
 		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
+			if pos.Symbol == symbol && pos.Side == "long" {
+				quantity = pos.Quantity
 				break
 			}
 		}
@@ -719,15 +744,16 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		"timeInForce":  "GTC",
 		"quantity":     qtyStr,
 		"price":        priceStr,
+		"reduceOnly":   "true", // 只减仓，防止反向开仓
 	}
 
 	body, err := t.request("POST", "/fapi/v3/order", params)
 	if err != nil {
-		return nil, err
+		return nil, describeOrderError("平多仓", err)
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	result, err := parseAsterOrder(body)
+	if err != nil {
 		return nil, err
 	}
 
@@ -742,7 +768,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 }
 
 // CloseShort 平空单
-func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+func (t *AsterTrader) CloseShort(symbol string, quantity float64) (*Order, error) {
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -751,9 +777,8 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		}
 
 		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "short" {
-				// Aster的GetPositions已经将空仓数量转换为正数，直接使用
-				quantity = pos["positionAmt"].(float64)
+			if pos.Symbol == symbol && pos.Side == "short" {
+				quantity = pos.Quantity
 				break
 			}
 		}
@@ -802,15 +827,16 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		"timeInForce":  "GTC",
 		"quantity":     qtyStr,
 		"price":        priceStr,
+		"reduceOnly":   "true", // 只减仓，防止反向开仓
 	}
 
 	body, err := t.request("POST", "/fapi/v3/order", params)
 	if err != nil {
-		return nil, err
+		return nil, describeOrderError("平空仓", err)
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
+	result, err := parseAsterOrder(body)
+	if err != nil {
 		return nil, err
 	}
 
@@ -824,15 +850,28 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 	return result, nil
 }
 
-// SetLeverage 设置杠杆倍数
-func (t *AsterTrader) SetLeverage(symbol string, leverage int) error {
+// SetLeverage 设置杠杆倍数，返回交易所确认的实际杠杆
+func (t *AsterTrader) SetLeverage(symbol string, leverage int) (int, error) {
 	params := map[string]interface{}{
 		"symbol":   symbol,
 		"leverage": leverage,
 	}
 
-	_, err := t.request("POST", "/fapi/v3/leverage", params)
-	return err
+	body, err := t.request("POST", "/fapi/v3/leverage", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Leverage int `json:"leverage"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Leverage <= 0 {
+		return leverage, nil
+	}
+	if result.Leverage != leverage {
+		log.Printf("  ⚠ %s 请求杠杆 %dx，交易所实际生效 %dx", symbol, leverage, result.Leverage)
+	}
+	return result.Leverage, nil
 }
 
 // GetMarketPrice 获取市场价格
@@ -862,6 +901,32 @@ func (t *AsterTrader) GetMarketPrice(symbol string) (float64, error) {
 	return strconv.ParseFloat(priceStr, 64)
 }
 
+// GetFundingRate 获取当前资金费率（Aster按8小时结算）
+func (t *AsterTrader) GetFundingRate(symbol string) (FundingRate, error) {
+	resp, err := t.client.Get(fmt.Sprintf("%s/fapi/v3/premiumIndex?symbol=%s", t.baseURL, symbol))
+	if err != nil {
+		return FundingRate{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return FundingRate{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		LastFundingRate string `json:"lastFundingRate"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return FundingRate{}, err
+	}
+	rate, err := strconv.ParseFloat(result.LastFundingRate, 64)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("资金费率格式错误: %w", err)
+	}
+	return FundingRate{Rate: rate, IntervalHours: 8}, nil
+}
+
 // SetStopLoss 设置止损
 func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
 	side := "SELL"
@@ -897,10 +962,11 @@ func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity,
 		"stopPrice":    priceStr,
 		"quantity":     qtyStr,
 		"timeInForce":  "GTC",
+		"reduceOnly":   "true", // 止损止盈只减仓，不会反向开仓
 	}
 
 	_, err = t.request("POST", "/fapi/v3/order", params)
-	return err
+	return describeOrderError("设置止损", err)
 }
 
 // SetTakeProfit 设置止盈
@@ -938,10 +1004,124 @@ func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity
 		"stopPrice":    priceStr,
 		"quantity":     qtyStr,
 		"timeInForce":  "GTC",
+		"reduceOnly":   "true", // 止损止盈只减仓，不会反向开仓
 	}
 
 	_, err = t.request("POST", "/fapi/v3/order", params)
-	return err
+	return describeOrderError("设置止盈", err)
+}
+
+// SetTriggerLimitExit 挂限价条件单（STOP/TAKE_PROFIT：触发后按限价只减仓，市价条件单未生效时的替代）
+func (t *AsterTrader) SetTriggerLimitExit(symbol, positionSide, kind string, quantity, triggerPrice, limitPrice float64) error {
+	side := "SELL"
+	if positionSide == "SHORT" {
+		side = "BUY"
+	}
+	orderType, action := "STOP", "设置限价止损"
+	if kind == OpenOrderTakeProfit {
+		orderType, action = "TAKE_PROFIT", "设置限价止盈"
+	}
+
+	formattedTrigger, err := t.formatPrice(symbol, triggerPrice)
+	if err != nil {
+		return err
+	}
+	formattedLimit, err := t.formatPrice(symbol, limitPrice)
+	if err != nil {
+		return err
+	}
+	formattedQty, err := t.formatQuantity(symbol, quantity)
+	if err != nil {
+		return err
+	}
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"symbol":       symbol,
+		"positionSide": "BOTH",
+		"type":         orderType,
+		"side":         side,
+		"stopPrice":    t.formatFloatWithPrecision(formattedTrigger, prec.PricePrecision),
+		"price":        t.formatFloatWithPrecision(formattedLimit, prec.PricePrecision),
+		"quantity":     t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision),
+		"timeInForce":  "GTC",
+		"reduceOnly":   "true",
+	}
+
+	_, err = t.request("POST", "/fapi/v3/order", params)
+	return describeOrderError(action, err)
+}
+
+// GetOrderStatus 查询订单状态（用于确认限价单的实际成交数量和均价）
+func (t *AsterTrader) GetOrderStatus(symbol string, orderID int64) (*Order, error) {
+	params := map[string]interface{}{
+		"symbol":  symbol,
+		"orderId": orderID,
+	}
+
+	body, err := t.request("GET", "/fapi/v3/order", params)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单状态失败: %w", err)
+	}
+
+	order, err := parseAsterOrder(body)
+	if err != nil {
+		return nil, err
+	}
+	order.OrderID = orderID
+	order.Symbol = symbol
+	return order, nil
+}
+
+// GetOpenOrders 获取该币种未成交的挂单（实现Trader接口）
+func (t *AsterTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	params := map[string]interface{}{
+		"symbol": symbol,
+	}
+
+	body, err := t.request("GET", "/fapi/v3/openOrders", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	var orders []struct {
+		OrderID       int64  `json:"orderId"`
+		Symbol        string `json:"symbol"`
+		Type          string `json:"type"`
+		Side          string `json:"side"`
+		PositionSide  string `json:"positionSide"`
+		StopPrice     string `json:"stopPrice"`
+		Price         string `json:"price"`
+		OrigQty       string `json:"origQty"`
+		ExecutedQty   string `json:"executedQty"`
+		ReduceOnly    bool   `json:"reduceOnly"`
+		ClosePosition bool   `json:"closePosition"`
+	}
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析挂单失败: %w", err)
+	}
+
+	result := make([]OpenOrder, 0, len(orders))
+	for _, o := range orders {
+		order := OpenOrder{
+			OrderID:      o.OrderID,
+			Symbol:       o.Symbol,
+			Type:         openOrderType(o.Type),
+			Side:         o.Side,
+			PositionSide: o.PositionSide,
+			ReduceOnly:   o.ReduceOnly || o.ClosePosition,
+		}
+		order.TriggerPrice, _ = strconv.ParseFloat(o.StopPrice, 64)
+		order.Price, _ = strconv.ParseFloat(o.Price, 64)
+		origQty, _ := strconv.ParseFloat(o.OrigQty, 64)
+		executedQty, _ := strconv.ParseFloat(o.ExecutedQty, 64)
+		order.Quantity = origQty - executedQty
+		result = append(result, order)
+	}
+	return result, nil
 }
 
 // CancelAllOrders 取消所有订单
@@ -954,6 +1134,20 @@ func (t *AsterTrader) CancelAllOrders(symbol string) error {
 	return err
 }
 
+// CancelOrder 取消指定挂单
+func (t *AsterTrader) CancelOrder(symbol string, orderID int64) error {
+	params := map[string]interface{}{
+		"symbol":  symbol,
+		"orderId": orderID,
+	}
+
+	_, err := t.request("DELETE", "/fapi/v3/order", params)
+	if err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+	return nil
+}
+
 // FormatQuantity 格式化数量（实现Trader接口）
 func (t *AsterTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
 	formatted, err := t.formatQuantity(symbol, quantity)
@@ -962,3 +1156,18 @@ func (t *AsterTrader) FormatQuantity(symbol string, quantity float64) (string, e
 	}
 	return fmt.Sprintf("%v", formatted), nil
 }
+
+// GetSymbolFilters 获取交易对的下单数量规则（实现Trader接口）
+func (t *AsterTrader) GetSymbolFilters(symbol string) (SymbolFilters, error) {
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return SymbolFilters{}, err
+	}
+	return SymbolFilters{
+		StepSize:    prec.StepSize,
+		MinQty:      prec.MinQty,
+		MaxQty:      prec.MaxQty,
+		MinNotional: prec.MinNotional,
+		TickSize:    prec.TickSize,
+	}, nil
+}
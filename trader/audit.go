@@ -0,0 +1,168 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/database/repositories"
+	"time"
+)
+
+// auditTrader 交易所请求审计（包装交易器，记录每次下单、平仓、改杠杆、止损止盈、撤单的请求和返回）
+type auditTrader struct {
+	Trader
+	exchange string
+	db       *database.DB
+}
+
+// enableAudit 启用交易所请求审计（execution_audit_enabled，默认开启）
+func (at *AutoTrader) enableAudit() {
+	db := at.decisionLogger.GetDB()
+	if db == nil || !executionConfig().AuditEnabled {
+		return
+	}
+	at.trader = &auditTrader{Trader: at.trader, exchange: at.exchange, db: db}
+	log.Printf("📝 [%s] 已启用交易所请求审计", at.name)
+}
+
+// record 保存一条审计记录（写入失败只记录日志，不影响交易）
+func (t *auditTrader) record(op, symbol string, request map[string]interface{}, response interface{}, start time.Time, err error) {
+	event := &models.ExchangeAuditEvent{
+		Exchange:  t.exchange,
+		Operation: op,
+		Symbol:    symbol,
+		Success:   err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CreatedAt: start,
+	}
+	if b, mErr := json.Marshal(request); mErr == nil {
+		event.Request = string(b)
+	}
+	if err != nil {
+		event.Error = err.Error()
+	} else if response != nil {
+		if b, mErr := json.Marshal(response); mErr == nil {
+			event.Response = string(b)
+		}
+	}
+	if dbErr := t.db.ExchangeAudit().Insert(event); dbErr != nil {
+		log.Printf("⚠️ 保存交易所审计记录失败 (%s %s): %v", op, symbol, dbErr)
+	}
+}
+
+func (t *auditTrader) OpenLong(symbol string, quantity float64, leverage int) (*Order, error) {
+	start := time.Now()
+	order, err := t.Trader.OpenLong(symbol, quantity, leverage)
+	t.record("open_long", symbol, map[string]interface{}{"quantity": quantity, "leverage": leverage}, order, start, err)
+	return order, err
+}
+
+func (t *auditTrader) OpenShort(symbol string, quantity float64, leverage int) (*Order, error) {
+	start := time.Now()
+	order, err := t.Trader.OpenShort(symbol, quantity, leverage)
+	t.record("open_short", symbol, map[string]interface{}{"quantity": quantity, "leverage": leverage}, order, start, err)
+	return order, err
+}
+
+func (t *auditTrader) CloseLong(symbol string, quantity float64) (*Order, error) {
+	start := time.Now()
+	order, err := t.Trader.CloseLong(symbol, quantity)
+	t.record("close_long", symbol, map[string]interface{}{"quantity": quantity}, order, start, err)
+	return order, err
+}
+
+func (t *auditTrader) CloseShort(symbol string, quantity float64) (*Order, error) {
+	start := time.Now()
+	order, err := t.Trader.CloseShort(symbol, quantity)
+	t.record("close_short", symbol, map[string]interface{}{"quantity": quantity}, order, start, err)
+	return order, err
+}
+
+func (t *auditTrader) SetLeverage(symbol string, leverage int) (int, error) {
+	start := time.Now()
+	effective, err := t.Trader.SetLeverage(symbol, leverage)
+	t.record("set_leverage", symbol, map[string]interface{}{"leverage": leverage, "effective_leverage": effective}, nil, start, err)
+	return effective, err
+}
+
+func (t *auditTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	start := time.Now()
+	err := t.Trader.SetStopLoss(symbol, positionSide, quantity, stopPrice)
+	t.record("set_stop_loss", symbol, map[string]interface{}{
+		"position_side": positionSide, "quantity": quantity, "stop_price": stopPrice,
+	}, nil, start, err)
+	return err
+}
+
+func (t *auditTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	start := time.Now()
+	err := t.Trader.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+	t.record("set_take_profit", symbol, map[string]interface{}{
+		"position_side": positionSide, "quantity": quantity, "take_profit_price": takeProfitPrice,
+	}, nil, start, err)
+	return err
+}
+
+func (t *auditTrader) CancelAllOrders(symbol string) error {
+	start := time.Now()
+	err := t.Trader.CancelAllOrders(symbol)
+	t.record("cancel_all_orders", symbol, map[string]interface{}{}, nil, start, err)
+	return err
+}
+
+func (t *auditTrader) CancelOrder(symbol string, orderID int64) error {
+	start := time.Now()
+	err := t.Trader.CancelOrder(symbol, orderID)
+	t.record("cancel_order", symbol, map[string]interface{}{"order_id": orderID}, nil, start, err)
+	return err
+}
+
+// ExchangeAuditEntry 交易所请求审计记录（API返回格式）
+type ExchangeAuditEntry struct {
+	ID        int64           `json:"id"`
+	Exchange  string          `json:"exchange"`
+	Operation string          `json:"operation"`
+	Symbol    string          `json:"symbol"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	Success   bool            `json:"success"`
+	Error     string          `json:"error,omitempty"`
+	LatencyMs int64           `json:"latency_ms"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// GetExchangeAudit 分页查询交易所请求审计记录（用于API），同时返回过滤后的总数
+func (at *AutoTrader) GetExchangeAudit(opts repositories.QueryOptions, operation string) ([]ExchangeAuditEntry, int, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, 0, fmt.Errorf("数据库不可用")
+	}
+	events, total, err := db.ExchangeAudit().Query(opts, operation)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]ExchangeAuditEntry, 0, len(events))
+	for _, e := range events {
+		entry := ExchangeAuditEntry{
+			ID:        e.ID,
+			Exchange:  e.Exchange,
+			Operation: e.Operation,
+			Symbol:    e.Symbol,
+			Success:   e.Success,
+			Error:     e.Error,
+			LatencyMs: e.LatencyMs,
+			Timestamp: e.CreatedAt,
+		}
+		if e.Request != "" {
+			entry.Request = json.RawMessage(e.Request)
+		}
+		if e.Response != "" {
+			entry.Response = json.RawMessage(e.Response)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, nil
+}
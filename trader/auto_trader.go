@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"nofx/database"
 	"nofx/database/models"
 	"nofx/decision"
+	"nofx/i18n"
 	"nofx/logger"
 	"nofx/market"
 	"nofx/mcp"
+	"nofx/money"
+	"nofx/monitoring"
 	"nofx/pool"
+	"nofx/sharedstate"
 	"strings"
 	"sync"
 	"time"
@@ -39,12 +44,34 @@ type AutoTraderConfig struct {
 	AsterSigner     string // Aster API钱包地址
 	AsterPrivateKey string // Aster API钱包私钥
 
-	CoinPoolAPIURL string
+	CoinPoolAPIURL string // 全局币种池API（全局配置由main设置，trader不再修改全局配置）
+	CoinSource     string // 候选币种来源: "pool"（币种池服务）或 "static"（仅默认币种列表），空=跟随全局use_default_coins
+
+	// 独立币种池API（空=使用全局配置）
+	TraderCoinPoolAPIURL    string
+	TraderOITopAPIURL       string
+	CoinPoolAuthHeader      string
+	CoinPoolRefreshInterval time.Duration
+
+	// 行情数据源（空=binance，可选 bybit/hyperliquid 或 primary:/average: 组合）
+	MarketDataSource string
+
+	// 提示词语言（空=zh，可选 en）
+	PromptLanguage string
+
+	// AI生成参数（temperature/top_p/max_tokens/reasoning_effort，零值=使用默认值）
+	AIParams mcp.GenerationParams
+
+	// 决策审核模型（两阶段AI：空=不启用；deepseek/qwen/custom）
+	CriticAIModel   string
+	CriticModelName string   // 审核模型名称（custom必填；deepseek/qwen可选，覆盖默认模型）
+	CriticRules     []string // 审核规则清单（空=使用内置规则）
 
 	// AI配置
 	UseQwen     bool
 	DeepSeekKey string
 	QwenKey     string
+	AIModelName string // deepseek/qwen使用的模型名称（空=默认模型）
 
 	// 自定义AI API配置
 	CustomAPIURL    string
@@ -74,10 +101,10 @@ type AutoTraderConfig struct {
 	// 数据优化配置
 	CompactMode bool // true=紧凑模式（减少数据量），false=完整模式
 
-	// 风险控制（仅作为提示，AI可自主决定）
-	MaxDailyLoss    float64       // 最大日亏损百分比（提示）
+	// 风险控制
+	MaxDailyLoss    float64       // 最大日亏损百分比（达到后当日只平仓，0表示不限制）
 	MaxDrawdown     float64       // 最大回撤百分比（提示）
-	StopTradingTime time.Duration // 触发风控后暂停时长
+	StopTradingTime time.Duration // 风控停止时长（期间只平仓）
 }
 
 // AutoTrader 自动交易器
@@ -88,21 +115,55 @@ type AutoTrader struct {
 	exchange              string // 交易平台名称
 	config                AutoTraderConfig
 	trader                Trader // 使用Trader接口（支持多平台）
-	mcpClient             *mcp.Client
+	mcpClient             *mcp.Client             // 通过aiClient()读取（运行时可切换模型）
+	aiMu                  sync.RWMutex            // 保护mcpClient和aiModel的切换
+	critic                *decision.Critic // 决策审核器（nil表示不启用两阶段审核）
 	decisionLogger        *logger.DecisionLogger // 决策日志记录器
 	initialBalance        float64
-	dailyPnL              float64
+	dailyPnL              float64                 // 当日盈亏（扣除入金/出金，由updateDailyLoss更新）
+	dayStartEquity        float64                 // 日初净值（扣除累计净入金，0表示下个周期重新记录）
+	dailyLossHit          bool                    // 当日是否已触发日亏损限制（用于只预警一次）
 	lastResetTime         time.Time
-	stopUntil             time.Time
 	isRunning             bool
-	isPaused              bool                   // 是否暂停
-	startTime             time.Time              // 系统启动时间
-	callCount             int                    // AI调用次数
-	positionFirstSeenTime map[string]int64       // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
-	lastKnownPositions    map[string]bool        // 上次已知的持仓 (symbol_side -> true)，用于检测自动平仓
-	enableAILearning      bool                   // 是否启用AI学习
-	aiLearnInterval       int                    // AI学习间隔（周期数）
-	mu                    sync.RWMutex           // 保护并发访问
+	lifecycle             stateMachine            // 持久化的运行状态（暂停/风控停止/只平仓），生效状态通过State()获取
+	maintenanceWindow     string                  // 当前所处的维护窗口（非空时自动暂停，由TraderManager定时更新）
+	lastHeartbeat         time.Time               // 交易循环最近一次完成周期（或暂停跳过）的时间，用于检测卡死
+	stallAlerted          bool                    // 本次卡死是否已发出预警（收到新心跳后重置）
+	symbolLeverage        map[string]int          // 开仓时交易所确认的币种杠杆（持仓未返回杠杆时用于保证金计算）
+	startTime             time.Time               // 系统启动时间
+	callCount             int                     // AI调用次数
+	positionFirstSeenTime map[string]int64        // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	lastKnownPositions    map[string]bool         // 上次已知的持仓 (symbol_side -> true)，用于检测自动平仓
+	pendingAutoCloses     []logger.DecisionAction // 干跑验证时检测到的自动平仓（合并到下一条决策记录）
+	approvals             approvalQueue           // 等待人工审批的大额开仓决策
+	drawdown              drawdownLock            // 净值峰值回撤锁（触发后只平仓）
+	exitPolicyPeakR       map[string]float64      // 持仓期间达到的最大浮盈(R)，用于时间止损 (symbol_side -> R)
+	enableAILearning      bool                    // 是否启用AI学习
+	aiLearnInterval       int                     // AI学习间隔（周期数）
+	promptCache           *decision.PromptCache   // 重复Prompt抑制缓存
+	lastMarketData        map[string]*market.Data // 最近一次AI决策使用的市场数据（用于记录开仓指标快照）
+	lastMarketDataAt      time.Time               // 最近一次AI决策的市场快照时间
+	netFlows              float64                 // 累计净入金（入金 - 出金，来自资金划转台账）
+	lastFlowSync          time.Time               // 最近一次同步资金划转的时间
+	lastIncomeSync        time.Time               // 最近一次同步收入历史的时间
+	lastRegime            string                  // 最近一次检测到的市场状态
+	lastEquity            float64                 // 最近一次获取的账户净值（用于风险预算检查）
+	lastPreview           *PreviewPlan            // 最近一次预览计划（预览模式周期或API触发）
+	mu                    sync.RWMutex            // 保护并发访问
+	cycleMu               sync.Mutex              // 串行化AI决策周期与手动注入的决策
+	candidateSource       pool.CandidateSource    // 候选币种来源
+	poolClient            *pool.Client            // Trader独立的币种池客户端（OI Top数据也从这里获取）
+	marketProvider        market.Provider         // 行情数据源
+	monitor               *monitoring.PerformanceMonitor // 性能监控器（风险评分/预警）
+	clock                 decision.Clock          // 时间来源（nil表示time.Now，集成测试注入固定时钟）
+}
+
+// now 当前时间（注入了时钟时使用注入的时钟，决策周期内的时间都从这里取）
+func (at *AutoTrader) now() time.Time {
+	if at.clock != nil {
+		return at.clock.Now()
+	}
+	return time.Now()
 }
 
 // NewAutoTrader 创建自动交易器
@@ -126,26 +187,52 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		}
 	}
 
-	mcpClient := mcp.New()
+	mcpClient := newPrimaryAIClient(config)
+	if err := config.AIParams.Validate(); err != nil {
+		return nil, err
+	}
+	mcpClient.SetGenerationParams(config.AIParams)
 
-	// 初始化AI
-	if config.AIModel == "custom" {
-		// 使用自定义API
-		mcpClient.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
-		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
-	} else if config.UseQwen || config.AIModel == "qwen" {
-		// 使用Qwen
-		mcpClient.SetQwenAPIKey(config.QwenKey, "")
-		log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
-	} else {
-		// 默认使用DeepSeek
-		mcpClient.SetDeepSeekAPIKey(config.DeepSeekKey)
-		log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+	critic, err := newCritic(config)
+	if err != nil {
+		return nil, err
+	}
+	if critic != nil {
+		log.Printf("🧑‍⚖️ [%s] 决策审核模型: %s (%s)", config.Name, critic.Client.ProviderName(), critic.Client.Model)
+	}
+
+	// 配置备用AI提供商（主提供商连续失败时自动故障转移，恢复后自动切回）
+	addAIFallbacks(mcpClient, config)
+
+	// Trader独立的币种池客户端（未配置独立API时与全局配置共享同一币种范围）
+	poolConfig := pool.ClientConfig{
+		CoinPoolAPIURL:  config.TraderCoinPoolAPIURL,
+		OITopAPIURL:     config.TraderOITopAPIURL,
+		AuthHeader:      config.CoinPoolAuthHeader,
+		RefreshInterval: config.CoinPoolRefreshInterval,
 	}
+	if poolConfig.CoinPoolAPIURL != "" || poolConfig.OITopAPIURL != "" {
+		// 独立缓存目录，避免不同币种范围的缓存互相覆盖
+		poolConfig.CacheDir = fmt.Sprintf("coin_pool_cache/%s", config.ID)
+		log.Printf("🪙 [%s] 使用独立币种池API（刷新间隔: %v）", config.Name, config.CoinPoolRefreshInterval)
+	}
+	poolClient := pool.NewClient(poolConfig)
+
+	// 候选币种来源（AI500取前10个评分最高的币种，减少候选数量以提高响应速度）
+	candidateSource, err := pool.NewCandidateSource(config.CoinSource, 10, poolClient)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📋 [%s] 候选币种来源: %s", config.Name, candidateSource.Name())
 
-	// 初始化币种池API
-	if config.CoinPoolAPIURL != "" {
-		pool.SetCoinPoolAPI(config.CoinPoolAPIURL)
+	marketProvider, err := market.NewProvider(config.MarketDataSource)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("📈 [%s] 行情数据源: %s", config.Name, marketProvider.Name())
+
+	if err := i18n.Validate(config.PromptLanguage); err != nil {
+		return nil, err
 	}
 
 	// 设置默认交易平台
@@ -155,7 +242,6 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 
 	// 根据配置创建对应的交易器
 	var trader Trader
-	var err error
 
 	switch config.Exchange {
 	case "binance":
@@ -199,6 +285,7 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		config:                config,
 		trader:                trader,
 		mcpClient:             mcpClient,
+		critic:                critic,
 		decisionLogger:        decisionLogger,
 		initialBalance:        config.InitialBalance,
 		lastResetTime:         time.Now(),
@@ -207,9 +294,15 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
 		lastKnownPositions:    make(map[string]bool),
+		exitPolicyPeakR:       make(map[string]float64),
 		enableAILearning:      config.EnableAILearning,
 		aiLearnInterval:       config.AILearnInterval,
+		promptCache:           decision.NewPromptCache(),
+		candidateSource:       candidateSource,
+		poolClient:            poolClient,
+		marketProvider:        marketProvider,
 	}
+	at.lifecycle.state = StateRunning
 
 	// 从数据库恢复持仓开仓时间和运行状态
 	if db := decisionLogger.GetDB(); db != nil {
@@ -220,19 +313,36 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		}
 		
 		// 恢复运行状态
-		if isPaused, exists := db.GetTraderState(); exists {
-			at.isPaused = isPaused
-			if isPaused {
-				log.Printf("✓ 从数据库恢复状态: 暂停中")
-			} else {
-				log.Printf("✓ 从数据库恢复状态: 运行中")
-			}
+		if saved, exists := db.GetTraderState(); exists {
+			at.restoreState(saved)
 		} else {
 			// 没有保存的状态，默认为运行（不暂停）
 			log.Printf("✓ 首次启动，默认状态: 运行中")
 		}
+
+		// 恢复累计净入金（盈亏按初始余额+净入金计算）
+		if net, err := db.BalanceFlow().GetNetTotal(); err == nil && net != 0 {
+			at.netFlows = net
+			log.Printf("✓ 从资金划转台账恢复净入金: %+.2f USDT", net)
+		}
+
+		// 恢复净值峰值和回撤锁状态
+		at.restoreDrawdownLock(db)
 	}
 
+	// 故障注入（仅非实盘模式，由 chaos_enabled 配置开启）
+	at.enableChaos()
+
+	// 交易所请求审计（包在故障注入外层，记录机器人实际看到的请求结果）
+	at.enableAudit()
+
+	// 资金费率套利：恢复套利仓位标记，并登记为可用的跨交易所对冲腿
+	at.restoreFundingArbLegs()
+	registerFundingArbTrader(at)
+
+	// 性能监控（指标/预警通过API查询）
+	at.initPerformanceMonitor()
+
 	return at, nil
 }
 
@@ -251,8 +361,7 @@ func (at *AutoTrader) Run() error {
 	}
 	
 	// 同步数据优化模式
-	market.CompactMode = at.config.CompactMode
-	if market.CompactMode {
+	if at.compactMode() {
 		log.Println("📦 数据模式: 紧凑模式 (优化性能)")
 	} else {
 		log.Println("📊 数据模式: 完整模式 (完整数据)")
@@ -263,27 +372,34 @@ func (at *AutoTrader) Run() error {
 	ticker := time.NewTicker(at.config.ScanInterval)
 	defer ticker.Stop()
 
-	// 首次立即执行（检查暂停状态）
-	if !at.IsPaused() {
-		if err := at.runCycle(); err != nil {
-			log.Printf("❌ 执行失败: %v", err)
-		}
-	} else {
-		log.Printf("[%s] ⏸️  Trader已暂停，跳过首次执行", at.name)
+	// 决策历史归档清理（启动时执行一次，之后每天一次）
+	retentionTicker := time.NewTicker(RetentionCheckInterval)
+	defer retentionTicker.Stop()
+	go at.applyRetention()
+
+	// 周期间止损监控（止损单缺失时兜底平仓）
+	go at.runPositionDriftMonitor()
+
+	if at.monitor != nil {
+		at.monitor.Start()
+	}
+
+	// 首次立即执行（状态检查在runCycle入口统一处理）
+	at.markCycleHeartbeat()
+	if err := at.runCycle(); err != nil {
+		log.Printf("❌ 执行失败: %v", err)
 	}
+	at.markCycleHeartbeat()
 
 	for at.isRunning {
 		select {
 		case <-ticker.C:
-			// 检查是否暂停
-			if at.IsPaused() {
-				log.Printf("[%s] ⏸️  Trader已暂停，跳过本次交易循环", at.name)
-				continue
-			}
-			
 			if err := at.runCycle(); err != nil {
 				log.Printf("❌ 执行失败: %v", err)
 			}
+			at.markCycleHeartbeat()
+		case <-retentionTicker.C:
+			go at.applyRetention()
 		}
 	}
 
@@ -293,22 +409,32 @@ func (at *AutoTrader) Run() error {
 // Stop 停止自动交易
 func (at *AutoTrader) Stop() {
 	at.isRunning = false
+	unregisterFundingArbTrader(at)
+	if at.monitor != nil {
+		at.monitor.Stop()
+	}
 	log.Println("⏹ 自动交易系统停止")
 }
 
 // runCycle 运行一个交易周期（使用AI全权决策）
 func (at *AutoTrader) runCycle() error {
-	// ⚠️ 关键检查：如果暂停，完全不执行任何操作
-	// 不收集数据、不调用AI、不记录日志、不增加callCount
-	if at.IsPaused() {
+	// ⚠️ 关键检查：暂停（含全局停止开关、维护窗口）或已停止时完全不执行任何操作
+	status, ok := at.cycleGate()
+	if !ok {
 		return nil
 	}
+
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
 	
 	at.callCount++
 
-	log.Printf("\n" + strings.Repeat("=", 70))
-	log.Printf("[%s] ⏰ %s - AI决策周期 #%d", at.name, time.Now().Format("2006-01-02 15:04:05"), at.callCount)
-	log.Printf(strings.Repeat("=", 70))
+	// 超时未审批的大额决策作废
+	at.expireApprovals()
+
+	log.Printf("\n%s", strings.Repeat("=", 70))
+	log.Printf("[%s] ⏰ %s - AI决策周期 #%d", at.name, at.now().Format("2006-01-02 15:04:05"), at.callCount)
+	log.Println(strings.Repeat("=", 70))
 
 	// 创建决策记录
 	record := &logger.DecisionRecord{
@@ -316,28 +442,40 @@ func (at *AutoTrader) runCycle() error {
 		Success:      true,
 	}
 
-	// 1. 检查是否需要停止交易（风险控制暂停）
-	if time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
-		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
-		record.Success = false
-		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
-		at.decisionLogger.LogDecision(record)
-		return nil
+	// 1. 风控停止期间照常运行交易周期，只管理现有持仓（只平仓模式，由验证和开仓检查拒绝开仓）
+	if status.State == StateRiskStopped {
+		remaining := status.Until.Sub(at.now())
+		log.Printf("⏸ 风险控制：风控停止中（%s），剩余 %.0f 分钟，本周期只平仓", status.Reason, remaining.Minutes())
+		record.ExecutionLog = append(record.ExecutionLog,
+			fmt.Sprintf("⏸ 风控停止中（%s），剩余 %.0f 分钟，本周期只平仓", status.Reason, remaining.Minutes()))
 	}
 
 	// 2. 重置日盈亏（每天重置）
-	if time.Since(at.lastResetTime) > 24*time.Hour {
-		at.dailyPnL = 0
-		at.lastResetTime = time.Now()
+	if at.now().Sub(at.lastResetTime) > 24*time.Hour {
+		at.resetDailyLoss()
 		log.Println("📅 日盈亏已重置")
 	}
 
+	// 同步入金/出金（盈亏按净投入计算）
+	at.reconcileBalanceFlows(at.now())
+
+	// 同步交易所收入历史（已实现盈亏、资金费、手续费，用于月度收益表）
+	at.syncIncomeHistory(at.now())
+
+	// 预览模式：不执行任何会下单的步骤（资金费率套利、自动退出策略、AI决策），只记录计划订单
+	preview := previewMode()
+
+	// 资金费率套利（独立于AI决策，先于构建上下文执行，使AI看到的可用余额已扣除套利占用）
+	if !preview {
+		at.runFundingArbitrage(record)
+	}
+
 	// 3. 收集交易上下文（同时检测自动平仓）
 	ctx, autoClosedPositions, err := at.buildTradingContext()
 	if err != nil {
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("构建交易上下文失败: %v", err)
+		record.Timestamp = at.now()
 		at.decisionLogger.LogDecision(record)
 		return fmt.Errorf("构建交易上下文失败: %w", err)
 	}
@@ -357,6 +495,14 @@ func (at *AutoTrader) runCycle() error {
 			fmt.Sprintf("🤖 %s %s 自动平仓（止损/止盈触发）", autoCloseAction.Symbol, autoCloseAction.Action))
 	}
 
+	// 自动退出策略（保本止损、时间止损、周末/事件前平仓），独立于AI决策
+	if !preview {
+		at.runExitPolicies(ctx, record)
+	}
+
+	// 标记决策时生效的AI学习总结版本（用于评估总结效果）
+	record.LearningSummaryID = ctx.AILearningSummaryID
+
 	// 保存账户状态快照
 	record.AccountState = logger.AccountSnapshot{
 		TotalBalance:          ctx.Account.TotalEquity,
@@ -390,15 +536,64 @@ func (at *AutoTrader) runCycle() error {
 
 	// 4. 调用AI获取完整决策
 	log.Println("🤖 正在请求AI分析并决策...")
-	decision, err := decision.GetFullDecision(ctx, at.mcpClient)
+	aiClient := at.aiClient()
+	decision, err := decision.GetFullDecision(ctx, aiClient)
+	record.AIProvider = aiClient.LastUsedProvider()
+	aiParams := aiClient.GenerationParams()
+	record.AIParams = aiParams.JSON()
+	if !ctx.MarketSnapshotAt.IsZero() {
+		record.LatencyMs = at.now().Sub(ctx.MarketSnapshotAt).Milliseconds()
+		log.Printf("⏱️  决策延迟: %dms（市场快照 → AI决策完成）", record.LatencyMs)
+	}
+	record.MarketFetchMs = ctx.Timings.MarketFetchMs
+	record.PromptBuildMs = ctx.Timings.PromptBuildMs
+	record.AILatencyMs = ctx.Timings.AILatencyMs
+	record.ParseValidateMs = ctx.Timings.ParseValidateMs
+	record.SymbolsFetched = ctx.Timings.SymbolsFetched
+	record.SymbolsFailed = ctx.Timings.SymbolsFailed
+	for _, ex := range ctx.LiquidityExclusions {
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("💧 %s 流动性不足，未纳入候选: %s", ex.Symbol, ex.Reason))
+	}
+	if stats := ctx.CompactStats; stats != nil && stats.Symbols > 0 {
+		line := fmt.Sprintf("📦 紧凑模式: %d个币种行情数据约%d tokens（完整格式约%d，节省%.0f%%）",
+			stats.Symbols, stats.CompactTokens, stats.FullTokens, stats.SavedPct())
+		if stats.CandidatesDropped > 0 {
+			line += fmt.Sprintf("，另有%d个候选币种超出上限未分析", stats.CandidatesDropped)
+		}
+		log.Println(line)
+		record.ExecutionLog = append(record.ExecutionLog, line)
+	}
+	at.mu.Lock()
+	at.lastMarketData = ctx.MarketDataMap
+	at.lastMarketDataAt = ctx.MarketSnapshotAt
+	at.mu.Unlock()
+	if ctx.Regime != nil {
+		record.Regime = ctx.Regime.Label
+		at.lastRegime = ctx.Regime.Label
+	}
+	if ctx.Breadth != nil {
+		at.saveMarketBreadth(ctx.Breadth)
+	}
 
 	// 即使有错误，也保存思维链、决策和输入prompt（用于debug）
 	if decision != nil {
+		for _, reason := range decision.CloseOnlyRejected {
+			record.ExecutionLog = append(record.ExecutionLog, "🔒 "+reason)
+		}
+		record.Cached = decision.Cached
+		record.PromptHash = decision.PromptHash
+		record.SchemaVersion = decision.SchemaVersion
 		record.SystemPrompt = decision.SystemPrompt
 		record.InputPrompt = decision.UserPrompt
 		record.CoTTrace = decision.CoTTrace
-		if len(decision.Decisions) > 0 {
-			decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
+		if decision.Critic != nil && !decision.Cached {
+			criticJSON, _ := json.Marshal(decision.Critic)
+			record.CriticReview = string(criticJSON)
+			record.CriticReviewed = decision.Critic.Reviewed
+			record.CriticVetoed = decision.Critic.Vetoed
+		}
+		if recorded := decision.RecordedDecisions(); len(recorded) > 0 {
+			decisionJSON, _ := json.MarshalIndent(recorded, "", "  ")
 			record.DecisionJSON = string(decisionJSON)
 		}
 	}
@@ -406,34 +601,38 @@ func (at *AutoTrader) runCycle() error {
 	if err != nil {
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("获取AI决策失败: %v", err)
+		if isValidationRejection(err) {
+			at.notifyWebhooks(WebhookValidationRejected, ValidationEventData{Cycle: at.callCount, Source: "ai", Error: err.Error()})
+		}
 
 		// 打印AI思维链（即使有错误）
 		if decision != nil && decision.CoTTrace != "" {
-			log.Printf("\n" + strings.Repeat("-", 70))
+			log.Printf("\n%s", strings.Repeat("-", 70))
 			log.Println("💭 AI思维链分析（错误情况）:")
 			log.Println(strings.Repeat("-", 70))
 			log.Println(decision.CoTTrace)
-			log.Printf(strings.Repeat("-", 70) + "\n")
+			log.Printf("%s\n", strings.Repeat("-", 70))
 		}
 
+		record.Timestamp = at.now()
 		at.decisionLogger.LogDecision(record)
 		return fmt.Errorf("获取AI决策失败: %w", err)
 	}
 
 	// 5. 打印AI思维链
-	log.Printf("\n" + strings.Repeat("-", 70))
+	log.Printf("\n%s", strings.Repeat("-", 70))
 	log.Println("💭 AI思维链分析:")
 	log.Println(strings.Repeat("-", 70))
 	log.Println(decision.CoTTrace)
-	log.Printf(strings.Repeat("-", 70) + "\n")
+	log.Printf("%s\n", strings.Repeat("-", 70))
 
 	// 6. 打印AI决策
 	log.Printf("📋 AI决策列表 (%d 个):\n", len(decision.Decisions))
 	for i, d := range decision.Decisions {
 		log.Printf("  [%d] %s: %s - %s", i+1, d.Symbol, d.Action, d.Reasoning)
 		if d.Action == "open_long" || d.Action == "open_short" {
-			log.Printf("      杠杆: %dx | 仓位: %.2f USDT | 止损: %.4f | 止盈: %.4f",
-				d.Leverage, d.PositionSizeUSD, d.StopLoss, d.TakeProfit)
+			log.Printf("      杠杆: %dx | 名义价值: %.2f USDT | 保证金: %.2f USDT | 止损: %.4f | 止盈: %.4f",
+				d.Leverage, d.NotionalUSD, d.MarginUSD, d.StopLoss, d.TakeProfit)
 		}
 	}
 	log.Println()
@@ -447,34 +646,20 @@ func (at *AutoTrader) runCycle() error {
 	}
 	log.Println()
 
-	// 执行决策并记录结果
-	for _, d := range sortedDecisions {
-
-		actionRecord := logger.DecisionAction{
-			Action:    d.Action,
-			Symbol:    d.Symbol,
-			Quantity:  0,
-			Leverage:  d.Leverage,
-			Price:     0,
-			Timestamp: time.Now(),
-			Success:   false,
-		}
-
-		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
-			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
-			actionRecord.Error = err.Error()
-			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
-		} else {
-			actionRecord.Success = true
-			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
-			// 成功执行后短暂延迟
-			time.Sleep(1 * time.Second)
-		}
-
-		record.Decisions = append(record.Decisions, actionRecord)
+	// 执行决策并记录结果（平仓并发执行，开仓在平仓完成后顺序执行；预览模式只生成计划订单）
+	executionStart := time.Now()
+	if preview {
+		at.previewDecisionBatch(sortedDecisions, decision, record)
+	} else {
+		at.executeDecisionBatch(sortedDecisions, record)
 	}
+	record.ExecutionMs = time.Since(executionStart).Milliseconds()
+	log.Printf("⏱️  周期耗时: 行情%dms | Prompt%dms | AI%dms | 解析验证%dms | 执行%dms（行情成功%d/失败%d个币种）",
+		record.MarketFetchMs, record.PromptBuildMs, record.AILatencyMs, record.ParseValidateMs, record.ExecutionMs,
+		record.SymbolsFetched, record.SymbolsFailed)
 
 	// 8. 保存决策记录
+	record.Timestamp = at.now()
 	if err := at.decisionLogger.LogDecision(record); err != nil {
 		log.Printf("⚠ 保存决策记录失败: %v", err)
 	}
@@ -487,7 +672,7 @@ func (at *AutoTrader) runCycle() error {
 	return nil
 }
 
-// buildTradingContext 构建交易上下文（同时检测自动平仓）
+// buildTradingContext 构建交易上下文（同时更新回撤锁和日亏损、检测自动平仓）
 func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.DecisionAction, error) {
 	// 1. 获取账户信息
 	balance, err := at.trader.GetBalance()
@@ -495,103 +680,107 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 		return nil, nil, fmt.Errorf("获取账户余额失败: %w", err)
 	}
 
-	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
+	// Total Equity = 钱包余额 + 未实现盈亏
+	totalEquity := balance.TotalEquity()
 
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	// 更新净值峰值，回撤超过阈值时切换为只平仓模式
+	at.updateDrawdownLock(totalEquity)
 
-	// Total Equity = 钱包余额 + 未实现盈亏
-	totalEquity := totalWalletBalance + totalUnrealizedProfit
+	// 更新当日盈亏，亏损达到日亏损上限时当日切换为只平仓模式
+	at.updateDailyLoss(totalEquity)
 
 	// 2. 获取持仓信息并检测自动平仓
 	positions, err := at.trader.GetPositions()
 	if err != nil {
 		return nil, nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
+	autoClosedPositions := at.trackPositions(positions)
 
-	var positionInfos []decision.PositionInfo
-	totalMarginUsed := 0.0
+	// 3. 获取候选币种（币种池服务: AI500 + OI Top 去重；静态列表: 仅默认币种）
+	mergedPool, err := at.candidateSource.GetCandidates()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取候选币种失败: %w", err)
+	}
 
-	// 当前持仓的key集合（用于清理已平仓的记录）
-	currentPositionKeys := make(map[string]bool)
-	
-	// 检测自动平仓事件（持仓消失但不是AI主动平仓）
-	// 这些自动平仓事件会被记录到决策日志中
-	var autoClosedPositions []logger.DecisionAction
+	// 构建候选币种列表（包含来源信息）
+	var candidateCoins []decision.CandidateCoin
+	for _, symbol := range mergedPool.AllSymbols {
+		sources := mergedPool.SymbolSources[symbol]
+		candidateCoins = append(candidateCoins, decision.CandidateCoin{
+			Symbol:  symbol,
+			Sources: sources, // "ai500" 和/或 "oi_top"，静态列表为 "default"
+		})
+	}
 
-	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity // 空仓数量为负，转为正数
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
+	// 排除非TRADING状态或即将下架/交割的币种
+	candidateCoins = filterTradableCandidates(candidateCoins)
 
-		// 计算占用保证金（估算）
-		leverage := 10 // 默认值，实际应该从持仓信息获取
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
-		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
-		totalMarginUsed += marginUsed
+	// 排除计价币种未启用或交易所不支持该保证金类型（如币本位）的币种
+	candidateCoins = filterQuoteCandidates(candidateCoins, at.exchange)
 
-		// 计算盈亏百分比
-		pnlPct := 0.0
-		if side == "long" {
-			pnlPct = ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
-		} else {
-			pnlPct = ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
+	// 排除资金费率套利占用的币种
+	if arbSymbols := fundingArbSymbols(at.id); len(arbSymbols) > 0 {
+		filtered := candidateCoins[:0]
+		for _, coin := range candidateCoins {
+			if !arbSymbols[coin.Symbol] {
+				filtered = append(filtered, coin)
+			}
 		}
+		candidateCoins = filtered
+	}
 
-		// 跟踪持仓首次出现时间
-		posKey := symbol + "_" + side
+	// 同步行情数据配置（数据质量检查、持仓量历史，获取市场数据时生效）
+	syncMarketSettings()
+
+	ctx := at.newDecisionContext(balance, positions, candidateCoins)
+
+	// 订阅持仓币种的订单流（未开启时退订）
+	positionSymbols := make([]string, 0, len(ctx.Positions))
+	for _, pos := range ctx.Positions {
+		positionSymbols = append(positionSymbols, pos.Symbol)
+	}
+	market.WatchOrderFlow(positionSymbols)
+
+	log.Printf("📋 候选币种(%s): 总计%d个", at.candidateSource.Name(), len(candidateCoins))
+	at.lastEquity = totalEquity
+	return ctx, autoClosedPositions, nil
+}
+
+// buildDryRunContext 构建只读的交易上下文（决策干跑验证使用）
+// 只读取余额和持仓：不更新回撤锁和日亏损，不处理自动平仓（不写交易记录、不撤单、不发通知），不改动待记录的自动平仓
+func (at *AutoTrader) buildDryRunContext() (*decision.Context, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	return at.newDecisionContext(balance, positions, nil), nil
+}
+
+// trackPositions 跟踪持仓首次出现时间，检测自动平仓（上次存在但这次不存在的持仓）并清理其记录
+// 返回本周期检测到的自动平仓（含干跑验证期间遗留、尚未写入决策记录的自动平仓）
+func (at *AutoTrader) trackPositions(positions []Position) []logger.DecisionAction {
+	// 当前持仓的key集合（用于清理已平仓的记录）
+	currentPositionKeys := make(map[string]bool)
+	for _, pos := range positions {
+		// 资金费率套利仓位由套利模块管理
+		if isFundingArbLeg(at.id, pos.Symbol, pos.Side) {
+			continue
+		}
+		posKey := pos.Symbol + "_" + pos.Side
 		currentPositionKeys[posKey] = true
 		if _, exists := at.positionFirstSeenTime[posKey]; !exists {
-			// 新持仓，先尝试从数据库恢复
-			if db := at.decisionLogger.GetDB(); db != nil {
-				if savedTime, ok := db.GetPositionOpenTime(symbol, side); ok {
-					at.positionFirstSeenTime[posKey] = savedTime
-					log.Printf("  📅 从数据库恢复 %s %s 的开仓时间", symbol, side)
-				} else {
-					// 数据库中没有，记录当前时间（可能是系统重启前的持仓）
-					at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
-				}
-			} else {
-				// 没有数据库，使用当前时间
-				at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
-			}
+			at.positionFirstSeenTime[posKey] = at.positionOpenTime(pos.Symbol, pos.Side)
 		}
-		updateTime := at.positionFirstSeenTime[posKey]
-
-		positionInfos = append(positionInfos, decision.PositionInfo{
-			Symbol:           symbol,
-			Side:             side,
-			EntryPrice:       entryPrice,
-			MarkPrice:        markPrice,
-			Quantity:         quantity,
-			Leverage:         leverage,
-			UnrealizedPnL:    unrealizedPnl,
-			UnrealizedPnLPct: pnlPct,
-			LiquidationPrice: liquidationPrice,
-			MarginUsed:       marginUsed,
-			UpdateTime:       updateTime,
-		})
 	}
 
+	// 检测自动平仓事件（持仓消失但不是AI主动平仓）
+	// 这些自动平仓事件会被记录到决策日志中
+	var autoClosedPositions []logger.DecisionAction
+
 	// 检测自动平仓（上次存在但这次不存在的持仓）
 	for key := range at.lastKnownPositions {
 		if !currentPositionKeys[key] {
@@ -609,26 +798,28 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 				}
 				
 				// 获取当前价格作为平仓价
-				marketData, _ := market.Get(symbol)
+				marketData, _ := market.GetWith(at.marketProvider, symbol)
 				closePrice := 0.0
 				if marketData != nil {
 					closePrice = marketData.CurrentPrice
 				}
 				
-				autoClosedPositions = append(autoClosedPositions, logger.DecisionAction{
-					Action:      action,
-					Symbol:      symbol,
-					Quantity:    0, // 无法获取数量
-					Price:       closePrice,
-					Timestamp:   time.Now(),
-					Success:     true,
-					WasStopLoss: true, // 标记为可能的止损/止盈
-				})
-				
 				log.Printf("  📍 检测到自动平仓: %s %s (可能触发止损/止盈)", symbol, strings.ToUpper(side))
 				
-				// 保存交易记录到trade_outcomes表
-				at.saveAutoClosedTradeOutcome(symbol, side, closePrice)
+				// 保存交易记录到trade_outcomes表（平仓决策关联到本周期检测到的自动平仓动作）
+				tradeOutcomeID, exitKind := at.saveAutoClosedTradeOutcome(symbol, side, closePrice)
+				autoClose := logger.DecisionAction{
+					Action:         action,
+					Symbol:         symbol,
+					Quantity:       0, // 无法获取数量
+					Price:          closePrice,
+					Timestamp:      at.now(),
+					Success:        true,
+					WasStopLoss:    exitKind != OpenOrderTakeProfit, // 止盈单触发的不算止损，无法判断时仍标记为可能的止损
+					TradeOutcomeID: tradeOutcomeID,
+				}
+				autoClosedPositions = append(autoClosedPositions, autoClose)
+				at.notifyAutoClosed(side, autoClose)
 				
 				// 从数据库删除（在 if 块内部，symbol 和 side 变量可用）
 				if db := at.decisionLogger.GetDB(); db != nil {
@@ -636,6 +827,11 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 						log.Printf("  ⚠️  从数据库删除开仓时间失败: %v", err)
 					}
 				}
+				at.deleteEntrySnapshot(symbol, side)
+				at.releaseRiskBudget(symbol, side)
+
+				// 止损和止盈只成交了一个，撤销另一个残留的条件单（本地模拟OCO）
+				at.cancelOrphanExitOrders(symbol, side)
 			}
 			
 			// 清理内存记录
@@ -646,34 +842,107 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 	// 更新已知持仓列表
 	at.lastKnownPositions = currentPositionKeys
 
-	// 3. 获取合并的候选币种池（AI500 + OI Top，去重）
-	// 优化：减少候选币种数量，提高响应速度
-	const ai500Limit = 10 // AI500取前10个评分最高的币种（从20减少到10）
+	// 合并干跑验证期间检测到、尚未写入决策记录的自动平仓
+	if len(at.pendingAutoCloses) > 0 {
+		autoClosedPositions = append(at.pendingAutoCloses, autoClosedPositions...)
+		at.pendingAutoCloses = nil
+	}
+
+	// 释放已不存在持仓占用的风险预算
+	at.reconcileRiskLedger(currentPositionKeys)
 
-	// 获取合并后的币种池（AI500 + OI Top）
-	mergedPool, err := pool.GetMergedCoinPool(ai500Limit)
-	if err != nil {
-		return nil, nil, fmt.Errorf("获取合并币种池失败: %w", err)
+	return autoClosedPositions
+}
+
+// positionOpenTime 持仓开仓时间（毫秒）：优先使用本进程跟踪的首次出现时间，其次从数据库恢复，都没有时使用当前时间
+func (at *AutoTrader) positionOpenTime(symbol, side string) int64 {
+	if t, ok := at.positionFirstSeenTime[symbol+"_"+side]; ok {
+		return t
 	}
+	if db := at.decisionLogger.GetDB(); db != nil {
+		if savedTime, ok := db.GetPositionOpenTime(symbol, side); ok {
+			log.Printf("  📅 从数据库恢复 %s %s 的开仓时间", symbol, side)
+			return savedTime
+		}
+	}
+	// 数据库中没有（可能是系统重启前的持仓）或没有数据库，使用当前时间
+	return at.now().UnixMilli()
+}
 
-	// 构建候选币种列表（包含来源信息）
-	var candidateCoins []decision.CandidateCoin
-	for _, symbol := range mergedPool.AllSymbols {
-		sources := mergedPool.SymbolSources[symbol]
-		candidateCoins = append(candidateCoins, decision.CandidateCoin{
-			Symbol:  symbol,
-			Sources: sources, // "ai500" 和/或 "oi_top"
+// newDecisionContext 用账户余额、持仓和候选币种构建决策上下文（只读取状态，不修改trader和数据库）
+func (at *AutoTrader) newDecisionContext(balance *Balance, positions []Position, candidateCoins []decision.CandidateCoin) *decision.Context {
+	availableBalance := balance.AvailableBalance
+	totalEquity := balance.TotalEquity()
+
+	var positionInfos []decision.PositionInfo
+	var marginPositions []decision.PositionInfo // 估算账户强平使用的全部持仓（含资金费率套利仓位）
+	totalMarginUsed := 0.0
+
+	// 持仓止损止盈价（开仓时记录）
+	var exitLevels map[string][2]float64
+	if db := at.decisionLogger.GetDB(); db != nil {
+		if levels, err := db.GetAllPositionExitLevels(); err == nil {
+			exitLevels = levels
+		}
+	}
+
+	for _, pos := range positions {
+		symbol := pos.Symbol
+		side := pos.Side
+		entryPrice := pos.EntryPrice
+		markPrice := pos.MarkPrice
+		quantity := pos.Quantity
+
+		// 计算占用保证金（估算）
+		leverage := at.positionLeverage(pos)
+		marginUsed := (quantity * markPrice) / float64(leverage)
+		totalMarginUsed += marginUsed
+		marginPositions = append(marginPositions, marginPositionInfo(pos, leverage))
+
+		// 资金费率套利仓位由套利模块管理，只计入保证金占用和账户强平估算，不提供给AI
+		if isFundingArbLeg(at.id, symbol, side) {
+			continue
+		}
+
+		// 计算盈亏百分比
+		pnlPct := 0.0
+		if side == "long" {
+			pnlPct = ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
+		} else {
+			pnlPct = ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
+		}
+
+		posKey := symbol + "_" + side
+		updateTime := at.positionOpenTime(symbol, side)
+		positionInfos = append(positionInfos, decision.PositionInfo{
+			Symbol:           symbol,
+			Side:             side,
+			EntryPrice:       entryPrice,
+			MarkPrice:        markPrice,
+			Quantity:         quantity,
+			Leverage:         leverage,
+			UnrealizedPnL:    pos.UnrealizedProfit,
+			UnrealizedPnLPct: pnlPct,
+			LiquidationPrice: pos.LiquidationPrice,
+			MarginUsed:       marginUsed,
+			MarginMode:       pos.MarginMode,
+			IsolatedMargin:   pos.IsolatedMargin,
+			UpdateTime:       updateTime,
+			StopLoss:         exitLevels[posKey][0],
+			TakeProfit:       exitLevels[posKey][1],
+			ExitLevelUpdates: at.exitLevelUpdateCount(symbol, side, updateTime),
 		})
 	}
 
-	log.Printf("📋 合并币种池: AI500前%d + OI_Top20 = 总计%d个候选币种",
-		ai500Limit, len(candidateCoins))
+	// 附加交易所挂单（止损/止盈条件单），让AI看到实际生效的保护单
+	at.attachOpenOrders(positionInfos)
 
-	// 4. 计算总盈亏
-	totalPnL := totalEquity - at.initialBalance
+	// 4. 计算总盈亏（相对净投入：初始余额+净入金，入金/出金不计为盈亏）
+	basis, _ := at.costBasis()
+	totalPnL := totalEquity - basis
 	totalPnLPct := 0.0
-	if at.initialBalance > 0 {
-		totalPnLPct = (totalPnL / at.initialBalance) * 100
+	if basis > 0 {
+		totalPnLPct = (totalPnL / basis) * 100
 	}
 
 	marginUsedPct := 0.0
@@ -692,12 +961,14 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 
 	// 6. 加载AI学习总结（如果有）
 	var aiLearningSummary string
+	var aiLearningSummaryID int64
 	if db := at.decisionLogger.GetDB(); db != nil {
 		summary, err := db.GetActiveAILearningSummary()
 		if err != nil {
 			log.Printf("⚠️ 加载AI学习总结失败: %v", err)
 		} else if summary != nil {
 			aiLearningSummary = summary.SummaryContent
+			aiLearningSummaryID = summary.ID
 			log.Printf("📚 已加载AI学习总结（分析%d笔交易，胜率%.1f%%）", summary.TradesCount, summary.WinRate*100)
 		}
 	}
@@ -718,19 +989,47 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 	log.Printf("[DEBUG] buildTradingContext: at.config.AIAutonomyMode=%v", at.config.AIAutonomyMode)
 	
 	ctx := &decision.Context{
-		CurrentTime:       time.Now().Format("2006-01-02 15:04:05"),
-		RuntimeMinutes:    int(time.Since(at.startTime).Minutes()),
+		CurrentTime:       at.now().Format("2006-01-02 15:04:05"),
+		RuntimeMinutes:    int(at.now().Sub(at.startTime).Minutes()),
 		CallCount:         at.callCount,
 		BTCETHLeverage:    at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
 		AltcoinLeverage:   at.config.AltcoinLeverage, // 使用配置的杠杆倍数
 		MaxPositions:      at.config.MaxPositions,    // 使用配置的最大持仓数
 		AILearningSummary: aiLearningSummary, // 添加AI学习总结
+		AILearningSummaryID: aiLearningSummaryID,
 		DecisionLogger:    at.decisionLogger, // 传递DecisionLogger用于访问数据库
 		AIAutonomyMode:    at.config.AIAutonomyMode, // AI自主模式
 		Account:           accountInfo,
 		Positions:         positionInfos,
+		MarginPositions:   marginPositions,
 		CandidateCoins:    candidateCoins,
 		Performance:       performance, // 添加历史表现分析
+		PromptCache:       at.promptCache,
+		PromptCacheWindow: promptCacheWindow(),
+		SymbolAlerts:      symbolStatusAlerts(positionInfos, at.PromptLanguage()),
+		AllowStopLoosening: executionConfig().AllowStopLoosening,
+		Rebalance:          rebalanceTargets(),
+		Sizing:             sizingTargets(),
+		Funding:            fundingTargets(),
+		MarketProvider:     at.marketProvider,
+		CoinPool:           at.poolClient,
+		CandidateSource:    at.candidateSource.Name(),
+		MaintenanceNotices: at.maintenanceNotices(at.now()),
+		Language:           at.PromptLanguage(),
+		RiskGroups:         riskGroups(),
+		Critic:             at.critic,
+		CloseOnlyReason:    at.closeOnlyReason(),
+		RecentOrderErrors:  at.recentOrderErrors(at.now()),
+		EntryThrottle:      at.entryThrottle(at.now()),
+		LiquidityFilter:    liquidityFilter(),
+		LiquidationGuard:   liquidationGuard(),
+		TimeOfDay:          at.timeOfDayReport(),
+		CompactMode:        at.compactMode(),
+		CompactMaxCandidates: compactMaxCandidates(),
+		Clock:              at.clock,
+	}
+	if floor := at.confidenceFloor(); floor.Entry > 0 || floor.Close > 0 {
+		ctx.ConfidenceFloor = &floor
 	}
 	
 	// 调试：打印构建后的Context.AIAutonomyMode
@@ -739,23 +1038,101 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, []logger.Decisio
 	// 9. 计算风险管理指标
 	ctx.RiskMetrics = decision.CalculateRiskMetrics(ctx)
 	
-	// 10. 计算账户风险相关字段
+	// 10. 计算账户风险相关字段（风险预算以台账为准）
 	decision.CalculateAccountRiskMetrics(&ctx.Account, totalEquity, positionInfos)
+	at.applyRiskBudget(ctx, totalEquity)
 
-	return ctx, autoClosedPositions, nil
+	return ctx
+}
+
+// promptCacheWindow 重复Prompt复用窗口（system_configs.ai_prompt_cache_window_minutes，0表示关闭）
+func promptCacheWindow() time.Duration {
+	minutes := 10
+	if rc := database.GetGlobalConfig(); rc != nil {
+		minutes = rc.GetAIConfig().PromptCacheWindowMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// compactMaxCandidates 紧凑模式下最多分析的候选币种数（system_configs.ai_compact_max_candidates，0表示不限制）
+func compactMaxCandidates() int {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetAIConfig().CompactMaxCandidates
+	}
+	return 5
+}
+
+// compactMode 当前是否使用紧凑模式（行情数据只输出指标摘要）
+func (at *AutoTrader) compactMode() bool {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.config.CompactMode
+}
+
+// SetCompactMode 切换紧凑模式（配置热重载时调用，下个交易周期生效）
+func (at *AutoTrader) SetCompactMode(enabled bool) {
+	at.mu.Lock()
+	changed := at.config.CompactMode != enabled
+	at.config.CompactMode = enabled
+	at.mu.Unlock()
+	if changed {
+		log.Printf("[%s] 📦 紧凑模式: %v（下个交易周期生效）", at.name, enabled)
+	}
 }
 
 // executeDecisionWithRecord 执行AI决策并记录详细信息
 func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	// 已停止的实例（如卡死后被看门狗替换）恢复后不再下单
+	if !at.isRunning {
+		return fmt.Errorf("❌ trader已停止，放弃执行 %s %s", decision.Symbol, decision.Action)
+	}
+	// 验证阶段的参数调整（如止损按强平价收紧）随动作一起记录
+	if decision.Adjustment != "" {
+		actionRecord.Adjustment = joinAdjustment(actionRecord.Adjustment, decision.Adjustment)
+	}
+	if decision.Action != "hold" && decision.Action != "wait" {
+		if err := at.checkFundingArbSymbol(decision.Symbol); err != nil {
+			return err
+		}
+	}
+	if decision.Action == "open_long" || decision.Action == "open_short" {
+		if err := at.checkMaintenanceOpen(at.now()); err != nil {
+			return err
+		}
+		if err := at.checkCloseOnlyOpen(); err != nil {
+			return err
+		}
+	}
+
 	switch decision.Action {
 	case "open_long":
-		return at.executeOpenLongWithRecord(decision, actionRecord)
+		if err := at.executeOpenLongWithRecord(decision, actionRecord); err != nil {
+			return err
+		}
+		at.recordEntry(decision.Symbol, at.now())
+		at.notifyPositionExecuted(decision, actionRecord)
+		return nil
 	case "open_short":
-		return at.executeOpenShortWithRecord(decision, actionRecord)
+		if err := at.executeOpenShortWithRecord(decision, actionRecord); err != nil {
+			return err
+		}
+		at.recordEntry(decision.Symbol, at.now())
+		at.notifyPositionExecuted(decision, actionRecord)
+		return nil
 	case "close_long":
-		return at.executeCloseLongWithRecord(decision, actionRecord)
+		if err := at.executeCloseLongWithRecord(decision, actionRecord); err != nil {
+			return err
+		}
+		at.notifyPositionExecuted(decision, actionRecord)
+		return nil
 	case "close_short":
-		return at.executeCloseShortWithRecord(decision, actionRecord)
+		if err := at.executeCloseShortWithRecord(decision, actionRecord); err != nil {
+			return err
+		}
+		at.notifyPositionExecuted(decision, actionRecord)
+		return nil
+	case "update_stop_loss", "update_take_profit":
+		return at.executeUpdateExitLevelWithRecord(decision, actionRecord)
 	case "hold", "wait":
 		// 无需执行，仅记录
 		return nil
@@ -772,54 +1149,116 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	positions, err := at.trader.GetPositions()
 	if err == nil {
 		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
+			if pos.Symbol == decision.Symbol && pos.Side == "long" {
 				return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
 			}
 		}
 	}
 
+	// 交易对状态检查（下架/交割/暂停交易）
+	if err := checkSymbolTradable(decision.Symbol); err != nil {
+		return err
+	}
+	if err := checkSymbolMargin(at.exchange, decision.Symbol, poolConfig().AllowedQuoteAssets); err != nil {
+		return fmt.Errorf("❌ %s 拒绝开仓: %w", decision.Symbol, err)
+	}
+
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := market.GetWith(at.marketProvider, decision.Symbol)
 	if err != nil {
 		return err
 	}
 
-	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	// 行情数据质量检查（缺失K线/异常跳变/数据过期）
+	if err := checkDataQuality(marketData); err != nil {
+		return err
+	}
+
+	// 连环爆仓检查（极端行情暂停新开仓）
+	if err := checkLiquidationCascade(decision.Symbol); err != nil {
+		return err
+	}
+
+	// 资金费结算前的不利费率检查
+	if err := checkFundingEntry(marketData, "long"); err != nil {
+		return err
+	}
+
+	// 同步杠杆（以交易所实际生效的杠杆计算保证金）
+	if err := at.syncLeverage(decision, actionRecord); err != nil {
+		return err
+	}
+
+	// 仓位计算（名义价值/保证金 → 下单数量，含价格漂移复核和交易所数量规则）
+	size, err := at.sizePosition(decision, "long", marketData.CurrentPrice)
+	if size != nil {
+		actionRecord.PriceDriftPct = size.DriftPct
+		if size.Adjustment != "" {
+			actionRecord.Adjustment = joinAdjustment(actionRecord.Adjustment, size.Adjustment)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	entryPrice, quantity := size.Price, size.Quantity
 	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
+	actionRecord.NotionalUSD = size.NotionalUSD
+	actionRecord.MarginUSD = size.MarginUSD
+
+	// 风险预算检查
+	if err := at.checkRiskBudget(decision, entryPrice, quantity); err != nil {
+		return err
+	}
 
-	// 开仓
+	// 开仓（下单前记录盘口快照）
+	book := at.captureBookSnapshot(decision.Symbol, true, quantity)
 	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
 		return err
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
+	actionRecord.OrderID = order.OrderID
+
+	// 轮询订单状态，确认实际成交数量和均价
+	filledQty, avgPrice := at.confirmOrderFill(decision.Symbol, order, quantity, entryPrice)
+	actionRecord.ExecutedQty = filledQty
+	actionRecord.AvgPrice = avgPrice
+	attachBookSnapshot(actionRecord, book, true, avgPrice)
+	if filledQty <= 0 {
+		return fmt.Errorf("订单未成交（订单ID: %d）", order.OrderID)
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	log.Printf("  ✓ 开仓成功，订单ID: %d, 数量: %.4f, 成交: %.4f @ %.4f", order.OrderID, quantity, filledQty, avgPrice)
 
 	// 记录开仓时间（内存 + 数据库）
 	posKey := decision.Symbol + "_long"
-	openTimeMs := time.Now().UnixMilli()
+	openTimeMs := at.now().UnixMilli()
 	at.positionFirstSeenTime[posKey] = openTimeMs
 	
 	// 保存到数据库（持久化）
 	if db := at.decisionLogger.GetDB(); db != nil {
 		if err := db.SavePositionOpenTime(decision.Symbol, "long", openTimeMs); err != nil {
 			log.Printf("  ⚠️  保存开仓时间到数据库失败: %v", err)
+		} else if err := db.SavePositionExitLevels(decision.Symbol, "long", decision.StopLoss, decision.TakeProfit); err != nil {
+			log.Printf("  ⚠️  保存止损止盈价失败: %v", err)
 		}
 	}
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	// 记录开仓指标快照（平仓时写入交易结果用于归因分析）
+	at.saveEntrySnapshot(decision, "long", marketData)
+
+	// 占用风险预算（按实际成交）
+	at.consumeRiskBudget(decision, "long", avgPrice, filledQty)
+
+	// 设置止损止盈（按实际成交数量，交易所支持时作为OCO挂单）
+	slErr, tpErr := at.placeExitOrders(decision.Symbol, "LONG", filledQty, decision.StopLoss, decision.TakeProfit)
+	if slErr != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", slErr)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	if tpErr != nil {
+		log.Printf("  ⚠ 设置止盈失败: %v", tpErr)
 	}
 
 	return nil
@@ -833,59 +1272,198 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	positions, err := at.trader.GetPositions()
 	if err == nil {
 		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
+			if pos.Symbol == decision.Symbol && pos.Side == "short" {
 				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
 			}
 		}
 	}
 
+	// 交易对状态检查（下架/交割/暂停交易）
+	if err := checkSymbolTradable(decision.Symbol); err != nil {
+		return err
+	}
+	if err := checkSymbolMargin(at.exchange, decision.Symbol, poolConfig().AllowedQuoteAssets); err != nil {
+		return fmt.Errorf("❌ %s 拒绝开仓: %w", decision.Symbol, err)
+	}
+
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := market.GetWith(at.marketProvider, decision.Symbol)
 	if err != nil {
 		return err
 	}
 
-	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	// 行情数据质量检查（缺失K线/异常跳变/数据过期）
+	if err := checkDataQuality(marketData); err != nil {
+		return err
+	}
+
+	// 连环爆仓检查（极端行情暂停新开仓）
+	if err := checkLiquidationCascade(decision.Symbol); err != nil {
+		return err
+	}
+
+	// 资金费结算前的不利费率检查
+	if err := checkFundingEntry(marketData, "short"); err != nil {
+		return err
+	}
+
+	// 同步杠杆（以交易所实际生效的杠杆计算保证金）
+	if err := at.syncLeverage(decision, actionRecord); err != nil {
+		return err
+	}
+
+	// 仓位计算（名义价值/保证金 → 下单数量，含价格漂移复核和交易所数量规则）
+	size, err := at.sizePosition(decision, "short", marketData.CurrentPrice)
+	if size != nil {
+		actionRecord.PriceDriftPct = size.DriftPct
+		if size.Adjustment != "" {
+			actionRecord.Adjustment = joinAdjustment(actionRecord.Adjustment, size.Adjustment)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	entryPrice, quantity := size.Price, size.Quantity
 	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
+	actionRecord.NotionalUSD = size.NotionalUSD
+	actionRecord.MarginUSD = size.MarginUSD
 
-	// 开仓
+	// 风险预算检查
+	if err := at.checkRiskBudget(decision, entryPrice, quantity); err != nil {
+		return err
+	}
+
+	// 开仓（下单前记录盘口快照）
+	book := at.captureBookSnapshot(decision.Symbol, false, quantity)
 	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
 		return err
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
+	actionRecord.OrderID = order.OrderID
+
+	// 轮询订单状态，确认实际成交数量和均价
+	filledQty, avgPrice := at.confirmOrderFill(decision.Symbol, order, quantity, entryPrice)
+	actionRecord.ExecutedQty = filledQty
+	actionRecord.AvgPrice = avgPrice
+	attachBookSnapshot(actionRecord, book, false, avgPrice)
+	if filledQty <= 0 {
+		return fmt.Errorf("订单未成交（订单ID: %d）", order.OrderID)
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	log.Printf("  ✓ 开仓成功，订单ID: %d, 数量: %.4f, 成交: %.4f @ %.4f", order.OrderID, quantity, filledQty, avgPrice)
 
 	// 记录开仓时间（内存 + 数据库）
 	posKey := decision.Symbol + "_short"
-	openTimeMs := time.Now().UnixMilli()
+	openTimeMs := at.now().UnixMilli()
 	at.positionFirstSeenTime[posKey] = openTimeMs
 	
 	// 保存到数据库（持久化）
 	if db := at.decisionLogger.GetDB(); db != nil {
 		if err := db.SavePositionOpenTime(decision.Symbol, "short", openTimeMs); err != nil {
 			log.Printf("  ⚠️  保存开仓时间到数据库失败: %v", err)
+		} else if err := db.SavePositionExitLevels(decision.Symbol, "short", decision.StopLoss, decision.TakeProfit); err != nil {
+			log.Printf("  ⚠️  保存止损止盈价失败: %v", err)
 		}
 	}
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	// 记录开仓指标快照（平仓时写入交易结果用于归因分析）
+	at.saveEntrySnapshot(decision, "short", marketData)
+
+	// 占用风险预算（按实际成交）
+	at.consumeRiskBudget(decision, "short", avgPrice, filledQty)
+
+	// 设置止损止盈（按实际成交数量，交易所支持时作为OCO挂单）
+	slErr, tpErr := at.placeExitOrders(decision.Symbol, "SHORT", filledQty, decision.StopLoss, decision.TakeProfit)
+	if slErr != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", slErr)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	if tpErr != nil {
+		log.Printf("  ⚠ 设置止盈失败: %v", tpErr)
 	}
 
 	return nil
 }
 
+// 订单状态轮询参数
+const (
+	orderStatusPollAttempts = 5
+	orderStatusPollInterval = 500 * time.Millisecond
+)
+
+// isFinalOrderStatus 判断订单是否已处于终态
+func isFinalOrderStatus(status string) bool {
+	switch status {
+	case "FILLED", "CANCELED", "EXPIRED", "REJECTED":
+		return true
+	}
+	return false
+}
+
+// confirmOrderFill 下单后轮询订单状态，返回实际成交数量和成交均价
+// 查询失败时回退为请求数量和参考价格（保持原有行为）
+func (at *AutoTrader) confirmOrderFill(symbol string, order *Order, requestedQty, refPrice float64) (float64, float64) {
+	executedQty, avgPrice := requestedQty, refPrice
+
+	// 部分交易所（如Hyperliquid IOC单）下单结果已包含成交信息
+	if order.HasFill && isFinalOrderStatus(order.Status) {
+		if order.AvgPrice > 0 {
+			avgPrice = order.AvgPrice
+		}
+		return order.ExecutedQty, avgPrice
+	}
+
+	orderID := order.OrderID
+	if orderID == 0 {
+		return executedQty, avgPrice
+	}
+
+	var lastStatus *Order
+	for attempt := 1; attempt <= orderStatusPollAttempts; attempt++ {
+		result, err := at.trader.GetOrderStatus(symbol, orderID)
+		if err != nil {
+			log.Printf("  ⚠️  查询订单状态失败 (%d/%d): %v", attempt, orderStatusPollAttempts, err)
+		} else {
+			lastStatus = result
+			if isFinalOrderStatus(result.Status) {
+				break
+			}
+		}
+		if attempt < orderStatusPollAttempts {
+			time.Sleep(orderStatusPollInterval)
+		}
+	}
+
+	if lastStatus == nil {
+		log.Printf("  ⚠️  无法确认订单 %d 成交情况，按请求数量记录", orderID)
+		return executedQty, avgPrice
+	}
+
+	status, qty := lastStatus.Status, lastStatus.ExecutedQty
+	if lastStatus.AvgPrice > 0 {
+		avgPrice = lastStatus.AvgPrice
+	}
+
+	switch {
+	case isFinalOrderStatus(status):
+		// 终态（含部分成交后撤单）以交易所成交数量为准
+		executedQty = qty
+	case qty > 0:
+		log.Printf("  ⚠️  订单 %d 仍在部分成交中 (%s)，按已成交数量 %.4f 记录", orderID, status, qty)
+		executedQty = qty
+	default:
+		log.Printf("  ⚠️  订单 %d 尚未成交 (%s)，按请求数量记录", orderID, status)
+	}
+
+	if executedQty < requestedQty*0.999 {
+		log.Printf("  ⚠️  订单 %d 部分成交: %.4f/%.4f (%s)", orderID, executedQty, requestedQty, status)
+	}
+
+	return executedQty, avgPrice
+}
+
 // executeCloseLongWithRecord 执行平多仓并记录详细信息（修复版：记录TradeOutcome + 防止重复平仓）
 func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  🔄 平多仓: %s", decision.Symbol)
@@ -902,28 +1480,18 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	positionExists := false
 
 	for _, pos := range positions {
-		if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-			entryPrice = pos["entryPrice"].(float64)
-			if qty, ok := pos["positionAmt"].(float64); ok && qty > 0 {
-				quantity = qty
-			} else if qty, ok := pos["positionAmt"].(string); ok {
-				fmt.Sscanf(qty, "%f", &quantity)
-			}
-			
-			if lev, ok := pos["leverage"].(int); ok {
-				leverage = lev
-			} else if lev, ok := pos["leverage"].(float64); ok {
-				leverage = int(lev)
-			}
+		if pos.Symbol == decision.Symbol && pos.Side == "long" {
+			entryPrice = pos.EntryPrice
+			quantity = pos.Quantity
+			leverage = at.positionLeverage(pos)
 			
 			openPrice = entryPrice
 			
 			// 从positionFirstSeenTime获取开仓时间
-			posKey := decision.Symbol + "_long"
-			if ts, exists := at.positionFirstSeenTime[posKey]; exists {
-				openTime = time.Unix(ts/1000, (ts%1000)*1000000)
+			if ts, exists := at.GetPositionOpenTime(decision.Symbol, "long"); exists {
+				openTime = ts
 			} else {
-				openTime = time.Now().Add(-30 * time.Minute) // 默认30分钟前
+				openTime = at.now().Add(-30 * time.Minute) // 默认30分钟前
 			}
 			
 			positionExists = true
@@ -940,7 +1508,7 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := market.GetWith(at.marketProvider, decision.Symbol)
 	if err != nil {
 		return fmt.Errorf("获取市场数据失败: %w", err)
 	}
@@ -948,42 +1516,50 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	actionRecord.Price = closePrice
 
 	// 平仓
+	book := at.captureBookSnapshot(decision.Symbol, false, quantity)
 	order, err := at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
 	if err != nil {
 		return fmt.Errorf("平仓失败: %w", err)
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	actionRecord.OrderID = order.OrderID
 
-	log.Printf("  ✓ 平仓成功")
+	// 轮询订单状态，按实际成交数量和均价计算盈亏（部分成交后撤单时只统计已成交部分）
+	positionQty := quantity
+	filledQty, avgPrice := at.confirmOrderFill(decision.Symbol, order, quantity, closePrice)
+	actionRecord.ExecutedQty = filledQty
+	actionRecord.AvgPrice = avgPrice
+	attachBookSnapshot(actionRecord, book, false, avgPrice)
+	quantity = filledQty
+	closePrice = avgPrice
+	partiallyClosed := filledQty < positionQty*0.999
+
+	log.Printf("  ✓ 平仓成功，成交: %.4f/%.4f @ %.4f", filledQty, positionQty, avgPrice)
 
 	// ===== 修复3: 立即记录TradeOutcome =====
 	log.Printf("  📊 持仓信息: openPrice=%.4f, quantity=%.4f, leverage=%d", openPrice, quantity, leverage)
 	if openPrice > 0 && quantity > 0 {
-		closeTime := time.Now()
+		closeTime := at.now()
 		durationMinutes := int64(closeTime.Sub(openTime).Minutes())
 		if durationMinutes < 0 {
 			durationMinutes = 0
 		}
 
 		// 计算盈亏
-		pnl := quantity * (closePrice - openPrice)
-		positionValue := quantity * openPrice
+		pnl := tradePnL(decision.Symbol, "long", openPrice, closePrice, quantity)
+		positionValue := money.Mul(quantity, openPrice)
 		if leverage == 0 {
 			leverage = 1
 		}
-		marginUsed := positionValue / float64(leverage)
-		pnlPct := 0.0
-		if marginUsed > 0 {
-			pnlPct = (pnl / marginUsed) * 100
-		}
+		marginUsed := money.Div(positionValue, float64(leverage))
+		pnlPct := money.Pct(pnl, marginUsed)
 
 		// 判断退出原因
 		exitReason := "主动平仓"
-		if actionRecord.WasStopLoss {
+		if actionRecord.ExitPolicy != "" {
+			exitReason = exitPolicyReason(actionRecord.ExitPolicy)
+		} else if actionRecord.WasStopLoss {
 			exitReason = "止损/止盈触发"
 		} else if pnl > 0 {
 			exitReason = "主动止盈"
@@ -1023,19 +1599,29 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 			FailureType:     failureType,
 		}
 
-		// 保存到数据库
+		at.applyEntrySnapshot(trade)
+
+		// 保存到数据库（写入决策记录时关联到本平仓动作）
 		if err := at.decisionLogger.SaveTradeOutcome(trade); err != nil {
 			log.Printf("  ⚠️  保存交易记录失败: %v", err)
 		} else {
+			actionRecord.TradeOutcomeID = trade.ID
 			log.Printf("  💾 交易记录已保存: PnL=%+.2f USDT (%.2f%%), 持仓%d分钟", pnl, pnlPct, durationMinutes)
 		}
 	} else {
 		log.Printf("  ⚠️  无法保存交易记录: openPrice=%.4f, quantity=%.4f (条件不满足)", openPrice, quantity)
 	}
 
+	// 部分成交时剩余持仓仍在，保留开仓时间记录
+	if partiallyClosed {
+		log.Printf("  ⚠️  %s 平仓仅部分成交，剩余 %.4f 仍持有", decision.Symbol, positionQty-quantity)
+		return nil
+	}
+
 	// 清理持仓时间记录（内存 + 数据库）
-	posKey := decision.Symbol + "_long"
-	delete(at.positionFirstSeenTime, posKey)
+	at.mu.Lock()
+	delete(at.positionFirstSeenTime, decision.Symbol+"_long")
+	at.mu.Unlock()
 	
 	// 从数据库删除
 	if db := at.decisionLogger.GetDB(); db != nil {
@@ -1043,6 +1629,8 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 			log.Printf("  ⚠️  从数据库删除开仓时间失败: %v", err)
 		}
 	}
+	at.deleteEntrySnapshot(decision.Symbol, "long")
+	at.releaseRiskBudget(decision.Symbol, "long")
 
 	return nil
 }
@@ -1063,39 +1651,18 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	positionExists := false
 
 	for _, pos := range positions {
-		if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-			entryPrice = pos["entryPrice"].(float64)
-			if qty, ok := pos["positionAmt"].(float64); ok {
-				// 空仓的positionAmt是负数，取绝对值
-				if qty < 0 {
-					quantity = -qty
-				} else {
-					quantity = qty
-				}
-			} else if qty, ok := pos["positionAmt"].(string); ok {
-				var tempQty float64
-				fmt.Sscanf(qty, "%f", &tempQty)
-				if tempQty < 0 {
-					quantity = -tempQty
-				} else {
-					quantity = tempQty
-				}
-			}
-			
-			if lev, ok := pos["leverage"].(int); ok {
-				leverage = lev
-			} else if lev, ok := pos["leverage"].(float64); ok {
-				leverage = int(lev)
-			}
+		if pos.Symbol == decision.Symbol && pos.Side == "short" {
+			entryPrice = pos.EntryPrice
+			quantity = pos.Quantity
+			leverage = at.positionLeverage(pos)
 			
 			openPrice = entryPrice
 			
 			// 从positionFirstSeenTime获取开仓时间
-			posKey := decision.Symbol + "_short"
-			if ts, exists := at.positionFirstSeenTime[posKey]; exists {
-				openTime = time.Unix(ts/1000, (ts%1000)*1000000)
+			if ts, exists := at.GetPositionOpenTime(decision.Symbol, "short"); exists {
+				openTime = ts
 			} else {
-				openTime = time.Now().Add(-30 * time.Minute) // 默认30分钟前
+				openTime = at.now().Add(-30 * time.Minute) // 默认30分钟前
 			}
 			
 			positionExists = true
@@ -1112,7 +1679,7 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := market.GetWith(at.marketProvider, decision.Symbol)
 	if err != nil {
 		return fmt.Errorf("获取市场数据失败: %w", err)
 	}
@@ -1120,42 +1687,50 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	actionRecord.Price = closePrice
 
 	// 平仓
+	book := at.captureBookSnapshot(decision.Symbol, true, quantity)
 	order, err := at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
 	if err != nil {
 		return fmt.Errorf("平仓失败: %w", err)
 	}
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
-		actionRecord.OrderID = orderID
-	}
+	actionRecord.OrderID = order.OrderID
+
+	// 轮询订单状态，按实际成交数量和均价计算盈亏（部分成交后撤单时只统计已成交部分）
+	positionQty := quantity
+	filledQty, avgPrice := at.confirmOrderFill(decision.Symbol, order, quantity, closePrice)
+	actionRecord.ExecutedQty = filledQty
+	actionRecord.AvgPrice = avgPrice
+	attachBookSnapshot(actionRecord, book, true, avgPrice)
+	quantity = filledQty
+	closePrice = avgPrice
+	partiallyClosed := filledQty < positionQty*0.999
 
-	log.Printf("  ✓ 平仓成功")
+	log.Printf("  ✓ 平仓成功，成交: %.4f/%.4f @ %.4f", filledQty, positionQty, avgPrice)
 
 	// ===== 修复3: 立即记录TradeOutcome =====
 	log.Printf("  📊 持仓信息: openPrice=%.4f, quantity=%.4f, leverage=%d", openPrice, quantity, leverage)
 	if openPrice > 0 && quantity > 0 {
-		closeTime := time.Now()
+		closeTime := at.now()
 		durationMinutes := int64(closeTime.Sub(openTime).Minutes())
 		if durationMinutes < 0 {
 			durationMinutes = 0
 		}
 
 		// 计算盈亏（做空盈亏计算）
-		pnl := quantity * (openPrice - closePrice)
-		positionValue := quantity * openPrice
+		pnl := tradePnL(decision.Symbol, "short", openPrice, closePrice, quantity)
+		positionValue := money.Mul(quantity, openPrice)
 		if leverage == 0 {
 			leverage = 1
 		}
-		marginUsed := positionValue / float64(leverage)
-		pnlPct := 0.0
-		if marginUsed > 0 {
-			pnlPct = (pnl / marginUsed) * 100
-		}
+		marginUsed := money.Div(positionValue, float64(leverage))
+		pnlPct := money.Pct(pnl, marginUsed)
 
 		// 判断退出原因
 		exitReason := "主动平仓"
-		if actionRecord.WasStopLoss {
+		if actionRecord.ExitPolicy != "" {
+			exitReason = exitPolicyReason(actionRecord.ExitPolicy)
+		} else if actionRecord.WasStopLoss {
 			exitReason = "止损/止盈触发"
 		} else if pnl > 0 {
 			exitReason = "主动止盈"
@@ -1195,19 +1770,29 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 			FailureType:     failureType,
 		}
 
-		// 保存到数据库
+		at.applyEntrySnapshot(trade)
+
+		// 保存到数据库（写入决策记录时关联到本平仓动作）
 		if err := at.decisionLogger.SaveTradeOutcome(trade); err != nil {
 			log.Printf("  ⚠️  保存交易记录失败: %v", err)
 		} else {
+			actionRecord.TradeOutcomeID = trade.ID
 			log.Printf("  💾 交易记录已保存: PnL=%+.2f USDT (%.2f%%), 持仓%d分钟", pnl, pnlPct, durationMinutes)
 		}
 	} else {
 		log.Printf("  ⚠️  无法保存交易记录: openPrice=%.4f, quantity=%.4f (条件不满足)", openPrice, quantity)
 	}
 
+	// 部分成交时剩余持仓仍在，保留开仓时间记录
+	if partiallyClosed {
+		log.Printf("  ⚠️  %s 平仓仅部分成交，剩余 %.4f 仍持有", decision.Symbol, positionQty-quantity)
+		return nil
+	}
+
 	// 清理持仓时间记录（内存 + 数据库）
-	posKey := decision.Symbol + "_short"
-	delete(at.positionFirstSeenTime, posKey)
+	at.mu.Lock()
+	delete(at.positionFirstSeenTime, decision.Symbol+"_short")
+	at.mu.Unlock()
 	
 	// 从数据库删除
 	if db := at.decisionLogger.GetDB(); db != nil {
@@ -1215,20 +1800,23 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 			log.Printf("  ⚠️  从数据库删除开仓时间失败: %v", err)
 		}
 	}
+	at.deleteEntrySnapshot(decision.Symbol, "short")
+	at.releaseRiskBudget(decision.Symbol, "short")
 
 	return nil
 }
 
 // saveAutoClosedTradeOutcome 保存自动平仓的交易记录（从Binance历史订单获取完整信息）
-func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, closePrice float64) {
+// 返回交易结果ID（保存失败为0）和触发类型（OpenOrderStopLoss / OpenOrderTakeProfit，无法判断为空）
+func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, closePrice float64) (int64, string) {
 	// 尝试从positionFirstSeenTime获取开仓时间
 	posKey := symbol + "_" + side
-	openTime := time.Now().Add(-30 * time.Minute) // 默认30分钟前
+	openTime := at.now().Add(-30 * time.Minute) // 默认30分钟前
 	if ts, exists := at.positionFirstSeenTime[posKey]; exists {
 		openTime = time.Unix(ts/1000, (ts%1000)*1000000)
 	}
 	
-	closeTime := time.Now()
+	closeTime := at.now()
 	durationMinutes := int64(closeTime.Sub(openTime).Minutes())
 	if durationMinutes < 0 {
 		durationMinutes = 0
@@ -1237,17 +1825,18 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 	// 尝试从Binance历史订单获取完整信息
 	var quantity, openPrice, leverage float64
 	var realizedPnl float64
+	var closeOrderID int64
 	
 	trades, err := at.trader.GetAccountTrades(symbol, 20) // 获取最近20条成交记录
 	if err == nil && len(trades) > 0 {
 		// 找到最近的平仓成交（根据positionSide和side判断）
 		for _, trade := range trades {
-			tradeSide, _ := trade["side"].(string)
-			positionSide, _ := trade["positionSide"].(string)
-			tradeTime, _ := trade["time"].(int64)
+			tradeSide := trade.Side
+			positionSide := trade.PositionSide
+			tradeTime := trade.Time
 			
 			// 匹配平仓订单：时间在5分钟内 + 方向匹配
-			if time.Since(time.UnixMilli(tradeTime)) < 5*time.Minute {
+			if at.now().Sub(time.UnixMilli(tradeTime)) < 5*time.Minute {
 				// Binance BOTH模式：平多是SELL，平空是BUY
 				if (side == "long" && positionSide == "BOTH" && tradeSide == "SELL") ||
 				   (side == "short" && positionSide == "BOTH" && tradeSide == "BUY") ||
@@ -1255,15 +1844,10 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 				   (side == "short" && positionSide == "SHORT") {
 					
 					// 找到平仓订单
-					if price, ok := trade["price"].(float64); ok {
-						closePrice = price
-					}
-					if qty, ok := trade["qty"].(float64); ok {
-						quantity = qty
-					}
-					if pnl, ok := trade["realizedPnl"].(float64); ok {
-						realizedPnl = pnl
-					}
+					closePrice = trade.Price
+					quantity = trade.Quantity
+					realizedPnl = trade.RealizedPnL
+					closeOrderID = trade.OrderID
 					
 					log.Printf("  📊 从历史订单获取平仓信息: price=%.4f, qty=%.4f, pnl=%.2f", closePrice, quantity, realizedPnl)
 					break
@@ -1274,10 +1858,9 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 		// 查找对应的开仓订单（从后往前找，因为开仓在前）
 		for i := len(trades) - 1; i >= 0; i-- {
 			trade := trades[i]
-			tradeSide, _ := trade["side"].(string)
-			positionSide, _ := trade["positionSide"].(string)
-			tradeTime, _ := trade["time"].(int64)
-			tradeTimestamp := time.UnixMilli(tradeTime)
+			tradeSide := trade.Side
+			positionSide := trade.PositionSide
+			tradeTimestamp := time.UnixMilli(trade.Time)
 			
 			// 开仓订单必须在openTime附近（±5分钟）
 			if tradeTimestamp.After(openTime.Add(-5*time.Minute)) && tradeTimestamp.Before(openTime.Add(5*time.Minute)) {
@@ -1286,10 +1869,8 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 				   (side == "long" && positionSide == "LONG") ||
 				   (side == "short" && positionSide == "SHORT") {
 					
-					if price, ok := trade["price"].(float64); ok {
-						openPrice = price
-						log.Printf("  📊 从历史订单获取开仓信息: openPrice=%.4f", openPrice)
-					}
+					openPrice = trade.Price
+					log.Printf("  📊 从历史订单获取开仓信息: openPrice=%.4f", openPrice)
 					break
 				}
 			}
@@ -1298,37 +1879,30 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 	
 	// 如果获取不到数量，尝试估算（使用realizedPnl反推）
 	if quantity == 0 && realizedPnl != 0 && openPrice > 0 && closePrice > 0 {
-		priceDiff := closePrice - openPrice
-		if side == "short" {
-			priceDiff = openPrice - closePrice
-		}
+		priceDiff := money.PnL(side, openPrice, closePrice, 1)
 		if priceDiff != 0 {
-			quantity = realizedPnl / priceDiff
+			quantity = money.Div(realizedPnl, priceDiff)
 			log.Printf("  📊 根据盈亏反推数量: %.4f", quantity)
 		}
 	}
 	
-	// 计算leverage（如果有数量和价格）
+	// 计算leverage（如果有数量和价格）：使用开仓时交易所确认的杠杆
 	if quantity > 0 && openPrice > 0 {
-		leverage = 10 // 默认杠杆
+		leverage = float64(at.positionLeverage(Position{Symbol: symbol}))
 	}
 	
 	// 计算盈亏
 	pnl := realizedPnl
 	if pnl == 0 && quantity > 0 && openPrice > 0 {
-		if side == "long" {
-			pnl = quantity * (closePrice - openPrice)
-		} else {
-			pnl = quantity * (openPrice - closePrice)
-		}
+		pnl = tradePnL(symbol, side, openPrice, closePrice, quantity)
 	}
 	
-	positionValue := quantity * openPrice
-	marginUsed := positionValue / float64(leverage)
-	pnlPct := 0.0
-	if marginUsed > 0 {
-		pnlPct = (pnl / marginUsed) * 100
-	}
+	positionValue := money.Mul(quantity, openPrice)
+	marginUsed := money.Div(positionValue, float64(leverage))
+	pnlPct := money.Pct(pnl, marginUsed)
+
+	// 区分止损单和止盈单触发
+	exitKind := at.classifyAutoClose(symbol, side, closeOrderID, closePrice, pnl)
 	
 	// 构建交易记录
 	trade := &logger.TradeOutcome{
@@ -1345,9 +1919,10 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 		DurationMinutes: durationMinutes,
 		OpenTime:        openTime,
 		CloseTime:       closeTime,
-		WasStopLoss:     true,
+		WasStopLoss:     exitKind == OpenOrderStopLoss,
+		TakeProfitHit:   exitKind == OpenOrderTakeProfit,
 		EntryReason:     "AI自动开仓",
-		ExitReason:      "止损/止盈自动触发",
+		ExitReason:      at.autoCloseExitReason(symbol, side, exitKind, openTime),
 		IsPremature:     durationMinutes < 30,
 		FailureType:     func() string {
 			if pnl < 0 && durationMinutes < 30 {
@@ -1359,13 +1934,16 @@ func (at *AutoTrader) saveAutoClosedTradeOutcome(symbol string, side string, clo
 		}(),
 	}
 	
+	at.applyEntrySnapshot(trade)
+
 	// 保存到数据库
 	if err := at.decisionLogger.SaveTradeOutcome(trade); err != nil {
 		log.Printf("  ⚠️  保存自动平仓记录失败: %v", err)
-	} else {
-		log.Printf("  💾 已记录自动平仓: %s %s, PnL=%+.2f USDT (%.2f%%), 持仓%d分钟", 
-			symbol, side, pnl, pnlPct, durationMinutes)
+		return 0, exitKind
 	}
+	log.Printf("  💾 已记录自动平仓: %s %s (%s), PnL=%+.2f USDT (%.2f%%), 持仓%d分钟", 
+		symbol, side, trade.ExitReason, pnl, pnlPct, durationMinutes)
+	return trade.ID, exitKind
 }
 
 // GetID 获取trader ID
@@ -1380,6 +1958,8 @@ func (at *AutoTrader) GetName() string {
 
 // GetAIModel 获取AI模型
 func (at *AutoTrader) GetAIModel() string {
+	at.aiMu.RLock()
+	defer at.aiMu.RUnlock()
 	return at.aiModel
 }
 
@@ -1395,67 +1975,56 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		aiProvider = "Qwen"
 	}
 
-	at.mu.RLock()
-	defer at.mu.RUnlock()
-	
-	return map[string]interface{}{
-		"trader_id":       at.id,
-		"trader_name":     at.name,
-		"ai_model":        at.aiModel,
-		"exchange":        at.exchange,
-		"is_running":      at.isRunning && !at.isPaused,
-		"is_paused":       at.isPaused,
-		"start_time":      at.startTime.Format(time.RFC3339),
-		"runtime_minutes": int(time.Since(at.startTime).Minutes()),
-		"call_count":      at.callCount,
-		"initial_balance": at.initialBalance,
-		"scan_interval":   at.config.ScanInterval.String(),
-		"stop_until":      at.stopUntil.Format(time.RFC3339),
-		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
-		"ai_provider":     aiProvider,
-	}
-}
-
-// Pause 暂停trader
-func (at *AutoTrader) Pause() {
-	at.mu.Lock()
-	defer at.mu.Unlock()
-	
-	at.isPaused = true
-	
-	// 保存状态到数据库
-	if db := at.decisionLogger.GetDB(); db != nil {
-		if err := db.SaveTraderState(true); err != nil {
-			log.Printf("[%s] ⚠️  保存暂停状态到数据库失败: %v", at.name, err)
-		}
+	aiCritic := ""
+	if at.critic != nil {
+		aiCritic = fmt.Sprintf("%s (%s)", at.critic.Client.ProviderName(), at.critic.Client.Model)
 	}
-	
-	log.Printf("[%s] ⏸️  Trader已暂停", at.name)
-}
 
-// Resume 恢复trader
-func (at *AutoTrader) Resume() {
-	at.mu.Lock()
-	defer at.mu.Unlock()
-	
-	at.isPaused = false
-	
-	// 保存状态到数据库
-	if db := at.decisionLogger.GetDB(); db != nil {
-		if err := db.SaveTraderState(false); err != nil {
-			log.Printf("[%s] ⚠️  保存运行状态到数据库失败: %v", at.name, err)
-		}
-	}
-	
-	log.Printf("[%s] ▶️  Trader已恢复", at.name)
-}
+	state := at.State()
+	killSwitch := sharedstate.KillSwitchActive()
+	upcoming := at.upcomingMaintenance(at.now())
+	drawdown := at.GetDrawdownLockStatus()
+	settings := at.GetRuntimeSettings()
 
-// IsPaused 检查是否暂停
-func (at *AutoTrader) IsPaused() bool {
 	at.mu.RLock()
 	defer at.mu.RUnlock()
 	
-	return at.isPaused
+	return map[string]interface{}{
+		"trader_id":          at.id,
+		"trader_name":        at.name,
+		"ai_model":           at.GetAIModel(),
+		"exchange":           at.exchange,
+		"exit_order_mode":    at.ExitOrderMode(),     // 止损止盈联动方式（native_oco / emulated_oco）
+		"venue_capabilities": at.VenueCapabilities(), // 交易所保护单能力及止损止盈依次尝试的方式
+		"coin_source":        at.candidateSource.Name(),
+		"market_data_source": at.marketProvider.Name(),
+		"prompt_language":    string(at.PromptLanguage()),
+		"state":              state, // 生效的运行状态（running/paused/risk_stopped/close_only/stopped）及原因
+		"is_running":         state.State == StateRunning || state.State == StateCloseOnly || state.State == StateRiskStopped,
+		"is_paused":          state.State == StatePaused || state.Stored == StatePaused,
+		"kill_switch":        killSwitch,
+		"preview_mode":       previewMode(), // 预览模式（只生成计划订单，不下单）
+		"size_multiplier":    positionMultiplier(), // 压力测试缩放系数（1表示不缩放）
+		"close_only":         state.State == StateCloseOnly || state.State == StateRiskStopped, // 只平仓（回撤锁、日亏损限制、风控停止或人工设置）
+		"drawdown_lock":      drawdown,
+		"maintenance_window": at.maintenanceWindow,
+		"maintenance_plan":   upcoming, // 未来N小时内的维护窗口
+		"last_heartbeat":     at.lastHeartbeat.Format(time.RFC3339),
+		"stalled":            at.stallAlerted,
+		"start_time":         at.startTime.Format(time.RFC3339),
+		"runtime_minutes":    int(at.now().Sub(at.startTime).Minutes()),
+		"call_count":         at.callCount,
+		"initial_balance":    at.initialBalance,
+		"net_flows":          at.netFlows,                    // 累计净入金（入金 - 出金）
+		"cost_basis":         at.initialBalance + at.netFlows, // 净投入（盈亏基准）
+		"scan_interval":      at.config.ScanInterval.String(),
+		"last_reset_time":    at.lastResetTime.Format(time.RFC3339),
+		"ai_provider":        aiProvider,
+		"ai_active_provider": at.aiClient().LastUsedProvider(),
+		"ai_provider_health": at.aiClient().HealthStatus(),
+		"ai_critic":          aiCritic, // 决策审核模型（空表示不启用两阶段审核）
+		"config":             settings, // 交易周期实际使用的配置（含默认值补齐）
+	}
 }
 
 // GetPositionOpenTime 获取持仓的开仓时间
@@ -1481,10 +2050,10 @@ func (at *AutoTrader) ManualClosePosition(symbol string, side string) error {
 	}
 	
 	// 查找对应的持仓
-	var targetPosition map[string]interface{}
-	for _, pos := range positions {
-		if pos["symbol"] == symbol && pos["side"] == side {
-			targetPosition = pos
+	var targetPosition *Position
+	for i := range positions {
+		if positions[i].Symbol == symbol && positions[i].Side == side {
+			targetPosition = &positions[i]
 			break
 		}
 	}
@@ -1494,18 +2063,10 @@ func (at *AutoTrader) ManualClosePosition(symbol string, side string) error {
 	}
 	
 	// 获取持仓数量
-	quantity := 0.0
-	if positionAmt, ok := targetPosition["positionAmt"].(float64); ok {
-		quantity = positionAmt
-		if quantity < 0 {
-			quantity = -quantity // 转为正数
-		}
-	} else {
-		return fmt.Errorf("无法获取持仓数量")
-	}
+	quantity := targetPosition.Quantity
 	
 	// 执行平仓
-	var result map[string]interface{}
+	var result *Order
 	var closeErr error
 	if side == "long" {
 		result, closeErr = at.trader.CloseLong(symbol, quantity)
@@ -1520,8 +2081,8 @@ func (at *AutoTrader) ManualClosePosition(symbol string, side string) error {
 	}
 	
 	// 记录订单ID（如果有）
-	if orderId, ok := result["order_id"].(string); ok {
-		log.Printf("[%s] 📝 平仓订单ID: %s", at.name, orderId)
+	if result.OrderID != 0 {
+		log.Printf("[%s] 📝 平仓订单ID: %d", at.name, result.OrderID)
 	}
 	
 	// 清理持仓时间记录（内存 + 数据库）
@@ -1536,11 +2097,133 @@ func (at *AutoTrader) ManualClosePosition(symbol string, side string) error {
 			log.Printf("[%s] ⚠️  从数据库删除开仓时间失败: %v", at.name, err)
 		}
 	}
+	at.deleteEntrySnapshot(symbol, side)
+	at.releaseRiskBudget(symbol, side)
 	
 	log.Printf("[%s] ✅ 手动平仓成功: %s %s", at.name, symbol, side)
 	return nil
 }
 
+// ExecuteManualDecision 执行操作员手动注入的决策
+// 决策与AI决策走相同的验证和执行流程，并以 manual 标记记录到决策历史
+// 返回的决策记录即使执行失败也已保存（Success=false，ErrorMessage为失败原因）
+func (at *AutoTrader) ExecuteManualDecision(d *decision.Decision) (*logger.DecisionRecord, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	if sharedstate.KillSwitchActive() {
+		return nil, fmt.Errorf("全局停止开关已开启，拒绝执行手动决策")
+	}
+
+	d.Symbol = market.Normalize(d.Symbol)
+	log.Printf("[%s] 🖐️ 手动注入决策: %s %s - %s", at.name, d.Symbol, d.Action, d.Reasoning)
+	return at.executeOperatorDecision(d, "🖐️ 操作员手动注入决策")
+}
+
+// executeOperatorDecision 验证并执行操作员提交/审批的决策，记录为manual（调用方需持有cycleMu）
+func (at *AutoTrader) executeOperatorDecision(d *decision.Decision, cotTrace string) (*logger.DecisionRecord, error) {
+	record := &logger.DecisionRecord{
+		ExecutionLog:  []string{},
+		Success:       true,
+		Manual:        true,
+		CoTTrace:      cotTrace,
+		SchemaVersion: decision.CurrentSchemaVersion,
+	}
+	if d.Reasoning != "" {
+		record.CoTTrace += "\n" + d.Reasoning
+	}
+	decisionJSON, _ := json.MarshalIndent([]decision.Decision{*d}, "", "  ")
+	record.DecisionJSON = string(decisionJSON)
+
+	// 1. 构建交易上下文（与AI决策周期一致，验证依赖账户和持仓状态）
+	ctx, autoClosedPositions, err := at.buildTradingContext()
+	if err != nil {
+		record.Success = false
+		record.ErrorMessage = fmt.Sprintf("构建交易上下文失败: %v", err)
+		if logErr := at.decisionLogger.LogDecision(record); logErr != nil {
+			log.Printf("⚠ 保存决策记录失败: %v", logErr)
+		}
+		return record, fmt.Errorf("构建交易上下文失败: %w", err)
+	}
+	// 操作员决策不带AI信心度，不检查最低信心度
+	ctx.ConfidenceFloor = nil
+
+	for _, autoCloseAction := range autoClosedPositions {
+		record.Decisions = append(record.Decisions, autoCloseAction)
+		record.ExecutionLog = append(record.ExecutionLog,
+			fmt.Sprintf("🤖 %s %s 自动平仓（止损/止盈触发）", autoCloseAction.Symbol, autoCloseAction.Action))
+	}
+
+	record.LearningSummaryID = ctx.AILearningSummaryID
+	record.AccountState = logger.AccountSnapshot{
+		TotalBalance:          ctx.Account.TotalEquity,
+		AvailableBalance:      ctx.Account.AvailableBalance,
+		TotalUnrealizedProfit: ctx.Account.TotalPnL,
+		PositionCount:         ctx.Account.PositionCount,
+		MarginUsedPct:         ctx.Account.MarginUsedPct,
+	}
+	for _, pos := range ctx.Positions {
+		record.Positions = append(record.Positions, logger.PositionSnapshot{
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			PositionAmt:      pos.Quantity,
+			EntryPrice:       pos.EntryPrice,
+			MarkPrice:        pos.MarkPrice,
+			UnrealizedProfit: pos.UnrealizedPnL,
+			Leverage:         float64(pos.Leverage),
+			LiquidationPrice: pos.LiquidationPrice,
+		})
+	}
+
+	// 2. 验证决策（规则与AI决策一致）
+	if err := decision.ValidateDecision(d, ctx); err != nil {
+		d.ValidationError = err.Error()
+		decisionJSON, _ := json.MarshalIndent([]decision.Decision{*d}, "", "  ")
+		record.DecisionJSON = string(decisionJSON)
+		record.Success = false
+		record.ErrorMessage = fmt.Sprintf("决策验证失败: %v", err)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 验证失败: %v", d.Symbol, d.Action, err))
+		at.notifyWebhooks(WebhookValidationRejected, ValidationEventData{
+			Cycle: at.callCount, Source: "operator", Symbol: d.Symbol, Action: d.Action, Error: err.Error(),
+		})
+		if logErr := at.decisionLogger.LogDecision(record); logErr != nil {
+			log.Printf("⚠ 保存决策记录失败: %v", logErr)
+		}
+		return record, fmt.Errorf("决策验证失败: %w", err)
+	}
+
+	// 3. 执行决策
+	actionRecord := logger.DecisionAction{
+		Action:    d.Action,
+		Symbol:    d.Symbol,
+		Leverage:  d.Leverage,
+		Timestamp: at.now(),
+	}
+	execErr := at.executeDecisionWithRecord(d, &actionRecord)
+	if execErr != nil {
+		log.Printf("❌ 执行手动决策失败 (%s %s): %v", d.Symbol, d.Action, execErr)
+		setActionError(&actionRecord, execErr)
+		record.Success = false
+		record.ErrorMessage = fmt.Sprintf("执行决策失败: %v", execErr)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, execErr))
+	} else {
+		actionRecord.Success = true
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
+	}
+	record.Decisions = append(record.Decisions, actionRecord)
+
+	// 4. 保存决策记录
+	if err := at.decisionLogger.LogDecision(record); err != nil {
+		log.Printf("⚠ 保存决策记录失败: %v", err)
+	}
+
+	if execErr != nil {
+		return record, fmt.Errorf("执行决策失败: %w", execErr)
+	}
+	log.Printf("[%s] ✅ 操作员决策执行成功: %s %s", at.name, d.Symbol, d.Action)
+	return record, nil
+}
+
 // GetAccountInfo 获取账户信息（用于API）
 func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	balance, err := at.trader.GetBalance()
@@ -1549,22 +2232,12 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	}
 
 	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
-
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	totalWalletBalance := balance.TotalWalletBalance
+	totalUnrealizedProfit := balance.TotalUnrealizedProfit
+	availableBalance := balance.AvailableBalance
 
 	// Total Equity = 钱包余额 + 未实现盈亏
-	totalEquity := totalWalletBalance + totalUnrealizedProfit
+	totalEquity := balance.TotalEquity()
 
 	// 获取持仓计算总保证金
 	positions, err := at.trader.GetPositions()
@@ -1574,27 +2247,20 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 
 	totalMarginUsed := 0.0
 	totalUnrealizedPnL := 0.0
+	totalNotional := 0.0
 	for _, pos := range positions {
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		totalUnrealizedPnL += unrealizedPnl
-
-		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
-		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
+		totalUnrealizedPnL += pos.UnrealizedProfit
+		marginUsed := (pos.Quantity * pos.MarkPrice) / float64(at.positionLeverage(pos))
 		totalMarginUsed += marginUsed
+		totalNotional += pos.Quantity * pos.MarkPrice
 	}
 
-	totalPnL := totalEquity - at.initialBalance
+	// 盈亏相对净投入（初始余额+净入金）计算，入金/出金不计为盈亏
+	basis, netFlows := at.costBasis()
+	totalPnL := totalEquity - basis
 	totalPnLPct := 0.0
-	if at.initialBalance > 0 {
-		totalPnLPct = (totalPnL / at.initialBalance) * 100
+	if basis > 0 {
+		totalPnLPct = (totalPnL / basis) * 100
 	}
 
 	marginUsedPct := 0.0
@@ -1602,6 +2268,9 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
 	}
 
+	// 按报告币种折算（跨交易所对比时使用）
+	rate := at.GetReportingRate()
+
 	return map[string]interface{}{
 		// 核心字段
 		"total_equity":      totalEquity,           // 账户净值 = wallet + unrealized
@@ -1610,25 +2279,49 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"available_balance": availableBalance,      // 可用余额
 
 		// 盈亏统计
-		"total_pnl":            totalPnL,           // 总盈亏 = equity - initial
-		"total_pnl_pct":        totalPnLPct,        // 总盈亏百分比
+		"total_pnl":            totalPnL,           // 总盈亏 = equity - (initial + net_flows)
+		"total_pnl_pct":        totalPnLPct,        // 总盈亏百分比（相对净投入）
 		"total_unrealized_pnl": totalUnrealizedPnL, // 未实现盈亏（从持仓计算）
 		"initial_balance":      at.initialBalance,  // 初始余额
+		"net_flows":            netFlows,           // 累计净入金（入金 - 出金）
+		"cost_basis":           basis,              // 净投入 = 初始余额 + 净入金
 		"daily_pnl":            at.dailyPnL,        // 日盈亏
 
 		// 持仓信息
 		"position_count":  len(positions),  // 持仓数量
 		"margin_used":     totalMarginUsed, // 保证金占用
 		"margin_used_pct": marginUsedPct,   // 保证金使用率
+		"total_notional":  totalNotional,   // 持仓名义价值合计
+		"risk_groups":     groupExposure(positions), // 分组风控额度占用（未启用时为null）
+
+		// 以上金额均以 settlement_asset 计价；reporting 为按指数价格折算到报告币种的金额
+		"settlement_asset": rate.SettlementAsset,
+		"reporting": map[string]interface{}{
+			"currency":          rate.Currency,
+			"rate":              rate.Rate,
+			"estimated":         rate.Estimated,
+			"total_equity":      rate.Convert(totalEquity),
+			"available_balance": rate.Convert(availableBalance),
+			"total_pnl":         rate.Convert(totalPnL),
+			"cost_basis":        rate.Convert(basis),
+			"margin_used":       rate.Convert(totalMarginUsed),
+			"total_notional":    rate.Convert(totalNotional),
+		},
 	}, nil
 }
 
+// PromptLanguage 提示词语言（生成的prompt章节、prompt模板和AI学习总结使用该语言）
+func (at *AutoTrader) PromptLanguage() i18n.Lang {
+	return i18n.Parse(at.config.PromptLanguage)
+}
+
 // CallAI 调用AI（供外部使用，如生成学习总结）
 func (at *AutoTrader) CallAI(systemPrompt, userPrompt string) (string, error) {
-	if at.mcpClient == nil {
+	client := at.aiClient()
+	if client == nil {
 		return "", fmt.Errorf("MCP客户端未初始化")
 	}
-	return at.mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	return client.CallWithMessages(systemPrompt, userPrompt)
 }
 
 // maybeGenerateAILearningSummary 检查是否需要生成AI学习总结
@@ -1652,35 +2345,14 @@ func (at *AutoTrader) maybeGenerateAILearningSummary() {
 
 	log.Printf("🤖 [%s] 正在生成AI学习总结（分析最近%d笔交易）...", at.name, len(trades))
 
-	// 构建分析prompt
-	systemPrompt := `你是一个专业的加密货币交易分析师。请分析这些历史交易记录，用简洁的Markdown格式输出总结。
-
-要求：
-1. 找出3个最关键的失败模式（什么总是导致亏损）
-2. 找出2个成功模式（什么策略有效）
-3. 提出3条具体的改进建议
-
-**重要**：只总结交易策略和模式，**不要提及具体币种名称**（如BTC、ETH等），避免形成偏见影响未来判断。
-
-格式：
-## ❌ 避免这些错误
-1. [具体错误模式，1句话，不提币种]
-2. ...
-
-## ✅ 复制这些成功策略
-1. [具体成功模式，1句话，不提币种]
-2. ...
-
-## 💡 改进建议
-1. [具体建议，1句话]
-2. ...
+	// 构建分析prompt（按trader的提示词语言生成，总结会在同一语言的prompt中使用）
+	lang := at.PromptLanguage()
+	systemPrompt := i18n.T(lang, "learning.summary_system")
 
-保持简洁，每个要点不超过15个字。`
-
-	userPrompt := at.buildTradeAnalysisPrompt(trades)
+	userPrompt := at.buildTradeAnalysisPrompt(trades, lang)
 
 	// 调用AI
-	summary, err := at.mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	summary, err := at.aiClient().CallWithMessages(systemPrompt, userPrompt)
 	if err != nil {
 		log.Printf("❌ [%s] AI分析失败: %v", at.name, err)
 		return
@@ -1724,10 +2396,10 @@ func (at *AutoTrader) maybeGenerateAILearningSummary() {
 }
 
 // buildTradeAnalysisPrompt 构建交易分析prompt
-func (at *AutoTrader) buildTradeAnalysisPrompt(trades []*models.TradeOutcome) string {
+func (at *AutoTrader) buildTradeAnalysisPrompt(trades []*models.TradeOutcome, lang i18n.Lang) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("# 最近%d笔交易记录\n\n", len(trades)))
+	sb.WriteString(i18n.T(lang, "learning.trades_title", len(trades)))
 
 	for i, trade := range trades {
 		emoji := "✅"
@@ -1736,18 +2408,27 @@ func (at *AutoTrader) buildTradeAnalysisPrompt(trades []*models.TradeOutcome) st
 		}
 
 		sb.WriteString(fmt.Sprintf("%d. %s %s %s\n", i+1, emoji, trade.Symbol, strings.ToUpper(trade.Side)))
-		sb.WriteString(fmt.Sprintf("   盈亏: %.2f USDT (%.1f%%) | 持仓: %d分钟\n", 
+		sb.WriteString(i18n.T(lang, "learning.trade_pnl",
 			trade.PnL, trade.PnLPct, trade.DurationMinutes))
 		
 		if trade.FailureType != "" {
-			sb.WriteString(fmt.Sprintf("   失败: %s\n", trade.FailureType))
+			sb.WriteString(i18n.T(lang, "learning.trade_failure", trade.FailureType))
 		}
 		if trade.IsPremature {
-			sb.WriteString("   ⚠️ 过早平仓\n")
+			sb.WriteString(i18n.T(lang, "learning.trade_premature"))
+		}
+		if trade.EntryRSI > 0 {
+			sb.WriteString(i18n.T(lang, "learning.trade_indicators",
+				trade.EntryRSI, trade.EntryMACD, trade.EntryVolRatio))
 		}
 		sb.WriteString("\n")
 	}
 
+	// 开仓指标归因（如 "RSI>70开仓的平均亏损为整体的2倍"）
+	if attribution := logger.FormatEntryAttribution(logger.AttributeEntryIndicators(trades, database.CurrentLearningConfig()), lang); attribution != "" {
+		sb.WriteString(attribution)
+	}
+
 	return sb.String()
 }
 
@@ -1760,21 +2441,13 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 
 	var result []map[string]interface{}
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
-
-		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
-		}
+		symbol := pos.Symbol
+		side := pos.Side
+		entryPrice := pos.EntryPrice
+		markPrice, unrealizedPnl, markSource := at.liveMark(pos)
+		quantity := pos.Quantity
+		liquidationPrice := pos.LiquidationPrice
+		leverage := at.positionLeverage(pos)
 
 		pnlPct := 0.0
 		if side == "long" {
@@ -1793,7 +2466,7 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 		if openTimeMs, exists := at.positionFirstSeenTime[posKey]; exists {
 			openTimeObj := time.Unix(openTimeMs/1000, (openTimeMs%1000)*1000000)
 			openTime = openTimeObj.Format(time.RFC3339)
-			holdingMinutes = int64(time.Now().Sub(openTimeObj).Minutes())
+			holdingMinutes = int64(at.now().Sub(openTimeObj).Minutes())
 		}
 		at.mu.RUnlock()
 
@@ -1807,16 +2480,19 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 			"unrealized_pnl":     unrealizedPnl,
 			"unrealized_pnl_pct": pnlPct,
 			"liquidation_price":  liquidationPrice,
+			"liquidation_distance_pct": liquidationDistancePct(markPrice, liquidationPrice), // 标记价格到强平价的距离(%)
+			"mark_price_source":  markSource, // stream=标记价格数据流实时计算，exchange=交易所持仓接口
 			"margin_used":        marginUsed,
 			"open_time":          openTime,
 			"holding_minutes":    holdingMinutes,
+			"funding_arb":        isFundingArbLeg(at.id, symbol, side), // 资金费率套利仓位（由套利模块管理）
 		})
 	}
 
 	return result, nil
 }
 
-// sortDecisionsByPriority 对决策排序：先平仓，再开仓，最后hold/wait
+// sortDecisionsByPriority 对决策排序：先平仓，再调整止损止盈，再开仓，最后hold/wait
 // 这样可以避免换仓时仓位叠加超限
 func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision {
 	if len(decisions) <= 1 {
@@ -1828,10 +2504,12 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 		switch action {
 		case "close_long", "close_short":
 			return 1 // 最高优先级：先平仓
+		case "update_stop_loss", "update_take_profit":
+			return 2 // 调整现有持仓的保护单
 		case "open_long", "open_short":
-			return 2 // 次优先级：后开仓
+			return 3 // 后开仓
 		case "hold", "wait":
-			return 3 // 最低优先级：观望
+			return 4 // 最低优先级：观望
 		default:
 			return 999 // 未知动作放最后
 		}
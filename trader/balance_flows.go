@@ -0,0 +1,107 @@
+package trader
+
+import (
+	"log"
+	"nofx/analytics"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/market"
+	"time"
+)
+
+// balanceReconcileConfig 入金/出金对账配置
+func balanceReconcileConfig() database.BalanceReconcileConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetBalanceReconcileConfig()
+	}
+	return database.BalanceReconcileConfig{Enabled: true, IntervalMinutes: 60}
+}
+
+// reconcileBalanceFlows 按间隔从交易所同步资金划转并更新累计净入金
+// 首次同步从最早的决策记录开始（之前的资金已包含在初始余额中），之后从台账中最近一笔划转开始增量同步
+func (at *AutoTrader) reconcileBalanceFlows(now time.Time) {
+	cfg := balanceReconcileConfig()
+	db := at.decisionLogger.GetDB()
+	if !cfg.Enabled || db == nil {
+		return
+	}
+	at.mu.RLock()
+	lastSync := at.lastFlowSync
+	at.mu.RUnlock()
+	if !lastSync.IsZero() && now.Sub(lastSync) < time.Duration(cfg.IntervalMinutes)*time.Minute {
+		return
+	}
+
+	since, err := db.BalanceFlow().GetLatestTime()
+	if err != nil {
+		log.Printf("⚠️  [%s] 查询资金划转台账失败: %v", at.name, err)
+		return
+	}
+	if since.IsZero() {
+		if since, err = db.Decision().GetFirstTimestamp(); err != nil || since.IsZero() {
+			since = at.startTime
+		}
+	}
+
+	transfers, err := at.trader.GetTransfers(since.UnixMilli())
+	if err != nil {
+		log.Printf("⚠️  [%s] 同步资金划转失败: %v", at.name, err)
+		return
+	}
+	added := 0
+	for _, t := range transfers {
+		// 非稳定币划转（如BNB）无法按1:1折算，不计入净投入
+		if !market.IsStableAsset(t.Asset) {
+			log.Printf("  ⚠️  [%s] 忽略非稳定币划转: %s %+.6f", at.name, t.Asset, t.Amount)
+			continue
+		}
+		inserted, err := db.BalanceFlow().Insert(&models.BalanceFlow{
+			TranID:    t.ID,
+			Asset:     t.Asset,
+			Amount:    t.Amount,
+			Timestamp: time.UnixMilli(t.Time),
+		})
+		if err != nil {
+			log.Printf("⚠️  [%s] 记录资金划转失败: %v", at.name, err)
+			continue
+		}
+		if inserted {
+			added++
+			log.Printf("💸 [%s] 检测到资金划转: %+.2f %s (%s)", at.name, t.Amount, t.Asset, time.UnixMilli(t.Time).Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	net, err := db.BalanceFlow().GetNetTotal()
+	if err != nil {
+		log.Printf("⚠️  [%s] 统计净入金失败: %v", at.name, err)
+		return
+	}
+	at.mu.Lock()
+	at.netFlows = net
+	at.lastFlowSync = now
+	at.mu.Unlock()
+	if added > 0 {
+		log.Printf("💰 [%s] 净投入已更新: 初始余额 %.2f + 净入金 %+.2f = %.2f USDT", at.name, at.initialBalance, net, at.initialBalance+net)
+	}
+}
+
+// costBasis 净投入 = 初始余额 + 累计净入金（盈亏和收益率以此为基准）
+func (at *AutoTrader) costBasis() (basis, netFlows float64) {
+	at.mu.RLock()
+	netFlows = at.netFlows
+	at.mu.RUnlock()
+	return at.initialBalance + netFlows, netFlows
+}
+
+// GetFlowLedger 资金划转累计台账（用于收益曲线扣除入金/出金）
+func (at *AutoTrader) GetFlowLedger() (*analytics.FlowLedger, []*models.BalanceFlow, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return analytics.NewFlowLedger(nil), nil, nil
+	}
+	flows, err := db.BalanceFlow().GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	return analytics.NewFlowLedger(flows), flows, nil
+}
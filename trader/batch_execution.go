@@ -0,0 +1,125 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+// decisionResult 单个决策的执行结果
+type decisionResult struct {
+	action logger.DecisionAction
+	log    string
+}
+
+// isCloseAction 是否为平仓决策
+func isCloseAction(action string) bool {
+	return action == "close_long" || action == "close_short"
+}
+
+// executeDecisionBatch 批量执行已排序的决策
+// 1. 平仓决策相互独立，按 execution_close_concurrency 并发执行，缩短多笔平仓的敞口时间
+// 2. 开仓等其他决策在全部平仓完成后顺序执行，平仓释放的保证金对开仓可见
+// 3. 顺序下单之间按交易所限频配置间隔（execution_order_delay_ms[_<exchange>]）
+// 执行日志和决策记录保持排序后的顺序
+func (at *AutoTrader) executeDecisionBatch(decisions []decision.Decision, record *logger.DecisionRecord) {
+	cfg := executionConfig()
+	delay := cfg.OrderDelay(at.exchange)
+	results := make([]decisionResult, len(decisions))
+
+	var closes, others []int
+	for i := range decisions {
+		if isCloseAction(decisions[i].Action) {
+			closes = append(closes, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+
+	closed := false
+	if len(closes) > 0 {
+		concurrency := cfg.CloseConcurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		if concurrency > 1 && len(closes) > 1 {
+			log.Printf("⚡ 并发执行 %d 个平仓决策（并发数 %d）", len(closes), concurrency)
+		}
+
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for n, i := range closes {
+			sem <- struct{}{}
+			// 顺序执行时仍按交易所限频间隔下单
+			if concurrency == 1 && n > 0 && delay > 0 {
+				time.Sleep(delay)
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				results[i] = at.executeSingleDecision(&decisions[i])
+			}(i)
+		}
+		wg.Wait()
+
+		for _, i := range closes {
+			closed = closed || results[i].action.Success
+		}
+	}
+
+	placed := closed
+	for _, i := range others {
+		d := &decisions[i]
+		placesOrder := d.Action == "open_long" || d.Action == "open_short" || decision.IsExitLevelUpdate(d.Action)
+		if pending := at.parkForApproval(d); pending != nil {
+			results[i] = decisionResult{
+				action: logger.DecisionAction{
+					Action:    d.Action,
+					Symbol:    d.Symbol,
+					Leverage:  d.Leverage,
+					Timestamp: at.now(),
+					Error:     fmt.Sprintf("等待人工审批（%s），审批ID=%s", pending.Reason, pending.ID),
+				},
+				log: fmt.Sprintf("⏸️ %s %s 等待人工审批（%s），审批ID=%s", d.Symbol, d.Action, pending.Reason, pending.ID),
+			}
+			continue
+		}
+		if placesOrder && placed && delay > 0 {
+			time.Sleep(delay)
+		}
+		results[i] = at.executeSingleDecision(d)
+		if placesOrder && results[i].action.Success {
+			placed = true
+		}
+	}
+
+	for _, r := range results {
+		record.ExecutionLog = append(record.ExecutionLog, r.log)
+		record.Decisions = append(record.Decisions, r.action)
+	}
+}
+
+// executeSingleDecision 执行单个决策并生成记录
+func (at *AutoTrader) executeSingleDecision(d *decision.Decision) decisionResult {
+	actionRecord := logger.DecisionAction{
+		Action:    d.Action,
+		Symbol:    d.Symbol,
+		Quantity:  0,
+		Leverage:  d.Leverage,
+		Price:     0,
+		Timestamp: at.now(),
+		Success:   false,
+	}
+
+	if err := at.executeDecisionWithRecord(d, &actionRecord); err != nil {
+		log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
+		setActionError(&actionRecord, err)
+		return decisionResult{action: actionRecord, log: fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err)}
+	}
+	actionRecord.Success = true
+	return decisionResult{action: actionRecord, log: fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action)}
+}
@@ -7,6 +7,9 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/money"
 	"strconv"
 	"sync"
 	"time"
@@ -19,17 +22,21 @@ type FuturesTrader struct {
 	client *futures.Client
 
 	// 余额缓存
-	cachedBalance     map[string]interface{}
+	cachedBalance     *Balance
 	balanceCacheTime  time.Time
 	balanceCacheMutex sync.RWMutex
 
 	// 持仓缓存
-	cachedPositions     []map[string]interface{}
+	cachedPositions     []Position
 	positionsCacheTime  time.Time
 	positionsCacheMutex sync.RWMutex
 
 	// 缓存有效期（15秒）
 	cacheDuration time.Duration
+
+	// 持仓模式缓存（true=双向持仓/对冲模式，false=单向持仓）
+	dualSidePosition  *bool
+	positionModeMutex sync.Mutex
 }
 
 // NewFuturesTrader 创建合约交易器
@@ -42,14 +49,15 @@ func NewFuturesTrader(apiKey, secretKey string) *FuturesTrader {
 }
 
 // GetBalance 获取账户余额（带缓存）
-func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
+func (t *FuturesTrader) GetBalance() (*Balance, error) {
 	// 先检查缓存是否有效
 	t.balanceCacheMutex.RLock()
 	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
 		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
-		return t.cachedBalance, nil
+		balance := *t.cachedBalance
+		return &balance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
 
@@ -61,10 +69,29 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
 	}
 
-	result := make(map[string]interface{})
-	result["totalWalletBalance"], _ = strconv.ParseFloat(account.TotalWalletBalance, 64)
-	result["availableBalance"], _ = strconv.ParseFloat(account.AvailableBalance, 64)
-	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
+	result := &Balance{Assets: make(map[string]AssetBalance)}
+	result.TotalWalletBalance, _ = strconv.ParseFloat(account.TotalWalletBalance, 64)
+	result.AvailableBalance, _ = strconv.ParseFloat(account.AvailableBalance, 64)
+	result.TotalUnrealizedProfit, _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
+
+	// 各保证金资产余额（USDT本位和USDC本位合约分别使用各自资产作为保证金）
+	for _, a := range account.Assets {
+		asset := AssetBalance{Asset: a.Asset}
+		asset.WalletBalance, _ = strconv.ParseFloat(a.WalletBalance, 64)
+		asset.AvailableBalance, _ = strconv.ParseFloat(a.AvailableBalance, 64)
+		asset.UnrealizedProfit, _ = strconv.ParseFloat(a.UnrealizedProfit, 64)
+		if asset.WalletBalance == 0 && asset.UnrealizedProfit == 0 {
+			continue
+		}
+		result.Assets[a.Asset] = asset
+
+		// 单资产模式下总额只包含USDT，其他稳定币保证金（USDC）按1:1计入
+		if !account.MultiAssetsMargin && a.Asset != "USDT" && market.IsStableAsset(a.Asset) {
+			result.TotalWalletBalance += asset.WalletBalance
+			result.AvailableBalance += asset.AvailableBalance
+			result.TotalUnrealizedProfit += asset.UnrealizedProfit
+		}
+	}
 
 	log.Printf("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
 		account.TotalWalletBalance,
@@ -73,7 +100,8 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 
 	// 更新缓存
 	t.balanceCacheMutex.Lock()
-	t.cachedBalance = result
+	cached := *result
+	t.cachedBalance = &cached
 	t.balanceCacheTime = time.Now()
 	t.balanceCacheMutex.Unlock()
 
@@ -81,14 +109,14 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 }
 
 // GetPositions 获取所有持仓（带缓存）
-func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
+func (t *FuturesTrader) GetPositions() ([]Position, error) {
 	// 先检查缓存是否有效
 	t.positionsCacheMutex.RLock()
 	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.positionsCacheTime)
 		t.positionsCacheMutex.RUnlock()
 		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
-		return t.cachedPositions, nil
+		return append([]Position(nil), t.cachedPositions...), nil
 	}
 	t.positionsCacheMutex.RUnlock()
 
@@ -99,65 +127,68 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
 
-	var result []map[string]interface{}
+	var result []Position
 	for _, pos := range positions {
 		posAmt, _ := strconv.ParseFloat(pos.PositionAmt, 64)
 		if posAmt == 0 {
 			continue // 跳过无持仓的
 		}
 
-		posMap := make(map[string]interface{})
-		posMap["symbol"] = pos.Symbol
-		posMap["positionAmt"], _ = strconv.ParseFloat(pos.PositionAmt, 64)
-		posMap["entryPrice"], _ = strconv.ParseFloat(pos.EntryPrice, 64)
-		posMap["markPrice"], _ = strconv.ParseFloat(pos.MarkPrice, 64)
-		posMap["unRealizedProfit"], _ = strconv.ParseFloat(pos.UnRealizedProfit, 64)
-		posMap["leverage"], _ = strconv.ParseFloat(pos.Leverage, 64)
-		posMap["liquidationPrice"], _ = strconv.ParseFloat(pos.LiquidationPrice, 64)
+		p := Position{Symbol: pos.Symbol, Quantity: posAmt}
+		p.EntryPrice, _ = strconv.ParseFloat(pos.EntryPrice, 64)
+		p.MarkPrice, _ = strconv.ParseFloat(pos.MarkPrice, 64)
+		p.UnrealizedProfit, _ = strconv.ParseFloat(pos.UnRealizedProfit, 64)
+		p.LiquidationPrice, _ = strconv.ParseFloat(pos.LiquidationPrice, 64)
+		p.Leverage, _ = strconv.Atoi(pos.Leverage)
+		p.MarginMode = normalizeMarginMode(pos.MarginType)
+		if p.MarginMode == decision.MarginModeIsolated {
+			p.IsolatedMargin, _ = strconv.ParseFloat(pos.IsolatedMargin, 64)
+		}
 
-		// 判断方向
+		// 判断方向（币安空仓数量为负数，统一转为正数）
 		if posAmt > 0 {
-			posMap["side"] = "long"
+			p.Side = "long"
 		} else {
-			posMap["side"] = "short"
+			p.Side = "short"
+			p.Quantity = -posAmt
 		}
 
-		result = append(result, posMap)
+		result = append(result, p)
 	}
 
 	// 更新缓存
 	t.positionsCacheMutex.Lock()
-	t.cachedPositions = result
+	t.cachedPositions = append([]Position(nil), result...)
 	t.positionsCacheTime = time.Now()
 	t.positionsCacheMutex.Unlock()
 
 	return result, nil
 }
 
-// SetLeverage 设置杠杆（智能判断+冷却期）
-func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
-	// 先尝试获取当前杠杆（从持仓信息）
+// SetLeverage 设置杠杆（智能判断+冷却期），返回交易所确认的实际杠杆
+func (t *FuturesTrader) SetLeverage(symbol string, leverage int) (int, error) {
+	// 先查询该币种当前杠杆（positionRisk 没有持仓时也会返回杠杆设置，能发现手动修改过的杠杆）
 	currentLeverage := 0
-	positions, err := t.GetPositions()
+	risks, err := t.client.NewGetPositionRiskService().Symbol(symbol).Do(context.Background())
 	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == symbol {
-				if lev, ok := pos["leverage"].(float64); ok {
-					currentLeverage = int(lev)
-					break
-				}
+		for _, risk := range risks {
+			if risk.Symbol == symbol {
+				currentLeverage, _ = strconv.Atoi(risk.Leverage)
+				break
 			}
 		}
+	} else {
+		log.Printf("  ⚠ 查询 %s 当前杠杆失败: %v", symbol, err)
 	}
 
 	// 如果当前杠杆已经是目标杠杆，跳过
 	if currentLeverage == leverage && currentLeverage > 0 {
 		log.Printf("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
-		return nil
+		return leverage, nil
 	}
 
 	// 切换杠杆
-	_, err = t.client.NewChangeLeverageService().
+	resp, err := t.client.NewChangeLeverageService().
 		Symbol(symbol).
 		Leverage(leverage).
 		Do(context.Background())
@@ -166,18 +197,27 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if contains(err.Error(), "No need to change") {
 			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
-			return nil
+			return leverage, nil
 		}
-		return fmt.Errorf("设置杠杆失败: %w", err)
+		return 0, fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	// 以交易所返回的杠杆为准（可能被杠杆分层上限调整）
+	effective := leverage
+	if resp != nil && resp.Leverage > 0 {
+		effective = resp.Leverage
+	}
+	if effective != leverage {
+		log.Printf("  ⚠ %s 请求杠杆 %dx，交易所实际生效 %dx", symbol, leverage, effective)
+	} else {
+		log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	}
 
 	// 切换杠杆后等待5秒（避免冷却期错误）
 	log.Printf("  ⏱ 等待5秒冷却期...")
 	time.Sleep(5 * time.Second)
 
-	return nil
+	return effective, nil
 }
 
 // SetMarginType 设置保证金模式
@@ -206,14 +246,14 @@ func (t *FuturesTrader) SetMarginType(symbol string, marginType futures.MarginTy
 }
 
 // OpenLong 开多仓
-func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (*Order, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
 		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
 	}
 
 	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if _, err := t.SetLeverage(symbol, leverage); err != nil {
 		return nil, err
 	}
 
@@ -232,34 +272,30 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeBuy).
-		PositionSide(futures.PositionSideTypeLong).
+		PositionSide(t.orderPositionSide(futures.PositionSideTypeLong)).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("开多仓失败: %w", err)
+		return nil, describeOrderError("开多仓", err)
 	}
 
 	log.Printf("✓ 开多仓成功: %s 数量: %s", symbol, quantityStr)
 	log.Printf("  订单ID: %d", order.OrderID)
 
-	result := make(map[string]interface{})
-	result["orderId"] = order.OrderID
-	result["symbol"] = order.Symbol
-	result["status"] = order.Status
-	return result, nil
+	return binanceOrderResult(order), nil
 }
 
 // OpenShort 开空仓
-func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (*Order, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
 		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
 	}
 
 	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if _, err := t.SetLeverage(symbol, leverage); err != nil {
 		return nil, err
 	}
 
@@ -278,27 +314,23 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 	order, err := t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(futures.SideTypeSell).
-		PositionSide(futures.PositionSideTypeShort).
+		PositionSide(t.orderPositionSide(futures.PositionSideTypeShort)).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("开空仓失败: %w", err)
+		return nil, describeOrderError("开空仓", err)
 	}
 
 	log.Printf("✓ 开空仓成功: %s 数量: %s", symbol, quantityStr)
 	log.Printf("  订单ID: %d", order.OrderID)
 
-	result := make(map[string]interface{})
-	result["orderId"] = order.OrderID
-	result["symbol"] = order.Symbol
-	result["status"] = order.Status
-	return result, nil
+	return binanceOrderResult(order), nil
 }
 
 // CloseLong 平多仓
-func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (*Order, error) {
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -307,8 +339,8 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		}
 
 		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
+			if pos.Symbol == symbol && pos.Side == "long" {
+				quantity = pos.Quantity
 				break
 			}
 		}
@@ -325,16 +357,13 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 	}
 
 	// 创建市价卖出订单（平多）
-	order, err := t.client.NewCreateOrderService().
-		Symbol(symbol).
-		Side(futures.SideTypeSell).
-		PositionSide(futures.PositionSideTypeLong).
+	order, err := t.reduceOnlyOrderService(symbol, futures.SideTypeSell, futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("平多仓失败: %w", err)
+		return nil, describeOrderError("平多仓", err)
 	}
 
 	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, quantityStr)
@@ -344,15 +373,11 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		log.Printf("  ⚠ 取消挂单失败: %v", err)
 	}
 
-	result := make(map[string]interface{})
-	result["orderId"] = order.OrderID
-	result["symbol"] = order.Symbol
-	result["status"] = order.Status
-	return result, nil
+	return binanceOrderResult(order), nil
 }
 
 // CloseShort 平空仓
-func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (*Order, error) {
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -361,8 +386,8 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		}
 
 		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "short" {
-				quantity = -pos["positionAmt"].(float64) // 空仓数量是负的，取绝对值
+			if pos.Symbol == symbol && pos.Side == "short" {
+				quantity = pos.Quantity
 				break
 			}
 		}
@@ -379,16 +404,13 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 	}
 
 	// 创建市价买入订单（平空）
-	order, err := t.client.NewCreateOrderService().
-		Symbol(symbol).
-		Side(futures.SideTypeBuy).
-		PositionSide(futures.PositionSideTypeShort).
+	order, err := t.reduceOnlyOrderService(symbol, futures.SideTypeBuy, futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
 		Do(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("平空仓失败: %w", err)
+		return nil, describeOrderError("平空仓", err)
 	}
 
 	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, quantityStr)
@@ -398,11 +420,52 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		log.Printf("  ⚠ 取消挂单失败: %v", err)
 	}
 
-	result := make(map[string]interface{})
-	result["orderId"] = order.OrderID
-	result["symbol"] = order.Symbol
-	result["status"] = order.Status
-	return result, nil
+	return binanceOrderResult(order), nil
+}
+
+// isDualSidePosition 查询账户是否为双向持仓模式（结果缓存，查询失败时按双向持仓处理）
+func (t *FuturesTrader) isDualSidePosition() bool {
+	t.positionModeMutex.Lock()
+	defer t.positionModeMutex.Unlock()
+
+	if t.dualSidePosition != nil {
+		return *t.dualSidePosition
+	}
+
+	mode, err := t.client.NewGetPositionModeService().Do(context.Background())
+	if err != nil {
+		log.Printf("  ⚠ 查询持仓模式失败，按双向持仓处理: %v", err)
+		return true
+	}
+
+	dual := mode.DualSidePosition
+	t.dualSidePosition = &dual
+	if !dual {
+		log.Printf("  ℹ️ 币安账户为单向持仓模式，平仓单将使用reduceOnly")
+	}
+	return dual
+}
+
+// orderPositionSide 根据账户持仓模式返回下单使用的positionSide
+func (t *FuturesTrader) orderPositionSide(posSide futures.PositionSideType) futures.PositionSideType {
+	if t.isDualSidePosition() {
+		return posSide
+	}
+	return futures.PositionSideTypeBoth
+}
+
+// reduceOnlyOrderService 创建只减仓的订单请求
+// 双向持仓模式下指定positionSide即可保证不会反向开仓（此时币安不接受reduceOnly参数），
+// 单向持仓模式下显式设置reduceOnly=true
+func (t *FuturesTrader) reduceOnlyOrderService(symbol string, side futures.SideType, posSide futures.PositionSideType) *futures.CreateOrderService {
+	svc := t.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(side)
+
+	if t.isDualSidePosition() {
+		return svc.PositionSide(posSide)
+	}
+	return svc.PositionSide(futures.PositionSideTypeBoth).ReduceOnly(true)
 }
 
 // CancelAllOrders 取消该币种的所有挂单
@@ -419,6 +482,77 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 	return nil
 }
 
+// CancelOrder 取消指定挂单
+func (t *FuturesTrader) CancelOrder(symbol string, orderID int64) error {
+	_, err := t.client.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(context.Background())
+
+	if err != nil {
+		return fmt.Errorf("取消订单失败: %w", err)
+	}
+
+	log.Printf("  ✓ 已取消 %s 的挂单 (orderId=%d)", symbol, orderID)
+	return nil
+}
+
+// GetOpenOrders 获取该币种未成交的挂单
+func (t *FuturesTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	orders, err := t.client.NewListOpenOrdersService().
+		Symbol(symbol).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	result := make([]OpenOrder, 0, len(orders))
+	for _, o := range orders {
+		order := OpenOrder{
+			OrderID:      o.OrderID,
+			Symbol:       o.Symbol,
+			Type:         openOrderType(string(o.Type)),
+			Side:         string(o.Side),
+			PositionSide: string(o.PositionSide),
+			ReduceOnly:   o.ReduceOnly || o.ClosePosition,
+		}
+		order.TriggerPrice, _ = strconv.ParseFloat(o.StopPrice, 64)
+		order.Price, _ = strconv.ParseFloat(o.Price, 64)
+		origQty, _ := strconv.ParseFloat(o.OrigQuantity, 64)
+		executedQty, _ := strconv.ParseFloat(o.ExecutedQuantity, 64)
+		order.Quantity = origQty - executedQty
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+// GetOrderStatus 查询订单状态（用于确认实际成交数量和均价）
+func (t *FuturesTrader) GetOrderStatus(symbol string, orderID int64) (*Order, error) {
+	order, err := t.client.NewGetOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("查询订单状态失败: %w", err)
+	}
+
+	result := &Order{OrderID: order.OrderID, Symbol: order.Symbol, Status: string(order.Status), Type: string(order.OrigType)}
+	if result.Type == "" {
+		result.Type = string(order.Type)
+	}
+	result.ExecutedQty, _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
+	result.OrigQty, _ = strconv.ParseFloat(order.OrigQuantity, 64)
+	result.AvgPrice, _ = strconv.ParseFloat(order.AvgPrice, 64)
+	return result, nil
+}
+
+// binanceOrderResult 转换下单响应（市价单响应中的成交数量不可靠，由调用方查询订单状态确认）
+func binanceOrderResult(order *futures.CreateOrderResponse) *Order {
+	result := &Order{OrderID: order.OrderID, Symbol: order.Symbol, Status: string(order.Status)}
+	result.OrigQty, _ = strconv.ParseFloat(order.OrigQuantity, 64)
+	return result
+}
+
 // GetMarketPrice 获取市场价格
 func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
 	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(context.Background())
@@ -465,10 +599,11 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		return err
 	}
 
+	// closePosition=true 的条件单本身只减仓（币安不允许与reduceOnly同时发送）
 	_, err = t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
-		PositionSide(posSide).
+		PositionSide(t.orderPositionSide(posSide)).
 		Type(futures.OrderTypeStopMarket).
 		StopPrice(fmt.Sprintf("%.8f", stopPrice)).
 		Quantity(quantityStr).
@@ -477,7 +612,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		Do(context.Background())
 
 	if err != nil {
-		return fmt.Errorf("设置止损失败: %w", err)
+		return describeOrderError("设置止损", err)
 	}
 
 	log.Printf("  止损价设置: %.4f", stopPrice)
@@ -503,10 +638,11 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		return err
 	}
 
+	// closePosition=true 的条件单本身只减仓（币安不允许与reduceOnly同时发送）
 	_, err = t.client.NewCreateOrderService().
 		Symbol(symbol).
 		Side(side).
-		PositionSide(posSide).
+		PositionSide(t.orderPositionSide(posSide)).
 		Type(futures.OrderTypeTakeProfitMarket).
 		StopPrice(fmt.Sprintf("%.8f", takeProfitPrice)).
 		Quantity(quantityStr).
@@ -515,7 +651,7 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		Do(context.Background())
 
 	if err != nil {
-		return fmt.Errorf("设置止盈失败: %w", err)
+		return describeOrderError("设置止盈", err)
 	}
 
 	log.Printf("  止盈价设置: %.4f", takeProfitPrice)
@@ -547,6 +683,61 @@ func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
 	return 3, nil // 默认精度为3
 }
 
+// GetFundingRate 获取当前资金费率（币安按8小时结算）
+func (t *FuturesTrader) GetFundingRate(symbol string) (FundingRate, error) {
+	indexes, err := t.client.NewPremiumIndexService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("获取资金费率失败: %w", err)
+	}
+	if len(indexes) == 0 {
+		return FundingRate{}, fmt.Errorf("未找到 %s 的资金费率", symbol)
+	}
+	rate, err := strconv.ParseFloat(indexes[0].LastFundingRate, 64)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("资金费率格式错误: %w", err)
+	}
+	return FundingRate{Rate: rate, IntervalHours: 8}, nil
+}
+
+// GetSymbolFilters 获取交易对的下单数量规则（市价单优先使用 MARKET_LOT_SIZE）
+func (t *FuturesTrader) GetSymbolFilters(symbol string) (SymbolFilters, error) {
+	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
+	if err != nil {
+		return SymbolFilters{}, fmt.Errorf("获取交易规则失败: %w", err)
+	}
+
+	for _, s := range exchangeInfo.Symbols {
+		if s.Symbol != symbol {
+			continue
+		}
+		var filters SymbolFilters
+		if lot := s.LotSizeFilter(); lot != nil {
+			filters.StepSize, _ = strconv.ParseFloat(lot.StepSize, 64)
+			filters.MinQty, _ = strconv.ParseFloat(lot.MinQuantity, 64)
+			filters.MaxQty, _ = strconv.ParseFloat(lot.MaxQuantity, 64)
+		}
+		if lot := s.MarketLotSizeFilter(); lot != nil {
+			if step, _ := strconv.ParseFloat(lot.StepSize, 64); step > 0 {
+				filters.StepSize = step
+			}
+			if minQty, _ := strconv.ParseFloat(lot.MinQuantity, 64); minQty > 0 {
+				filters.MinQty = minQty
+			}
+			if maxQty, _ := strconv.ParseFloat(lot.MaxQuantity, 64); maxQty > 0 {
+				filters.MaxQty = maxQty
+			}
+		}
+		if notional := s.MinNotionalFilter(); notional != nil {
+			filters.MinNotional, _ = strconv.ParseFloat(notional.Notional, 64)
+		}
+		if price := s.PriceFilter(); price != nil {
+			filters.TickSize, _ = strconv.ParseFloat(price.TickSize, 64)
+		}
+		return filters, nil
+	}
+	return SymbolFilters{}, fmt.Errorf("未找到交易对 %s 的交易规则", symbol)
+}
+
 // calculatePrecision 从stepSize计算精度
 func calculatePrecision(stepSize string) int {
 	// 去除尾部的0
@@ -598,12 +789,11 @@ func (t *FuturesTrader) FormatQuantity(symbol string, quantity float64) (string,
 		return fmt.Sprintf("%.3f", quantity), nil
 	}
 
-	format := fmt.Sprintf("%%.%df", precision)
-	return fmt.Sprintf(format, quantity), nil
+	return money.FormatFixed(quantity, precision), nil
 }
 
 // GetAccountTrades 获取账户历史成交记录（用于追踪止损止盈订单）
-func (t *FuturesTrader) GetAccountTrades(symbol string, limit int) ([]map[string]interface{}, error) {
+func (t *FuturesTrader) GetAccountTrades(symbol string, limit int) ([]Fill, error) {
 	service := t.client.NewListAccountTradeService().Symbol(symbol)
 	if limit > 0 {
 		service = service.Limit(limit)
@@ -614,33 +804,93 @@ func (t *FuturesTrader) GetAccountTrades(symbol string, limit int) ([]map[string
 		return nil, fmt.Errorf("获取历史成交失败: %w", err)
 	}
 	
-	var result []map[string]interface{}
+	var result []Fill
 	for _, trade := range trades {
-		priceFloat, _ := strconv.ParseFloat(trade.Price, 64)
-		qtyFloat, _ := strconv.ParseFloat(trade.Quantity, 64)
-		realizedPnlFloat, _ := strconv.ParseFloat(trade.RealizedPnl, 64)
-		
-		result = append(result, map[string]interface{}{
-			"id":            trade.ID,
-			"orderId":       trade.OrderID,
-			"symbol":        trade.Symbol,
-			"side":          trade.Side,
-			"price":         priceFloat,
-			"qty":           qtyFloat,
-			"quoteQty":      trade.QuoteQuantity,
-			"commission":    trade.Commission,
-			"commissionAsset": trade.CommissionAsset,
-			"time":          trade.Time,
-			"buyer":         trade.Buyer,
-			"maker":         trade.Maker,
-			"positionSide":  trade.PositionSide,
-			"realizedPnl":   realizedPnlFloat,
-		})
+		fill := Fill{
+			ID:              trade.ID,
+			OrderID:         trade.OrderID,
+			Symbol:          trade.Symbol,
+			Side:            string(trade.Side),
+			PositionSide:    string(trade.PositionSide),
+			CommissionAsset: trade.CommissionAsset,
+			Time:            trade.Time,
+			Buyer:           trade.Buyer,
+			Maker:           trade.Maker,
+		}
+		fill.Price, _ = strconv.ParseFloat(trade.Price, 64)
+		fill.Quantity, _ = strconv.ParseFloat(trade.Quantity, 64)
+		fill.QuoteQuantity, _ = strconv.ParseFloat(trade.QuoteQuantity, 64)
+		fill.Commission, _ = strconv.ParseFloat(trade.Commission, 64)
+		fill.RealizedPnL, _ = strconv.ParseFloat(trade.RealizedPnl, 64)
+		result = append(result, fill)
 	}
 	
 	return result, nil
 }
 
+// transferPageLimit 单次查询资金划转的条数上限
+const transferPageLimit = 1000
+
+// GetTransfers 获取资金划转记录（收入历史中类型为TRANSFER的记录，按时间分页拉取）
+func (t *FuturesTrader) GetTransfers(startTime int64) ([]Transfer, error) {
+	var result []Transfer
+	for {
+		incomes, err := t.client.NewGetIncomeHistoryService().
+			IncomeType("TRANSFER").
+			StartTime(startTime).
+			Limit(transferPageLimit).
+			Do(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("获取资金划转记录失败: %w", err)
+		}
+		for _, income := range incomes {
+			amount, _ := strconv.ParseFloat(income.Income, 64)
+			result = append(result, Transfer{
+				ID:     strconv.FormatInt(income.TranID, 10),
+				Asset:  income.Asset,
+				Amount: amount,
+				Time:   income.Time,
+			})
+		}
+		if len(incomes) < transferPageLimit {
+			return result, nil
+		}
+		startTime = incomes[len(incomes)-1].Time + 1
+	}
+}
+
+// GetIncomeHistory 获取收入历史（不含资金划转，划转由 GetTransfers 同步，按时间分页拉取）
+func (t *FuturesTrader) GetIncomeHistory(startTime int64) ([]Income, error) {
+	var result []Income
+	for {
+		incomes, err := t.client.NewGetIncomeHistoryService().
+			StartTime(startTime).
+			Limit(transferPageLimit).
+			Do(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("获取收入历史失败: %w", err)
+		}
+		for _, income := range incomes {
+			if income.IncomeType == "TRANSFER" {
+				continue
+			}
+			amount, _ := strconv.ParseFloat(income.Income, 64)
+			result = append(result, Income{
+				ID:     strconv.FormatInt(income.TranID, 10),
+				Type:   income.IncomeType,
+				Symbol: income.Symbol,
+				Asset:  income.Asset,
+				Amount: amount,
+				Time:   income.Time,
+			})
+		}
+		if len(incomes) < transferPageLimit {
+			return result, nil
+		}
+		startTime = incomes[len(incomes)-1].Time + 1
+	}
+}
+
 // 辅助函数
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && stringContains(s, substr)
@@ -0,0 +1,52 @@
+package trader
+
+import (
+	"encoding/json"
+	"log"
+	"nofx/logger"
+	"nofx/market"
+)
+
+// bookSnapshotter 交易所驱动可以提供本交易所盘口时实现（未实现时使用Binance公开行情）
+type bookSnapshotter interface {
+	BookSnapshot(symbol string) (*market.BookSnapshot, error)
+}
+
+// captureBookSnapshot 下单前记录盘口前5档和最近成交，并按盘口估算成交均价（获取失败只记录日志，不影响下单）
+func (at *AutoTrader) captureBookSnapshot(symbol string, buy bool, quantity float64) *market.BookSnapshot {
+	cfg := executionConfig()
+	if !cfg.BookSnapshotEnabled {
+		return nil
+	}
+
+	var snapshot *market.BookSnapshot
+	var err error
+	if s, ok := baseTrader(at.trader).(bookSnapshotter); ok {
+		snapshot, err = s.BookSnapshot(symbol)
+	} else {
+		snapshot, err = market.FetchBookSnapshot(symbol, cfg.BookSnapshotTrades)
+	}
+	if err != nil {
+		log.Printf("  ⚠️  记录%s盘口快照失败: %v", symbol, err)
+	}
+	if snapshot == nil {
+		return nil
+	}
+	snapshot.EstimateFill(buy, quantity)
+	return snapshot
+}
+
+// attachBookSnapshot 记录实际成交均价并把盘口快照保存到决策动作
+func attachBookSnapshot(actionRecord *logger.DecisionAction, snapshot *market.BookSnapshot, buy bool, avgPrice float64) {
+	if snapshot == nil {
+		return
+	}
+	snapshot.RecordFill(buy, avgPrice)
+	if b, err := json.Marshal(snapshot); err == nil {
+		actionRecord.BookSnapshot = b
+	}
+	if snapshot.ExpectedPrice > 0 && snapshot.FillPrice > 0 {
+		log.Printf("  📖 盘口: 价差 %.1fbps | 预期滑点 %.1fbps | 实际滑点 %.1fbps",
+			snapshot.SpreadBps, snapshot.ExpectedSlippageBps, snapshot.FillSlippageBps)
+	}
+}
@@ -0,0 +1,176 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"nofx/database"
+)
+
+// chaosGarbageResponses 模拟的AI异常输出（空响应、拒答、截断JSON、非法JSON）
+var chaosGarbageResponses = []string{
+	"",
+	"抱歉，我无法提供交易建议。",
+	`分析完成。[{"symbol": "BTCUSDT", "action": "open_lo`,
+	`[{"symbol": BTCUSDT, "action": "open_long", "leverage": "十倍"}]`,
+	`[{"symbol": "BTCUSDT", "action": "buy_everything", "position_size_usd": -100}]`,
+}
+
+// chaosTrader 故障注入交易器（包装真实交易器，按配置概率模拟超时和部分成交）
+type chaosTrader struct {
+	Trader
+}
+
+// isLiveTrading 是否为实盘（目前只有Hyperliquid支持测试网）
+func isLiveTrading(config AutoTraderConfig) bool {
+	return !(config.Exchange == "hyperliquid" && config.HyperliquidTestnet)
+}
+
+// chaosHit 按概率判断是否触发故障（每次读取最新配置，支持热更新概率）
+func chaosHit(prob func(cfg database.ChaosConfig) float64) bool {
+	cfg := database.CurrentChaosConfig()
+	return cfg.Enabled && rand.Float64() < prob(cfg)
+}
+
+// enableChaos 在非实盘模式下启用故障注入（交易所、AI、数据库三层）
+func (at *AutoTrader) enableChaos() {
+	if !database.CurrentChaosConfig().Enabled {
+		return
+	}
+	if isLiveTrading(at.config) {
+		log.Printf("⚠️  [%s] 实盘模式忽略故障注入配置 chaos_enabled", at.name)
+		return
+	}
+
+	at.trader = &chaosTrader{Trader: at.trader}
+
+	at.mcpClient.SetFaultInjector(chaosAIResponse)
+
+	at.decisionLogger.SetWriteFaultInjector(func(op string) error {
+		if !chaosHit(func(cfg database.ChaosConfig) float64 { return cfg.DBWriteFailProb }) {
+			return nil
+		}
+		log.Printf("🧪 [chaos] 模拟数据库写入失败: %s", op)
+		return fmt.Errorf("🧪 [chaos] 模拟数据库写入失败: %s", op)
+	})
+
+	log.Printf("🧪 [%s] 已启用故障注入（交易所超时/部分成交/AI乱码/数据库写入失败）", at.name)
+}
+
+// chaosAIResponse 按概率把AI响应替换为乱码
+func chaosAIResponse(response string) string {
+	if !chaosHit(func(cfg database.ChaosConfig) float64 { return cfg.AIGarbageProb }) {
+		return response
+	}
+	garbage := chaosGarbageResponses[rand.Intn(len(chaosGarbageResponses))]
+	log.Printf("🧪 [chaos] 模拟AI返回乱码: %q", garbage)
+	return garbage
+}
+
+// chaosEnabled 是否已启用故障注入
+func (at *AutoTrader) chaosEnabled() bool {
+	_, ok := at.trader.(*chaosTrader)
+	return ok
+}
+
+// maybeTimeout 按概率模拟交易所超时
+func (t *chaosTrader) maybeTimeout(op string) error {
+	if chaosHit(func(cfg database.ChaosConfig) float64 { return cfg.ExchangeTimeoutProb }) {
+		log.Printf("🧪 [chaos] 模拟交易所超时: %s", op)
+		return fmt.Errorf("🧪 [chaos] %s: context deadline exceeded (Client.Timeout exceeded while awaiting headers)", op)
+	}
+	return nil
+}
+
+// maybePartialFill 按概率把开仓结果改为部分成交后撤单
+func (t *chaosTrader) maybePartialFill(order *Order, quantity float64) *Order {
+	if !chaosHit(func(cfg database.ChaosConfig) float64 { return cfg.PartialFillProb }) {
+		return order
+	}
+	filled := quantity * (0.1 + rand.Float64()*0.8)
+	log.Printf("🧪 [chaos] 模拟部分成交: %.4f / %.4f", filled, quantity)
+
+	result := *order
+	result.Status = "CANCELED"
+	result.ExecutedQty = filled
+	result.HasFill = true
+	return &result
+}
+
+func (t *chaosTrader) GetBalance() (*Balance, error) {
+	if err := t.maybeTimeout("获取账户余额"); err != nil {
+		return nil, err
+	}
+	return t.Trader.GetBalance()
+}
+
+func (t *chaosTrader) GetPositions() ([]Position, error) {
+	if err := t.maybeTimeout("获取持仓"); err != nil {
+		return nil, err
+	}
+	return t.Trader.GetPositions()
+}
+
+func (t *chaosTrader) OpenLong(symbol string, quantity float64, leverage int) (*Order, error) {
+	if err := t.maybeTimeout("开多仓"); err != nil {
+		return nil, err
+	}
+	order, err := t.Trader.OpenLong(symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return t.maybePartialFill(order, quantity), nil
+}
+
+func (t *chaosTrader) OpenShort(symbol string, quantity float64, leverage int) (*Order, error) {
+	if err := t.maybeTimeout("开空仓"); err != nil {
+		return nil, err
+	}
+	order, err := t.Trader.OpenShort(symbol, quantity, leverage)
+	if err != nil {
+		return nil, err
+	}
+	return t.maybePartialFill(order, quantity), nil
+}
+
+func (t *chaosTrader) CloseLong(symbol string, quantity float64) (*Order, error) {
+	if err := t.maybeTimeout("平多仓"); err != nil {
+		return nil, err
+	}
+	return t.Trader.CloseLong(symbol, quantity)
+}
+
+func (t *chaosTrader) CloseShort(symbol string, quantity float64) (*Order, error) {
+	if err := t.maybeTimeout("平空仓"); err != nil {
+		return nil, err
+	}
+	return t.Trader.CloseShort(symbol, quantity)
+}
+
+func (t *chaosTrader) GetMarketPrice(symbol string) (float64, error) {
+	if err := t.maybeTimeout("获取市场价格"); err != nil {
+		return 0, err
+	}
+	return t.Trader.GetMarketPrice(symbol)
+}
+
+func (t *chaosTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	if err := t.maybeTimeout("设置止损"); err != nil {
+		return err
+	}
+	return t.Trader.SetStopLoss(symbol, positionSide, quantity, stopPrice)
+}
+
+func (t *chaosTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	if err := t.maybeTimeout("设置止盈"); err != nil {
+		return err
+	}
+	return t.Trader.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice)
+}
+
+func (t *chaosTrader) GetOrderStatus(symbol string, orderID int64) (*Order, error) {
+	if err := t.maybeTimeout("查询订单状态"); err != nil {
+		return nil, err
+	}
+	return t.Trader.GetOrderStatus(symbol, orderID)
+}
@@ -0,0 +1,16 @@
+package trader
+
+import (
+	"nofx/database"
+	"nofx/decision"
+)
+
+// confidenceFloor 该trader适用的决策最低信心度（system_configs.confidence_min_* 和按trader覆盖的 confidence_floors）
+func (at *AutoTrader) confidenceFloor() decision.ConfidenceFloor {
+	rc := database.GetGlobalConfig()
+	if rc == nil {
+		return decision.ConfidenceFloor{}
+	}
+	floor := rc.GetConfidenceConfig().For(at.id)
+	return decision.ConfidenceFloor{Entry: floor.Entry, Close: floor.Close}
+}
@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/mcp"
+)
+
+// criticGenerationParams 审核模型的生成参数（只输出简短的JSON结论，低温度保证格式稳定）
+var criticGenerationParams = func() mcp.GenerationParams {
+	temperature := 0.2
+	return mcp.GenerationParams{Temperature: &temperature, MaxTokens: 800}
+}()
+
+// newCritic 按配置创建决策审核器（未配置审核模型时返回nil）
+// deepseek/qwen 使用trader已配置的密钥，custom 使用trader的自定义API地址和密钥
+func newCritic(config AutoTraderConfig) (*decision.Critic, error) {
+	if config.CriticAIModel == "" {
+		return nil, nil
+	}
+
+	client := mcp.New()
+	switch config.CriticAIModel {
+	case "deepseek":
+		if config.DeepSeekKey == "" {
+			return nil, fmt.Errorf("审核模型deepseek需要配置DeepSeek密钥")
+		}
+		client.SetDeepSeekAPIKey(config.DeepSeekKey)
+	case "qwen":
+		if config.QwenKey == "" {
+			return nil, fmt.Errorf("审核模型qwen需要配置Qwen密钥")
+		}
+		client.SetQwenAPIKey(config.QwenKey, "")
+	case "custom":
+		if config.CustomAPIURL == "" || config.CustomAPIKey == "" {
+			return nil, fmt.Errorf("审核模型custom需要配置自定义API地址和密钥")
+		}
+		client.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CriticModelName)
+	default:
+		return nil, fmt.Errorf("不支持的审核模型: %s", config.CriticAIModel)
+	}
+	if config.CriticModelName != "" {
+		client.Model = config.CriticModelName
+	}
+	client.SetGenerationParams(criticGenerationParams)
+
+	return &decision.Critic{Client: client, Rules: config.CriticRules}, nil
+}
@@ -0,0 +1,202 @@
+package trader
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/harness"
+	"nofx/market"
+	"nofx/pool"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cycleNow 周期测试的固定时间（与cassette中录制的行情时间一致）
+var cycleNow = time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+
+// fakeTrader 内存交易所：返回固定的账户和持仓，记录下单调用
+type fakeTrader struct {
+	mu        sync.Mutex
+	balance   Balance
+	positions []Position
+	calls     []string
+}
+
+func (f *fakeTrader) record(format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTrader) GetBalance() (*Balance, error) {
+	b := f.balance
+	return &b, nil
+}
+
+func (f *fakeTrader) GetPositions() ([]Position, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Position(nil), f.positions...), nil
+}
+
+func (f *fakeTrader) GetAccountTrades(symbol string, limit int) ([]Fill, error) { return nil, nil }
+func (f *fakeTrader) GetTransfers(startTime int64) ([]Transfer, error)          { return nil, nil }
+
+func (f *fakeTrader) OpenLong(symbol string, quantity float64, leverage int) (*Order, error) {
+	f.record("open_long %s", symbol)
+	return &Order{OrderID: 1, Symbol: symbol, Status: "FILLED", OrigQty: quantity, ExecutedQty: quantity, HasFill: true}, nil
+}
+
+func (f *fakeTrader) OpenShort(symbol string, quantity float64, leverage int) (*Order, error) {
+	f.record("open_short %s", symbol)
+	return &Order{OrderID: 2, Symbol: symbol, Status: "FILLED", OrigQty: quantity, ExecutedQty: quantity, HasFill: true}, nil
+}
+
+func (f *fakeTrader) CloseLong(symbol string, quantity float64) (*Order, error) {
+	return f.close(symbol, "long")
+}
+
+func (f *fakeTrader) CloseShort(symbol string, quantity float64) (*Order, error) {
+	return f.close(symbol, "short")
+}
+
+// close 平掉整个持仓（按标记价格成交）
+func (f *fakeTrader) close(symbol, side string) (*Order, error) {
+	f.record("close_%s %s", side, symbol)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, pos := range f.positions {
+		if pos.Symbol == symbol && pos.Side == side {
+			f.positions = append(f.positions[:i], f.positions[i+1:]...)
+			return &Order{OrderID: 3, Symbol: symbol, Status: "FILLED", OrigQty: pos.Quantity,
+				ExecutedQty: pos.Quantity, AvgPrice: pos.MarkPrice, HasFill: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s 持仓不存在", symbol, side)
+}
+
+func (f *fakeTrader) SetLeverage(symbol string, leverage int) (int, error) { return leverage, nil }
+func (f *fakeTrader) GetMarketPrice(symbol string) (float64, error) {
+	return 0, fmt.Errorf("未录制价格")
+}
+
+func (f *fakeTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	f.record("stop_loss %s %s %.2f", symbol, positionSide, stopPrice)
+	return nil
+}
+
+func (f *fakeTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	f.record("take_profit %s %s %.2f", symbol, positionSide, takeProfitPrice)
+	return nil
+}
+
+func (f *fakeTrader) GetOrderStatus(symbol string, orderID int64) (*Order, error) {
+	return &Order{OrderID: orderID, Symbol: symbol, Status: "FILLED"}, nil
+}
+
+func (f *fakeTrader) CancelAllOrders(symbol string) error {
+	f.record("cancel_all %s", symbol)
+	return nil
+}
+
+func (f *fakeTrader) CancelOrder(symbol string, orderID int64) error   { return nil }
+func (f *fakeTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) { return []OpenOrder{}, nil }
+func (f *fakeTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return fmt.Sprintf("%.3f", quantity), nil
+}
+func (f *fakeTrader) GetSymbolFilters(symbol string) (SymbolFilters, error) {
+	return SymbolFilters{}, nil
+}
+func (f *fakeTrader) GetFundingRate(symbol string) (FundingRate, error) {
+	return FundingRate{Rate: 0.0001, IntervalHours: 8}, nil
+}
+
+// TestRunCycleClosesLongFromCassette 回放行情和AI响应跑完整决策周期：AI决定平掉BTC多仓
+func TestRunCycleClosesLongFromCassette(t *testing.T) {
+	cassettePath, err := filepath.Abs("testdata/cycle_close_long.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	harness.ChdirTemp(t)
+
+	cassette, err := harness.LoadCassette(cassettePath, harness.ModeReplay)
+	if err != nil {
+		t.Fatalf("加载cassette失败: %v", err)
+	}
+	t.Cleanup(harness.Install(cassette))
+
+	// 录制的K线时间固定，关闭按真实时间判断的过期检查；增量指标状态跨测试保留，关闭后每次从录制的K线重新计算
+	quality, indicators := market.QualitySettings, market.IndicatorCache
+	market.QualitySettings.StaleIntervals = 0
+	market.IndicatorCache.Incremental = false
+	t.Cleanup(func() { market.QualitySettings, market.IndicatorCache = quality, indicators })
+
+	defaultCoins := pool.GetDefaultCoins()
+	pool.SetDefaultCoins([]string{"BTCUSDT"})
+	t.Cleanup(func() { pool.SetDefaultCoins(defaultCoins) })
+
+	at, err := NewAutoTrader(AutoTraderConfig{
+		ID:              "cycle_test",
+		Name:            "Cycle Test",
+		AIModel:         "custom",
+		CustomAPIURL:    "https://ai.example.com/v1",
+		CustomAPIKey:    "test-key",
+		CustomModelName: "test-model",
+		Exchange:        "binance",
+		CoinSource:      pool.SourceStatic,
+		ScanInterval:    3 * time.Minute,
+		InitialBalance:  1000,
+		BTCETHLeverage:  5,
+		AltcoinLeverage: 5,
+	})
+	if err != nil {
+		t.Fatalf("创建AutoTrader失败: %v", err)
+	}
+	t.Cleanup(at.Stop)
+
+	fake := &fakeTrader{
+		balance: Balance{TotalWalletBalance: 1000, AvailableBalance: 800, TotalUnrealizedProfit: 20},
+		positions: []Position{{
+			Symbol: "BTCUSDT", Side: "long", Quantity: 0.02, EntryPrice: 60000, MarkPrice: 61000,
+			UnrealizedProfit: 20, LiquidationPrice: 48000, Leverage: 5,
+		}},
+	}
+	clock := harness.NewFakeClock(cycleNow)
+	at.trader = fake
+	at.isRunning = true
+	at.clock = clock
+	at.startTime = cycleNow.Add(-9 * time.Minute)
+	at.lastResetTime = cycleNow.Add(-time.Hour)
+
+	if err := at.runCycle(); err != nil {
+		t.Fatalf("决策周期失败: %v", err)
+	}
+
+	if got := strings.Join(fake.calls, "; "); !strings.Contains(got, "close_long BTCUSDT") {
+		t.Fatalf("应平掉BTC多仓，实际交易所调用: %s", got)
+	}
+	// 交易规则和BTC市值占比有进程级缓存，重复运行时不会再次请求，只要求AI请求被回放
+	for _, it := range cassette.Unused() {
+		if it.Method == http.MethodPost {
+			t.Errorf("cassette中的AI请求未被使用: %s", it.URL)
+		}
+	}
+
+	records, err := at.decisionLogger.GetLatestRecords(1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("读取决策记录失败: %v (%d条)", err, len(records))
+	}
+	record := records[0]
+	if !record.Success {
+		t.Fatalf("决策记录应为成功: %s", record.ErrorMessage)
+	}
+	if !record.Timestamp.Equal(cycleNow) {
+		t.Errorf("决策时间应取自注入的时钟: %v", record.Timestamp)
+	}
+	if len(record.Decisions) != 1 || record.Decisions[0].Action != "close_long" || !record.Decisions[0].Success {
+		t.Errorf("决策动作记录不正确: %+v", record.Decisions)
+	}
+	harness.AssertGolden(t, "cycle_close_long_prompt", record.InputPrompt)
+}
@@ -0,0 +1,70 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/monitoring"
+	"time"
+)
+
+// updateDailyLoss 更新当日盈亏（净值扣除累计净入金，相对日初净值计算），亏损达到 max_daily_loss 时当日切换为只平仓模式（每个周期调用）
+func (at *AutoTrader) updateDailyLoss(totalEquity float64) {
+	_, netFlows := at.costBasis()
+	equity := totalEquity - netFlows
+
+	at.mu.Lock()
+	if at.dayStartEquity <= 0 {
+		at.dayStartEquity = equity
+	}
+	at.dailyPnL = equity - at.dayStartEquity
+	reason := at.dailyLossReasonLocked()
+	newlyHit := reason != "" && !at.dailyLossHit
+	if newlyHit {
+		at.dailyLossHit = true
+	}
+	at.mu.Unlock()
+
+	if !newlyHit {
+		return
+	}
+	log.Printf("⛔ [%s] 触发日亏损限制，当日切换为只平仓模式: %s", at.name, reason)
+	if at.monitor != nil {
+		at.monitor.RaiseAlert(monitoring.Alert{
+			ID:      fmt.Sprintf("daily_loss_%s_%s", at.id, time.Now().Format("20060102")),
+			Type:    monitoring.AlertTypeRisk,
+			Level:   monitoring.AlertLevelCritical,
+			Title:   "日亏损限制触发：只平仓模式",
+			Message: reason + "，日盈亏重置前只管理现有持仓",
+		})
+	}
+}
+
+// resetDailyLoss 日盈亏重置（下个周期以当时的净值作为日初净值）
+func (at *AutoTrader) resetDailyLoss() {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	at.dailyPnL = 0
+	at.dayStartEquity = 0
+	at.dailyLossHit = false
+	at.lastResetTime = time.Now()
+}
+
+// dailyLossReason 日亏损限制触发的原因（为空表示未触发）
+func (at *AutoTrader) dailyLossReason() string {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.dailyLossReasonLocked()
+}
+
+// dailyLossReasonLocked 日亏损限制触发的原因（调用方持有 at.mu）
+func (at *AutoTrader) dailyLossReasonLocked() string {
+	limit := at.config.MaxDailyLoss
+	if limit <= 0 || at.dayStartEquity <= 0 || at.dailyPnL >= 0 {
+		return ""
+	}
+	lossPct := -at.dailyPnL / at.dayStartEquity * 100
+	if lossPct < limit {
+		return ""
+	}
+	return fmt.Sprintf("当日亏损 %.2f USDT（%.2f%%），达到日亏损上限 %.2f%%", -at.dailyPnL, lossPct, limit)
+}
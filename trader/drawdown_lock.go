@@ -0,0 +1,201 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/monitoring"
+	"sync"
+	"time"
+)
+
+// drawdownLock 净值峰值回撤锁（净值 = 账户净值 - 累计净入金，不受入金/出金影响）
+// 从峰值回撤超过阈值后切换为只平仓模式，直到人工解除
+type drawdownLock struct {
+	mu       sync.Mutex
+	peak     float64   // 净值峰值
+	current  float64   // 最近一次计算的净值
+	locked   bool      // 是否处于只平仓模式
+	lockedAt time.Time // 锁定时间
+	reason   string    // 锁定原因
+}
+
+// DrawdownLockStatus 回撤锁状态
+type DrawdownLockStatus struct {
+	Enabled       bool       `json:"enabled"`
+	ThresholdPct  float64    `json:"threshold_pct"`
+	Locked        bool       `json:"locked"` // true表示只平仓（禁止开新仓）
+	PeakEquity    float64    `json:"peak_equity"`
+	CurrentEquity float64    `json:"current_equity"`
+	DrawdownPct   float64    `json:"drawdown_pct"` // 当前相对峰值的回撤(%)
+	LockedAt      *time.Time `json:"locked_at,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+}
+
+// drawdownLockConfig 回撤锁配置
+func drawdownLockConfig() database.DrawdownLockConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetDrawdownLockConfig()
+	}
+	return database.DrawdownLockConfig{Enabled: false, Pct: 10.0}
+}
+
+// drawdownPct 相对峰值的回撤(%)
+func (l *drawdownLock) drawdownPct() float64 {
+	if l.peak <= 0 || l.current >= l.peak {
+		return 0
+	}
+	return (l.peak - l.current) / l.peak * 100
+}
+
+// restoreDrawdownLock 从数据库恢复净值峰值和锁定状态
+func (at *AutoTrader) restoreDrawdownLock(db *database.DB) {
+	saved, err := db.Position().GetDrawdownLock()
+	if err != nil {
+		log.Printf("⚠️  恢复回撤锁状态失败: %v", err)
+		return
+	}
+	if saved == nil {
+		return
+	}
+	at.drawdown.mu.Lock()
+	defer at.drawdown.mu.Unlock()
+	at.drawdown.peak = saved.PeakEquity
+	at.drawdown.locked = saved.Locked
+	at.drawdown.reason = saved.Reason
+	if saved.LockedAt != nil {
+		at.drawdown.lockedAt = *saved.LockedAt
+	}
+	if saved.Locked {
+		log.Printf("⛔ 从数据库恢复回撤锁: 只平仓模式（%s）", saved.Reason)
+	}
+}
+
+// saveDrawdownLockLocked 持久化回撤锁状态（调用方持有 drawdown.mu）
+func (at *AutoTrader) saveDrawdownLockLocked() {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	state := &models.DrawdownLock{
+		PeakEquity: at.drawdown.peak,
+		Locked:     at.drawdown.locked,
+		Reason:     at.drawdown.reason,
+	}
+	if at.drawdown.locked {
+		lockedAt := at.drawdown.lockedAt
+		state.LockedAt = &lockedAt
+	}
+	if err := db.Position().SaveDrawdownLock(state); err != nil {
+		log.Printf("⚠️  [%s] 保存回撤锁状态失败: %v", at.name, err)
+	}
+}
+
+// updateDrawdownLock 更新净值峰值，回撤超过阈值时切换为只平仓模式（每个周期调用）
+func (at *AutoTrader) updateDrawdownLock(totalEquity float64) {
+	_, netFlows := at.costBasis()
+	equity := totalEquity - netFlows
+	cfg := drawdownLockConfig()
+
+	at.drawdown.mu.Lock()
+	defer at.drawdown.mu.Unlock()
+
+	l := &at.drawdown
+	l.current = equity
+	changed := false
+	if l.peak <= 0 {
+		l.peak = at.initialBalance
+		changed = true
+	}
+	if equity > l.peak {
+		l.peak = equity
+		changed = true
+	}
+
+	if cfg.Enabled && cfg.Pct > 0 && !l.locked {
+		if dd := l.drawdownPct(); dd >= cfg.Pct {
+			l.locked = true
+			l.lockedAt = time.Now()
+			l.reason = fmt.Sprintf("净值从峰值 %.2f 回撤 %.2f%% 至 %.2f（阈值 %.2f%%）", l.peak, dd, equity, cfg.Pct)
+			changed = true
+			log.Printf("⛔ [%s] 触发回撤锁，切换为只平仓模式: %s", at.name, l.reason)
+			if at.monitor != nil {
+				at.monitor.RaiseAlert(monitoring.Alert{
+					ID:      at.drawdownAlertID(l.lockedAt),
+					Type:    monitoring.AlertTypeRisk,
+					Level:   monitoring.AlertLevelCritical,
+					Title:   "回撤锁触发：只平仓模式",
+					Message: l.reason + "，需人工解除后才能开新仓",
+				})
+			}
+		}
+	}
+
+	if changed {
+		at.saveDrawdownLockLocked()
+	}
+}
+
+// drawdownAlertID 回撤锁预警ID（每次锁定一个，解除时标记为已解决）
+func (at *AutoTrader) drawdownAlertID(lockedAt time.Time) string {
+	return fmt.Sprintf("drawdown_lock_%s_%d", at.id, lockedAt.Unix())
+}
+
+// drawdownLockReason 回撤锁触发的原因（为空表示未锁定）
+func (at *AutoTrader) drawdownLockReason() string {
+	at.drawdown.mu.Lock()
+	defer at.drawdown.mu.Unlock()
+	if !at.drawdown.locked {
+		return ""
+	}
+	return at.drawdown.reason
+}
+
+// GetDrawdownLockStatus 回撤锁状态
+func (at *AutoTrader) GetDrawdownLockStatus() DrawdownLockStatus {
+	cfg := drawdownLockConfig()
+
+	at.drawdown.mu.Lock()
+	defer at.drawdown.mu.Unlock()
+	status := DrawdownLockStatus{
+		Enabled:       cfg.Enabled,
+		ThresholdPct:  cfg.Pct,
+		Locked:        at.drawdown.locked,
+		PeakEquity:    at.drawdown.peak,
+		CurrentEquity: at.drawdown.current,
+		DrawdownPct:   at.drawdown.drawdownPct(),
+		Reason:        at.drawdown.reason,
+	}
+	if at.drawdown.locked {
+		lockedAt := at.drawdown.lockedAt
+		status.LockedAt = &lockedAt
+	}
+	return status
+}
+
+// ResetDrawdownLock 人工解除回撤锁，并把净值峰值重置为当前净值（避免下个周期立即再次锁定）
+func (at *AutoTrader) ResetDrawdownLock() DrawdownLockStatus {
+	at.drawdown.mu.Lock()
+	wasLocked := at.drawdown.locked
+	alertID := at.drawdownAlertID(at.drawdown.lockedAt)
+	at.drawdown.locked = false
+	at.drawdown.lockedAt = time.Time{}
+	at.drawdown.reason = ""
+	if at.drawdown.current > 0 {
+		at.drawdown.peak = at.drawdown.current
+	}
+	at.saveDrawdownLockLocked()
+	peak := at.drawdown.peak
+	at.drawdown.mu.Unlock()
+
+	if wasLocked {
+		log.Printf("🔓 [%s] 回撤锁已人工解除，净值峰值重置为 %.2f", at.name, peak)
+		if at.monitor != nil {
+			_ = at.monitor.ResolveAlert(alertID)
+		}
+	} else {
+		log.Printf("🔓 [%s] 净值峰值已重置为 %.2f", at.name, peak)
+	}
+	return at.GetDrawdownLockStatus()
+}
@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// DryRunDecision 用当前实时上下文验证决策（与AI决策、手动注入决策的验证流程一致），不执行下单、不写决策记录
+func (at *AutoTrader) DryRunDecision(d *decision.Decision) (*decision.DryRunResult, error) {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	d.Symbol = market.Normalize(d.Symbol)
+
+	// 只读取余额和持仓，不触发自动平仓处理、回撤锁等有副作用的流程
+	ctx, err := at.buildDryRunContext()
+	if err != nil {
+		return nil, fmt.Errorf("构建交易上下文失败: %w", err)
+	}
+
+	result := decision.DryRunDecision(d, ctx)
+	if result.Valid && (d.Action == "open_long" || d.Action == "open_short") {
+		// 交易所层面的检查（计价币种/保证金类型）
+		if err := checkSymbolMargin(at.exchange, d.Symbol, poolConfig().AllowedQuoteAssets); err != nil {
+			result.Valid = false
+			result.Error = err.Error()
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"log"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+)
+
+// entryIndicators 从市场数据中提取开仓指标（MACD、RSI7、成交量比率）
+func entryIndicators(data *market.Data) (macd, rsi, volRatio float64) {
+	if data == nil {
+		return 0, 0, 0
+	}
+	macd = data.CurrentMACD
+	rsi = data.CurrentRSI7
+	if lt := data.LongerTermContext; lt != nil && lt.AverageVolume > 0 {
+		volRatio = lt.CurrentVolume / lt.AverageVolume
+	}
+	return macd, rsi, volRatio
+}
+
+// saveEntrySnapshot 记录开仓时的指标快照
+// 优先使用本周期AI决策时看到的市场数据（ctx.MarketDataMap），没有时使用执行时获取的数据
+func (at *AutoTrader) saveEntrySnapshot(d *decision.Decision, side string, fallback *market.Data) {
+	if !database.CurrentLearningConfig().EntrySnapshotEnabled {
+		return
+	}
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+
+	data := fallback
+	if md, ok := at.lastMarketData[d.Symbol]; ok && md != nil {
+		data = md
+	}
+	macd, rsi, volRatio := entryIndicators(data)
+
+	snapshot := &models.PositionEntrySnapshot{
+		Symbol:        d.Symbol,
+		Side:          side,
+		EntryMACD:     macd,
+		EntryRSI:      rsi,
+		EntryVolRatio: volRatio,
+		EntryReason:   d.Reasoning,
+		Regime:        at.lastRegime,
+	}
+	if err := db.SavePositionEntrySnapshot(snapshot); err != nil {
+		log.Printf("  ⚠️  保存开仓指标快照失败: %v", err)
+		return
+	}
+	log.Printf("  📸 开仓指标快照: MACD=%.4f RSI7=%.1f 量比=%.2f 市场状态=%s", macd, rsi, volRatio, at.lastRegime)
+}
+
+// applyEntrySnapshot 用开仓指标快照填充交易结果
+func (at *AutoTrader) applyEntrySnapshot(trade *logger.TradeOutcome) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	snapshot, ok := db.GetPositionEntrySnapshot(trade.Symbol, trade.Side)
+	if !ok {
+		return
+	}
+	trade.EntryMACD = snapshot.EntryMACD
+	trade.EntryRSI = snapshot.EntryRSI
+	trade.EntryVolRatio = snapshot.EntryVolRatio
+	trade.Regime = snapshot.Regime
+	if snapshot.EntryReason != "" {
+		trade.EntryReason = snapshot.EntryReason
+	}
+}
+
+// deleteEntrySnapshot 持仓完全平仓后删除开仓指标快照
+func (at *AutoTrader) deleteEntrySnapshot(symbol, side string) {
+	if db := at.decisionLogger.GetDB(); db != nil {
+		if err := db.DeletePositionEntrySnapshot(symbol, side); err != nil {
+			log.Printf("  ⚠️  删除开仓指标快照失败: %v", err)
+		}
+	}
+}
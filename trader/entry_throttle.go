@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"log"
+	"nofx/database"
+	"nofx/decision"
+	"time"
+)
+
+// entryCountRetentionDays 每日开仓计数保留天数
+const entryCountRetentionDays = 30
+
+// entryDay 开仓计数所属的日期（按UTC日切换）
+func entryDay(now time.Time) string {
+	return now.UTC().Format("2006-01-02")
+}
+
+// entryThrottleConfig 每日开仓次数限制配置
+func entryThrottleConfig() database.EntryThrottleConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetEntryThrottleConfig()
+	}
+	return database.EntryThrottleConfig{}
+}
+
+// entryThrottle 构建每日开仓次数限制（两个上限都为0时不启用，返回nil）
+func (at *AutoTrader) entryThrottle(now time.Time) *decision.EntryThrottle {
+	cfg := entryThrottleConfig()
+	if cfg.MaxPerSymbolDaily <= 0 && cfg.MaxTotalDaily <= 0 {
+		return nil
+	}
+	throttle := &decision.EntryThrottle{
+		MaxPerSymbol: cfg.MaxPerSymbolDaily,
+		MaxTotal:     cfg.MaxTotalDaily,
+		Counts:       map[string]int{},
+	}
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return throttle
+	}
+	counts, err := db.EntryCount().GetByDay(entryDay(now))
+	if err != nil {
+		log.Printf("⚠️  [%s] 读取今日开仓次数失败: %v", at.name, err)
+		return throttle
+	}
+	throttle.Counts = counts
+	return throttle
+}
+
+// recordEntry 开仓成功后累加当日开仓次数（不论是否启用限制都记录，便于中途开启时立即生效）
+func (at *AutoTrader) recordEntry(symbol string, now time.Time) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	if err := db.EntryCount().Increment(entryDay(now), symbol); err != nil {
+		log.Printf("⚠️  [%s] 记录开仓次数失败: %v", at.name, err)
+		return
+	}
+	cutoff := entryDay(now.AddDate(0, 0, -entryCountRetentionDays))
+	if err := db.EntryCount().DeleteBefore(cutoff); err != nil {
+		log.Printf("⚠️  [%s] 清理历史开仓次数失败: %v", at.name, err)
+	}
+}
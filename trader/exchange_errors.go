@@ -0,0 +1,116 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// 交易所错误分类（记录在决策动作上，用于监控统计和提示AI避免重复下无效订单）
+const (
+	ErrorClassInsufficientMargin = "insufficient_margin" // 保证金/余额不足
+	ErrorClassReduceOnly         = "reduce_only"         // 只减仓订单被拒（持仓不存在或方向/数量不匹配）
+	ErrorClassRateLimit          = "rate_limit"          // 请求频率超限
+	ErrorClassPrecision          = "precision"           // 数量/价格精度、最小下单量等过滤规则不满足
+	ErrorClassPermission         = "permission"          // API Key权限、签名或IP白名单问题
+	ErrorClassOther              = "other"               // 其他错误（包括系统自身的风控拦截）
+)
+
+// orderErrorLookback 提示AI的近期下单错误统计窗口
+const orderErrorLookback = 24 * time.Hour
+
+// maxPromptErrorClasses 提示词中最多列出的错误分类数
+const maxPromptErrorClasses = 3
+
+// orderErrorClasses 币安/Aster错误码对应的分类
+var orderErrorClasses = map[int64]string{
+	-1003: ErrorClassRateLimit,
+	-1015: ErrorClassRateLimit,
+	-1002: ErrorClassPermission,
+	-1022: ErrorClassPermission,
+	-2014: ErrorClassPermission,
+	-2015: ErrorClassPermission,
+	-2022: ErrorClassReduceOnly,
+	-4118: ErrorClassReduceOnly,
+	-2018: ErrorClassInsufficientMargin,
+	-2019: ErrorClassInsufficientMargin,
+	-2027: ErrorClassInsufficientMargin,
+	-2028: ErrorClassInsufficientMargin,
+	-1013: ErrorClassPrecision,
+	-1111: ErrorClassPrecision,
+	-4003: ErrorClassPrecision,
+	-4014: ErrorClassPrecision,
+	-4164: ErrorClassPrecision,
+}
+
+// orderErrorKeywords 没有错误码时（Hyperliquid、HTTP错误）按关键字分类，按顺序匹配
+var orderErrorKeywords = []struct {
+	class    string
+	keywords []string
+}{
+	{ErrorClassRateLimit, []string{"http 429", "too many requests", "rate limit", "request weight"}},
+	{ErrorClassPermission, []string{"http 401", "http 403", "permission", "unauthorized", "api-key", "signature"}},
+	{ErrorClassReduceOnly, []string{"reduce only", "reduceonly", "reduce-only"}},
+	{ErrorClassInsufficientMargin, []string{"insufficient margin", "margin is insufficient", "insufficient balance"}},
+	{ErrorClassPrecision, []string{"precision", "lot_size", "price_filter", "min_notional", "tick size", "minimum value"}},
+}
+
+// classifyExchangeError 对下单错误分类：优先按交易所错误码，其次按关键字
+func classifyExchangeError(err error) string {
+	if err == nil {
+		return ""
+	}
+	if code, ok := orderErrorCode(err); ok {
+		if class, exists := orderErrorClasses[code]; exists {
+			return class
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, group := range orderErrorKeywords {
+		for _, keyword := range group.keywords {
+			if strings.Contains(msg, keyword) {
+				return group.class
+			}
+		}
+	}
+	return ErrorClassOther
+}
+
+// setActionError 记录动作的错误信息和错误分类
+func setActionError(action *logger.DecisionAction, err error) {
+	action.Error = err.Error()
+	action.ErrorClass = classifyExchangeError(err)
+}
+
+// recentOrderErrors 近期最常见的交易所下单错误（不含未分类错误），用于提示AI不要重复下无效订单
+func (at *AutoTrader) recentOrderErrors(now time.Time) []decision.OrderErrorStat {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil
+	}
+	stats, err := db.Decision().GetActionErrorStats(now.Add(-orderErrorLookback))
+	if err != nil {
+		log.Printf("⚠️  [%s] 统计近期下单错误失败: %v", at.name, err)
+		return nil
+	}
+
+	var result []decision.OrderErrorStat
+	for _, s := range stats.Classes {
+		if s.Class == ErrorClassOther {
+			continue
+		}
+		result = append(result, decision.OrderErrorStat{
+			Class:      s.Class,
+			Count:      s.Count,
+			LastSymbol: s.LastSymbol,
+			LastAction: s.LastAction,
+			LastError:  s.LastError,
+		})
+		if len(result) >= maxPromptErrorClasses {
+			break
+		}
+	}
+	return result
+}
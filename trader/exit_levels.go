@@ -0,0 +1,194 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database/models"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// executeUpdateExitLevelWithRecord 调整现有持仓的止损或止盈（不平仓）
+// 交易所不支持单独修改条件单，统一撤销该币种全部挂单后按新价格重新挂止损和止盈
+func (at *AutoTrader) executeUpdateExitLevelWithRecord(d *decision.Decision, actionRecord *logger.DecisionAction) error {
+	isStopLoss := d.Action == "update_stop_loss"
+	levelName := "止盈"
+	if isStopLoss {
+		levelName = "止损"
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var pos *Position
+	for i := range positions {
+		if positions[i].Symbol != d.Symbol {
+			continue
+		}
+		if pos != nil {
+			return fmt.Errorf("%s 同时持有多仓和空仓，撤单会影响另一方向的保护单，拒绝调整%s", d.Symbol, levelName)
+		}
+		pos = &positions[i]
+	}
+	if pos == nil {
+		log.Printf("  ⚠️  %s 持仓不存在，可能已被止损/止盈自动平仓，跳过调整%s", d.Symbol, levelName)
+		actionRecord.Error = "持仓不存在（可能已自动平仓）"
+		return nil
+	}
+
+	// 当前止损止盈价（开仓或上次调整时记录）
+	var stopLoss, takeProfit float64
+	db := at.decisionLogger.GetDB()
+	if db != nil {
+		if levels, err := db.GetAllPositionExitLevels(); err == nil {
+			level := levels[d.Symbol+"_"+pos.Side]
+			stopLoss, takeProfit = level[0], level[1]
+		}
+	}
+	// 数据库没有记录时以交易所条件单为准（撤单后需要按原价重新挂另一侧的保护单）
+	if stopLoss == 0 || takeProfit == 0 {
+		if sl, tp, err := at.exchangeExitLevels(d.Symbol, pos.Side); err != nil {
+			log.Printf("  ⚠️  获取 %s 挂单失败: %v", d.Symbol, err)
+		} else {
+			if stopLoss == 0 {
+				stopLoss = sl
+			}
+			if takeProfit == 0 {
+				takeProfit = tp
+			}
+		}
+	}
+
+	oldPrice, newPrice := takeProfit, d.TakeProfit
+	if isStopLoss {
+		oldPrice, newPrice = stopLoss, d.StopLoss
+		// 执行前以交易所持仓方向和数据库中的止损再检查一次（验证阶段的持仓可能已过时）
+		if !executionConfig().AllowStopLoosening && !decision.StopLossTightens(pos.Side, stopLoss, newPrice) {
+			return fmt.Errorf("止损只能收紧：%s %s 当前止损%.4f，新止损%.4f", d.Symbol, pos.Side, stopLoss, newPrice)
+		}
+	}
+	if oldPrice == newPrice {
+		log.Printf("  ℹ️  %s %s%s未变化(%.4f)，无需调整", d.Symbol, pos.Side, levelName, newPrice)
+		return nil
+	}
+	if isStopLoss {
+		stopLoss = newPrice
+	} else {
+		takeProfit = newPrice
+	}
+
+	actionRecord.Quantity = pos.Quantity
+	actionRecord.Price = pos.MarkPrice
+	log.Printf("  🎯 调整%s: %s %s %.4f → %.4f", levelName, d.Symbol, pos.Side, oldPrice, newPrice)
+
+	// 撤销旧的条件单后重新挂单
+	if err := at.trader.CancelAllOrders(d.Symbol); err != nil {
+		return fmt.Errorf("撤销旧止损止盈单失败: %w", err)
+	}
+	positionSide := strings.ToUpper(pos.Side)
+	placedOCO := false
+	if placer, ok := at.ocoPlacer(); ok && stopLoss > 0 && takeProfit > 0 {
+		// 交易所支持OCO时作为一组重新挂单；失败时按原逻辑分别挂单
+		round := at.exitPriceRounder(d.Symbol, positionSide)
+		if err := placer.SetStopLossTakeProfitOCO(d.Symbol, positionSide, pos.Quantity, round(stopLoss), round(takeProfit)); err != nil {
+			log.Printf("  ⚠️  %s OCO挂单失败，改为分别挂止损止盈: %v", d.Symbol, err)
+		} else {
+			placedOCO = true
+		}
+	}
+	if !placedOCO {
+		if stopLoss > 0 {
+			if err := at.placeProtectiveOrder(d.Symbol, positionSide, OpenOrderStopLoss, pos.Quantity, stopLoss); err != nil {
+				if !isStopLoss {
+					at.alertProtectionFailure(d.Symbol, positionSide, OpenOrderStopLoss, err)
+					return fmt.Errorf("重新设置止损失败，持仓当前无止损保护: %w", err)
+				}
+				// 新止损挂单失败时恢复旧止损，避免持仓失去保护
+				log.Printf("  ❌ 设置新止损失败: %v，尝试恢复旧止损 %.4f", err, oldPrice)
+				if oldPrice > 0 {
+					if restoreErr := at.placeProtectiveOrder(d.Symbol, positionSide, OpenOrderStopLoss, pos.Quantity, oldPrice); restoreErr != nil {
+						log.Printf("  🚨 恢复旧止损失败，%s %s 当前无止损保护: %v", d.Symbol, pos.Side, restoreErr)
+						at.alertProtectionFailure(d.Symbol, positionSide, OpenOrderStopLoss, restoreErr)
+					}
+				} else {
+					at.alertProtectionFailure(d.Symbol, positionSide, OpenOrderStopLoss, err)
+				}
+				if takeProfit > 0 {
+					if tpErr := at.placeProtectiveOrder(d.Symbol, positionSide, OpenOrderTakeProfit, pos.Quantity, takeProfit); tpErr != nil {
+						log.Printf("  ⚠ 恢复止盈失败: %v", tpErr)
+					}
+				}
+				return fmt.Errorf("设置新止损失败: %w", err)
+			}
+		} else {
+			log.Printf("  ⚠️  %s %s 当前止损未知，撤单后未重新挂止损", d.Symbol, pos.Side)
+		}
+		if takeProfit > 0 {
+			if err := at.placeProtectiveOrder(d.Symbol, positionSide, OpenOrderTakeProfit, pos.Quantity, takeProfit); err != nil {
+				at.alertProtectionFailure(d.Symbol, positionSide, OpenOrderTakeProfit, err)
+				if !isStopLoss {
+					return fmt.Errorf("设置新止盈失败: %w", err)
+				}
+				log.Printf("  ⚠ 重新设置止盈失败: %v", err)
+			}
+		} else {
+			log.Printf("  ⚠️  %s %s 当前止盈未知，撤单后未重新挂止盈", d.Symbol, pos.Side)
+		}
+	}
+
+	// 持久化新的止损止盈价并记录调整历史
+	if db != nil {
+		if err := db.SavePositionExitLevels(d.Symbol, pos.Side, stopLoss, takeProfit); err != nil {
+			log.Printf("  ⚠️  保存止损止盈价失败: %v", err)
+		}
+		levelType := "take_profit"
+		if isStopLoss {
+			levelType = "stop_loss"
+		}
+		change := &models.ExitLevelChange{
+			Symbol:    d.Symbol,
+			Side:      pos.Side,
+			LevelType: levelType,
+			OldPrice:  oldPrice,
+			NewPrice:  newPrice,
+			MarkPrice: pos.MarkPrice,
+			Reason:    d.Reasoning,
+			CreatedAt: time.Now(),
+		}
+		if err := db.SaveExitLevelChange(change); err != nil {
+			log.Printf("  ⚠️  保存%s调整记录失败: %v", levelName, err)
+		}
+	}
+
+	log.Printf("  ✓ %s已调整: %s %s %.4f → %.4f", levelName, d.Symbol, pos.Side, oldPrice, newPrice)
+	return nil
+}
+
+// exitLevelUpdateCount 统计持仓自开仓以来的止损/止盈调整次数
+func (at *AutoTrader) exitLevelUpdateCount(symbol, side string, openTimeMs int64) int {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return 0
+	}
+	changes, err := db.GetExitLevelChanges(symbol, side, time.UnixMilli(openTimeMs))
+	if err != nil {
+		return 0
+	}
+	return len(changes)
+}
+
+// GetExitLevelHistory 获取持仓本次开仓以来的止损/止盈调整记录
+func (at *AutoTrader) GetExitLevelHistory(symbol, side string) ([]*models.ExitLevelChange, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	since := time.Time{}
+	if openTime, ok := at.GetPositionOpenTime(symbol, side); ok {
+		since = openTime
+	}
+	return db.GetExitLevelChanges(symbol, side, since)
+}
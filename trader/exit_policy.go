@@ -0,0 +1,298 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// 自动退出策略名称（记录在TradeOutcome.ExitReason中）
+const (
+	ExitPolicyBreakEven = "break_even"    // 浮盈达到N R后止损移到开仓价
+	ExitPolicyTimeStop  = "time_stop"     // 持仓超时且从未达到最低浮盈
+	ExitPolicyWeekend   = "weekend_close" // 周末前平仓
+	ExitPolicyEvent     = "event_close"   // 重大事件前平仓
+	ExitPolicyStopGuard = "stop_guard"    // 周期间越过止损且交易所止损单缺失，强制市价平仓
+)
+
+// exitPolicyConfig 获取自动退出策略配置（全局配置未初始化时全部关闭）
+func exitPolicyConfig() database.ExitPolicyConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetExitPolicyConfig()
+	}
+	return database.ExitPolicyConfig{BreakEvenR: 1.0, TimeStopHours: 24, TimeStopMinR: 0.5, EventCloseMinutes: 30}
+}
+
+// exitPolicyReason 自动退出策略触发时记录的退出原因
+func exitPolicyReason(policy string) string {
+	return fmt.Sprintf("退出策略[%s]", policy)
+}
+
+// runExitPolicies 在AI决策前执行自动退出策略（保本止损、时间止损、周末/事件前平仓）
+// 被平掉的持仓从上下文中移除，AI只会看到剩余持仓
+func (at *AutoTrader) runExitPolicies(ctx *decision.Context, record *logger.DecisionRecord) {
+	cfg := exitPolicyConfig()
+	if !cfg.BreakEvenEnabled && !cfg.TimeStopEnabled && !cfg.WeekendCloseEnabled && len(cfg.EventTimes) == 0 {
+		return
+	}
+
+	now := at.now()
+	eventPolicy, eventDesc := upcomingExitEvent(cfg, now)
+	current := make(map[string]bool, len(ctx.Positions))
+	remaining := ctx.Positions[:0]
+
+	for _, pos := range ctx.Positions {
+		posKey := pos.Symbol + "_" + pos.Side
+		current[posKey] = true
+
+		policy, reason := eventPolicy, eventDesc
+		if policy == "" {
+			policy, reason = at.checkRiskExitPolicies(cfg, pos, now)
+		}
+		if policy == "" || policy == ExitPolicyBreakEven {
+			if policy == ExitPolicyBreakEven {
+				at.moveStopToBreakEven(pos, reason, record)
+			}
+			remaining = append(remaining, pos)
+			continue
+		}
+
+		if at.closeByExitPolicy(pos, policy, reason, record) {
+			ctx.Account.PositionCount--
+			delete(current, posKey)
+			continue
+		}
+		remaining = append(remaining, pos)
+	}
+	ctx.Positions = remaining
+
+	// 清理已不存在持仓的浮盈峰值记录
+	for key := range at.exitPolicyPeakR {
+		if !current[key] {
+			delete(at.exitPolicyPeakR, key)
+		}
+	}
+}
+
+// checkRiskExitPolicies 按R倍数检查保本止损和时间止损
+func (at *AutoTrader) checkRiskExitPolicies(cfg database.ExitPolicyConfig, pos decision.PositionInfo, now time.Time) (string, string) {
+	openTime := time.UnixMilli(pos.UpdateTime)
+	risk := math.Abs(pos.EntryPrice - at.initialStopLoss(pos, openTime))
+	if risk <= 0 || pos.EntryPrice <= 0 || pos.MarkPrice <= 0 {
+		return "", ""
+	}
+	r := (pos.MarkPrice - pos.EntryPrice) / risk
+	if pos.Side == "short" {
+		r = -r
+	}
+
+	// 持仓期间达到的最大浮盈（内存记录，重启后从当前浮盈重新开始）
+	posKey := pos.Symbol + "_" + pos.Side
+	if peak, ok := at.exitPolicyPeakR[posKey]; !ok || r > peak {
+		at.exitPolicyPeakR[posKey] = r
+	}
+	peak := at.exitPolicyPeakR[posKey]
+
+	held := now.Sub(openTime)
+	if cfg.TimeStopEnabled && cfg.TimeStopHours > 0 && held.Hours() >= cfg.TimeStopHours && peak < cfg.TimeStopMinR {
+		return ExitPolicyTimeStop, fmt.Sprintf("持仓%.1f小时，最高浮盈%.2fR未达到%.2fR", held.Hours(), peak, cfg.TimeStopMinR)
+	}
+
+	if cfg.BreakEvenEnabled && cfg.BreakEvenR > 0 && r >= cfg.BreakEvenR && !stopAtBreakEven(pos) {
+		return ExitPolicyBreakEven, fmt.Sprintf("浮盈%.2fR ≥ %.2fR，止损移到开仓价%.4f", r, cfg.BreakEvenR, pos.EntryPrice)
+	}
+	return "", ""
+}
+
+// stopAtBreakEven 当前止损是否已经在开仓价或更有利的位置
+func stopAtBreakEven(pos decision.PositionInfo) bool {
+	if pos.StopLoss <= 0 {
+		return false
+	}
+	if pos.Side == "long" {
+		return pos.StopLoss >= pos.EntryPrice
+	}
+	return pos.StopLoss <= pos.EntryPrice
+}
+
+// initialStopLoss 获取持仓开仓时的初始止损（用于计算R），之后的调整记录中第一次止损调整前的价格
+func (at *AutoTrader) initialStopLoss(pos decision.PositionInfo, openTime time.Time) float64 {
+	if db := at.decisionLogger.GetDB(); db != nil {
+		if changes, err := db.GetExitLevelChanges(pos.Symbol, pos.Side, openTime); err == nil {
+			for _, change := range changes {
+				if change.LevelType == "stop_loss" && change.OldPrice > 0 {
+					return change.OldPrice
+				}
+			}
+		}
+	}
+	return pos.StopLoss
+}
+
+// upcomingExitEvent 检查是否处于周末或重大事件前的平仓窗口
+func upcomingExitEvent(cfg database.ExitPolicyConfig, now time.Time) (string, string) {
+	if cfg.EventCloseMinutes <= 0 {
+		return "", ""
+	}
+	window := time.Duration(cfg.EventCloseMinutes) * time.Minute
+	utc := now.UTC()
+
+	if cfg.WeekendCloseEnabled {
+		daysUntil := (int(time.Saturday) - int(utc.Weekday()) + 7) % 7
+		weekend := time.Date(utc.Year(), utc.Month(), utc.Day()+daysUntil, 0, 0, 0, 0, time.UTC)
+		if daysUntil == 0 {
+			weekend = weekend.AddDate(0, 0, 7)
+		}
+		if weekend.Sub(utc) <= window {
+			return ExitPolicyWeekend, fmt.Sprintf("距周末(%s)不足%d分钟", weekend.Format("2006-01-02 15:04 UTC"), cfg.EventCloseMinutes)
+		}
+	}
+
+	for _, s := range cfg.EventTimes {
+		eventTime, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			log.Printf("⚠️  退出策略事件时间格式错误(%s): %v", s, err)
+			continue
+		}
+		if until := eventTime.Sub(utc); until > 0 && until <= window {
+			return ExitPolicyEvent, fmt.Sprintf("距重大事件(%s)不足%d分钟", eventTime.UTC().Format("2006-01-02 15:04 UTC"), cfg.EventCloseMinutes)
+		}
+	}
+	return "", ""
+}
+
+// moveStopToBreakEven 把止损移到开仓价（走update_stop_loss的执行流程，保留调整记录）
+func (at *AutoTrader) moveStopToBreakEven(pos decision.PositionInfo, reason string, record *logger.DecisionRecord) {
+	d := &decision.Decision{
+		Symbol:    pos.Symbol,
+		Action:    "update_stop_loss",
+		StopLoss:  pos.EntryPrice,
+		Reasoning: exitPolicyReason(ExitPolicyBreakEven) + "：" + reason,
+	}
+	actionRecord := logger.DecisionAction{
+		Action:     d.Action,
+		Symbol:     d.Symbol,
+		Timestamp:  at.now(),
+		ExitPolicy: ExitPolicyBreakEven,
+	}
+
+	log.Printf("  🛡️ %s %s %s", pos.Symbol, exitPolicyReason(ExitPolicyBreakEven), reason)
+	if err := at.executeUpdateExitLevelWithRecord(d, &actionRecord); err != nil {
+		setActionError(&actionRecord, err)
+		log.Printf("  ❌ %s 保本止损设置失败: %v", pos.Symbol, err)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", pos.Symbol, exitPolicyReason(ExitPolicyBreakEven), err))
+	} else {
+		actionRecord.Success = actionRecord.Error == ""
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("🛡️ %s %s：%s", pos.Symbol, exitPolicyReason(ExitPolicyBreakEven), reason))
+	}
+	record.Decisions = append(record.Decisions, actionRecord)
+}
+
+// closeByExitPolicy 按退出策略平仓，返回持仓是否已平掉
+func (at *AutoTrader) closeByExitPolicy(pos decision.PositionInfo, policy, reason string, record *logger.DecisionRecord) bool {
+	d := &decision.Decision{
+		Symbol:    pos.Symbol,
+		Action:    "close_" + pos.Side,
+		Reasoning: exitPolicyReason(policy) + "：" + reason,
+	}
+	actionRecord := logger.DecisionAction{
+		Action:     d.Action,
+		Symbol:     d.Symbol,
+		Quantity:   pos.Quantity,
+		Timestamp:  at.now(),
+		ExitPolicy: policy,
+	}
+
+	log.Printf("  ⏏️ %s %s %s：%s", pos.Symbol, pos.Side, exitPolicyReason(policy), reason)
+	var err error
+	if pos.Side == "long" {
+		err = at.executeCloseLongWithRecord(d, &actionRecord)
+	} else {
+		err = at.executeCloseShortWithRecord(d, &actionRecord)
+	}
+	if err != nil {
+		setActionError(&actionRecord, err)
+		log.Printf("  ❌ %s %s 退出策略平仓失败: %v", pos.Symbol, pos.Side, err)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s %s 失败: %v", pos.Symbol, d.Action, exitPolicyReason(policy), err))
+		record.Decisions = append(record.Decisions, actionRecord)
+		return false
+	}
+
+	actionRecord.Success = actionRecord.Error == ""
+	at.notifyPositionExecuted(d, &actionRecord)
+	record.Decisions = append(record.Decisions, actionRecord)
+	record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏏️ %s %s %s：%s", pos.Symbol, d.Action, exitPolicyReason(policy), reason))
+
+	// 持仓已由本周期主动平掉，下次构建上下文时不再当作止损/止盈自动平仓
+	at.mu.Lock()
+	delete(at.lastKnownPositions, pos.Symbol+"_"+pos.Side)
+	at.mu.Unlock()
+	return actionRecord.Success
+}
+
+// classifyAutoClose 判断自动平仓由止损单还是止盈单触发，返回 OpenOrderStopLoss / OpenOrderTakeProfit（无法判断返回空）
+// 优先查询平仓成交对应订单的类型（STOP_MARKET / TAKE_PROFIT_MARKET）；查不到时按平仓价离记录的止损/止盈价远近判断，最后按盈亏方向判断
+func (at *AutoTrader) classifyAutoClose(symbol, side string, closeOrderID int64, closePrice, pnl float64) string {
+	if closeOrderID > 0 {
+		order, err := at.trader.GetOrderStatus(symbol, closeOrderID)
+		if err != nil {
+			log.Printf("  ⚠️  查询平仓订单类型失败: %v", err)
+		} else if kind := openOrderType(order.Type); kind == OpenOrderStopLoss || kind == OpenOrderTakeProfit {
+			return kind
+		}
+	}
+
+	if db := at.decisionLogger.GetDB(); db != nil && closePrice > 0 {
+		if levels, err := db.GetAllPositionExitLevels(); err == nil {
+			stopLoss, takeProfit := levels[symbol+"_"+side][0], levels[symbol+"_"+side][1]
+			if stopLoss > 0 && takeProfit > 0 {
+				if math.Abs(closePrice-takeProfit) < math.Abs(closePrice-stopLoss) {
+					return OpenOrderTakeProfit
+				}
+				return OpenOrderStopLoss
+			}
+		}
+	}
+
+	// 止损可能已被移到盈利区（保本/移动止损），盈利平仓无法仅凭盈亏判断
+	if pnl < 0 {
+		return OpenOrderStopLoss
+	}
+	return ""
+}
+
+// autoCloseExitReason 止损/止盈自动平仓的退出原因（保本止损被触发时记录为保本退出策略）
+func (at *AutoTrader) autoCloseExitReason(symbol, side, exitKind string, openTime time.Time) string {
+	switch exitKind {
+	case OpenOrderTakeProfit:
+		return "止盈自动触发"
+	case OpenOrderStopLoss:
+	default:
+		return "止损/止盈自动触发"
+	}
+
+	const stopReason = "止损自动触发"
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return stopReason
+	}
+	changes, err := db.GetExitLevelChanges(symbol, side, openTime)
+	if err != nil {
+		return stopReason
+	}
+	breakEven := false
+	for _, change := range changes {
+		if change.LevelType == "stop_loss" {
+			breakEven = strings.HasPrefix(change.Reason, exitPolicyReason(ExitPolicyBreakEven))
+		}
+	}
+	if !breakEven {
+		return stopReason
+	}
+	return exitPolicyReason(ExitPolicyBreakEven)
+}
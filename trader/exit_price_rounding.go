@@ -0,0 +1,53 @@
+package trader
+
+import (
+	"log"
+	"math"
+	"nofx/money"
+	"strings"
+)
+
+// priceTick 价格在该数量级下的最小变动单位（tickSize与有效数字限制取较大者）
+func (f SymbolFilters) priceTick(price float64) float64 {
+	tick := f.TickSize
+	if f.PriceSigFigs > 0 && price > 0 {
+		sigTick := math.Pow(10, math.Floor(math.Log10(price))-float64(f.PriceSigFigs-1))
+		// 整数价格不受有效数字限制
+		tick = math.Max(tick, math.Min(sigTick, 1))
+	}
+	return tick
+}
+
+// roundExitPrice 按交易对价格规则取整止损/止盈价（方向与持仓相关，不做四舍五入）
+// 平多（卖出）向下取整、平空（买入）向上取整：
+// 止损远离当前价（多仓止损不会被取整到开仓价之上，止损距离只会略微放宽），止盈靠近当前价（不会要求比目标更远的价格）
+// 限价条件单的限价按同样方向取整，触发后更容易成交
+func roundExitPrice(filters SymbolFilters, positionSide string, price float64) float64 {
+	tick := filters.priceTick(price)
+	if tick <= 0 || price <= 0 {
+		return price
+	}
+	if strings.EqualFold(positionSide, "LONG") {
+		return money.FloorToStep(price, tick)
+	}
+	return money.CeilToStep(price, tick)
+}
+
+// exitPriceRounder 获取交易对价格规则，返回该持仓止损止盈价的取整函数（获取失败时原样返回，由驱动按自身精度处理）
+func (at *AutoTrader) exitPriceRounder(symbol, positionSide string) func(price float64) float64 {
+	filters, err := at.trader.GetSymbolFilters(symbol)
+	if err != nil {
+		log.Printf("  ⚠️  获取 %s 价格规则失败，止损止盈价按交易所精度提交: %v", symbol, err)
+		return func(price float64) float64 { return price }
+	}
+	return func(price float64) float64 {
+		if price <= 0 {
+			return price
+		}
+		rounded := roundExitPrice(filters, positionSide, price)
+		if rounded != price {
+			log.Printf("  📐 %s %s 价格按最小变动单位取整: %v → %v", symbol, positionSide, price, rounded)
+		}
+		return rounded
+	}
+}
@@ -0,0 +1,600 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/logger"
+	"nofx/money"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 资金费率套利（Delta中性）
+// 在候选币种中寻找费率差足够大的币种：永续腿收取资金费（正费率做空、负费率做多），对冲腿反向持仓抵消价格风险
+// 套利仓位独立于AI决策：不出现在Prompt中，AI对这些币种的决策会被拒绝，执行结果记录到当前周期的决策记录
+
+// fundingArbReportLimit 套利报告最多返回的历史仓位数
+const fundingArbReportLimit = 100
+
+var (
+	fundingArbMu      sync.RWMutex
+	fundingArbTraders = make(map[string]*AutoTrader)     // 运行中的Trader（跨交易所对冲时查找对冲腿）
+	fundingArbLegs    = make(map[string]map[string]bool) // traderID -> 套利占用的持仓(symbol_side)，包括作为对冲腿的持仓
+)
+
+// registerFundingArbTrader 登记Trader（可被其他Trader用作对冲腿）
+func registerFundingArbTrader(at *AutoTrader) {
+	fundingArbMu.Lock()
+	defer fundingArbMu.Unlock()
+	fundingArbTraders[at.id] = at
+}
+
+// unregisterFundingArbTrader 注销Trader（重载时新实例可能已登记，只删除自身）
+func unregisterFundingArbTrader(at *AutoTrader) {
+	fundingArbMu.Lock()
+	defer fundingArbMu.Unlock()
+	if fundingArbTraders[at.id] == at {
+		delete(fundingArbTraders, at.id)
+	}
+}
+
+func lookupFundingArbTrader(id string) *AutoTrader {
+	fundingArbMu.RLock()
+	defer fundingArbMu.RUnlock()
+	return fundingArbTraders[id]
+}
+
+// markFundingArbLeg 标记/取消标记套利占用的持仓
+func markFundingArbLeg(traderID, symbol, side string, held bool) {
+	fundingArbMu.Lock()
+	defer fundingArbMu.Unlock()
+	legs := fundingArbLegs[traderID]
+	if held {
+		if legs == nil {
+			legs = make(map[string]bool)
+			fundingArbLegs[traderID] = legs
+		}
+		legs[symbol+"_"+side] = true
+		return
+	}
+	delete(legs, symbol+"_"+side)
+}
+
+// isFundingArbLeg 该持仓是否为套利仓位（对AI不可见）
+func isFundingArbLeg(traderID, symbol, side string) bool {
+	fundingArbMu.RLock()
+	defer fundingArbMu.RUnlock()
+	return fundingArbLegs[traderID][symbol+"_"+side]
+}
+
+// fundingArbSymbols 该Trader被套利占用的币种（AI不能对这些币种开平仓）
+func fundingArbSymbols(traderID string) map[string]bool {
+	fundingArbMu.RLock()
+	defer fundingArbMu.RUnlock()
+	symbols := make(map[string]bool)
+	for key := range fundingArbLegs[traderID] {
+		if i := strings.LastIndex(key, "_"); i > 0 {
+			symbols[key[:i]] = true
+		}
+	}
+	return symbols
+}
+
+// markFundingArbPosition 标记套利仓位的两条腿
+func (at *AutoTrader) markFundingArbPosition(pos *models.FundingArbPosition, held bool) {
+	markFundingArbLeg(at.id, pos.Symbol, pos.PerpSide, held)
+	if peerID, ok := fundingArbPeerID(pos.HedgeVenue); ok {
+		markFundingArbLeg(peerID, pos.Symbol, oppositeSide(pos.PerpSide), held)
+	}
+}
+
+// restoreFundingArbLegs 启动时从数据库恢复套利仓位标记
+func (at *AutoTrader) restoreFundingArbLegs() {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	positions, err := db.FundingArb().GetOpen()
+	if err != nil {
+		log.Printf("⚠️  恢复资金费率套利仓位失败: %v", err)
+		return
+	}
+	for _, pos := range positions {
+		at.markFundingArbPosition(pos, true)
+	}
+	if len(positions) > 0 {
+		log.Printf("✓ 从数据库恢复了 %d 个资金费率套利仓位", len(positions))
+	}
+}
+
+func fundingArbPeerID(venue string) (string, bool) {
+	if !strings.HasPrefix(venue, hedgeVenuePeerPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(venue, hedgeVenuePeerPrefix), true
+}
+
+func oppositeSide(side string) string {
+	if side == "long" {
+		return "short"
+	}
+	return "long"
+}
+
+// fundingCarryPct 套利仓位每8小时的资金费收益率(%)
+// 永续做空收取正费率，对冲腿做多支付对冲腿费率；反向同理
+func fundingCarryPct(perpSide string, perp, hedge FundingRate) float64 {
+	spread := perp.Per8hPct() - hedge.Per8hPct()
+	if perpSide == "long" {
+		return -spread
+	}
+	return spread
+}
+
+// legPnL 单条腿的价差盈亏
+func legPnL(side string, entry, exit, quantity float64) float64 {
+	return money.PnL(side, entry, exit, quantity)
+}
+
+// runFundingArbitrage 每个周期执行一次：先管理已有套利仓位（累计资金费、检查退出条件），再寻找新的套利机会
+func (at *AutoTrader) runFundingArbitrage(record *logger.DecisionRecord) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	cfg := database.CurrentFundingArbConfig()
+	open, err := db.FundingArb().GetOpen()
+	if err != nil {
+		log.Printf("⚠️  查询资金费率套利仓位失败: %v", err)
+		return
+	}
+	if !cfg.Enabled && len(open) == 0 {
+		return
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️  资金费率套利: 获取持仓失败: %v", err)
+		return
+	}
+	held := make(map[string]bool)
+	for _, pos := range positions {
+		held[pos.Symbol+"_"+pos.Side] = true
+	}
+
+	var remaining []*models.FundingArbPosition
+	for _, pos := range open {
+		if !at.manageFundingArbPosition(pos, cfg, held[pos.Symbol+"_"+pos.PerpSide], record) {
+			remaining = append(remaining, pos)
+		}
+	}
+
+	if cfg.Enabled {
+		at.openFundingArbPositions(cfg, remaining, held, record)
+	}
+}
+
+// manageFundingArbPosition 累计资金费并检查退出条件，返回是否已平仓
+func (at *AutoTrader) manageFundingArbPosition(pos *models.FundingArbPosition, cfg database.FundingArbConfig, perpHeld bool, record *logger.DecisionRecord) bool {
+	hedge, err := at.newFundingHedge(pos.HedgeVenue)
+	if err != nil {
+		log.Printf("⚠️  资金费率套利 %s: 无法连接对冲腿: %v", pos.Symbol, err)
+		return false
+	}
+
+	// 永续腿已不存在（被强平或手动平仓），只需平掉对冲腿
+	if !perpHeld {
+		return at.closeFundingArbPosition(pos, hedge, "永续腿已不存在", false, record)
+	}
+
+	perpPrice, err := at.trader.GetMarketPrice(pos.Symbol)
+	if err != nil {
+		log.Printf("⚠️  资金费率套利 %s: 获取价格失败: %v", pos.Symbol, err)
+		return false
+	}
+
+	// 按当前费率差估算上次累计以来的资金费
+	carry := pos.EntryRatePct
+	perpRate, perpErr := at.trader.GetFundingRate(pos.Symbol)
+	hedgeRate, hedgeErr := hedge.FundingRate(pos.Symbol)
+	if perpErr == nil && hedgeErr == nil {
+		carry = fundingCarryPct(pos.PerpSide, perpRate, hedgeRate)
+		now := time.Now()
+		hours := now.Sub(pos.LastAccrualAt).Hours()
+		funding := pos.Quantity * perpPrice * carry / 100 * hours / 8
+		if db := at.decisionLogger.GetDB(); db != nil {
+			if err := db.FundingArb().UpdateAccrual(pos.ID, funding, now); err != nil {
+				log.Printf("⚠️  资金费率套利 %s: 保存资金费失败: %v", pos.Symbol, err)
+			} else {
+				pos.FundingCollected += funding
+				pos.LastAccrualAt = now
+			}
+		}
+	} else {
+		log.Printf("⚠️  资金费率套利 %s: 获取资金费率失败: %v %v", pos.Symbol, perpErr, hedgeErr)
+	}
+
+	// 永续腿不利波动（占保证金比例）
+	legLossPct := 0.0
+	if pos.PerpEntryPrice > 0 && cfg.Leverage > 0 {
+		legLossPct = -legPnL(pos.PerpSide, pos.PerpEntryPrice, perpPrice, 1) / pos.PerpEntryPrice * float64(cfg.Leverage) * 100
+	}
+
+	reason := ""
+	switch {
+	case !cfg.Enabled:
+		reason = "套利模块已关闭"
+	case perpErr == nil && hedgeErr == nil && carry < cfg.ExitRatePct:
+		reason = fmt.Sprintf("费率差 %.4f%% 低于退出阈值 %.4f%%", carry, cfg.ExitRatePct)
+	case cfg.MaxHoldHours > 0 && time.Since(pos.OpenedAt).Hours() >= cfg.MaxHoldHours:
+		reason = fmt.Sprintf("持有超过 %.0f 小时", cfg.MaxHoldHours)
+	case cfg.MaxLegLossPct > 0 && legLossPct >= cfg.MaxLegLossPct:
+		reason = fmt.Sprintf("永续腿不利波动 %.2f%% 超过上限 %.2f%%", legLossPct, cfg.MaxLegLossPct)
+	}
+	if reason == "" {
+		log.Printf("  💱 套利持仓 %s %s/%s: 费率差 %.4f%%/8h | 累计资金费 %.4f USDT",
+			pos.Symbol, pos.PerpSide, pos.HedgeVenue, carry, pos.FundingCollected)
+		return false
+	}
+	return at.closeFundingArbPosition(pos, hedge, reason, true, record)
+}
+
+// closeFundingArbPosition 平掉套利仓位的两条腿，返回是否已完成平仓
+// 永续腿平仓失败时保留记录下个周期重试；对冲腿平仓失败时永续腿已平，下个周期按"永续腿已不存在"重试对冲腿
+func (at *AutoTrader) closeFundingArbPosition(pos *models.FundingArbPosition, hedge fundingHedge, reason string, closePerp bool, record *logger.DecisionRecord) bool {
+	log.Printf("  💱 资金费率套利平仓: %s（%s）", pos.Symbol, reason)
+	action := logger.DecisionAction{
+		Action:    "funding_arb_close",
+		Symbol:    pos.Symbol,
+		Quantity:  pos.Quantity,
+		Timestamp: time.Now(),
+	}
+	defer func() {
+		record.Decisions = append(record.Decisions, action)
+	}()
+
+	perpExit := pos.PerpEntryPrice
+	if closePerp {
+		price, err := at.trader.GetMarketPrice(pos.Symbol)
+		if err == nil {
+			perpExit = price
+		}
+		var order *Order
+		if pos.PerpSide == "long" {
+			order, err = at.trader.CloseLong(pos.Symbol, pos.Quantity)
+		} else {
+			order, err = at.trader.CloseShort(pos.Symbol, pos.Quantity)
+		}
+		if err != nil {
+			setActionError(&action, fmt.Errorf("永续腿平仓失败: %w", err))
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s 资金费率套利平仓失败: %v", pos.Symbol, err))
+			return false
+		}
+		action.OrderID = order.OrderID
+		action.ExecutedQty, perpExit = at.confirmOrderFill(pos.Symbol, order, pos.Quantity, perpExit)
+	}
+	action.Price = perpExit
+	action.AvgPrice = perpExit
+
+	hedgeExit, err := hedge.Close(pos.Symbol, oppositeSide(pos.PerpSide), pos.Quantity)
+	if err != nil {
+		setActionError(&action, fmt.Errorf("对冲腿平仓失败: %w", err))
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s 资金费率套利对冲腿平仓失败: %v", pos.Symbol, err))
+		return false
+	}
+
+	realized := money.Sum(
+		legPnL(pos.PerpSide, pos.PerpEntryPrice, perpExit, pos.Quantity),
+		legPnL(oppositeSide(pos.PerpSide), pos.HedgeEntryPrice, hedgeExit, pos.Quantity),
+		pos.FundingCollected)
+	if db := at.decisionLogger.GetDB(); db != nil {
+		if err := db.FundingArb().Close(pos.ID, perpExit, hedgeExit, realized, reason); err != nil {
+			log.Printf("  ⚠️  保存套利平仓记录失败: %v", err)
+		}
+	}
+	at.markFundingArbPosition(pos, false)
+
+	action.Success = true
+	record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s 资金费率套利平仓（%s）: 资金费 %.4f USDT，合计盈亏 %.4f USDT",
+		pos.Symbol, reason, pos.FundingCollected, realized))
+	log.Printf("  ✓ 套利平仓完成: %s 资金费 %.4f USDT，合计盈亏 %.4f USDT", pos.Symbol, pos.FundingCollected, realized)
+	return true
+}
+
+// fundingArbOpportunity 套利机会
+type fundingArbOpportunity struct {
+	symbol   string
+	perpSide string
+	carryPct float64 // 每8小时费率差(%)
+}
+
+// openFundingArbPositions 在候选币种中寻找费率差最大的币种开新套利仓位（受仓位数和总名义价值限制）
+func (at *AutoTrader) openFundingArbPositions(cfg database.FundingArbConfig, open []*models.FundingArbPosition, held map[string]bool, record *logger.DecisionRecord) {
+	openNotional := 0.0
+	arbSymbols := fundingArbSymbols(at.id)
+	for _, pos := range open {
+		openNotional += pos.Quantity * pos.PerpEntryPrice
+	}
+	slots := cfg.MaxPositions - len(open)
+	if slots <= 0 || cfg.NotionalUSD <= 0 || openNotional+cfg.NotionalUSD > cfg.MaxTotalNotional {
+		return
+	}
+
+	hedge, err := at.newFundingHedge(cfg.Hedge)
+	if err != nil {
+		log.Printf("⚠️  资金费率套利: %v", err)
+		return
+	}
+
+	candidates, err := at.candidateSource.GetCandidates()
+	if err != nil {
+		log.Printf("⚠️  资金费率套利: 获取候选币种失败: %v", err)
+		return
+	}
+
+	var opportunities []fundingArbOpportunity
+	for _, symbol := range candidates.AllSymbols {
+		// 跳过AI持仓和已有套利仓位的币种
+		if arbSymbols[symbol] || held[symbol+"_long"] || held[symbol+"_short"] {
+			continue
+		}
+		perpRate, err := at.trader.GetFundingRate(symbol)
+		if err != nil {
+			continue
+		}
+		hedgeRate, err := hedge.FundingRate(symbol)
+		if err != nil {
+			continue
+		}
+		perpSide := "short"
+		if perpRate.Per8hPct() < hedgeRate.Per8hPct() {
+			perpSide = "long"
+		}
+		carry := fundingCarryPct(perpSide, perpRate, hedgeRate)
+		if carry < cfg.MinRatePct || !hedge.Supports(oppositeSide(perpSide)) {
+			continue
+		}
+		opportunities = append(opportunities, fundingArbOpportunity{symbol: symbol, perpSide: perpSide, carryPct: carry})
+	}
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].carryPct > opportunities[j].carryPct
+	})
+
+	for _, opp := range opportunities {
+		if slots <= 0 || openNotional+cfg.NotionalUSD > cfg.MaxTotalNotional {
+			break
+		}
+		pos, err := at.openFundingArbPosition(opp, cfg, hedge, record)
+		if err != nil {
+			log.Printf("  ⚠️  资金费率套利 %s 开仓跳过: %v", opp.symbol, err)
+			continue
+		}
+		slots--
+		openNotional += pos.Quantity * pos.PerpEntryPrice
+	}
+}
+
+// openFundingArbPosition 先开永续腿再开对冲腿，对冲腿失败时回滚永续腿
+func (at *AutoTrader) openFundingArbPosition(opp fundingArbOpportunity, cfg database.FundingArbConfig, hedge fundingHedge, record *logger.DecisionRecord) (*models.FundingArbPosition, error) {
+	if err := checkSymbolTradable(opp.symbol); err != nil {
+		return nil, err
+	}
+	perpPrice, err := at.trader.GetMarketPrice(opp.symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取永续价格失败: %w", err)
+	}
+	hedgePrice, err := hedge.Price(opp.symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取对冲腿价格失败: %w", err)
+	}
+	if basis := math.Abs(perpPrice-hedgePrice) / hedgePrice * 100; basis > cfg.MaxBasisPct {
+		return nil, fmt.Errorf("两腿价差 %.3f%% 超过上限 %.3f%%", basis, cfg.MaxBasisPct)
+	}
+
+	// 数量同时满足两条腿的下单规则
+	perpFilters, err := at.trader.GetSymbolFilters(opp.symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取永续下单规则失败: %w", err)
+	}
+	hedgeFilters, err := hedge.Filters(opp.symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取对冲腿下单规则失败: %w", err)
+	}
+	quantity, err := applySymbolFilters(opp.symbol, cfg.NotionalUSD/perpPrice, perpPrice, mergeSymbolFilters(perpFilters, hedgeFilters))
+	if err != nil {
+		return nil, err
+	}
+
+	pos := &models.FundingArbPosition{
+		Symbol:       opp.symbol,
+		PerpSide:     opp.perpSide,
+		HedgeVenue:   hedge.Name(),
+		EntryRatePct: opp.carryPct,
+	}
+	// 先标记，避免下单期间被AI（或对冲Trader的AI）当作普通持仓处理
+	at.markFundingArbPosition(pos, true)
+
+	log.Printf("  💱 资金费率套利开仓: %s 永续%s + %s %s | 费率差 %.4f%%/8h | 数量 %.6f",
+		opp.symbol, opp.perpSide, hedge.Name(), oppositeSide(opp.perpSide), opp.carryPct, quantity)
+	action := logger.DecisionAction{
+		Action:    "funding_arb_open",
+		Symbol:    opp.symbol,
+		Quantity:  quantity,
+		Leverage:  cfg.Leverage,
+		Price:     perpPrice,
+		Timestamp: time.Now(),
+	}
+	defer func() {
+		record.Decisions = append(record.Decisions, action)
+	}()
+	fail := func(err error) (*models.FundingArbPosition, error) {
+		at.markFundingArbPosition(pos, false)
+		setActionError(&action, err)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s 资金费率套利开仓失败: %v", opp.symbol, err))
+		return nil, err
+	}
+
+	var order *Order
+	if opp.perpSide == "long" {
+		order, err = at.trader.OpenLong(opp.symbol, quantity, cfg.Leverage)
+	} else {
+		order, err = at.trader.OpenShort(opp.symbol, quantity, cfg.Leverage)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("永续腿开仓失败: %w", err))
+	}
+	action.OrderID = order.OrderID
+	filledQty, perpAvg := at.confirmOrderFill(opp.symbol, order, quantity, perpPrice)
+	action.ExecutedQty = filledQty
+	action.AvgPrice = perpAvg
+	if filledQty <= 0 {
+		return fail(fmt.Errorf("永续腿订单未成交（订单ID: %d）", order.OrderID))
+	}
+
+	hedgeQty, hedgeAvg, err := hedge.Open(opp.symbol, oppositeSide(opp.perpSide), filledQty, cfg.Leverage)
+	if err != nil {
+		// 回滚永续腿，避免留下单边敞口
+		if opp.perpSide == "long" {
+			_, unwindErr := at.trader.CloseLong(opp.symbol, filledQty)
+			err = fmt.Errorf("对冲腿开仓失败: %w（永续腿回滚: %v）", err, unwindErr)
+		} else {
+			_, unwindErr := at.trader.CloseShort(opp.symbol, filledQty)
+			err = fmt.Errorf("对冲腿开仓失败: %w（永续腿回滚: %v）", err, unwindErr)
+		}
+		return fail(err)
+	}
+	if hedgeQty < filledQty*0.99 {
+		log.Printf("  ⚠️  对冲腿部分成交: %.6f/%.6f", hedgeQty, filledQty)
+	}
+
+	now := time.Now()
+	pos.Quantity = filledQty
+	pos.PerpEntryPrice = perpAvg
+	pos.HedgeEntryPrice = hedgeAvg
+	pos.OpenedAt = now
+	pos.LastAccrualAt = now
+	if db := at.decisionLogger.GetDB(); db != nil {
+		if pos.ID, err = db.FundingArb().Insert(pos); err != nil {
+			log.Printf("  ⚠️  保存套利仓位失败: %v", err)
+		}
+	}
+
+	action.Success = true
+	action.NotionalUSD = filledQty * perpAvg
+	if cfg.Leverage > 0 {
+		action.MarginUSD = action.NotionalUSD / float64(cfg.Leverage)
+	}
+	record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s 资金费率套利开仓: 永续%s @ %.4f + %s @ %.4f，费率差 %.4f%%/8h",
+		opp.symbol, opp.perpSide, perpAvg, hedge.Name(), hedgeAvg, opp.carryPct))
+	return pos, nil
+}
+
+// mergeSymbolFilters 合并两条腿的下单规则（取更严格的一方）
+func mergeSymbolFilters(a, b SymbolFilters) SymbolFilters {
+	merged := SymbolFilters{
+		StepSize:    math.Max(a.StepSize, b.StepSize),
+		MinQty:      math.Max(a.MinQty, b.MinQty),
+		MaxQty:      a.MaxQty,
+		MinNotional: math.Max(a.MinNotional, b.MinNotional),
+	}
+	if b.MaxQty > 0 && (merged.MaxQty == 0 || b.MaxQty < merged.MaxQty) {
+		merged.MaxQty = b.MaxQty
+	}
+	return merged
+}
+
+// checkFundingArbSymbol AI决策不能操作套利占用的币种
+func (at *AutoTrader) checkFundingArbSymbol(symbol string) error {
+	if fundingArbSymbols(at.id)[symbol] {
+		return fmt.Errorf("❌ %s 为资金费率套利仓位，由套利模块管理，拒绝AI决策", symbol)
+	}
+	return nil
+}
+
+// FundingArbPositionView 套利仓位（API展示）
+type FundingArbPositionView struct {
+	ID               int64      `json:"id"`
+	Symbol           string     `json:"symbol"`
+	PerpSide         string     `json:"perp_side"`
+	HedgeVenue       string     `json:"hedge_venue"`
+	Quantity         float64    `json:"quantity"`
+	PerpEntryPrice   float64    `json:"perp_entry_price"`
+	HedgeEntryPrice  float64    `json:"hedge_entry_price"`
+	NotionalUSD      float64    `json:"notional_usd"`
+	EntryRatePct     float64    `json:"entry_rate_pct"` // 开仓时费率差(%/8h)
+	FundingCollected float64    `json:"funding_collected"`
+	OpenedAt         time.Time  `json:"opened_at"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty"`
+	PerpExitPrice    float64    `json:"perp_exit_price,omitempty"`
+	HedgeExitPrice   float64    `json:"hedge_exit_price,omitempty"`
+	RealizedPnL      float64    `json:"realized_pnl,omitempty"`
+	CloseReason      string     `json:"close_reason,omitempty"`
+}
+
+// FundingArbReport 资金费率套利报告
+type FundingArbReport struct {
+	Enabled           bool                      `json:"enabled"`
+	Hedge             string                    `json:"hedge"`
+	OpenCount         int                       `json:"open_count"`
+	OpenNotional      float64                   `json:"open_notional"` // 持仓中永续腿名义价值(USDT)
+	OpenFunding       float64                   `json:"open_funding"`  // 持仓中累计资金费(USDT)
+	ClosedCount       int                       `json:"closed_count"`
+	ClosedFunding     float64                   `json:"closed_funding"`      // 已平仓资金费(USDT)
+	ClosedRealizedPnL float64                   `json:"closed_realized_pnl"` // 已平仓合计盈亏(USDT，含价差)
+	Positions         []*FundingArbPositionView `json:"positions"`           // 最近的套利仓位（按开仓时间倒序）
+}
+
+// GetFundingArbReport 获取资金费率套利报告
+func (at *AutoTrader) GetFundingArbReport() (*FundingArbReport, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	positions, err := db.FundingArb().GetRecent(fundingArbReportLimit)
+	if err != nil {
+		return nil, fmt.Errorf("查询资金费率套利仓位失败: %w", err)
+	}
+
+	cfg := database.CurrentFundingArbConfig()
+	report := &FundingArbReport{
+		Enabled:   cfg.Enabled,
+		Hedge:     cfg.Hedge,
+		Positions: make([]*FundingArbPositionView, 0, len(positions)),
+	}
+	for _, pos := range positions {
+		view := &FundingArbPositionView{
+			ID:               pos.ID,
+			Symbol:           pos.Symbol,
+			PerpSide:         pos.PerpSide,
+			HedgeVenue:       pos.HedgeVenue,
+			Quantity:         pos.Quantity,
+			PerpEntryPrice:   pos.PerpEntryPrice,
+			HedgeEntryPrice:  pos.HedgeEntryPrice,
+			NotionalUSD:      pos.Quantity * pos.PerpEntryPrice,
+			EntryRatePct:     pos.EntryRatePct,
+			FundingCollected: pos.FundingCollected,
+			OpenedAt:         pos.OpenedAt,
+			ClosedAt:         pos.ClosedAt,
+			PerpExitPrice:    pos.PerpExitPrice,
+			HedgeExitPrice:   pos.HedgeExitPrice,
+			RealizedPnL:      pos.RealizedPnL,
+			CloseReason:      pos.CloseReason,
+		}
+		report.Positions = append(report.Positions, view)
+		if pos.ClosedAt == nil {
+			report.OpenCount++
+			report.OpenNotional += view.NotionalUSD
+			report.OpenFunding += pos.FundingCollected
+		} else {
+			report.ClosedCount++
+			report.ClosedFunding += pos.FundingCollected
+			report.ClosedRealizedPnL += pos.RealizedPnL
+		}
+	}
+	return report, nil
+}
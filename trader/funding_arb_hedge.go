@@ -0,0 +1,254 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"nofx/money"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2"
+)
+
+// 对冲腿类型
+const (
+	HedgeVenueBinanceSpot = "binance_spot" // 币安现货（只能做多，仅用于正费率）
+	hedgeVenuePeerPrefix  = "trader:"      // trader:<id> 另一个Trader的永续合约（跨交易所）
+)
+
+// fundingHedge 资金费率套利的对冲腿（与永续腿方向相反，抵消价格风险）
+type fundingHedge interface {
+	// Name 对冲腿标识（记录到套利仓位表）
+	Name() string
+
+	// Supports 是否支持该方向的对冲仓位
+	Supports(side string) bool
+
+	// FundingRate 对冲腿自身的资金费率（现货为0）
+	FundingRate(symbol string) (FundingRate, error)
+
+	// Price 对冲腿的当前价格
+	Price(symbol string) (float64, error)
+
+	// Filters 对冲腿的下单数量规则
+	Filters(symbol string) (SymbolFilters, error)
+
+	// Open 开对冲仓位，返回实际成交数量和均价
+	Open(symbol, side string, quantity float64, leverage int) (float64, float64, error)
+
+	// Close 平对冲仓位，返回成交均价
+	Close(symbol, side string, quantity float64) (float64, error)
+}
+
+// newFundingHedge 根据配置创建对冲腿
+func (at *AutoTrader) newFundingHedge(venue string) (fundingHedge, error) {
+	switch {
+	case venue == HedgeVenueBinanceSpot:
+		if at.config.BinanceAPIKey == "" || at.config.BinanceSecretKey == "" {
+			return nil, fmt.Errorf("对冲腿 %s 需要币安API密钥", venue)
+		}
+		return newBinanceSpotHedge(at.config.BinanceAPIKey, at.config.BinanceSecretKey), nil
+	case strings.HasPrefix(venue, hedgeVenuePeerPrefix):
+		peerID := strings.TrimPrefix(venue, hedgeVenuePeerPrefix)
+		if peerID == at.id {
+			return nil, fmt.Errorf("对冲腿不能是Trader自身")
+		}
+		peer := lookupFundingArbTrader(peerID)
+		if peer == nil {
+			return nil, fmt.Errorf("对冲Trader %s 未运行", peerID)
+		}
+		return &perpHedge{peer: peer}, nil
+	default:
+		return nil, fmt.Errorf("不支持的对冲腿: %s", venue)
+	}
+}
+
+// binanceSpotHedge 币安现货对冲腿
+type binanceSpotHedge struct {
+	client *binance.Client
+}
+
+func newBinanceSpotHedge(apiKey, secretKey string) *binanceSpotHedge {
+	return &binanceSpotHedge{client: binance.NewClient(apiKey, secretKey)}
+}
+
+func (h *binanceSpotHedge) Name() string { return HedgeVenueBinanceSpot }
+
+func (h *binanceSpotHedge) Supports(side string) bool { return side == "long" }
+
+func (h *binanceSpotHedge) FundingRate(symbol string) (FundingRate, error) {
+	return FundingRate{}, nil
+}
+
+func (h *binanceSpotHedge) Price(symbol string) (float64, error) {
+	prices, err := h.client.NewListPricesService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("获取现货价格失败: %w", err)
+	}
+	if len(prices) == 0 {
+		return 0, fmt.Errorf("未找到现货交易对 %s", symbol)
+	}
+	return strconv.ParseFloat(prices[0].Price, 64)
+}
+
+func (h *binanceSpotHedge) symbolInfo(symbol string) (*binance.Symbol, error) {
+	info, err := h.client.NewExchangeInfoService().Symbol(symbol).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("获取现货交易规则失败: %w", err)
+	}
+	for i := range info.Symbols {
+		if info.Symbols[i].Symbol == symbol {
+			return &info.Symbols[i], nil
+		}
+	}
+	return nil, fmt.Errorf("未找到现货交易对 %s", symbol)
+}
+
+func (h *binanceSpotHedge) Filters(symbol string) (SymbolFilters, error) {
+	s, err := h.symbolInfo(symbol)
+	if err != nil {
+		return SymbolFilters{}, err
+	}
+	var filters SymbolFilters
+	if lot := s.LotSizeFilter(); lot != nil {
+		filters.StepSize, _ = strconv.ParseFloat(lot.StepSize, 64)
+		filters.MinQty, _ = strconv.ParseFloat(lot.MinQuantity, 64)
+		filters.MaxQty, _ = strconv.ParseFloat(lot.MaxQuantity, 64)
+	}
+	if lot := s.MarketLotSizeFilter(); lot != nil {
+		if maxQty, _ := strconv.ParseFloat(lot.MaxQuantity, 64); maxQty > 0 && (filters.MaxQty == 0 || maxQty < filters.MaxQty) {
+			filters.MaxQty = maxQty
+		}
+	}
+	if notional := s.NotionalFilter(); notional != nil {
+		filters.MinNotional, _ = strconv.ParseFloat(notional.MinNotional, 64)
+	}
+	return filters, nil
+}
+
+func (h *binanceSpotHedge) Open(symbol, side string, quantity float64, leverage int) (float64, float64, error) {
+	if !h.Supports(side) {
+		return 0, 0, fmt.Errorf("现货对冲腿不支持做空")
+	}
+	return h.marketOrder(symbol, binance.SideTypeBuy, quantity)
+}
+
+// Close 卖出现货（买入时手续费可能以该币种扣除，按可用余额卖出）
+func (h *binanceSpotHedge) Close(symbol, side string, quantity float64) (float64, error) {
+	s, err := h.symbolInfo(symbol)
+	if err != nil {
+		return 0, err
+	}
+	account, err := h.client.NewGetAccountService().Do(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("获取现货余额失败: %w", err)
+	}
+	for _, b := range account.Balances {
+		if b.Asset != s.BaseAsset {
+			continue
+		}
+		if free, _ := strconv.ParseFloat(b.Free, 64); free < quantity {
+			quantity = free
+		}
+	}
+	if lot := s.LotSizeFilter(); lot != nil {
+		step, _ := strconv.ParseFloat(lot.StepSize, 64)
+		quantity = money.FloorToStep(quantity, step)
+	}
+	if quantity <= 0 {
+		return 0, fmt.Errorf("现货 %s 可用余额不足", s.BaseAsset)
+	}
+	_, avgPrice, err := h.marketOrder(symbol, binance.SideTypeSell, quantity)
+	return avgPrice, err
+}
+
+// marketOrder 现货市价单，返回成交数量和均价
+func (h *binanceSpotHedge) marketOrder(symbol string, side binance.SideType, quantity float64) (float64, float64, error) {
+	order, err := h.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(side).
+		Type(binance.OrderTypeMarket).
+		Quantity(strconv.FormatFloat(quantity, 'f', -1, 64)).
+		NewOrderRespType(binance.NewOrderRespTypeFULL).
+		Do(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("现货下单失败: %w", err)
+	}
+	executed, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	quote, _ := strconv.ParseFloat(order.CummulativeQuoteQuantity, 64)
+	if executed <= 0 {
+		return 0, 0, fmt.Errorf("现货订单未成交（订单ID: %d）", order.OrderID)
+	}
+	return executed, quote / executed, nil
+}
+
+// perpHedge 另一个Trader的永续合约对冲腿（跨交易所费率差）
+type perpHedge struct {
+	peer *AutoTrader
+}
+
+func (h *perpHedge) Name() string { return hedgeVenuePeerPrefix + h.peer.id }
+
+func (h *perpHedge) Supports(side string) bool { return side == "long" || side == "short" }
+
+func (h *perpHedge) FundingRate(symbol string) (FundingRate, error) {
+	return h.peer.trader.GetFundingRate(symbol)
+}
+
+func (h *perpHedge) Price(symbol string) (float64, error) {
+	return h.peer.trader.GetMarketPrice(symbol)
+}
+
+func (h *perpHedge) Filters(symbol string) (SymbolFilters, error) {
+	return h.peer.trader.GetSymbolFilters(symbol)
+}
+
+func (h *perpHedge) Open(symbol, side string, quantity float64, leverage int) (float64, float64, error) {
+	// 对冲Trader已有该币种持仓时不叠加（避免与其AI仓位混在一起）
+	positions, err := h.peer.trader.GetPositions()
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取对冲Trader持仓失败: %w", err)
+	}
+	for _, pos := range positions {
+		if pos.Symbol == symbol {
+			return 0, 0, fmt.Errorf("对冲Trader %s 已有 %s 持仓", h.peer.id, symbol)
+		}
+	}
+
+	price, err := h.Price(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+	var order *Order
+	if side == "long" {
+		order, err = h.peer.trader.OpenLong(symbol, quantity, leverage)
+	} else {
+		order, err = h.peer.trader.OpenShort(symbol, quantity, leverage)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	filledQty, avgPrice := h.peer.confirmOrderFill(symbol, order, quantity, price)
+	if filledQty <= 0 {
+		return 0, 0, fmt.Errorf("对冲订单未成交（订单ID: %d）", order.OrderID)
+	}
+	return filledQty, avgPrice, nil
+}
+
+func (h *perpHedge) Close(symbol, side string, quantity float64) (float64, error) {
+	price, err := h.Price(symbol)
+	if err != nil {
+		return 0, err
+	}
+	var order *Order
+	if side == "long" {
+		order, err = h.peer.trader.CloseLong(symbol, quantity)
+	} else {
+		order, err = h.peer.trader.CloseShort(symbol, quantity)
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, avgPrice := h.peer.confirmOrderFill(symbol, order, quantity, price)
+	return avgPrice, nil
+}
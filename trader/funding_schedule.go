@@ -0,0 +1,33 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/market"
+	"time"
+)
+
+// fundingTargets 从运行时配置构建资金费结算时间感知参数（prompt和延迟开仓都关闭时返回nil）
+func fundingTargets() *decision.FundingTargets {
+	cfg := database.FundingScheduleConfig{PromptEnabled: true, AdverseRatePct: 0.05}
+	if rc := database.GetGlobalConfig(); rc != nil {
+		cfg = rc.GetFundingScheduleConfig()
+	}
+	if !cfg.PromptEnabled && cfg.EntryDelayMinutes <= 0 {
+		return nil
+	}
+	return &decision.FundingTargets{
+		PromptEnabled:     cfg.PromptEnabled,
+		EntryDelayMinutes: cfg.EntryDelayMinutes,
+		AdverseRatePct:    cfg.AdverseRatePct,
+	}
+}
+
+// checkFundingEntry 距资金费结算不足配置时间且费率对开仓方向不利时延迟开仓
+func checkFundingEntry(data *market.Data, side string) error {
+	if reason := decision.AdverseFundingDelay(data, side, fundingTargets(), time.Now()); reason != "" {
+		return fmt.Errorf("⏳ %s 延迟开仓: %s", data.Symbol, reason)
+	}
+	return nil
+}
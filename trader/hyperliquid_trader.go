@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/money"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -71,7 +75,7 @@ func NewHyperliquidTrader(privateKeyHex string, walletAddr string, testnet bool)
 }
 
 // GetBalance 获取账户余额
-func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
+func (t *HyperliquidTrader) GetBalance() (*Balance, error) {
 	log.Printf("🔄 正在调用Hyperliquid API获取账户余额...")
 
 	// 获取账户状态
@@ -82,7 +86,7 @@ func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
 	}
 
 	// 解析余额信息（MarginSummary字段都是string）
-	result := make(map[string]interface{})
+	result := &Balance{}
 
 	// 🔍 调试：打印API返回的完整CrossMarginSummary结构
 	summaryJSON, _ := json.MarshalIndent(accountState.MarginSummary, "  ", "  ")
@@ -107,34 +111,48 @@ func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
 	// 需要返回"不包含未实现盈亏的钱包余额"
 	walletBalanceWithoutUnrealized := accountValue - totalUnrealizedPnl
 
-	result["totalWalletBalance"] = walletBalanceWithoutUnrealized // 钱包余额（不含未实现盈亏）
-	result["availableBalance"] = accountValue - totalMarginUsed   // 可用余额（总净值 - 占用保证金）
-	result["totalUnrealizedProfit"] = totalUnrealizedPnl          // 未实现盈亏
+	result.TotalWalletBalance = walletBalanceWithoutUnrealized // 钱包余额（不含未实现盈亏）
+	result.AvailableBalance = accountValue - totalMarginUsed   // 可用余额（总净值 - 占用保证金）
+	result.TotalUnrealizedProfit = totalUnrealizedPnl          // 未实现盈亏
+	// Hyperliquid 以USDC作为唯一保证金资产
+	result.Assets = map[string]AssetBalance{
+		"USDC": {
+			Asset:            "USDC",
+			WalletBalance:    result.TotalWalletBalance,
+			AvailableBalance: result.AvailableBalance,
+			UnrealizedProfit: result.TotalUnrealizedProfit,
+		},
+	}
 
 	log.Printf("✓ Hyperliquid 账户: 总净值=%.2f (钱包%.2f+未实现%.2f), 可用=%.2f, 保证金占用=%.2f",
 		accountValue,
 		walletBalanceWithoutUnrealized,
 		totalUnrealizedPnl,
-		result["availableBalance"],
+		result.AvailableBalance,
 		totalMarginUsed)
 
 	return result, nil
 }
 
 // GetAccountTrades 获取账户历史成交（Hyperliquid暂未实现）
-func (t *HyperliquidTrader) GetAccountTrades(symbol string, limit int) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil // 暂不支持
+func (t *HyperliquidTrader) GetAccountTrades(symbol string, limit int) ([]Fill, error) {
+	return []Fill{}, nil // 暂不支持
+}
+
+// GetTransfers 获取资金划转记录（Hyperliquid暂未实现）
+func (t *HyperliquidTrader) GetTransfers(startTime int64) ([]Transfer, error) {
+	return []Transfer{}, nil // 暂不支持
 }
 
 // GetPositions 获取所有持仓
-func (t *HyperliquidTrader) GetPositions() ([]map[string]interface{}, error) {
+func (t *HyperliquidTrader) GetPositions() ([]Position, error) {
 	// 获取账户状态
 	accountState, err := t.exchange.Info().UserState(t.ctx, t.walletAddr)
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
 
-	var result []map[string]interface{}
+	var result []Position
 
 	// 遍历所有持仓
 	for _, assetPos := range accountState.AssetPositions {
@@ -147,19 +165,16 @@ func (t *HyperliquidTrader) GetPositions() ([]map[string]interface{}, error) {
 			continue // 跳过无持仓的
 		}
 
-		posMap := make(map[string]interface{})
-
 		// 标准化symbol格式（Hyperliquid使用如"BTC"，我们转换为"BTCUSDT"）
-		symbol := position.Coin + "USDT"
-		posMap["symbol"] = symbol
+		pos := Position{Symbol: position.Coin + "USDT"}
 
 		// 持仓数量和方向
 		if posAmt > 0 {
-			posMap["side"] = "long"
-			posMap["positionAmt"] = posAmt
+			pos.Side = "long"
+			pos.Quantity = posAmt
 		} else {
-			posMap["side"] = "short"
-			posMap["positionAmt"] = -posAmt // 转为正数
+			pos.Side = "short"
+			pos.Quantity = -posAmt // 转为正数
 		}
 
 		// 价格信息（EntryPx和LiquidationPx是指针类型）
@@ -180,42 +195,60 @@ func (t *HyperliquidTrader) GetPositions() ([]map[string]interface{}, error) {
 			markPrice = positionValue / absFloat(posAmt)
 		}
 
-		posMap["entryPrice"] = entryPrice
-		posMap["markPrice"] = markPrice
-		posMap["unRealizedProfit"] = unrealizedPnl
-		posMap["leverage"] = float64(position.Leverage.Value)
-		posMap["liquidationPrice"] = liquidationPx
+		pos.EntryPrice = entryPrice
+		pos.MarkPrice = markPrice
+		pos.UnrealizedProfit = unrealizedPnl
+		pos.Leverage = int(position.Leverage.Value)
+		pos.LiquidationPrice = liquidationPx
+		pos.MarginMode = normalizeMarginMode(position.Leverage.Type)
+		if pos.MarginMode == decision.MarginModeIsolated {
+			pos.IsolatedMargin, _ = strconv.ParseFloat(position.MarginUsed, 64)
+		}
 
-		result = append(result, posMap)
+		result = append(result, pos)
 	}
 
 	return result, nil
 }
 
-// SetLeverage 设置杠杆
-func (t *HyperliquidTrader) SetLeverage(symbol string, leverage int) error {
+// SetLeverage 设置杠杆，返回交易所确认的实际杠杆
+func (t *HyperliquidTrader) SetLeverage(symbol string, leverage int) (int, error) {
 	// Hyperliquid symbol格式（去掉USDT后缀）
 	coin := convertSymbolToHyperliquid(symbol)
 
 	// 调用UpdateLeverage (leverage int, name string, isCross bool)
-	_, err := t.exchange.UpdateLeverage(t.ctx, leverage, coin, false) // false = 逐仓模式
+	state, err := t.exchange.UpdateLeverage(t.ctx, leverage, coin, false) // false = 逐仓模式
 	if err != nil {
-		return fmt.Errorf("设置杠杆失败: %w", err)
+		return 0, fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
-	return nil
+	// 返回中带有该币种持仓时以持仓杠杆为准（超过最大杠杆的请求会被交易所拒绝）
+	effective := leverage
+	if state != nil {
+		for _, ap := range state.AssetPositions {
+			if ap.Position.Coin == coin && ap.Position.Leverage.Value > 0 {
+				effective = ap.Position.Leverage.Value
+				break
+			}
+		}
+	}
+	if effective != leverage {
+		log.Printf("  ⚠ %s 请求杠杆 %dx，交易所实际生效 %dx", symbol, leverage, effective)
+	} else {
+		log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	}
+	return effective, nil
 }
 
 // OpenLong 开多仓
-func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage int) (*Order, error) {
 	// 先取消该币种的所有委托单
 	if err := t.CancelAllOrders(symbol); err != nil {
 		log.Printf("  ⚠ 取消旧委托单失败: %v", err)
 	}
 
 	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if _, err := t.SetLeverage(symbol, leverage); err != nil {
 		return nil, err
 	}
 
@@ -250,30 +283,27 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 		ReduceOnly: false,
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
 	if err != nil {
 		return nil, fmt.Errorf("开多仓失败: %w", err)
 	}
 
 	log.Printf("✓ 开多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
 
-	result := make(map[string]interface{})
-	result["orderId"] = 0 // Hyperliquid没有返回order ID
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
+	result := buildOrderResult(symbol, orderStatus)
 
 	return result, nil
 }
 
 // OpenShort 开空仓
-func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage int) (*Order, error) {
 	// 先取消该币种的所有委托单
 	if err := t.CancelAllOrders(symbol); err != nil {
 		log.Printf("  ⚠ 取消旧委托单失败: %v", err)
 	}
 
 	// 设置杠杆
-	if err := t.SetLeverage(symbol, leverage); err != nil {
+	if _, err := t.SetLeverage(symbol, leverage); err != nil {
 		return nil, err
 	}
 
@@ -308,23 +338,20 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 		ReduceOnly: false,
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
 	if err != nil {
 		return nil, fmt.Errorf("开空仓失败: %w", err)
 	}
 
 	log.Printf("✓ 开空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
 
-	result := make(map[string]interface{})
-	result["orderId"] = 0
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
+	result := buildOrderResult(symbol, orderStatus)
 
 	return result, nil
 }
 
 // CloseLong 平多仓
-func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (*Order, error) {
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -333,8 +360,8 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 		}
 
 		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
+			if pos.Symbol == symbol && pos.Side == "long" {
+				quantity = pos.Quantity
 				break
 			}
 		}
@@ -375,9 +402,12 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 		ReduceOnly: true, // 只平仓，不开新仓
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
+	if err == nil {
+		err = orderStatusError(orderStatus)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("平多仓失败: %w", err)
+		return nil, describeOrderError("平多仓", err)
 	}
 
 	log.Printf("✓ 平多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
@@ -387,16 +417,13 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 		log.Printf("  ⚠ 取消挂单失败: %v", err)
 	}
 
-	result := make(map[string]interface{})
-	result["orderId"] = 0
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
+	result := buildOrderResult(symbol, orderStatus)
 
 	return result, nil
 }
 
 // CloseShort 平空仓
-func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (*Order, error) {
 	// 如果数量为0，获取当前持仓数量
 	if quantity == 0 {
 		positions, err := t.GetPositions()
@@ -405,8 +432,8 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 		}
 
 		for _, pos := range positions {
-			if pos["symbol"] == symbol && pos["side"] == "short" {
-				quantity = pos["positionAmt"].(float64)
+			if pos.Symbol == symbol && pos.Side == "short" {
+				quantity = pos.Quantity
 				break
 			}
 		}
@@ -447,9 +474,12 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 		ReduceOnly: true,
 	}
 
-	_, err = t.exchange.Order(t.ctx, order, nil)
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
+	if err == nil {
+		err = orderStatusError(orderStatus)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("平空仓失败: %w", err)
+		return nil, describeOrderError("平空仓", err)
 	}
 
 	log.Printf("✓ 平空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
@@ -459,14 +489,80 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 		log.Printf("  ⚠ 取消挂单失败: %v", err)
 	}
 
-	result := make(map[string]interface{})
-	result["orderId"] = 0
-	result["symbol"] = symbol
-	result["status"] = "FILLED"
+	result := buildOrderResult(symbol, orderStatus)
 
 	return result, nil
 }
 
+// buildOrderResult 根据IOC订单的返回状态构建订单结果（IOC单提交后即为终态）
+func buildOrderResult(symbol string, status hyperliquid.OrderStatus) *Order {
+	result := &Order{Symbol: symbol, Status: "FILLED"}
+
+	switch {
+	case status.Filled != nil:
+		result.OrderID = int64(status.Filled.Oid)
+		result.ExecutedQty, _ = strconv.ParseFloat(status.Filled.TotalSz, 64)
+		result.AvgPrice, _ = strconv.ParseFloat(status.Filled.AvgPx, 64)
+		result.HasFill = true
+	case status.Resting != nil:
+		// IOC单理论上不会挂单，出现时交给轮询确认
+		result.OrderID = status.Resting.Oid
+		result.Status = "NEW"
+	case status.Error != nil:
+		// 未成交即被取消（如价格偏离过大）
+		result.Status = "CANCELED"
+		result.HasFill = true
+	}
+
+	return result
+}
+
+// orderStatusError 提取订单返回中的错误（Hyperliquid拒单时接口本身不返回error）
+func orderStatusError(status hyperliquid.OrderStatus) error {
+	if status.Error != nil {
+		return fmt.Errorf("%s", *status.Error)
+	}
+	return nil
+}
+
+// GetOrderStatus 查询订单状态
+func (t *HyperliquidTrader) GetOrderStatus(symbol string, orderID int64) (*Order, error) {
+	resp, err := t.exchange.Info().QueryOrderByOid(t.ctx, t.walletAddr, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单状态失败: %w", err)
+	}
+
+	origQty, _ := strconv.ParseFloat(resp.Order.Order.OrigSz, 64)
+	remainingQty, _ := strconv.ParseFloat(resp.Order.Order.Sz, 64)
+	limitPrice, _ := strconv.ParseFloat(resp.Order.Order.LimitPx, 64)
+
+	status := "NEW"
+	switch resp.Order.Status {
+	case hyperliquid.OrderStatusValueFilled:
+		status = "FILLED"
+	case hyperliquid.OrderStatusValueOpen:
+		if remainingQty < origQty {
+			status = "PARTIALLY_FILLED"
+		}
+	case hyperliquid.OrderStatusValueRejected:
+		status = "REJECTED"
+	default:
+		if resp.Order.Status != "" {
+			status = "CANCELED"
+		}
+	}
+
+	return &Order{
+		OrderID:     orderID,
+		Symbol:      symbol,
+		Status:      status,
+		OrigQty:     origQty,
+		ExecutedQty: origQty - remainingQty,
+		AvgPrice:    limitPrice, // 订单查询接口不返回成交均价，使用限价近似
+		Type:        resp.Order.Order.OrderType,
+	}, nil
+}
+
 // CancelAllOrders 取消该币种的所有挂单
 func (t *HyperliquidTrader) CancelAllOrders(symbol string) error {
 	coin := convertSymbolToHyperliquid(symbol)
@@ -491,6 +587,59 @@ func (t *HyperliquidTrader) CancelAllOrders(symbol string) error {
 	return nil
 }
 
+// CancelOrder 取消指定挂单
+func (t *HyperliquidTrader) CancelOrder(symbol string, orderID int64) error {
+	coin := convertSymbolToHyperliquid(symbol)
+
+	resp, err := t.exchange.Cancel(t.ctx, coin, orderID)
+	if err == nil && resp != nil && !resp.Ok {
+		err = fmt.Errorf("%s", resp.Err)
+	}
+	if err != nil {
+		return fmt.Errorf("取消订单失败 (oid=%d): %w", orderID, err)
+	}
+
+	log.Printf("  ✓ 已取消 %s 的挂单 (oid=%d)", symbol, orderID)
+	return nil
+}
+
+// GetOpenOrders 获取该币种未成交的挂单（frontendOpenOrders 包含触发价和止盈止损类型）
+func (t *HyperliquidTrader) GetOpenOrders(symbol string) ([]OpenOrder, error) {
+	coin := convertSymbolToHyperliquid(symbol)
+
+	openOrders, err := t.exchange.Info().FrontendOpenOrders(t.ctx, t.walletAddr)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	var result []OpenOrder
+	for _, o := range openOrders {
+		if o.Coin != coin {
+			continue
+		}
+		side := "BUY"
+		if o.Side == hyperliquid.OrderSideAsk {
+			side = "SELL"
+		}
+		order := OpenOrder{
+			OrderID:      o.Oid,
+			Symbol:       symbol,
+			Type:         openOrderType(o.OrderType),
+			Side:         side,
+			PositionSide: "BOTH",
+			Quantity:     o.Sz,
+			ReduceOnly:   o.ReduceOnly,
+		}
+		if o.IsTrigger {
+			order.TriggerPrice = o.TriggerPx
+		} else {
+			order.Price = o.LimitPx
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
 // GetMarketPrice 获取市场价格
 func (t *HyperliquidTrader) GetMarketPrice(symbol string) (float64, error) {
 	coin := convertSymbolToHyperliquid(symbol)
@@ -513,6 +662,48 @@ func (t *HyperliquidTrader) GetMarketPrice(symbol string) (float64, error) {
 	return 0, fmt.Errorf("未找到 %s 的价格", symbol)
 }
 
+// BookSnapshot 获取Hyperliquid盘口前5档（Info接口不提供最近成交，快照只包含盘口）
+func (t *HyperliquidTrader) BookSnapshot(symbol string) (*market.BookSnapshot, error) {
+	coin := convertSymbolToHyperliquid(symbol)
+
+	book, err := t.exchange.Info().L2Snapshot(t.ctx, coin)
+	if err != nil {
+		return nil, fmt.Errorf("获取盘口失败: %w", err)
+	}
+	if len(book.Levels) < 2 {
+		return nil, fmt.Errorf("%s 盘口数据不完整", symbol)
+	}
+	toLevels := func(levels []hyperliquid.Level) []market.BookLevel {
+		out := make([]market.BookLevel, 0, len(levels))
+		for _, l := range levels {
+			out = append(out, market.BookLevel{Price: l.Px, Qty: l.Sz})
+		}
+		return out
+	}
+	return market.NewBookSnapshot(market.ProviderHyperliquid, toLevels(book.Levels[0]), toLevels(book.Levels[1])), nil
+}
+
+// GetFundingRate 获取当前资金费率（Hyperliquid每小时结算）
+func (t *HyperliquidTrader) GetFundingRate(symbol string) (FundingRate, error) {
+	coin := convertSymbolToHyperliquid(symbol)
+
+	metaAndCtxs, err := t.exchange.Info().MetaAndAssetCtxs(t.ctx)
+	if err != nil {
+		return FundingRate{}, fmt.Errorf("获取资金费率失败: %w", err)
+	}
+	for i, asset := range metaAndCtxs.Universe {
+		if asset.Name != coin || i >= len(metaAndCtxs.Ctxs) {
+			continue
+		}
+		rate, err := strconv.ParseFloat(metaAndCtxs.Ctxs[i].Funding, 64)
+		if err != nil {
+			return FundingRate{}, fmt.Errorf("资金费率格式错误: %w", err)
+		}
+		return FundingRate{Rate: rate, IntervalHours: 1}, nil
+	}
+	return FundingRate{}, fmt.Errorf("未找到 %s 的资金费率", symbol)
+}
+
 // SetStopLoss 设置止损单
 func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
 	coin := convertSymbolToHyperliquid(symbol)
@@ -541,9 +732,12 @@ func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quan
 		ReduceOnly: true,
 	}
 
-	_, err := t.exchange.Order(t.ctx, order, nil)
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
+	if err == nil {
+		err = orderStatusError(orderStatus)
+	}
 	if err != nil {
-		return fmt.Errorf("设置止损失败: %w", err)
+		return describeOrderError("设置止损", err)
 	}
 
 	log.Printf("  止损价设置: %.4f", roundedStopPrice)
@@ -578,23 +772,82 @@ func (t *HyperliquidTrader) SetTakeProfit(symbol string, positionSide string, qu
 		ReduceOnly: true,
 	}
 
-	_, err := t.exchange.Order(t.ctx, order, nil)
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
+	if err == nil {
+		err = orderStatusError(orderStatus)
+	}
 	if err != nil {
-		return fmt.Errorf("设置止盈失败: %w", err)
+		return describeOrderError("设置止盈", err)
 	}
 
 	log.Printf("  止盈价设置: %.4f", roundedTakeProfitPrice)
 	return nil
 }
 
+// SetTriggerLimitExit 挂限价trigger单（触发后按限价只减仓，市价trigger单未生效时的替代）
+func (t *HyperliquidTrader) SetTriggerLimitExit(symbol, positionSide, kind string, quantity, triggerPrice, limitPrice float64) error {
+	coin := convertSymbolToHyperliquid(symbol)
+	isBuy := positionSide == "SHORT"
+	tpsl, action := hyperliquid.StopLoss, "设置限价止损"
+	if kind == OpenOrderTakeProfit {
+		tpsl, action = hyperliquid.TakeProfit, "设置限价止盈"
+	}
+
+	roundedTrigger := t.roundPriceToSigfigs(triggerPrice)
+	order := hyperliquid.CreateOrderRequest{
+		Coin:  coin,
+		IsBuy: isBuy,
+		Size:  t.roundToSzDecimals(coin, quantity),
+		Price: t.roundPriceToSigfigs(limitPrice),
+		OrderType: hyperliquid.OrderType{
+			Trigger: &hyperliquid.TriggerOrderType{
+				TriggerPx: roundedTrigger,
+				IsMarket:  false,
+				Tpsl:      tpsl,
+			},
+		},
+		ReduceOnly: true,
+	}
+
+	orderStatus, err := t.exchange.Order(t.ctx, order, nil)
+	if err == nil {
+		err = orderStatusError(orderStatus)
+	}
+	if err != nil {
+		return describeOrderError(action, err)
+	}
+
+	log.Printf("  限价%s设置: 触发%.4f 限价%.4f", exitOrderName(kind), roundedTrigger, order.Price)
+	return nil
+}
+
 // FormatQuantity 格式化数量到正确的精度
 func (t *HyperliquidTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
 	coin := convertSymbolToHyperliquid(symbol)
 	szDecimals := t.getSzDecimals(coin)
 
 	// 使用szDecimals格式化数量
-	formatStr := fmt.Sprintf("%%.%df", szDecimals)
-	return fmt.Sprintf(formatStr, quantity), nil
+	return money.FormatFixed(quantity, szDecimals), nil
+}
+
+// hyperliquidMinOrderValue Hyperliquid单笔订单最小价值(USDC)
+const hyperliquidMinOrderValue = 10.0
+
+// hyperliquidMaxPriceDecimals 永续合约价格的最大小数位（实际上限为该值减去szDecimals）
+const hyperliquidMaxPriceDecimals = 6
+
+// GetSymbolFilters 获取交易对的下单数量规则（步进值由szDecimals决定）
+// 价格最多5位有效数字，且小数位不超过 6-szDecimals（永续合约规则）
+func (t *HyperliquidTrader) GetSymbolFilters(symbol string) (SymbolFilters, error) {
+	szDecimals := t.getSzDecimals(convertSymbolToHyperliquid(symbol))
+	step := math.Pow(10, -float64(szDecimals))
+	return SymbolFilters{
+		StepSize:     step,
+		MinQty:       step,
+		MinNotional:  hyperliquidMinOrderValue,
+		TickSize:     math.Pow(10, -float64(max(hyperliquidMaxPriceDecimals-szDecimals, 0))),
+		PriceSigFigs: 5,
+	}, nil
 }
 
 // getSzDecimals 获取币种的数量精度
@@ -617,16 +870,7 @@ func (t *HyperliquidTrader) getSzDecimals(coin string) int {
 
 // roundToSzDecimals 将数量四舍五入到正确的精度
 func (t *HyperliquidTrader) roundToSzDecimals(coin string, quantity float64) float64 {
-	szDecimals := t.getSzDecimals(coin)
-
-	// 计算倍数（10^szDecimals）
-	multiplier := 1.0
-	for i := 0; i < szDecimals; i++ {
-		multiplier *= 10.0
-	}
-
-	// 四舍五入
-	return float64(int(quantity*multiplier+0.5)) / multiplier
+	return money.RoundToPlaces(quantity, t.getSzDecimals(coin))
 }
 
 // roundPriceToSigfigs 将价格四舍五入到5位有效数字
@@ -0,0 +1,107 @@
+package trader
+
+import (
+	"log"
+	"nofx/analytics"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/market"
+	"time"
+)
+
+// incomeHistorian 支持查询收入历史的交易器（可选能力，目前只有币安驱动实现）
+type incomeHistorian interface {
+	GetIncomeHistory(startTime int64) ([]Income, error)
+}
+
+// incomeSyncConfig 收入历史同步配置
+func incomeSyncConfig() database.IncomeSyncConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetIncomeSyncConfig()
+	}
+	return database.IncomeSyncConfig{Enabled: true, IntervalMinutes: 60}
+}
+
+// syncIncomeHistory 按间隔从交易所同步收入历史（已实现盈亏、资金费、手续费、清算费）
+// 首次同步从最早的决策记录开始，之后从最近一笔收入开始增量同步（同一流水重复拉取时忽略）
+func (at *AutoTrader) syncIncomeHistory(now time.Time) {
+	cfg := incomeSyncConfig()
+	db := at.decisionLogger.GetDB()
+	if !cfg.Enabled || db == nil {
+		return
+	}
+	historian, ok := baseTrader(at.trader).(incomeHistorian)
+	if !ok {
+		return
+	}
+	at.mu.RLock()
+	lastSync := at.lastIncomeSync
+	at.mu.RUnlock()
+	if !lastSync.IsZero() && now.Sub(lastSync) < time.Duration(cfg.IntervalMinutes)*time.Minute {
+		return
+	}
+
+	since, err := db.Income().GetLatestTime()
+	if err != nil {
+		log.Printf("⚠️  [%s] 查询收入历史失败: %v", at.name, err)
+		return
+	}
+	if since.IsZero() {
+		if since, err = db.Decision().GetFirstTimestamp(); err != nil || since.IsZero() {
+			since = at.startTime
+		}
+	}
+
+	incomes, err := historian.GetIncomeHistory(since.UnixMilli())
+	if err != nil {
+		log.Printf("⚠️  [%s] 同步收入历史失败: %v", at.name, err)
+		return
+	}
+	added := 0
+	for _, income := range incomes {
+		// 非稳定币收入（如BNB抵扣手续费）无法按1:1折算，不计入收益表
+		if !market.IsStableAsset(income.Asset) {
+			continue
+		}
+		inserted, err := db.Income().Insert(&models.IncomeRecord{
+			TranID:     income.ID,
+			IncomeType: income.Type,
+			Symbol:     income.Symbol,
+			Asset:      income.Asset,
+			Amount:     income.Amount,
+			Timestamp:  time.UnixMilli(income.Time),
+		})
+		if err != nil {
+			log.Printf("⚠️  [%s] 记录收入历史失败: %v", at.name, err)
+			continue
+		}
+		if inserted {
+			added++
+		}
+	}
+
+	at.mu.Lock()
+	at.lastIncomeSync = now
+	at.mu.Unlock()
+	if added > 0 {
+		log.Printf("🧾 [%s] 同步收入历史: 新增 %d 条", at.name, added)
+	}
+}
+
+// GetIncomeStatement 最近N个月（含当月，按UTC自然月）的收益表，并与系统记录的已实现盈亏对账
+func (at *AutoTrader) GetIncomeStatement(months int, now time.Time) (*analytics.IncomeStatement, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return analytics.BuildIncomeStatement(nil, months, now), nil
+	}
+	incomes, err := db.Income().GetSince(analytics.IncomeStatementStart(months, now))
+	if err != nil {
+		return nil, err
+	}
+	statement := analytics.BuildIncomeStatement(incomes, months, now)
+	if err := statement.Reconcile(db.Trade().GetRealizedPnLBetween); err != nil {
+		return nil, err
+	}
+	_, statement.Supported = baseTrader(at.trader).(incomeHistorian)
+	return statement, nil
+}
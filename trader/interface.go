@@ -4,28 +4,31 @@ package trader
 // 支持多个交易平台（币安、Hyperliquid等）
 type Trader interface {
 	// GetBalance 获取账户余额
-	GetBalance() (map[string]interface{}, error)
+	GetBalance() (*Balance, error)
 
 	// GetPositions 获取所有持仓
-	GetPositions() ([]map[string]interface{}, error)
+	GetPositions() ([]Position, error)
 	
 	// GetAccountTrades 获取账户历史成交（最近N条）
-	GetAccountTrades(symbol string, limit int) ([]map[string]interface{}, error)
+	GetAccountTrades(symbol string, limit int) ([]Fill, error)
+
+	// GetTransfers 获取指定时间（毫秒）之后的资金划转记录（入金/出金）
+	GetTransfers(startTime int64) ([]Transfer, error)
 
 	// OpenLong 开多仓
-	OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
+	OpenLong(symbol string, quantity float64, leverage int) (*Order, error)
 
 	// OpenShort 开空仓
-	OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
+	OpenShort(symbol string, quantity float64, leverage int) (*Order, error)
 
 	// CloseLong 平多仓（quantity=0表示全部平仓）
-	CloseLong(symbol string, quantity float64) (map[string]interface{}, error)
+	CloseLong(symbol string, quantity float64) (*Order, error)
 
 	// CloseShort 平空仓（quantity=0表示全部平仓）
-	CloseShort(symbol string, quantity float64) (map[string]interface{}, error)
+	CloseShort(symbol string, quantity float64) (*Order, error)
 
-	// SetLeverage 设置杠杆
-	SetLeverage(symbol string, leverage int) error
+	// SetLeverage 设置杠杆，返回交易所确认的实际杠杆（可能因杠杆分层上限与请求值不同）
+	SetLeverage(symbol string, leverage int) (int, error)
 
 	// GetMarketPrice 获取市场价格
 	GetMarketPrice(symbol string) (float64, error)
@@ -36,9 +39,38 @@ type Trader interface {
 	// SetTakeProfit 设置止盈单
 	SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error
 
+	// GetOrderStatus 查询订单状态
+	GetOrderStatus(symbol string, orderID int64) (*Order, error)
+
 	// CancelAllOrders 取消该币种的所有挂单
 	CancelAllOrders(symbol string) error
 
+	// CancelOrder 取消指定挂单
+	CancelOrder(symbol string, orderID int64) error
+
+	// GetOpenOrders 获取该币种未成交的挂单（含止损止盈条件单）
+	GetOpenOrders(symbol string) ([]OpenOrder, error)
+
 	// FormatQuantity 格式化数量到正确的精度
 	FormatQuantity(symbol string, quantity float64) (string, error)
+
+	// GetSymbolFilters 获取交易对的下单数量规则（步进值、最小数量、最小名义价值）
+	GetSymbolFilters(symbol string) (SymbolFilters, error)
+
+	// GetFundingRate 获取当前资金费率
+	GetFundingRate(symbol string) (FundingRate, error)
+}
+
+// FundingRate 资金费率（各交易所结算周期不同，比较时统一换算为每8小时）
+type FundingRate struct {
+	Rate          float64 // 每个结算周期的费率（0.0001 = 0.01%）
+	IntervalHours float64 // 结算周期（小时）
+}
+
+// Per8hPct 换算为每8小时费率（%）
+func (f FundingRate) Per8hPct() float64 {
+	if f.IntervalHours <= 0 {
+		return f.Rate * 100
+	}
+	return f.Rate * 100 * 8 / f.IntervalHours
 }
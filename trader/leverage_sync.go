@@ -0,0 +1,58 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// syncLeverage 开仓前显式设置币种杠杆并以交易所确认的杠杆为准
+// 实际杠杆与AI请求不同时（手动修改过或受杠杆分层上限限制）更新决策杠杆并记录调整，后续保证金计算都使用实际杠杆
+func (at *AutoTrader) syncLeverage(d *decision.Decision, actionRecord *logger.DecisionAction) error {
+	effective, err := at.trader.SetLeverage(d.Symbol, d.Leverage)
+	if err != nil {
+		return fmt.Errorf("❌ %s 同步杠杆失败: %w", d.Symbol, err)
+	}
+	if effective <= 0 {
+		effective = d.Leverage
+	}
+
+	if effective != d.Leverage {
+		note := fmt.Sprintf("杠杆 %dx → %dx（交易所实际生效）", d.Leverage, effective)
+		log.Printf("  ⚖️  %s %s", d.Symbol, note)
+		actionRecord.Adjustment = joinAdjustment(actionRecord.Adjustment, note)
+		d.Leverage = effective
+	}
+	actionRecord.Leverage = effective
+
+	at.mu.Lock()
+	if at.symbolLeverage == nil {
+		at.symbolLeverage = make(map[string]int)
+	}
+	at.symbolLeverage[d.Symbol] = effective
+	at.mu.Unlock()
+	return nil
+}
+
+// positionLeverage 持仓杠杆：交易所未返回时使用本进程开仓时确认的杠杆，都没有时才使用默认值
+func (at *AutoTrader) positionLeverage(pos Position) int {
+	if pos.Leverage > 0 {
+		return pos.Leverage
+	}
+	at.mu.RLock()
+	leverage := at.symbolLeverage[pos.Symbol]
+	at.mu.RUnlock()
+	if leverage > 0 {
+		return leverage
+	}
+	return pos.EffectiveLeverage()
+}
+
+// joinAdjustment 合并同一动作的多条调整说明
+func joinAdjustment(existing, note string) string {
+	if existing == "" {
+		return note
+	}
+	return existing + "；" + note
+}
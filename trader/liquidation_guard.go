@@ -0,0 +1,16 @@
+package trader
+
+import (
+	"nofx/database"
+	"nofx/decision"
+)
+
+// liquidationGuard 止损与强平价距离校验参数（liq_guard_*，未启用时返回nil）
+// 校验在决策验证阶段执行，预览、干跑和实盘使用同一结果
+func liquidationGuard() *decision.LiquidationGuard {
+	cfg := database.LoadRuntimeConfig().GetLiquidationGuardConfig()
+	if !cfg.Enabled {
+		return nil
+	}
+	return &decision.LiquidationGuard{BufferPct: cfg.BufferPct, Reject: cfg.Mode == "reject"}
+}
@@ -0,0 +1,40 @@
+package trader
+
+import (
+	"nofx/database"
+	"nofx/decision"
+)
+
+// liquidityFilter 从运行时配置构建候选币种流动性过滤规则（配置不可用时返回nil，使用默认的持仓价值过滤）
+func liquidityFilter() *decision.LiquidityFilter {
+	rc := database.GetGlobalConfig()
+	if rc == nil {
+		return nil
+	}
+	cfg := rc.GetLiquidityFilterConfig()
+	filter := &decision.LiquidityFilter{
+		Mode:    cfg.Mode,
+		Default: liquidityThresholds(cfg.Default),
+	}
+	switch filter.Mode {
+	case decision.LiquidityModeOI, decision.LiquidityModeVolume, decision.LiquidityModeBoth:
+	default:
+		filter.Mode = decision.LiquidityModeOI
+	}
+	for _, g := range cfg.Groups {
+		filter.Groups = append(filter.Groups, decision.LiquidityGroup{
+			Name:       g.Name,
+			Symbols:    g.Symbols,
+			Thresholds: liquidityThresholds(g.LiquidityThresholds),
+		})
+	}
+	return filter
+}
+
+func liquidityThresholds(t database.LiquidityThresholds) decision.LiquidityThresholds {
+	return decision.LiquidityThresholds{
+		MinOIValueM:     t.MinOIValueM,
+		MinQuoteVolumeM: t.MinQuoteVolumeM,
+		MaxAvgSpreadBps: t.MaxAvgSpreadBps,
+	}
+}
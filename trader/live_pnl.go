@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"math"
+	"nofx/market"
+)
+
+// 标记价格来源
+const (
+	markPriceFromStream   = "stream"   // 标记价格数据流（实时）
+	markPriceFromExchange = "exchange" // 交易所持仓接口（可能是缓存的REST数据）
+)
+
+// liveMark 用实时标记价格重新计算持仓的标记价格和未实现盈亏
+// 只有币安持仓使用标记价格数据流（数据流来自币安，其他交易所的标记价格不同），数据流不可用时沿用持仓接口的数据
+func (at *AutoTrader) liveMark(pos Position) (markPrice, unrealizedPnL float64, source string) {
+	if at.exchange == "binance" && pos.EntryPrice > 0 {
+		if mp, ok := market.GetMarkPrice(pos.Symbol); ok {
+			return mp.Price, tradePnL(pos.Symbol, pos.Side, pos.EntryPrice, mp.Price, pos.Quantity), markPriceFromStream
+		}
+	}
+	return pos.MarkPrice, pos.UnrealizedProfit, markPriceFromExchange
+}
+
+// liquidationDistancePct 标记价格到强平价的距离（占标记价格%，没有强平价时为0）
+func liquidationDistancePct(markPrice, liquidationPrice float64) float64 {
+	if markPrice <= 0 || liquidationPrice <= 0 {
+		return 0
+	}
+	return math.Abs(markPrice-liquidationPrice) / markPrice * 100
+}
@@ -0,0 +1,164 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/i18n"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenancePeriod 维护窗口的一次具体时段
+type MaintenancePeriod struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// maintenanceWeekdays 维护窗口配置中的星期写法
+var maintenanceWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseClock 解析 HH:MM
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, 0, fmt.Errorf("时间格式错误(应为HH:MM): %s", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// windowAppliesOn 窗口是否在指定星期生效
+func windowAppliesOn(w database.MaintenanceWindow, day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(d) > 3 {
+			d = d[:3] // 兼容 monday/tuesday 等全称
+		}
+		if wd, ok := maintenanceWeekdays[d]; ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenancePeriods 计算trader在 [now, now+lookahead] 内的维护时段（包含正在进行的），按开始时间排序
+func maintenancePeriods(windows []database.MaintenanceWindow, traderID string, now time.Time, lookahead time.Duration) []MaintenancePeriod {
+	var periods []MaintenancePeriod
+	horizon := now.Add(lookahead)
+	for _, w := range windows {
+		if w.TraderID != "" && w.TraderID != traderID {
+			continue
+		}
+		loc := time.UTC
+		if w.Timezone != "" {
+			l, err := time.LoadLocation(w.Timezone)
+			if err != nil {
+				log.Printf("⚠️  维护窗口 %s 时区无效(%s): %v", w.Name, w.Timezone, err)
+				continue
+			}
+			loc = l
+		}
+		sh, sm, err := parseClock(w.Start)
+		if err != nil {
+			log.Printf("⚠️  维护窗口 %s 开始%v", w.Name, err)
+			continue
+		}
+		eh, em, err := parseClock(w.End)
+		if err != nil {
+			log.Printf("⚠️  维护窗口 %s 结束%v", w.Name, err)
+			continue
+		}
+
+		// 从前一天开始检查（跨天窗口可能在今天仍未结束）
+		local := now.In(loc)
+		days := int(lookahead.Hours()/24) + 1
+		for offset := -1; offset <= days; offset++ {
+			start := time.Date(local.Year(), local.Month(), local.Day()+offset, sh, sm, 0, 0, loc)
+			if !windowAppliesOn(w, start.Weekday()) {
+				continue
+			}
+			end := time.Date(start.Year(), start.Month(), start.Day(), eh, em, 0, 0, loc)
+			if !end.After(start) {
+				end = end.AddDate(0, 0, 1)
+			}
+			if end.After(now) && start.Before(horizon) {
+				periods = append(periods, MaintenancePeriod{Name: w.Name, Start: start, End: end})
+			}
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+// upcomingMaintenance 当前trader未来一段时间内的维护时段
+func (at *AutoTrader) upcomingMaintenance(now time.Time) []MaintenancePeriod {
+	cfg := database.LoadRuntimeConfig().GetMaintenanceConfig()
+	if len(cfg.Windows) == 0 {
+		return nil
+	}
+	return maintenancePeriods(cfg.Windows, at.id, now, time.Duration(cfg.LookaheadHours)*time.Hour)
+}
+
+// CheckMaintenanceWindow 根据维护窗口自动暂停/恢复trader（由TraderManager定时调用）
+// 维护暂停与手动暂停相互独立：窗口结束后只解除维护暂停，不会恢复被手动暂停的trader
+func (at *AutoTrader) CheckMaintenanceWindow(now time.Time) {
+	active := ""
+	for _, p := range at.upcomingMaintenance(now) {
+		if !now.Before(p.Start) && now.Before(p.End) {
+			active = fmt.Sprintf("%s（至 %s）", p.Name, p.End.Format("2006-01-02 15:04 MST"))
+			break
+		}
+	}
+
+	at.mu.Lock()
+	previous := at.maintenanceWindow
+	at.maintenanceWindow = active
+	at.mu.Unlock()
+
+	if active != "" && previous == "" {
+		log.Printf("[%s] 🛠  进入维护窗口 %s，自动暂停", at.name, active)
+	} else if active == "" && previous != "" {
+		log.Printf("[%s] ▶️  维护窗口 %s 已结束，自动恢复", at.name, previous)
+	}
+}
+
+// checkMaintenanceOpen 维护窗口开始前的禁止开仓期内拒绝开新仓（避免临近暂停时留下无人看管的新仓位）
+func (at *AutoTrader) checkMaintenanceOpen(now time.Time) error {
+	cfg := database.LoadRuntimeConfig().GetMaintenanceConfig()
+	if len(cfg.Windows) == 0 || cfg.NoOpenMinutes <= 0 {
+		return nil
+	}
+	guard := time.Duration(cfg.NoOpenMinutes) * time.Minute
+	if periods := maintenancePeriods(cfg.Windows, at.id, now, guard); len(periods) > 0 {
+		p := periods[0]
+		return fmt.Errorf("❌ 维护窗口 %s 将于 %s 开始（%d分钟内禁止开新仓）", p.Name, p.Start.Format("2006-01-02 15:04 MST"), cfg.NoOpenMinutes)
+	}
+	return nil
+}
+
+// maintenanceNotices 维护窗口提示（写入prompt）
+func (at *AutoTrader) maintenanceNotices(now time.Time) []string {
+	periods := at.upcomingMaintenance(now)
+	if len(periods) == 0 {
+		return nil
+	}
+	noOpen := database.LoadRuntimeConfig().GetMaintenanceConfig().NoOpenMinutes
+	lang := at.PromptLanguage()
+	notices := make([]string, 0, len(periods)+1)
+	for _, p := range periods {
+		notices = append(notices, i18n.T(lang, "user.maintenance_window", p.Name,
+			p.Start.Format("2006-01-02 15:04 MST"), p.End.Format("2006-01-02 15:04 MST"), p.Start.Sub(now).Hours()))
+	}
+	if noOpen > 0 {
+		notices = append(notices, i18n.T(lang, "user.maintenance_rule", noOpen))
+	}
+	return notices
+}
@@ -0,0 +1,60 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"strings"
+)
+
+// normalizeMarginMode 把交易所返回的保证金模式归一为 cross / isolated（未返回时为空）
+func normalizeMarginMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "isolated":
+		return decision.MarginModeIsolated
+	case "cross", "crossed":
+		return decision.MarginModeCross
+	}
+	return ""
+}
+
+// marginPositionInfo 账户强平估算使用的持仓信息
+func marginPositionInfo(pos Position, leverage int) decision.PositionInfo {
+	return decision.PositionInfo{
+		Symbol:           pos.Symbol,
+		Side:             pos.Side,
+		EntryPrice:       pos.EntryPrice,
+		MarkPrice:        pos.MarkPrice,
+		Quantity:         pos.Quantity,
+		Leverage:         leverage,
+		UnrealizedPnL:    pos.UnrealizedProfit,
+		LiquidationPrice: pos.LiquidationPrice,
+		MarginUsed:       pos.Quantity * pos.MarkPrice / float64(leverage),
+		MarginMode:       pos.MarginMode,
+		IsolatedMargin:   pos.IsolatedMargin,
+	}
+}
+
+// GetRiskMetrics 按交易所实时余额和持仓计算风险指标（含账户级强平估算，不获取行情，波动率风险为0）
+func (at *AutoTrader) GetRiskMetrics() (*decision.RiskMetrics, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取余额失败: %w", err)
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	infos := make([]decision.PositionInfo, 0, len(positions))
+	for _, pos := range positions {
+		infos = append(infos, marginPositionInfo(pos, at.positionLeverage(pos)))
+	}
+	ctx := &decision.Context{
+		Account:        decision.AccountInfo{TotalEquity: balance.TotalEquity()},
+		Positions:      infos,
+		DecisionLogger: at.decisionLogger,
+		RiskGroups:     riskGroups(),
+	}
+	metrics := decision.CalculateRiskMetrics(ctx)
+	return &metrics, nil
+}
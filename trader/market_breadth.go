@@ -0,0 +1,28 @@
+package trader
+
+import (
+	"log"
+	"nofx/database/models"
+	"nofx/decision"
+	"time"
+)
+
+// saveMarketBreadth 保存本周期的市场广度（用于计算BTC市值占比趋势和事后分析）
+func (at *AutoTrader) saveMarketBreadth(breadth *decision.MarketBreadth) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	err := db.MarketBreadth().Insert(&models.MarketBreadth{
+		Timestamp:            time.Now(),
+		CycleNumber:          at.callCount,
+		BTCDominance:         breadth.BTCDominance,
+		AboveEMA50Pct:        breadth.AboveEMA50Pct,
+		SymbolCount:          breadth.SymbolCount,
+		AggregateOIChangePct: breadth.AggregateOIChangePct,
+		OISymbolCount:        breadth.OISymbolCount,
+	})
+	if err != nil {
+		log.Printf("⚠️  保存市场广度失败: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/database"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// syncMarketSettings 把运行时配置同步到market包（数据质量检查、持仓量历史、爆仓监控、K线持久化、增量指标、共享缓存、新闻，支持热更新）
+func syncMarketSettings() {
+	rc := database.GetGlobalConfig()
+	if rc == nil {
+		return
+	}
+
+	quality := rc.GetDataQualityConfig()
+	market.QualitySettings = market.DataQualitySettings{
+		OutlierSigma:      quality.OutlierSigma,
+		MaxMissingCandles: quality.MaxMissingCandles,
+		StaleIntervals:    quality.StaleIntervals,
+		ExcludeDegraded:   quality.ExcludeDegraded,
+	}
+
+	oiHistory := rc.GetOIHistoryConfig()
+	market.OIHistory = market.OIHistorySettings{
+		Period:      oiHistory.Period,
+		SeriesShown: oiHistory.SeriesShown,
+	}
+
+	liquidation := rc.GetLiquidationConfig()
+	market.LiquidationCascade = market.LiquidationSettings{
+		CascadeUSD:       liquidation.CascadeUSD,
+		SymbolCascadeUSD: liquidation.SymbolCascadeUSD,
+		PauseEntries:     liquidation.PauseEntries,
+	}
+
+	orderFlow := rc.GetOrderFlowConfig()
+	market.OrderFlow = market.OrderFlowSettings{
+		Enabled:      orderFlow.Enabled,
+		ProximityPct: orderFlow.ProximityPct,
+	}
+
+	klineStore := rc.GetKlineStoreConfig()
+	market.KlineStorage = market.KlineStorageSettings{
+		ServeFromDisk: klineStore.ServeFromDisk,
+		MaxAge:        time.Duration(klineStore.MaxAgeSeconds) * time.Second,
+	}
+
+	indicators := rc.GetIndicatorCacheConfig()
+	market.IndicatorCache = market.IndicatorCacheSettings{
+		Incremental: indicators.Incremental,
+		StateTTL:    time.Duration(indicators.StateTTLMinutes) * time.Minute,
+	}
+
+	news := rc.GetNewsConfig()
+	market.News = market.NewsSettings{
+		Enabled:      news.Enabled,
+		Source:       news.Source,
+		URLs:         news.URLs,
+		APIKey:       news.APIKey,
+		MaxHeadlines: news.MaxHeadlines,
+		MaxAge:       time.Duration(news.MaxAgeHours) * time.Hour,
+		CacheTTL:     time.Duration(news.CacheTTLMinutes) * time.Minute,
+	}
+
+	shared := rc.GetSharedStateConfig()
+	market.SharedCache = market.SharedCacheSettings{
+		TTL:                time.Duration(shared.MarketCacheTTLSeconds) * time.Second,
+		RateLimitPerMinute: shared.RateLimitPerMinute,
+		MaxWait:            30 * time.Second,
+	}
+}
+
+// checkDataQuality 开仓前检查行情数据质量（数据降级且配置了排除时拒绝开仓）
+func checkDataQuality(data *market.Data) error {
+	if !market.QualitySettings.ExcludeDegraded || !data.IsDegraded() {
+		return nil
+	}
+	return fmt.Errorf("❌ %s 行情数据质量降级（%s），拒绝开仓", data.Symbol, strings.Join(data.Quality.Issues, "; "))
+}
+
+// checkLiquidationCascade 开仓前检查连环爆仓（全市场或该币种正在连环爆仓且配置了暂停时拒绝开仓）
+func checkLiquidationCascade(symbol string) error {
+	if !market.LiquidationCascade.PauseEntries {
+		return nil
+	}
+	summary := market.GetLiquidationSummary()
+	if summary == nil || !summary.Cascade {
+		return nil
+	}
+	total5m := summary.Long5m + summary.Short5m
+	for _, stats := range summary.CascadeSymbols {
+		if stats.Symbol == symbol {
+			return fmt.Errorf("❌ %s 正在连环爆仓（5分钟 多头%.2fM / 空头%.2fM USDT），暂停新开仓",
+				symbol, stats.Long5m/1e6, stats.Short5m/1e6)
+		}
+	}
+	if threshold := market.LiquidationCascade.CascadeUSD; threshold > 0 && total5m >= threshold {
+		return fmt.Errorf("❌ 全市场连环爆仓（5分钟爆仓 %.2fM USDT ≥ %.2fM），暂停新开仓 %s",
+			total5m/1e6, threshold/1e6, symbol)
+	}
+	return nil
+}
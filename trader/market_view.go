@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/market"
+	"time"
+)
+
+// MarketSnapshotView 某一时刻的行情数据及其在提示词中的格式化片段
+type MarketSnapshotView struct {
+	At     time.Time    `json:"at"`
+	Data   *market.Data `json:"data"`   // 含增强指标（enhanced_indicators）和市场情绪（market_sentiment）
+	Prompt string       `json:"prompt"` // 与AI提示词中相同的格式化片段
+}
+
+// MarketView 引擎对某个币种的行情视图（用于与交易所界面核对AI看到的数据）
+type MarketView struct {
+	Symbol    string              `json:"symbol"`
+	Provider  string              `json:"provider"`
+	Live      *MarketSnapshotView `json:"live"`                 // 实时计算的结果
+	LastCycle *MarketSnapshotView `json:"last_cycle,omitempty"` // 最近一次AI决策实际使用的数据（币种不在本周期候选中时为空）
+	Regime    string              `json:"regime,omitempty"`     // 最近一次检测到的市场状态
+}
+
+// GetMarketView 按引擎的计算方式获取币种行情，并附带最近一次AI决策使用的数据
+func (at *AutoTrader) GetMarketView(symbol string) (*MarketView, error) {
+	symbol = market.Normalize(symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("币种不能为空")
+	}
+	lang := at.PromptLanguage()
+	compact := at.compactMode()
+
+	data, err := market.GetWith(at.marketProvider, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s市场数据失败: %w", symbol, err)
+	}
+	view := &MarketView{
+		Symbol:   symbol,
+		Provider: at.marketProvider.Name(),
+		Live: &MarketSnapshotView{
+			At:     time.Now(),
+			Data:   data,
+			Prompt: market.FormatForPrompt(data, lang, compact),
+		},
+	}
+
+	at.mu.RLock()
+	last, ok := at.lastMarketData[symbol]
+	lastAt := at.lastMarketDataAt
+	view.Regime = at.lastRegime
+	at.mu.RUnlock()
+	if ok && last != nil {
+		view.LastCycle = &MarketSnapshotView{
+			At:     lastAt,
+			Data:   last,
+			Prompt: market.FormatForPrompt(last, lang, compact),
+		}
+	}
+	return view, nil
+}
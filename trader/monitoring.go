@@ -0,0 +1,63 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"nofx/monitoring"
+)
+
+// initPerformanceMonitor 为trader创建性能监控器（需要决策数据库；运行时配置不可用时使用默认阈值）
+func (at *AutoTrader) initPerformanceMonitor() {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		log.Printf("⚠️ [%s] 决策数据库不可用，跳过性能监控", at.name)
+		return
+	}
+	at.monitor = monitoring.NewPerformanceMonitor(at.id, db, at.decisionLogger)
+
+	// 决策记录持续写入失败（如磁盘已满）时发出危险预警，记录暂存在待补写队列中
+	monitor := at.monitor
+	at.decisionLogger.SetFailureAlert(func(failures int, err error) {
+		monitor.RaiseAlert(monitoring.Alert{
+			ID:      fmt.Sprintf("decision_log_%d", time.Now().Unix()),
+			Type:    monitoring.AlertTypeSystem,
+			Level:   monitoring.AlertLevelCritical,
+			Title:   "决策记录写入失败",
+			Message: fmt.Sprintf("连续%d次写入数据库失败，记录已暂存到待补写队列，数据库恢复后自动补写: %v", failures, err),
+		})
+	})
+}
+
+// GetMonitoringMetrics 获取性能监控指标和监控状态
+func (at *AutoTrader) GetMonitoringMetrics() (*monitoring.PerformanceMetrics, map[string]interface{}, error) {
+	if at.monitor == nil {
+		return nil, nil, fmt.Errorf("trader %s 未启用性能监控", at.id)
+	}
+	return at.monitor.GetMetrics(), at.monitor.GetStatus(), nil
+}
+
+// GetMonitoringAlerts 获取性能监控预警（按时间倒序）
+func (at *AutoTrader) GetMonitoringAlerts(limit int) ([]monitoring.Alert, error) {
+	if at.monitor == nil {
+		return nil, fmt.Errorf("trader %s 未启用性能监控", at.id)
+	}
+	return at.monitor.GetAlerts(limit), nil
+}
+
+// ResolveMonitoringAlert 将性能监控预警标记为已解决
+func (at *AutoTrader) ResolveMonitoringAlert(alertID string) error {
+	if at.monitor == nil {
+		return fmt.Errorf("trader %s 未启用性能监控", at.id)
+	}
+	return at.monitor.ResolveAlert(alertID)
+}
+
+// GetRiskScore 获取性能监控的风险评分（未启用监控时返回false）
+func (at *AutoTrader) GetRiskScore() (int, bool) {
+	if at.monitor == nil {
+		return 0, false
+	}
+	return at.monitor.GetMetrics().RiskScore, true
+}
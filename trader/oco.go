@@ -0,0 +1,138 @@
+package trader
+
+import (
+	"log"
+	"strings"
+)
+
+// 止损止盈联动方式
+const (
+	ExitOrderModeNative   = "native_oco"   // 交易所端OCO：一个触发后交易所自动撤销另一个
+	ExitOrderModeEmulated = "emulated_oco" // 本地模拟：检测到持仓被平掉后撤销残留的条件单
+)
+
+// ocoExitPlacer 支持交易所端OCO止损止盈的交易器（可选能力，按驱动检测）
+// 目前接入的交易所都不支持：币安/Aster合约没有OCO，Hyperliquid的normalTpsl分组SDK未开放
+type ocoExitPlacer interface {
+	SetStopLossTakeProfitOCO(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error
+}
+
+// baseTrader 去掉审计、故障注入等包装层，返回实际的交易所驱动
+func baseTrader(t Trader) Trader {
+	for {
+		switch w := t.(type) {
+		case *auditTrader:
+			t = w.Trader
+		case *chaosTrader:
+			t = w.Trader
+		default:
+			return t
+		}
+	}
+}
+
+// ocoPlacer 交易所驱动支持OCO时返回对应实现
+func (at *AutoTrader) ocoPlacer() (ocoExitPlacer, bool) {
+	placer, ok := baseTrader(at.trader).(ocoExitPlacer)
+	return placer, ok
+}
+
+// ExitOrderMode 当前交易所止损止盈的联动方式
+func (at *AutoTrader) ExitOrderMode() string {
+	if _, ok := at.ocoPlacer(); ok {
+		return ExitOrderModeNative
+	}
+	return ExitOrderModeEmulated
+}
+
+// placeExitOrders 为持仓挂止损和止盈（positionSide为LONG/SHORT）
+// 交易所支持OCO且两个价格都有效时作为一组下单；不支持或OCO下单失败时按交易所能力分别挂单，由本地模拟OCO撤销残留
+// 保护单无法在交易所建立时发出预警
+func (at *AutoTrader) placeExitOrders(symbol, positionSide string, quantity, stopLoss, takeProfit float64) (slErr, tpErr error) {
+	if placer, ok := at.ocoPlacer(); ok && stopLoss > 0 && takeProfit > 0 {
+		round := at.exitPriceRounder(symbol, positionSide)
+		err := placer.SetStopLossTakeProfitOCO(symbol, positionSide, quantity, round(stopLoss), round(takeProfit))
+		if err == nil {
+			log.Printf("  🔗 %s %s 止损%.4f/止盈%.4f 已作为OCO挂单", symbol, positionSide, stopLoss, takeProfit)
+			return nil, nil
+		}
+		log.Printf("  ⚠️  %s OCO挂单失败，改为分别挂止损止盈: %v", symbol, err)
+	}
+
+	if stopLoss > 0 {
+		if slErr = at.placeProtectiveOrder(symbol, positionSide, OpenOrderStopLoss, quantity, stopLoss); slErr != nil {
+			at.alertProtectionFailure(symbol, positionSide, OpenOrderStopLoss, slErr)
+		}
+	}
+	if takeProfit > 0 {
+		if tpErr = at.placeProtectiveOrder(symbol, positionSide, OpenOrderTakeProfit, quantity, takeProfit); tpErr != nil {
+			at.alertProtectionFailure(symbol, positionSide, OpenOrderTakeProfit, tpErr)
+		}
+	}
+	return slErr, tpErr
+}
+
+// cancelOrphanExitOrders 持仓已被平掉后撤销该方向残留的止损/止盈条件单（本地模拟OCO）
+// 止盈成交后止损单仍挂着，价格回落时会触发并反向开仓或报错；交易所端OCO已自动撤销时这里查不到挂单
+func (at *AutoTrader) cancelOrphanExitOrders(symbol, side string) {
+	orders, err := at.trader.GetOpenOrders(symbol)
+	if err != nil {
+		log.Printf("  ⚠️  获取 %s 挂单失败，无法撤销残留的止损止盈单: %v", symbol, err)
+		return
+	}
+	for _, o := range positionOrders(orders, side) {
+		if o.Type != OpenOrderStopLoss && o.Type != OpenOrderTakeProfit {
+			continue
+		}
+		if err := at.trader.CancelOrder(symbol, o.OrderID); err != nil {
+			log.Printf("  ⚠️  撤销 %s %s 残留%s单失败 (orderId=%d): %v", symbol, side, exitOrderName(o.Type), o.OrderID, err)
+			continue
+		}
+		log.Printf("  🔗 %s %s 持仓已平，撤销残留%s单 %.4f (orderId=%d)", symbol, side, exitOrderName(o.Type), o.TriggerPrice, o.OrderID)
+	}
+}
+
+// sweepOrphanExitOrders 记录了止损止盈价但交易所已无对应持仓时撤销残留条件单（周期间止损监控调用）
+func (at *AutoTrader) sweepOrphanExitOrders(levels map[string][2]float64, positions []Position) {
+	var closed []string
+	for key := range levels {
+		if !hasPosition(positions, key) {
+			closed = append(closed, key)
+		}
+	}
+	if len(closed) == 0 {
+		return
+	}
+
+	// 撤单前重新确认持仓，避免持仓接口偶发返回不完整时撤掉仍在生效的保护单
+	confirmed, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️  [%s] 确认持仓失败，暂不撤销残留止损止盈单: %v", at.name, err)
+		return
+	}
+	for _, key := range closed {
+		idx := strings.LastIndex(key, "_")
+		if idx <= 0 || hasPosition(confirmed, key) {
+			continue
+		}
+		at.cancelOrphanExitOrders(key[:idx], key[idx+1:])
+	}
+}
+
+// hasPosition 持仓列表中是否有该持仓（key为symbol_side）
+func hasPosition(positions []Position, key string) bool {
+	for _, pos := range positions {
+		if pos.Symbol+"_"+pos.Side == key {
+			return true
+		}
+	}
+	return false
+}
+
+// exitOrderName 条件单类型的中文名称
+func exitOrderName(orderType string) string {
+	if orderType == OpenOrderTakeProfit {
+		return "止盈"
+	}
+	return "止损"
+}
@@ -0,0 +1,98 @@
+package trader
+
+import (
+	"log"
+	"nofx/decision"
+	"strings"
+)
+
+// openOrderType 把交易所订单类型归一为挂单分类
+// 币安/Aster: STOP / STOP_MARKET / TAKE_PROFIT / TAKE_PROFIT_MARKET / LIMIT；Hyperliquid: "Stop Market" / "Take Profit Limit" / "Limit"
+func openOrderType(exchangeType string) string {
+	t := strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(exchangeType)), " ", "_")
+	switch {
+	case strings.HasPrefix(t, "TAKE_PROFIT"):
+		return OpenOrderTakeProfit
+	case strings.HasPrefix(t, "STOP"):
+		return OpenOrderStopLoss
+	case t == "LIMIT":
+		return OpenOrderLimit
+	default:
+		return OpenOrderOther
+	}
+}
+
+// positionOrders 筛选属于该持仓的挂单（双向持仓按 positionSide 匹配，单向持仓按平仓方向匹配）
+func positionOrders(orders []OpenOrder, side string) []decision.OpenOrderInfo {
+	closingSide := "SELL"
+	if side == "short" {
+		closingSide = "BUY"
+	}
+	result := []decision.OpenOrderInfo{}
+	for _, o := range orders {
+		switch o.PositionSide {
+		case "LONG", "SHORT":
+			if o.PositionSide != strings.ToUpper(side) {
+				continue
+			}
+		default:
+			if o.Side != closingSide {
+				continue
+			}
+		}
+		result = append(result, decision.OpenOrderInfo{
+			OrderID:      o.OrderID,
+			Type:         o.Type,
+			TriggerPrice: o.TriggerPrice,
+			Price:        o.Price,
+			Quantity:     o.Quantity,
+		})
+	}
+	return result
+}
+
+// attachOpenOrders 为持仓附加交易所当前的挂单（每个币种查询一次）
+// 数据库中没有记录止损/止盈价时，以交易所条件单的触发价补充；查询失败时该持仓不附加挂单，不影响本周期决策
+func (at *AutoTrader) attachOpenOrders(positions []decision.PositionInfo) {
+	bySymbol := make(map[string][]OpenOrder)
+	for i := range positions {
+		pos := &positions[i]
+		orders, fetched := bySymbol[pos.Symbol]
+		if !fetched {
+			var err error
+			orders, err = at.trader.GetOpenOrders(pos.Symbol)
+			if err != nil {
+				log.Printf("  ⚠️  获取 %s 挂单失败: %v", pos.Symbol, err)
+				continue
+			}
+			bySymbol[pos.Symbol] = orders
+		}
+
+		pos.OpenOrders = positionOrders(orders, pos.Side)
+		for _, o := range pos.OpenOrders {
+			if o.Type == OpenOrderStopLoss && pos.StopLoss == 0 {
+				pos.StopLoss = o.TriggerPrice
+			}
+			if o.Type == OpenOrderTakeProfit && pos.TakeProfit == 0 {
+				pos.TakeProfit = o.TriggerPrice
+			}
+		}
+	}
+}
+
+// exchangeExitLevels 交易所条件单上的止损/止盈触发价（没有对应条件单时为0）
+func (at *AutoTrader) exchangeExitLevels(symbol, side string) (stopLoss, takeProfit float64, err error) {
+	orders, err := at.trader.GetOpenOrders(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, o := range positionOrders(orders, side) {
+		switch o.Type {
+		case OpenOrderStopLoss:
+			stopLoss = o.TriggerPrice
+		case OpenOrderTakeProfit:
+			takeProfit = o.TriggerPrice
+		}
+	}
+	return stopLoss, takeProfit, nil
+}
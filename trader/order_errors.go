@@ -0,0 +1,66 @@
+package trader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// orderErrorHints 交易所订单错误码说明（币安/Aster 共用同一套错误码）
+var orderErrorHints = map[int64]string{
+	-2022: "reduceOnly订单被拒绝：当前没有可减少的持仓，或数量超过持仓",
+	-1106: "发送了不需要的参数（对冲模式下不能携带reduceOnly）",
+	-4061: "订单持仓方向与账户持仓模式不匹配",
+	-2021: "触发价格会立即触发，止损/止盈价设置在了错误的一侧",
+	-4045: "已达到止损/止盈挂单数量上限",
+	-2019: "保证金不足",
+}
+
+// hyperliquidErrorHints Hyperliquid返回的订单错误关键字说明
+var hyperliquidErrorHints = map[string]string{
+	"Reduce only order would increase position": "reduceOnly订单被拒绝：该订单会增加持仓或反向开仓",
+	"Insufficient margin":                       "保证金不足",
+}
+
+// orderErrorCode 从交易所错误中提取错误码（币安SDK错误或 Aster 的 "HTTP xxx: {json}" 错误）
+func orderErrorCode(err error) (int64, bool) {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code, true
+	}
+
+	msg := err.Error()
+	if idx := strings.Index(msg, "{"); idx >= 0 {
+		var body struct {
+			Code int64 `json:"code"`
+		}
+		if json.Unmarshal([]byte(msg[idx:]), &body) == nil && body.Code != 0 {
+			return body.Code, true
+		}
+	}
+	return 0, false
+}
+
+// describeOrderError 为下单错误附加可读的原因说明，便于日志和AI学习定位问题
+func describeOrderError(action string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if code, ok := orderErrorCode(err); ok {
+		if hint, exists := orderErrorHints[code]; exists {
+			return fmt.Errorf("%s失败（%s）: %w", action, hint, err)
+		}
+	}
+
+	for keyword, hint := range hyperliquidErrorHints {
+		if strings.Contains(err.Error(), keyword) {
+			return fmt.Errorf("%s失败（%s）: %w", action, hint, err)
+		}
+	}
+
+	return fmt.Errorf("%s失败: %w", action, err)
+}
@@ -0,0 +1,180 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/monitoring"
+	"time"
+)
+
+// minPositionDriftInterval 周期间止损监控的最小检查间隔
+const minPositionDriftInterval = 5 * time.Second
+
+// positionDriftConfig 周期间止损监控配置
+func positionDriftConfig() database.PositionDriftConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetPositionDriftConfig()
+	}
+	return database.PositionDriftConfig{Enabled: true, IntervalSeconds: 20}
+}
+
+// positionDriftInterval 检查间隔
+func positionDriftInterval(cfg database.PositionDriftConfig) time.Duration {
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if interval < minPositionDriftInterval {
+		return minPositionDriftInterval
+	}
+	return interval
+}
+
+// stopLossBreached 标记价格是否已越过止损价
+func stopLossBreached(side string, markPrice, stopLoss float64) bool {
+	if stopLoss <= 0 || markPrice <= 0 {
+		return false
+	}
+	if side == "long" {
+		return markPrice <= stopLoss
+	}
+	return markPrice >= stopLoss
+}
+
+// runPositionDriftMonitor 在两个交易周期之间监控持仓是否越过止损（随Run启动，trader停止后退出）
+// 止损单下单失败或被拒时，持仓可能在周期间远远越过止损，这里按较短间隔兜底
+func (at *AutoTrader) runPositionDriftMonitor() {
+	interval := positionDriftInterval(positionDriftConfig())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !at.isRunning {
+			return
+		}
+		cfg := positionDriftConfig()
+		if next := positionDriftInterval(cfg); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+		if state := at.State().State; !cfg.Enabled || state == StatePaused || state == StateStopped {
+			continue
+		}
+		// 预览模式不下单也不撤单（与交易周期跳过出场策略和AI执行一致）
+		if previewMode() {
+			continue
+		}
+		// 交易周期（或手动决策）执行中时跳过，周期内会重新获取持仓并处理
+		if !at.cycleMu.TryLock() {
+			continue
+		}
+		at.checkPositionDrift(time.Now())
+		at.cycleMu.Unlock()
+	}
+}
+
+// checkPositionDrift 检查持仓标记价格是否越过记录的止损价，越过且交易所止损单确认缺失时强制市价平仓（调用方持有 cycleMu）
+func (at *AutoTrader) checkPositionDrift(now time.Time) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	levels, err := db.GetAllPositionExitLevels()
+	if err != nil {
+		log.Printf("⚠️  [%s] 止损监控读取止损价失败: %v", at.name, err)
+		return
+	}
+	if len(levels) == 0 {
+		return
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️  [%s] 止损监控获取持仓失败: %v", at.name, err)
+		return
+	}
+
+	// 周期间持仓被止损/止盈平掉时尽快撤销另一侧残留的条件单
+	at.sweepOrphanExitOrders(levels, positions)
+
+	for _, pos := range positions {
+		stopLoss := levels[pos.Symbol+"_"+pos.Side][0]
+		if !stopLossBreached(pos.Side, pos.MarkPrice, stopLoss) {
+			continue
+		}
+
+		// 止损单仍在时等待交易所触发；查询失败时无法确认缺失，不强制平仓
+		orders, err := at.trader.GetOpenOrders(pos.Symbol)
+		if err != nil {
+			log.Printf("⚠️  [%s] %s %s 标记价格%.4f已越过止损%.4f，但获取挂单失败，无法确认止损单状态: %v",
+				at.name, pos.Symbol, pos.Side, pos.MarkPrice, stopLoss, err)
+			continue
+		}
+		if hasStopOrder(positionOrders(orders, pos.Side)) {
+			continue
+		}
+		at.forceCloseDriftedPosition(pos, stopLoss, now)
+	}
+}
+
+// hasStopOrder 挂单中是否有止损单
+func hasStopOrder(orders []decision.OpenOrderInfo) bool {
+	for _, o := range orders {
+		if o.Type == OpenOrderStopLoss {
+			return true
+		}
+	}
+	return false
+}
+
+// forceCloseDriftedPosition 市价平掉越过止损且缺少止损单的持仓，动作合并到下一条决策记录并发出预警
+func (at *AutoTrader) forceCloseDriftedPosition(pos Position, stopLoss float64, now time.Time) {
+	reason := fmt.Sprintf("标记价格%.4f已越过止损%.4f，交易所止损单缺失", pos.MarkPrice, stopLoss)
+	d := &decision.Decision{
+		Symbol:    pos.Symbol,
+		Action:    "close_" + pos.Side,
+		Reasoning: exitPolicyReason(ExitPolicyStopGuard) + "：" + reason,
+	}
+	actionRecord := logger.DecisionAction{
+		Action:      d.Action,
+		Symbol:      d.Symbol,
+		Quantity:    pos.Quantity,
+		Timestamp:   now,
+		WasStopLoss: true,
+		ExitPolicy:  ExitPolicyStopGuard,
+	}
+
+	log.Printf("🚨 [%s] %s %s %s，强制市价平仓", at.name, pos.Symbol, pos.Side, reason)
+	var err error
+	if pos.Side == "long" {
+		err = at.executeCloseLongWithRecord(d, &actionRecord)
+	} else {
+		err = at.executeCloseShortWithRecord(d, &actionRecord)
+	}
+
+	title := "止损单缺失：已强制平仓"
+	message := fmt.Sprintf("%s %s %s，已按市价平仓", pos.Symbol, pos.Side, reason)
+	if err != nil {
+		setActionError(&actionRecord, err)
+		title = "止损单缺失：强制平仓失败"
+		message = fmt.Sprintf("%s %s %s，强制平仓失败: %v", pos.Symbol, pos.Side, reason, err)
+		log.Printf("❌ [%s] %s %s 强制平仓失败: %v", at.name, pos.Symbol, pos.Side, err)
+	} else {
+		actionRecord.Success = actionRecord.Error == ""
+		at.notifyPositionExecuted(d, &actionRecord)
+		// 持仓已主动平掉，下次构建上下文时不再当作止损/止盈自动平仓
+		at.mu.Lock()
+		delete(at.lastKnownPositions, pos.Symbol+"_"+pos.Side)
+		at.mu.Unlock()
+	}
+	at.pendingAutoCloses = append(at.pendingAutoCloses, actionRecord)
+
+	if at.monitor != nil {
+		at.monitor.RaiseAlert(monitoring.Alert{
+			ID:      fmt.Sprintf("position_drift_%s_%s_%s_%d", at.id, pos.Symbol, pos.Side, now.Unix()),
+			Type:    monitoring.AlertTypeRisk,
+			Level:   monitoring.AlertLevelCritical,
+			Title:   title,
+			Message: message,
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// 预览计划的触发方式
+const (
+	PreviewSourceConfig = "config" // execution_preview_mode 开启时的每个交易周期
+	PreviewSourceAPI    = "api"    // 操作员通过API触发的单次预览
+)
+
+// PlannedOrder 预览模式下计划提交的订单（与实际执行走相同的检查和仓位计算，但不下单、不改杠杆）
+type PlannedOrder struct {
+	Action      string  `json:"action"`
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side,omitempty"`         // long / short
+	Quantity    float64 `json:"quantity"`               // 下单数量（开仓已按交易所规则取整，平仓为当前持仓数量）
+	Price       float64 `json:"price"`                  // 下单参考价（调整止损止盈时为新的触发价）
+	NotionalUSD float64 `json:"notional_usd,omitempty"` // 名义价值 = 数量 × 价格
+	MarginUSD   float64 `json:"margin_usd,omitempty"`
+	Leverage    int     `json:"leverage,omitempty"`    // 请求的杠杆（实际执行时以交易所确认的杠杆为准）
+	StopLoss    float64 `json:"stop_loss,omitempty"`   // 已按价格规则和持仓方向取整
+	TakeProfit  float64 `json:"take_profit,omitempty"` // 已按价格规则和持仓方向取整
+	DriftPct    float64 `json:"price_drift_pct,omitempty"`
+	Adjustment  string  `json:"adjustment,omitempty"` // 仓位/止损调整说明
+	Approval    string  `json:"approval,omitempty"`   // 需要人工审批的原因（实际执行时进入待审批队列）
+	Error       string  `json:"error,omitempty"`      // 实际执行时会被拒绝的原因（为空表示会提交）
+}
+
+// PreviewPlan 一个预览周期的计划（行情、AI调用、验证照常执行，止步于下单）
+type PreviewPlan struct {
+	TraderID   string              `json:"trader_id"`
+	Source     string              `json:"source"` // config / api
+	CreatedAt  time.Time           `json:"created_at"`
+	State      TraderState         `json:"state"` // 预览时的生效状态
+	PromptHash string              `json:"prompt_hash,omitempty"`
+	Cached     bool                `json:"cached"`
+	CoTTrace   string              `json:"cot_trace,omitempty"`
+	Decisions  []decision.Decision `json:"decisions"` // 验证后按执行顺序排列的决策
+	Orders     []PlannedOrder      `json:"orders"`    // 计划提交的订单（不含hold/wait）
+	Error      string              `json:"error,omitempty"`
+}
+
+// PreviewDecisions 操作员触发一次预览：收集行情、调用AI、验证决策并生成计划订单，不下单、不写决策记录
+// 资金费率套利和自动退出策略会下单，预览时不执行
+func (at *AutoTrader) PreviewDecisions() (*PreviewPlan, error) {
+	status := at.State()
+
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	ctx, autoClosedPositions, err := at.buildTradingContext()
+	if err != nil {
+		return nil, fmt.Errorf("构建交易上下文失败: %w", err)
+	}
+	// 构建上下文时检测到的自动平仓留给下一条决策记录
+	at.pendingAutoCloses = autoClosedPositions
+
+	log.Printf("[%s] 🔍 预览：正在请求AI分析并决策（不下单）...", at.name)
+	fullDecision, err := decision.GetFullDecision(ctx, at.aiClient())
+	at.mu.Lock()
+	at.lastMarketData = ctx.MarketDataMap
+	at.lastMarketDataAt = ctx.MarketSnapshotAt
+	at.mu.Unlock()
+
+	plan := &PreviewPlan{
+		TraderID:  at.id,
+		Source:    PreviewSourceAPI,
+		CreatedAt: time.Now(),
+		State:     status.State,
+		Decisions: []decision.Decision{},
+		Orders:    []PlannedOrder{},
+	}
+	if fullDecision != nil {
+		plan.PromptHash = fullDecision.PromptHash
+		plan.Cached = fullDecision.Cached
+		plan.CoTTrace = fullDecision.CoTTrace
+	}
+	if err != nil {
+		plan.Error = fmt.Sprintf("获取AI决策失败: %v", err)
+		at.storePreview(plan)
+		return plan, nil
+	}
+
+	at.planDecisions(plan, sortDecisionsByPriority(fullDecision.Decisions))
+	at.storePreview(plan)
+	return plan, nil
+}
+
+// previewDecisionBatch 预览模式下代替 executeDecisionBatch：生成计划订单写入执行日志，不下单
+func (at *AutoTrader) previewDecisionBatch(decisions []decision.Decision, d *decision.FullDecision, record *logger.DecisionRecord) {
+	plan := &PreviewPlan{
+		TraderID:   at.id,
+		Source:     PreviewSourceConfig,
+		CreatedAt:  time.Now(),
+		State:      at.State().State,
+		PromptHash: d.PromptHash,
+		Cached:     d.Cached,
+		CoTTrace:   d.CoTTrace,
+		Decisions:  []decision.Decision{},
+		Orders:     []PlannedOrder{},
+	}
+	at.planDecisions(plan, decisions)
+	at.storePreview(plan)
+
+	record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("🔍 预览模式（execution_preview_mode）：%d 个计划订单未提交", len(plan.Orders)))
+	for _, order := range plan.Orders {
+		record.ExecutionLog = append(record.ExecutionLog, "🔍 "+order.String())
+	}
+}
+
+// planDecisions 为排序后的决策逐个生成计划订单并打印
+func (at *AutoTrader) planDecisions(plan *PreviewPlan, decisions []decision.Decision) {
+	plan.Decisions = append(plan.Decisions, decisions...)
+	log.Printf("[%s] 🔍 预览计划（%d 个决策，未下单）:", at.name, len(decisions))
+	for i := range decisions {
+		d := decisions[i]
+		if d.Action == "hold" || d.Action == "wait" {
+			continue
+		}
+		order := at.planDecision(&d)
+		plan.Orders = append(plan.Orders, order)
+		log.Printf("  🔍 %s", order.String())
+	}
+}
+
+// planDecision 按实际执行的检查顺序生成单个决策的计划订单（不改杠杆、不下单、不占用风险预算）
+func (at *AutoTrader) planDecision(d *decision.Decision) PlannedOrder {
+	order := PlannedOrder{Action: d.Action, Symbol: d.Symbol, Leverage: d.Leverage}
+	var err error
+	switch d.Action {
+	case "open_long", "open_short":
+		err = at.planOpen(d, &order)
+	case "close_long", "close_short":
+		err = at.planClose(d, &order)
+	case "update_stop_loss", "update_take_profit":
+		err = at.planExitLevelUpdate(d, &order)
+	default:
+		err = fmt.Errorf("未知的action: %s", d.Action)
+	}
+	if err != nil {
+		order.Error = err.Error()
+	}
+	return order
+}
+
+// planOpen 开仓计划：与 executeOpenLongWithRecord/executeOpenShortWithRecord 相同的检查和仓位计算
+func (at *AutoTrader) planOpen(d *decision.Decision, order *PlannedOrder) error {
+	side := "long"
+	if d.Action == "open_short" {
+		side = "short"
+	}
+	order.Side = side
+	order.StopLoss, order.TakeProfit = d.StopLoss, d.TakeProfit
+
+	if err := at.checkFundingArbSymbol(d.Symbol); err != nil {
+		return err
+	}
+	if err := at.checkMaintenanceOpen(time.Now()); err != nil {
+		return err
+	}
+	if err := at.checkCloseOnlyOpen(); err != nil {
+		return err
+	}
+	if positions, err := at.trader.GetPositions(); err == nil {
+		for _, pos := range positions {
+			if pos.Symbol == d.Symbol && pos.Side == side {
+				return fmt.Errorf("❌ %s 已有%s仓，拒绝开仓以防止仓位叠加超限", d.Symbol, sideName(side))
+			}
+		}
+	}
+	if err := checkSymbolTradable(d.Symbol); err != nil {
+		return err
+	}
+	if err := checkSymbolMargin(at.exchange, d.Symbol, poolConfig().AllowedQuoteAssets); err != nil {
+		return fmt.Errorf("❌ %s 拒绝开仓: %w", d.Symbol, err)
+	}
+
+	marketData, err := market.GetWith(at.marketProvider, d.Symbol)
+	if err != nil {
+		return err
+	}
+	if err := checkDataQuality(marketData); err != nil {
+		return err
+	}
+	if err := checkLiquidationCascade(d.Symbol); err != nil {
+		return err
+	}
+	if err := checkFundingEntry(marketData, side); err != nil {
+		return err
+	}
+
+	size, err := at.sizePosition(d, side, marketData.CurrentPrice)
+	if size != nil {
+		order.Price, order.Quantity, order.DriftPct, order.Adjustment = size.Price, size.Quantity, size.DriftPct, size.Adjustment
+	}
+	if err != nil {
+		return err
+	}
+	order.NotionalUSD, order.MarginUSD = size.NotionalUSD, size.MarginUSD
+	// 止损按强平价收紧在决策验证阶段完成，这里只展示调整说明
+	if d.Adjustment != "" {
+		order.Adjustment = joinAdjustment(order.Adjustment, d.Adjustment)
+	}
+	if err := at.checkRiskBudget(d, size.Price, size.Quantity); err != nil {
+		return err
+	}
+	if reason, _, _ := at.approvalReason(d, approvalConfig()); reason != "" {
+		order.Approval = reason
+	}
+
+	round := at.exitPriceRounder(d.Symbol, strings.ToUpper(side))
+	if d.StopLoss > 0 {
+		order.StopLoss = round(d.StopLoss)
+	}
+	if d.TakeProfit > 0 {
+		order.TakeProfit = round(d.TakeProfit)
+	}
+	return nil
+}
+
+// planClose 平仓计划：按当前持仓数量全部平仓
+func (at *AutoTrader) planClose(d *decision.Decision, order *PlannedOrder) error {
+	side := "long"
+	if d.Action == "close_short" {
+		side = "short"
+	}
+	order.Side = side
+	if err := at.checkFundingArbSymbol(d.Symbol); err != nil {
+		return err
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	for _, pos := range positions {
+		if pos.Symbol != d.Symbol || pos.Side != side {
+			continue
+		}
+		order.Quantity = pos.Quantity
+		order.Leverage = at.positionLeverage(pos)
+		order.Price = pos.MarkPrice
+		if marketData, err := market.GetWith(at.marketProvider, d.Symbol); err == nil {
+			order.Price = marketData.CurrentPrice
+		}
+		order.NotionalUSD = order.Quantity * order.Price
+		return nil
+	}
+	return fmt.Errorf("%s %s仓不存在（可能已自动平仓），不会下单", d.Symbol, sideName(side))
+}
+
+// planExitLevelUpdate 调整止损/止盈计划：新价格按持仓方向取整
+func (at *AutoTrader) planExitLevelUpdate(d *decision.Decision, order *PlannedOrder) error {
+	if err := at.checkFundingArbSymbol(d.Symbol); err != nil {
+		return err
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var pos *Position
+	for i := range positions {
+		if positions[i].Symbol != d.Symbol {
+			continue
+		}
+		if pos != nil {
+			return fmt.Errorf("%s 同时持有多仓和空仓，实际执行时会拒绝调整", d.Symbol)
+		}
+		pos = &positions[i]
+	}
+	if pos == nil {
+		return fmt.Errorf("%s 持仓不存在（可能已自动平仓），不会下单", d.Symbol)
+	}
+
+	order.Side = pos.Side
+	order.Quantity = pos.Quantity
+	order.Leverage = at.positionLeverage(*pos)
+	round := at.exitPriceRounder(d.Symbol, strings.ToUpper(pos.Side))
+	if d.Action == "update_stop_loss" {
+		order.StopLoss = round(d.StopLoss)
+		order.Price = order.StopLoss
+	} else {
+		order.TakeProfit = round(d.TakeProfit)
+		order.Price = order.TakeProfit
+	}
+	return nil
+}
+
+// sideName 持仓方向的中文名称
+func sideName(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
+
+// String 计划订单的单行描述（用于日志和决策记录的执行日志）
+func (o PlannedOrder) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s", o.Symbol, o.Action)
+	if o.Quantity > 0 {
+		fmt.Fprintf(&sb, " 数量 %.6f @ %.4f", o.Quantity, o.Price)
+	}
+	if o.NotionalUSD > 0 {
+		fmt.Fprintf(&sb, " = %.2f USDT", o.NotionalUSD)
+	}
+	if o.Leverage > 0 {
+		fmt.Fprintf(&sb, " %dx", o.Leverage)
+	}
+	if o.StopLoss > 0 {
+		fmt.Fprintf(&sb, " 止损 %.4f", o.StopLoss)
+	}
+	if o.TakeProfit > 0 {
+		fmt.Fprintf(&sb, " 止盈 %.4f", o.TakeProfit)
+	}
+	if o.Adjustment != "" {
+		fmt.Fprintf(&sb, "（%s）", o.Adjustment)
+	}
+	if o.Approval != "" {
+		fmt.Fprintf(&sb, " ⏸️ 需人工审批: %s", o.Approval)
+	}
+	if o.Error != "" {
+		fmt.Fprintf(&sb, " ❌ 执行时会被拒绝: %s", o.Error)
+	}
+	return sb.String()
+}
+
+// storePreview 保存最近一次预览计划（供API查询）
+func (at *AutoTrader) storePreview(plan *PreviewPlan) {
+	at.mu.Lock()
+	at.lastPreview = plan
+	at.mu.Unlock()
+}
+
+// LastPreview 最近一次预览计划（没有时返回nil）
+func (at *AutoTrader) LastPreview() *PreviewPlan {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+	return at.lastPreview
+}
+
+// previewMode 当前是否处于预览模式（execution_preview_mode）
+func previewMode() bool {
+	return executionConfig().PreviewMode
+}
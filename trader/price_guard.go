@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/money"
+)
+
+// executionConfig 下单执行配置（全局配置未初始化时使用默认值）
+func executionConfig() database.ExecutionConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetExecutionConfig()
+	}
+	return database.ExecutionConfig{MaxPriceDriftPct: 1.0, DriftAction: "reject", CloseConcurrency: 3, OrderDelayMs: 1000, AuditEnabled: true, BookSnapshotEnabled: true, BookSnapshotTrades: 20}
+}
+
+// recheckEntryPrice 下单前重新获取价格，与AI分析时的价格比较
+// 漂移超过阈值时按配置拒绝，或保持AI计划的止损风险不变缩减仓位（不会放大仓位）
+// 返回下单参考价、下单数量和漂移百分比
+func (at *AutoTrader) recheckEntryPrice(d *decision.Decision, side string, fallbackPrice float64) (float64, float64, float64, error) {
+	current, err := at.trader.GetMarketPrice(d.Symbol)
+	if err != nil || current <= 0 {
+		log.Printf("  ⚠️  下单前获取最新价格失败，使用行情价格 %.4f: %v", fallbackPrice, err)
+		current = fallbackPrice
+	}
+	quantity := money.Div(d.NotionalUSD, current)
+
+	analyzed := 0.0
+	if md, ok := at.lastMarketData[d.Symbol]; ok && md != nil {
+		analyzed = md.CurrentPrice
+	}
+	if analyzed <= 0 {
+		return current, quantity, 0, nil
+	}
+
+	drift := (current - analyzed) / analyzed * 100
+	cfg := executionConfig()
+	if cfg.MaxPriceDriftPct <= 0 || math.Abs(drift) <= cfg.MaxPriceDriftPct {
+		return current, quantity, drift, nil
+	}
+
+	if cfg.DriftAction != "resize" {
+		return current, quantity, drift, fmt.Errorf("❌ %s 价格漂移 %+.2f%% 超过阈值 %.2f%%（分析价 %.4f → 当前价 %.4f），拒绝开仓",
+			d.Symbol, drift, cfg.MaxPriceDriftPct, analyzed, current)
+	}
+
+	// 按止损距离缩减仓位：保持AI在分析价格下计划承担的风险
+	if d.StopLoss <= 0 ||
+		(side == "long" && current <= d.StopLoss) ||
+		(side == "short" && current >= d.StopLoss) {
+		return current, quantity, drift, fmt.Errorf("❌ %s 价格漂移 %+.2f%% 后已越过止损价 %.4f，拒绝开仓", d.Symbol, drift, d.StopLoss)
+	}
+	plannedRisk := math.Abs(analyzed-d.StopLoss) * (d.NotionalUSD / analyzed)
+	resized := math.Min(quantity, plannedRisk/math.Abs(current-d.StopLoss))
+	log.Printf("  ⚖️  %s 价格漂移 %+.2f%%（分析价 %.4f → 当前价 %.4f），仓位数量 %.4f → %.4f",
+		d.Symbol, drift, analyzed, current, quantity, resized)
+	return current, resized, drift, nil
+}
@@ -0,0 +1,34 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/experiment"
+)
+
+// StartQualityRescore 按当前质量评分规则重新评分历史决策（异步执行，返回任务快照）
+// 行情用本地K线重建，不访问交易所、不调用AI；信心度门槛使用该trader当前的配置
+func (at *AutoTrader) StartQualityRescore(cfg experiment.RescoreConfig) (experiment.RescoreJob, error) {
+	env := experiment.RescoreEnv{DecisionLogger: at.decisionLogger}
+	if floor := at.confidenceFloor(); floor.Entry > 0 || floor.Close > 0 {
+		env.ConfidenceFloor = &floor
+	}
+	return experiment.SubmitRescore(at.id, cfg, env)
+}
+
+// QualityVersions 该trader已保存的质量评分版本
+func (at *AutoTrader) QualityVersions() ([]experiment.QualityVersion, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	return experiment.ListQualityVersions(db)
+}
+
+// CompareQualityVersions 对比两个质量评分版本（base为recorded时与决策当时记录的评分对比）
+func (at *AutoTrader) CompareQualityVersions(base, target string) (*experiment.QualityComparison, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	return experiment.CompareQualityVersions(db, base, target)
+}
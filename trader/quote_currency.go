@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/money"
+	"strings"
+)
+
+// exchangeMarginTypes 各交易所支持交易的合约保证金类型
+// 币安 U本位接口支持USDT和USDC保证金合约；币本位合约使用独立的 COIN-M 接口，暂未接入
+var exchangeMarginTypes = map[string][]market.MarginType{
+	"binance":     {market.MarginUSDT, market.MarginUSDC},
+	"hyperliquid": {market.MarginUSDT}, // 内部统一使用 XXXUSDT 写法，实际以USDC结算
+	"aster":       {market.MarginUSDT},
+}
+
+// poolConfig 获取币种池配置（全局配置未初始化时使用默认值）
+func poolConfig() database.PoolConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetPoolConfig()
+	}
+	return database.PoolConfig{AllowedQuoteAssets: []string{"USDT", "USDC"}}
+}
+
+// exchangeSupportsMargin 交易所是否支持该保证金类型
+func exchangeSupportsMargin(exchange string, margin market.MarginType) bool {
+	for _, m := range exchangeMarginTypes[exchange] {
+		if m == margin {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSymbolMargin 检查交易对的计价币种是否允许、保证金类型是否被当前交易所支持
+func checkSymbolMargin(exchange, symbol string, allowedQuotes []string) error {
+	quote := market.ParseSymbolQuote(symbol)
+	if !exchangeSupportsMargin(exchange, quote.Margin) {
+		return fmt.Errorf("%s 交易所不支持%s保证金合约", exchange, quote.Margin)
+	}
+	for _, q := range allowedQuotes {
+		if strings.EqualFold(q, quote.Quote) {
+			return nil
+		}
+	}
+	return fmt.Errorf("计价币种%s未在pool_allowed_quote_assets中启用", quote.Quote)
+}
+
+// filterQuoteCandidates 按计价币种和交易所支持的保证金类型过滤候选币种
+func filterQuoteCandidates(coins []decision.CandidateCoin, exchange string) []decision.CandidateCoin {
+	allowed := poolConfig().AllowedQuoteAssets
+	var kept []decision.CandidateCoin
+	var excluded []string
+	for _, coin := range coins {
+		if err := checkSymbolMargin(exchange, coin.Symbol, allowed); err != nil {
+			excluded = append(excluded, fmt.Sprintf("%s(%v)", coin.Symbol, err))
+			continue
+		}
+		kept = append(kept, coin)
+	}
+	if len(excluded) > 0 {
+		log.Printf("🚫 排除%d个计价币种/保证金类型不支持的候选币种: %s", len(excluded), strings.Join(excluded, ", "))
+	}
+	return kept
+}
+
+// tradePnL 按交易对的保证金类型计算已实现盈亏，并折算为USDT计价
+// U本位（USDT/USDC）：(平仓价-开仓价)×数量，稳定币按1:1折算
+// 币本位：张数×面值×(1/开仓价-1/平仓价) 得到基础币种盈亏，再按平仓价折算
+func tradePnL(symbol, side string, openPrice, closePrice, quantity float64) float64 {
+	quote := market.ParseSymbolQuote(symbol)
+	if quote.Margin != market.MarginCoin {
+		return money.PnL(side, openPrice, closePrice, quantity)
+	}
+	coinPnL := money.InversePnL(side, openPrice, closePrice, quantity, market.CoinContractSize(quote.Base))
+	return money.Mul(coinPnL, closePrice)
+}
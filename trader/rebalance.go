@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"nofx/database"
+	"nofx/decision"
+)
+
+// rebalanceTargets 从运行时配置构建组合再平衡目标（关闭时返回nil）
+func rebalanceTargets() *decision.RebalanceTargets {
+	cfg := database.RebalanceConfig{
+		Enabled:       true,
+		MaxSymbolPct:  50,
+		MaxSectorPct:  70,
+		MaxNetBiasPct: 80,
+		MinPositions:  2,
+	}
+	if rc := database.GetGlobalConfig(); rc != nil {
+		cfg = rc.GetRebalanceConfig()
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	return &decision.RebalanceTargets{
+		MaxSymbolPct:  cfg.MaxSymbolPct,
+		MaxSectorPct:  cfg.MaxSectorPct,
+		MaxNetBiasPct: cfg.MaxNetBiasPct,
+		MinPositions:  cfg.MinPositions,
+		Enforce:       cfg.Enforce,
+		SectorMap:     cfg.SectorMap,
+	}
+}
@@ -0,0 +1,62 @@
+package trader
+
+import (
+	"log"
+	"nofx/database"
+	"nofx/market"
+	"nofx/money"
+)
+
+// exchangeSettlementAssets 各交易所账户净值的计价资产
+// 币安U本位账户把USDC等保证金资产按1:1合计为USDT；Hyperliquid以USDC结算；Aster以USDT结算
+var exchangeSettlementAssets = map[string]string{
+	"binance":     "USDT",
+	"hyperliquid": "USDC",
+	"aster":       "USDT",
+}
+
+// ReportingRate 账户计价资产到报告币种的折算汇率
+type ReportingRate struct {
+	Currency        string  `json:"currency"`         // 报告币种
+	SettlementAsset string  `json:"settlement_asset"` // 账户净值的计价资产
+	Rate            float64 `json:"rate"`             // 1单位计价资产 = Rate单位报告币种
+	Estimated       bool    `json:"estimated"`        // 获取指数价格失败，按1:1估算
+}
+
+// Convert 把计价资产金额折算为报告币种
+func (r ReportingRate) Convert(amount float64) float64 {
+	return money.Mul(amount, r.Rate)
+}
+
+// ReportingCurrency 报告币种（全局配置未初始化时为USD）
+func ReportingCurrency() string {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetReportingConfig().Currency
+	}
+	return "USD"
+}
+
+// SettlementAsset 账户净值的计价资产
+func (at *AutoTrader) SettlementAsset() string {
+	if asset, ok := exchangeSettlementAssets[at.exchange]; ok {
+		return asset
+	}
+	return market.USDReferenceAsset
+}
+
+// GetReportingRate 计价资产到报告币种的折算汇率（按指数价格，获取失败时按1:1估算）
+func (at *AutoTrader) GetReportingRate() ReportingRate {
+	r := ReportingRate{
+		Currency:        ReportingCurrency(),
+		SettlementAsset: at.SettlementAsset(),
+		Rate:            1,
+	}
+	rate, err := market.ConversionRate(r.SettlementAsset, r.Currency)
+	if err != nil {
+		log.Printf("⚠️  [%s] 获取%s→%s折算汇率失败，按1:1估算: %v", at.name, r.SettlementAsset, r.Currency, err)
+		r.Estimated = true
+		return r
+	}
+	r.Rate = rate
+	return r
+}
@@ -0,0 +1,40 @@
+package trader
+
+import (
+	"log"
+	"nofx/database"
+	"nofx/logger"
+	"time"
+)
+
+// RetentionCheckInterval 决策历史归档清理的检查间隔
+const RetentionCheckInterval = 24 * time.Hour
+
+// retentionConfig 决策历史保留配置（全局配置未初始化时使用默认值）
+func retentionConfig() database.RetentionConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetRetentionConfig()
+	}
+	return database.RetentionConfig{Days: 30, ArchiveEnabled: true}
+}
+
+// applyRetention 按配置归档并清理过期的决策记录（Days<=0 表示永久保留）
+func (at *AutoTrader) applyRetention() {
+	cfg := retentionConfig()
+	if cfg.Days <= 0 {
+		return
+	}
+	if _, err := at.decisionLogger.ApplyRetention(cfg.Days, cfg.ArchiveEnabled, false); err != nil {
+		log.Printf("⚠️  [%s] 决策历史归档清理失败: %v", at.name, err)
+	}
+}
+
+// PurgeDecisionHistory 手动归档清理 days 天前的决策记录
+func (at *AutoTrader) PurgeDecisionHistory(days int, archive, vacuum bool) (*logger.RetentionResult, error) {
+	return at.decisionLogger.ApplyRetention(days, archive, vacuum)
+}
+
+// GetStorageUsage 获取决策历史存储用量
+func (at *AutoTrader) GetStorageUsage() (*logger.StorageUsage, error) {
+	return at.decisionLogger.GetStorageUsage()
+}
@@ -0,0 +1,178 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/database"
+	"nofx/database/models"
+	"nofx/decision"
+	"time"
+)
+
+// RiskBudgetStatus 日风险预算状态
+// 已用预算 = 持仓占用的风险（开仓时按 |入场价-止损价| × 数量 占用，平仓释放）+ 当日已实现亏损
+type RiskBudgetStatus struct {
+	TradingDay      string                     `json:"trading_day"`      // 交易日（每日零点重置已实现亏损）
+	DailyBudget     float64                    `json:"daily_budget"`     // 日风险预算(USD)
+	ReservedRisk    float64                    `json:"reserved_risk"`    // 持仓占用风险(USD)
+	RealizedLoss    float64                    `json:"realized_loss"`    // 当日已实现亏损(USD)
+	UsedBudget      float64                    `json:"used_budget"`      // 已用预算(USD)
+	RemainingBudget float64                    `json:"remaining_budget"` // 剩余预算(USD)
+	Enforced        bool                       `json:"enforced"`         // 预算不足时是否拒绝开仓
+	Entries         []decision.RiskBudgetEntry `json:"entries"`          // 占用明细
+}
+
+// riskBudgetConfig 日风险预算配置（全局配置未初始化时使用默认值）
+func riskBudgetConfig() database.RiskBudgetConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetRiskBudgetConfig()
+	}
+	return database.RiskBudgetConfig{DailyBudgetPct: 5.0, Enforce: true}
+}
+
+// tradeRiskUSD 计算单笔交易的定义风险（止损距离 × 数量），没有止损时使用AI给出的risk_usd
+func tradeRiskUSD(entryPrice, stopLoss, quantity, fallback float64) float64 {
+	if entryPrice > 0 && stopLoss > 0 && quantity > 0 {
+		return math.Abs(entryPrice-stopLoss) * quantity
+	}
+	return fallback
+}
+
+// riskBudgetStatus 根据台账计算当前风险预算状态
+func (at *AutoTrader) riskBudgetStatus(totalEquity float64) (*RiskBudgetStatus, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	cfg := riskBudgetConfig()
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	status := &RiskBudgetStatus{
+		TradingDay:  dayStart.Format("2006-01-02"),
+		DailyBudget: totalEquity * cfg.DailyBudgetPct / 100,
+		Enforced:    cfg.Enforce,
+	}
+
+	entries, err := db.RiskLedger().GetOpen()
+	if err != nil {
+		return nil, fmt.Errorf("查询风险台账失败: %w", err)
+	}
+	for _, entry := range entries {
+		status.ReservedRisk += entry.RiskUSD
+		status.Entries = append(status.Entries, decision.RiskBudgetEntry{
+			Symbol:   entry.Symbol,
+			Side:     entry.Side,
+			RiskUSD:  entry.RiskUSD,
+			OpenedAt: entry.OpenedAt,
+		})
+	}
+
+	if status.RealizedLoss, err = db.Trade().GetRealizedLossSince(dayStart); err != nil {
+		return nil, fmt.Errorf("查询当日已实现亏损失败: %w", err)
+	}
+
+	status.UsedBudget = status.ReservedRisk + status.RealizedLoss
+	status.RemainingBudget = math.Max(0, status.DailyBudget-status.UsedBudget)
+	return status, nil
+}
+
+// applyRiskBudget 用风险台账填充账户的风险预算字段
+func (at *AutoTrader) applyRiskBudget(ctx *decision.Context, totalEquity float64) {
+	status, err := at.riskBudgetStatus(totalEquity)
+	if err != nil {
+		log.Printf("⚠️  计算风险预算失败: %v", err)
+		return
+	}
+	ctx.Account.DailyRiskBudget = status.DailyBudget
+	ctx.Account.UsedRiskBudget = status.UsedBudget
+	ctx.Account.RemainingRiskBudget = status.RemainingBudget
+	ctx.RiskBudgetEnforced = status.Enforced
+	ctx.RiskBudgetEntries = status.Entries
+}
+
+// checkRiskBudget 开仓前检查剩余风险预算
+func (at *AutoTrader) checkRiskBudget(d *decision.Decision, entryPrice, quantity float64) error {
+	status, err := at.riskBudgetStatus(at.lastEquity)
+	if err != nil {
+		log.Printf("  ⚠️  无法检查风险预算: %v", err)
+		return nil
+	}
+	if !status.Enforced {
+		return nil
+	}
+
+	risk := tradeRiskUSD(entryPrice, d.StopLoss, quantity, d.RiskUSD)
+	if risk > status.RemainingBudget {
+		return fmt.Errorf("❌ %s 风险预算不足：本笔风险 %.2f USDT > 剩余日预算 %.2f USDT（已用 %.2f / %.2f）",
+			d.Symbol, risk, status.RemainingBudget, status.UsedBudget, status.DailyBudget)
+	}
+	return nil
+}
+
+// consumeRiskBudget 开仓成交后占用风险预算
+func (at *AutoTrader) consumeRiskBudget(d *decision.Decision, side string, entryPrice, quantity float64) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+
+	entry := &models.RiskLedgerEntry{
+		Symbol:     d.Symbol,
+		Side:       side,
+		EntryPrice: entryPrice,
+		StopLoss:   d.StopLoss,
+		Quantity:   quantity,
+		RiskUSD:    tradeRiskUSD(entryPrice, d.StopLoss, quantity, d.RiskUSD),
+		OpenedAt:   time.Now(),
+	}
+	if err := db.RiskLedger().Consume(entry); err != nil {
+		log.Printf("  ⚠️  记录风险预算占用失败: %v", err)
+		return
+	}
+	log.Printf("  🎯 占用风险预算: %.2f USDT", entry.RiskUSD)
+}
+
+// releaseRiskBudget 持仓完全平仓后释放风险预算
+func (at *AutoTrader) releaseRiskBudget(symbol, side string) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	released, err := db.RiskLedger().Release(symbol, side)
+	if err != nil {
+		log.Printf("  ⚠️  释放风险预算失败: %v", err)
+		return
+	}
+	if released > 0 {
+		log.Printf("  🎯 释放风险预算: %s %s %.2f USDT", symbol, side, released)
+	}
+}
+
+// reconcileRiskLedger 释放交易所已不存在持仓的台账记录（如系统停机期间被平仓）
+func (at *AutoTrader) reconcileRiskLedger(currentPositionKeys map[string]bool) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return
+	}
+	entries, err := db.RiskLedger().GetOpen()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !currentPositionKeys[entry.Symbol+"_"+entry.Side] {
+			at.releaseRiskBudget(entry.Symbol, entry.Side)
+		}
+	}
+}
+
+// GetRiskBudget 获取当前风险预算状态（用于API）
+func (at *AutoTrader) GetRiskBudget() (*RiskBudgetStatus, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取余额失败: %w", err)
+	}
+	return at.riskBudgetStatus(balance.TotalEquity())
+}
@@ -0,0 +1,43 @@
+package trader
+
+import (
+	"nofx/database"
+	"nofx/decision"
+)
+
+// riskGroups 从运行时配置构建分组风控额度（关闭时返回nil）
+func riskGroups() decision.RiskGroups {
+	cfg := database.LoadRuntimeConfig().GetRiskGroupsConfig()
+	if !cfg.Enabled || len(cfg.Groups) == 0 {
+		return nil
+	}
+	groups := make(decision.RiskGroups, 0, len(cfg.Groups))
+	for _, g := range cfg.Groups {
+		groups = append(groups, decision.RiskGroup{
+			Name:           g.Name,
+			Symbols:        g.Symbols,
+			MaxPositions:   g.MaxPositions,
+			MaxNotionalUSD: g.MaxNotionalUSD,
+			MaxLeverage:    g.MaxLeverage,
+		})
+	}
+	return groups
+}
+
+// groupExposure 交易所持仓的分组额度占用（未启用分组时返回nil）
+func groupExposure(positions []Position) []decision.GroupExposure {
+	groups := riskGroups()
+	if groups == nil {
+		return nil
+	}
+	infos := make([]decision.PositionInfo, 0, len(positions))
+	for _, pos := range positions {
+		infos = append(infos, decision.PositionInfo{
+			Symbol:    pos.Symbol,
+			Side:      pos.Side,
+			Quantity:  pos.Quantity,
+			MarkPrice: pos.MarkPrice,
+		})
+	}
+	return decision.CalculateGroupExposure(infos, groups)
+}
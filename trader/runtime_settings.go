@@ -0,0 +1,83 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/mcp"
+)
+
+// RuntimeSettings 交易周期实际使用的配置（构造时已补齐默认值，和数据库中保存的原始值可能不同）
+type RuntimeSettings struct {
+	Leverage       LeverageSettings         `json:"leverage"`
+	MaxPositions   int                      `json:"max_positions"`
+	AIAutonomyMode bool                     `json:"ai_autonomy_mode"` // true=完全自主，false=限制模式
+	AILearning     AILearningSettings       `json:"ai_learning"`
+	CompactMode    bool                     `json:"compact_mode"` // 行情数据紧凑模式（每个trader独立，修改后热重载生效）
+	ScanInterval   string                   `json:"scan_interval"`
+	EntryThrottle  EntryThrottleLimits      `json:"entry_throttle"`
+	Confidence     decision.ConfidenceFloor `json:"confidence_floor"` // 决策最低信心度（0表示不限制）
+	AIParams       mcp.GenerationParams     `json:"ai_params"`        // 实际发送的AI生成参数（已补齐默认值）
+	Critic         *CriticSettings          `json:"critic,omitempty"` // 决策审核模型（nil表示不启用两阶段审核）
+}
+
+// CriticSettings 决策审核模型配置
+type CriticSettings struct {
+	AIModel   string   `json:"ai_model"`
+	ModelName string   `json:"model_name"`      // 实际使用的模型名称
+	Rules     []string `json:"rules,omitempty"` // 空=使用内置规则
+}
+
+// LeverageSettings 杠杆配置
+type LeverageSettings struct {
+	BTCETH  int `json:"btc_eth"`
+	Altcoin int `json:"altcoin"`
+}
+
+// AILearningSettings AI学习配置
+type AILearningSettings struct {
+	Enabled        bool `json:"enabled"`
+	IntervalCycles int  `json:"interval_cycles"` // 每N个周期生成一次学习总结
+}
+
+// EntryThrottleLimits 每日开仓次数上限（0表示不限制）
+type EntryThrottleLimits struct {
+	MaxPerSymbolDaily int `json:"max_per_symbol_daily"`
+	MaxTotalDaily     int `json:"max_total_daily"`
+}
+
+// GetRuntimeSettings 交易周期实际使用的配置
+func (at *AutoTrader) GetRuntimeSettings() RuntimeSettings {
+	throttle := entryThrottleConfig()
+	return RuntimeSettings{
+		Leverage: LeverageSettings{
+			BTCETH:  at.config.BTCETHLeverage,
+			Altcoin: at.config.AltcoinLeverage,
+		},
+		MaxPositions:   at.config.MaxPositions,
+		AIAutonomyMode: at.config.AIAutonomyMode,
+		AILearning: AILearningSettings{
+			Enabled:        at.enableAILearning && at.aiLearnInterval > 0,
+			IntervalCycles: at.aiLearnInterval,
+		},
+		CompactMode:  at.compactMode(),
+		ScanInterval: at.config.ScanInterval.String(),
+		EntryThrottle: EntryThrottleLimits{
+			MaxPerSymbolDaily: throttle.MaxPerSymbolDaily,
+			MaxTotalDaily:     throttle.MaxTotalDaily,
+		},
+		Confidence: at.confidenceFloor(),
+		AIParams:   at.aiClient().GenerationParams(),
+		Critic:     at.criticSettings(),
+	}
+}
+
+// criticSettings 决策审核模型配置（未启用时返回nil）
+func (at *AutoTrader) criticSettings() *CriticSettings {
+	if at.critic == nil {
+		return nil
+	}
+	return &CriticSettings{
+		AIModel:   at.config.CriticAIModel,
+		ModelName: at.critic.Client.Model,
+		Rules:     at.config.CriticRules,
+	}
+}
@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/money"
+)
+
+// SymbolFilters 交易对的下单数量和价格规则（由各交易所实现提供）
+type SymbolFilters struct {
+	StepSize     float64 // 数量步进值（0表示不限制）
+	MinQty       float64 // 最小下单数量
+	MaxQty       float64 // 最大下单数量（0表示不限制）
+	MinNotional  float64 // 最小名义价值(USDT)
+	TickSize     float64 // 价格步进值（PRICE_FILTER tickSize，0表示不限制）
+	PriceSigFigs int     // 价格最多有效数字（Hyperliquid为5，0表示不限制）
+}
+
+// PositionSize 仓位计算结果（决策金额 → 交易所下单数量）
+type PositionSize struct {
+	Price       float64 // 下单参考价
+	Quantity    float64 // 下单数量（已按交易所规则取整）
+	NotionalUSD float64 // 实际名义价值 = 数量 × 价格
+	MarginUSD   float64 // 实际保证金 = 名义价值 / 杠杆
+	Leverage    int
+	DriftPct    float64 // 分析价 → 下单价的漂移(%)
+	Adjustment  string  // 仓位调整说明（压力测试缩放）
+}
+
+// sizePosition 仓位计算器：统一把决策中的名义价值/保证金转换为交易所下单数量
+// 1. 名义价值 = notional_usd，或 margin_usd × 杠杆（见 decision.NormalizeSizing）
+// 2. 按压力测试缩放系数缩小名义价值和保证金
+// 3. 下单前价格复核（漂移超限时拒绝或按止损风险缩减）
+// 4. 按交易所步进值向下取整，检查最小数量和最小名义价值
+func (at *AutoTrader) sizePosition(d *decision.Decision, side string, fallbackPrice float64) (*PositionSize, error) {
+	d.NormalizeSizing()
+	if d.NotionalUSD <= 0 {
+		return nil, fmt.Errorf("❌ %s 仓位大小必须大于0", d.Symbol)
+	}
+	adjustment := scalePositionSize(d)
+
+	price, quantity, drift, err := at.recheckEntryPrice(d, side, fallbackPrice)
+	size := &PositionSize{Price: price, Quantity: quantity, Leverage: d.Leverage, DriftPct: drift, Adjustment: adjustment}
+	if err != nil {
+		return size, err
+	}
+
+	filters, err := at.trader.GetSymbolFilters(d.Symbol)
+	if err != nil {
+		log.Printf("  ⚠️  获取 %s 下单规则失败，仅按交易所精度下单: %v", d.Symbol, err)
+	} else if size.Quantity, err = applySymbolFilters(d.Symbol, quantity, price, filters); err != nil {
+		return size, err
+	}
+
+	size.NotionalUSD = money.Mul(size.Quantity, price)
+	if size.Leverage > 0 {
+		size.MarginUSD = money.Div(size.NotionalUSD, float64(size.Leverage))
+	}
+	log.Printf("  📐 仓位计算: 计划名义价值 %.2f USDT（保证金 %.2f × %dx）→ 数量 %.6f @ %.4f = %.2f USDT",
+		d.NotionalUSD, d.MarginUSD, d.Leverage, size.Quantity, price, size.NotionalUSD)
+	return size, nil
+}
+
+// positionMultiplier 当前的压力测试缩放系数（未配置时为1）
+func positionMultiplier() float64 {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetSizingConfig().PositionMultiplier
+	}
+	return 1
+}
+
+// scalePositionSize 按压力测试缩放系数缩小决策的名义价值和保证金，返回调整说明（未缩放时为空）
+// 止损止盈价格不变，因此单笔风险按同一比例缩小
+func scalePositionSize(d *decision.Decision) string {
+	m := positionMultiplier()
+	if m >= 1 {
+		return ""
+	}
+	note := fmt.Sprintf("压力测试缩放 ×%.2f：名义价值 %.2f → %.2f USDT", m, d.NotionalUSD, money.Mul(d.NotionalUSD, m))
+	d.NotionalUSD = money.Mul(d.NotionalUSD, m)
+	d.PositionSizeUSD = d.NotionalUSD
+	d.MarginUSD = money.Mul(d.MarginUSD, m)
+	d.RiskUSD = money.Mul(d.RiskUSD, m)
+	log.Printf("  🧪 %s %s", d.Symbol, note)
+	return note
+}
+
+// applySymbolFilters 按交易所规则调整下单数量（向下取整到步进值，不会放大仓位）
+func applySymbolFilters(symbol string, quantity, price float64, filters SymbolFilters) (float64, error) {
+	if filters.MaxQty > 0 && quantity > filters.MaxQty {
+		log.Printf("  ⚠️  %s 下单数量 %.6f 超过交易所上限，截断为 %.6f", symbol, quantity, filters.MaxQty)
+		quantity = filters.MaxQty
+	}
+	// 十进制取整，避免 0.3/0.1=2.9999 这类浮点误差被多舍掉一个步进
+	quantity = money.FloorToStep(quantity, filters.StepSize)
+	if quantity <= 0 || quantity < filters.MinQty {
+		return 0, fmt.Errorf("❌ %s 下单数量 %.6f 低于交易所最小数量 %.6f，请增大仓位", symbol, quantity, filters.MinQty)
+	}
+	if notional := money.Mul(quantity, price); filters.MinNotional > 0 && notional < filters.MinNotional {
+		return 0, fmt.Errorf("❌ %s 名义价值 %.2f USDT 低于交易所最小名义价值 %.2f USDT，请增大仓位",
+			symbol, notional, filters.MinNotional)
+	}
+	return quantity, nil
+}
+
+// sizingTargets 从运行时配置构建ATR仓位建议参数（关闭时返回nil）
+func sizingTargets() *decision.SizingTargets {
+	cfg := database.SizingConfig{PromptEnabled: true, RiskPerTradePct: 1.0, ATRStopMultiple: 1.5}
+	if rc := database.GetGlobalConfig(); rc != nil {
+		cfg = rc.GetSizingConfig()
+	}
+	if !cfg.PromptEnabled {
+		return nil
+	}
+	return &decision.SizingTargets{
+		RiskPerTradePct: cfg.RiskPerTradePct,
+		ATRStopMultiple: cfg.ATRStopMultiple,
+	}
+}
@@ -0,0 +1,312 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database/models"
+	"nofx/sharedstate"
+	"sync"
+	"time"
+)
+
+// TraderState trader运行状态
+type TraderState string
+
+const (
+	StateRunning     TraderState = "running"      // 正常交易
+	StatePaused      TraderState = "paused"       // 暂停：不收集数据、不调用AI、不下单
+	StateRiskStopped TraderState = "risk_stopped" // 风控停止：到期前照常运行交易周期，但只平仓（AI只管理现有持仓），到期自动恢复运行
+	StateCloseOnly   TraderState = "close_only"   // 只平仓：照常运行交易周期，但拒绝开仓
+	StateStopped     TraderState = "stopped"      // 已停止：主循环未运行或已退出（由Run/Stop控制，不持久化）
+)
+
+// 状态来源（生效状态由哪个条件决定）
+const (
+	StateSourceOperator    = "operator"      // 操作员或风控设置并持久化的状态
+	StateSourceLifecycle   = "lifecycle"     // 主循环未运行
+	StateSourceKillSwitch  = "kill_switch"   // 全局停止开关
+	StateSourceSharedPause = "shared_pause"  // 其他进程设置的共享暂停标记
+	StateSourceMaintenance = "maintenance"   // 维护窗口
+	StateSourceDrawdown    = "drawdown_lock" // 回撤锁
+	StateSourceDailyLoss   = "daily_loss"    // 日亏损限制
+)
+
+// stateTransitions 持久化状态之间允许的转换（相同状态之间的转换用于更新原因或到期时间，始终允许）
+// 暂停中不能直接进入风控停止（需要先恢复），stopped由主循环生命周期决定，不参与转换
+var stateTransitions = map[TraderState][]TraderState{
+	StateRunning:     {StatePaused, StateRiskStopped, StateCloseOnly},
+	StatePaused:      {StateRunning, StateCloseOnly},
+	StateRiskStopped: {StateRunning, StatePaused, StateCloseOnly},
+	StateCloseOnly:   {StateRunning, StatePaused, StateRiskStopped},
+}
+
+// canTransitionTo 是否允许从当前状态转换到目标状态
+func (s TraderState) canTransitionTo(to TraderState) bool {
+	if s == to {
+		_, known := stateTransitions[to]
+		return known
+	}
+	for _, allowed := range stateTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StateStatus 当前生效的状态
+// 生效状态按优先级合并持久化状态和临时条件：已停止 > 全局停止开关 > 暂停 > 共享暂停 > 维护窗口 > 风控停止 > 只平仓（含回撤锁、日亏损限制） > 运行
+type StateStatus struct {
+	State  TraderState `json:"state"`
+	Reason string      `json:"reason,omitempty"`
+	Source string      `json:"source"`
+	Since  time.Time   `json:"since"`           // 持久化状态的开始时间
+	Until  *time.Time  `json:"until,omitempty"` // 风控停止的到期时间
+	Stored TraderState `json:"stored_state"`    // 持久化的状态（不含维护窗口、回撤锁等临时条件）
+}
+
+// stateMachine 持久化的trader状态
+type stateMachine struct {
+	mu     sync.Mutex
+	state  TraderState
+	reason string
+	since  time.Time
+	until  time.Time
+}
+
+// restoreState 从数据库恢复状态（旧数据只有暂停标记，按暂停/运行恢复）
+func (at *AutoTrader) restoreState(saved *models.TraderState) {
+	at.lifecycle.mu.Lock()
+	defer at.lifecycle.mu.Unlock()
+
+	state := TraderState(saved.State)
+	if _, known := stateTransitions[state]; !known {
+		state = StateRunning
+		if saved.IsPaused {
+			state = StatePaused
+		}
+	}
+	at.lifecycle.state = state
+	at.lifecycle.reason = saved.Reason
+	at.lifecycle.since = saved.UpdatedAt
+	if saved.Until != nil {
+		at.lifecycle.until = *saved.Until
+	}
+	log.Printf("✓ 从数据库恢复状态: %s %s", state, saved.Reason)
+}
+
+// Transition 切换持久化状态（非法转换返回错误），until 只对风控停止有效
+func (at *AutoTrader) Transition(to TraderState, reason string, until time.Time) error {
+	at.lifecycle.mu.Lock()
+	defer at.lifecycle.mu.Unlock()
+	return at.transitionLocked(to, reason, until, at.now())
+}
+
+// transitionLocked 切换状态并持久化（调用方持有 lifecycle.mu）
+func (at *AutoTrader) transitionLocked(to TraderState, reason string, until, now time.Time) error {
+	from := at.lifecycle.state
+	if !from.canTransitionTo(to) {
+		return fmt.Errorf("不能从 %s 切换到 %s", from, to)
+	}
+	if to == StateRiskStopped && !until.After(now) {
+		return fmt.Errorf("风控停止需要指定未来的到期时间")
+	}
+	if to != StateRiskStopped {
+		until = time.Time{}
+	}
+
+	at.lifecycle.state = to
+	at.lifecycle.reason = reason
+	at.lifecycle.until = until
+	if from != to || at.lifecycle.since.IsZero() {
+		at.lifecycle.since = now
+	}
+
+	// 共享暂停标记（多进程部署时其他进程中的同一trader也暂停）
+	if (from == StatePaused) != (to == StatePaused) {
+		if err := sharedstate.SetPaused(at.id, to == StatePaused); err != nil {
+			log.Printf("[%s] ⚠️  %v", at.name, err)
+		}
+	}
+
+	if db := at.decisionLogger.GetDB(); db != nil {
+		saved := &models.TraderState{IsPaused: to == StatePaused, State: string(to), Reason: reason}
+		if !until.IsZero() {
+			saved.Until = &until
+		}
+		if err := db.SaveTraderState(saved); err != nil {
+			log.Printf("[%s] ⚠️  保存状态到数据库失败: %v", at.name, err)
+		}
+	}
+
+	if from != to && (to == StatePaused || to == StateRiskStopped) {
+		data := PauseEventData{State: to, From: from, Reason: reason}
+		if !until.IsZero() {
+			data.Until = &until
+		}
+		at.notifyWebhooks(WebhookTraderPaused, data)
+	}
+
+	if until.IsZero() {
+		log.Printf("[%s] 🔀 状态切换: %s → %s %s", at.name, from, to, reason)
+	} else {
+		log.Printf("[%s] 🔀 状态切换: %s → %s %s（至 %s）", at.name, from, to, reason, until.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// defaultRiskStopDuration 未配置停止交易时长时风控停止的默认时长
+const defaultRiskStopDuration = time.Hour
+
+// StateChange 操作员切换状态的请求
+type StateChange struct {
+	State           TraderState `json:"state"` // running / paused / risk_stopped / close_only
+	Reason          string      `json:"reason"`
+	DurationMinutes int         `json:"duration_minutes"` // 风控停止时长（0表示使用stop_trading_minutes配置，未配置时1小时）
+}
+
+// ChangeState 按操作员请求切换状态，返回切换后的生效状态
+func (at *AutoTrader) ChangeState(req StateChange) (StateStatus, error) {
+	if _, known := stateTransitions[req.State]; !known {
+		return at.State(), fmt.Errorf("未知状态 %q（可选 running/paused/risk_stopped/close_only）", req.State)
+	}
+	var until time.Time
+	if req.State == StateRiskStopped {
+		duration := time.Duration(req.DurationMinutes) * time.Minute
+		if duration <= 0 {
+			duration = at.config.StopTradingTime
+		}
+		if duration <= 0 {
+			duration = defaultRiskStopDuration
+		}
+		until = at.now().Add(duration)
+	}
+	reason := req.Reason
+	if reason == "" && req.State != StateRunning {
+		reason = "人工设置"
+	}
+	err := at.Transition(req.State, reason, until)
+	return at.State(), err
+}
+
+// RiskStop 风控停止一段时间（到期自动恢复运行）
+func (at *AutoTrader) RiskStop(duration time.Duration, reason string) error {
+	return at.Transition(StateRiskStopped, reason, at.now().Add(duration))
+}
+
+// State 当前生效的状态（风控停止到期时自动恢复运行）
+func (at *AutoTrader) State() StateStatus {
+	now := at.now()
+
+	at.lifecycle.mu.Lock()
+	if at.lifecycle.state == StateRiskStopped && !now.Before(at.lifecycle.until) {
+		if err := at.transitionLocked(StateRunning, "风控停止到期", time.Time{}, now); err != nil {
+			log.Printf("[%s] ⚠️  风控停止到期恢复失败: %v", at.name, err)
+		}
+	}
+	status := StateStatus{
+		State:  at.lifecycle.state,
+		Reason: at.lifecycle.reason,
+		Source: StateSourceOperator,
+		Since:  at.lifecycle.since,
+		Stored: at.lifecycle.state,
+	}
+	if status.State == StateRiskStopped {
+		until := at.lifecycle.until
+		status.Until = &until
+	}
+	at.lifecycle.mu.Unlock()
+
+	at.mu.RLock()
+	maintenance := at.maintenanceWindow
+	at.mu.RUnlock()
+
+	override := func(state TraderState, source, reason string) {
+		status.State, status.Source, status.Reason, status.Until = state, source, reason, nil
+	}
+	switch {
+	case !at.isRunning:
+		override(StateStopped, StateSourceLifecycle, "主循环未运行")
+	case sharedstate.KillSwitchActive():
+		override(StatePaused, StateSourceKillSwitch, "全局停止开关已开启")
+	case status.Stored == StatePaused:
+	case sharedstate.IsPaused(at.id):
+		override(StatePaused, StateSourceSharedPause, "其他进程设置了共享暂停标记")
+	case maintenance != "":
+		override(StatePaused, StateSourceMaintenance, "维护窗口 "+maintenance)
+	case status.Stored == StateRiskStopped:
+	default:
+		if reason := at.drawdownLockReason(); reason != "" {
+			override(StateCloseOnly, StateSourceDrawdown, reason)
+		} else if reason := at.dailyLossReason(); reason != "" {
+			override(StateCloseOnly, StateSourceDailyLoss, reason)
+		}
+	}
+	return status
+}
+
+// cycleGate 交易周期入口的唯一状态检查（返回false时跳过本周期：不收集数据、不调用AI、不记录日志、不增加callCount）
+// 只平仓和风控停止照常执行（AI只管理现有持仓，由决策验证和开仓检查拒绝开仓）
+func (at *AutoTrader) cycleGate() (StateStatus, bool) {
+	status := at.State()
+	switch status.State {
+	case StateStopped:
+		return status, false
+	case StatePaused:
+		log.Printf("[%s] ⏸️  Trader已暂停（%s），跳过本次交易循环", at.name, status.Reason)
+		return status, false
+	}
+	return status, true
+}
+
+// closeOnlyReason 只平仓模式的原因（为空表示允许开仓），风控停止期间同样只平仓
+func (at *AutoTrader) closeOnlyReason() string {
+	status := at.State()
+	switch status.State {
+	case StateCloseOnly:
+		return status.Reason
+	case StateRiskStopped:
+		return fmt.Sprintf("风控停止（%s），%s前只平仓", status.Reason, status.Until.Format("01-02 15:04"))
+	}
+	return ""
+}
+
+// checkCloseOnlyOpen 只平仓模式（含风控停止）下拒绝开仓
+func (at *AutoTrader) checkCloseOnlyOpen() error {
+	status := at.State()
+	switch {
+	case status.State == StateRiskStopped:
+		return fmt.Errorf("⛔ 风控停止中（只平仓模式）: %s，%s 后才能开仓", status.Reason, status.Until.Format("2006-01-02 15:04:05"))
+	case status.State != StateCloseOnly:
+		return nil
+	case status.Source == StateSourceDrawdown:
+		return fmt.Errorf("⛔ 回撤锁生效中（只平仓模式）: %s，需人工解除后才能开仓", status.Reason)
+	case status.Source == StateSourceDailyLoss:
+		return fmt.Errorf("⛔ 日亏损限制生效中（只平仓模式）: %s，日盈亏重置后才能开仓", status.Reason)
+	}
+	return fmt.Errorf("⛔ 只平仓模式: %s，恢复运行后才能开仓", status.Reason)
+}
+
+// Pause 暂停trader
+func (at *AutoTrader) Pause() {
+	if err := at.Transition(StatePaused, "人工暂停", time.Time{}); err != nil {
+		log.Printf("[%s] ⚠️  暂停失败: %v", at.name, err)
+		return
+	}
+	log.Printf("[%s] ⏸️  Trader已暂停", at.name)
+}
+
+// Resume 恢复trader
+func (at *AutoTrader) Resume() {
+	if err := at.Transition(StateRunning, "", time.Time{}); err != nil {
+		log.Printf("[%s] ⚠️  恢复失败: %v", at.name, err)
+		return
+	}
+	log.Printf("[%s] ▶️  Trader已恢复", at.name)
+}
+
+// IsPaused 检查是否暂停（人工暂停、全局停止开关、共享暂停标记或处于维护窗口；主循环未运行时按持久化状态判断）
+func (at *AutoTrader) IsPaused() bool {
+	status := at.State()
+	return status.State == StatePaused || status.Stored == StatePaused
+}
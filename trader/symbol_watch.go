@@ -0,0 +1,80 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/decision"
+	"nofx/i18n"
+	"nofx/market"
+	"strings"
+)
+
+// filterTradableCandidates 过滤非TRADING状态或即将下架/交割的候选币种
+// exchangeInfo 获取失败时不过滤（避免因为接口抖动清空候选池）
+func filterTradableCandidates(coins []decision.CandidateCoin) []decision.CandidateCoin {
+	statuses, err := market.GetSymbolStatuses()
+	if err != nil {
+		log.Printf("⚠️  获取交易对状态失败，跳过下架过滤: %v", err)
+		return coins
+	}
+
+	var tradable []decision.CandidateCoin
+	var excluded []string
+	for _, coin := range coins {
+		status, ok := statuses[coin.Symbol]
+		if ok && (!status.IsTrading() || status.DelistingWithin(market.DelistingAlertWindow)) {
+			excluded = append(excluded, fmt.Sprintf("%s(%s)", coin.Symbol, describeSymbolStatus(status, i18n.Default)))
+			continue
+		}
+		tradable = append(tradable, coin)
+	}
+
+	if len(excluded) > 0 {
+		log.Printf("🚫 排除%d个非正常交易状态的候选币种: %s", len(excluded), strings.Join(excluded, ", "))
+	}
+	return tradable
+}
+
+// checkSymbolTradable 开仓前检查交易对状态（拒绝非TRADING或即将下架的币种）
+func checkSymbolTradable(symbol string) error {
+	status, ok := market.GetSymbolStatus(symbol)
+	if !ok {
+		return nil
+	}
+	if !status.IsTrading() || status.DelistingWithin(market.DelistingAlertWindow) {
+		return fmt.Errorf("❌ %s 当前%s，拒绝开仓", symbol, describeSymbolStatus(status, i18n.Default))
+	}
+	return nil
+}
+
+// symbolStatusAlerts 检查持仓币种的交易所状态，返回需要提醒的预警信息
+func symbolStatusAlerts(positions []decision.PositionInfo, lang i18n.Lang) []string {
+	if len(positions) == 0 {
+		return nil
+	}
+	statuses, err := market.GetSymbolStatuses()
+	if err != nil {
+		return nil
+	}
+
+	var alerts []string
+	for _, pos := range positions {
+		status, ok := statuses[pos.Symbol]
+		if !ok || (status.IsTrading() && !status.DelistingWithin(market.DelistingAlertWindow)) {
+			continue
+		}
+		alert := i18n.T(lang, "user.symbol_alert",
+			pos.Symbol, strings.ToUpper(pos.Side), describeSymbolStatus(status, lang))
+		log.Printf("🚨 %s", alert)
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// describeSymbolStatus 交易对状态描述
+func describeSymbolStatus(status *market.SymbolStatus, lang i18n.Lang) string {
+	if !status.IsTrading() {
+		return i18n.T(lang, "user.symbol_not_trading", status.Status)
+	}
+	return i18n.T(lang, "user.symbol_delisting", status.DeliveryDate.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,24 @@
+package trader
+
+import (
+	"log"
+	"nofx/analytics"
+	"nofx/database"
+)
+
+// timeOfDayReport 历史开仓时段表现（未启用或没有明显的弱势/强势时段时返回nil，prompt中不显示）
+func (at *AutoTrader) timeOfDayReport() *analytics.TimeOfDayReport {
+	cfg := database.CurrentLearningConfig()
+	if !cfg.TimeOfDayPromptEnabled || cfg.TimeOfDayLookbackTrades <= 0 {
+		return nil
+	}
+	report, err := at.decisionLogger.AnalyzeTimeOfDay(cfg.TimeOfDayLookbackTrades, cfg.TimeOfDayMinTrades)
+	if err != nil {
+		log.Printf("⚠️  [%s] 分析开仓时段表现失败: %v", at.name, err)
+		return nil
+	}
+	if len(report.Weak) == 0 && len(report.Strong) == 0 {
+		return nil
+	}
+	return report
+}
@@ -0,0 +1,222 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/database/models"
+	"nofx/logger"
+	"nofx/money"
+	"sort"
+	"time"
+)
+
+// defaultImportFillLimit 每个币种拉取的历史成交条数（Binance单次上限1000）
+const defaultImportFillLimit = 500
+
+// TradeImportReport 历史成交导入结果
+type TradeImportReport struct {
+	Symbols    []string  `json:"symbols"`
+	Fills      int       `json:"fills"`      // 拉取的成交条数
+	Imported   int       `json:"imported"`   // 新导入的交易
+	Duplicates int       `json:"duplicates"` // 已导入过的交易
+	AfterBot   int       `json:"after_bot"`  // 平仓时间晚于本系统首笔交易的配对结果（已由系统记录，跳过）
+	Unmatched  int       `json:"unmatched"`  // 找不到开仓成交的平仓成交（历史成交被截断）
+	OpenLegs   int       `json:"open_legs"`  // 尚未平仓的持仓（不导入）
+	Cutoff     time.Time `json:"cutoff,omitempty"`
+	Errors     []string  `json:"errors,omitempty"`
+}
+
+// importLeg 从成交配对出的一笔完整交易（开仓到仓位归零）
+type importLeg struct {
+	side          string // long / short
+	remaining     float64
+	openQty       float64
+	openNotional  float64
+	closeQty      float64
+	closeNotional float64
+	realizedPnL   float64
+	openTime      int64
+	closeTime     int64
+}
+
+// pairFills 按时间顺序把成交配对成完整交易（尽力而为）
+// 双向持仓按 positionSide 分别累计；单向持仓（BOTH）按净仓位累计，穿越0时拆分为平仓+反向开仓
+// 返回已平仓的交易、找不到开仓的平仓成交数和仍未平仓的持仓数
+func pairFills(fills []Fill) (legs []importLeg, unmatched, open int) {
+	sorted := append([]Fill(nil), fills...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Time != sorted[j].Time {
+			return sorted[i].Time < sorted[j].Time
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	active := make(map[string]*importLeg) // key: LONG / SHORT / BOTH
+	for _, f := range sorted {
+		if f.Quantity <= 0 || f.Price <= 0 {
+			continue
+		}
+		key := f.PositionSide
+		if key == "" {
+			key = "BOTH"
+		}
+
+		// 成交方向对应的开仓方向
+		side := "long"
+		if f.Side == "SELL" {
+			side = "short"
+		}
+		opening := false
+		switch key {
+		case "LONG":
+			opening = f.Side == "BUY"
+		case "SHORT":
+			opening = f.Side == "SELL"
+		default:
+			leg := active[key]
+			opening = leg == nil || leg.side == side
+		}
+
+		qty := f.Quantity
+		leg := active[key]
+		if !opening {
+			if leg == nil {
+				unmatched++
+				continue
+			}
+			closed := math.Min(qty, leg.remaining)
+			leg.remaining -= closed
+			leg.closeQty += closed
+			leg.closeNotional += closed * f.Price
+			leg.realizedPnL += f.RealizedPnL
+			leg.closeTime = f.Time
+			if leg.remaining <= leg.openQty*1e-9 {
+				legs = append(legs, *leg)
+				delete(active, key)
+			}
+			qty -= closed
+			// 单向持仓穿越0：剩余数量按反向开仓处理
+			if qty <= f.Quantity*1e-9 || key != "BOTH" {
+				continue
+			}
+			leg = nil
+		}
+
+		if leg == nil {
+			leg = &importLeg{side: side, openTime: f.Time}
+			active[key] = leg
+		}
+		leg.remaining += qty
+		leg.openQty += qty
+		leg.openNotional += qty * f.Price
+	}
+	return legs, unmatched, len(active)
+}
+
+// ImportExchangeTrades 从交易所历史成交导入交易结果，为表现分析和AI学习提供初始样本
+// 只导入在本系统首笔交易开仓之前平仓的交易（之后的交易已由系统自己记录），重复导入会按开平仓时间去重
+func (at *AutoTrader) ImportExchangeTrades(symbols []string, limit int) (*TradeImportReport, error) {
+	db := at.decisionLogger.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接不可用")
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("未指定导入的币种")
+	}
+	if limit <= 0 {
+		limit = defaultImportFillLimit
+	}
+
+	cutoff, err := db.Trade().GetFirstBotOpenTime()
+	if err != nil {
+		return nil, fmt.Errorf("查询首笔交易时间失败: %w", err)
+	}
+	report := &TradeImportReport{Symbols: symbols, Cutoff: cutoff}
+
+	for _, symbol := range symbols {
+		fills, err := at.trader.GetAccountTrades(symbol, limit)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", symbol, err))
+			continue
+		}
+		report.Fills += len(fills)
+
+		legs, unmatched, open := pairFills(fills)
+		report.Unmatched += unmatched
+		report.OpenLegs += open
+		leverage := at.positionLeverage(Position{Symbol: symbol})
+
+		for _, leg := range legs {
+			trade := importedTradeOutcome(symbol, leg, leverage)
+			if !cutoff.IsZero() && !trade.CloseTime.Before(cutoff) {
+				report.AfterBot++
+				continue
+			}
+			exists, err := db.Trade().ExistsImported(symbol, trade.Side, trade.OpenTime, trade.CloseTime)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: 检查重复失败: %v", symbol, err))
+				continue
+			}
+			if exists {
+				report.Duplicates++
+				continue
+			}
+			if err := at.decisionLogger.SaveTradeOutcome(trade); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: 保存失败: %v", symbol, err))
+				continue
+			}
+			report.Imported++
+		}
+	}
+
+	log.Printf("📥 [%s] 历史成交导入完成: %d个币种 %d条成交 → 导入%d笔, 重复%d笔, 系统已记录%d笔, 未配对%d条, 未平仓%d个",
+		at.name, len(symbols), report.Fills, report.Imported, report.Duplicates, report.AfterBot, report.Unmatched, report.OpenLegs)
+	return report, nil
+}
+
+// importedTradeOutcome 把配对的成交转换为交易结果（杠杆使用交易所当前设置，历史杠杆无法从成交中获取）
+func importedTradeOutcome(symbol string, leg importLeg, leverage int) *logger.TradeOutcome {
+	openPrice := money.Div(leg.openNotional, leg.openQty)
+	closePrice := money.Div(leg.closeNotional, leg.closeQty)
+	pnl := leg.realizedPnL
+	if pnl == 0 {
+		pnl = tradePnL(symbol, leg.side, openPrice, closePrice, leg.closeQty)
+	}
+	positionValue := money.Mul(leg.openQty, openPrice)
+	marginUsed := money.Div(positionValue, float64(leverage))
+
+	openTime := time.UnixMilli(leg.openTime)
+	closeTime := time.UnixMilli(leg.closeTime)
+	durationMinutes := int64(closeTime.Sub(openTime).Minutes())
+	isPremature := durationMinutes < 30
+	failureType := ""
+	if pnl < 0 {
+		if isPremature {
+			failureType = "过早平仓（<30分钟）+ 亏损"
+		} else {
+			failureType = "信号判断错误或止损设置不当"
+		}
+	}
+
+	return &logger.TradeOutcome{
+		Symbol:          symbol,
+		Side:            leg.side,
+		Quantity:        leg.openQty,
+		Leverage:        leverage,
+		OpenPrice:       openPrice,
+		ClosePrice:      closePrice,
+		PositionValue:   positionValue,
+		MarginUsed:      marginUsed,
+		PnL:             pnl,
+		PnLPct:          money.Pct(pnl, marginUsed),
+		DurationMinutes: durationMinutes,
+		OpenTime:        openTime,
+		CloseTime:       closeTime,
+		EntryReason:     "交易所历史成交导入",
+		ExitReason:      "历史成交导入",
+		IsPremature:     isPremature,
+		FailureType:     failureType,
+		Source:          models.TradeSourceImport,
+	}
+}
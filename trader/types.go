@@ -0,0 +1,119 @@
+package trader
+
+// 各交易所实现负责把原始响应转换为以下结构，调用方不再做类型断言
+
+// Balance 账户余额(USDT)
+// 总额字段为各保证金资产折算后的合计（稳定币1:1），Assets 保留各资产的原始余额
+type Balance struct {
+	TotalWalletBalance    float64                 // 钱包余额（不含未实现盈亏）
+	AvailableBalance      float64                 // 可用余额
+	TotalUnrealizedProfit float64                 // 未实现盈亏
+	Assets                map[string]AssetBalance // 各保证金资产余额（USDT/USDC/币本位资产），以资产本身计价
+}
+
+// AssetBalance 单个保证金资产的余额（以该资产计价）
+type AssetBalance struct {
+	Asset            string
+	WalletBalance    float64
+	AvailableBalance float64
+	UnrealizedProfit float64
+}
+
+// TotalEquity 账户净值 = 钱包余额 + 未实现盈亏
+func (b *Balance) TotalEquity() float64 {
+	return b.TotalWalletBalance + b.TotalUnrealizedProfit
+}
+
+// Position 持仓
+type Position struct {
+	Symbol           string
+	Side             string  // "long" 或 "short"
+	Quantity         float64 // 持仓数量（始终为正数，方向见Side）
+	EntryPrice       float64
+	MarkPrice        float64
+	UnrealizedProfit float64
+	LiquidationPrice float64
+	Leverage         int     // 0表示交易所未返回
+	MarginMode       string  // cross / isolated（空表示交易所未返回）
+	IsolatedMargin   float64 // 逐仓保证金（含未实现盈亏，全仓持仓为0）
+}
+
+// Order 下单结果或订单状态
+type Order struct {
+	OrderID     int64
+	Symbol      string
+	Status      string  // NEW / PARTIALLY_FILLED / FILLED / CANCELED / EXPIRED / REJECTED
+	OrigQty     float64 // 下单数量
+	ExecutedQty float64 // 已成交数量
+	AvgPrice    float64 // 成交均价（0表示未知）
+	Type        string  // 交易所原始订单类型（如 STOP_MARKET / TAKE_PROFIT_MARKET / LIMIT，查询订单状态时返回）
+	HasFill     bool    // 下单结果已包含最终成交信息（如IOC单），无需再查询订单状态
+}
+
+// Fill 账户成交记录
+type Fill struct {
+	ID              int64
+	OrderID         int64
+	Symbol          string
+	Side            string // BUY / SELL
+	PositionSide    string // LONG / SHORT / BOTH
+	Price           float64
+	Quantity        float64
+	QuoteQuantity   float64
+	Commission      float64
+	CommissionAsset string
+	RealizedPnL     float64
+	Time            int64 // 成交时间（毫秒）
+	Buyer           bool
+	Maker           bool
+}
+
+// 挂单类型（各交易所订单类型归一后的分类）
+const (
+	OpenOrderStopLoss   = "stop_loss"   // 止损条件单
+	OpenOrderTakeProfit = "take_profit" // 止盈条件单
+	OpenOrderLimit      = "limit"       // 普通限价单
+	OpenOrderOther      = "other"       // 其他类型（如追踪止损）
+)
+
+// OpenOrder 未成交的挂单（含止损止盈条件单）
+type OpenOrder struct {
+	OrderID      int64
+	Symbol       string
+	Type         string  // stop_loss / take_profit / limit / other
+	Side         string  // BUY / SELL
+	PositionSide string  // LONG / SHORT / BOTH
+	TriggerPrice float64 // 触发价（条件单）
+	Price        float64 // 限价（市价触发的条件单为0）
+	Quantity     float64 // 剩余数量（0表示触发后平掉全部持仓）
+	ReduceOnly   bool
+}
+
+// Transfer 合约账户资金划转（入金为正、出金为负）
+type Transfer struct {
+	ID     string
+	Asset  string
+	Amount float64
+	Time   int64 // 划转时间（毫秒）
+}
+
+// Income 合约账户收入流水（已实现盈亏、资金费、手续费等，金额带符号）
+type Income struct {
+	ID     string
+	Type   string // REALIZED_PNL / FUNDING_FEE / COMMISSION / INSURANCE_CLEAR / ...
+	Symbol string
+	Asset  string
+	Amount float64
+	Time   int64 // 入账时间（毫秒）
+}
+
+// defaultPositionLeverage 交易所未返回杠杆时估算保证金使用的默认值
+const defaultPositionLeverage = 10
+
+// EffectiveLeverage 持仓杠杆（交易所未返回时使用默认值）
+func (p Position) EffectiveLeverage() int {
+	if p.Leverage <= 0 {
+		return defaultPositionLeverage
+	}
+	return p.Leverage
+}
@@ -0,0 +1,223 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/database"
+	"nofx/monitoring"
+	"strings"
+	"time"
+)
+
+// 保护单（止损/止盈）在交易所的实现方式
+const (
+	ProtectionMarketTrigger = "market_trigger" // 触发后市价成交的条件单（STOP_MARKET/TAKE_PROFIT_MARKET、Hyperliquid市价trigger单）
+	ProtectionLimitTrigger  = "limit_trigger"  // 触发后限价成交的条件单（限价按让价比例设置，极端行情可能不成交）
+	ProtectionLocalGuard    = "local_guard"    // 交易所无法建立止损，由周期间止损监控越过止损后市价平仓
+)
+
+// 保护单下单后确认挂单的重试次数和间隔（交易所挂单列表可能有短暂延迟）
+const (
+	protectionVerifyAttempts = 3
+	protectionVerifyDelay    = 500 * time.Millisecond
+)
+
+// VenueCapabilities 交易所驱动支持的保护单类型
+type VenueCapabilities struct {
+	Exchange         string   `json:"exchange"`
+	StopMarket       bool     `json:"stop_market"`        // 市价止损条件单
+	TakeProfitMarket bool     `json:"take_profit_market"` // 市价止盈条件单
+	TriggerLimit     bool     `json:"trigger_limit"`      // 限价条件单（驱动实现了 triggerLimitPlacer 才可用）
+	NativeOCO        bool     `json:"native_oco"`         // 交易所端OCO
+	VerifyPlacement  bool     `json:"verify_placement"`   // 下单返回成功后查询挂单确认已生效（下单接口可能静默忽略条件单）
+	StopLossChain    []string `json:"stop_loss_chain"`    // 止损依次尝试的方式
+	TakeProfitChain  []string `json:"take_profit_chain"`  // 止盈依次尝试的方式
+}
+
+// venueCapabilityMatrix 各交易所驱动的内置保护单能力（可通过 venue_capabilities 配置覆盖）
+// 币安：STOP_MARKET/TAKE_PROFIT_MARKET 稳定可用，下单成功即生效
+// Aster：接口与币安一致，但条件单偶发下单成功却不出现在挂单中，需要确认，失败时改挂 STOP/TAKE_PROFIT 限价条件单
+// Hyperliquid：市价trigger单对应 STOP_MARKET，下单状态可能返回成功但未挂上，需要确认，失败时改挂限价trigger单
+var venueCapabilityMatrix = map[string]VenueCapabilities{
+	"binance":     {StopMarket: true, TakeProfitMarket: true},
+	"aster":       {StopMarket: true, TakeProfitMarket: true, TriggerLimit: true, VerifyPlacement: true},
+	"hyperliquid": {StopMarket: true, TakeProfitMarket: true, TriggerLimit: true, VerifyPlacement: true},
+}
+
+// triggerLimitPlacer 支持限价条件单的交易器（可选能力，按驱动检测）
+// kind 为 OpenOrderStopLoss / OpenOrderTakeProfit，价格到达 triggerPrice 后按 limitPrice 挂只减仓限价单
+type triggerLimitPlacer interface {
+	SetTriggerLimitExit(symbol, positionSide, kind string, quantity, triggerPrice, limitPrice float64) error
+}
+
+// venueProtectionConfig 保护单下单配置
+func venueProtectionConfig() database.VenueProtectionConfig {
+	if rc := database.GetGlobalConfig(); rc != nil {
+		return rc.GetVenueProtectionConfig()
+	}
+	return database.VenueProtectionConfig{TriggerLimitSlippagePct: 1.0}
+}
+
+// resolveVenueCapabilities 内置矩阵合并配置覆盖后的交易所能力（未知交易所按全部不支持处理，止损只能依靠本地监控）
+func resolveVenueCapabilities(exchange string, overrides []database.VenueCapabilityOverride) VenueCapabilities {
+	caps := venueCapabilityMatrix[exchange]
+	caps.Exchange = exchange
+	for _, o := range overrides {
+		if !strings.EqualFold(o.Exchange, exchange) {
+			continue
+		}
+		if o.StopMarket != nil {
+			caps.StopMarket = *o.StopMarket
+		}
+		if o.TakeProfitMarket != nil {
+			caps.TakeProfitMarket = *o.TakeProfitMarket
+		}
+		if o.TriggerLimit != nil {
+			caps.TriggerLimit = *o.TriggerLimit
+		}
+		if o.VerifyPlacement != nil {
+			caps.VerifyPlacement = *o.VerifyPlacement
+		}
+	}
+	return caps
+}
+
+// VenueCapabilities 当前交易所的保护单能力（已按驱动实际实现的可选接口修正）
+func (at *AutoTrader) VenueCapabilities() VenueCapabilities {
+	caps := resolveVenueCapabilities(at.exchange, venueProtectionConfig().Overrides)
+	if _, ok := baseTrader(at.trader).(triggerLimitPlacer); !ok {
+		caps.TriggerLimit = false
+	}
+	_, caps.NativeOCO = at.ocoPlacer()
+	caps.StopLossChain = caps.protectionChain(OpenOrderStopLoss, positionDriftConfig().Enabled)
+	caps.TakeProfitChain = caps.protectionChain(OpenOrderTakeProfit, false)
+	return caps
+}
+
+// protectionChain 该类保护单依次尝试的方式（止损在交易所全部失败时可由本地监控兜底）
+func (c VenueCapabilities) protectionChain(kind string, localGuard bool) []string {
+	chain := []string{}
+	if (kind == OpenOrderStopLoss && c.StopMarket) || (kind == OpenOrderTakeProfit && c.TakeProfitMarket) {
+		chain = append(chain, ProtectionMarketTrigger)
+	}
+	if c.TriggerLimit {
+		chain = append(chain, ProtectionLimitTrigger)
+	}
+	if kind == OpenOrderStopLoss && localGuard {
+		chain = append(chain, ProtectionLocalGuard)
+	}
+	return chain
+}
+
+// triggerLimitPrice 限价条件单的限价：按让价比例放宽，保证触发后能以略差的价格成交
+// 平多（卖出）限价低于触发价，平空（买入）限价高于触发价
+func triggerLimitPrice(positionSide string, triggerPrice, slippagePct float64) float64 {
+	if slippagePct <= 0 {
+		return triggerPrice
+	}
+	if strings.EqualFold(positionSide, "LONG") {
+		return triggerPrice * (1 - slippagePct/100)
+	}
+	return triggerPrice * (1 + slippagePct/100)
+}
+
+// placeProtectiveOrder 按交易所能力依次尝试挂止损/止盈（kind 为 OpenOrderStopLoss / OpenOrderTakeProfit）
+// 价格先按交易对价格规则和持仓方向取整
+// 交易所上没有建立保护单时返回错误（止损即使由本地监控兜底也返回错误，调用方需要预警）
+func (at *AutoTrader) placeProtectiveOrder(symbol, positionSide, kind string, quantity, price float64) error {
+	caps := at.VenueCapabilities()
+	name := exitOrderName(kind)
+	round := at.exitPriceRounder(symbol, positionSide)
+	price = round(price)
+	var failures []string
+
+	if (kind == OpenOrderStopLoss && caps.StopMarket) || (kind == OpenOrderTakeProfit && caps.TakeProfitMarket) {
+		var err error
+		if kind == OpenOrderStopLoss {
+			err = at.trader.SetStopLoss(symbol, positionSide, quantity, price)
+		} else {
+			err = at.trader.SetTakeProfit(symbol, positionSide, quantity, price)
+		}
+		if err == nil {
+			err = at.verifyProtectiveOrder(caps, symbol, positionSide, kind)
+		}
+		if err == nil {
+			return nil
+		}
+		failures = append(failures, fmt.Sprintf("市价条件单: %v", err))
+		log.Printf("  ⚠️  %s %s 市价%s条件单未建立: %v", symbol, positionSide, name, err)
+	}
+
+	if caps.TriggerLimit {
+		if placer, ok := baseTrader(at.trader).(triggerLimitPlacer); ok {
+			limitPrice := round(triggerLimitPrice(positionSide, price, venueProtectionConfig().TriggerLimitSlippagePct))
+			err := placer.SetTriggerLimitExit(symbol, positionSide, kind, quantity, price, limitPrice)
+			if err == nil {
+				err = at.verifyProtectiveOrder(caps, symbol, positionSide, kind)
+			}
+			if err == nil {
+				log.Printf("  🔁 %s %s %s改用限价条件单: 触发%.4f 限价%.4f", symbol, positionSide, name, price, limitPrice)
+				return nil
+			}
+			failures = append(failures, fmt.Sprintf("限价条件单: %v", err))
+			log.Printf("  ⚠️  %s %s 限价%s条件单未建立: %v", symbol, positionSide, name, err)
+		}
+	}
+
+	if len(failures) == 0 {
+		failures = append(failures, fmt.Sprintf("%s不支持%s条件单", caps.Exchange, name))
+	}
+	return fmt.Errorf("交易所%s单未建立（%s）", name, strings.Join(failures, "；"))
+}
+
+// verifyProtectiveOrder 交易所需要确认时查询挂单，确认该持仓方向已有对应的条件单
+func (at *AutoTrader) verifyProtectiveOrder(caps VenueCapabilities, symbol, positionSide, kind string) error {
+	if !caps.VerifyPlacement {
+		return nil
+	}
+	side := strings.ToLower(positionSide)
+	var lastErr error
+	for attempt := 0; attempt < protectionVerifyAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(protectionVerifyDelay)
+		}
+		orders, err := at.trader.GetOpenOrders(symbol)
+		if err != nil {
+			lastErr = fmt.Errorf("下单成功但查询挂单失败: %w", err)
+			continue
+		}
+		for _, o := range positionOrders(orders, side) {
+			if o.Type == kind {
+				return nil
+			}
+		}
+		lastErr = fmt.Errorf("下单返回成功但挂单中未找到%s单", exitOrderName(kind))
+	}
+	return lastErr
+}
+
+// alertProtectionFailure 保护单无法在交易所建立时发出预警（止损为危险级别，止盈为警告级别）
+func (at *AutoTrader) alertProtectionFailure(symbol, positionSide, kind string, err error) {
+	level := monitoring.AlertLevelWarning
+	title := fmt.Sprintf("止盈单未建立：%s", symbol)
+	message := fmt.Sprintf("%s %s %v，止盈需由AI决策平仓", symbol, positionSide, err)
+	if kind == OpenOrderStopLoss {
+		level = monitoring.AlertLevelCritical
+		title = fmt.Sprintf("止损单未建立：%s 无交易所止损保护", symbol)
+		if positionDriftConfig().Enabled {
+			message = fmt.Sprintf("%s %s %v，当前仅由周期间止损监控兜底（越过止损后市价平仓）", symbol, positionSide, err)
+		} else {
+			message = fmt.Sprintf("%s %s %v，周期间止损监控未启用，持仓没有任何止损保护", symbol, positionSide, err)
+		}
+	}
+	log.Printf("🚨 [%s] %s", at.name, message)
+	if at.monitor != nil {
+		at.monitor.RaiseAlert(monitoring.Alert{
+			ID:      fmt.Sprintf("protection_%s_%s_%s_%s_%d", at.id, symbol, strings.ToLower(positionSide), kind, time.Now().Unix()),
+			Type:    monitoring.AlertTypeRisk,
+			Level:   level,
+			Title:   title,
+			Message: message,
+		})
+	}
+}
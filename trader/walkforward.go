@@ -0,0 +1,30 @@
+package trader
+
+import "nofx/experiment"
+
+// WalkForwardBaseline 当前配置对应的walk-forward基准（system prompt按当前数据库中的模板构建）
+func (at *AutoTrader) WalkForwardBaseline() experiment.Variant {
+	return experiment.Variant{
+		Name:            "baseline",
+		Language:        string(at.PromptLanguage()),
+		AIAutonomyMode:  at.config.AIAutonomyMode,
+		BTCETHLeverage:  at.config.BTCETHLeverage,
+		AltcoinLeverage: at.config.AltcoinLeverage,
+		MaxPositions:    at.config.MaxPositions,
+	}
+}
+
+// StartWalkForward 用历史K线回放对比当前配置与提议的改动（异步执行，返回任务快照）
+// 回放使用该trader的AI模型和prompt模板，不访问交易所、不下单
+func (at *AutoTrader) StartWalkForward(cfg experiment.WalkForwardConfig, change experiment.VariantChange) (experiment.Job, error) {
+	baseline := at.WalkForwardBaseline()
+	candidate, err := baseline.Apply(change)
+	if err != nil {
+		return experiment.Job{}, err
+	}
+	env := experiment.Env{
+		Call:           at.aiClient().CallWithMessages,
+		DecisionLogger: at.decisionLogger,
+	}
+	return experiment.SubmitWalkForward(at.id, cfg, baseline, candidate, env)
+}
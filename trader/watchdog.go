@@ -0,0 +1,57 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"nofx/monitoring"
+	"time"
+)
+
+// markCycleHeartbeat 交易循环完成一次周期（或因暂停跳过）后更新心跳
+func (at *AutoTrader) markCycleHeartbeat() {
+	at.mu.Lock()
+	recovered := at.stallAlerted
+	at.lastHeartbeat = time.Now()
+	at.stallAlerted = false
+	at.mu.Unlock()
+
+	if recovered {
+		log.Printf("[%s] ✅ 交易循环已恢复", at.name)
+	}
+}
+
+// CheckCycleStall 检查交易循环是否卡死（超过 multiplier × 扫描间隔 没有心跳）
+// 首次检测到卡死时发出危险预警并返回true，同一次卡死不重复预警
+func (at *AutoTrader) CheckCycleStall(now time.Time, multiplier float64) bool {
+	if !at.isRunning || multiplier <= 0 {
+		return false
+	}
+	threshold := time.Duration(multiplier * float64(at.config.ScanInterval))
+
+	at.mu.Lock()
+	if at.lastHeartbeat.IsZero() || at.stallAlerted || now.Sub(at.lastHeartbeat) <= threshold {
+		at.mu.Unlock()
+		return false
+	}
+	at.stallAlerted = true
+	silence := now.Sub(at.lastHeartbeat)
+	at.mu.Unlock()
+
+	message := fmt.Sprintf("已 %.0f 分钟没有完成交易周期（阈值 %.0f 分钟），可能卡在网络请求中", silence.Minutes(), threshold.Minutes())
+	log.Printf("[%s] 🚨 交易循环卡死: %s", at.name, message)
+	if at.monitor != nil {
+		at.monitor.RaiseAlert(monitoring.Alert{
+			ID:      fmt.Sprintf("stall_%d", now.Unix()),
+			Type:    monitoring.AlertTypeSystem,
+			Level:   monitoring.AlertLevelCritical,
+			Title:   "交易循环卡死",
+			Message: message,
+		})
+	}
+	return true
+}
+
+// Config 创建trader时使用的配置（用于看门狗重建实例）
+func (at *AutoTrader) Config() AutoTraderConfig {
+	return at.config
+}
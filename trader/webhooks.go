@@ -0,0 +1,285 @@
+package trader
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"nofx/database"
+	"nofx/decision"
+	"nofx/logger"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 回调事件类型
+const (
+	WebhookPositionOpened     = "position_opened"     // 开仓成交
+	WebhookPositionClosed     = "position_closed"     // 平仓成交（AI/自动退出策略平仓，或交易所止损止盈单触发）
+	WebhookStopLossHit        = "stop_loss_hit"       // 止损平仓：交易所止损单触发或止损单缺失时强制平仓（同时发送position_closed）
+	WebhookValidationRejected = "validation_rejected" // 决策验证失败，本周期决策未执行
+	WebhookTraderPaused       = "trader_paused"       // trader进入暂停或风控停止
+)
+
+// 回调请求头
+const (
+	webhookHeaderEvent     = "X-Nofx-Event"
+	webhookHeaderDelivery  = "X-Nofx-Delivery"  // 事件ID（重试时不变，接收方可据此去重）
+	webhookHeaderTimestamp = "X-Nofx-Timestamp" // 签名时间（Unix秒）
+	webhookHeaderSignature = "X-Nofx-Signature" // sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+)
+
+// WebhookEvent 回调请求体
+type WebhookEvent struct {
+	ID         string      `json:"id"`
+	Event      string      `json:"event"`
+	TraderID   string      `json:"trader_id"`
+	TraderName string      `json:"trader_name"`
+	Exchange   string      `json:"exchange"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Data       interface{} `json:"data"`
+}
+
+// PositionEventData 开仓/平仓/止损触发事件数据
+type PositionEventData struct {
+	Symbol         string  `json:"symbol"`
+	Side           string  `json:"side"`    // long / short
+	Action         string  `json:"action"`  // open_long / open_short / close_long / close_short
+	Trigger        string  `json:"trigger"` // ai：AI或人工注入的决策，exit_policy：自动退出策略，exchange：交易所止损止盈单
+	OrderID        int64   `json:"order_id,omitempty"`
+	Quantity       float64 `json:"quantity,omitempty"` // 实际成交数量（交易所触发的平仓无法获取）
+	Price          float64 `json:"price,omitempty"`    // 成交均价（交易所触发的平仓为检测到时的市价）
+	Leverage       int     `json:"leverage,omitempty"`
+	StopLoss       float64 `json:"stop_loss,omitempty"`
+	TakeProfit     float64 `json:"take_profit,omitempty"`
+	ExitPolicy     string  `json:"exit_policy,omitempty"`
+	Reasoning      string  `json:"reasoning,omitempty"`
+	TradeOutcomeID int64   `json:"trade_outcome_id,omitempty"`
+}
+
+// ValidationEventData 决策验证失败事件数据
+type ValidationEventData struct {
+	Cycle  int    `json:"cycle"`
+	Source string `json:"source"` // ai / operator
+	Symbol string `json:"symbol,omitempty"`
+	Action string `json:"action,omitempty"`
+	Error  string `json:"error"`
+}
+
+// PauseEventData trader暂停事件数据
+type PauseEventData struct {
+	State  TraderState `json:"state"` // paused / risk_stopped
+	From   TraderState `json:"from"`
+	Reason string      `json:"reason,omitempty"`
+	Until  *time.Time  `json:"until,omitempty"`
+}
+
+// webhookHTTPClient 回调请求使用的客户端（超时按配置在每次请求时设置）
+var webhookHTTPClient = &http.Client{}
+
+// notifyWebhooks 异步发送事件到订阅的回调地址（未启用或没有订阅时不做任何事，不阻塞交易流程）
+func (at *AutoTrader) notifyWebhooks(event string, data interface{}) {
+	rc := database.GetGlobalConfig()
+	if rc == nil {
+		return
+	}
+	cfg := rc.GetWebhookConfig()
+	if !cfg.Enabled {
+		return
+	}
+	var endpoints []database.WebhookEndpoint
+	for _, ep := range cfg.Endpoints {
+		if ep.URL != "" && webhookSubscribed(ep, event, at.id) {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	payload := WebhookEvent{
+		ID:         newWebhookID(),
+		Event:      event,
+		TraderID:   at.id,
+		TraderName: at.name,
+		Exchange:   at.exchange,
+		Timestamp:  time.Now().UTC(),
+		Data:       data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("[%s] ⚠️  序列化回调事件失败 (%s): %v", at.name, event, err)
+		return
+	}
+	for _, ep := range endpoints {
+		secret := ep.Secret
+		if secret == "" {
+			secret = cfg.Secret
+		}
+		go deliverWebhook(ep.URL, secret, payload, body, cfg)
+	}
+}
+
+// webhookSubscribed 回调地址是否订阅了该trader的事件
+func webhookSubscribed(ep database.WebhookEndpoint, event, traderID string) bool {
+	if ep.TraderID != "" && ep.TraderID != traderID {
+		return false
+	}
+	if len(ep.Events) == 0 {
+		return true
+	}
+	for _, e := range ep.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverWebhook 发送回调，网络错误、429和5xx按指数退避重试（其他4xx视为接收方拒绝，不再重试）
+func deliverWebhook(url, secret string, payload WebhookEvent, body []byte, cfg database.WebhookConfig) {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	backoff := time.Duration(cfg.RetryBaseSeconds) * time.Second
+	if backoff <= 0 {
+		backoff = 2 * time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		retry, err := postWebhook(url, secret, payload, body, timeout)
+		if err == nil {
+			return
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	log.Printf("⚠️  回调发送失败 (%s %s → %s): %v", payload.Event, payload.ID, url, lastErr)
+}
+
+// postWebhook 发送一次回调请求，返回失败时是否值得重试
+func postWebhook(url, secret string, payload WebhookEvent, body []byte, timeout time.Duration) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookHeaderEvent, payload.Event)
+	req.Header.Set(webhookHeaderDelivery, payload.ID)
+	req.Header.Set(webhookHeaderTimestamp, timestamp)
+	if secret != "" {
+		req.Header.Set(webhookHeaderSignature, "sha256="+signWebhook(secret, timestamp, body))
+	}
+
+	client := *webhookHTTPClient
+	client.Timeout = timeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// signWebhook 回调签名：hex(HMAC-SHA256(secret, timestamp + "." + body))
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookID 生成事件ID
+func newWebhookID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// notifyPositionExecuted 开仓/平仓成交后发送回调（决策或自动退出策略执行，止损保护强制平仓同时发送stop_loss_hit）
+func (at *AutoTrader) notifyPositionExecuted(d *decision.Decision, action *logger.DecisionAction) {
+	event := WebhookPositionClosed
+	if strings.HasPrefix(d.Action, "open_") {
+		event = WebhookPositionOpened
+	}
+	trigger := "ai"
+	if action.ExitPolicy != "" {
+		trigger = "exit_policy"
+	}
+	data := PositionEventData{
+		Symbol:         d.Symbol,
+		Side:           positionSideOf(d.Action),
+		Action:         d.Action,
+		Trigger:        trigger,
+		OrderID:        action.OrderID,
+		Quantity:       action.ExecutedQty,
+		Price:          action.AvgPrice,
+		ExitPolicy:     action.ExitPolicy,
+		Reasoning:      d.Reasoning,
+		TradeOutcomeID: action.TradeOutcomeID,
+	}
+	if event == WebhookPositionOpened {
+		data.Leverage = d.Leverage
+		data.StopLoss = d.StopLoss
+		data.TakeProfit = d.TakeProfit
+	}
+	at.notifyWebhooks(event, data)
+	if event == WebhookPositionClosed && action.WasStopLoss {
+		at.notifyWebhooks(WebhookStopLossHit, data)
+	}
+}
+
+// notifyAutoClosed 检测到交易所止损止盈单触发平仓后发送回调
+func (at *AutoTrader) notifyAutoClosed(side string, action logger.DecisionAction) {
+	data := PositionEventData{
+		Symbol:         action.Symbol,
+		Side:           side,
+		Action:         action.Action,
+		Trigger:        "exchange",
+		Price:          action.Price,
+		TradeOutcomeID: action.TradeOutcomeID,
+	}
+	at.notifyWebhooks(WebhookPositionClosed, data)
+	if action.WasStopLoss {
+		at.notifyWebhooks(WebhookStopLossHit, data)
+	}
+}
+
+// isValidationRejection AI决策是否因未通过验证被拒绝
+func isValidationRejection(err error) bool {
+	return errors.Is(err, decision.ErrValidationFailed)
+}
+
+// positionSideOf 决策动作对应的持仓方向
+func positionSideOf(action string) string {
+	if strings.HasSuffix(action, "_short") {
+		return "short"
+	}
+	return "long"
+}